@@ -0,0 +1,129 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPICheckRun(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+
+		// a pull request which adds a file the check run annotates
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/contents/checked.txt?token=%s", token), &api.CreateFileOptions{
+			FileOptions: api.FileOptions{
+				BranchName:    "master",
+				NewBranchName: "checked",
+				Message:       "Add checked.txt",
+			},
+			Content: base64.StdEncoding.EncodeToString([]byte("first line\nsecond line\n")),
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+		pr, err := doAPICreatePullRequest(NewAPITestContext(t, "user2", "repo1"), "user2", "repo1", "master", "checked")(t)
+		assert.NoError(t, err)
+		sha := pr.Head.Sha
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/check-runs?token=%s", token), &api.CreateCheckRunOption{
+			Name:    "ci/lint",
+			HeadSHA: sha,
+			Status:  api.CheckRunStatusInProgress,
+		})
+		resp := session.MakeRequest(t, req, http.StatusCreated)
+		var run api.CheckRun
+		DecodeJSON(t, resp, &run)
+		assert.EqualValues(t, api.CheckRunStatusInProgress, run.Status)
+		assert.NotNil(t, run.Started)
+		assert.Nil(t, run.Completed)
+		models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, Context: "ci/lint", State: api.CommitStatusPending})
+
+		// a conclusion completes the run
+		conclusion := api.CheckRunConclusionFailure
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/user2/repo1/check-runs/%d?token=%s", run.ID, token), &api.EditCheckRunOption{
+			Conclusion: &conclusion,
+			Output: &api.CheckRunOutputOption{
+				Title:   "1 problem",
+				Summary: "Found **1** problem",
+				Annotations: []*api.CheckRunAnnotationOption{{
+					Path:      "checked.txt",
+					StartLine: 2,
+					Level:     api.CheckAnnotationLevelFailure,
+					Message:   "second line is wrong",
+				}},
+			},
+		})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &run)
+		assert.EqualValues(t, api.CheckRunStatusCompleted, run.Status)
+		assert.EqualValues(t, api.CheckRunConclusionFailure, run.Conclusion)
+		assert.NotNil(t, run.Completed)
+		assert.EqualValues(t, 1, run.Output.AnnotationsCount)
+		models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, Context: "ci/lint", State: api.CommitStatusFailure})
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/check-runs/%d/annotations?token=%s", run.ID, token))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var annotations []*api.CheckRunAnnotation
+		DecodeJSON(t, resp, &annotations)
+		if assert.Len(t, annotations, 1) {
+			assert.EqualValues(t, 2, annotations[0].EndLine)
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/commits/checked/check-runs?token=%s", token))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var runs []*api.CheckRun
+		DecodeJSON(t, resp, &runs)
+		if assert.Len(t, runs, 1) {
+			assert.EqualValues(t, run.ID, runs[0].ID)
+		}
+
+		// invalid conclusions are rejected
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/check-runs?token=%s", token), &api.CreateCheckRunOption{
+			Name:       "ci/lint",
+			HeadSHA:    sha,
+			Conclusion: "unknown",
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/user2/repo1/checks/%d", run.ID))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "<strong>1</strong>")
+
+		// the annotation shows up next to the annotated line in the diff
+		req = NewRequest(t, "GET", fmt.Sprintf("/user2/repo1/pulls/%d/files", pr.Index))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Contains(t, htmlDoc.doc.Find(".check-run-annotations").Text(), "second line is wrong")
+	})
+}
+
+func TestRequiredStatusCheckPattern(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user2")
+
+		csrf := GetCSRF(t, session, "/user2/repo1/settings/branches")
+		req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/branches/master", map[string]string{
+			"_csrf":                 csrf,
+			"protected":             "on",
+			"enable_status_check":   "on",
+			"status_check_contexts": "ci/*",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		protectedBranch := models.AssertExistsAndLoadBean(t, &models.ProtectedBranch{RepoID: 1, BranchName: "master"}).(*models.ProtectedBranch)
+		assert.EqualValues(t, []string{"ci/*"}, protectedBranch.StatusCheckContexts)
+		assert.True(t, protectedBranch.IsStatusCheckContextRequired("ci/build"))
+		assert.False(t, protectedBranch.IsStatusCheckContextRequired("deploy"))
+	})
+}
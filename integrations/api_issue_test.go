@@ -202,6 +202,19 @@ func TestAPISearchIssues(t *testing.T) {
 	resp = session.MakeRequest(t, req, http.StatusOK)
 	DecodeJSON(t, resp, &apiIssues)
 	assert.Len(t, apiIssues, 1)
+
+	query = url.Values{"q": {"first"}, "state": {"all"}}
+	link.RawQuery = query.Encode()
+	req = NewRequest(t, "GET", link.String())
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiIssues)
+	if assert.Len(t, apiIssues, 1) {
+		assert.EqualValues(t, 1, apiIssues[0].ID)
+		if assert.Len(t, apiIssues[0].SearchHighlights, 1) {
+			assert.Equal(t, "content", apiIssues[0].SearchHighlights[0].Field)
+			assert.Contains(t, apiIssues[0].SearchHighlights[0].Fragment, "<mark>first</mark>")
+		}
+	}
 }
 
 func TestAPISearchIssuesWithLabels(t *testing.T) {
@@ -46,6 +46,11 @@ func testAPICreateOAuth2Application(t *testing.T) {
 	assert.NotEmpty(t, createdApp.Created)
 	assert.EqualValues(t, appBody.RedirectURIs[0], createdApp.RedirectURIs[0])
 	models.AssertExistsAndLoadBean(t, &models.OAuth2Application{UID: user.ID, Name: createdApp.Name})
+
+	// access tokens could escape their scopes with the client secret of an application
+	token := getTokenForLoggedInUser(t, loginUser(t, user.Name))
+	req = NewRequestWithJSON(t, "POST", "/api/v1/user/applications/oauth2?token="+token, &appBody)
+	MakeRequest(t, req, http.StatusUnauthorized)
 }
 
 func testAPIListOAuth2Applications(t *testing.T) {
@@ -160,4 +165,9 @@ func testAPIUpdateOAuth2Application(t *testing.T) {
 	assert.EqualValues(t, expectedApp.RedirectURIs[0], appBody.RedirectURIs[0])
 	assert.EqualValues(t, expectedApp.RedirectURIs[1], appBody.RedirectURIs[1])
 	models.AssertExistsAndLoadBean(t, &models.OAuth2Application{ID: expectedApp.ID, Name: expectedApp.Name})
+
+	// updating regenerates the client secret
+	token := getTokenForLoggedInUser(t, loginUser(t, user.Name))
+	req = NewRequestWithJSON(t, "PATCH", urlStr+"?token="+token, &appBody)
+	MakeRequest(t, req, http.StatusUnauthorized)
 }
@@ -0,0 +1,185 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageContainer(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	digestOf := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	layer := []byte("layer content")
+	configDigest, layerDigest := digestOf(config), digestOf(layer)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"%s","size":%d}]}`,
+		container_module.MediaTypeDockerManifest, configDigest, len(config), layerDigest, len(layer)))
+	manifestDigest := digestOf(manifest)
+
+	image := "test-image"
+	root := fmt.Sprintf("/v2/%s/%s", user.Name, image)
+
+	t.Run("Authenticate", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/v2"), http.StatusUnauthorized)
+		assert.Equal(t, `Basic realm="Gitea Package Registry"`, resp.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, "registry/2.0", resp.Header().Get("Docker-Distribution-Api-Version"))
+
+		MakeRequest(t, AddBasicAuthHeader(NewRequest(t, "GET", "/v2"), user.Name), http.StatusOK)
+	})
+
+	t.Run("UploadBlob", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		// Monolithic upload
+		req := NewRequestWithBody(t, "POST", root+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "POST", root+"/blobs/uploads?digest="+layerDigest, bytes.NewReader(config))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "POST", root+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req = AddBasicAuthHeader(req, user.Name)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		assert.Equal(t, configDigest, resp.Header().Get("Docker-Content-Digest"))
+		assert.Equal(t, root+"/blobs/"+configDigest, resp.Header().Get("Location"))
+
+		// Chunked upload
+		req = NewRequest(t, "POST", root+"/blobs/uploads")
+		req = AddBasicAuthHeader(req, user.Name)
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		location := resp.Header().Get("Location")
+		assert.NotEmpty(t, resp.Header().Get("Docker-Upload-UUID"))
+
+		req = NewRequestWithBody(t, "PATCH", location, bytes.NewReader(layer[:5]))
+		req = AddBasicAuthHeader(req, user.Name)
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		assert.Equal(t, "0-4", resp.Header().Get("Range"))
+
+		req = NewRequest(t, "GET", location)
+		req = AddBasicAuthHeader(req, user.Name)
+		resp = MakeRequest(t, req, http.StatusNoContent)
+		assert.Equal(t, "0-4", resp.Header().Get("Range"))
+
+		req = NewRequestWithBody(t, "PUT", location+"?digest="+layerDigest, bytes.NewReader(layer[5:]))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", location)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("HeadBlob", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "HEAD", root+"/blobs/"+layerDigest)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, fmt.Sprintf("%d", len(layer)), resp.Header().Get("Content-Length"))
+
+		req = NewRequest(t, "HEAD", root+"/blobs/"+digestOf([]byte("unknown")))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("GetBlob", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/blobs/"+layerDigest)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, layer, resp.Body.Bytes())
+	})
+
+	t.Run("UploadManifest", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		unknown := bytes.Replace(manifest, []byte(layerDigest), []byte(digestOf([]byte("unknown"))), 1)
+		req := NewRequestWithBody(t, "PUT", root+"/manifests/latest", bytes.NewReader(unknown))
+		req = AddBasicAuthHeader(req, user.Name)
+		resp := MakeRequest(t, req, http.StatusNotFound)
+		assert.Contains(t, resp.Body.String(), "MANIFEST_BLOB_UNKNOWN")
+
+		for _, tag := range []string{"latest", "v1"} {
+			req = NewRequestWithBody(t, "PUT", root+"/manifests/"+tag, bytes.NewReader(manifest))
+			req = AddBasicAuthHeader(req, user.Name)
+			resp = MakeRequest(t, req, http.StatusCreated)
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		}
+	})
+
+	t.Run("GetManifest", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		for _, reference := range []string{"latest", manifestDigest} {
+			req := NewRequest(t, "HEAD", root+"/manifests/"+reference)
+			resp := MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+
+			req = NewRequest(t, "GET", root+"/manifests/"+reference)
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, container_module.MediaTypeDockerManifest, resp.Header().Get("Content-Type"))
+			assert.Equal(t, manifest, resp.Body.Bytes())
+		}
+
+		req := NewRequest(t, "GET", root+"/manifests/unknown")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("GetTagsList", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/tags/list")
+		resp := MakeRequest(t, req, http.StatusOK)
+		var tags struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		DecodeJSON(t, resp, &tags)
+		assert.Equal(t, strings.ToLower(user.Name)+"/"+image, tags.Name)
+		assert.Equal(t, []string{"latest", "v1"}, tags.Tags)
+
+		req = NewRequest(t, "GET", root+"/tags/list?n=1")
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &tags)
+		assert.Equal(t, []string{"latest"}, tags.Tags)
+		assert.Contains(t, resp.Header().Get("Link"), "last=latest")
+	})
+
+	t.Run("DeleteManifest", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", root+"/manifests/v1")
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		MakeRequest(t, NewRequest(t, "GET", root+"/manifests/v1"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", root+"/manifests/latest"), http.StatusOK)
+
+		req = NewRequest(t, "DELETE", root+"/manifests/"+manifestDigest)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		MakeRequest(t, NewRequest(t, "GET", root+"/manifests/latest"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", root+"/manifests/"+manifestDigest), http.StatusNotFound)
+	})
+}
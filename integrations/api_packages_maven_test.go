@@ -0,0 +1,88 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageMaven(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	groupID := "com.gitea"
+	artifactID := "test-project"
+	packageVersion := "1.0.1"
+
+	root := fmt.Sprintf("/api/packages/%s/maven/%s/%s", user.Name, strings.ReplaceAll(groupID, ".", "/"), artifactID)
+	filename := fmt.Sprintf("%s-%s.jar", artifactID, packageVersion)
+
+	putFile := func(t *testing.T, path, content string, expectedStatus int) {
+		req := NewRequestWithBody(t, "PUT", root+path, strings.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root+"/"+packageVersion+"/"+filename, strings.NewReader("test"))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		putFile(t, "/"+packageVersion+"/"+filename, "test", http.StatusCreated)
+		putFile(t, "/"+packageVersion+"/"+filename, "test", http.StatusConflict)
+		putFile(t, "/"+packageVersion+"/"+filename+".sha1", "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", http.StatusOK)
+		putFile(t, "/"+packageVersion+"/"+filename+".md5", "00000000000000000000000000000000", http.StatusBadRequest)
+		putFile(t, "/maven-metadata.xml", "ignored", http.StatusOK)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageMaven, groupID+":"+artifactID)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/"+packageVersion+"/"+filename)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "test", resp.Body.String())
+
+		req = NewRequest(t, "GET", root+"/"+packageVersion+"/"+filename+".md5")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "098f6bcd4621d373cade4e832627b4f6", resp.Body.String())
+
+		req = NewRequest(t, "GET", root+"/"+packageVersion+"/unknown.jar")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		putFile(t, "/1.1.0/"+artifactID+"-1.1.0.jar", "test 2", http.StatusCreated)
+
+		req := NewRequest(t, "GET", root+"/maven-metadata.xml")
+		resp := MakeRequest(t, req, http.StatusOK)
+		body := resp.Body.String()
+		assert.Contains(t, body, "<groupId>"+groupID+"</groupId>")
+		assert.Contains(t, body, "<artifactId>"+artifactID+"</artifactId>")
+		assert.Contains(t, body, "<latest>1.1.0</latest>")
+		assert.Contains(t, body, "<version>"+packageVersion+"</version>")
+
+		req = NewRequest(t, "GET", root+"/maven-metadata.xml.sha1")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Len(t, resp.Body.String(), 40)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageNpm(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	token := getTokenForLoggedInUser(t, loginUser(t, user.Name))
+
+	packageName := "@scope/test-package"
+	packageVersion := "1.0.1"
+	filename := "test-package-1.0.1.tgz"
+	data := "H4sIAAAAAAAA/ytITM5OTE/VL4DQelnF+XkpDHoGBgZmJiYK2MRNzQ0NjEwMjQ2NDEwUFJRQ/AM="
+	content, _ := base64.StdEncoding.DecodeString(data)
+
+	upload := fmt.Sprintf(`{
+		"_id": "%[1]s",
+		"name": "%[1]s",
+		"description": "Test Description",
+		"versions": {
+			"%[2]s": {
+				"name": "%[1]s",
+				"version": "%[2]s",
+				"description": "Test Description",
+				"dist": {
+					"shasum": "74e52fe5aa24e09df89f62909e4df89609b2c2f3"
+				}
+			}
+		},
+		"_attachments": {
+			"%[3]s": {
+				"data": "%[4]s",
+				"length": %[5]d
+			}
+		}
+	}`, packageName, packageVersion, filename, data, len(content))
+
+	root := fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, url.PathEscape(packageName))
+
+	t.Run("Upload", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root, strings.NewReader(upload))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"?token="+token, strings.NewReader(strings.Replace(upload, "74e52fe5", "00000000", 1)))
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"?token="+token, strings.NewReader(upload))
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestWithBody(t, "PUT", root+"?token="+token, strings.NewReader(upload))
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("PackageMetadata", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var doc struct {
+			Name     string            `json:"name"`
+			DistTags map[string]string `json:"dist-tags"`
+			Versions map[string]struct {
+				Description string `json:"description"`
+				Dist        struct {
+					Shasum  string `json:"shasum"`
+					Tarball string `json:"tarball"`
+				} `json:"dist"`
+			} `json:"versions"`
+		}
+		DecodeJSON(t, resp, &doc)
+
+		assert.Equal(t, packageName, doc.Name)
+		assert.Equal(t, packageVersion, doc.DistTags["latest"])
+		assert.Len(t, doc.Versions, 1)
+		v := doc.Versions[packageVersion]
+		assert.Equal(t, "Test Description", v.Description)
+		assert.Equal(t, "74e52fe5aa24e09df89f62909e4df89609b2c2f3", v.Dist.Shasum)
+		assert.True(t, strings.HasSuffix(v.Dist.Tarball, root+"/-/"+packageVersion+"/"+filename))
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/-/%s/%s", root, packageVersion, filename))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/-/%s/%s", root, "0.0.1", filename))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageGeneric(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	packageName := "te-st_pac.kage"
+	packageVersion := "1.0.3"
+	filename := "fi-le_na.me"
+	content := []byte{1, 2, 3}
+
+	url := fmt.Sprintf("/api/packages/%s/generic/%s/%s/%s", user.Name, packageName, packageVersion, filename)
+
+	t.Run("Upload", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, "user4")
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageGeneric, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+		assert.EqualValues(t, len(content), pfs[0].Blob.Size)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/%s/%s/%s", user.Name, packageName, packageVersion, "in%20valid"), bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", url)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		p, err := models.GetPackageByName(user.ID, models.PackageGeneric, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, pv.DownloadCount)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/generic/%s/%s/%s", user.Name, packageName, packageVersion, "unknown"))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("API", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s?type=generic", user.Name))
+		resp := MakeRequest(t, req, http.StatusOK)
+		var apiPackages []*api.Package
+		DecodeJSON(t, resp, &apiPackages)
+		assert.Len(t, apiPackages, 1)
+		assert.Equal(t, packageName, apiPackages[0].Name)
+		assert.Equal(t, packageVersion, apiPackages[0].Version)
+		assert.Equal(t, "generic", apiPackages[0].Type)
+		assert.Equal(t, user.Name, apiPackages[0].Creator.UserName)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s/generic/%s/%s/files", user.Name, packageName, packageVersion))
+		resp = MakeRequest(t, req, http.StatusOK)
+		var apiFiles []*api.PackageFile
+		DecodeJSON(t, resp, &apiFiles)
+		assert.Len(t, apiFiles, 1)
+		assert.Equal(t, "039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81", apiFiles[0].HashSHA256)
+
+		// Packages linked to a private repository are hidden from users without access to it
+		req = NewRequest(t, "PUT", fmt.Sprintf("/api/v1/packages/%s/generic/%s/-/link/repo2", user.Name, packageName))
+		req = AddBasicAuthHeader(req, "user4")
+		MakeRequest(t, req, http.StatusForbidden)
+		req = NewRequest(t, "PUT", fmt.Sprintf("/api/v1/packages/%s/generic/%s/-/link/repo2", user.Name, packageName))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/repo2/packages", user.Name))
+		req = AddBasicAuthHeader(req, user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &apiPackages)
+		assert.Len(t, apiPackages, 1)
+		assert.Equal(t, "repo2", apiPackages[0].Repository.Name)
+
+		MakeRequest(t, NewRequest(t, "GET", url), http.StatusUnauthorized)
+		MakeRequest(t, AddBasicAuthHeader(NewRequest(t, "GET", url), "user4"), http.StatusForbidden)
+		MakeRequest(t, NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s/generic/%s/%s", user.Name, packageName, packageVersion)), http.StatusNotFound)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/packages/%s/generic/%s/-/link", user.Name, packageName))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+		MakeRequest(t, NewRequest(t, "GET", url), http.StatusOK)
+	})
+
+	t.Run("TokenScope", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		token := createScopedToken(t, "no-package", []string{"repo"}, nil)
+		req := NewRequestWithBody(t, "PUT", url+"2?token="+token, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusForbidden)
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s?token=%s", user.Name, token))
+		MakeRequest(t, req, http.StatusForbidden)
+
+		token = createScopedToken(t, "package", []string{"package"}, nil)
+		req = NewRequestWithBody(t, "PUT", url+"2?token="+token, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusCreated)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", url+"2")
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/packages/%s/generic/%s/%s", user.Name, packageName, packageVersion))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/packages/%s/generic/%s/%s", user.Name, packageName, packageVersion))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		_, err := models.GetPackageByName(user.ID, models.PackageGeneric, packageName)
+		assert.Equal(t, models.ErrPackageNotExist, err)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoProject(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/projects")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var apiProjects []*api.Project
+	DecodeJSON(t, resp, &apiProjects)
+	if assert.Len(t, apiProjects, 1) {
+		assert.EqualValues(t, 1, apiProjects[0].ID)
+		assert.Equal(t, "repository", apiProjects[0].Type)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/projects/1/boards")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiBoards []*api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoards)
+	assert.Len(t, apiBoards, 3)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/projects/1/boards?token="+token, &api.CreateProjectBoardOption{
+		Title:   "Review",
+		Sorting: 4,
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var apiBoard api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoard)
+	assert.Equal(t, "Review", apiBoard.Title)
+	assert.EqualValues(t, 4, apiBoard.Sorting)
+
+	// cards are ordered by their sorting on the board
+	for _, opts := range []api.MoveProjectIssueOption{
+		{IssueID: 1, BoardID: 1, Sorting: 2},
+		{IssueID: 2, BoardID: 1, Sorting: 1},
+	} {
+		req = NewRequestWithJSON(t, "POST", "/api/v1/projects/1/issues?token="+token, &opts)
+		session.MakeRequest(t, req, http.StatusNoContent)
+	}
+	req = NewRequest(t, "GET", "/api/v1/projects/boards/1/issues")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiIssues []*api.Issue
+	DecodeJSON(t, resp, &apiIssues)
+	if assert.Len(t, apiIssues, 2) {
+		assert.EqualValues(t, 2, apiIssues[0].ID)
+		assert.EqualValues(t, 1, apiIssues[1].ID)
+	}
+
+	// issues of other repositories are rejected
+	req = NewRequestWithJSON(t, "POST", "/api/v1/projects/1/issues?token="+token, &api.MoveProjectIssueOption{IssueID: 4})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/projects/1?token="+token, &api.EditProjectOption{
+		State: &[]string{"closed"}[0],
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var apiProject api.Project
+	DecodeJSON(t, resp, &apiProject)
+	assert.Equal(t, api.StateClosed, apiProject.State)
+	assert.NotNil(t, apiProject.Closed)
+
+	// readers cannot change the project
+	token5 := getTokenForLoggedInUser(t, loginUser(t, "user5"))
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/projects/boards/1?token="+token5, &api.EditProjectBoardOption{
+		Title: &[]string{"Todo"}[0],
+	})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/projects/boards/1?token="+token, &api.EditProjectBoardOption{
+		Title:   &[]string{"Todo"}[0],
+		Default: &[]bool{true}[0],
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiBoard)
+	assert.Equal(t, "Todo", apiBoard.Title)
+	assert.True(t, apiBoard.Default)
+
+	req = NewRequest(t, "DELETE", "/api/v1/projects/1/issues/1?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	assert.EqualValues(t, 0, models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue).ProjectID())
+
+	req = NewRequest(t, "DELETE", "/api/v1/projects/boards/2?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.ProjectBoard{ID: 2})
+
+	req = NewRequest(t, "DELETE", "/api/v1/projects/1?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Project{ID: 1})
+}
+
+func TestAPIOrgProject(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects?token="+token, &api.CreateProjectOption{
+		Title:     "Organization project",
+		BoardType: "basic_kanban",
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var apiProject api.Project
+	DecodeJSON(t, resp, &apiProject)
+	assert.Equal(t, "organization", apiProject.Type)
+	assert.EqualValues(t, 3, apiProject.OwnerID)
+
+	// only members can create projects of an organization
+	token5 := getTokenForLoggedInUser(t, loginUser(t, "user5"))
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects?token="+token5, &api.CreateProjectOption{Title: "Other"})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequest(t, "GET", "/api/v1/orgs/user3/projects")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiProjects []*api.Project
+	DecodeJSON(t, resp, &apiProjects)
+	assert.Len(t, apiProjects, 1)
+
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/projects/%d/boards", apiProject.ID))
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiBoards []*api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoards)
+	if !assert.Len(t, apiBoards, 3) {
+		return
+	}
+
+	// boards of another project are rejected
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/projects/%d/issues?token=%s", apiProject.ID, token), &api.MoveProjectIssueOption{
+		IssueID: 1,
+		BoardID: 1,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// issues of a public and of a private repository
+	for _, issueID := range []int64{1, 4} {
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/projects/%d/issues?token=%s", apiProject.ID, token), &api.MoveProjectIssueOption{
+			IssueID: issueID,
+			BoardID: apiBoards[0].ID,
+		})
+		session.MakeRequest(t, req, http.StatusNoContent)
+	}
+
+	issuesURL := fmt.Sprintf("/api/v1/projects/boards/%d/issues", apiBoards[0].ID)
+	req = NewRequest(t, "GET", issuesURL+"?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var apiIssues []*api.Issue
+	DecodeJSON(t, resp, &apiIssues)
+	assert.Len(t, apiIssues, 2)
+
+	// issues are only listed to the users who can read them
+	req = NewRequest(t, "GET", issuesURL)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiIssues)
+	if assert.Len(t, apiIssues, 1) {
+		assert.EqualValues(t, 1, apiIssues[0].ID)
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/scim"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createSCIMToken(t *testing.T, username, name string, scopes []string) string {
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/"+username+"/tokens", &api.CreateAccessTokenOption{
+		Name:   name,
+		Scopes: scopes,
+	})
+	req = AddBasicAuthHeader(req, username)
+	resp := MakeRequest(t, req, http.StatusCreated)
+
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	return token.Token
+}
+
+func newSCIMRequest(t *testing.T, method, urlStr, token, body string) *http.Request {
+	req := NewRequestWithBody(t, method, urlStr, strings.NewReader(body))
+	req.Header.Set("Content-Type", scim.ContentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAPISCIMAuthentication(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/scim/v2/Users")
+	resp := MakeRequest(t, req, http.StatusUnauthorized)
+	assert.Equal(t, scim.ContentType+"; charset=utf-8", resp.Header().Get("Content-Type"))
+	var scimErr scim.Error
+	DecodeJSON(t, resp, &scimErr)
+	assert.Equal(t, []string{scim.SchemaError}, scimErr.Schemas)
+	assert.Equal(t, "401", scimErr.Status)
+
+	// signing in with a password is not enough
+	req = AddBasicAuthHeader(NewRequest(t, "GET", "/scim/v2/Users"), "user1")
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// tokens of users who are not site administrators are rejected
+	req = newSCIMRequest(t, "GET", "/scim/v2/Users", createSCIMToken(t, "user2", "scim", nil), "")
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// tokens need the admin scope
+	req = newSCIMRequest(t, "GET", "/scim/v2/Users", createSCIMToken(t, "user1", "repo", []string{"repo"}), "")
+	MakeRequest(t, req, http.StatusForbidden)
+
+	token := createSCIMToken(t, "user1", "scim", []string{"admin"})
+	req = newSCIMRequest(t, "GET", "/scim/v2/ServiceProviderConfig", token, "")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var config scim.ServiceProviderConfig
+	DecodeJSON(t, resp, &config)
+	assert.True(t, config.Patch.Supported)
+	assert.False(t, config.Bulk.Supported)
+
+	req = newSCIMRequest(t, "GET", "/scim/v2/ResourceTypes", token, "")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var resourceTypes scim.ListResponse
+	DecodeJSON(t, resp, &resourceTypes)
+	assert.EqualValues(t, 2, resourceTypes.TotalResults)
+}
+
+func TestAPISCIMUsers(t *testing.T) {
+	defer prepareTestEnv(t)()
+	token := createSCIMToken(t, "user1", "scim", []string{"admin"})
+
+	req := newSCIMRequest(t, "POST", "/scim/v2/Users", token, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"externalId": "fry-id",
+		"userName": "fry",
+		"name": {"givenName": "Philip", "familyName": "Fry"},
+		"emails": [{"value": "fry@example.com"}, {"value": "fry@planetexpress.com", "type": "work", "primary": true}],
+		"active": true
+	}`)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var su scim.User
+	DecodeJSON(t, resp, &su)
+	assert.Equal(t, "fry", su.UserName)
+	assert.Equal(t, "Philip Fry", su.DisplayName)
+	assert.Equal(t, "fry@planetexpress.com", su.PrimaryEmail())
+	assert.True(t, su.IsActive())
+	assert.Equal(t, resp.Header().Get("Location"), su.Meta.Location)
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{Name: "fry"}).(*models.User)
+	assert.Equal(t, su.ID, strconv.FormatInt(user.ID, 10))
+	assert.Equal(t, "Philip Fry", user.FullName)
+	assert.Equal(t, "fry@planetexpress.com", user.Email)
+	assert.False(t, user.ProhibitLogin)
+	assert.False(t, user.MustChangePassword)
+
+	// user names and emails are unique
+	req = newSCIMRequest(t, "POST", "/scim/v2/Users", token, `{"userName": "FRY", "emails": [{"value": "fry2@planetexpress.com"}]}`)
+	resp = MakeRequest(t, req, http.StatusConflict)
+	var scimErr scim.Error
+	DecodeJSON(t, resp, &scimErr)
+	assert.Equal(t, scim.ErrorTypeUniqueness, scimErr.ScimType)
+	req = newSCIMRequest(t, "POST", "/scim/v2/Users", token, `{"userName": "fry2", "emails": [{"value": "fry@planetexpress.com"}]}`)
+	MakeRequest(t, req, http.StatusConflict)
+	req = newSCIMRequest(t, "POST", "/scim/v2/Users", token, `{"emails": [{"value": "fry2@planetexpress.com"}]}`)
+	MakeRequest(t, req, http.StatusBadRequest)
+	req = newSCIMRequest(t, "POST", "/scim/v2/Users", token, `{"userName": `)
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	t.Run("List", func(t *testing.T) {
+		for filter, expected := range map[string]int64{
+			`userName eq "FRY"`:                                            1,
+			`emails.value eq "fry@planetexpress.com"`:                      1,
+			`id eq "` + su.ID + `"`:                                        1,
+			`userName eq "zoidberg"`:                                       0,
+			`userName eq "user3"`:                                          0, // organizations are no users
+			`externalId eq "fry-id"`:                                       0,
+			`emails[type eq "work"].value eq "fry@example"`:                -1,
+			`displayName eq "Philip Fry"`:                                  -1,
+			`userName eq "fry" or userName eq "zoidberg"`:                  -1,
+			`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "fry"`: 1,
+		} {
+			req := newSCIMRequest(t, "GET", "/scim/v2/Users?filter="+url.QueryEscape(filter), token, "")
+			if expected < 0 {
+				MakeRequest(t, req, http.StatusBadRequest)
+				continue
+			}
+			resp := MakeRequest(t, req, http.StatusOK)
+			var list scim.ListResponse
+			DecodeJSON(t, resp, &list)
+			assert.Equal(t, expected, list.TotalResults, filter)
+			assert.Len(t, list.Resources, int(expected), filter)
+		}
+
+		req := newSCIMRequest(t, "GET", "/scim/v2/Users?count=50", token, "")
+		resp := MakeRequest(t, req, http.StatusOK)
+		var all scim.ListResponse
+		DecodeJSON(t, resp, &all)
+		assert.EqualValues(t, models.GetCount(t, &models.User{}, models.Cond("type = ?", models.UserTypeIndividual)), all.TotalResults)
+		assert.Len(t, all.Resources, int(all.TotalResults))
+
+		// the start index does not need to be aligned with the count
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users?startIndex=4&count=3", token, "")
+		resp = MakeRequest(t, req, http.StatusOK)
+		var page scim.ListResponse
+		DecodeJSON(t, resp, &page)
+		assert.Equal(t, all.TotalResults, page.TotalResults)
+		assert.Equal(t, 4, page.StartIndex)
+		assert.Equal(t, 3, page.ItemsPerPage)
+		assert.Equal(t, all.Resources[3:6], page.Resources)
+
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users?count=0", token, "")
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &page)
+		assert.Equal(t, all.TotalResults, page.TotalResults)
+		assert.Empty(t, page.Resources)
+	})
+
+	userURL := "/scim/v2/Users/" + su.ID
+
+	t.Run("Patch", func(t *testing.T) {
+		// some identity providers send booleans as strings
+		req := newSCIMRequest(t, "PATCH", userURL, token, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "Replace", "path": "active", "value": "False"}]
+		}`)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var patched scim.User
+		DecodeJSON(t, resp, &patched)
+		assert.False(t, patched.IsActive())
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: user.ID}).(*models.User)
+		assert.True(t, user.ProhibitLogin)
+
+		req = newSCIMRequest(t, "PATCH", userURL, token, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "replace", "value": {"displayName": "Philip J. Fry", "emails[type eq \"work\"].value": "philip@planetexpress.com", "name.givenName": "Philip"}},
+				{"op": "replace", "path": "active", "value": true}
+			]
+		}`)
+		MakeRequest(t, req, http.StatusOK)
+		user = models.AssertExistsAndLoadBean(t, &models.User{ID: user.ID}).(*models.User)
+		assert.Equal(t, "Philip J. Fry", user.FullName)
+		assert.Equal(t, "philip@planetexpress.com", user.Email)
+		assert.False(t, user.ProhibitLogin)
+
+		for _, operations := range []string{
+			`[{"op": "move", "path": "active"}]`,
+			`[{"op": "remove", "path": "userName"}]`,
+			`[{"op": "replace", "path": "active", "value": "maybe"}]`,
+			`[{"op": "replace", "path": "emails[type eq work].value", "value": "fry@planetexpress.com"}]`,
+			`[{"op": "replace", "value": "fry"}]`,
+		} {
+			req = newSCIMRequest(t, "PATCH", userURL, token, `{"Operations": `+operations+`}`)
+			MakeRequest(t, req, http.StatusBadRequest)
+		}
+
+		// the email address of another user
+		req = newSCIMRequest(t, "PATCH", userURL, token, `{"Operations": [{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "user2@example.com"}]}`)
+		MakeRequest(t, req, http.StatusConflict)
+
+		// the caller cannot deactivate themselves
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/1", token, `{"Operations": [{"op": "replace", "path": "active", "value": false}]}`)
+		MakeRequest(t, req, http.StatusBadRequest)
+		assert.False(t, models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User).ProhibitLogin)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		req := newSCIMRequest(t, "PUT", userURL, token, `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": "philip",
+			"name": {"formatted": "Philip J. Fry II"},
+			"emails": [{"value": "fry@planetexpress.com", "primary": true}],
+			"active": false
+		}`)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var replaced scim.User
+		DecodeJSON(t, resp, &replaced)
+		assert.Equal(t, "philip", replaced.UserName)
+
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: user.ID}).(*models.User)
+		assert.Equal(t, "philip", user.Name)
+		assert.Equal(t, "Philip J. Fry II", user.FullName)
+		assert.Equal(t, "fry@planetexpress.com", user.Email)
+		assert.True(t, user.ProhibitLogin)
+		models.AssertExistsAndLoadBean(t, &models.UserRedirect{LowerName: "fry", RedirectUserID: user.ID})
+
+		req = newSCIMRequest(t, "PUT", userURL, token, `{"userName": "user2"}`)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req := newSCIMRequest(t, "DELETE", userURL, token, "")
+		MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.User{ID: user.ID})
+
+		req = newSCIMRequest(t, "GET", userURL, token, "")
+		MakeRequest(t, req, http.StatusNotFound)
+		req = newSCIMRequest(t, "DELETE", userURL, token, "")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// organizations are no users
+		req = newSCIMRequest(t, "DELETE", "/scim/v2/Users/3", token, "")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// users owning repositories cannot be deleted
+		req = newSCIMRequest(t, "DELETE", "/scim/v2/Users/2", token, "")
+		MakeRequest(t, req, http.StatusConflict)
+
+		// the caller cannot delete themselves
+		req = newSCIMRequest(t, "DELETE", "/scim/v2/Users/1", token, "")
+		MakeRequest(t, req, http.StatusBadRequest)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: 1})
+	})
+}
+
+func TestAPISCIMGroups(t *testing.T) {
+	defer prepareTestEnv(t)()
+	token := createSCIMToken(t, "user1", "scim", []string{"admin"})
+
+	req := newSCIMRequest(t, "POST", "/scim/v2/Groups", token, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "user3/scim_team",
+		"members": [{"value": "2"}]
+	}`)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var sg scim.Group
+	DecodeJSON(t, resp, &sg)
+	assert.Equal(t, "user3/scim_team", sg.DisplayName)
+	assert.Equal(t, []scim.Reference{{Value: "2", Display: "user2", Ref: sg.Members[0].Ref}}, sg.Members)
+
+	team := models.AssertExistsAndLoadBean(t, &models.Team{OrgID: 3, Name: "scim_team"}).(*models.Team)
+	assert.Equal(t, sg.ID, strconv.FormatInt(team.ID, 10))
+	assert.Equal(t, models.AccessModeRead, team.Authorize)
+	assert.True(t, team.IsMember(2))
+
+	for body, status := range map[string]int{
+		`{"displayName": "user3/scim_team"}`:                            http.StatusConflict,
+		`{"displayName": "scim_team"}`:                                  http.StatusBadRequest,
+		`{"displayName": "unknown/scim_team"}`:                          http.StatusBadRequest,
+		`{"displayName": "user3/SCIM Team"}`:                            http.StatusBadRequest,
+		`{"displayName": "user3/other", "members": [{"value": "999"}]}`: http.StatusBadRequest,
+	} {
+		req = newSCIMRequest(t, "POST", "/scim/v2/Groups", token, body)
+		MakeRequest(t, req, status)
+	}
+	models.AssertNotExistsBean(t, &models.Team{OrgID: 3, Name: "other"})
+
+	t.Run("List", func(t *testing.T) {
+		req := newSCIMRequest(t, "GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "user3/scim_team"`), token, "")
+		resp := MakeRequest(t, req, http.StatusOK)
+		var list scim.ListResponse
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, 1, list.TotalResults)
+
+		req = newSCIMRequest(t, "GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "scim_team"`), token, "")
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, 0, list.TotalResults)
+
+		req = newSCIMRequest(t, "GET", "/scim/v2/Groups?count=100&excludedAttributes=members", token, "")
+		resp = MakeRequest(t, req, http.StatusOK)
+		var groups struct {
+			TotalResults int64
+			Resources    []scim.Group
+		}
+		DecodeJSON(t, resp, &groups)
+		assert.EqualValues(t, models.GetCount(t, &models.Team{}), groups.TotalResults)
+		assert.Len(t, groups.Resources, int(groups.TotalResults))
+		for _, group := range groups.Resources {
+			assert.Empty(t, group.Members)
+		}
+
+		// users list the groups they are member of
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users/2", token, "")
+		resp = MakeRequest(t, req, http.StatusOK)
+		var su scim.User
+		DecodeJSON(t, resp, &su)
+		assert.Contains(t, su.Groups, scim.Reference{Value: sg.ID, Display: "user3/scim_team", Ref: sg.Meta.Location})
+	})
+
+	groupURL := "/scim/v2/Groups/" + sg.ID
+
+	t.Run("Patch", func(t *testing.T) {
+		req := newSCIMRequest(t, "PATCH", groupURL, token, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "Add", "path": "members", "value": [{"value": "4"}, {"value": "5"}]},
+				{"op": "Remove", "path": "members[value eq \"2\"]"},
+				{"op": "Replace", "path": "displayName", "value": "user3/crew"}
+			]
+		}`)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var patched scim.Group
+		DecodeJSON(t, resp, &patched)
+		assert.Equal(t, "user3/crew", patched.DisplayName)
+		assert.Len(t, patched.Members, 2)
+
+		team := models.AssertExistsAndLoadBean(t, &models.Team{ID: team.ID}).(*models.Team)
+		assert.Equal(t, "crew", team.Name)
+		assert.False(t, team.IsMember(2))
+		assert.True(t, team.IsMember(4))
+		assert.True(t, team.IsMember(5))
+
+		req = newSCIMRequest(t, "PATCH", groupURL, token, `{"Operations": [{"op": "remove", "path": "members", "value": [{"value": "5"}]}]}`)
+		MakeRequest(t, req, http.StatusOK)
+		assert.False(t, team.IsMember(5))
+
+		for _, operations := range []string{
+			`[{"op": "replace", "path": "displayName", "value": "user26/crew"}]`,
+			`[{"op": "remove", "path": "displayName"}]`,
+			`[{"op": "add", "path": "members", "value": [{"value": "user2"}]}]`,
+			`[{"op": "add", "path": "members[value eq \"2\"]"}]`,
+		} {
+			req = newSCIMRequest(t, "PATCH", groupURL, token, `{"Operations": `+operations+`}`)
+			MakeRequest(t, req, http.StatusBadRequest)
+		}
+
+		// the owners team cannot be renamed or lose its last member
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Groups/1", token, `{"Operations": [{"op": "replace", "path": "displayName", "value": "user3/admins"}]}`)
+		MakeRequest(t, req, http.StatusBadRequest)
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Groups/1", token, `{"Operations": [{"op": "remove", "path": "members"}]}`)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		req := newSCIMRequest(t, "PUT", groupURL, token, `{"displayName": "user3/crew", "members": [{"value": "2"}]}`)
+		MakeRequest(t, req, http.StatusOK)
+		assert.True(t, team.IsMember(2))
+		assert.False(t, team.IsMember(4))
+
+		req = newSCIMRequest(t, "PUT", groupURL, token, `{"displayName": "user3/crew"}`)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var replaced scim.Group
+		DecodeJSON(t, resp, &replaced)
+		assert.Empty(t, replaced.Members)
+		assert.False(t, team.IsMember(2))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req := newSCIMRequest(t, "DELETE", groupURL, token, "")
+		MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.Team{ID: team.ID})
+
+		req = newSCIMRequest(t, "GET", groupURL, token, "")
+		MakeRequest(t, req, http.StatusNotFound)
+		req = newSCIMRequest(t, "GET", fmt.Sprintf("/scim/v2/Groups/%s", "crew"), token, "")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = newSCIMRequest(t, "DELETE", "/scim/v2/Groups/1", token, "")
+		MakeRequest(t, req, http.StatusConflict)
+		models.AssertExistsAndLoadBean(t, &models.Team{ID: 1})
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISecrets(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	for _, base := range []string{"/api/v1/repos/user2/repo1/secrets", "/api/v1/orgs/user3/secrets"} {
+		t.Run(base, func(t *testing.T) {
+			defer PrintCurrentTest(t)()
+
+			url := fmt.Sprintf("%s/my_token?token=%s", base, token)
+
+			req := NewRequestWithJSON(t, "PUT", url, &api.CreateOrUpdateSecretOption{Data: "value1"})
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithJSON(t, "PUT", url, &api.CreateOrUpdateSecretOption{Data: "value2"})
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/GITEA_TOKEN?token=%s", base, token), &api.CreateOrUpdateSecretOption{Data: "value"})
+			MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s?token=%s", base, token))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var secrets []*api.Secret
+			DecodeJSON(t, resp, &secrets)
+			assert.Len(t, secrets, 1)
+			assert.Equal(t, "MY_TOKEN", secrets[0].Name)
+			assert.NotContains(t, resp.Body.String(), "value2")
+
+			req = NewRequest(t, "DELETE", url)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequest(t, "DELETE", url)
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+	}
+
+	t.Run("NoAdmin", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user4"))
+
+		req := NewRequestWithJSON(t, "PUT", "/api/v1/repos/user2/repo1/secrets/MY_TOKEN?token="+token, &api.CreateOrUpdateSecretOption{Data: "value"})
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", "/api/v1/orgs/user3/secrets?token="+token)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("Web", func(t *testing.T) {
+		defer PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", "/user2/repo1/settings/secrets")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+
+		req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/secrets", map[string]string{
+			"_csrf": htmlDoc.GetCSRF(),
+			"name":  "web_token",
+			"data":  "web-value",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		s, err := models.GetSecret(0, 1, "WEB_TOKEN")
+		assert.NoError(t, err)
+		value, err := s.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "web-value", value)
+
+		req = NewRequest(t, "GET", "/user2/repo1/settings/secrets")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "WEB_TOKEN")
+		assert.NotContains(t, resp.Body.String(), "web-value")
+
+		req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/secrets/delete", map[string]string{
+			"_csrf": htmlDoc.GetCSRF(),
+			"id":    "WEB_TOKEN",
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+		_, err = models.GetSecret(0, 1, "WEB_TOKEN")
+		assert.Equal(t, models.ErrSecretNotExist, err)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createScopedToken(t *testing.T, name string, scopes, repos []string) string {
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+		Name:         name,
+		Scopes:       scopes,
+		Repositories: repos,
+	})
+	req = AddBasicAuthHeader(req, "user2")
+	resp := MakeRequest(t, req, http.StatusCreated)
+
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	assert.ElementsMatch(t, scopes, token.Scopes)
+	assert.ElementsMatch(t, repos, token.Repositories)
+	return token.Token
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// tokens without scopes have full access
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{Name: "full"})
+	req = AddBasicAuthHeader(req, "user2")
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var full api.AccessToken
+	DecodeJSON(t, resp, &full)
+	assert.EqualValues(t, []string{"all"}, full.Scopes)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+full.Token), http.StatusOK)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{Name: "invalid", Scopes: []string{"everything"}})
+	req = AddBasicAuthHeader(req, "user2")
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	readToken := createScopedToken(t, "read", []string{"read:repo"}, []string{})
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1?token="+readToken), http.StatusOK)
+	MakeRequest(t, NewRequest(t, "DELETE", "/api/v1/repos/user2/repo1?token="+readToken), http.StatusForbidden)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+readToken), http.StatusForbidden)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/issues?token="+readToken), http.StatusForbidden)
+
+	// git pushes need the repo scope
+	req = NewRequest(t, "GET", "/user2/repo1.git/info/refs?service=git-receive-pack")
+	req.SetBasicAuth("user2", readToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	restrictedToken := createScopedToken(t, "restricted", []string{"repo", "issue"}, []string{"user2/repo1"})
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1?token="+restrictedToken), http.StatusOK)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/issues?token="+restrictedToken), http.StatusOK)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo2?token="+restrictedToken), http.StatusNotFound)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/search?token="+restrictedToken), http.StatusForbidden)
+
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/refs?service=git-upload-pack")
+	req.SetBasicAuth("user2", restrictedToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// read-only scopes only allow safe methods
+	readOnlyToken := createScopedToken(t, "read-only", []string{"read:issue", "read:user"}, []string{})
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/issues?token="+readOnlyToken), http.StatusOK)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+readOnlyToken), http.StatusOK)
+	MakeRequest(t, NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues?token="+readOnlyToken, &api.CreateIssueOption{Title: "read-only"}), http.StatusForbidden)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user/starred/user2/repo1?token="+readOnlyToken), http.StatusNotFound)
+	MakeRequest(t, NewRequest(t, "PUT", "/api/v1/user/starred/user2/repo1?token="+readOnlyToken), http.StatusForbidden)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user/orgs?token="+readOnlyToken), http.StatusForbidden)
+
+	// tokens cannot be used to manage tokens, neither to escalate their scopes nor to get rid of other tokens
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{Name: "escalated", Scopes: []string{"all"}})
+	req.SetBasicAuth("user2", readToken)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{Name: "escalated", Scopes: []string{"all"}})
+	req.SetBasicAuth(readToken, "x-oauth-basic")
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "GET", "/api/v1/users/user2/tokens")
+	req.SetBasicAuth("user2", readToken)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "DELETE", "/api/v1/users/user2/tokens/"+fmt.Sprint(full.ID))
+	req.SetBasicAuth("user2", readToken)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "DELETE", "/api/v1/users/user2/tokens/"+fmt.Sprint(full.ID))
+	req.SetBasicAuth("user2", full.Token)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/user?token="+full.Token), http.StatusOK)
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIListWikiPages(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	urlStr := "/api/v1/repos/user2/repo1/wiki/pages"
+	req := NewRequest(t, "GET", urlStr)
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	var pages []*api.WikiPageMetaData
+	DecodeJSON(t, resp, &pages)
+	assert.Len(t, pages, 3)
+	assert.Equal(t, "Home", pages[0].Title)
+	assert.Equal(t, "Page With Image", pages[1].Title)
+	assert.Equal(t, "Page With Spaced Name", pages[2].Title)
+	assert.Equal(t, "Page-With-Spaced-Name", pages[2].SubURL)
+	assert.Equal(t, "c10d10b7e655b3dab1f53176db57c8219a5488d6", pages[2].LastCommit.ID)
+
+	req = NewRequest(t, "GET", urlStr+"?page=2&limit=2")
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &pages)
+	assert.Len(t, pages, 1)
+	assert.Equal(t, "Page With Spaced Name", pages[0].Title)
+	assert.Equal(t, "3", resp.Header().Get("X-Total-Count"))
+}
+
+func TestAPIGetWikiPage(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/Home")
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	var page *api.WikiPage
+	DecodeJSON(t, resp, &page)
+	assert.Equal(t, "Home", page.Title)
+	assert.EqualValues(t, 1, page.CommitCount)
+	content, err := base64.StdEncoding.DecodeString(page.ContentBase64)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "This is the home page!")
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/Not-Existing")
+	MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPISearchWikiPages(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/search?q=spaced")
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	var pages []*api.WikiPageMetaData
+	DecodeJSON(t, resp, &pages)
+	if assert.Len(t, pages, 1) {
+		assert.Equal(t, "Page With Spaced Name", pages[0].Title)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/search")
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPIWikiPageLifecycle(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	// anonymous users must not be able to create pages
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/wiki/pages", &api.CreateWikiPageOptions{
+		Title:         "Anonymous",
+		ContentBase64: encode("Anonymous content"),
+	})
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// create
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/pages?token=%s", token), &api.CreateWikiPageOptions{
+		Title:         "New Page",
+		ContentBase64: encode("First revision"),
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var page *api.WikiPage
+	DecodeJSON(t, resp, &page)
+	assert.Equal(t, "New Page", page.Title)
+	assert.Equal(t, encode("First revision"), page.ContentBase64)
+	assert.Equal(t, "Add 'New Page'\n", page.LastCommit.Message)
+
+	// creating the same page again conflicts
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/pages?token=%s", token), &api.CreateWikiPageOptions{
+		Title:         "New Page",
+		ContentBase64: encode("Other content"),
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	// update
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/page/New-Page?token=%s", token), &api.CreateWikiPageOptions{
+		ContentBase64: encode("Second revision"),
+		Message:       "Second revision",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &page)
+	assert.Equal(t, encode("Second revision"), page.ContentBase64)
+	assert.EqualValues(t, 2, page.CommitCount)
+
+	// renaming onto an existing page conflicts
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/page/New-Page?token=%s", token), &api.CreateWikiPageOptions{
+		Title:         "Home",
+		ContentBase64: encode("Second revision"),
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	// revisions contain the content at each commit
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/revisions/New-Page")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var revisions *api.WikiPageRevisionList
+	DecodeJSON(t, resp, &revisions)
+	assert.EqualValues(t, 2, revisions.Count)
+	if assert.Len(t, revisions.Revisions, 2) {
+		assert.Equal(t, encode("Second revision"), revisions.Revisions[0].ContentBase64)
+		assert.Equal(t, encode("First revision"), revisions.Revisions[1].ContentBase64)
+	}
+
+	// rename
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/page/New-Page?token=%s", token), &api.CreateWikiPageOptions{
+		Title:         "Renamed Page",
+		ContentBase64: encode("Second revision"),
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &page)
+	assert.Equal(t, "Renamed Page", page.Title)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/New-Page"), http.StatusNotFound)
+
+	// delete
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/page/Renamed-Page?token=%s", token))
+	session.MakeRequest(t, req, http.StatusNoContent)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/wiki/page/Renamed-Page"), http.StatusNotFound)
+
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/user2/repo1/wiki/page/Renamed-Page?token=%s", token))
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
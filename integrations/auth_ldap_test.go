@@ -97,10 +97,10 @@ func getLDAPServerHost() string {
 	return host
 }
 
-func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
+func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string, groupTeamMap ...string) {
 	session := loginUser(t, "user1")
 	csrf := GetCSRF(t, session, "/admin/auths/new")
-	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
+	values := map[string]string{
 		"_csrf":                    csrf,
 		"type":                     "2",
 		"name":                     "ldap",
@@ -119,7 +119,16 @@ func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
 		"attribute_ssh_public_key": sshKeyAttribute,
 		"is_sync_enabled":          "on",
 		"is_active":                "on",
-	})
+	}
+	if len(groupTeamMap) > 0 {
+		values["groups_enabled"] = "on"
+		values["group_dn"] = "ou=people,dc=planetexpress,dc=com"
+		values["group_member_uid"] = "member"
+		values["user_uid"] = "dn"
+		values["group_team_map"] = groupTeamMap[0]
+		values["group_team_map_removal"] = "on"
+	}
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", values)
 	session.MakeRequest(t, req, http.StatusFound)
 }
 
@@ -240,3 +249,59 @@ func TestLDAPUserSSHKeySync(t *testing.T) {
 		assert.ElementsMatch(t, u.SSHKeys, syncedKeys, "Unequal number of keys synchronized for user: %s", u.UserName)
 	}
 }
+
+func TestLDAPGroupTeamSync(t *testing.T) {
+	if skipLDAPTests() {
+		t.Skip()
+		return
+	}
+	defer prepareTestEnv(t)()
+	addAuthSourceLDAP(t, "", `{
+		"cn=ship_crew,ou=people,dc=planetexpress,dc=com": {"user3": ["team1", "test_team"], "NonExistingOrg": ["Team"]},
+		"cn=admin_staff,ou=people,dc=planetexpress,dc=com": {"user17": ["review_team"]}
+	}`)
+	models.SyncExternalUsers(context.Background(), true)
+
+	team1 := models.AssertExistsAndLoadBean(t, &models.Team{ID: 2}).(*models.Team)
+	testTeam := models.AssertExistsAndLoadBean(t, &models.Team{ID: 7}).(*models.Team)
+	reviewTeam := models.AssertExistsAndLoadBean(t, &models.Team{ID: 9}).(*models.Team)
+	for _, u := range gitLDAPUsers {
+		user := models.AssertExistsAndLoadBean(t, &models.User{Name: u.UserName}).(*models.User)
+		// the ship crew are fry, leela and bender, the admin staff are the professor and hermes
+		isCrew := !u.IsAdmin
+		assert.Equal(t, isCrew, team1.IsMember(user.ID), u.UserName)
+		assert.Equal(t, isCrew, testTeam.IsMember(user.ID), u.UserName)
+		assert.Equal(t, !isCrew, reviewTeam.IsMember(user.ID), u.UserName)
+	}
+
+	// users are removed from the mapped teams of the groups they are not a member of when they sign in
+	professor := models.AssertExistsAndLoadBean(t, &models.User{Name: gitLDAPUsers[0].UserName}).(*models.User)
+	assert.NoError(t, models.AddTeamMember(team1, professor.ID))
+	loginUserWithPassword(t, gitLDAPUsers[0].UserName, gitLDAPUsers[0].Password)
+	assert.False(t, team1.IsMember(professor.ID))
+	assert.True(t, reviewTeam.IsMember(professor.ID))
+}
+
+func TestLDAPGroupTeamSyncInvalidMap(t *testing.T) {
+	defer prepareTestEnv(t)()
+	session := loginUser(t, "user1")
+	csrf := GetCSRF(t, session, "/admin/auths/new")
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
+		"_csrf":              csrf,
+		"type":               "2",
+		"name":               "ldap",
+		"host":               getLDAPServerHost(),
+		"port":               "389",
+		"user_base":          "ou=people,dc=planetexpress,dc=com",
+		"filter":             "(&(objectClass=inetOrgPerson)(uid=%s))",
+		"attribute_username": "uid",
+		"attribute_mail":     "mail",
+		"groups_enabled":     "on",
+		"group_team_map":     `{"cn=ship_crew,ou=people,dc=planetexpress,dc=com": ["team1"]}`,
+		"is_active":          "on",
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".ui.negative.message").Text(), "The LDAP group team map is invalid")
+	models.AssertNotExistsBean(t, &models.LoginSource{Name: "ldap"})
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestOAuth2Provider starts a Gitea compatible OAuth2 provider returning the profile for every token
+func newTestOAuth2Provider(profile *map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(*profile)
+	})
+	return httptest.NewServer(mux)
+}
+
+func addAuthSourceOAuth2Groups(t *testing.T, name, providerURL string) {
+	session := loginUser(t, "user1")
+	csrf := GetCSRF(t, session, "/admin/auths/new")
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
+		"_csrf":                         csrf,
+		"type":                          "6",
+		"name":                          name,
+		"oauth2_provider":               "gitea",
+		"oauth2_key":                    "client-id",
+		"oauth2_secret":                 "client-secret",
+		"oauth2_use_custom_url":         "on",
+		"oauth2_auth_url":               providerURL + "/login/oauth/authorize",
+		"oauth2_token_url":              providerURL + "/login/oauth/access_token",
+		"oauth2_profile_url":            providerURL + "/api/v1/user",
+		"oauth2_group_claim_name":       "groups",
+		"oauth2_required_group":         "git",
+		"oauth2_admin_group":            "admins",
+		"oauth2_restricted_group":       "guests",
+		"oauth2_group_team_map":         `{"developers": {"user3": ["test_team"]}}`,
+		"oauth2_group_team_map_removal": "on",
+		"is_active":                     "on",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+}
+
+func oauth2SignIn(t *testing.T, name string, expectedRedirect string) *TestSession {
+	session := emptyTestSession(t)
+	req := NewRequest(t, "GET", "/user/oauth2/"+name)
+	resp := session.MakeRequest(t, req, http.StatusTemporaryRedirect)
+	authURL, err := url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+
+	req = NewRequestf(t, "GET", "/user/oauth2/%s/callback?code=code&state=%s", name, url.QueryEscape(authURL.Query().Get("state")))
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, expectedRedirect, resp.Header().Get("Location"))
+	return session
+}
+
+func TestOAuth2GroupClaimMapping(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	defer func(enabled bool) {
+		setting.OAuth2Client.EnableAutoRegistration = enabled
+	}(setting.OAuth2Client.EnableAutoRegistration)
+	setting.OAuth2Client.EnableAutoRegistration = true
+
+	profile := map[string]interface{}{
+		"id":        1001,
+		"login":     "hermes",
+		"email":     "hermes@planetexpress.com",
+		"full_name": "Hermes Conrad",
+		"groups":    []string{"git", "developers", "admins"},
+	}
+	provider := newTestOAuth2Provider(&profile)
+	defer provider.Close()
+	addAuthSourceOAuth2Groups(t, "oauth2-groups", provider.URL)
+
+	// the user is registered as administrator and added to the mapped team
+	session := oauth2SignIn(t, "oauth2-groups", "/")
+	user := models.AssertExistsAndLoadBean(t, &models.User{Name: "hermes"}).(*models.User)
+	assert.True(t, user.IsAdmin)
+	assert.False(t, user.IsRestricted)
+	team := models.AssertExistsAndLoadBean(t, &models.Team{ID: 7}).(*models.Team)
+	assert.True(t, team.IsMember(user.ID))
+
+	req := NewRequest(t, "GET", "/user/settings")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "hermes", NewHTMLParser(t, resp.Body).GetInputValueByName("name"))
+
+	// the mapping is applied again on the next sign in
+	profile["groups"] = []string{"git", "guests"}
+	oauth2SignIn(t, "oauth2-groups", "/")
+	user = models.AssertExistsAndLoadBean(t, &models.User{Name: "hermes"}).(*models.User)
+	assert.False(t, user.IsAdmin)
+	assert.True(t, user.IsRestricted)
+	assert.False(t, team.IsMember(user.ID))
+
+	// members of other groups cannot sign in
+	profile["groups"] = []string{"developers"}
+	oauth2SignIn(t, "oauth2-groups", "/user/login")
+	assert.False(t, team.IsMember(user.ID))
+
+	profile = map[string]interface{}{
+		"id":    1002,
+		"login": "zoidberg",
+		"email": "zoidberg@planetexpress.com",
+	}
+	oauth2SignIn(t, "oauth2-groups", "/user/login")
+	models.AssertNotExistsBean(t, &models.User{Name: "zoidberg"})
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/ci/runner"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorkflow = `name: test
+on:
+  push:
+    branches: [master]
+jobs:
+  build:
+    runs-on: linux
+    env:
+      GREETING: hello
+    steps:
+      - name: Greet
+        run: echo "$GREETING from $GITEA_REPOSITORY"
+      - name: Check out
+        run: test -f README.md
+`
+
+func TestCI(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		// register a runner
+		adminSession := loginUser(t, "user1")
+		adminToken := getTokenForLoggedInUser(t, adminSession)
+		req := NewRequestWithJSON(t, "POST", "/api/v1/admin/ci/runners?token="+adminToken, &api.CreateCIRunnerOption{
+			Name:   "local",
+			Labels: []string{"linux"},
+		})
+		resp := adminSession.MakeRequest(t, req, http.StatusCreated)
+		var apiRunner api.CIRunner
+		DecodeJSON(t, resp, &apiRunner)
+		assert.NotEmpty(t, apiRunner.Token)
+
+		req = NewRequest(t, "GET", "/api/v1/admin/ci/runners?token="+adminToken)
+		resp = adminSession.MakeRequest(t, req, http.StatusOK)
+		var apiRunners []*api.CIRunner
+		DecodeJSON(t, resp, &apiRunners)
+		if assert.Len(t, apiRunners, 1) {
+			assert.Empty(t, apiRunners[0].Token)
+		}
+
+		// runners have to authenticate
+		req = NewRequest(t, "POST", "/api/v1/ci/runner/jobs/fetch")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		workDir, err := ioutil.TempDir("", "ci-runner")
+		assert.NoError(t, err)
+		defer os.RemoveAll(workDir)
+		r := &runner.Runner{
+			URL:     u.String(),
+			Token:   apiRunner.Token,
+			WorkDir: workDir,
+		}
+		ran, err := r.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, ran)
+
+		// pushing a workflow file triggers it
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/contents/.gitea/workflows/test.yml?token=%s", token), &api.CreateFileOptions{
+			FileOptions: api.FileOptions{
+				BranchName: "master",
+				Message:    "Add workflow",
+			},
+			Content: base64.StdEncoding.EncodeToString([]byte(testWorkflow)),
+		})
+		resp = session.MakeRequest(t, req, http.StatusCreated)
+		var fileResponse api.FileResponse
+		DecodeJSON(t, resp, &fileResponse)
+		sha := fileResponse.Commit.SHA
+
+		var runs []*api.CIRun
+		for i := 0; i < 100 && len(runs) == 0; i++ {
+			req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/ci/runs?token=%s", token))
+			resp = session.MakeRequest(t, req, http.StatusOK)
+			DecodeJSON(t, resp, &runs)
+			if len(runs) == 0 {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+		if !assert.Len(t, runs, 1) || !assert.Len(t, runs[0].Jobs, 1) {
+			return
+		}
+		assert.EqualValues(t, "test", runs[0].Name)
+		assert.EqualValues(t, "push", runs[0].Event)
+		assert.EqualValues(t, sha, runs[0].CommitSHA)
+		assert.EqualValues(t, api.CIStatusWaiting, runs[0].Status)
+		job := runs[0].Jobs[0]
+		const statusContext = "test / build (push)"
+		models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, Context: statusContext, State: api.CommitStatusPending})
+
+		// the runner runs the job once it has been queued and reports its log and result
+		for i := 0; i < 100 && err == nil && !ran; i++ {
+			if ran, err = r.RunOnce(context.Background()); !ran {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+		assert.NoError(t, err)
+		assert.True(t, ran)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/ci/runs/%d?token=%s", runs[0].ID, token))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		var run api.CIRun
+		DecodeJSON(t, resp, &run)
+		assert.EqualValues(t, api.CIStatusSuccess, run.Status)
+		assert.EqualValues(t, api.CIStatusSuccess, run.Jobs[0].Status)
+		assert.NotNil(t, run.Jobs[0].Stopped)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/ci/jobs/%d/logs?token=%s", job.ID, token))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "=== Greet\nhello from user2/repo1\n")
+		assert.Contains(t, resp.Body.String(), "=== Check out\n")
+
+		models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, Context: statusContext, State: api.CommitStatusSuccess})
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/user2/repo1/ci/jobs/%d", job.ID))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "hello from user2/repo1")
+
+		// finished jobs cannot be reported again
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/ci/runner/jobs/%d/result", job.ID), &api.CIJobResultOption{Result: api.CIStatusFailure})
+		req.Header.Set(runner.TokenHeader, apiRunner.Token)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func codeSearchRepoNames(t testing.TB, doc *HTMLDoc) []string {
+	groups := doc.doc.Find(".repository.search .repo-search-group")
+	names := make([]string, groups.Length())
+	groups.Each(func(i int, group *goquery.Selection) {
+		names[i] = group.Find(".header a").First().Text()
+	})
+	return names
+}
+
+func TestCodeSearchAcrossRepos(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	for _, name := range []string{"user2/repo1", "user3/repo3"} {
+		repo, err := models.GetRepositoryByOwnerAndName(name[:5], name[6:])
+		assert.NoError(t, err)
+		executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+	}
+
+	session := loginUser(t, "user2")
+	testCodeSearchPage := func(session *TestSession, url string, expected []string) {
+		req := NewRequest(t, "GET", url)
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		assert.ElementsMatch(t, expected, codeSearchRepoNames(t, NewHTMLParser(t, resp.Body)))
+	}
+
+	// private repositories are only found by users who can read them
+	testCodeSearchPage(emptyTestSession(t), "/explore/code?q=Description", []string{"user2/repo1"})
+	testCodeSearchPage(session, "/explore/code?q=Description", []string{"user2/repo1", "user3/repo3"})
+	testCodeSearchPage(session, "/explore/code?q=Description&l=Python", []string{})
+
+	// the organization page only searches the repositories of the organization
+	testCodeSearchPage(emptyTestSession(t), "/user3/-/code?q=Description", []string{})
+	testCodeSearchPage(session, "/user3/-/code?q=Description", []string{"user3/repo3"})
+
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequest(t, "GET", "/api/v1/repos/code/search?q=Description&token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var results api.CodeSearchResults
+	DecodeJSON(t, resp, &results)
+	assert.EqualValues(t, 2, results.TotalCount)
+	assert.Len(t, results.Repositories, 2)
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, results.Languages, 1) {
+		assert.Equal(t, "Markdown", results.Languages[0].Language)
+		assert.EqualValues(t, 2, results.Languages[0].Count)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/orgs/user3/code/search?q=Description&mode=substring&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	results = api.CodeSearchResults{}
+	DecodeJSON(t, resp, &results)
+	assert.False(t, results.Truncated)
+	if assert.Len(t, results.Repositories, 1) {
+		repoResults := results.Repositories[0]
+		assert.Equal(t, "user3/repo3", repoResults.Repository.FullName)
+		if assert.Len(t, repoResults.Files, 1) {
+			assert.Equal(t, "README.md", repoResults.Files[0].Filename)
+			assert.Contains(t, repoResults.Files[0].Lines, &api.CodeSearchLine{Number: 3, Content: "Description for repo3"})
+		}
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/orgs/user3/code/search?q=Description")
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.CodeSearchResults{}
+	DecodeJSON(t, resp, &results)
+	assert.EqualValues(t, 0, results.TotalCount)
+	assert.Empty(t, results.Repositories)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/code/search?q=%28Desc&mode=regexp&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	// a regexp without a word every match contains would have to look up all files
+	req = NewRequest(t, "GET", "/api/v1/repos/code/search?q=.%2A&mode=regexp&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequest(t, "GET", "/api/v1/repos/code/search?q=Description&mode=unknown&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	migration "code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/repository"
+	mirror_service "code.gitea.io/gitea/services/mirror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorPullWithCredentials(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		srcRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 2}).(*models.Repository)
+		assert.True(t, srcRepo.IsPrivate)
+
+		cloneURL := *u
+		cloneURL.Path = "/user2/repo2.git"
+		cloneURL.User = url.UserPassword("user2", userPassword)
+
+		opts := migration.MigrateOptions{
+			RepoName:  "test_mirror_credentials",
+			Mirror:    true,
+			CloneAddr: cloneURL.String(),
+		}
+		mirrorRepo, err := repository.CreateRepository(user, user, models.CreateRepoOptions{
+			Name:     opts.RepoName,
+			IsMirror: true,
+			Status:   models.RepositoryBeingMigrated,
+		})
+		assert.NoError(t, err)
+		mirrorRepo, err = repository.MigrateRepositoryGitData(context.Background(), user, mirrorRepo, opts)
+		assert.NoError(t, err)
+
+		// The credentials are stored as secret instead of in the Git repository config
+		addr, err := git.GetRemoteAddress(mirrorRepo.RepoPath(), "origin")
+		assert.NoError(t, err)
+		assert.NotContains(t, addr, userPassword)
+		assert.NotContains(t, addr, "user2@")
+
+		assert.NoError(t, mirrorRepo.GetMirror())
+		creds, err := mirrorRepo.Mirror.GetCredentials()
+		assert.NoError(t, err)
+		assert.Equal(t, "user2", creds.Username())
+		assert.Equal(t, "user2", mirror_service.Username(mirrorRepo.Mirror))
+		assert.Equal(t, userPassword, mirror_service.Password(mirrorRepo.Mirror))
+
+		// Syncing passes the stored credentials to Git
+		_, err = git.NewCommand("branch", "mirror-credentials", "master").RunInDir(srcRepo.RepoPath())
+		assert.NoError(t, err)
+
+		mirror_service.StartToMirror(mirrorRepo.ID)
+		assert.Eventually(t, func() bool {
+			return git.IsBranchExist(mirrorRepo.RepoPath(), "mirror-credentials")
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+}
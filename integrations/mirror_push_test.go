@@ -0,0 +1,57 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repository"
+	mirror_service "code.gitea.io/gitea/services/mirror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorPushWithCredentials(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		srcRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		mirrorRepo, err := repository.CreateRepository(user, user, models.CreateRepoOptions{
+			Name:      "test_push_mirror_credentials",
+			IsPrivate: true,
+		})
+		assert.NoError(t, err)
+
+		remoteURL := *u
+		remoteURL.Path = "/user2/" + mirrorRepo.Name + ".git"
+		remoteURL.User = url.UserPassword("user2", userPassword)
+
+		m, err := mirror_service.AddPushMirror(srcRepo, remoteURL.String(), time.Hour, false)
+		assert.NoError(t, err)
+
+		// The credentials are stored as secret instead of in the Git repository config
+		addr, err := git.GetRemoteAddress(srcRepo.RepoPath(), m.RemoteName)
+		assert.NoError(t, err)
+		assert.NotContains(t, addr, userPassword)
+		assert.NotContains(t, addr, "user2@")
+		creds, err := m.GetCredentials()
+		assert.NoError(t, err)
+		assert.Equal(t, "user2", creds.Username())
+
+		// Syncing passes the stored credentials to Git
+		assert.True(t, mirror_service.SyncPushMirror(context.Background(), m.ID))
+		assert.True(t, git.IsBranchExist(mirrorRepo.RepoPath(), "master"))
+
+		assert.NoError(t, mirror_service.RemovePushMirror(m))
+		creds, err = m.GetCredentials()
+		assert.NoError(t, err)
+		assert.Nil(t, creds)
+	})
+}
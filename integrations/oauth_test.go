@@ -7,8 +7,10 @@ package integrations
 import (
 	"bytes"
 	"io/ioutil"
+	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
 
 	jsoniter "github.com/json-iterator/go"
@@ -261,3 +263,310 @@ func TestRefreshTokenInvalidation(t *testing.T) {
 	refreshReq.Body = ioutil.NopCloser(bytes.NewReader(bs))
 	MakeRequest(t, refreshReq, 400)
 }
+
+const (
+	oauthClientID     = "da7da3ba-9a13-4167-856f-3899de0b0138"
+	oauthClientSecret = "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA="
+)
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	Error        string `json:"error"`
+}
+
+type oauthIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope"`
+	ClientID  string `json:"client_id"`
+	Username  string `json:"username"`
+	TokenType string `json:"token_type"`
+	Subject   string `json:"sub"`
+}
+
+func requestOAuthToken(t *testing.T, values map[string]string, expectedStatus int) *oauthTokenResponse {
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", values)
+	resp := MakeRequest(t, req, expectedStatus)
+	parsed := new(oauthTokenResponse)
+	DecodeJSON(t, resp, parsed)
+	return parsed
+}
+
+func introspectOAuthToken(t *testing.T, token string) *oauthIntrospectionResponse {
+	req := NewRequestWithValues(t, "POST", "/login/oauth/introspect", map[string]string{
+		"token": token,
+	})
+	req.SetBasicAuth(oauthClientID, oauthClientSecret)
+	resp := MakeRequest(t, req, 200)
+	parsed := new(oauthIntrospectionResponse)
+	DecodeJSON(t, resp, parsed)
+	return parsed
+}
+
+func oauthAPIRequest(t *testing.T, method, urlStr, token string, expectedStatus int) {
+	req := NewRequest(t, method, urlStr)
+	req.Header.Set("Authorization", "Bearer "+token)
+	MakeRequest(t, req, expectedStatus)
+}
+
+func TestOAuthScopes(t *testing.T) {
+	defer prepareTestEnv(t)()
+	session := loginUser(t, "user4")
+
+	req := NewRequest(t, "GET", defaultAuthorize+"&scope=openid+read:repo")
+	resp := session.MakeRequest(t, req, 200)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".oauth2-authorize-application-box li").Text(), "Read repositories.")
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/grant", map[string]string{
+		"_csrf":        htmlDoc.GetCSRF(),
+		"client_id":    oauthClientID,
+		"redirect_uri": "a",
+		"state":        "thestate",
+		"scope":        "openid read:repo",
+	})
+	resp = session.MakeRequest(t, req, 302)
+	u, err := resp.Result().Location()
+	assert.NoError(t, err)
+
+	token := requestOAuthToken(t, map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"redirect_uri":  "a",
+		"code":          u.Query().Get("code"),
+	}, 200)
+	assert.Equal(t, "openid read:repo", token.Scope)
+
+	oauthAPIRequest(t, "GET", "/api/v1/user/repos", token.AccessToken, 200)
+	oauthAPIRequest(t, "GET", "/api/v1/user", token.AccessToken, 403)
+	oauthAPIRequest(t, "POST", "/api/v1/user/repos", token.AccessToken, 403)
+
+	// the grant covers the scopes already
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=read:repo")
+	session.MakeRequest(t, req, 302)
+	// but the user has to authorize more scopes
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=repo")
+	session.MakeRequest(t, req, 200)
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=all")
+	resp = session.MakeRequest(t, req, 200)
+	NewHTMLParser(t, resp.Body).AssertElement(t, ".oauth2-authorize-application-box li", false)
+
+	// authorizing other scopes keeps the ones granted before
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=read:org")
+	resp = session.MakeRequest(t, req, 200)
+	req = NewRequestWithValues(t, "POST", "/login/oauth/grant", map[string]string{
+		"_csrf":        NewHTMLParser(t, resp.Body).GetCSRF(),
+		"client_id":    oauthClientID,
+		"redirect_uri": "a",
+		"state":        "thestate",
+		"scope":        "read:org",
+	})
+	session.MakeRequest(t, req, 302)
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=openid+read:repo+read:org")
+	session.MakeRequest(t, req, 302)
+
+	// no API scope asks for no API access
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=openid")
+	session.MakeRequest(t, req, 302)
+	session = loginUser(t, "user2")
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=openid")
+	resp = session.MakeRequest(t, req, 200)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, ".oauth2-authorize-application-box li", false)
+	assert.Contains(t, htmlDoc.doc.Find(".oauth2-authorize-application-box b").Text(), "not be able to access your account")
+}
+
+func TestOAuthClientCredentials(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// the application only gets the scopes its owner, user1, authorized it for
+	resp := requestOAuthToken(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"scope":         "read:repo",
+	}, 400)
+	assert.Equal(t, "invalid_scope", resp.Error)
+	grant := models.AssertExistsAndLoadBean(t, &models.OAuth2Grant{ID: 1, UserID: 1}).(*models.OAuth2Grant)
+	assert.NoError(t, grant.AddScope("repo"))
+	resp = requestOAuthToken(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"scope":         "all",
+	}, 400)
+	assert.Equal(t, "invalid_scope", resp.Error)
+
+	// tokens act on behalf of the owner of the application, user1
+	token := requestOAuthToken(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"scope":         "read:repo",
+	}, 200)
+	assert.NotEmpty(t, token.AccessToken)
+	assert.Empty(t, token.RefreshToken)
+	assert.Equal(t, "read:repo", token.Scope)
+
+	oauthAPIRequest(t, "GET", "/api/v1/user/repos", token.AccessToken, 200)
+	oauthAPIRequest(t, "GET", "/api/v1/user", token.AccessToken, 403)
+
+	introspection := introspectOAuthToken(t, token.AccessToken)
+	assert.Equal(t, &oauthIntrospectionResponse{
+		Active:    true,
+		Scope:     "read:repo",
+		ClientID:  oauthClientID,
+		Username:  "user1",
+		TokenType: "bearer",
+		Subject:   "1",
+	}, introspection)
+
+	// revoking the token leaves the grant of user1 as a user alone
+	req := NewRequestWithValues(t, "POST", "/login/oauth/revoke", map[string]string{
+		"token": token.AccessToken,
+	})
+	req.SetBasicAuth(oauthClientID, oauthClientSecret)
+	MakeRequest(t, req, 200)
+	assert.False(t, introspectOAuthToken(t, token.AccessToken).Active)
+	models.AssertExistsAndLoadBean(t, &models.OAuth2Grant{ID: 1, UserID: 1})
+
+	// there is no default scope
+	resp = requestOAuthToken(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+	}, 400)
+	assert.Equal(t, "invalid_scope", resp.Error)
+
+	resp = requestOAuthToken(t, map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     oauthClientID,
+		"client_secret": "wrong",
+		"scope":         "read:repo",
+	}, 400)
+	assert.Equal(t, "invalid_client", resp.Error)
+}
+
+func TestOAuthIntrospectAndRevoke(t *testing.T) {
+	defer prepareTestEnv(t)()
+	token := requestOAuthToken(t, map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"redirect_uri":  "a",
+		"code":          "authcode",
+		"code_verifier": "N1Zo9-8Rfwhkt68r1r29ty8YwIraXR8eh_1Qwxg7yQXsonBt",
+	}, 200)
+	// the grant has no API scope
+	oauthAPIRequest(t, "GET", "/api/v1/user", token.AccessToken, 403)
+
+	// clients have to authenticate
+	req := NewRequestWithValues(t, "POST", "/login/oauth/introspect", map[string]string{
+		"token": token.AccessToken,
+	})
+	req.SetBasicAuth(oauthClientID, "wrong")
+	MakeRequest(t, req, 401)
+	req = NewRequestWithValues(t, "POST", "/login/oauth/revoke", map[string]string{
+		"token":         token.AccessToken,
+		"client_id":     oauthClientID,
+		"client_secret": "wrong",
+	})
+	MakeRequest(t, req, 401)
+
+	introspection := introspectOAuthToken(t, token.AccessToken)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, "openid profile", introspection.Scope)
+	assert.Equal(t, "user1", introspection.Username)
+	assert.False(t, introspectOAuthToken(t, "invalid").Active)
+
+	// revoking the refresh token revokes the access token as well
+	req = NewRequestWithValues(t, "POST", "/login/oauth/revoke", map[string]string{
+		"token":           token.RefreshToken,
+		"token_type_hint": "refresh_token",
+	})
+	req.SetBasicAuth(oauthClientID, oauthClientSecret)
+	MakeRequest(t, req, 200)
+	assert.False(t, introspectOAuthToken(t, token.AccessToken).Active)
+	oauthAPIRequest(t, "GET", "/api/v1/user", token.AccessToken, 401)
+
+	// unknown tokens are no error
+	req = NewRequestWithValues(t, "POST", "/login/oauth/revoke", map[string]string{
+		"token": token.RefreshToken,
+	})
+	req.SetBasicAuth(oauthClientID, oauthClientSecret)
+	MakeRequest(t, req, 200)
+}
+
+func TestOAuthDeviceAuthorization(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	type deviceAuthorizationResponse struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		Interval                int64  `json:"interval"`
+	}
+	authorizeDevice := func(scope string) *deviceAuthorizationResponse {
+		req := NewRequestWithValues(t, "POST", "/login/oauth/device_authorization", map[string]string{
+			"client_id": oauthClientID,
+			"scope":     scope,
+		})
+		resp := MakeRequest(t, req, 200)
+		parsed := new(deviceAuthorizationResponse)
+		DecodeJSON(t, resp, parsed)
+		return parsed
+	}
+	pollToken := func(deviceCode string, expectedStatus int) *oauthTokenResponse {
+		return requestOAuthToken(t, map[string]string{
+			"grant_type":  "urn:ietf:params:oauth:grant-type:device_code",
+			"client_id":   oauthClientID,
+			"device_code": deviceCode,
+		}, expectedStatus)
+	}
+
+	device := authorizeDevice("issue")
+	assert.Equal(t, setting.AppURL+"login/oauth/device", device.VerificationURI)
+	assert.Equal(t, device.VerificationURI+"?user_code="+device.UserCode, device.VerificationURIComplete)
+	assert.EqualValues(t, 5, device.Interval)
+
+	assert.Equal(t, "authorization_pending", pollToken(device.DeviceCode, 400).Error)
+	assert.Equal(t, "slow_down", pollToken(device.DeviceCode, 400).Error)
+	assert.Equal(t, "invalid_grant", pollToken("invalid", 400).Error)
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/login/oauth/device?user_code=invalid")
+	resp := session.MakeRequest(t, req, 200)
+	NewHTMLParser(t, resp.Body).AssertElement(t, "#authorize-device", false)
+
+	req = NewRequest(t, "GET", "/login/oauth/device?user_code="+strings.ToLower(device.UserCode))
+	resp = session.MakeRequest(t, req, 200)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, "#authorize-device", true)
+	req = NewRequestWithValues(t, "POST", "/login/oauth/device", map[string]string{
+		"_csrf":     htmlDoc.GetCSRF(),
+		"user_code": device.UserCode,
+		"granted":   "true",
+	})
+	session.MakeRequest(t, req, 200)
+
+	token := pollToken(device.DeviceCode, 200)
+	assert.NotEmpty(t, token.AccessToken)
+	assert.NotEmpty(t, token.RefreshToken)
+	oauthAPIRequest(t, "GET", "/api/v1/repos/issues/search", token.AccessToken, 200)
+	oauthAPIRequest(t, "GET", "/api/v1/user", token.AccessToken, 403)
+	assert.Equal(t, "user2", introspectOAuthToken(t, token.AccessToken).Username)
+	// device codes can only be used once
+	assert.Equal(t, "invalid_grant", pollToken(device.DeviceCode, 400).Error)
+
+	device = authorizeDevice("")
+	req = NewRequestWithValues(t, "POST", "/login/oauth/device", map[string]string{
+		"_csrf":     htmlDoc.GetCSRF(),
+		"user_code": device.UserCode,
+		"granted":   "false",
+	})
+	session.MakeRequest(t, req, 200)
+	assert.Equal(t, "access_denied", pollToken(device.DeviceCode, 400).Error)
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgProject(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user3/-/projects/new")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	req = NewRequestWithValues(t, "POST", "/user3/-/projects/new", map[string]string{
+		"_csrf":      GetCSRF(t, session, "/user3/-/projects/new"),
+		"title":      "Organization project",
+		"board_type": "1",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	projects, _, err := models.GetProjects(models.ProjectSearchOptions{OwnerID: 3})
+	assert.NoError(t, err)
+	if !assert.Len(t, projects, 1) {
+		return
+	}
+	project := projects[0]
+	assert.Equal(t, models.ProjectTypeOrganization, project.Type)
+	projectLink := fmt.Sprintf("/user3/-/projects/%d", project.ID)
+
+	// issues of a public and of a private repository
+	for _, ref := range []string{"user2/repo1#1", "user2/repo2#1"} {
+		req = NewRequestWithValues(t, "POST", projectLink+"/issues", map[string]string{
+			"_csrf": GetCSRF(t, session, projectLink),
+			"issue": ref,
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+	}
+	assert.Equal(t, 2, project.NumIssues())
+
+	req = NewRequest(t, "GET", projectLink)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 2, htmlDoc.doc.Find(".board-card").Length())
+
+	// cards are only shown to the users who can read the issue
+	req = NewRequest(t, "GET", projectLink)
+	resp = MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 1, htmlDoc.doc.Find(".board-card").Length())
+	assert.EqualValues(t, 1, htmlDoc.doc.Find(`.board-card[data-issue="1"]`).Length())
+
+	// non-members can neither change the project nor add issues they cannot write
+	session5 := loginUser(t, "user5")
+	req = NewRequest(t, "GET", projectLink+"/edit")
+	session5.MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequestWithValues(t, "POST", projectLink+"/issues", map[string]string{
+		"_csrf": GetCSRF(t, session5, "/user3/-/projects"),
+		"issue": "user2/repo1#4",
+	})
+	session5.MakeRequest(t, req, http.StatusNotFound)
+
+	// members cannot add issues of repositories they cannot write
+	session4 := loginUser(t, "user4")
+	req = NewRequestWithValues(t, "POST", projectLink+"/issues", map[string]string{
+		"_csrf": GetCSRF(t, session4, projectLink),
+		"issue": "user2/repo1#4",
+	})
+	session4.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, 2, project.NumIssues())
+}
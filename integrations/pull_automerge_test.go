@@ -0,0 +1,143 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/services/forms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPrepareAutoMergePull(t *testing.T, session *TestSession) *models.PullRequest {
+	testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+	testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+	// require the "testci" status check to succeed before merging into master
+	csrf := GetCSRF(t, session, "/user2/repo1/settings/branches")
+	req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/branches/master", map[string]string{
+		"_csrf":                 csrf,
+		"protected":             "on",
+		"enable_status_check":   "on",
+		"status_check_contexts": "testci",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+	elem := strings.Split(test.RedirectURL(resp), "/")
+	assert.EqualValues(t, "pulls", elem[3])
+
+	index, err := strconv.ParseInt(elem[4], 10, 64)
+	assert.NoError(t, err)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: 1, Index: index}).(*models.Issue)
+
+	// wait for the pull request to be checked for conflicts
+	var pr *models.PullRequest
+	for i := 0; i < 50; i++ {
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{IssueID: issue.ID}).(*models.PullRequest)
+		if !pr.IsChecking() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.True(t, pr.CanAutoMerge())
+	return pr
+}
+
+func TestPullAutoMergeAfterCommitStatusSucceed(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user1")
+		token := getTokenForLoggedInUser(t, session)
+		pr := testPrepareAutoMergePull(t, session)
+
+		// the required status check is missing, so the merge is scheduled
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token), &forms.MergePullRequestForm{
+			Do:                     string(models.MergeStyleMerge),
+			MergeWhenChecksSucceed: true,
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+		models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID})
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pr.IssueID, Type: models.CommentTypePRScheduledToAutoMerge})
+
+		// scheduling it again conflicts
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token), &forms.MergePullRequestForm{
+			Do:                     string(models.MergeStyleMerge),
+			MergeWhenChecksSucceed: true,
+		})
+		session.MakeRequest(t, req, http.StatusConflict)
+
+		// a failing status does not merge
+		headCommitID := getHeadCommitIDOfPull(t, token, pr.Index)
+		for _, state := range []api.CommitStatusState{api.CommitStatusFailure, api.CommitStatusSuccess} {
+			req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", headCommitID, token), api.CreateStatusOption{
+				State:   state,
+				Context: "testci",
+			})
+			session.MakeRequest(t, req, http.StatusCreated)
+		}
+
+		// the success status triggers the merge, which drops the schedule
+		merged := false
+		for i := 0; i < 50 && !merged; i++ {
+			time.Sleep(100 * time.Millisecond)
+			merged = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest).HasMerged
+		}
+		assert.True(t, merged)
+		scheduled := true
+		for i := 0; i < 50 && scheduled; i++ {
+			scheduled, _, _ = models.GetScheduledAutoMergeByPullID(pr.ID)
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.False(t, scheduled)
+	})
+}
+
+func TestPullAutoMergeCancel(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user1")
+		token := getTokenForLoggedInUser(t, session)
+		pr := testPrepareAutoMergePull(t, session)
+
+		urlStr := fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token)
+		req := NewRequest(t, "DELETE", urlStr)
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithJSON(t, "POST", urlStr, &forms.MergePullRequestForm{
+			Do:                     string(models.MergeStyleSquash),
+			MergeWhenChecksSucceed: true,
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+
+		// other users which are not allowed to merge can't cancel it
+		session4 := loginUser(t, "user4")
+		token4 := getTokenForLoggedInUser(t, session4)
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token4))
+		session4.MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "DELETE", urlStr)
+		session.MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.PullAutoMerge{PullID: pr.ID})
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pr.IssueID, Type: models.CommentTypePRUnScheduledToAutoMerge})
+		assert.False(t, models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest).HasMerged)
+	})
+}
+
+func getHeadCommitIDOfPull(t *testing.T, token string, index int64) string {
+	req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d?token=%s", index, token))
+	resp := MakeRequest(t, req, http.StatusOK)
+	var apiPull *api.PullRequest
+	DecodeJSON(t, resp, &apiPull)
+	return apiPull.Head.Sha
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/services/forms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullCodeOwners(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		_, err := createFileInBranch(user2, repo1, "CODEOWNERS", "master", "* @user5\nREADME.md @user4 @user1\n")
+		assert.NoError(t, err)
+
+		session := loginUser(t, "user2")
+		csrf := GetCSRF(t, session, "/user2/repo1/settings/branches")
+		req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/branches/master", map[string]string{
+			"_csrf":                       csrf,
+			"protected":                   "on",
+			"require_code_owner_approval": "on",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		session1 := loginUser(t, "user1")
+		testRepoFork(t, session1, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session1, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+		resp := testPullCreate(t, session1, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		index, err := strconv.ParseInt(elem[4], 10, 64)
+		assert.NoError(t, err)
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: 1, Index: index}).(*models.Issue)
+
+		// only the owners of the changed file are requested, but not the poster
+		models.AssertExistsAndLoadBean(t, &models.Review{IssueID: issue.ID, ReviewerID: 4, Type: models.ReviewTypeRequest})
+		models.AssertNotExistsBean(t, &models.Review{IssueID: issue.ID, ReviewerID: 5})
+		models.AssertNotExistsBean(t, &models.Review{IssueID: issue.ID, ReviewerID: 1})
+
+		for i := 0; i < 50; i++ {
+			pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{IssueID: issue.ID}).(*models.PullRequest)
+			if !pr.IsChecking() {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		// merging is blocked until a code owner approves
+		token := getTokenForLoggedInUser(t, session)
+		req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", index, token), &forms.MergePullRequestForm{
+			Do: string(models.MergeStyleMerge),
+		})
+		session.MakeRequest(t, req, http.StatusMethodNotAllowed)
+
+		session4 := loginUser(t, "user4")
+		token4 := getTokenForLoggedInUser(t, session4)
+		req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/reviews?token=%s", index, token4), &api.CreatePullReviewOptions{
+			Body:  "LGTM",
+			Event: api.ReviewStateApproved,
+		})
+		session4.MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", index, token), &forms.MergePullRequestForm{
+			Do: string(models.MergeStyleMerge),
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+	})
+}
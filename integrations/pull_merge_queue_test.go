@@ -0,0 +1,176 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/services/forms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCreateMergeQueuePull(t *testing.T, session *TestSession, token, headBranch, filePath string) *models.PullRequest {
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user1/repo1/contents/%s?token=%s", filePath, token), &api.CreateFileOptions{
+		FileOptions: api.FileOptions{
+			BranchName:    "master",
+			NewBranchName: headBranch,
+			Message:       "Add " + filePath,
+		},
+		Content: base64.StdEncoding.EncodeToString([]byte("Content of " + filePath + "\n")),
+	})
+	session.MakeRequest(t, req, http.StatusCreated)
+	resp := testPullCreate(t, session, "user1", "repo1", headBranch, "Add "+filePath)
+	elem := strings.Split(test.RedirectURL(resp), "/")
+	index, err := strconv.ParseInt(elem[4], 10, 64)
+	assert.NoError(t, err)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: 1, Index: index}).(*models.Issue)
+
+	var pr *models.PullRequest
+	for i := 0; i < 50; i++ {
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{IssueID: issue.ID}).(*models.PullRequest)
+		if !pr.IsChecking() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.True(t, pr.CanAutoMerge())
+
+	// the head has to pass the status checks to get into the merge queue
+	testPostCommitStatus(t, session, token, getHeadCommitIDOfPull(t, token, pr.Index), api.CommitStatusSuccess)
+	return pr
+}
+
+func testPostCommitStatus(t *testing.T, session *TestSession, token, sha string, state api.CommitStatusState) {
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", sha, token), api.CreateStatusOption{
+		State:   state,
+		Context: "testci",
+	})
+	session.MakeRequest(t, req, http.StatusCreated)
+}
+
+// waitForMergeQueueEntry waits until the speculative merge of the pull request has been built onto baseCommitID
+func waitForMergeQueueEntry(t *testing.T, pr *models.PullRequest, baseCommitID string) *models.MergeQueueEntry {
+	for i := 0; i < 100; i++ {
+		exists, entry, err := models.GetMergeQueueEntryByPullID(pr.ID)
+		assert.NoError(t, err)
+		if exists && entry.BaseCommitID == baseCommitID && len(entry.CommitID) > 0 {
+			return entry
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.FailNow(t, "merge queue entry has not been built", "PR[%d] onto %s", pr.ID, baseCommitID)
+	return nil
+}
+
+// queuePullWhenMergeable queues the pull request once the conflict check, which runs after every push to its base branch, is done
+func queuePullWhenMergeable(t *testing.T, session *TestSession, token string, pr *models.PullRequest) {
+	for i := 0; i < 100; i++ {
+		if models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest).CanAutoMerge() {
+			req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token), &forms.MergePullRequestForm{
+				Do: string(models.MergeStyleMerge),
+			})
+			// the check may not have started yet when the status still says mergeable
+			resp := session.MakeRequest(t, req, NoExpectedStatus)
+			if resp.Code != http.StatusMethodNotAllowed {
+				assert.EqualValues(t, http.StatusAccepted, resp.Code)
+				return
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.FailNow(t, "pull request has not become mergeable", "PR[%d]", pr.ID)
+}
+
+func TestPullMergeQueue(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user1")
+		token := getTokenForLoggedInUser(t, session)
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+
+		csrf := GetCSRF(t, session, "/user2/repo1/settings/branches")
+		req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/branches/master", map[string]string{
+			"_csrf":                 csrf,
+			"protected":             "on",
+			"enable_status_check":   "on",
+			"status_check_contexts": "testci",
+			"enable_merge_queue":    "on",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		pr1 := testCreateMergeQueuePull(t, session, token, "queue-1", "queue-1.txt")
+		pr2 := testCreateMergeQueuePull(t, session, token, "queue-2", "queue-2.txt")
+
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		gitRepo, err := git.OpenRepository(repo1.RepoPath())
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		masterCommitID, err := gitRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		// merging queues the pull requests instead
+		for _, pr := range []*models.PullRequest{pr1, pr2} {
+			req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token), &forms.MergePullRequestForm{
+				Do: string(models.MergeStyleMerge),
+			})
+			session.MakeRequest(t, req, http.StatusAccepted)
+			models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pr.IssueID, Type: models.CommentTypeMergeQueueAdd})
+		}
+
+		// pr2 is merged onto the speculative merge of pr1, which is pushed for CI
+		entry1 := waitForMergeQueueEntry(t, pr1, masterCommitID)
+		entry2 := waitForMergeQueueEntry(t, pr2, entry1.CommitID)
+		queueCommitID, err := gitRepo.GetRefCommitID(pr2.GetMergeQueueRefName())
+		assert.NoError(t, err)
+		assert.Equal(t, entry2.CommitID, queueCommitID)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge_queue?token=%s", pr2.Index, token))
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var apiEntry api.PullMergeQueueEntry
+		DecodeJSON(t, resp, &apiEntry)
+		assert.EqualValues(t, 2, apiEntry.Position)
+		assert.Equal(t, entry2.CommitID, apiEntry.CommitID)
+
+		// failing checks eject pr1, so pr2 is rebuilt onto master
+		testPostCommitStatus(t, session, token, entry1.CommitID, api.CommitStatusFailure)
+		entry2 = waitForMergeQueueEntry(t, pr2, masterCommitID)
+		models.AssertNotExistsBean(t, &models.MergeQueueEntry{PullID: pr1.ID})
+		comment := models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pr1.IssueID, Type: models.CommentTypeMergeQueueRemove}).(*models.Comment)
+		assert.NotEmpty(t, comment.Content)
+		assert.False(t, models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr1.ID}).(*models.PullRequest).HasMerged)
+
+		// succeeding checks merge pr2 by fast-forwarding master to its speculative merge
+		testPostCommitStatus(t, session, token, entry2.CommitID, api.CommitStatusSuccess)
+		merged, queued := false, true
+		for i := 0; i < 100 && (!merged || queued); i++ {
+			time.Sleep(100 * time.Millisecond)
+			merged = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr2.ID}).(*models.PullRequest).HasMerged
+			queued, _, err = models.GetMergeQueueEntryByPullID(pr2.ID)
+			assert.NoError(t, err)
+		}
+		assert.True(t, merged)
+		assert.False(t, queued)
+		masterCommitID, err = gitRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+		assert.Equal(t, entry2.CommitID, masterCommitID)
+
+		// pr1 can be queued again and removed by hand
+		queuePullWhenMergeable(t, session, token, pr1)
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge_queue?token=%s", pr1.Index, token))
+		session.MakeRequest(t, req, http.StatusNoContent)
+		session.MakeRequest(t, req, http.StatusNotFound)
+	})
+}
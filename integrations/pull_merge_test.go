@@ -333,3 +333,125 @@ func TestCantMergeUnrelated(t *testing.T) {
 		gitRepo.Close()
 	})
 }
+
+func testEditPullRequestsConfig(t *testing.T, session *TestSession, user, repo string, opts *api.EditRepoOption) {
+	hasPullRequests := true
+	opts.HasPullRequests = &hasPullRequests
+
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, http.MethodPatch, fmt.Sprintf("/api/v1/repos/%s/%s?token=%s", user, repo, token), opts)
+	session.MakeRequest(t, req, http.StatusOK)
+}
+
+func TestPullFastForwardOnly(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		allowFastForwardOnly := true
+		testEditPullRequestsConfig(t, session, "user2", "repo1", &api.EditRepoOption{AllowFastForwardOnly: &allowFastForwardOnly})
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		testPullMerge(t, session, elem[1], elem[2], elem[4], models.MergeStyleFastForwardOnly)
+
+		headRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		defer headRepo.Close()
+		headCommitID, err := headRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		baseRepo, err := git.OpenRepository(models.RepoPath("user2", "repo1"))
+		assert.NoError(t, err)
+		defer baseRepo.Close()
+		baseCommitID, err := baseRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		// No merge commit has been created
+		assert.EqualValues(t, headCommitID, baseCommitID)
+
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: 1, HeadBranch: "master"}).(*models.PullRequest)
+		assert.True(t, pr.HasMerged)
+		assert.EqualValues(t, headCommitID, pr.MergedCommitID)
+	})
+}
+
+func TestCantFastForwardDiverged(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFileToNewBranch(t, session, "user1", "repo1", "master", "diverged", "README.md", "Hello, World (Edited Once)\n")
+		testEditFileToNewBranch(t, session, "user1", "repo1", "master", "base", "README.md", "Hello, World (Edited Twice)\n")
+
+		allowFastForwardOnly := true
+		testEditPullRequestsConfig(t, session, "user1", "repo1", &api.EditRepoOption{AllowFastForwardOnly: &allowFastForwardOnly})
+
+		token := getTokenForLoggedInUser(t, session)
+		req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/%s/pulls?token=%s", "user1", "repo1", token), &api.CreatePullRequestOption{
+			Head:  "diverged",
+			Base:  "base",
+			Title: "create a diverged pr",
+		})
+		session.MakeRequest(t, req, 201)
+
+		user1 := models.AssertExistsAndLoadBean(t, &models.User{
+			Name: "user1",
+		}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{
+			OwnerID: user1.ID,
+			Name:    "repo1",
+		}).(*models.Repository)
+
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{
+			HeadRepoID: repo1.ID,
+			BaseRepoID: repo1.ID,
+			HeadBranch: "diverged",
+			BaseBranch: "base",
+		}).(*models.PullRequest)
+
+		gitRepo, err := git.OpenRepository(models.RepoPath(user1.Name, repo1.Name))
+		assert.NoError(t, err)
+
+		err = pull.Merge(pr, user1, gitRepo, models.MergeStyleFastForwardOnly, "")
+		assert.Error(t, err, "Merge should return an error as the branches diverged")
+		assert.True(t, models.IsErrMergeDivergingFastForwardOnly(err), "Merge error is not a diverging fast-forward-only error")
+		gitRepo.Close()
+	})
+}
+
+func TestPullMergeMessageTemplate(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		mergeMessageTemplate := "${PullRequestTitle} (${PullRequestReference})\n\nMerge ${HeadBranch} into ${BaseBranch}\n${Reviewers}"
+		testEditPullRequestsConfig(t, session, "user2", "repo1", &api.EditRepoOption{MergeMessageTemplate: &mergeMessageTemplate})
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		// The merge form is prefilled from the template
+		req := NewRequest(t, "GET", path.Join(elem[1], elem[2], "pulls", elem[4]))
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		title, _ := htmlDoc.doc.Find(".ui.form.merge-fields input[name=merge_title_field]").Attr("value")
+		assert.EqualValues(t, "This is a pull title (#"+elem[4]+")", title)
+		assert.EqualValues(t, "Merge master into master", htmlDoc.doc.Find(".ui.form.merge-fields textarea[name=merge_message_field]").Text())
+
+		// Merging without a title uses the rendered template
+		testPullMerge(t, session, elem[1], elem[2], elem[4], models.MergeStyleMerge)
+
+		gitRepo, err := git.OpenRepository(models.RepoPath("user2", "repo1"))
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		commit, err := gitRepo.GetBranchCommit("master")
+		assert.NoError(t, err)
+		assert.EqualValues(t, "This is a pull title (#"+elem[4]+")\n\nMerge master into master\n", commit.Message())
+	})
+}
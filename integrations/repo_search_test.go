@@ -37,6 +37,17 @@ func TestSearchRepo(t *testing.T) {
 	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
 
 	testSearch(t, "/user2/repo1/search?q=Description&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=escription+for&t=substring&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=%5EDesc%5Cw%2B+for&t=regexp&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=%5Efor&t=regexp&page=1", []string{})
+	testSearch(t, "/user2/repo1/search?q=Description&symbols=true&page=1", []string{})
+
+	req := NewRequest(t, "GET", "/user2/repo1/search?q=%28Desc&t=regexp")
+	resp := MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, 1, NewHTMLParser(t, resp.Body).doc.Find(".ui.error.message").Length())
+	req = NewRequest(t, "GET", "/user2/repo1/search?q=.%2A&t=regexp")
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, 1, NewHTMLParser(t, resp.Body).doc.Find(".ui.error.message").Length())
 
 	setting.Indexer.IncludePatterns = setting.IndexerGlobFromString("**.txt")
 	setting.Indexer.ExcludePatterns = setting.IndexerGlobFromString("**/y/**")
@@ -54,7 +65,7 @@ func TestSearchRepo(t *testing.T) {
 }
 
 func testSearch(t *testing.T, url string, expected []string) {
-	req := NewRequestf(t, "GET", url)
+	req := NewRequest(t, "GET", url)
 	resp := MakeRequest(t, req, http.StatusOK)
 
 	filenames := resultFilenames(t, NewHTMLParser(t, resp.Body))
@@ -0,0 +1,264 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/saml"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSAMLIdPEntityID = "https://idp.example.com/metadata"
+
+// testSAMLIdP is a minimal identity provider issuing signed responses to AuthnRequests
+type testSAMLIdP struct {
+	keyPair tls.Certificate
+	certPEM string
+}
+
+func newTestSAMLIdP(t *testing.T) *testSAMLIdP {
+	cert, key, err := saml.GenerateServiceProviderCertificate("idp.example.com")
+	assert.NoError(t, err)
+	keyPair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	assert.NoError(t, err)
+	return &testSAMLIdP{keyPair: keyPair, certPEM: cert}
+}
+
+func (idp *testSAMLIdP) metadata() string {
+	block, _ := pem.Decode([]byte(idp.certPEM))
+	return fmt.Sprintf(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <KeyDescriptor use="signing">
+      <KeyInfo xmlns="http://www.w3.org/2000/09/xmldsig#">
+        <X509Data><X509Certificate>%s</X509Certificate></X509Data>
+      </KeyInfo>
+    </KeyDescriptor>
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`, testSAMLIdPEntityID, base64.StdEncoding.EncodeToString(block.Bytes))
+}
+
+// requestID decodes the AuthnRequest of a HTTP-Redirect binding URL and returns its ID
+func (idp *testSAMLIdP) requestID(t *testing.T, redirectURL string) string {
+	u, err := url.Parse(redirectURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "idp.example.com", u.Host)
+
+	compressed, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	assert.NoError(t, err)
+	raw, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	assert.NoError(t, err)
+
+	var request struct {
+		ID                          string `xml:"ID,attr"`
+		AssertionConsumerServiceURL string `xml:"AssertionConsumerServiceURL,attr"`
+	}
+	assert.NoError(t, xml.Unmarshal(raw, &request))
+	assert.Equal(t, setting.AppURL+"user/saml/saml/acs", request.AssertionConsumerServiceURL)
+	return request.ID
+}
+
+// response returns a base64 encoded response to the request, signed if sign is true
+func (idp *testSAMLIdP) response(t *testing.T, requestID, nameID string, attributes map[string][]string, sign bool) string {
+	baseURL := setting.AppURL + "user/saml/saml"
+	now := time.Now().UTC()
+	notBefore := now.Add(-time.Minute).Format(time.RFC3339)
+	notOnOrAfter := now.Add(5 * time.Minute).Format(time.RFC3339)
+	id := now.UnixNano()
+
+	var attributeStatement strings.Builder
+	for name, values := range attributes {
+		fmt.Fprintf(&attributeStatement, `<saml:Attribute Name="%s">`, name)
+		for _, value := range values {
+			fmt.Fprintf(&attributeStatement, `<saml:AttributeValue>%s</saml:AttributeValue>`, value)
+		}
+		attributeStatement.WriteString(`</saml:Attribute>`)
+	}
+
+	response := fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response%[9]d" Version="2.0" IssueInstant="%[1]s" Destination="%[2]s/acs" InResponseTo="%[3]s">
+<saml:Issuer>%[4]s</saml:Issuer>
+<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+<saml:Assertion ID="_assertion%[9]d" Version="2.0" IssueInstant="%[1]s">
+<saml:Issuer>%[4]s</saml:Issuer>
+<saml:Subject>
+<saml:NameID>%[5]s</saml:NameID>
+<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer"><saml:SubjectConfirmationData InResponseTo="%[3]s" NotOnOrAfter="%[6]s" Recipient="%[2]s/acs"/></saml:SubjectConfirmation>
+</saml:Subject>
+<saml:Conditions NotBefore="%[7]s" NotOnOrAfter="%[6]s"><saml:AudienceRestriction><saml:Audience>%[2]s/metadata</saml:Audience></saml:AudienceRestriction></saml:Conditions>
+<saml:AuthnStatement AuthnInstant="%[1]s"><saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:Password</saml:AuthnContextClassRef></saml:AuthnContext></saml:AuthnStatement>
+<saml:AttributeStatement>%[8]s</saml:AttributeStatement>
+</saml:Assertion>
+</samlp:Response>`, now.Format(time.RFC3339), baseURL, requestID, testSAMLIdPEntityID, nameID, notOnOrAfter, notBefore, attributeStatement.String(), id)
+
+	doc := etree.NewDocument()
+	assert.NoError(t, doc.ReadFromString(response))
+	if sign {
+		signed, err := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(idp.keyPair)).SignEnveloped(doc.Root())
+		assert.NoError(t, err)
+		doc.SetRoot(signed)
+	}
+	raw, err := doc.WriteToBytes()
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func addAuthSourceSAML(t *testing.T, idp *testSAMLIdP, values map[string]string) {
+	session := loginUser(t, "user1")
+	csrf := GetCSRF(t, session, "/admin/auths/new")
+	form := map[string]string{
+		"_csrf":                    csrf,
+		"type":                     "8",
+		"name":                     "saml",
+		"saml_metadata":            idp.metadata(),
+		"saml_name_id_format":      saml.NameIDFormatUnspecified,
+		"saml_attribute_username":  "uid",
+		"saml_attribute_email":     "mail",
+		"saml_attribute_full_name": "cn",
+		"saml_attribute_groups":    "groups",
+		"is_active":                "on",
+	}
+	for key, value := range values {
+		form[key] = value
+	}
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", form)
+	session.MakeRequest(t, req, http.StatusFound)
+}
+
+func samlSignIn(t *testing.T, idp *testSAMLIdP, nameID string, attributes map[string][]string, sign bool) (*TestSession, *httptest.ResponseRecorder) {
+	session := emptyTestSession(t)
+	req := NewRequest(t, "GET", "/user/saml/saml")
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	requestID := idp.requestID(t, resp.Header().Get("Location"))
+
+	req = NewRequestWithValues(t, "POST", "/user/saml/saml/acs", map[string]string{
+		"SAMLResponse": idp.response(t, requestID, nameID, attributes, sign),
+	})
+	return session, session.MakeRequest(t, req, http.StatusFound)
+}
+
+func TestSAMLMetadata(t *testing.T) {
+	defer prepareTestEnv(t)()
+	idp := newTestSAMLIdP(t)
+	addAuthSourceSAML(t, idp, nil)
+
+	req := NewRequest(t, "GET", "/user/saml/saml/metadata")
+	resp := MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "application/samlmetadata+xml", resp.Header().Get("Content-Type"))
+	assert.Contains(t, resp.Body.String(), fmt.Sprintf(`entityID="%suser/saml/saml/metadata"`, setting.AppURL))
+	assert.Contains(t, resp.Body.String(), fmt.Sprintf(`Location="%suser/saml/saml/acs"`, setting.AppURL))
+
+	req = NewRequest(t, "GET", "/user/saml/unknown/metadata")
+	MakeRequest(t, req, http.StatusNotFound)
+
+	// the private key of the service provider is stored as secret
+	source := models.AssertExistsAndLoadBean(t, &models.LoginSource{Name: "saml"}).(*models.LoginSource)
+	assert.Contains(t, source.SAML().ServiceProviderPrivateKey, "PRIVATE KEY")
+	models.AssertExistsAndLoadBean(t, &models.Secret{Name: fmt.Sprintf("saml-private-key-%d", source.ID), IsInternal: true})
+}
+
+func TestSAMLAutoRegistration(t *testing.T) {
+	defer prepareTestEnv(t)()
+	idp := newTestSAMLIdP(t)
+	addAuthSourceSAML(t, idp, map[string]string{
+		"saml_auto_registration": "on",
+		"saml_required_group":    "git",
+		"saml_admin_group":       "admins",
+	})
+	source, err := models.GetActiveSAMLLoginSourceByName("saml")
+	assert.NoError(t, err)
+
+	attributes := map[string][]string{
+		"uid":    {"fry"},
+		"mail":   {"fry@planetexpress.com"},
+		"cn":     {"Philip J. Fry"},
+		"groups": {"git"},
+	}
+	session, resp := samlSignIn(t, idp, "fry-id", attributes, true)
+	assert.Equal(t, "/", resp.Header().Get("Location"))
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{Name: "fry"}).(*models.User)
+	assert.Equal(t, "fry@planetexpress.com", user.Email)
+	assert.Equal(t, "Philip J. Fry", user.FullName)
+	assert.Equal(t, models.LoginSAML, user.LoginType)
+	assert.Equal(t, source.ID, user.LoginSource)
+	assert.Equal(t, "fry-id", user.LoginName)
+	assert.False(t, user.IsAdmin)
+
+	req := NewRequest(t, "GET", "/user/settings")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "fry", NewHTMLParser(t, resp.Body).GetInputValueByName("name"))
+
+	// signing in again reuses the linked user and syncs the admin flag
+	_, resp = samlSignIn(t, idp, "fry-id", map[string][]string{"uid": {"fry"}, "groups": {"git", "admins"}}, true)
+	assert.Equal(t, "/", resp.Header().Get("Location"))
+	models.AssertExistsAndLoadBean(t, &models.User{ID: user.ID, IsAdmin: true})
+
+	// responses are only accepted once
+	req = NewRequest(t, "GET", "/user/saml/saml")
+	resp = emptyTestSession(t).MakeRequest(t, req, http.StatusFound)
+	samlResponse := idp.response(t, idp.requestID(t, resp.Header().Get("Location")), "fry-id", attributes, true)
+	for _, location := range []string{"/", "/user/login"} {
+		req = NewRequestWithValues(t, "POST", "/user/saml/saml/acs", map[string]string{
+			"SAMLResponse": samlResponse,
+		})
+		resp = emptyTestSession(t).MakeRequest(t, req, http.StatusFound)
+		assert.Equal(t, location, resp.Header().Get("Location"))
+	}
+}
+
+func TestSAMLRejectedResponses(t *testing.T) {
+	defer prepareTestEnv(t)()
+	idp := newTestSAMLIdP(t)
+	addAuthSourceSAML(t, idp, map[string]string{
+		"saml_auto_registration": "on",
+		"saml_required_group":    "git",
+	})
+	attributes := map[string][]string{
+		"uid":    {"bender"},
+		"mail":   {"bender@planetexpress.com"},
+		"groups": {"git"},
+	}
+
+	// unsigned response
+	_, resp := samlSignIn(t, idp, "bender-id", attributes, false)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+
+	// response signed by another identity provider
+	_, resp = samlSignIn(t, newTestSAMLIdP(t), "bender-id", attributes, true)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+
+	// response to a request that was not issued by us
+	session := emptyTestSession(t)
+	req := NewRequestWithValues(t, "POST", "/user/saml/saml/acs", map[string]string{
+		"SAMLResponse": idp.response(t, "_forged", "bender-id", attributes, true),
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+
+	// user not in the required group
+	_, resp = samlSignIn(t, idp, "bender-id", map[string][]string{"uid": {"bender"}, "mail": {"bender@planetexpress.com"}}, true)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+
+	models.AssertNotExistsBean(t, &models.User{Name: "bender"})
+}
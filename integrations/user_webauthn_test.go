@@ -0,0 +1,208 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// softAuthenticator emulates a security key holding a single resident key
+type softAuthenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	userID    []byte
+	signCount uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	assert.NoError(t, err)
+	return &softAuthenticator{key: key, id: id}
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge string) []byte {
+	appURL, err := url.Parse(setting.AppURL)
+	assert.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":      string(ceremony),
+		"challenge": challenge,
+		"origin":    appURL.Scheme + "://" + appURL.Host,
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(attested []byte) []byte {
+	appURL, _ := url.Parse(setting.AppURL)
+	rpIDHash := sha256.Sum256([]byte(appURL.Hostname()))
+	flags := protocol.FlagUserPresent | protocol.FlagUserVerified
+	if attested != nil {
+		flags |= protocol.FlagAttestedCredentialData
+	}
+	a.signCount++
+
+	buf := bytes.NewBuffer(rpIDHash[:])
+	buf.WriteByte(byte(flags))
+	_ = binary.Write(buf, binary.BigEndian, a.signCount)
+	buf.Write(attested)
+	return buf.Bytes()
+}
+
+func (a *softAuthenticator) create(t *testing.T, options *protocol.CredentialCreation) map[string]interface{} {
+	a.userID = options.Response.User.ID
+
+	publicKey, err := cbor.Marshal(&webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1,
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	assert.NoError(t, err)
+
+	attested := bytes.NewBuffer(make([]byte, 16)) // AAGUID
+	_ = binary.Write(attested, binary.BigEndian, uint16(len(a.id)))
+	attested.Write(a.id)
+	attested.Write(publicKey)
+
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(attested.Bytes()),
+	})
+	assert.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	return map[string]interface{}{
+		"id":    enc(a.id),
+		"rawId": enc(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"attestationObject": enc(attestationObject),
+			"clientDataJSON":    enc(a.clientData(t, protocol.CreateCeremony, options.Response.Challenge.String())),
+		},
+	}
+}
+
+func (a *softAuthenticator) get(t *testing.T, options *protocol.CredentialAssertion) map[string]interface{} {
+	authData := a.authData(nil)
+	clientData := a.clientData(t, protocol.AssertCeremony, options.Response.Challenge.String())
+	clientDataHash := sha256.Sum256(clientData)
+	signed := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	assert.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	return map[string]interface{}{
+		"id":    enc(a.id),
+		"rawId": enc(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"authenticatorData": enc(authData),
+			"clientDataJSON":    enc(clientData),
+			"signature":         enc(signature),
+			"userHandle":        enc(a.userID),
+		},
+	}
+}
+
+func TestWebAuthn(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// user24 is enrolled in TOTP, which is required to add security keys
+	twofa, err := models.GetTwoFactorByUID(24)
+	assert.NoError(t, err)
+	assert.NoError(t, twofa.SetSecret("JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, models.UpdateTwoFactor(twofa))
+
+	session := loginUser(t, "user24")
+	passcode, err := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	assert.NoError(t, err)
+	req := NewRequestWithValues(t, "POST", "/user/two_factor", map[string]string{
+		"_csrf":    GetCSRF(t, session, "/user/two_factor"),
+		"passcode": passcode,
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	// register a resident key
+	csrf := GetCSRF(t, session, "/user/settings/security")
+	req = NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/request_register", map[string]string{
+		"_csrf":        csrf,
+		"name":         "My Key",
+		"passwordless": "true",
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var creation protocol.CredentialCreation
+	DecodeJSON(t, resp, &creation)
+	if assert.NotNil(t, creation.Response.AuthenticatorSelection.RequireResidentKey) {
+		assert.True(t, *creation.Response.AuthenticatorSelection.RequireResidentKey)
+	}
+
+	authenticator := newSoftAuthenticator(t)
+	req = NewRequestWithJSON(t, "POST", "/user/settings/security/webauthn/register", authenticator.create(t, &creation))
+	req.Header.Add("X-Csrf-Token", csrf)
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: 24, Name: "My Key"})
+
+	// names must be unique
+	req = NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/request_register", map[string]string{
+		"_csrf": csrf,
+		"name":  "my key",
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	// signing in with the password now asks for the security key
+	req = NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+		"_csrf":     GetCSRF(t, emptyTestSession(t), "/user/login"),
+		"user_name": "user24",
+		"password":  userPassword,
+	})
+	resp = MakeRequest(t, req, http.StatusFound)
+	assert.EqualValues(t, setting.AppSubURL+"/user/webauthn", resp.Header().Get("Location"))
+
+	// passwordless sign in
+	session = emptyTestSession(t)
+	csrf = GetCSRF(t, session, "/user/login")
+	req = NewRequest(t, "GET", "/user/webauthn/passwordless/assertion")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var assertion protocol.CredentialAssertion
+	DecodeJSON(t, resp, &assertion)
+	assert.Empty(t, assertion.Response.AllowedCredentials)
+
+	req = NewRequestWithJSON(t, "POST", "/user/webauthn/passwordless/assertion", authenticator.get(t, &assertion))
+	req.Header.Add("X-Csrf-Token", csrf)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.True(t, strings.HasPrefix(resp.Body.String(), setting.AppSubURL+"/"))
+	session.MakeRequest(t, NewRequest(t, "GET", "/user/settings"), http.StatusOK)
+
+	cred := models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: 24, Name: "My Key"}).(*models.WebAuthnCredential)
+	assert.EqualValues(t, 2, cred.SignCount)
+}
@@ -40,6 +40,8 @@ type ProtectedBranch struct {
 	BlockOnRejectedReviews        bool     `xorm:"NOT NULL DEFAULT false"`
 	BlockOnOfficialReviewRequests bool     `xorm:"NOT NULL DEFAULT false"`
 	BlockOnOutdatedBranch         bool     `xorm:"NOT NULL DEFAULT false"`
+	RequireCodeOwnerApproval      bool     `xorm:"NOT NULL DEFAULT false"`
+	EnableMergeQueue              bool     `xorm:"NOT NULL DEFAULT false"`
 	DismissStaleApprovals         bool     `xorm:"NOT NULL DEFAULT false"`
 	RequireSignedCommits          bool     `xorm:"NOT NULL DEFAULT false"`
 	ProtectedFilePatterns         string   `xorm:"TEXT"`
@@ -212,6 +214,29 @@ func (protectBranch *ProtectedBranch) MergeBlockedByOutdatedBranch(pr *PullReque
 	return protectBranch.BlockOnOutdatedBranch && pr.CommitsBehind > 0
 }
 
+// MatchStatusCheckContext returns true if a required status check context matches the context of a commit status.
+// Required contexts may be glob patterns like "ci/*", anything which is no valid pattern has to match exactly.
+func MatchStatusCheckContext(required, context string) bool {
+	if required == context {
+		return true
+	}
+	g, err := glob.Compile(required)
+	if err != nil {
+		return false
+	}
+	return g.Match(context)
+}
+
+// IsStatusCheckContextRequired returns true if a commit status with the context is required to pass
+func (protectBranch *ProtectedBranch) IsStatusCheckContextRequired(context string) bool {
+	for _, required := range protectBranch.StatusCheckContexts {
+		if MatchStatusCheckContext(required, context) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetProtectedFilePatterns parses a semicolon separated list of protected file patterns and returns a glob.Glob slice
 func (protectBranch *ProtectedBranch) GetProtectedFilePatterns() []glob.Glob {
 	extarr := make([]glob.Glob, 0, 10)
@@ -0,0 +1,251 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// CheckRun represents a run of a check, e.g. a CI job, on a commit of a repository.
+// Its state is mirrored into a CommitStatus with the name of the run as context.
+type CheckRun struct {
+	ID         int64                  `xorm:"pk autoincr"`
+	RepoID     int64                  `xorm:"INDEX(s) NOT NULL"`
+	Repo       *Repository            `xorm:"-"`
+	HeadSHA    string                 `xorm:"VARCHAR(64) INDEX(s) NOT NULL"`
+	Name       string                 `xorm:"NOT NULL"`
+	Status     api.CheckRunStatus     `xorm:"VARCHAR(20) NOT NULL"`
+	Conclusion api.CheckRunConclusion `xorm:"VARCHAR(20)"`
+	DetailsURL string                 `xorm:"TEXT"`
+	ExternalID string
+	CreatorID  int64
+	Creator    *User `xorm:"-"`
+
+	// Title, Summary and Text make up the report of the run, Summary and Text are markdown
+	Title            string
+	Summary          string `xorm:"LONGTEXT"`
+	Text             string `xorm:"LONGTEXT"`
+	AnnotationsCount int64  `xorm:"NOT NULL DEFAULT 0"`
+
+	StartedUnix   timeutil.TimeStamp
+	CompletedUnix timeutil.TimeStamp
+	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+}
+
+// CheckRunAnnotation represents a remark of a check run about some lines of a file of the checked commit
+type CheckRunAnnotation struct {
+	ID         int64                    `xorm:"pk autoincr"`
+	CheckRunID int64                    `xorm:"INDEX NOT NULL"`
+	CheckRun   *CheckRun                `xorm:"-"`
+	Path       string                   `xorm:"TEXT NOT NULL"`
+	StartLine  int64                    `xorm:"NOT NULL"`
+	EndLine    int64                    `xorm:"NOT NULL"`
+	Level      api.CheckAnnotationLevel `xorm:"VARCHAR(10) NOT NULL"`
+	Title      string
+	Message    string `xorm:"TEXT"`
+	RawDetails string `xorm:"TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// LoadAttributes loads the repository and the creator of the check run
+func (run *CheckRun) LoadAttributes() (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = GetRepositoryByID(run.RepoID); err != nil {
+			return fmt.Errorf("GetRepositoryByID [%d]: %v", run.RepoID, err)
+		}
+	}
+	if run.Creator == nil && run.CreatorID > 0 {
+		if run.Creator, err = GetUserByID(run.CreatorID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("GetUserByID [%d]: %v", run.CreatorID, err)
+			}
+			run.Creator = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// HTMLURL returns the absolute URL to the page of the check run
+func (run *CheckRun) HTMLURL() string {
+	return fmt.Sprintf("%s/checks/%d", run.Repo.HTMLURL(), run.ID)
+}
+
+// APIURL returns the absolute API URL of the check run
+func (run *CheckRun) APIURL() string {
+	return fmt.Sprintf("%sapi/v1/repos/%s/check-runs/%d", setting.AppURL, run.Repo.FullName(), run.ID)
+}
+
+// IsCompleted returns true if the check run has finished
+func (run *CheckRun) IsCompleted() bool {
+	return run.Status == api.CheckRunStatusCompleted
+}
+
+// CommitStatusState returns the state of the commit status which mirrors the check run
+func (run *CheckRun) CommitStatusState() api.CommitStatusState {
+	if !run.IsCompleted() {
+		return api.CommitStatusPending
+	}
+	switch run.Conclusion {
+	case api.CheckRunConclusionSuccess, api.CheckRunConclusionNeutral, api.CheckRunConclusionSkipped:
+		return api.CommitStatusSuccess
+	case api.CheckRunConclusionCancelled:
+		return api.CommitStatusError
+	}
+	return api.CommitStatusFailure
+}
+
+// CreateCheckRun inserts a check run together with its annotations
+func CreateCheckRun(run *CheckRun, annotations []*CheckRunAnnotation) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	run.AnnotationsCount = int64(len(annotations))
+	if _, err := sess.Insert(run); err != nil {
+		return err
+	}
+	if err := insertCheckRunAnnotations(sess, run, annotations); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// UpdateCheckRun updates the given columns of a check run and adds annotations to it
+func UpdateCheckRun(run *CheckRun, annotations []*CheckRunAnnotation, cols ...string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if len(annotations) > 0 {
+		run.AnnotationsCount += int64(len(annotations))
+		cols = append(cols, "annotations_count")
+	}
+	if _, err := sess.ID(run.ID).Cols(cols...).Update(run); err != nil {
+		return err
+	}
+	if err := insertCheckRunAnnotations(sess, run, annotations); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func insertCheckRunAnnotations(e Engine, run *CheckRun, annotations []*CheckRunAnnotation) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	for _, annotation := range annotations {
+		annotation.CheckRunID = run.ID
+		if annotation.EndLine < annotation.StartLine {
+			annotation.EndLine = annotation.StartLine
+		}
+	}
+	_, err := e.Insert(&annotations)
+	return err
+}
+
+// GetCheckRunByID returns the check run of a repository by its ID
+func GetCheckRunByID(repoID, id int64) (*CheckRun, error) {
+	run := new(CheckRun)
+	has, err := x.ID(id).Where("repo_id = ?", repoID).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCheckRunNotExist{ID: id, RepoID: repoID}
+	}
+	return run, nil
+}
+
+// FindCheckRunsOptions holds the options for listing the check runs of a commit
+type FindCheckRunsOptions struct {
+	ListOptions
+	RepoID  int64
+	HeadSHA string
+	Name    string
+	Status  api.CheckRunStatus
+}
+
+func (opts *FindCheckRunsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if len(opts.HeadSHA) > 0 {
+		cond = cond.And(builder.Eq{"head_sha": opts.HeadSHA})
+	}
+	if len(opts.Name) > 0 {
+		cond = cond.And(builder.Eq{"name": opts.Name})
+	}
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.Eq{"status": opts.Status})
+	}
+	return cond
+}
+
+// FindCheckRuns returns the check runs matching the options, the latest first, and their total count
+func FindCheckRuns(opts FindCheckRunsOptions) ([]*CheckRun, int64, error) {
+	count, err := x.Where(opts.toConds()).Count(new(CheckRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(opts.toConds()).Desc("id")
+	if opts.Page > 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	runs := make([]*CheckRun, 0, opts.PageSize)
+	return runs, count, sess.Find(&runs)
+}
+
+// GetCheckRunAnnotations returns the annotations of a check run ordered by file and line
+func GetCheckRunAnnotations(checkRunID int64, listOptions ListOptions) ([]*CheckRunAnnotation, error) {
+	sess := x.Where("check_run_id = ?", checkRunID).Asc("path", "start_line", "id")
+	if listOptions.Page > 0 {
+		sess = listOptions.setSessionPagination(sess)
+	}
+	annotations := make([]*CheckRunAnnotation, 0, listOptions.PageSize)
+	return annotations, sess.Find(&annotations)
+}
+
+// GetLatestCheckRunAnnotations returns the annotations of the latest run of each check of a commit
+// with their check runs loaded
+func GetLatestCheckRunAnnotations(repoID int64, sha string) ([]*CheckRunAnnotation, error) {
+	runs := make([]*CheckRun, 0, 5)
+	latestRuns := builder.Select("MAX(id)").From("check_run").
+		Where(builder.Eq{"repo_id": repoID, "head_sha": sha}).GroupBy("name")
+	if err := x.Where(builder.In("id", latestRuns)).And("annotations_count > 0").Find(&runs); err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	runsByID := make(map[int64]*CheckRun, len(runs))
+	ids := make([]int64, 0, len(runs))
+	for _, run := range runs {
+		runsByID[run.ID] = run
+		ids = append(ids, run.ID)
+	}
+
+	annotations := make([]*CheckRunAnnotation, 0, 10)
+	if err := x.In("check_run_id", ids).Asc("path", "start_line", "id").Find(&annotations); err != nil {
+		return nil, err
+	}
+	for _, annotation := range annotations {
+		annotation.CheckRun = runsByID[annotation.CheckRunID]
+	}
+	return annotations, nil
+}
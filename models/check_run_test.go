@@ -0,0 +1,74 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	const sha = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	run := &CheckRun{RepoID: 1, HeadSHA: sha, Name: "lint", Status: api.CheckRunStatusInProgress}
+	assert.NoError(t, CreateCheckRun(run, []*CheckRunAnnotation{
+		{Path: "README.md", StartLine: 2, Level: api.CheckAnnotationLevelWarning, Message: "warning"},
+	}))
+	assert.EqualValues(t, 1, run.AnnotationsCount)
+	assert.EqualValues(t, api.CommitStatusPending, run.CommitStatusState())
+
+	run.Status, run.Conclusion = api.CheckRunStatusCompleted, api.CheckRunConclusionNeutral
+	assert.NoError(t, UpdateCheckRun(run, []*CheckRunAnnotation{
+		{Path: "README.md", StartLine: 1, EndLine: 3, Level: api.CheckAnnotationLevelNotice, Message: "notice"},
+	}, "status", "conclusion"))
+	assert.EqualValues(t, api.CommitStatusSuccess, run.CommitStatusState())
+
+	run = AssertExistsAndLoadBean(t, &CheckRun{ID: run.ID}).(*CheckRun)
+	assert.EqualValues(t, api.CheckRunStatusCompleted, run.Status)
+	assert.EqualValues(t, 2, run.AnnotationsCount)
+
+	annotations, err := GetCheckRunAnnotations(run.ID, ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, annotations, 2) {
+		assert.EqualValues(t, 1, annotations[0].StartLine)
+		assert.EqualValues(t, 2, annotations[1].EndLine)
+	}
+
+	// only the annotations of the latest run of each check count
+	rerun := &CheckRun{RepoID: 1, HeadSHA: sha, Name: "lint", Status: api.CheckRunStatusQueued}
+	assert.NoError(t, CreateCheckRun(rerun, nil))
+	other := &CheckRun{RepoID: 1, HeadSHA: sha, Name: "vet", Status: api.CheckRunStatusQueued}
+	assert.NoError(t, CreateCheckRun(other, []*CheckRunAnnotation{
+		{Path: "README.md", StartLine: 1, Level: api.CheckAnnotationLevelFailure, Message: "failure"},
+	}))
+	annotations, err = GetLatestCheckRunAnnotations(1, sha)
+	assert.NoError(t, err)
+	if assert.Len(t, annotations, 1) {
+		assert.EqualValues(t, other.ID, annotations[0].CheckRun.ID)
+	}
+
+	runs, count, err := FindCheckRuns(FindCheckRunsOptions{RepoID: 1, HeadSHA: sha, Name: "lint"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, runs, 2) {
+		assert.EqualValues(t, rerun.ID, runs[0].ID)
+	}
+
+	_, err = GetCheckRunByID(2, run.ID)
+	assert.True(t, IsErrCheckRunNotExist(err))
+}
+
+func TestMatchStatusCheckContext(t *testing.T) {
+	assert.True(t, MatchStatusCheckContext("ci/build", "ci/build"))
+	assert.True(t, MatchStatusCheckContext("ci/*", "ci/build"))
+	assert.True(t, MatchStatusCheckContext("ci/*", "ci/build/linux"))
+	assert.False(t, MatchStatusCheckContext("ci/*", "lint"))
+	assert.True(t, MatchStatusCheckContext("[invalid", "[invalid"))
+	assert.False(t, MatchStatusCheckContext("[invalid", "invalid"))
+}
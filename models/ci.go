@@ -0,0 +1,283 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/ci"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// CIRun represents a run of a workflow of a repository triggered by a push or a pull request
+type CIRun struct {
+	ID            int64       `xorm:"pk autoincr"`
+	RepoID        int64       `xorm:"INDEX NOT NULL"`
+	Repo          *Repository `xorm:"-"`
+	WorkflowFile  string
+	Name          string
+	Event         string
+	Ref           string
+	CommitSHA     string `xorm:"VARCHAR(40) INDEX"`
+	TriggerUserID int64
+	TriggerUser   *User `xorm:"-"`
+	PullID        int64
+	Status        api.CIStatus `xorm:"VARCHAR(20) NOT NULL"`
+	Jobs          []*CIJob     `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// CIJob represents a job of a run of a workflow, which is handed to a runner
+type CIJob struct {
+	ID        int64             `xorm:"pk autoincr"`
+	RunID     int64             `xorm:"INDEX NOT NULL"`
+	Run       *CIRun            `xorm:"-"`
+	RepoID    int64             `xorm:"INDEX NOT NULL"`
+	Name      string            `xorm:"NOT NULL"`
+	RunsOn    []string          `xorm:"JSON TEXT"`
+	Env       map[string]string `xorm:"JSON TEXT"`
+	Steps     []*ci.Step        `xorm:"JSON TEXT"`
+	Status    api.CIStatus      `xorm:"VARCHAR(20) INDEX NOT NULL"`
+	RunnerID  int64             `xorm:"INDEX"`
+	LogChunks int               `xorm:"NOT NULL DEFAULT 0"`
+
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// LoadAttributes loads the repository and the user who triggered the run
+func (run *CIRun) LoadAttributes() (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = GetRepositoryByID(run.RepoID); err != nil {
+			return fmt.Errorf("GetRepositoryByID [%d]: %v", run.RepoID, err)
+		}
+	}
+	if run.TriggerUser == nil {
+		if run.TriggerUser, err = GetUserByID(run.TriggerUserID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("GetUserByID [%d]: %v", run.TriggerUserID, err)
+			}
+			run.TriggerUser = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// LoadJobs loads the jobs of the run
+func (run *CIRun) LoadJobs() error {
+	if run.Jobs != nil {
+		return nil
+	}
+	run.Jobs = make([]*CIJob, 0, 2)
+	if err := x.Where("run_id = ?", run.ID).Asc("id").Find(&run.Jobs); err != nil {
+		return err
+	}
+	for _, job := range run.Jobs {
+		job.Run = run
+	}
+	return nil
+}
+
+// HTMLURL returns the absolute URL to the jobs of the run
+func (run *CIRun) HTMLURL() string {
+	return fmt.Sprintf("%s/ci/runs/%d", run.Repo.HTMLURL(), run.ID)
+}
+
+// LoadRun loads the run of the job with its attributes
+func (job *CIJob) LoadRun() (err error) {
+	if job.Run == nil {
+		if job.Run, err = GetCIRunByID(job.RepoID, job.RunID); err != nil {
+			return err
+		}
+	}
+	return job.Run.LoadAttributes()
+}
+
+// HTMLURL returns the absolute URL to the page with the log of the job, the run has to be loaded
+func (job *CIJob) HTMLURL() string {
+	return fmt.Sprintf("%s/ci/jobs/%d", job.Run.Repo.HTMLURL(), job.ID)
+}
+
+// CommitStatusContext returns the context of the commit status which reports the job, the run has to be loaded
+func (job *CIJob) CommitStatusContext() string {
+	return fmt.Sprintf("%s / %s (%s)", job.Run.Name, job.Name, job.Run.Event)
+}
+
+// LogChunkPath returns the path of a chunk of the log of the job in the CI logs storage
+func (job *CIJob) LogChunkPath(index int) string {
+	return fmt.Sprintf("%d/%d/%d.log", job.RepoID, job.ID, index)
+}
+
+// CreateCIRun inserts a run of a workflow together with its jobs
+func CreateCIRun(run *CIRun, jobs []*CIJob) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	run.Status = api.CIStatusWaiting
+	if _, err := sess.Insert(run); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		job.RunID = run.ID
+		job.Run = run
+		job.RepoID = run.RepoID
+		job.Status = api.CIStatusWaiting
+		if _, err := sess.Insert(job); err != nil {
+			return err
+		}
+	}
+	run.Jobs = jobs
+	return sess.Commit()
+}
+
+// GetCIRunByID returns a run of a workflow of a repository
+func GetCIRunByID(repoID, id int64) (*CIRun, error) {
+	run := new(CIRun)
+	has, err := x.ID(id).Where("repo_id = ?", repoID).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIRunNotExist{ID: id, RepoID: repoID}
+	}
+	return run, nil
+}
+
+// GetCIRuns returns the runs of the workflows of a repository, the latest first
+func GetCIRuns(repoID int64, listOptions ListOptions) ([]*CIRun, int64, error) {
+	count, err := x.Where("repo_id = ?", repoID).Count(new(CIRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where("repo_id = ?", repoID).Desc("id")
+	if listOptions.Page > 0 {
+		sess = listOptions.setSessionPagination(sess)
+	}
+	runs := make([]*CIRun, 0, listOptions.PageSize)
+	return runs, count, sess.Find(&runs)
+}
+
+// GetCIJobByID returns a job by its ID, a repoID of 0 matches the jobs of all repositories
+func GetCIJobByID(repoID, id int64) (*CIJob, error) {
+	job := new(CIJob)
+	sess := x.ID(id)
+	if repoID > 0 {
+		sess = sess.Where("repo_id = ?", repoID)
+	}
+	has, err := sess.Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIJobNotExist{ID: id}
+	}
+	return job, nil
+}
+
+// GetWaitingCIJobIDs returns the IDs of the jobs which wait for a runner, the oldest first
+func GetWaitingCIJobIDs() ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("ci_job").Where("status = ?", api.CIStatusWaiting).Asc("id").Cols("id").Find(&ids)
+}
+
+// AssignCIJob hands a waiting job to the runner, it returns nil if the job is not waiting anymore
+func AssignCIJob(runner *CIRunner, id int64) (*CIJob, error) {
+	// Another runner may have been handed the job meanwhile
+	cnt, err := x.ID(id).Where("status = ?", api.CIStatusWaiting).
+		Cols("status", "runner_id", "started_unix").
+		Update(&CIJob{Status: api.CIStatusRunning, RunnerID: runner.ID, StartedUnix: timeutil.TimeStampNow()})
+	if err != nil {
+		return nil, err
+	} else if cnt == 0 {
+		return nil, nil
+	}
+	job, err := GetCIJobByID(0, id)
+	if err != nil {
+		return nil, err
+	}
+	return job, updateCIRunStatus(x, job.RunID)
+}
+
+// AllocateCIJobLogChunk reserves the index of the next chunk of the log of a job.
+// The counter is incremented by the database, so concurrent uploads never get the same index.
+func AllocateCIJobLogChunk(job *CIJob) (int, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return 0, err
+	}
+
+	if _, err := sess.ID(job.ID).Incr("log_chunks").Update(new(CIJob)); err != nil {
+		return 0, err
+	}
+	var chunks int
+	if has, err := sess.Table("ci_job").Where("id = ?", job.ID).Cols("log_chunks").Get(&chunks); err != nil {
+		return 0, err
+	} else if !has {
+		return 0, ErrCIJobNotExist{ID: job.ID}
+	}
+	if err := sess.Commit(); err != nil {
+		return 0, err
+	}
+	job.LogChunks = chunks
+	return chunks - 1, nil
+}
+
+// FinishCIJob records the result of a job and updates the status of its run
+func FinishCIJob(job *CIJob, status api.CIStatus) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	job.Status = status
+	job.StoppedUnix = timeutil.TimeStampNow()
+	if _, err := sess.ID(job.ID).Cols("status", "stopped_unix").Update(job); err != nil {
+		return err
+	}
+	if err := updateCIRunStatus(sess, job.RunID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// updateCIRunStatus sums up the status of the jobs of a run: it is running until all jobs are done,
+// fails if any job failed and is cancelled if any job has been cancelled
+func updateCIRunStatus(e Engine, runID int64) error {
+	jobs := make([]*CIJob, 0, 2)
+	if err := e.Where("run_id = ?", runID).Cols("status").Find(&jobs); err != nil {
+		return err
+	}
+
+	status := api.CIStatusSuccess
+	waiting := true
+	for _, job := range jobs {
+		if job.Status != api.CIStatusWaiting {
+			waiting = false
+		}
+		switch {
+		case !job.Status.IsDone():
+			status = api.CIStatusRunning
+		case status == api.CIStatusRunning:
+		case job.Status == api.CIStatusFailure:
+			status = api.CIStatusFailure
+		case job.Status == api.CIStatusCancelled && status != api.CIStatusFailure:
+			status = api.CIStatusCancelled
+		}
+	}
+	if waiting {
+		status = api.CIStatusWaiting
+	}
+	_, err := e.ID(runID).Cols("status").Update(&CIRun{Status: status})
+	return err
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/google/uuid"
+)
+
+// CIRunner represents a registered runner which fetches CI jobs and reports their logs and results
+type CIRunner struct {
+	ID             int64 `xorm:"pk autoincr"`
+	Name           string
+	Labels         []string `xorm:"JSON TEXT"`
+	Token          string   `xorm:"-"`
+	TokenHash      string   `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+	LastOnlineUnix timeutil.TimeStamp
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+}
+
+// CanRun returns true if the runner has all labels the job asks for
+func (r *CIRunner) CanRun(job *CIJob) bool {
+	return r.HasLabels(job.RunsOn)
+}
+
+// HasLabels returns true if the runner has all of the labels
+func (r *CIRunner) HasLabels(labels []string) bool {
+	for _, label := range labels {
+		found := false
+		for _, l := range r.Labels {
+			if strings.EqualFold(l, label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateLastOnline records that the runner has just contacted the server
+func (r *CIRunner) UpdateLastOnline() error {
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err := x.ID(r.ID).Cols("last_online_unix").Update(r)
+	return err
+}
+
+// CreateCIRunner registers a runner and generates the token it authenticates with
+func CreateCIRunner(r *CIRunner) error {
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return err
+	}
+	r.TokenSalt = salt
+	r.Token = base.EncodeSha1(gouuid.New().String())
+	r.TokenHash = hashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	_, err = x.Insert(r)
+	return err
+}
+
+// GetCIRunnerByToken returns the runner authenticating with the given token
+func GetCIRunnerByToken(token string) (*CIRunner, error) {
+	if len(token) < 8 {
+		return nil, ErrCIRunnerNotExist{}
+	}
+	runners := make([]*CIRunner, 0, 1)
+	if err := x.Where("token_last_eight = ?", token[len(token)-8:]).Find(&runners); err != nil {
+		return nil, err
+	}
+	for _, r := range runners {
+		if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(hashToken(token, r.TokenSalt))) == 1 {
+			return r, nil
+		}
+	}
+	return nil, ErrCIRunnerNotExist{}
+}
+
+// GetCIRunners returns all registered runners
+func GetCIRunners() ([]*CIRunner, error) {
+	runners := make([]*CIRunner, 0, 5)
+	return runners, x.Asc("id").Find(&runners)
+}
+
+// DeleteCIRunner unregisters a runner, the jobs it is running wait for another runner again.
+// It returns the IDs of these jobs.
+func DeleteCIRunner(id int64) ([]int64, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if cnt, err := sess.ID(id).Delete(new(CIRunner)); err != nil {
+		return nil, err
+	} else if cnt == 0 {
+		return nil, ErrCIRunnerNotExist{ID: id}
+	}
+	jobIDs := make([]int64, 0, 1)
+	if err := sess.Table("ci_job").Where("runner_id = ? AND status = ?", id, api.CIStatusRunning).
+		Cols("id").Find(&jobIDs); err != nil {
+		return nil, err
+	}
+	if len(jobIDs) > 0 {
+		if _, err := sess.In("id", jobIDs).
+			Cols("runner_id", "status", "started_unix").
+			Update(&CIJob{Status: api.CIStatusWaiting}); err != nil {
+			return nil, err
+		}
+	}
+	return jobIDs, sess.Commit()
+}
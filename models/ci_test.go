@@ -0,0 +1,105 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/ci"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIJobs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	linux := &CIRunner{Name: "linux", Labels: []string{"Linux"}}
+	assert.NoError(t, CreateCIRunner(linux))
+	windows := &CIRunner{Name: "windows", Labels: []string{"windows"}}
+	assert.NoError(t, CreateCIRunner(windows))
+
+	runner, err := GetCIRunnerByToken(linux.Token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, linux.ID, runner.ID)
+	_, err = GetCIRunnerByToken("invalid token")
+	assert.True(t, IsErrCIRunnerNotExist(err))
+
+	steps := []*ci.Step{{Run: "make test"}}
+	run := &CIRun{RepoID: 1, Name: "test", Event: ci.EventPush, Ref: "refs/heads/master",
+		CommitSHA: "65f1bf27bc3bf70f64657658635e66094edbcb4d", TriggerUserID: 2}
+	jobs := []*CIJob{
+		{Name: "linux", RunsOn: []string{"linux"}, Steps: steps},
+		{Name: "any", Steps: steps},
+	}
+	assert.NoError(t, CreateCIRun(run, jobs))
+	assert.EqualValues(t, api.CIStatusWaiting, run.Status)
+
+	assert.False(t, windows.CanRun(jobs[0]))
+	assert.True(t, windows.CanRun(jobs[1]))
+	assert.True(t, linux.CanRun(jobs[0]))
+
+	ids, err := GetWaitingCIJobIDs()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{jobs[0].ID, jobs[1].ID}, ids)
+
+	job, err := AssignCIJob(windows, jobs[1].ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.EqualValues(t, jobs[1].ID, job.ID)
+		assert.EqualValues(t, windows.ID, job.RunnerID)
+		assert.EqualValues(t, api.CIStatusRunning, job.Status)
+	}
+	run = AssertExistsAndLoadBean(t, &CIRun{ID: run.ID}).(*CIRun)
+	assert.EqualValues(t, api.CIStatusRunning, run.Status)
+
+	// A job is only handed to one runner
+	job, err = AssignCIJob(linux, jobs[1].ID)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	linuxJob, err := AssignCIJob(linux, jobs[0].ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, linuxJob) {
+		assert.EqualValues(t, api.CIStatusRunning, linuxJob.Status)
+	}
+	ids, err = GetWaitingCIJobIDs()
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// Unregistering a runner lets its jobs wait for other runners
+	ids, err = DeleteCIRunner(windows.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{jobs[1].ID}, ids)
+	_, err = DeleteCIRunner(windows.ID)
+	assert.True(t, IsErrCIRunnerNotExist(err))
+	job, err = AssignCIJob(linux, jobs[1].ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.EqualValues(t, linux.ID, job.RunnerID)
+	}
+
+	index, err := AllocateCIJobLogChunk(job)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, index)
+	// A stale copy of the job still gets the next index
+	index, err = AllocateCIJobLogChunk(&CIJob{ID: job.ID})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, index)
+	assert.EqualValues(t, 2, AssertExistsAndLoadBean(t, &CIJob{ID: job.ID}).(*CIJob).LogChunks)
+
+	assert.NoError(t, FinishCIJob(job, api.CIStatusSuccess))
+	run = AssertExistsAndLoadBean(t, &CIRun{ID: run.ID}).(*CIRun)
+	assert.EqualValues(t, api.CIStatusRunning, run.Status)
+
+	assert.NoError(t, FinishCIJob(linuxJob, api.CIStatusFailure))
+	run = AssertExistsAndLoadBean(t, &CIRun{ID: run.ID}).(*CIRun)
+	assert.EqualValues(t, api.CIStatusFailure, run.Status)
+
+	runs, count, err := GetCIRuns(1, ListOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.Len(t, runs, 1)
+}
@@ -628,6 +628,21 @@ func (err ErrAccessTokenEmpty) Error() string {
 	return "access token is empty"
 }
 
+// ErrAccessTokenScopeInvalid represents a "AccessTokenScopeInvalid" kind of error.
+type ErrAccessTokenScopeInvalid struct {
+	Scope string
+}
+
+// IsErrAccessTokenScopeInvalid checks if an error is a ErrAccessTokenScopeInvalid.
+func IsErrAccessTokenScopeInvalid(err error) bool {
+	_, ok := err.(ErrAccessTokenScopeInvalid)
+	return ok
+}
+
+func (err ErrAccessTokenScopeInvalid) Error() string {
+	return fmt.Sprintf("access token scope is invalid [scope: %s]", err.Scope)
+}
+
 // ________                            .__                __  .__
 // \_____  \_______  _________    ____ |__|____________ _/  |_|__| ____   ____
 //  /   |   \_  __ \/ ___\__  \  /    \|  \___   /\__  \\   __\  |/  _ \ /    \
@@ -970,6 +985,21 @@ func (err ErrInvalidTagName) Error() string {
 	return fmt.Sprintf("release tag name is not valid [tag_name: %s]", err.TagName)
 }
 
+// ErrProtectedTagName represents a "ProtectedTagName" kind of error.
+type ErrProtectedTagName struct {
+	TagName string
+}
+
+// IsErrProtectedTagName checks if an error is a ErrProtectedTagName.
+func IsErrProtectedTagName(err error) bool {
+	_, ok := err.(ErrProtectedTagName)
+	return ok
+}
+
+func (err ErrProtectedTagName) Error() string {
+	return fmt.Sprintf("release tag name is protected [tag_name: %s]", err.TagName)
+}
+
 // ErrRepoFileAlreadyExists represents a "RepoFileAlreadyExist" kind of error.
 type ErrRepoFileAlreadyExists struct {
 	Path string
@@ -1186,6 +1216,128 @@ func (err ErrNotAllowedToMerge) Error() string {
 	return fmt.Sprintf("not allowed to merge [reason: %s]", err.Reason)
 }
 
+// ErrAlreadyScheduledToAutoMerge represents an error that the pull request is already scheduled to merge automatically
+type ErrAlreadyScheduledToAutoMerge struct {
+	PullID int64
+}
+
+// IsErrAlreadyScheduledToAutoMerge checks if an error is an ErrAlreadyScheduledToAutoMerge.
+func IsErrAlreadyScheduledToAutoMerge(err error) bool {
+	_, ok := err.(ErrAlreadyScheduledToAutoMerge)
+	return ok
+}
+
+func (err ErrAlreadyScheduledToAutoMerge) Error() string {
+	return fmt.Sprintf("pull request is already scheduled to auto merge when checks succeed [pull_id: %d]", err.PullID)
+}
+
+// ErrNotScheduledToAutoMerge represents an error that the pull request is not scheduled to merge automatically
+type ErrNotScheduledToAutoMerge struct {
+	PullID int64
+}
+
+// IsErrNotScheduledToAutoMerge checks if an error is an ErrNotScheduledToAutoMerge.
+func IsErrNotScheduledToAutoMerge(err error) bool {
+	_, ok := err.(ErrNotScheduledToAutoMerge)
+	return ok
+}
+
+func (err ErrNotScheduledToAutoMerge) Error() string {
+	return fmt.Sprintf("pull request is not scheduled to auto merge [pull_id: %d]", err.PullID)
+}
+
+// ErrAlreadyInMergeQueue represents an error that the pull request is already in the merge queue
+type ErrAlreadyInMergeQueue struct {
+	PullID int64
+}
+
+// IsErrAlreadyInMergeQueue checks if an error is an ErrAlreadyInMergeQueue.
+func IsErrAlreadyInMergeQueue(err error) bool {
+	_, ok := err.(ErrAlreadyInMergeQueue)
+	return ok
+}
+
+func (err ErrAlreadyInMergeQueue) Error() string {
+	return fmt.Sprintf("pull request is already in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// ErrNotInMergeQueue represents an error that the pull request is not in the merge queue
+type ErrNotInMergeQueue struct {
+	PullID int64
+}
+
+// IsErrNotInMergeQueue checks if an error is an ErrNotInMergeQueue.
+func IsErrNotInMergeQueue(err error) bool {
+	_, ok := err.(ErrNotInMergeQueue)
+	return ok
+}
+
+func (err ErrNotInMergeQueue) Error() string {
+	return fmt.Sprintf("pull request is not in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// ErrCheckRunNotExist represents a "CheckRunNotExist" kind of error.
+type ErrCheckRunNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrCheckRunNotExist checks if an error is a ErrCheckRunNotExist.
+func IsErrCheckRunNotExist(err error) bool {
+	_, ok := err.(ErrCheckRunNotExist)
+	return ok
+}
+
+func (err ErrCheckRunNotExist) Error() string {
+	return fmt.Sprintf("check run does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
+// ErrCIRunnerNotExist represents a "CIRunnerNotExist" kind of error.
+type ErrCIRunnerNotExist struct {
+	ID int64
+}
+
+// IsErrCIRunnerNotExist checks if an error is a ErrCIRunnerNotExist.
+func IsErrCIRunnerNotExist(err error) bool {
+	_, ok := err.(ErrCIRunnerNotExist)
+	return ok
+}
+
+func (err ErrCIRunnerNotExist) Error() string {
+	return fmt.Sprintf("CI runner does not exist [id: %d]", err.ID)
+}
+
+// ErrCIRunNotExist represents a "CIRunNotExist" kind of error.
+type ErrCIRunNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrCIRunNotExist checks if an error is a ErrCIRunNotExist.
+func IsErrCIRunNotExist(err error) bool {
+	_, ok := err.(ErrCIRunNotExist)
+	return ok
+}
+
+func (err ErrCIRunNotExist) Error() string {
+	return fmt.Sprintf("CI run does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
+// ErrCIJobNotExist represents a "CIJobNotExist" kind of error.
+type ErrCIJobNotExist struct {
+	ID int64
+}
+
+// IsErrCIJobNotExist checks if an error is a ErrCIJobNotExist.
+func IsErrCIJobNotExist(err error) bool {
+	_, ok := err.(ErrCIJobNotExist)
+	return ok
+}
+
+func (err ErrCIJobNotExist) Error() string {
+	return fmt.Sprintf("CI job does not exist [id: %d]", err.ID)
+}
+
 // ErrTagAlreadyExists represents an error that tag with such name already exists.
 type ErrTagAlreadyExists struct {
 	TagName string
@@ -1539,6 +1691,23 @@ func (err ErrMergeUnrelatedHistories) Error() string {
 	return fmt.Sprintf("Merge UnrelatedHistories Error: %v: %s\n%s", err.Err, err.StdErr, err.StdOut)
 }
 
+// ErrMergeDivergingFastForwardOnly represents an error if a fast-forward-only merge fails because the branches have diverged
+type ErrMergeDivergingFastForwardOnly struct {
+	StdOut string
+	StdErr string
+	Err    error
+}
+
+// IsErrMergeDivergingFastForwardOnly checks if an error is a ErrMergeDivergingFastForwardOnly.
+func IsErrMergeDivergingFastForwardOnly(err error) bool {
+	_, ok := err.(ErrMergeDivergingFastForwardOnly)
+	return ok
+}
+
+func (err ErrMergeDivergingFastForwardOnly) Error() string {
+	return fmt.Sprintf("Merge DivergingFastForwardOnly Error: %v: %s\n%s", err.Err, err.StdErr, err.StdOut)
+}
+
 // ErrRebaseConflicts represents an error if rebase fails with a conflict
 type ErrRebaseConflicts struct {
 	Style     MergeStyle
@@ -1964,25 +2133,29 @@ func (err ErrExternalLoginUserNotExist) Error() string {
 	return fmt.Sprintf("external login user link does not exists [userID: %d, loginSourceID: %d]", err.UserID, err.LoginSourceID)
 }
 
-// ____ ________________________________              .__          __                 __  .__
-// |    |   \_____  \_   _____/\______   \ ____   ____ |__| _______/  |_____________ _/  |_|__| ____   ____
-// |    |   //  ____/|    __)   |       _// __ \ / ___\|  |/  ___/\   __\_  __ \__  \\   __\  |/  _ \ /    \
-// |    |  //       \|     \    |    |   \  ___// /_/  >  |\___ \  |  |  |  | \// __ \|  | |  (  <_> )   |  \
-// |______/ \_______ \___  /    |____|_  /\___  >___  /|__/____  > |__|  |__|  (____  /__| |__|\____/|___|  /
-// \/   \/            \/     \/_____/         \/                   \/                    \/
+//  __      __         ___.       _____             __   .__
+// /  \    /  \  ____  \_ |__    /  _  \   __ __  _/  |_ |  |__    ____
+// \   \/\/   /_/ __ \  | __ \  /  /_\  \ |  |  \ \   __\|  |  \  /    \
+//  \        / \  ___/  | \_\ \/    |    \|  |  /  |  |  |   Y  \|   |  \
+//   \__/\  /   \___  > |___  /\____|__  /|____/   |__|  |___|  /|___|  /
+//        \/        \/      \/         \/                     \/      \/
 
-// ErrU2FRegistrationNotExist represents a "ErrU2FRegistrationNotExist" kind of error.
-type ErrU2FRegistrationNotExist struct {
-	ID int64
+// ErrWebAuthnCredentialNotExist represents a "ErrWebAuthnCredentialNotExist" kind of error.
+type ErrWebAuthnCredentialNotExist struct {
+	ID           int64
+	CredentialID string
 }
 
-func (err ErrU2FRegistrationNotExist) Error() string {
-	return fmt.Sprintf("U2F registration does not exist [id: %d]", err.ID)
+func (err ErrWebAuthnCredentialNotExist) Error() string {
+	if err.CredentialID == "" {
+		return fmt.Sprintf("WebAuthn credential does not exist [id: %d]", err.ID)
+	}
+	return fmt.Sprintf("WebAuthn credential does not exist [credential_id: %s]", err.CredentialID)
 }
 
-// IsErrU2FRegistrationNotExist checks if an error is a ErrU2FRegistrationNotExist.
-func IsErrU2FRegistrationNotExist(err error) bool {
-	_, ok := err.(ErrU2FRegistrationNotExist)
+// IsErrWebAuthnCredentialNotExist checks if an error is a ErrWebAuthnCredentialNotExist.
+func IsErrWebAuthnCredentialNotExist(err error) bool {
+	_, ok := err.(ErrWebAuthnCredentialNotExist)
 	return ok
 }
 
@@ -1120,6 +1120,8 @@ type IssuesOptions struct {
 	IssueIDs           []int64
 	UpdatedAfterUnix   int64
 	UpdatedBeforeUnix  int64
+	// matches the title, the content or the comments of the issues, case insensitive
+	Keyword string
 	// prioritize issues from this repo
 	PriorityRepoID int64
 	IsArchived     util.OptionalBool
@@ -1146,6 +1148,8 @@ func sortIssuesSession(sess *xorm.Session, sortType string, priorityRepoID int64
 		sess.OrderBy("CASE WHEN issue.deadline_unix = 0 THEN 253370764800 ELSE issue.deadline_unix END ASC")
 	case "farduedate":
 		sess.Desc("issue.deadline_unix")
+	case "project-column-sorting":
+		sess.Asc("project_issue.sorting").Desc("issue.created_unix")
 	case "priorityrepo":
 		sess.OrderBy("CASE WHEN issue.repo_id = " + strconv.FormatInt(priorityRepoID, 10) + " THEN 1 ELSE 2 END, issue.created_unix DESC")
 	default:
@@ -1248,6 +1252,21 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) {
 	if len(opts.ExcludedLabelNames) > 0 {
 		sess.And(builder.NotIn("issue.id", BuildLabelNamesIssueIDsCondition(opts.ExcludedLabelNames)))
 	}
+
+	if len(opts.Keyword) > 0 {
+		kw := strings.ToUpper(opts.Keyword)
+		sess.And(builder.Or(
+			builder.Like{"UPPER(issue.name)", kw},
+			builder.Like{"UPPER(issue.content)", kw},
+			builder.In("issue.id", builder.Select("issue_id").
+				From("comment").
+				Where(builder.And(
+					builder.Eq{"type": CommentTypeComment},
+					builder.Like{"UPPER(content)", kw},
+				)),
+			),
+		))
+	}
 }
 
 func applyReposCondition(sess *xorm.Session, repoIDs []int64) *xorm.Session {
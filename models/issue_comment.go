@@ -102,6 +102,14 @@ const (
 	CommentTypeProjectBoard
 	// Dismiss Review
 	CommentTypeDismissReview
+	// 33 Pull request scheduled to merge when all checks succeed
+	CommentTypePRScheduledToAutoMerge
+	// 34 Scheduled auto merge of a pull request canceled
+	CommentTypePRUnScheduledToAutoMerge
+	// 35 Pull request added to the merge queue
+	CommentTypeMergeQueueAdd
+	// 36 Pull request removed from the merge queue
+	CommentTypeMergeQueueRemove
 )
 
 // CommentTag defines comment tag type
@@ -17,11 +17,13 @@ import (
 	"code.gitea.io/gitea/modules/auth/ldap"
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/auth/pam"
+	"code.gitea.io/gitea/modules/auth/saml"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/markbates/goth"
 
 	"xorm.io/xorm"
 	"xorm.io/xorm/convert"
@@ -40,6 +42,7 @@ const (
 	LoginDLDAP            // 5
 	LoginOAuth2           // 6
 	LoginSSPI             // 7
+	LoginSAML             // 8
 )
 
 // LoginNames contains the name of LoginType values.
@@ -50,6 +53,7 @@ var LoginNames = map[LoginType]string{
 	LoginPAM:    "PAM",
 	LoginOAuth2: "OAuth2",
 	LoginSSPI:   "SPNEGO with SSPI",
+	LoginSAML:   "SAML 2.0",
 }
 
 // SecurityProtocolNames contains the name of SecurityProtocol values.
@@ -66,6 +70,7 @@ var (
 	_ convert.Conversion = &PAMConfig{}
 	_ convert.Conversion = &OAuth2Config{}
 	_ convert.Conversion = &SSPIConfig{}
+	_ convert.Conversion = &SAMLConfig{}
 )
 
 // LDAPConfig holds configuration for LDAP login source.
@@ -138,6 +143,12 @@ type OAuth2Config struct {
 	OpenIDConnectAutoDiscoveryURL string
 	CustomURLMapping              *oauth2.CustomURLMapping
 	IconURL                       string
+	GroupClaimName                string // Claim listing the groups of the user
+	RequiredGroup                 string // Group users need to be a member of to sign in
+	AdminGroup                    string // Group users need to be a member of to be site administrator
+	RestrictedGroup               string // Group users need to be a member of to be restricted
+	GroupTeamMap                  string // Map groups to teams of organizations
+	GroupTeamMapRemoval           bool   // Remove user from mapped teams when they are not a member of the group anymore
 }
 
 // FromDB fills up an OAuth2Config from serialized format.
@@ -152,6 +163,48 @@ func (cfg *OAuth2Config) ToDB() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+// Groups returns the groups listed in the group claim of the user, the claim
+// may hold a single group or a list of groups
+func (cfg *OAuth2Config) Groups(gothUser goth.User) []string {
+	if len(cfg.GroupClaimName) == 0 {
+		return nil
+	}
+	switch claim := gothUser.RawData[cfg.GroupClaimName].(type) {
+	case string:
+		return []string{claim}
+	case []string:
+		return claim
+	case []interface{}:
+		groups := make([]string, 0, len(claim))
+		for _, group := range claim {
+			if name, ok := group.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// IsAllowed returns whether a user who is a member of the groups may sign in through this source
+func (cfg *OAuth2Config) IsAllowed(groups []string) bool {
+	return len(cfg.GroupClaimName) == 0 || len(cfg.RequiredGroup) == 0 || util.IsStringInSlice(cfg.RequiredGroup, groups)
+}
+
+// GroupTeamMapping returns the parsed GroupTeamMap, which maps group names to the team names of organizations:
+// {"developers": {"MyOrg": ["Developers", "Reviewers"]}}
+func (cfg *OAuth2Config) GroupTeamMapping() (map[string]map[string][]string, error) {
+	mapping := make(map[string]map[string][]string)
+	if len(strings.TrimSpace(cfg.GroupTeamMap)) == 0 {
+		return mapping, nil
+	}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal([]byte(cfg.GroupTeamMap), &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
 // SSPIConfig holds configuration for SSPI single sign-on.
 type SSPIConfig struct {
 	AutoCreateUsers      bool
@@ -173,6 +226,23 @@ func (cfg *SSPIConfig) ToDB() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+// SAMLConfig holds configuration for the SAML 2.0 login source.
+type SAMLConfig struct {
+	*saml.Source
+}
+
+// FromDB fills up a SAMLConfig from serialized format.
+func (cfg *SAMLConfig) FromDB(bs []byte) error {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	return json.Unmarshal(bs, &cfg)
+}
+
+// ToDB exports a SAMLConfig to a serialized format.
+func (cfg *SAMLConfig) ToDB() ([]byte, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	return json.Marshal(cfg)
+}
+
 // LoginSource represents an external way for authorizing users.
 type LoginSource struct {
 	ID            int64 `xorm:"pk autoincr"`
@@ -213,12 +283,38 @@ func (source *LoginSource) BeforeSet(colName string, val xorm.Cell) {
 			source.Cfg = new(OAuth2Config)
 		case LoginSSPI:
 			source.Cfg = new(SSPIConfig)
+		case LoginSAML:
+			source.Cfg = new(SAMLConfig)
 		default:
 			panic(fmt.Sprintf("unrecognized login source type: %v", *val))
 		}
 	}
 }
 
+// AfterLoad loads the private key of SAML login sources, which is stored as internal secret
+func (source *LoginSource) AfterLoad(session *xorm.Session) {
+	if !source.IsSAML() || source.SAML().Source == nil {
+		return
+	}
+	var err error
+	if source.SAML().ServiceProviderPrivateKey, err = getInternalSecretValue(session, 0, 0, samlPrivateKeySecretName(source.ID)); err != nil {
+		log.Error("getInternalSecretValue[%d]: %v", source.ID, err)
+	}
+}
+
+// samlPrivateKeySecretName returns the name of the internal secret which stores the private key of the SAML login source
+func samlPrivateKeySecretName(id int64) string {
+	return fmt.Sprintf("saml-private-key-%d", id)
+}
+
+// saveSAMLPrivateKey stores the private key of a SAML login source as internal secret
+func saveSAMLPrivateKey(e Engine, source *LoginSource) error {
+	if !source.IsSAML() || source.SAML().Source == nil {
+		return nil
+	}
+	return setInternalSecretValue(e, 0, 0, samlPrivateKeySecretName(source.ID), source.SAML().ServiceProviderPrivateKey)
+}
+
 // TypeName return name of this login source type.
 func (source *LoginSource) TypeName() string {
 	return LoginNames[source.Type]
@@ -254,6 +350,11 @@ func (source *LoginSource) IsSSPI() bool {
 	return source.Type == LoginSSPI
 }
 
+// IsSAML returns true of this source is of the SAML type.
+func (source *LoginSource) IsSAML() bool {
+	return source.Type == LoginSAML
+}
+
 // HasTLS returns true of this source supports TLS.
 func (source *LoginSource) HasTLS() bool {
 	return ((source.IsLDAP() || source.IsDLDAP()) &&
@@ -311,6 +412,11 @@ func (source *LoginSource) SSPI() *SSPIConfig {
 	return source.Cfg.(*SSPIConfig)
 }
 
+// SAML returns SAMLConfig for this source, if of SAML type.
+func (source *LoginSource) SAML() *SAMLConfig {
+	return source.Cfg.(*SAMLConfig)
+}
+
 // CreateLoginSource inserts a LoginSource in the DB if not already
 // existing with the given name.
 func CreateLoginSource(source *LoginSource) error {
@@ -326,6 +432,9 @@ func CreateLoginSource(source *LoginSource) error {
 	}
 
 	_, err = x.Insert(source)
+	if err == nil {
+		err = saveSAMLPrivateKey(x, source)
+	}
 	if err == nil && source.IsOAuth2() && source.IsActived {
 		oAuth2Config := source.OAuth2()
 		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping)
@@ -403,6 +512,9 @@ func UpdateSource(source *LoginSource) error {
 	}
 
 	_, err := x.ID(source.ID).AllCols().Update(source)
+	if err == nil {
+		err = saveSAMLPrivateKey(x, source)
+	}
 	if err == nil && source.IsOAuth2() && source.IsActived {
 		oAuth2Config := source.OAuth2()
 		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping)
@@ -438,7 +550,10 @@ func DeleteSource(source *LoginSource) error {
 		oauth2.RemoveProvider(source.Name)
 	}
 
-	_, err = x.ID(source.ID).Delete(new(LoginSource))
+	if _, err = x.ID(source.ID).Delete(new(LoginSource)); err != nil {
+		return err
+	}
+	_, err = deleteSecret(x, 0, 0, samlPrivateKeySecretName(source.ID))
 	return err
 }
 
@@ -511,6 +626,10 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource) (*Use
 	}
 
 	if user != nil {
+		if err := syncGroupsToTeams(user, sr.LdapTeamAdd, sr.LdapTeamRemove, source.LDAP().GroupTeamMapRemoval); err != nil {
+			return user, err
+		}
+
 		if isAttributeSSHPublicKeySet && synchronizeLdapSSHPublicKeys(user, source, sr.SSHPublicKey) {
 			return user, RewriteAllPublicKeys()
 		}
@@ -546,9 +665,77 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource) (*Use
 		err = RewriteAllPublicKeys()
 	}
 
+	if err == nil {
+		err = syncGroupsToTeams(user, sr.LdapTeamAdd, sr.LdapTeamRemove, source.LDAP().GroupTeamMapRemoval)
+	}
+
 	return user, err
 }
 
+// syncGroupsToTeams adds the user to the teams mapped to the external groups they are a member of and,
+// if removeFromTeams is set, removes them from the teams mapped to the groups they are not a member of
+func syncGroupsToTeams(user *User, teamsToAdd, teamsToRemove map[string][]string, removeFromTeams bool) error {
+	if removeFromTeams {
+		for orgName, teamNames := range teamsToRemove {
+			for _, teamName := range teamNames {
+				// a team can be mapped to several groups, membership of one of them is enough
+				if util.IsStringInSlice(teamName, teamsToAdd[orgName], true) {
+					continue
+				}
+				team, err := getGroupMappedTeam(orgName, teamName)
+				if err != nil {
+					return err
+				} else if team == nil || !team.IsMember(user.ID) {
+					continue
+				}
+				log.Trace("syncGroupsToTeams: removing user %s from team %s/%s", user.Name, orgName, teamName)
+				if err := RemoveTeamMember(team, user.ID); err != nil {
+					if !IsErrLastOrgOwner(err) {
+						return err
+					}
+					log.Warn("syncGroupsToTeams: cannot remove the last owner %s of organization %s", user.Name, orgName)
+				}
+			}
+		}
+	}
+
+	for orgName, teamNames := range teamsToAdd {
+		for _, teamName := range teamNames {
+			team, err := getGroupMappedTeam(orgName, teamName)
+			if err != nil {
+				return err
+			} else if team == nil {
+				continue
+			}
+			if err := AddTeamMember(team, user.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getGroupMappedTeam returns a team of a group team map, or nil if it does not exist
+func getGroupMappedTeam(orgName, teamName string) (*Team, error) {
+	org, err := GetOrgByName(orgName)
+	if err != nil {
+		if IsErrOrgNotExist(err) {
+			log.Warn("Group team map: organization %s does not exist", orgName)
+			return nil, nil
+		}
+		return nil, err
+	}
+	team, err := GetTeam(org.ID, teamName)
+	if err != nil {
+		if IsErrTeamNotExist(err) {
+			log.Warn("Group team map: team %s of organization %s does not exist", teamName, orgName)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
 //   _________   __________________________
 //  /   _____/  /     \__    ___/\______   \
 //  \_____  \  /  \ /  \|    |    |     ___/
@@ -776,7 +963,7 @@ func UserSignIn(username, password string) (*User, error) {
 
 	if hasUser {
 		switch user.LoginType {
-		case LoginNoType, LoginPlain, LoginOAuth2:
+		case LoginNoType, LoginPlain, LoginOAuth2, LoginSAML:
 			if user.IsPasswordSet() && user.ValidatePassword(password) {
 
 				// Update password hash if server password hash algorithm have changed
@@ -819,8 +1006,8 @@ func UserSignIn(username, password string) (*User, error) {
 	}
 
 	for _, source := range sources {
-		if source.IsOAuth2() || source.IsSSPI() {
-			// don't try to authenticate against OAuth2 and SSPI sources here
+		if source.IsOAuth2() || source.IsSSPI() || source.IsSAML() {
+			// don't try to authenticate against OAuth2, SSPI and SAML sources here
 			continue
 		}
 		authUser, err := ExternalUserLogin(nil, username, password, source)
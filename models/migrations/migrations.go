@@ -309,6 +309,42 @@ var migrations = []Migration{
 	NewMigration("Add LFS columns to Mirror", addLFSMirrorColumns),
 	// v179 -> v180
 	NewMigration("Convert avatar url to text", convertAvatarURLToText),
+	// v180 -> v181
+	NewMigration("Create push mirror table", createPushMirrorTable),
+	// v181 -> v182
+	NewMigration("Create protected tag table", createProtectedTagTable),
+	// v182 -> v183
+	NewMigration("Create pull auto merge table", createPullAutoMergeTable),
+	// v183 -> v184
+	NewMigration("Add require code owner approval branch protection", addRequireCodeOwnerApproval),
+	// v184 -> v185
+	NewMigration("Migrate U2F registrations to WebAuthn credentials", migrateU2FToWebAuthn),
+	// v185 -> v186
+	NewMigration("Add scope and repository restriction to access tokens", addScopeToAccessToken),
+	// v186 -> v187
+	NewMigration("Add package tables", addPackageTables),
+	// v187 -> v188
+	NewMigration("Add secret table and move webhook secrets and mirror credentials into it", addSecretTable),
+	// v188 -> v189
+	NewMigration("Add owner to projects", addOwnerToProject),
+	// v189 -> v190
+	NewMigration("Add sorting to project issues", addSortingToProjectIssue),
+	// v190 -> v191
+	NewMigration("Add merge queue to protected branches", addMergeQueue),
+	// v191 -> v192
+	NewMigration("Add check runs and their annotations", addCheckRuns),
+	// v192 -> v193
+	NewMigration("Add CI runners, runs and jobs", addCI),
+	// v193 -> v194
+	NewMigration("Add OAuth2 device authorizations", addOAuth2DeviceAuthorization),
+	// v194 -> v195
+	NewMigration("Add client credentials grants to oauth2_grant table", addClientCredentialsToOAuth2Grant),
+	// v195 -> v196
+	NewMigration("Move the credentials of push mirrors into the secret table", movePushMirrorCredentialsToSecrets),
+	// v196 -> v197
+	NewMigration("Move the private keys of SAML login sources into the secret table", moveSAMLPrivateKeysToSecrets),
+	// v197 -> v198
+	NewMigration("Grant full API access to OAuth2 grants from before scopes were enforced", addAllScopeToOAuth2Grants),
 }
 
 // GetCurrentDBVersion returns the current db version
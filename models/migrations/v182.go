@@ -0,0 +1,27 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func createPullAutoMergeTable(x *xorm.Engine) error {
+	type PullAutoMerge struct {
+		ID          int64  `xorm:"pk autoincr"`
+		PullID      int64  `xorm:"UNIQUE"`
+		DoerID      int64  `xorm:"NOT NULL"`
+		MergeStyle  string `xorm:"varchar(30)"`
+		Message     string `xorm:"LONGTEXT"`
+		CreatedUnix int64  `xorm:"created"`
+	}
+
+	if err := x.Sync2(&PullAutoMerge{}); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"crypto/elliptic"
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tstranex/u2f"
+	"xorm.io/xorm"
+)
+
+func migrateU2FToWebAuthn(x *xorm.Engine) error {
+	type webauthnCredential struct {
+		ID              int64 `xorm:"pk autoincr"`
+		Name            string
+		LowerName       string `xorm:"unique(s)"`
+		UserID          int64  `xorm:"INDEX unique(s)"`
+		CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+		PublicKey       []byte
+		AttestationType string
+		AAGUID          []byte
+		SignCount       uint32 `xorm:"BIGINT"`
+		CloneWarning    bool
+		CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	if err := x.Sync2(new(webauthnCredential)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	exist, err := x.IsTableExist("u2f_registration")
+	if err != nil {
+		return err
+	} else if !exist {
+		return nil
+	}
+
+	type u2fRegistration struct {
+		ID          int64 `xorm:"pk autoincr"`
+		Name        string
+		UserID      int64 `xorm:"INDEX"`
+		Raw         []byte
+		Counter     uint32             `xorm:"BIGINT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	regs := make([]*u2fRegistration, 0, 50)
+	if err := x.Table("u2f_registration").Asc("id").Find(&regs); err != nil {
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// the names of WebAuthn credentials are unique per user regardless of their case
+	names := make(map[int64]map[string]bool)
+	for _, reg := range regs {
+		parsed := new(u2f.Registration)
+		if err := parsed.UnmarshalBinary(reg.Raw); err != nil {
+			log.Warn("Unable to migrate U2F registration %d of user %d: %v", reg.ID, reg.UserID, err)
+			continue
+		}
+
+		// security keys registered through U2F always use ECDSA P-256 with SHA-256
+		byteLen := (elliptic.P256().Params().BitSize + 7) / 8
+		publicKey, err := cbor.Marshal(&webauthncose.EC2PublicKeyData{
+			PublicKeyData: webauthncose.PublicKeyData{
+				KeyType:   int64(webauthncose.EllipticKey),
+				Algorithm: int64(webauthncose.AlgES256),
+			},
+			Curve:  1, // P-256
+			XCoord: parsed.PubKey.X.FillBytes(make([]byte, byteLen)),
+			YCoord: parsed.PubKey.Y.FillBytes(make([]byte, byteLen)),
+		})
+		if err != nil {
+			return err
+		}
+
+		if names[reg.UserID] == nil {
+			names[reg.UserID] = make(map[string]bool)
+		}
+		name := reg.Name
+		for i := 2; names[reg.UserID][strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s (%d)", reg.Name, i)
+		}
+		names[reg.UserID][strings.ToLower(name)] = true
+
+		if _, err := sess.Insert(&webauthnCredential{
+			Name:            name,
+			LowerName:       strings.ToLower(name),
+			UserID:          reg.UserID,
+			CredentialID:    base32.HexEncoding.EncodeToString(parsed.KeyHandle),
+			PublicKey:       publicKey,
+			AttestationType: "fido-u2f",
+			AAGUID:          []byte{},
+			SignCount:       reg.Counter,
+			CreatedUnix:     reg.CreatedUnix,
+			UpdatedUnix:     reg.UpdatedUnix,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	return x.DropTables("u2f_registration")
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+func addScopeToAccessToken(x *xorm.Engine) error {
+	type AccessToken struct {
+		Scope   string  `xorm:"NOT NULL DEFAULT 'all'"`
+		RepoIDs []int64 `xorm:"JSON TEXT"`
+	}
+
+	return x.Sync2(new(AccessToken))
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/xorm"
+)
+
+func addSecretTable(x *xorm.Engine) error {
+	type Secret struct {
+		ID          int64              `xorm:"pk autoincr"`
+		OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+		Data        string             `xorm:"LONGTEXT"`
+		IsInternal  bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type Webhook struct {
+		ID     int64
+		RepoID int64
+		OrgID  int64
+		Secret string `xorm:"TEXT"`
+	}
+
+	type Mirror struct {
+		ID     int64
+		RepoID int64
+	}
+
+	type Repository struct {
+		ID          int64
+		OwnerName   string
+		Name        string
+		OriginalURL string
+	}
+
+	if err := x.Sync2(new(Secret)); err != nil {
+		return err
+	}
+
+	insertSecret := func(e *xorm.Session, ownerID, repoID int64, name, value string) error {
+		data, err := secret.EncryptSecret(setting.MasterKey, value)
+		if err != nil {
+			return err
+		}
+		_, err = e.Insert(&Secret{
+			OwnerID:    ownerID,
+			RepoID:     repoID,
+			Name:       name,
+			Data:       data,
+			IsInternal: true,
+		})
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// Move the webhook secrets into the secret table
+	webhooks := make([]*Webhook, 0, 50)
+	if err := sess.Where("secret IS NOT NULL AND secret <> ''").Find(&webhooks); err != nil {
+		return err
+	}
+	for _, w := range webhooks {
+		if err := insertSecret(sess, w.OrgID, w.RepoID, fmt.Sprintf("webhook-%d", w.ID), w.Secret); err != nil {
+			return err
+		}
+	}
+	if err := dropTableColumns(sess, "webhook", "secret"); err != nil {
+		return err
+	}
+
+	// Move the credentials of the mirror remotes from the Git repository configs into the secret table.
+	// The remote addresses are only rewritten once the credentials are committed, they would be lost otherwise.
+	remoteAddrs := make(map[string]string)
+	mirrors := make([]*Mirror, 0, 50)
+	if err := sess.Find(&mirrors); err != nil {
+		return err
+	}
+	for _, m := range mirrors {
+		repo := &Repository{}
+		if has, err := sess.ID(m.RepoID).Get(repo); err != nil {
+			return err
+		} else if !has {
+			continue
+		}
+
+		repoPaths := []string{
+			repoPath(repo.OwnerName, repo.Name),
+			filepath.Join(userPath(repo.OwnerName), strings.ToLower(repo.Name)+".wiki.git"),
+		}
+
+		var user *url.Userinfo
+		for _, p := range repoPaths {
+			if isExist, _ := util.IsExist(p); !isExist {
+				continue
+			}
+			addr, err := git.GetRemoteAddress(p, "origin")
+			if err != nil {
+				log.Warn("Unable to read the remote address of %s: %v", p, err)
+				continue
+			}
+			u, err := url.Parse(addr)
+			if err != nil || u.User == nil {
+				continue
+			}
+			if user == nil {
+				user = u.User
+			}
+			u.User = nil
+			remoteAddrs[p] = u.String()
+		}
+		if user == nil {
+			continue
+		}
+
+		if err := insertSecret(sess, 0, repo.ID, "mirror-credentials", user.String()); err != nil {
+			return err
+		}
+		repo.OriginalURL = util.SanitizeURLCredentials(repo.OriginalURL, false)
+		if _, err := sess.ID(repo.ID).Cols("original_url").Update(repo); err != nil {
+			return err
+		}
+	}
+
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	for p, addr := range remoteAddrs {
+		if _, err := git.NewCommand("remote", "set-url", "origin", addr).RunInDir(p); err != nil {
+			log.Error("Unable to remove the credentials from the remote address of %s: %v", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addMergeQueue(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		EnableMergeQueue bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	type MergeQueueEntry struct {
+		ID           int64  `xorm:"pk autoincr"`
+		RepoID       int64  `xorm:"INDEX(s) NOT NULL"`
+		BaseBranch   string `xorm:"INDEX(s) NOT NULL"`
+		PullID       int64  `xorm:"UNIQUE NOT NULL"`
+		DoerID       int64  `xorm:"NOT NULL"`
+		MergeStyle   string `xorm:"varchar(30)"`
+		Message      string `xorm:"LONGTEXT"`
+		BaseCommitID string `xorm:"VARCHAR(40)"`
+		HeadCommitID string `xorm:"VARCHAR(40)"`
+		CommitID     string `xorm:"VARCHAR(40) INDEX"`
+		CreatedUnix  int64  `xorm:"created"`
+		UpdatedUnix  int64  `xorm:"updated"`
+	}
+
+	if err := x.Sync2(new(ProtectedBranch)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(MergeQueueEntry)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
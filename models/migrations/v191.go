@@ -0,0 +1,54 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addCheckRuns(x *xorm.Engine) error {
+	type CheckRun struct {
+		ID               int64  `xorm:"pk autoincr"`
+		RepoID           int64  `xorm:"INDEX(s) NOT NULL"`
+		HeadSHA          string `xorm:"VARCHAR(64) INDEX(s) NOT NULL"`
+		Name             string `xorm:"NOT NULL"`
+		Status           string `xorm:"VARCHAR(20) NOT NULL"`
+		Conclusion       string `xorm:"VARCHAR(20)"`
+		DetailsURL       string `xorm:"TEXT"`
+		ExternalID       string
+		CreatorID        int64
+		Title            string
+		Summary          string `xorm:"LONGTEXT"`
+		Text             string `xorm:"LONGTEXT"`
+		AnnotationsCount int64  `xorm:"NOT NULL DEFAULT 0"`
+		StartedUnix      int64
+		CompletedUnix    int64
+		CreatedUnix      int64 `xorm:"created"`
+		UpdatedUnix      int64 `xorm:"updated"`
+	}
+
+	type CheckRunAnnotation struct {
+		ID          int64  `xorm:"pk autoincr"`
+		CheckRunID  int64  `xorm:"INDEX NOT NULL"`
+		Path        string `xorm:"TEXT NOT NULL"`
+		StartLine   int64  `xorm:"NOT NULL"`
+		EndLine     int64  `xorm:"NOT NULL"`
+		Level       string `xorm:"VARCHAR(10) NOT NULL"`
+		Title       string
+		Message     string `xorm:"TEXT"`
+		RawDetails  string `xorm:"TEXT"`
+		CreatedUnix int64  `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(CheckRun)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(CheckRunAnnotation)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
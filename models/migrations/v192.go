@@ -0,0 +1,67 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addCI(x *xorm.Engine) error {
+	type CIRunner struct {
+		ID             int64 `xorm:"pk autoincr"`
+		Name           string
+		Labels         []string `xorm:"JSON TEXT"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string `xorm:"INDEX token_last_eight"`
+		LastOnlineUnix int64
+		CreatedUnix    int64 `xorm:"created"`
+	}
+
+	type CIRun struct {
+		ID            int64 `xorm:"pk autoincr"`
+		RepoID        int64 `xorm:"INDEX NOT NULL"`
+		WorkflowFile  string
+		Name          string
+		Event         string
+		Ref           string
+		CommitSHA     string `xorm:"VARCHAR(40) INDEX"`
+		TriggerUserID int64
+		PullID        int64
+		Status        string `xorm:"VARCHAR(20) NOT NULL"`
+		CreatedUnix   int64  `xorm:"INDEX created"`
+		UpdatedUnix   int64  `xorm:"updated"`
+	}
+
+	type CIJob struct {
+		ID          int64             `xorm:"pk autoincr"`
+		RunID       int64             `xorm:"INDEX NOT NULL"`
+		RepoID      int64             `xorm:"INDEX NOT NULL"`
+		Name        string            `xorm:"NOT NULL"`
+		RunsOn      []string          `xorm:"JSON TEXT"`
+		Env         map[string]string `xorm:"JSON TEXT"`
+		Steps       string            `xorm:"TEXT"`
+		Status      string            `xorm:"VARCHAR(20) INDEX NOT NULL"`
+		RunnerID    int64             `xorm:"INDEX"`
+		LogChunks   int               `xorm:"NOT NULL DEFAULT 0"`
+		StartedUnix int64
+		StoppedUnix int64
+		CreatedUnix int64 `xorm:"created"`
+		UpdatedUnix int64 `xorm:"updated"`
+	}
+
+	if err := x.Sync2(new(CIRunner)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(CIRun)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(CIJob)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+// OAuth2GrantWithClientCredentials here is a snapshot of models.OAuth2Grant for this version of the database.
+// The grants of client credentials get their own row, so the unique index includes the new column.
+type OAuth2GrantWithClientCredentials struct {
+	ID                  int64  `xorm:"pk autoincr"`
+	UserID              int64  `xorm:"INDEX unique(user_application)"`
+	ApplicationID       int64  `xorm:"INDEX unique(user_application)"`
+	Counter             int64  `xorm:"NOT NULL DEFAULT 1"`
+	Scope               string `xorm:"TEXT"`
+	Nonce               string `xorm:"TEXT"`
+	IsClientCredentials bool   `xorm:"NOT NULL DEFAULT false unique(user_application)"`
+	CreatedUnix         int64  `xorm:"created"`
+	UpdatedUnix         int64  `xorm:"updated"`
+}
+
+// TableName sets the database table name to be the correct one, as the
+// autogenerated table name for this struct is "o_auth2_grant_with_client_credentials".
+func (grant *OAuth2GrantWithClientCredentials) TableName() string {
+	return "oauth2_grant"
+}
+
+func addClientCredentialsToOAuth2Grant(x *xorm.Engine) error {
+	if err := x.Sync2(new(OAuth2GrantWithClientCredentials)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/xorm"
+)
+
+func movePushMirrorCredentialsToSecrets(x *xorm.Engine) error {
+	type Secret struct {
+		ID          int64              `xorm:"pk autoincr"`
+		OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+		Data        string             `xorm:"LONGTEXT"`
+		IsInternal  bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type PushMirror struct {
+		ID         int64
+		RepoID     int64
+		RemoteName string
+	}
+
+	type Repository struct {
+		ID        int64
+		OwnerName string
+		Name      string
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// The remote addresses are only rewritten once the credentials are committed, they would be lost otherwise.
+	type remote struct {
+		path, name, addr string
+	}
+	remotes := make([]remote, 0, 50)
+
+	mirrors := make([]*PushMirror, 0, 50)
+	if err := sess.Find(&mirrors); err != nil {
+		return err
+	}
+	for _, m := range mirrors {
+		repo := &Repository{}
+		if has, err := sess.ID(m.RepoID).Get(repo); err != nil {
+			return err
+		} else if !has {
+			continue
+		}
+
+		repoPaths := []string{
+			repoPath(repo.OwnerName, repo.Name),
+			filepath.Join(userPath(repo.OwnerName), strings.ToLower(repo.Name)+".wiki.git"),
+		}
+
+		var user *url.Userinfo
+		for _, p := range repoPaths {
+			if isExist, _ := util.IsExist(p); !isExist {
+				continue
+			}
+			addr, err := git.GetRemoteAddress(p, m.RemoteName)
+			if err != nil {
+				log.Warn("Unable to read the remote address of %s: %v", p, err)
+				continue
+			}
+			u, err := url.Parse(addr)
+			if err != nil || u.User == nil {
+				continue
+			}
+			if user == nil {
+				user = u.User
+			}
+			u.User = nil
+			remotes = append(remotes, remote{path: p, name: m.RemoteName, addr: u.String()})
+		}
+		if user == nil {
+			continue
+		}
+
+		data, err := secret.EncryptSecret(setting.MasterKey, user.String())
+		if err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&Secret{
+			RepoID:     repo.ID,
+			Name:       fmt.Sprintf("push-mirror-credentials-%d", m.ID),
+			Data:       data,
+			IsInternal: true,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	for _, r := range remotes {
+		if _, err := git.NewCommand("remote", "set-url", r.name, r.addr).RunInDir(r.path); err != nil {
+			log.Error("Unable to remove the credentials from the remote address of %s: %v", r.path, err)
+		}
+	}
+	return nil
+}
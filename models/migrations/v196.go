@@ -0,0 +1,84 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	jsoniter "github.com/json-iterator/go"
+	"xorm.io/xorm"
+)
+
+func moveSAMLPrivateKeysToSecrets(x *xorm.Engine) error {
+	type Secret struct {
+		ID          int64              `xorm:"pk autoincr"`
+		OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+		Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+		Data        string             `xorm:"LONGTEXT"`
+		IsInternal  bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type LoginSource struct {
+		ID   int64 `xorm:"pk autoincr"`
+		Type int
+		Cfg  string `xorm:"TEXT"`
+	}
+
+	// LoginSAML is the type of SAML 2.0 login sources
+	const LoginSAML = 8
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	sources := make([]*LoginSource, 0, 5)
+	if err := sess.Where("type = ?", LoginSAML).Find(&sources); err != nil {
+		return err
+	}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	for _, source := range sources {
+		cfg := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(source.Cfg), &cfg); err != nil {
+			return fmt.Errorf("Unable to parse the config of login source %d: %v", source.ID, err)
+		}
+		key, _ := cfg["ServiceProviderPrivateKey"].(string)
+		if key == "" {
+			continue
+		}
+
+		data, err := secret.EncryptSecret(setting.MasterKey, key)
+		if err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&Secret{
+			Name:       fmt.Sprintf("saml-private-key-%d", source.ID),
+			Data:       data,
+			IsInternal: true,
+		}); err != nil {
+			return err
+		}
+
+		delete(cfg, "ServiceProviderPrivateKey")
+		bs, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		source.Cfg = string(bs)
+		if _, err := sess.ID(source.ID).Cols("cfg").Update(source); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
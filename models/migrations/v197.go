@@ -0,0 +1,38 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+func addAllScopeToOAuth2Grants(x *xorm.Engine) error {
+	type OAuth2Grant struct {
+		ID    int64  `xorm:"pk autoincr"`
+		Scope string `xorm:"TEXT"`
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// The tokens of existing grants had full API access, which their scope did not say as scopes were not enforced yet.
+	// Client credentials grants are newer than the enforcement, they keep the scope they were requested with.
+	grants := make([]*OAuth2Grant, 0, 50)
+	if err := sess.Table("oauth2_grant").Where("is_client_credentials = ?", false).Find(&grants); err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		grant.Scope = strings.TrimSpace(grant.Scope + " all")
+		if _, err := sess.Table("oauth2_grant").ID(grant.ID).Cols("scope").Update(grant); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
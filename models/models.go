@@ -119,12 +119,13 @@ func init() {
 		new(LFSLock),
 		new(Reaction),
 		new(IssueAssignees),
-		new(U2FRegistration),
+		new(WebAuthnCredential),
 		new(TeamUnit),
 		new(Review),
 		new(OAuth2Application),
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
+		new(OAuth2DeviceAuthorization),
 		new(Task),
 		new(LanguageStat),
 		new(EmailHash),
@@ -134,6 +135,20 @@ func init() {
 		new(ProjectIssue),
 		new(Session),
 		new(RepoTransfer),
+		new(PushMirror),
+		new(ProtectedTag),
+		new(PullAutoMerge),
+		new(MergeQueueEntry),
+		new(Package),
+		new(PackageVersion),
+		new(PackageFile),
+		new(PackageBlob),
+		new(Secret),
+		new(CheckRun),
+		new(CheckRunAnnotation),
+		new(CIRunner),
+		new(CIRun),
+		new(CIJob),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
 )
 
 // OAuth2Provider describes the display values of a single OAuth2 provider
@@ -170,3 +171,51 @@ func wrapOpenIDConnectInitializeError(err error, providerName string, oAuth2Conf
 	}
 	return err
 }
+
+// SyncOAuth2Groups applies the group claim mapping of an OAuth2 login source to the
+// administrator and restricted flags and the team memberships of the user
+func SyncOAuth2Groups(user *User, source *LoginSource, groups []string) error {
+	cfg := source.OAuth2()
+	if len(cfg.GroupClaimName) == 0 {
+		return nil
+	}
+
+	var cols []string
+	if len(cfg.AdminGroup) > 0 {
+		if isAdmin := util.IsStringInSlice(cfg.AdminGroup, groups); user.IsAdmin != isAdmin {
+			user.IsAdmin = isAdmin
+			cols = append(cols, "is_admin")
+		}
+	}
+	if len(cfg.RestrictedGroup) > 0 {
+		// administrators are never restricted
+		if isRestricted := !user.IsAdmin && util.IsStringInSlice(cfg.RestrictedGroup, groups); user.IsRestricted != isRestricted {
+			user.IsRestricted = isRestricted
+			cols = append(cols, "is_restricted")
+		}
+	}
+	if len(cols) > 0 {
+		log.Trace("SyncOAuth2Groups[%s]: updating %v of user %s", source.Name, cols, user.Name)
+		if err := UpdateUserCols(user, cols...); err != nil {
+			return err
+		}
+	}
+
+	mapping, err := cfg.GroupTeamMapping()
+	if err != nil {
+		return err
+	}
+	teamsToAdd := make(map[string][]string)
+	teamsToRemove := make(map[string][]string)
+	for group, orgTeams := range mapping {
+		isMember := util.IsStringInSlice(group, groups)
+		for org, teams := range orgTeams {
+			if isMember {
+				teamsToAdd[org] = append(teamsToAdd[org], teams...)
+			} else {
+				teamsToRemove[org] = append(teamsToRemove[org], teams...)
+			}
+		}
+	}
+	return syncGroupsToTeams(user, teamsToAdd, teamsToRemove, cfg.GroupTeamMapRemoval)
+}
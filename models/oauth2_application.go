@@ -95,7 +95,7 @@ func (app *OAuth2Application) GetGrantByUserID(userID int64) (*OAuth2Grant, erro
 
 func (app *OAuth2Application) getGrantByUserID(e Engine, userID int64) (grant *OAuth2Grant, err error) {
 	grant = new(OAuth2Grant)
-	if has, err := e.Where("user_id = ? AND application_id = ?", userID, app.ID).Get(grant); err != nil {
+	if has, err := e.Where("user_id = ? AND application_id = ? AND is_client_credentials = ?", userID, app.ID, false).Get(grant); err != nil {
 		return nil, err
 	} else if !has {
 		return nil, nil
@@ -103,6 +103,26 @@ func (app *OAuth2Application) getGrantByUserID(e Engine, userID int64) (grant *O
 	return grant, nil
 }
 
+// GetClientCredentialsGrant returns the grant the tokens issued to the application itself belong to, creating it if necessary.
+// It is separate from the grant of the owner, so revoking one does not affect the other.
+func (app *OAuth2Application) GetClientCredentialsGrant() (*OAuth2Grant, error) {
+	grant := new(OAuth2Grant)
+	if has, err := x.Where("user_id = ? AND application_id = ? AND is_client_credentials = ?", app.UID, app.ID, true).Get(grant); err != nil {
+		return nil, err
+	} else if has {
+		return grant, nil
+	}
+	grant = &OAuth2Grant{
+		ApplicationID:       app.ID,
+		UserID:              app.UID,
+		IsClientCredentials: true,
+	}
+	if _, err := x.Insert(grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
 // CreateGrant generates a grant for an user
 func (app *OAuth2Application) CreateGrant(userID int64, scope string) (*OAuth2Grant, error) {
 	return app.createGrant(x, userID, scope)
@@ -255,6 +275,10 @@ func deleteOAuth2Application(sess *xorm.Session, id, userid int64) error {
 	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2Grant)); err != nil {
 		return err
 	}
+
+	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2DeviceAuthorization)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -385,8 +409,10 @@ type OAuth2Grant struct {
 	Counter       int64              `xorm:"NOT NULL DEFAULT 1"`
 	Scope         string             `xorm:"TEXT"`
 	Nonce         string             `xorm:"TEXT"`
-	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
-	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+	// IsClientCredentials is true for the grant of the tokens the application requested for itself, without a user consenting
+	IsClientCredentials bool               `xorm:"NOT NULL DEFAULT false unique(user_application)"`
+	CreatedUnix         timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix         timeutil.TimeStamp `xorm:"updated"`
 }
 
 // TableName sets the table name to `oauth2_grant`
@@ -446,6 +472,40 @@ func (grant *OAuth2Grant) ScopeContains(scope string) bool {
 	return false
 }
 
+// AccessTokenScope returns the API scopes the user granted to the application
+func (grant *OAuth2Grant) AccessTokenScope() AccessTokenScope {
+	return OAuth2AccessTokenScope(grant.Scope)
+}
+
+// CoversScope returns true if the grant includes all API scopes of the given OAuth2 scope
+func (grant *OAuth2Grant) CoversScope(scope string) bool {
+	granted := grant.AccessTokenScope()
+	for _, s := range OAuth2AccessTokenScope(scope).Scopes() {
+		if !granted.Has(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddScope adds the scopes the user authorized the application for again to the grant.
+// The scope only grows, so the tokens refreshed for earlier authorizations keep their access.
+func (grant *OAuth2Grant) AddScope(scope string) error {
+	return grant.addScope(x, scope)
+}
+
+func (grant *OAuth2Grant) addScope(e Engine, scope string) error {
+	scopes := strings.Fields(grant.Scope)
+	for _, s := range strings.Fields(scope) {
+		if !util.IsStringInSlice(s, scopes) {
+			scopes = append(scopes, s)
+		}
+	}
+	grant.Scope = strings.Join(scopes, " ")
+	_, err := e.ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // SetNonce updates the current nonce value of a grant
 func (grant *OAuth2Grant) SetNonce(nonce string) error {
 	return grant.setNonce(x, nonce)
@@ -489,7 +549,7 @@ func getOAuth2GrantsByUserID(e Engine, uid int64) ([]*OAuth2Grant, error) {
 	var err error
 	if results, err = e.
 		Table("oauth2_grant").
-		Where("user_id = ?", uid).
+		Where("oauth2_grant.user_id = ? AND oauth2_grant.is_client_credentials = ?", uid, false).
 		Join("INNER", "oauth2_application", "application_id = oauth2_application.id").
 		Rows(new(joinedOAuth2Grant)); err != nil {
 		return nil, err
@@ -529,14 +589,49 @@ const (
 	TypeRefreshToken = iota
 )
 
+// OAuth2AccessTokenScope returns the API scopes among the given space separated OAuth2 scopes.
+// Other scopes, like the OpenID Connect ones, are ignored. No API scope at all grants no API access.
+func OAuth2AccessTokenScope(scope string) AccessTokenScope {
+	scopes := make([]string, 0, len(AccessTokenScopes))
+	for _, s := range strings.Fields(strings.ReplaceAll(scope, ",", " ")) {
+		if s == AccessTokenScopeAll || util.IsStringInSlice(s, AccessTokenScopes) {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 0 {
+		return ""
+	}
+	granted, _ := NewAccessTokenScope(scopes...)
+	return granted
+}
+
 // OAuth2Token represents a JWT token used to authenticate a client
 type OAuth2Token struct {
 	GrantID int64           `json:"gnt"`
 	Type    OAuth2TokenType `json:"tt"`
 	Counter int64           `json:"cnt,omitempty"`
+	// Scope is the OAuth2 scope the token was issued for, tokens issued before scopes were enforced have none
+	Scope *string `json:"scp,omitempty"`
 	jwt.StandardClaims
 }
 
+// GrantedScope returns the OAuth2 scope of the token, which defaults to the one of its grant
+func (token *OAuth2Token) GrantedScope(grant *OAuth2Grant) string {
+	if token.Scope != nil {
+		return *token.Scope
+	}
+	return grant.Scope
+}
+
+// AccessTokenScope returns the API scopes the token grants.
+// Tokens issued before scopes were enforced keep the full access they were issued with until they expire.
+func (token *OAuth2Token) AccessTokenScope() AccessTokenScope {
+	if token.Scope == nil {
+		return AccessTokenScopeAll
+	}
+	return OAuth2AccessTokenScope(*token.Scope)
+}
+
 // ParseOAuth2Token parses a singed jwt string
 func ParseOAuth2Token(jwtToken string) (*OAuth2Token, error) {
 	parsedToken, err := jwt.ParseWithClaims(jwtToken, &OAuth2Token{}, func(token *jwt.Token) (interface{}, error) {
@@ -132,6 +132,83 @@ func TestOAuth2Grant_ScopeContains(t *testing.T) {
 	assert.False(t, grant.ScopeContains("profile2"))
 }
 
+func TestOAuth2Grant_CoversScope(t *testing.T) {
+	grant := &OAuth2Grant{Scope: "openid repo issue"}
+	assert.True(t, grant.CoversScope("read:repo issue"))
+	assert.True(t, grant.CoversScope("openid profile repo"))
+	// no API scope asks for no API access
+	assert.True(t, grant.CoversScope(""))
+	assert.True(t, grant.CoversScope("openid"))
+	assert.False(t, grant.CoversScope("repo org"))
+	assert.False(t, grant.CoversScope("all"))
+
+	grant = &OAuth2Grant{Scope: "openid"}
+	assert.True(t, grant.CoversScope("openid profile"))
+	assert.False(t, grant.CoversScope("admin package"))
+	assert.False(t, grant.CoversScope("all"))
+
+	grant = &OAuth2Grant{Scope: "openid all"}
+	assert.True(t, grant.CoversScope("admin package"))
+	assert.True(t, grant.CoversScope("all"))
+}
+
+func TestOAuth2Grant_AddScope(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
+	assert.NoError(t, grant.AddScope("openid repo"))
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "openid profile repo"})
+	// a narrower authorization keeps the earlier scopes
+	assert.NoError(t, grant.AddScope("read:repo"))
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "openid profile repo read:repo"})
+}
+
+func TestOAuth2AccessTokenScope(t *testing.T) {
+	assert.EqualValues(t, "", OAuth2AccessTokenScope(""))
+	assert.EqualValues(t, "", OAuth2AccessTokenScope("openid profile email"))
+	assert.False(t, OAuth2AccessTokenScope("openid").Has(AccessTokenScopeReadRepo))
+	assert.EqualValues(t, AccessTokenScopeAll, OAuth2AccessTokenScope("repo all"))
+	assert.EqualValues(t, "repo,issue", OAuth2AccessTokenScope("openid repo  issue repo"))
+	assert.EqualValues(t, "read:repo,package", OAuth2AccessTokenScope("read:repo,package unknown"))
+}
+
+func TestOAuth2Token_GrantedScope(t *testing.T) {
+	grant := &OAuth2Grant{Scope: "openid repo"}
+	assert.Equal(t, "openid repo", (&OAuth2Token{}).GrantedScope(grant))
+	scope := "issue"
+	assert.Equal(t, "issue", (&OAuth2Token{Scope: &scope}).GrantedScope(grant))
+}
+
+func TestOAuth2Token_AccessTokenScope(t *testing.T) {
+	// tokens issued before scopes were enforced keep full access
+	assert.EqualValues(t, AccessTokenScopeAll, (&OAuth2Token{}).AccessTokenScope())
+	scope := "openid read:repo"
+	assert.EqualValues(t, "read:repo", (&OAuth2Token{Scope: &scope}).AccessTokenScope())
+	scope = ""
+	assert.EqualValues(t, "", (&OAuth2Token{Scope: &scope}).AccessTokenScope())
+}
+
+func TestOAuth2Application_GetClientCredentialsGrant(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	app := AssertExistsAndLoadBean(t, &OAuth2Application{ID: 1}).(*OAuth2Application)
+	grant, err := app.GetClientCredentialsGrant()
+	assert.NoError(t, err)
+	assert.True(t, grant.IsClientCredentials)
+	assert.Equal(t, app.UID, grant.UserID)
+	assert.NotEqual(t, int64(1), grant.ID)
+
+	again, err := app.GetClientCredentialsGrant()
+	assert.NoError(t, err)
+	assert.Equal(t, grant.ID, again.ID)
+
+	// the grant of the owner as a user stays separate
+	userGrant, err := app.GetGrantByUserID(app.UID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), userGrant.ID)
+	grants, err := GetOAuth2GrantsByUserID(app.UID)
+	assert.NoError(t, err)
+	assert.Len(t, grants, 1)
+}
+
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
@@ -0,0 +1,205 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+const (
+	// OAuth2DeviceCodeExpirationTime is how long a device may poll for an access token until the user has to authorize it again
+	OAuth2DeviceCodeExpirationTime = 10 * time.Minute
+	// OAuth2DevicePollingInterval is the minimum number of seconds a device has to wait between polling requests
+	OAuth2DevicePollingInterval = 5
+
+	// userCodeCharset are the characters of user codes, consonants only so they neither resemble nor form words (RFC 8628 section 6.1)
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+)
+
+// OAuth2DeviceAuthorizationStatus is the state of a device authorization request
+type OAuth2DeviceAuthorizationStatus int
+
+const (
+	// OAuth2DeviceAuthorizationPending means the user did not decide yet
+	OAuth2DeviceAuthorizationPending OAuth2DeviceAuthorizationStatus = iota
+	// OAuth2DeviceAuthorizationApproved means the user authorized the device
+	OAuth2DeviceAuthorizationApproved
+	// OAuth2DeviceAuthorizationDenied means the user rejected the request
+	OAuth2DeviceAuthorizationDenied
+)
+
+// OAuth2DeviceAuthorization is a request of a device to act on behalf of the user who enters its user code (RFC 8628).
+// The device polls for an access token with the device code until the user approved or denied the request.
+type OAuth2DeviceAuthorization struct {
+	ID             int64  `xorm:"pk autoincr"`
+	ApplicationID  int64  `xorm:"INDEX"`
+	DeviceCode     string `xorm:"UNIQUE"`
+	UserCode       string `xorm:"UNIQUE"`
+	Scope          string `xorm:"TEXT"`
+	Status         OAuth2DeviceAuthorizationStatus
+	GrantID        int64
+	PollInterval   int64
+	LastPolledUnix timeutil.TimeStamp
+	ValidUntil     timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name to `oauth2_device_authorization`
+func (auth *OAuth2DeviceAuthorization) TableName() string {
+	return "oauth2_device_authorization"
+}
+
+// IsExpired returns true if the device may not use the authorization anymore
+func (auth *OAuth2DeviceAuthorization) IsExpired() bool {
+	return auth.ValidUntil < timeutil.TimeStampNow()
+}
+
+// FormattedUserCode returns the user code in groups of four characters, as it is displayed to the user
+func (auth *OAuth2DeviceAuthorization) FormattedUserCode() string {
+	return auth.UserCode[:userCodeLength/2] + "-" + auth.UserCode[userCodeLength/2:]
+}
+
+// NormalizeOAuth2UserCode converts a user code as entered by the user to the stored form, ignoring case and separators
+func NormalizeOAuth2UserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
+
+func generateOAuth2UserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeCharset)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeCharset[idx.Int64()]
+	}
+	return string(code), nil
+}
+
+// CreateOAuth2DeviceAuthorization starts the authorization of a device for the application
+func (app *OAuth2Application) CreateOAuth2DeviceAuthorization(scope string) (*OAuth2DeviceAuthorization, error) {
+	deviceCode, err := secret.New()
+	if err != nil {
+		return nil, err
+	}
+	auth := &OAuth2DeviceAuthorization{
+		ApplicationID: app.ID,
+		DeviceCode:    deviceCode,
+		Scope:         scope,
+		PollInterval:  OAuth2DevicePollingInterval,
+		ValidUntil:    timeutil.TimeStampNow().AddDuration(OAuth2DeviceCodeExpirationTime),
+	}
+	// user codes are short, retry in the unlikely case of a collision
+	for i := 0; ; i++ {
+		if auth.UserCode, err = generateOAuth2UserCode(); err != nil {
+			return nil, err
+		}
+		has, err := x.Where("user_code = ?", auth.UserCode).Exist(new(OAuth2DeviceAuthorization))
+		if err != nil {
+			return nil, err
+		}
+		if !has || i >= 10 {
+			break
+		}
+	}
+	if _, err := x.Insert(auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// GetOAuth2DeviceAuthorizationByDeviceCode returns the device authorization with the given device code or nil if it does not exist
+func GetOAuth2DeviceAuthorizationByDeviceCode(deviceCode string) (*OAuth2DeviceAuthorization, error) {
+	auth := new(OAuth2DeviceAuthorization)
+	if has, err := x.Where("device_code = ?", deviceCode).Get(auth); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// GetOAuth2DeviceAuthorizationByUserCode returns the pending device authorization with the given user code or nil if it does not exist
+func GetOAuth2DeviceAuthorizationByUserCode(userCode string) (*OAuth2DeviceAuthorization, error) {
+	auth := new(OAuth2DeviceAuthorization)
+	if has, err := x.Where("user_code = ? AND status = ?", NormalizeOAuth2UserCode(userCode), OAuth2DeviceAuthorizationPending).
+		Get(auth); err != nil {
+		return nil, err
+	} else if !has || auth.IsExpired() {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// Poll records a polling request of the device. It returns true if the device polls too often,
+// in which case the interval is increased by five seconds as required by RFC 8628 section 3.5.
+func (auth *OAuth2DeviceAuthorization) Poll() (slowDown bool, err error) {
+	now := timeutil.TimeStampNow()
+	slowDown = auth.LastPolledUnix.Add(auth.PollInterval) > now
+	if slowDown {
+		auth.PollInterval += OAuth2DevicePollingInterval
+	}
+	auth.LastPolledUnix = now
+	_, err = x.ID(auth.ID).Cols("poll_interval", "last_polled_unix").Update(auth)
+	return slowDown, err
+}
+
+// Approve authorizes the device to act on behalf of the user with the requested scope
+func (auth *OAuth2DeviceAuthorization) Approve(userID int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	app, err := getOAuth2ApplicationByID(sess, auth.ApplicationID)
+	if err != nil {
+		return err
+	}
+	grant, err := app.getGrantByUserID(sess, userID)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		if grant, err = app.createGrant(sess, userID, auth.Scope); err != nil {
+			return err
+		}
+	} else if err = grant.addScope(sess, auth.Scope); err != nil {
+		return err
+	}
+
+	auth.Status = OAuth2DeviceAuthorizationApproved
+	auth.GrantID = grant.ID
+	if _, err = sess.ID(auth.ID).Cols("status", "grant_id").Update(auth); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// Deny rejects the authorization request
+func (auth *OAuth2DeviceAuthorization) Deny() error {
+	auth.Status = OAuth2DeviceAuthorizationDenied
+	_, err := x.ID(auth.ID).Cols("status").Update(auth)
+	return err
+}
+
+// Invalidate deletes the device authorization once the device obtained an access token or learned it was denied
+func (auth *OAuth2DeviceAuthorization) Invalidate() error {
+	_, err := x.ID(auth.ID).Delete(new(OAuth2DeviceAuthorization))
+	return err
+}
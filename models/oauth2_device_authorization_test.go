@@ -0,0 +1,75 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuth2DeviceAuthorization(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	app := AssertExistsAndLoadBean(t, &OAuth2Application{ID: 1}).(*OAuth2Application)
+
+	auth, err := app.CreateOAuth2DeviceAuthorization("repo")
+	assert.NoError(t, err)
+	assert.Len(t, auth.UserCode, 8)
+	assert.Regexp(t, `^[B-Z]{4}-[B-Z]{4}$`, auth.FormattedUserCode())
+	assert.True(t, len(auth.DeviceCode) > 32)
+	assert.False(t, auth.IsExpired())
+
+	loaded, err := GetOAuth2DeviceAuthorizationByDeviceCode(auth.DeviceCode)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.ID, loaded.ID)
+	loaded, err = GetOAuth2DeviceAuthorizationByUserCode(" " + auth.FormattedUserCode()[:5] + auth.FormattedUserCode()[5:] + " ")
+	assert.NoError(t, err)
+	assert.Equal(t, auth.ID, loaded.ID)
+	loaded, err = GetOAuth2DeviceAuthorizationByUserCode(NormalizeOAuth2UserCode(auth.FormattedUserCode()))
+	assert.NoError(t, err)
+	assert.Equal(t, auth.ID, loaded.ID)
+	loaded, err = GetOAuth2DeviceAuthorizationByDeviceCode("invalid")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// the first poll is fine, polling again right away is too fast
+	slowDown, err := auth.Poll()
+	assert.NoError(t, err)
+	assert.False(t, slowDown)
+	slowDown, err = auth.Poll()
+	assert.NoError(t, err)
+	assert.True(t, slowDown)
+	AssertExistsAndLoadBean(t, &OAuth2DeviceAuthorization{ID: auth.ID, PollInterval: 2 * OAuth2DevicePollingInterval})
+
+	// approving updates the existing grant of the user
+	assert.NoError(t, auth.Approve(1))
+	AssertExistsAndLoadBean(t, &OAuth2DeviceAuthorization{ID: auth.ID, Status: OAuth2DeviceAuthorizationApproved, GrantID: 1})
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "openid profile repo"})
+	loaded, err = GetOAuth2DeviceAuthorizationByUserCode(auth.UserCode)
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// or creates a new one
+	auth, err = app.CreateOAuth2DeviceAuthorization("")
+	assert.NoError(t, err)
+	assert.NoError(t, auth.Approve(2))
+	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{UserID: 2, ApplicationID: 1}).(*OAuth2Grant)
+	assert.Equal(t, grant.ID, auth.GrantID)
+
+	assert.NoError(t, auth.Invalidate())
+	AssertNotExistsBean(t, &OAuth2DeviceAuthorization{ID: auth.ID})
+
+	auth, err = app.CreateOAuth2DeviceAuthorization("")
+	assert.NoError(t, err)
+	assert.NoError(t, auth.Deny())
+	AssertExistsAndLoadBean(t, &OAuth2DeviceAuthorization{ID: auth.ID, Status: OAuth2DeviceAuthorizationDenied})
+
+	auth, err = app.CreateOAuth2DeviceAuthorization("")
+	assert.NoError(t, err)
+	auth.ValidUntil = timeutil.TimeStampNow() - 1
+	assert.True(t, auth.IsExpired())
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuth2Config_Groups(t *testing.T) {
+	cfg := &OAuth2Config{}
+	gothUser := goth.User{RawData: map[string]interface{}{
+		"groups": []interface{}{"developers", 1, "admins"},
+		"role":   "testers",
+	}}
+	assert.Nil(t, cfg.Groups(gothUser))
+	assert.True(t, cfg.IsAllowed(nil))
+
+	cfg.GroupClaimName = "groups"
+	assert.Equal(t, []string{"developers", "admins"}, cfg.Groups(gothUser))
+	cfg.GroupClaimName = "role"
+	assert.Equal(t, []string{"testers"}, cfg.Groups(gothUser))
+	cfg.GroupClaimName = "missing"
+	assert.Empty(t, cfg.Groups(gothUser))
+
+	cfg.RequiredGroup = "developers"
+	assert.True(t, cfg.IsAllowed([]string{"developers"}))
+	assert.False(t, cfg.IsAllowed([]string{"testers"}))
+	assert.False(t, cfg.IsAllowed(nil))
+
+	mapping, err := cfg.GroupTeamMapping()
+	assert.NoError(t, err)
+	assert.Empty(t, mapping)
+	cfg.GroupTeamMap = `{"developers": {"org3": ["team1"]}}`
+	mapping, err = cfg.GroupTeamMapping()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string][]string{"developers": {"org3": {"team1"}}}, mapping)
+	cfg.GroupTeamMap = `["developers"]`
+	_, err = cfg.GroupTeamMapping()
+	assert.Error(t, err)
+}
+
+func TestSyncOAuth2Groups(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	source := &LoginSource{
+		Type: LoginOAuth2,
+		Name: "oauth2",
+		Cfg: &OAuth2Config{
+			GroupClaimName:      "groups",
+			AdminGroup:          "admins",
+			RestrictedGroup:     "guests",
+			GroupTeamMap:        `{"developers": {"user3": ["test_team"]}, "testers": {"user3": ["team1"]}}`,
+			GroupTeamMapRemoval: true,
+		},
+	}
+	user := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	team1 := AssertExistsAndLoadBean(t, &Team{ID: 2}).(*Team)
+	testTeam := AssertExistsAndLoadBean(t, &Team{ID: 7}).(*Team)
+	assert.True(t, team1.IsMember(user.ID))
+
+	assert.NoError(t, SyncOAuth2Groups(user, source, []string{"developers", "admins", "guests"}))
+	user = AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	assert.True(t, user.IsAdmin)
+	assert.False(t, user.IsRestricted)
+	assert.True(t, testTeam.IsMember(user.ID))
+	assert.False(t, team1.IsMember(user.ID))
+
+	assert.NoError(t, SyncOAuth2Groups(user, source, []string{"testers", "guests"}))
+	user = AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	assert.False(t, user.IsAdmin)
+	assert.True(t, user.IsRestricted)
+	assert.False(t, testTeam.IsMember(user.ID))
+	assert.True(t, team1.IsMember(user.ID))
+
+	// without removal users are only added to the teams of their groups
+	source.OAuth2().GroupTeamMapRemoval = false
+	assert.NoError(t, SyncOAuth2Groups(user, source, []string{"developers"}))
+	assert.True(t, testTeam.IsMember(user.ID))
+	assert.True(t, team1.IsMember(user.ID))
+	user = AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	assert.False(t, user.IsRestricted)
+}
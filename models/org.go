@@ -296,10 +296,19 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		&OrgUser{OrgID: u.ID},
 		&TeamUser{OrgID: u.ID},
 		&TeamUnit{OrgID: u.ID},
+		&Secret{OwnerID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deletePackagesByOwner(e, u.ID); err != nil {
+		return fmt.Errorf("deletePackagesByOwner: %v", err)
+	}
+
+	if err := deleteProjectsByOwnerID(e, u.ID); err != nil {
+		return fmt.Errorf("deleteProjectsByOwnerID: %v", err)
+	}
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -51,7 +51,8 @@ type SearchMembersOptions struct {
 	ListOptions
 }
 
-// SearchTeam search for teams. Caller is responsible to check permissions.
+// SearchTeam search for teams of an organization, or of all organizations if no organization is given.
+// Caller is responsible to check permissions.
 func SearchTeam(opts *SearchTeamOptions) ([]*Team, int64, error) {
 	if opts.Page <= 0 {
 		opts.Page = 1
@@ -72,7 +73,9 @@ func SearchTeam(opts *SearchTeamOptions) ([]*Team, int64, error) {
 		cond = cond.And(keywordCond)
 	}
 
-	cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	if opts.OrgID > 0 {
+		cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	}
 
 	sess := x.NewSession()
 	defer sess.Close()
@@ -0,0 +1,232 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+var (
+	// ErrPackageNotExist indicates a package not exist error
+	ErrPackageNotExist = errors.New("Package does not exist")
+	// ErrPackageAlreadyExist indicates a package already exist error
+	ErrPackageAlreadyExist = errors.New("Package does already exist")
+)
+
+// PackageType specifies the protocol of a package
+type PackageType int
+
+// The package types which are supported by the registry
+const (
+	PackageGeneric PackageType = iota + 1
+	PackageNpm
+	PackageMaven
+	PackageContainer
+)
+
+var packageTypeNames = map[PackageType]string{
+	PackageGeneric:   "generic",
+	PackageNpm:       "npm",
+	PackageMaven:     "maven",
+	PackageContainer: "container",
+}
+
+// Name returns the name of the package type
+func (pt PackageType) Name() string {
+	return packageTypeNames[pt]
+}
+
+// PackageTypeFromName returns the package type with the given name, or 0 if there is none
+func PackageTypeFromName(name string) PackageType {
+	for pt, n := range packageTypeNames {
+		if n == strings.ToLower(name) {
+			return pt
+		}
+	}
+	return 0
+}
+
+// Package represents a package owned by a user or an organization, which may be linked to a repository
+type Package struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID      int64              `xorm:"INDEX"`
+	Type        PackageType        `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
+
+	Owner *User       `xorm:"-"`
+	Repo  *Repository `xorm:"-"`
+}
+
+// LoadAttributes loads the owner and the linked repository of the package
+func (p *Package) LoadAttributes() error {
+	return p.loadAttributes(x)
+}
+
+func (p *Package) loadAttributes(e Engine) (err error) {
+	if p.Owner == nil {
+		if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+			return err
+		}
+	}
+	if p.Repo == nil && p.RepoID != 0 {
+		if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil && !IsErrRepoNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// TryInsertPackage inserts a package. If a package with the same owner, type and name exists already,
+// that package is returned together with ErrPackageAlreadyExist.
+func TryInsertPackage(p *Package) (*Package, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	p.LowerName = strings.ToLower(p.Name)
+
+	existing := &Package{
+		OwnerID:   p.OwnerID,
+		Type:      p.Type,
+		LowerName: p.LowerName,
+	}
+	has, err := sess.Get(existing)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return existing, ErrPackageAlreadyExist
+	}
+	if _, err := sess.Insert(p); err != nil {
+		return nil, err
+	}
+	return p, sess.Commit()
+}
+
+// GetPackageByID returns the package with the given id
+func GetPackageByID(id int64) (*Package, error) {
+	p := &Package{}
+	has, err := x.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist
+	}
+	return p, nil
+}
+
+// GetPackageByName returns the package of an owner with the given type and name
+func GetPackageByName(ownerID int64, packageType PackageType, name string) (*Package, error) {
+	p := &Package{
+		OwnerID:   ownerID,
+		Type:      packageType,
+		LowerName: strings.ToLower(name),
+	}
+	has, err := x.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist
+	}
+	return p, nil
+}
+
+// SetPackageRepository links the package to the repository, or unlinks it if repoID is 0
+func SetPackageRepository(packageID, repoID int64) error {
+	_, err := x.ID(packageID).Cols("repo_id").Update(&Package{RepoID: repoID})
+	return err
+}
+
+func unlinkRepositoryFromPackages(e Engine, repoID int64) error {
+	_, err := e.Where("repo_id = ?", repoID).Cols("repo_id").Update(&Package{})
+	return err
+}
+
+// deletePackageIfUnused deletes the package if it has no versions left
+func deletePackageIfUnused(e Engine, packageID int64) error {
+	count, err := e.Where("package_id = ?", packageID).Count(&PackageVersion{})
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = e.ID(packageID).Delete(&Package{})
+	return err
+}
+
+// deletePackagesByOwner deletes all packages of an owner. The blobs of the packages are removed by the package cleanup.
+func deletePackagesByOwner(e Engine, ownerID int64) error {
+	packageIDs := builder.Select("id").From("package").Where(builder.Eq{"owner_id": ownerID})
+	versionIDs := builder.Select("id").From("package_version").Where(builder.In("package_id", packageIDs))
+	if _, err := e.Where(builder.In("version_id", versionIDs)).Delete(&PackageFile{}); err != nil {
+		return err
+	}
+	if _, err := e.Where(builder.In("package_id", packageIDs)).Delete(&PackageVersion{}); err != nil {
+		return err
+	}
+	_, err := e.Where("owner_id = ?", ownerID).Delete(&Package{})
+	return err
+}
+
+// GetPackageAccessMode returns the access mode the doer has to packages of the owner.
+// Packages which are linked to a repository follow the permissions of that repository,
+// unless the doer owns the package. p may be nil if the package does not exist yet.
+func GetPackageAccessMode(doer, owner *User, p *Package) (AccessMode, error) {
+	if doer != nil && doer.IsAdmin {
+		return AccessModeOwner, nil
+	}
+
+	mode := AccessModeNone
+	if doer != nil {
+		if doer.ID == owner.ID {
+			return AccessModeOwner, nil
+		}
+		if owner.IsOrganization() {
+			isOwner, err := owner.IsOwnedBy(doer.ID)
+			if err != nil {
+				return AccessModeNone, err
+			}
+			if isOwner {
+				return AccessModeOwner, nil
+			}
+			teams, err := owner.GetUserTeams(doer.ID)
+			if err != nil {
+				return AccessModeNone, err
+			}
+			for _, t := range teams {
+				if t.Authorize > mode {
+					mode = t.Authorize
+				}
+			}
+			if mode < AccessModeRead && len(teams) > 0 {
+				mode = AccessModeRead
+			}
+		}
+	}
+	if mode == AccessModeNone && HasOrgVisible(owner, doer) {
+		mode = AccessModeRead
+	}
+
+	if p != nil && p.RepoID != 0 {
+		repo, err := GetRepositoryByID(p.RepoID)
+		if err != nil {
+			return AccessModeNone, err
+		}
+		perm, err := GetUserRepoPermission(repo, doer)
+		if err != nil {
+			return AccessModeNone, err
+		}
+		mode = perm.UnitAccessMode(UnitTypeCode)
+	}
+	return mode, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ErrPackageBlobNotExist indicates a package blob not exist error
+var ErrPackageBlobNotExist = errors.New("Package blob does not exist")
+
+// PackageBlob represents the content of package files. Equal contents are only stored once.
+type PackageBlob struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Size        int64              `xorm:"NOT NULL DEFAULT 0"`
+	HashMD5     string             `xorm:"hash_md5 char(32) INDEX NOT NULL"`
+	HashSHA1    string             `xorm:"hash_sha1 char(40) INDEX NOT NULL"`
+	HashSHA256  string             `xorm:"hash_sha256 char(64) UNIQUE NOT NULL"`
+	HashSHA512  string             `xorm:"hash_sha512 char(128) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
+}
+
+// GetOrInsertPackageBlob inserts a blob. If a blob with the same content exists already, that one is returned
+// and exists is true.
+func GetOrInsertPackageBlob(pb *PackageBlob) (_ *PackageBlob, exists bool, err error) {
+	existing := &PackageBlob{HashSHA256: pb.HashSHA256}
+	has, err := x.Get(existing)
+	if err != nil {
+		return nil, false, err
+	}
+	if has {
+		return existing, true, nil
+	}
+	if _, err := x.Insert(pb); err != nil {
+		return nil, false, err
+	}
+	return pb, false, nil
+}
+
+// GetPackageBlobByID returns the blob with the given id
+func GetPackageBlobByID(id int64) (*PackageBlob, error) {
+	pb := &PackageBlob{}
+	has, err := x.ID(id).Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageBlobNotExist
+	}
+	return pb, nil
+}
+
+// GetPackageBlobForPackage returns the blob with the given SHA256 hash if a file of the package references it
+func GetPackageBlobForPackage(packageID int64, hashSHA256 string) (*PackageBlob, error) {
+	pb := &PackageBlob{}
+	has, err := x.
+		Join("INNER", "package_file", "package_file.blob_id = package_blob.id").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Where("package_version.package_id = ? AND package_blob.hash_sha256 = ?", packageID, hashSHA256).
+		Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageBlobNotExist
+	}
+	return pb, nil
+}
+
+// FindUnreferencedPackageBlobs returns the blobs which no package file references anymore
+func FindUnreferencedPackageBlobs() ([]*PackageBlob, error) {
+	pbs := make([]*PackageBlob, 0, 10)
+	return pbs, x.
+		Where(builder.NotIn("id", builder.Select("blob_id").From("package_file"))).
+		Find(&pbs)
+}
+
+// DeletePackageBlob deletes a blob
+func DeletePackageBlob(pb *PackageBlob) error {
+	_, err := x.ID(pb.ID).Delete(&PackageBlob{})
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+var (
+	// ErrPackageFileNotExist indicates a package file not exist error
+	ErrPackageFileNotExist = errors.New("Package file does not exist")
+	// ErrPackageFileAlreadyExist indicates a package file already exist error
+	ErrPackageFileAlreadyExist = errors.New("Package file does already exist")
+)
+
+// PackageFile represents a file of a package version, whose content is stored in a blob
+type PackageFile struct {
+	ID          int64              `xorm:"pk autoincr"`
+	VersionID   int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID      int64              `xorm:"INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
+
+	Blob *PackageBlob `xorm:"-"`
+}
+
+// LoadBlob loads the blob of the file
+func (pf *PackageFile) LoadBlob() (err error) {
+	if pf.Blob == nil {
+		pf.Blob, err = GetPackageBlobByID(pf.BlobID)
+	}
+	return err
+}
+
+// InsertPackageFile inserts a file. If the version has a file with the same name already, ErrPackageFileAlreadyExist is returned.
+func InsertPackageFile(pf *PackageFile) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	pf.LowerName = strings.ToLower(pf.Name)
+
+	has, err := sess.Exist(&PackageFile{
+		VersionID: pf.VersionID,
+		LowerName: pf.LowerName,
+	})
+	if err != nil {
+		return err
+	}
+	if has {
+		return ErrPackageFileAlreadyExist
+	}
+	if _, err := sess.Insert(pf); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetPackageFilesByVersionID returns the files of a version with their blobs
+func GetPackageFilesByVersionID(versionID int64) ([]*PackageFile, error) {
+	pfs := make([]*PackageFile, 0, 5)
+	if err := x.Where("version_id = ?", versionID).OrderBy("id").Find(&pfs); err != nil {
+		return nil, err
+	}
+	for _, pf := range pfs {
+		if err := pf.LoadBlob(); err != nil {
+			return nil, err
+		}
+	}
+	return pfs, nil
+}
+
+// GetPackageFileByName returns the file of a version with the given name
+func GetPackageFileByName(versionID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{
+		VersionID: versionID,
+		LowerName: strings.ToLower(name),
+	}
+	has, err := x.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist
+	}
+	return pf, pf.LoadBlob()
+}
+
+// DeletePackageFile deletes a file. The blob of the file is removed by the package cleanup.
+func DeletePackageFile(pf *PackageFile) error {
+	_, err := x.ID(pf.ID).Delete(&PackageFile{})
+	return err
+}
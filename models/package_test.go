@@ -0,0 +1,141 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryInsertPackage(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p, err := TryInsertPackage(&Package{OwnerID: 2, Type: PackageGeneric, Name: "Test"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", p.LowerName)
+
+	existing, err := TryInsertPackage(&Package{OwnerID: 2, Type: PackageGeneric, Name: "TEST"})
+	assert.Equal(t, ErrPackageAlreadyExist, err)
+	assert.Equal(t, p.ID, existing.ID)
+
+	other, err := TryInsertPackage(&Package{OwnerID: 2, Type: PackageNpm, Name: "test"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, p.ID, other.ID)
+
+	p, err = GetPackageByName(2, PackageGeneric, "tESt")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", p.Name)
+
+	_, err = GetPackageByName(3, PackageGeneric, "test")
+	assert.Equal(t, ErrPackageNotExist, err)
+}
+
+func TestDeletePackageVersion(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p, err := TryInsertPackage(&Package{OwnerID: 2, Type: PackageGeneric, Name: "test"})
+	assert.NoError(t, err)
+
+	pv1, err := InsertPackageVersion(&PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "1.0"})
+	assert.NoError(t, err)
+	pv2, err := InsertPackageVersion(&PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "2.0"})
+	assert.NoError(t, err)
+	_, err = InsertPackageVersion(&PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "2.0"})
+	assert.Equal(t, ErrPackageVersionAlreadyExist, err)
+
+	pb, exists, err := GetOrInsertPackageBlob(&PackageBlob{Size: 1, HashSHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, InsertPackageFile(&PackageFile{VersionID: pv1.ID, BlobID: pb.ID, Name: "file.bin"}))
+	assert.Equal(t, ErrPackageFileAlreadyExist, InsertPackageFile(&PackageFile{VersionID: pv1.ID, BlobID: pb.ID, Name: "FILE.bin"}))
+
+	found, err := GetPackageBlobForPackage(p.ID, pb.HashSHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, pb.ID, found.ID)
+
+	pbs, err := FindUnreferencedPackageBlobs()
+	assert.NoError(t, err)
+	assert.Len(t, pbs, 0)
+
+	assert.NoError(t, DeletePackageVersion(pv1))
+	_, err = GetPackageBlobForPackage(p.ID, pb.HashSHA256)
+	assert.Equal(t, ErrPackageBlobNotExist, err)
+	pbs, err = FindUnreferencedPackageBlobs()
+	assert.NoError(t, err)
+	assert.Len(t, pbs, 1)
+
+	_, err = GetPackageByID(p.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, DeletePackageVersion(pv2))
+	_, err = GetPackageByID(p.ID)
+	assert.Equal(t, ErrPackageNotExist, err)
+}
+
+func TestSearchPackageVersions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, name := range []string{"foo", "bar"} {
+		p, err := TryInsertPackage(&Package{OwnerID: 2, Type: PackageGeneric, Name: name})
+		assert.NoError(t, err)
+		_, err = InsertPackageVersion(&PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "1.0"})
+		assert.NoError(t, err)
+		_, err = InsertPackageVersion(&PackageVersion{PackageID: p.ID, CreatorID: 2, Version: "_internal", IsInternal: true})
+		assert.NoError(t, err)
+	}
+
+	pvs, count, err := SearchPackageVersions(&PackageSearchOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, pvs, 2)
+
+	pvs, count, err = SearchPackageVersions(&PackageSearchOptions{OwnerID: 2, Query: "FO"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.NoError(t, pvs[0].LoadAttributes())
+	assert.Equal(t, "foo", pvs[0].Package.Name)
+
+	_, count, err = SearchPackageVersions(&PackageSearchOptions{OwnerID: 2, Type: PackageNpm})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestGetPackageAccessMode(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	other := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+
+	p, err := TryInsertPackage(&Package{OwnerID: owner.ID, Type: PackageGeneric, Name: "test"})
+	assert.NoError(t, err)
+
+	for _, c := range []struct {
+		doer     *User
+		expected AccessMode
+	}{
+		{admin, AccessModeOwner},
+		{owner, AccessModeOwner},
+		{other, AccessModeRead},
+		{nil, AccessModeRead},
+	} {
+		mode, err := GetPackageAccessMode(c.doer, owner, p)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, mode)
+	}
+
+	// Packages linked to a private repository are hidden from users without access to it
+	assert.NoError(t, SetPackageRepository(p.ID, 2))
+	p, err = GetPackageByID(p.ID)
+	assert.NoError(t, err)
+
+	mode, err := GetPackageAccessMode(other, owner, p)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessModeNone, mode)
+	mode, err = GetPackageAccessMode(owner, owner, p)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessModeOwner, mode)
+}
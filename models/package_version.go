@@ -0,0 +1,195 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+var (
+	// ErrPackageVersionNotExist indicates a package version not exist error
+	ErrPackageVersionNotExist = errors.New("Package version does not exist")
+	// ErrPackageVersionAlreadyExist indicates a package version already exist error
+	ErrPackageVersionAlreadyExist = errors.New("Package version does already exist")
+)
+
+// PackageVersion represents a version of a package
+type PackageVersion struct {
+	ID           int64  `xorm:"pk autoincr"`
+	PackageID    int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID    int64  `xorm:"NOT NULL DEFAULT 0"`
+	Version      string `xorm:"NOT NULL"`
+	LowerVersion string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	// IsInternal marks versions which hold files for the bookkeeping of a protocol and are never shown
+	IsInternal    bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	MetadataJSON  string             `xorm:"metadata_json TEXT"`
+	DownloadCount int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
+
+	Package *Package `xorm:"-"`
+	Creator *User    `xorm:"-"`
+}
+
+// LoadAttributes loads the package and the creator of the version
+func (pv *PackageVersion) LoadAttributes() (err error) {
+	if pv.Package == nil {
+		if pv.Package, err = GetPackageByID(pv.PackageID); err != nil {
+			return err
+		}
+	}
+	if err = pv.Package.LoadAttributes(); err != nil {
+		return err
+	}
+	if pv.Creator == nil {
+		if pv.Creator, err = GetUserByID(pv.CreatorID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			pv.Creator = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// InsertPackageVersion inserts a version. If the version exists already, ErrPackageVersionAlreadyExist is returned
+// together with the existing version.
+func InsertPackageVersion(pv *PackageVersion) (*PackageVersion, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	pv.LowerVersion = strings.ToLower(pv.Version)
+
+	existing := &PackageVersion{
+		PackageID:    pv.PackageID,
+		LowerVersion: pv.LowerVersion,
+	}
+	has, err := sess.Get(existing)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return existing, ErrPackageVersionAlreadyExist
+	}
+	if _, err := sess.Insert(pv); err != nil {
+		return nil, err
+	}
+	return pv, sess.Commit()
+}
+
+// UpdatePackageVersion updates the metadata of a version
+func UpdatePackageVersion(pv *PackageVersion) error {
+	_, err := x.ID(pv.ID).Cols("metadata_json").Update(pv)
+	return err
+}
+
+// IncrementPackageVersionDownloadCount increments the download counter of a version
+func IncrementPackageVersionDownloadCount(pv *PackageVersion) error {
+	_, err := x.Exec("UPDATE `package_version` SET download_count=download_count+1 WHERE id=?", pv.ID)
+	return err
+}
+
+// GetPackageVersionByID returns the version with the given id
+func GetPackageVersionByID(id int64) (*PackageVersion, error) {
+	pv := &PackageVersion{}
+	has, err := x.ID(id).Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist
+	}
+	return pv, nil
+}
+
+// GetPackageVersionByName returns the version of a package
+func GetPackageVersionByName(packageID int64, version string) (*PackageVersion, error) {
+	pv := &PackageVersion{
+		PackageID:    packageID,
+		LowerVersion: strings.ToLower(version),
+	}
+	has, err := x.Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist
+	}
+	return pv, nil
+}
+
+// GetPackageVersionsByPackageID returns the versions of a package, newest first, without the internal ones
+func GetPackageVersionsByPackageID(packageID int64) ([]*PackageVersion, error) {
+	pvs := make([]*PackageVersion, 0, 10)
+	return pvs, x.
+		Where("package_id = ? AND is_internal = ?", packageID, false).
+		OrderBy("created_unix DESC, id DESC").
+		Find(&pvs)
+}
+
+// DeletePackageVersion deletes a version and its files, and the package if this was its last version.
+// The blobs of the files are removed by the package cleanup.
+func DeletePackageVersion(pv *PackageVersion) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("version_id = ?", pv.ID).Delete(&PackageFile{}); err != nil {
+		return err
+	}
+	if _, err := sess.ID(pv.ID).Delete(&PackageVersion{}); err != nil {
+		return err
+	}
+	if err := deletePackageIfUnused(sess, pv.PackageID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// PackageSearchOptions are options for SearchPackageVersions
+type PackageSearchOptions struct {
+	ListOptions
+	OwnerID int64
+	RepoID  int64
+	Type    PackageType
+	Query   string
+}
+
+func (opts *PackageSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond().And(builder.Eq{"package_version.is_internal": false})
+	if opts.OwnerID != 0 {
+		cond = cond.And(builder.Eq{"package.owner_id": opts.OwnerID})
+	}
+	if opts.RepoID != 0 {
+		cond = cond.And(builder.Eq{"package.repo_id": opts.RepoID})
+	}
+	if opts.Type != 0 {
+		cond = cond.And(builder.Eq{"package.type": opts.Type})
+	}
+	if opts.Query != "" {
+		cond = cond.And(builder.Like{"package.lower_name", strings.ToLower(opts.Query)})
+	}
+	return cond
+}
+
+// SearchPackageVersions returns the package versions matching the options, newest first
+func SearchPackageVersions(opts *PackageSearchOptions) ([]*PackageVersion, int64, error) {
+	sess := x.Where(opts.toConds()).
+		Join("INNER", "package", "package.id = package_version.package_id")
+	if opts.Page > 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+
+	pvs := make([]*PackageVersion, 0, 10)
+	count, err := sess.OrderBy("package_version.created_unix DESC, package_version.id DESC").FindAndCount(&pvs)
+	return pvs, count, err
+}
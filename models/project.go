@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
@@ -43,6 +44,7 @@ type Project struct {
 	Title       string `xorm:"INDEX NOT NULL"`
 	Description string `xorm:"TEXT"`
 	RepoID      int64  `xorm:"INDEX"`
+	OwnerID     int64  `xorm:"INDEX"`
 	CreatorID   int64  `xorm:"NOT NULL"`
 	IsClosed    bool   `xorm:"INDEX"`
 	BoardType   ProjectBoardType
@@ -67,7 +69,7 @@ func GetProjectsConfig() []ProjectsConfig {
 // IsProjectTypeValid checks if a project type is valid
 func IsProjectTypeValid(p ProjectType) bool {
 	switch p {
-	case ProjectTypeRepository:
+	case ProjectTypeIndividual, ProjectTypeRepository, ProjectTypeOrganization:
 		return true
 	default:
 		return false
@@ -77,21 +79,27 @@ func IsProjectTypeValid(p ProjectType) bool {
 // ProjectSearchOptions are options for GetProjects
 type ProjectSearchOptions struct {
 	RepoID   int64
+	OwnerID  int64
 	Page     int
+	PageSize int // defaults to the issue paging number of the UI
 	IsClosed util.OptionalBool
 	SortType string
 	Type     ProjectType
 }
 
-// GetProjects returns a list of all projects that have been created in the repository
+// GetProjects returns a list of all projects that have been created in the repository or by the owner
 func GetProjects(opts ProjectSearchOptions) ([]*Project, int64, error) {
 	return getProjects(x, opts)
 }
 
-func getProjects(e Engine, opts ProjectSearchOptions) ([]*Project, int64, error) {
-	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
-
-	var cond builder.Cond = builder.Eq{"repo_id": opts.RepoID}
+func (opts ProjectSearchOptions) toConds() builder.Cond {
+	var cond = builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
 	switch opts.IsClosed {
 	case util.OptionalBoolTrue:
 		cond = cond.And(builder.Eq{"is_closed": true})
@@ -102,7 +110,18 @@ func getProjects(e Engine, opts ProjectSearchOptions) ([]*Project, int64, error)
 	if opts.Type > 0 {
 		cond = cond.And(builder.Eq{"type": opts.Type})
 	}
+	return cond
+}
+
+// CountProjects returns the number of projects matching the options
+func CountProjects(opts ProjectSearchOptions) (int64, error) {
+	return x.Where(opts.toConds()).Count(new(Project))
+}
+
+func getProjects(e Engine, opts ProjectSearchOptions) ([]*Project, int64, error) {
+	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
 
+	cond := opts.toConds()
 	count, err := e.Where(cond).Count(new(Project))
 	if err != nil {
 		return nil, 0, fmt.Errorf("Count: %v", err)
@@ -111,7 +130,11 @@ func getProjects(e Engine, opts ProjectSearchOptions) ([]*Project, int64, error)
 	e = e.Where(cond)
 
 	if opts.Page > 0 {
-		e = e.Limit(setting.UI.IssuePagingNum, (opts.Page-1)*setting.UI.IssuePagingNum)
+		pageSize := opts.PageSize
+		if pageSize <= 0 {
+			pageSize = setting.UI.IssuePagingNum
+		}
+		e = e.Limit(pageSize, (opts.Page-1)*pageSize)
 	}
 
 	switch opts.SortType {
@@ -149,8 +172,10 @@ func NewProject(p *Project) error {
 		return err
 	}
 
-	if _, err := sess.Exec("UPDATE `repository` SET num_projects = num_projects + 1 WHERE id = ?", p.RepoID); err != nil {
-		return err
+	if p.Type == ProjectTypeRepository {
+		if _, err := sess.Exec("UPDATE `repository` SET num_projects = num_projects + 1 WHERE id = ?", p.RepoID); err != nil {
+			return err
+		}
 	}
 
 	if err := createBoardsForProjectsType(sess, p); err != nil {
@@ -259,7 +284,7 @@ func changeProjectStatus(e Engine, p *Project, isClosed bool) error {
 	if err != nil {
 		return err
 	}
-	if count < 1 {
+	if count < 1 || p.Type != ProjectTypeRepository {
 		return nil
 	}
 
@@ -302,5 +327,109 @@ func deleteProjectByID(e Engine, id int64) error {
 		return err
 	}
 
+	if p.Type != ProjectTypeRepository {
+		return nil
+	}
 	return updateRepositoryProjectCount(e, p.RepoID)
 }
+
+func deleteProjectsByOwnerID(e Engine, ownerID int64) error {
+	projectIDs := make([]int64, 0, 10)
+	if err := e.Table("project").Where("owner_id = ?", ownerID).Cols("id").Find(&projectIDs); err != nil {
+		return err
+	}
+	for _, id := range projectIDs {
+		if err := deleteProjectByID(e, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Link returns the relative URL of the project
+func (p *Project) Link() string {
+	return p.link(false)
+}
+
+// HTMLURL returns the absolute URL of the project
+func (p *Project) HTMLURL() string {
+	return p.link(true)
+}
+
+func (p *Project) link(absolute bool) string {
+	if p.Type == ProjectTypeRepository {
+		repo, err := GetRepositoryByID(p.RepoID)
+		if err != nil {
+			log.Error("GetRepositoryByID(%d): %v", p.RepoID, err)
+			return ""
+		}
+		if absolute {
+			return fmt.Sprintf("%s/projects/%d", repo.HTMLURL(), p.ID)
+		}
+		return fmt.Sprintf("%s/projects/%d", repo.Link(), p.ID)
+	}
+
+	owner, err := GetUserByID(p.OwnerID)
+	if err != nil {
+		log.Error("GetUserByID(%d): %v", p.OwnerID, err)
+		return ""
+	}
+	if absolute {
+		return fmt.Sprintf("%s/-/projects/%d", owner.HTMLURL(), p.ID)
+	}
+	return fmt.Sprintf("%s/-/projects/%d", owner.HomeLink(), p.ID)
+}
+
+// GetProjectAccessMode returns the access the doer has to the project.
+// Read access allows to see the project and its boards, write access to change them.
+func GetProjectAccessMode(p *Project, doer *User) (AccessMode, error) {
+	if UnitTypeProjects.UnitGlobalDisabled() {
+		return AccessModeNone, nil
+	}
+
+	if p.Type == ProjectTypeRepository {
+		repo, err := GetRepositoryByID(p.RepoID)
+		if err != nil {
+			return AccessModeNone, err
+		}
+		perm, err := GetUserRepoPermission(repo, doer)
+		if err != nil {
+			return AccessModeNone, err
+		}
+		if perm.CanWrite(UnitTypeProjects) && !repo.IsArchived {
+			return AccessModeWrite, nil
+		} else if perm.CanRead(UnitTypeProjects) {
+			return AccessModeRead, nil
+		}
+		return AccessModeNone, nil
+	}
+
+	owner, err := GetUserByID(p.OwnerID)
+	if err != nil {
+		return AccessModeNone, err
+	}
+	if owner.IsOrganization() && !HasOrgVisible(owner, doer) {
+		return AccessModeNone, nil
+	}
+	canWrite, err := CanWriteOwnerProjects(owner, doer)
+	if err != nil {
+		return AccessModeNone, err
+	} else if canWrite {
+		return AccessModeWrite, nil
+	}
+	return AccessModeRead, nil
+}
+
+// CanWriteOwnerProjects checks if the doer is allowed to manage the projects of a user or organization
+func CanWriteOwnerProjects(owner, doer *User) (bool, error) {
+	if doer == nil {
+		return false, nil
+	}
+	if doer.IsAdmin || doer.ID == owner.ID {
+		return true, nil
+	}
+	if !owner.IsOrganization() {
+		return false, nil
+	}
+	return owner.IsOrgMember(doer.ID)
+}
@@ -18,6 +18,9 @@ type ProjectIssue struct {
 
 	// If 0, then it has not been added to a specific board in the project
 	ProjectBoardID int64 `xorm:"INDEX"`
+
+	// the position of the card on its board
+	Sorting int64 `xorm:"NOT NULL DEFAULT 0"`
 }
 
 func deleteProjectIssuesByProjectID(e Engine, projectID int64) error {
@@ -89,8 +92,7 @@ func (i *Issue) projectBoardID(e Engine) int64 {
 func (p *Project) NumIssues() int {
 	c, err := x.Table("project_issue").
 		Where("project_id=?", p.ID).
-		GroupBy("issue_id").
-		Cols("issue_id").
+		Select("count(DISTINCT issue_id)").
 		Count()
 	if err != nil {
 		return 0
@@ -122,6 +124,34 @@ func (p *Project) NumOpenIssues() int {
 	return int(c)
 }
 
+// FilterReadableIssues returns the issues of the list which the doer is allowed to read.
+// Projects of users and organizations hold issues of any repository, so the permission is checked per issue.
+func FilterReadableIssues(issues IssueList, doer *User) (IssueList, error) {
+	if _, err := issues.LoadRepositories(); err != nil {
+		return nil, err
+	}
+
+	perms := make(map[int64]Permission)
+	readable := make(IssueList, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Repo == nil {
+			continue
+		}
+		perm, ok := perms[issue.RepoID]
+		if !ok {
+			var err error
+			if perm, err = GetUserRepoPermission(issue.Repo, doer); err != nil {
+				return nil, err
+			}
+			perms[issue.RepoID] = perm
+		}
+		if perm.CanReadIssuesOrPulls(issue.IsPull) {
+			readable = append(readable, issue)
+		}
+	}
+	return readable, nil
+}
+
 // ChangeProjectAssign changes the project associated with an issue
 func ChangeProjectAssign(issue *Issue, doer *User, newProjectID int64) error {
 	sess := x.NewSession()
@@ -177,6 +207,15 @@ func addUpdateIssueProject(e *xorm.Session, issue *Issue, doer *User, newProject
 
 // MoveIssueAcrossProjectBoards move a card from one board to another
 func MoveIssueAcrossProjectBoards(issue *Issue, board *ProjectBoard) error {
+	return moveIssueOnProjectBoards(issue, &ProjectIssue{ProjectBoardID: board.ID}, "project_board_id")
+}
+
+// MoveIssueToProjectBoard moves a card to a board and sets its position on the board
+func MoveIssueToProjectBoard(issue *Issue, board *ProjectBoard, sorting int64) error {
+	return moveIssueOnProjectBoards(issue, &ProjectIssue{ProjectBoardID: board.ID, Sorting: sorting}, "project_board_id", "sorting")
+}
+
+func moveIssueOnProjectBoards(issue *Issue, update *ProjectIssue, cols ...string) error {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
@@ -193,8 +232,7 @@ func MoveIssueAcrossProjectBoards(issue *Issue, board *ProjectBoard) error {
 		return fmt.Errorf("issue has to be added to a project first")
 	}
 
-	pis.ProjectBoardID = board.ID
-	if _, err := sess.ID(pis.ID).Cols("project_board_id").Update(&pis); err != nil {
+	if _, err := sess.ID(pis.ID).Cols(cols...).Update(update); err != nil {
 		return err
 	}
 
@@ -5,9 +5,11 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,9 +21,9 @@ func TestIsProjectTypeValid(t *testing.T) {
 		typ   ProjectType
 		valid bool
 	}{
-		{ProjectTypeIndividual, false},
+		{ProjectTypeIndividual, true},
 		{ProjectTypeRepository, true},
-		{ProjectTypeOrganization, false},
+		{ProjectTypeOrganization, true},
 		{UnknownType, false},
 	}
 
@@ -80,3 +82,82 @@ func TestProject(t *testing.T) {
 
 	assert.True(t, projectFromDB.IsClosed)
 }
+
+func TestOwnerProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := &Project{
+		Type:      ProjectTypeOrganization,
+		BoardType: ProjectBoardTypeBasicKanban,
+		Title:     "Organization Project",
+		OwnerID:   3,
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProject(project))
+
+	projects, count, err := GetProjects(ProjectSearchOptions{OwnerID: 3})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, projects, 1) {
+		assert.Equal(t, project.ID, projects[0].ID)
+	}
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	assert.Equal(t, fmt.Sprintf("%s/-/projects/%d", org.HomeLink(), project.ID), project.Link())
+
+	// Issues of different repositories can be added to the project
+	assert.NoError(t, ChangeProjectAssign(AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue), &User{ID: 2}, project.ID))
+	assert.NoError(t, ChangeProjectAssign(AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue), &User{ID: 2}, project.ID))
+	assert.Equal(t, 2, project.NumIssues())
+
+	assert.NoError(t, ChangeProjectStatus(project, true))
+	count, err = CountProjects(ProjectSearchOptions{OwnerID: 3, IsClosed: util.OptionalBoolTrue})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	assert.NoError(t, DeleteProjectByID(project.ID))
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: project.ID})
+	CheckConsistencyFor(t, &Repository{})
+}
+
+func TestCanWriteOwnerProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user5 := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+
+	test := func(owner, doer *User, expected bool) {
+		canWrite, err := CanWriteOwnerProjects(owner, doer)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, canWrite)
+	}
+	test(org, user2, true)
+	test(org, user5, false)
+	test(org, nil, false)
+	test(user5, user5, true)
+	test(user5, user2, false)
+}
+
+func TestFilterReadableIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// issue 1 belongs to a public repository, issue 4 to a private repository of user2
+	issues := IssueList{
+		AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue),
+		AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue),
+	}
+
+	readable, err := FilterReadableIssues(issues, AssertExistsAndLoadBean(t, &User{ID: 2}).(*User))
+	assert.NoError(t, err)
+	assert.Len(t, readable, 2)
+
+	readable, err = FilterReadableIssues(issues, AssertExistsAndLoadBean(t, &User{ID: 5}).(*User))
+	assert.NoError(t, err)
+	if assert.Len(t, readable, 1) {
+		assert.EqualValues(t, 1, readable[0].ID)
+	}
+
+	readable, err = FilterReadableIssues(issues, nil)
+	assert.NoError(t, err)
+	assert.Len(t, readable, 1)
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// PullAutoMerge represents a pull request scheduled to be merged once all checks succeed
+type PullAutoMerge struct {
+	ID          int64              `xorm:"pk autoincr"`
+	PullID      int64              `xorm:"UNIQUE"`
+	DoerID      int64              `xorm:"NOT NULL"`
+	Doer        *User              `xorm:"-"`
+	MergeStyle  MergeStyle         `xorm:"varchar(30)"`
+	Message     string             `xorm:"LONGTEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// LoadDoer loads the user who scheduled the auto merge
+func (pam *PullAutoMerge) LoadDoer() (err error) {
+	if pam.Doer != nil {
+		return nil
+	}
+	pam.Doer, err = GetUserByID(pam.DoerID)
+	return err
+}
+
+// ScheduleAutoMerge schedules a pull request to be merged when all checks succeed
+// and records it in the timeline of the pull request.
+func ScheduleAutoMerge(doer *User, pull *PullRequest, style MergeStyle, message string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullAutoMerge{PullID: pull.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrAlreadyScheduledToAutoMerge{PullID: pull.ID}
+	}
+
+	if _, err := sess.Insert(&PullAutoMerge{
+		DoerID:     doer.ID,
+		PullID:     pull.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return err
+	}
+
+	if err := createAutoMergeComment(sess, CommentTypePRScheduledToAutoMerge, pull, doer); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// CancelScheduledAutoMerge removes the scheduled auto merge of a pull request
+// and records it in the timeline of the pull request.
+func CancelScheduledAutoMerge(doer *User, pull *PullRequest) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullAutoMerge{PullID: pull.ID}); err != nil {
+		return err
+	} else if !exist {
+		return ErrNotScheduledToAutoMerge{PullID: pull.ID}
+	}
+
+	if _, err := sess.Delete(&PullAutoMerge{PullID: pull.ID}); err != nil {
+		return err
+	}
+
+	if err := createAutoMergeComment(sess, CommentTypePRUnScheduledToAutoMerge, pull, doer); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func createAutoMergeComment(e *xorm.Session, typ CommentType, pull *PullRequest, doer *User) error {
+	if err := pull.loadIssue(e); err != nil {
+		return err
+	}
+	if err := pull.loadBaseRepo(e); err != nil {
+		return err
+	}
+
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:  typ,
+		Doer:  doer,
+		Repo:  pull.BaseRepo,
+		Issue: pull.Issue,
+	})
+	return err
+}
+
+// GetScheduledAutoMergeByPullID returns the scheduled auto merge of a pull request, if any
+func GetScheduledAutoMergeByPullID(pullID int64) (bool, *PullAutoMerge, error) {
+	scheduledPRM := new(PullAutoMerge)
+	exists, err := x.Where("pull_id = ?", pullID).Get(scheduledPRM)
+	if err != nil || !exists {
+		return false, nil, err
+	}
+	return true, scheduledPRM, scheduledPRM.LoadDoer()
+}
+
+// DeleteScheduledAutoMerge removes the scheduled auto merge of a pull request without
+// leaving a timeline comment, e.g. once it has been merged.
+func DeleteScheduledAutoMerge(pullID int64) error {
+	_, err := x.Delete(&PullAutoMerge{PullID: pullID})
+	return err
+}
+
+// GetScheduledAutoMergePullRequests returns the unmerged pull requests into a repository
+// which are scheduled to auto merge
+func GetScheduledAutoMergePullRequests(baseRepoID int64) ([]*PullRequest, error) {
+	prs := make([]*PullRequest, 0, 5)
+	return prs, x.Join("INNER", "pull_auto_merge", "pull_auto_merge.pull_id = pull_request.id").
+		Where("pull_request.base_repo_id = ? AND pull_request.has_merged = ?", baseRepoID, false).
+		Find(&prs)
+}
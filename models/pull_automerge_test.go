@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAutoMerge(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	exists, _, err := GetScheduledAutoMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.True(t, IsErrNotScheduledToAutoMerge(CancelScheduledAutoMerge(doer, pr)))
+
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleSquash, "squashed"))
+	assert.True(t, IsErrAlreadyScheduledToAutoMerge(ScheduleAutoMerge(doer, pr, MergeStyleMerge, "")))
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr.IssueID, PosterID: doer.ID, Type: CommentTypePRScheduledToAutoMerge})
+
+	exists, scheduled, err := GetScheduledAutoMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, MergeStyleSquash, scheduled.MergeStyle)
+	assert.Equal(t, "squashed", scheduled.Message)
+	assert.Equal(t, doer.ID, scheduled.Doer.ID)
+
+	prs, err := GetScheduledAutoMergePullRequests(pr.BaseRepoID)
+	assert.NoError(t, err)
+	if assert.Len(t, prs, 1) {
+		assert.Equal(t, pr.ID, prs[0].ID)
+	}
+
+	assert.NoError(t, CancelScheduledAutoMerge(doer, pr))
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr.IssueID, PosterID: doer.ID, Type: CommentTypePRUnScheduledToAutoMerge})
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// MergeQueueEntry represents a pull request waiting in the merge queue of its base branch.
+// The entries of a branch are merged in the order they were added.
+type MergeQueueEntry struct {
+	ID         int64        `xorm:"pk autoincr"`
+	RepoID     int64        `xorm:"INDEX(s) NOT NULL"`
+	BaseBranch string       `xorm:"INDEX(s) NOT NULL"`
+	PullID     int64        `xorm:"UNIQUE NOT NULL"`
+	Pull       *PullRequest `xorm:"-"`
+	DoerID     int64        `xorm:"NOT NULL"`
+	Doer       *User        `xorm:"-"`
+	MergeStyle MergeStyle   `xorm:"varchar(30)"`
+	Message    string       `xorm:"LONGTEXT"`
+
+	// BaseCommitID is the commit the pull request has been merged onto: the head of the base branch
+	// for the first entry, otherwise the speculative merge of the entry ahead
+	BaseCommitID string `xorm:"VARCHAR(40)"`
+	// HeadCommitID is the head of the pull request which has been merged
+	HeadCommitID string `xorm:"VARCHAR(40)"`
+	// CommitID is the speculative merge which CI has to report its statuses for
+	CommitID string `xorm:"VARCHAR(40) INDEX"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// LoadAttributes loads the pull request and the user who added it to the merge queue
+func (e *MergeQueueEntry) LoadAttributes() (err error) {
+	if e.Pull == nil {
+		if e.Pull, err = GetPullRequestByID(e.PullID); err != nil {
+			return err
+		}
+	}
+	if e.Doer == nil {
+		if e.Doer, err = GetUserByID(e.DoerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddToMergeQueue appends a pull request to the merge queue of its base branch
+// and records it in the timeline of the pull request.
+func AddToMergeQueue(doer *User, pull *PullRequest, style MergeStyle, message string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&MergeQueueEntry{PullID: pull.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrAlreadyInMergeQueue{PullID: pull.ID}
+	}
+
+	if _, err := sess.Insert(&MergeQueueEntry{
+		RepoID:     pull.BaseRepoID,
+		BaseBranch: pull.BaseBranch,
+		PullID:     pull.ID,
+		DoerID:     doer.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return err
+	}
+
+	if err := createMergeQueueComment(sess, CommentTypeMergeQueueAdd, pull, doer, ""); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// RemoveFromMergeQueue removes a pull request from the merge queue and records it in the
+// timeline of the pull request. The reason is empty if doer removed it by hand.
+func RemoveFromMergeQueue(doer *User, pull *PullRequest, reason string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if deleted, err := sess.Delete(&MergeQueueEntry{PullID: pull.ID}); err != nil {
+		return err
+	} else if deleted == 0 {
+		return ErrNotInMergeQueue{PullID: pull.ID}
+	}
+
+	if err := createMergeQueueComment(sess, CommentTypeMergeQueueRemove, pull, doer, reason); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func createMergeQueueComment(e *xorm.Session, typ CommentType, pull *PullRequest, doer *User, content string) error {
+	if err := pull.loadIssue(e); err != nil {
+		return err
+	}
+	if err := pull.loadBaseRepo(e); err != nil {
+		return err
+	}
+
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:    typ,
+		Doer:    doer,
+		Repo:    pull.BaseRepo,
+		Issue:   pull.Issue,
+		Content: content,
+	})
+	return err
+}
+
+// DeleteMergeQueueEntry removes a pull request from the merge queue without
+// leaving a timeline comment, e.g. once it has been merged.
+func DeleteMergeQueueEntry(pullID int64) error {
+	_, err := x.Delete(&MergeQueueEntry{PullID: pullID})
+	return err
+}
+
+// GetMergeQueueEntryByPullID returns the merge queue entry of a pull request, if any
+func GetMergeQueueEntryByPullID(pullID int64) (bool, *MergeQueueEntry, error) {
+	entry := new(MergeQueueEntry)
+	exists, err := x.Where("pull_id = ?", pullID).Get(entry)
+	if err != nil || !exists {
+		return false, nil, err
+	}
+	return true, entry, nil
+}
+
+// GetMergeQueue returns the entries of the merge queue of a branch in the order they are merged
+func GetMergeQueue(repoID int64, branch string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 5)
+	return entries, x.Where("repo_id = ? AND base_branch = ?", repoID, branch).
+		Asc("id").
+		Find(&entries)
+}
+
+// GetMergeQueueEntriesByCommitID returns the entries whose speculative merge is the commit
+func GetMergeQueueEntriesByCommitID(repoID int64, commitID string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 1)
+	return entries, x.Where("repo_id = ? AND commit_id = ?", repoID, commitID).Find(&entries)
+}
+
+// Position returns the 1-based position of the entry in the merge queue of its branch
+func (e *MergeQueueEntry) Position() (int64, error) {
+	return x.Where("repo_id = ? AND base_branch = ? AND id <= ?", e.RepoID, e.BaseBranch, e.ID).Count(new(MergeQueueEntry))
+}
+
+// UpdateCommits stores the commits of the speculative merge of the entry
+func (e *MergeQueueEntry) UpdateCommits() error {
+	_, err := x.ID(e.ID).Cols("base_commit_id", "head_commit_id", "commit_id").Update(e)
+	return err
+}
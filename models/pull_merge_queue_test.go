@@ -0,0 +1,66 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeQueue(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pr2 := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	pr1 := AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	exists, _, err := GetMergeQueueEntryByPullID(pr2.ID)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.True(t, IsErrNotInMergeQueue(RemoveFromMergeQueue(doer, pr2, "")))
+
+	assert.NoError(t, AddToMergeQueue(doer, pr2, MergeStyleSquash, "squashed"))
+	assert.True(t, IsErrAlreadyInMergeQueue(AddToMergeQueue(doer, pr2, MergeStyleMerge, "")))
+	assert.NoError(t, AddToMergeQueue(doer, pr1, MergeStyleMerge, ""))
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr2.IssueID, PosterID: doer.ID, Type: CommentTypeMergeQueueAdd})
+
+	entries, err := GetMergeQueue(pr2.BaseRepoID, pr2.BaseBranch)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, pr2.ID, entries[0].PullID)
+		assert.Equal(t, pr1.ID, entries[1].PullID)
+		assert.Equal(t, MergeStyleSquash, entries[0].MergeStyle)
+		assert.Equal(t, "squashed", entries[0].Message)
+
+		position, err := entries[1].Position()
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, position)
+
+		entries[1].BaseCommitID = "1111111111111111111111111111111111111111"
+		entries[1].HeadCommitID = "2222222222222222222222222222222222222222"
+		entries[1].CommitID = "3333333333333333333333333333333333333333"
+		assert.NoError(t, entries[1].UpdateCommits())
+		byCommit, err := GetMergeQueueEntriesByCommitID(pr1.BaseRepoID, entries[1].CommitID)
+		assert.NoError(t, err)
+		if assert.Len(t, byCommit, 1) {
+			assert.Equal(t, pr1.ID, byCommit[0].PullID)
+			assert.Equal(t, entries[1].BaseCommitID, byCommit[0].BaseCommitID)
+		}
+	}
+
+	assert.NoError(t, RemoveFromMergeQueue(doer, pr2, "status checks failed"))
+	AssertNotExistsBean(t, &MergeQueueEntry{PullID: pr2.ID})
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr2.IssueID, PosterID: doer.ID, Type: CommentTypeMergeQueueRemove, Content: "status checks failed"})
+
+	_, entry, err := GetMergeQueueEntryByPullID(pr1.ID)
+	assert.NoError(t, err)
+	position, err := entry.Position()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, position)
+
+	assert.NoError(t, DeleteMergeQueueEntry(pr1.ID))
+	AssertNotExistsBean(t, &MergeQueueEntry{PullID: pr1.ID})
+}
@@ -1452,9 +1452,33 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 
+	if _, err := sess.In("pull_id", builder.Select("id").From("pull_request").Where(builder.Eq{"base_repo_id": repoID})).
+		Delete(&PullAutoMerge{}); err != nil {
+		return err
+	}
+
+	if _, err := sess.In("check_run_id", builder.Select("id").From("check_run").Where(builder.Eq{"repo_id": repoID})).
+		Delete(&CheckRunAnnotation{}); err != nil {
+		return err
+	}
+
+	ciJobs := make([]*CIJob, 0, 10)
+	if err = sess.Where("repo_id = ?", repoID).Cols("id", "repo_id", "log_chunks").Find(&ciJobs); err != nil {
+		return err
+	}
+	ciLogPaths := make([]string, 0, len(ciJobs))
+	for _, job := range ciJobs {
+		for i := 0; i < job.LogChunks; i++ {
+			ciLogPaths = append(ciLogPaths, job.LogChunkPath(i))
+		}
+	}
+
 	if err := deleteBeans(sess,
 		&Access{RepoID: repo.ID},
 		&Action{RepoID: repo.ID},
+		&CheckRun{RepoID: repoID},
+		&CIJob{RepoID: repoID},
+		&CIRun{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
 		&Comment{RefRepoID: repoID},
 		&CommitStatus{RepoID: repoID},
@@ -1467,10 +1491,13 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Notification{RepoID: repoID},
 		&ProtectedBranch{RepoID: repoID},
 		&PullRequest{BaseRepoID: repoID},
+		&PushMirror{RepoID: repoID},
+		&ProtectedTag{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&RepoIndexerStatus{RepoID: repoID},
 		&RepoRedirect{RedirectRepoID: repoID},
 		&RepoUnit{RepoID: repoID},
+		&Secret{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Task{RepoID: repoID},
 		&Watch{RepoID: repoID},
@@ -1479,6 +1506,10 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := unlinkRepositoryFromPackages(sess, repoID); err != nil {
+		return err
+	}
+
 	// Delete Labels and related objects
 	if err := deleteLabelsByRepoID(sess, repoID); err != nil {
 		return err
@@ -1574,6 +1605,11 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		RemoveStorageWithNotice(storage.Attachments, "Delete release attachment", releaseAttachments[i])
 	}
 
+	// Remove the logs of CI jobs.
+	for i := range ciLogPaths {
+		RemoveStorageWithNotice(storage.CILogs, "Delete CI job log", ciLogPaths[i])
+	}
+
 	if len(repo.Avatar) > 0 {
 		if err := storage.RepoAvatars.Delete(repo.CustomAvatarRelativePath()); err != nil {
 			return fmt.Errorf("Failed to remove %s: %v", repo.Avatar, err)
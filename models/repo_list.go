@@ -530,3 +530,63 @@ func FindUserAccessibleRepoIDs(user *User) ([]int64, error) {
 	}
 	return repoIDs, nil
 }
+
+// userCodeAccessibleRepositoryCondition returns a condition for checking if the user can read the code of a repository,
+// it follows the unit permissions of GetUserRepoPermission
+func userCodeAccessibleRepositoryCondition(user *User) builder.Cond {
+	cond := builder.In("`repository`.id", builder.Select("repo_id").
+		From("repo_unit").
+		Where(builder.Eq{"`type`": UnitTypeCode}))
+	if user != nil && user.IsAdmin {
+		return cond
+	}
+
+	readCond := builder.NewCond()
+	if user == nil || !user.IsRestricted {
+		// 1. Be able to read the code of all public repositories we can see
+		readCond = readCond.Or(builder.Eq{"`repository`.is_private": false})
+	}
+	if user != nil {
+		readCond = readCond.Or(
+			// 2. Repositories that we directly own
+			builder.Eq{"`repository`.owner_id": user.ID},
+			// 3. Repositories that we are a collaborator of
+			builder.In("`repository`.id", builder.Select("repo_id").
+				From("collaboration").
+				Where(builder.Eq{"user_id": user.ID})),
+			// 4. Repositories of a team that we are in which can read the code or owns the organization
+			builder.In("`repository`.id", builder.Select("`team_repo`.repo_id").
+				From("team_repo").
+				Join("INNER", "team_user", "`team_user`.team_id = `team_repo`.team_id").
+				Join("INNER", "team", "`team`.id = `team_repo`.team_id").
+				Where(builder.And(
+					builder.Eq{"`team_user`.uid": user.ID},
+					builder.Or(
+						builder.Gte{"`team`.authorize": AccessModeOwner},
+						builder.In("`team_repo`.team_id", builder.Select("team_id").
+							From("team_unit").
+							Where(builder.Eq{"`type`": UnitTypeCode})),
+					),
+				))))
+	}
+	return cond.And(accessibleRepositoryCondition(user), readCond)
+}
+
+// FindUserCodeAccessibleRepoIDs finds the ids of the repositories whose code the user can read,
+// limited to the repositories of the owner if ownerID is not zero
+func FindUserCodeAccessibleRepoIDs(user *User, ownerID int64) ([]int64, error) {
+	cond := userCodeAccessibleRepositoryCondition(user)
+	if ownerID > 0 {
+		cond = cond.And(builder.Eq{"`repository`.owner_id": ownerID})
+	}
+
+	repoIDs := make([]int64, 0, 10)
+	if err := x.
+		Table("repository").
+		Cols("id").
+		Where(cond).
+		Find(&repoIDs); err != nil {
+		return nil, fmt.Errorf("FindUserCodeAccessibleRepoIDs: %v", err)
+	}
+	return repoIDs, nil
+}
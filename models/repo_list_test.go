@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
 )
 
 func TestSearchRepository(t *testing.T) {
@@ -355,3 +356,57 @@ func TestSearchRepositoryByTopicName(t *testing.T) {
 		})
 	}
 }
+
+func TestFindUserCodeAccessibleRepoIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+
+	testCases := []struct {
+		user    *User
+		ownerID int64
+		repoIDs []int64
+	}{
+		{nil, 3, []int64{32}},
+		{admin, 3, []int64{3, 5, 32}},
+		{user2, 3, []int64{3, 5, 32}},
+		{user4, 3, []int64{3, 32}},
+	}
+	for _, testCase := range testCases {
+		repoIDs, err := FindUserCodeAccessibleRepoIDs(testCase.user, testCase.ownerID)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, testCase.repoIDs, repoIDs)
+	}
+
+	repoIDs, err := FindUserCodeAccessibleRepoIDs(nil, 0)
+	assert.NoError(t, err)
+	assert.NotContains(t, repoIDs, int64(3))
+	assert.Contains(t, repoIDs, int64(1))
+
+	// the condition has to agree with the permissions of every user,
+	// a fixture without is_private is neither public nor private to the database
+	var repos []*Repository
+	assert.NoError(t, x.Where("is_private IS NOT NULL").Find(&repos))
+	fixtureIDs := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		fixtureIDs = append(fixtureIDs, repo.ID)
+	}
+	var users []*User
+	assert.NoError(t, x.Where("type = ?", UserTypeIndividual).Find(&users))
+	for _, user := range append(users, nil) {
+		expected := make([]int64, 0, len(repos))
+		for _, repo := range repos {
+			perm, err := GetUserRepoPermission(repo, user)
+			assert.NoError(t, err)
+			if perm.CanRead(UnitTypeCode) {
+				expected = append(expected, repo.ID)
+			}
+		}
+		repoIDs = make([]int64, 0, len(repos))
+		cond := userCodeAccessibleRepositoryCondition(user).And(builder.In("`repository`.id", fixtureIDs))
+		assert.NoError(t, x.Table("repository").Cols("id").Where(cond).Find(&repoIDs))
+		assert.ElementsMatch(t, expected, repoIDs, "user %v", user)
+	}
+}
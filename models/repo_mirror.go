@@ -6,6 +6,7 @@
 package models
 
 import (
+	"net/url"
 	"time"
 
 	"code.gitea.io/gitea/modules/log"
@@ -89,8 +90,55 @@ func UpdateMirror(m *Mirror) error {
 
 // DeleteMirrorByRepoID deletes a mirror by repoID
 func DeleteMirrorByRepoID(repoID int64) error {
-	_, err := x.Delete(&Mirror{RepoID: repoID})
-	return err
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Delete(&Mirror{RepoID: repoID}); err != nil {
+		return err
+	}
+	if _, err := deleteSecret(sess, 0, repoID, mirrorCredentialsSecretName); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// mirrorCredentialsSecretName is the name of the internal secret which stores the credentials of the mirror remote
+const mirrorCredentialsSecretName = "mirror-credentials"
+
+// GetCredentials returns the credentials of the mirror remote, or nil if it has none
+func (m *Mirror) GetCredentials() (*url.Userinfo, error) {
+	return getInternalCredentials(x, m.RepoID, mirrorCredentialsSecretName)
+}
+
+// SetCredentials stores the credentials of the mirror remote. Nil removes them.
+func (m *Mirror) SetCredentials(user *url.Userinfo) error {
+	return setInternalCredentials(x, m.RepoID, mirrorCredentialsSecretName, user)
+}
+
+// getInternalCredentials returns the credentials of a remote stored as internal secret of the repository, or nil if there are none
+func getInternalCredentials(e Engine, repoID int64, name string) (*url.Userinfo, error) {
+	value, err := getInternalSecretValue(e, 0, repoID, name)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	u, err := url.Parse("//" + value + "@localhost")
+	if err != nil {
+		return nil, err
+	}
+	return u.User, nil
+}
+
+// setInternalCredentials stores the credentials of a remote as internal secret of the repository. Nil removes them.
+func setInternalCredentials(e Engine, repoID int64, name string, user *url.Userinfo) error {
+	var value string
+	if user != nil {
+		value = user.String()
+	}
+	return setInternalSecretValue(e, 0, repoID, name, value)
 }
 
 // MirrorsIterate iterates all mirror repositories.
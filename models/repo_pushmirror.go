@@ -0,0 +1,154 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ErrPushMirrorNotExist mirror does not exist error
+var ErrPushMirrorNotExist = errors.New("PushMirror does not exist")
+
+// PushMirror represents mirror information of a repository.
+type PushMirror struct {
+	ID         int64       `xorm:"pk autoincr"`
+	RepoID     int64       `xorm:"INDEX"`
+	Repo       *Repository `xorm:"-"`
+	RemoteName string
+
+	SyncOnCommit   bool `xorm:"NOT NULL DEFAULT true"`
+	Interval       time.Duration
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	LastUpdateUnix timeutil.TimeStamp `xorm:"INDEX last_update"`
+	LastError      string             `xorm:"text"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error("getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// InsertPushMirror inserts a push-mirror to database
+func InsertPushMirror(m *PushMirror) error {
+	_, err := x.Insert(m)
+	return err
+}
+
+// UpdatePushMirror updates the push-mirror
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := x.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// DeletePushMirrorByID deletes a push-mirror by ID, including its credentials
+func DeletePushMirrorByID(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	m := &PushMirror{}
+	if has, err := sess.ID(id).NoAutoCondition().Get(m); err != nil {
+		return err
+	} else if !has {
+		return nil
+	}
+	if _, err := sess.ID(id).Delete(&PushMirror{}); err != nil {
+		return err
+	}
+	if _, err := deleteSecret(sess, 0, m.RepoID, m.credentialsSecretName()); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// credentialsSecretName returns the name of the internal secret which stores the credentials of the push-mirror remote
+func (m *PushMirror) credentialsSecretName() string {
+	return fmt.Sprintf("push-mirror-credentials-%d", m.ID)
+}
+
+// GetCredentials returns the credentials of the push-mirror remote, or nil if it has none
+func (m *PushMirror) GetCredentials() (*url.Userinfo, error) {
+	return getInternalCredentials(x, m.RepoID, m.credentialsSecretName())
+}
+
+// SetCredentials stores the credentials of the push-mirror remote. Nil removes them.
+func (m *PushMirror) SetCredentials(user *url.Userinfo) error {
+	return setInternalCredentials(x, m.RepoID, m.credentialsSecretName(), user)
+}
+
+// DeletePushMirrorsByRepoID deletes all push-mirrors by repoID
+func DeletePushMirrorsByRepoID(repoID int64) error {
+	_, err := x.Delete(&PushMirror{RepoID: repoID})
+	return err
+}
+
+// GetPushMirrorByID returns push-mirror information.
+func GetPushMirrorByID(id int64) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.ID(id).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorByRepoIDAndName returns the push-mirror of a repository with the given remote name.
+func GetPushMirrorByRepoIDAndName(repoID int64, remoteName string) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.Where("repo_id = ? AND remote_name = ?", repoID, remoteName).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorsByRepoID returns push-mirror informations of a repository.
+func GetPushMirrorsByRepoID(repoID int64, listOptions ListOptions) ([]*PushMirror, error) {
+	sess := x.Where("repo_id=?", repoID)
+	if listOptions.Page != 0 {
+		sess = listOptions.setSessionPagination(sess)
+	}
+
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, sess.Find(&mirrors)
+}
+
+// GetPushMirrorsSyncedOnCommit returns the push-mirrors of a repository which should be synced after each push
+func GetPushMirrorsSyncedOnCommit(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, x.Where(builder.Eq{"repo_id": repoID, "sync_on_commit": true}).Find(&mirrors)
+}
+
+// PushMirrorsIterate iterates all push-mirror repositories.
+func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
+	return x.
+		Where("last_update + (`interval` / ?) <= ?", time.Second, time.Now().Unix()).
+		And("`interval` != 0").
+		Iterate(new(PushMirror), f)
+}
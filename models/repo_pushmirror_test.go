@@ -0,0 +1,82 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushMirrorsIterate(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	now := timeutil.TimeStampNow()
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{
+		RepoID:         1,
+		RemoteName:     "test-1",
+		LastUpdateUnix: now,
+		Interval:       1,
+	}))
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{
+		RepoID:         1,
+		RemoteName:     "test-2",
+		LastUpdateUnix: now,
+		Interval:       24 * time.Hour,
+	}))
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{
+		RepoID:         1,
+		RemoteName:     "test-3",
+		LastUpdateUnix: now,
+		Interval:       0,
+	}))
+
+	time.Sleep(1 * time.Millisecond)
+
+	var names []string
+	assert.NoError(t, PushMirrorsIterate(func(idx int, bean interface{}) error {
+		m, ok := bean.(*PushMirror)
+		assert.True(t, ok)
+		assert.NotNil(t, m.Repo)
+		names = append(names, m.RemoteName)
+		return nil
+	}))
+	assert.Equal(t, []string{"test-1"}, names)
+}
+
+func TestGetPushMirrors(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{RepoID: 1, RemoteName: "on-commit", SyncOnCommit: true}))
+	assert.NoError(t, InsertPushMirror(&PushMirror{RepoID: 1, RemoteName: "scheduled", Interval: time.Hour}))
+	assert.NoError(t, InsertPushMirror(&PushMirror{RepoID: 2, RemoteName: "other-repo", SyncOnCommit: true}))
+
+	mirrors, err := GetPushMirrorsByRepoID(1, ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 2)
+
+	mirrors, err = GetPushMirrorsSyncedOnCommit(1)
+	assert.NoError(t, err)
+	if assert.Len(t, mirrors, 1) {
+		assert.Equal(t, "on-commit", mirrors[0].RemoteName)
+	}
+
+	m, err := GetPushMirrorByRepoIDAndName(1, "scheduled")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, m.RepoID)
+
+	_, err = GetPushMirrorByRepoIDAndName(2, "scheduled")
+	assert.Equal(t, ErrPushMirrorNotExist, err)
+
+	assert.NoError(t, DeletePushMirrorByID(m.ID))
+	_, err = GetPushMirrorByID(m.ID)
+	assert.Equal(t, ErrPushMirrorNotExist, err)
+}
@@ -100,9 +100,12 @@ type PullRequestsConfig struct {
 	AllowRebase               bool
 	AllowRebaseMerge          bool
 	AllowSquash               bool
+	AllowFastForwardOnly      bool
 	AllowManualMerge          bool
 	AutodetectManualMerge     bool
 	DefaultMergeStyle         MergeStyle
+	MergeMessageTemplate      string
+	SquashMessageTemplate     string
 }
 
 // FromDB fills up a PullRequestsConfig from serialized format.
@@ -123,6 +126,7 @@ func (cfg *PullRequestsConfig) IsMergeStyleAllowed(mergeStyle MergeStyle) bool {
 		mergeStyle == MergeStyleRebase && cfg.AllowRebase ||
 		mergeStyle == MergeStyleRebaseMerge && cfg.AllowRebaseMerge ||
 		mergeStyle == MergeStyleSquash && cfg.AllowSquash ||
+		mergeStyle == MergeStyleFastForwardOnly && cfg.AllowFastForwardOnly ||
 		mergeStyle == MergeStyleManuallyMerged && cfg.AllowManualMerge
 }
 
@@ -135,6 +139,18 @@ func (cfg *PullRequestsConfig) GetDefaultMergeStyle() MergeStyle {
 	return MergeStyleMerge
 }
 
+// GetMergeMessageTemplate returns the commit message template for merging a pull request with mergeStyle,
+// it is empty if the repository has none or mergeStyle doesn't create a commit with a message
+func (cfg *PullRequestsConfig) GetMergeMessageTemplate(mergeStyle MergeStyle) string {
+	switch mergeStyle {
+	case MergeStyleMerge, MergeStyleRebaseMerge:
+		return cfg.MergeMessageTemplate
+	case MergeStyleSquash:
+		return cfg.SquashMessageTemplate
+	}
+	return ""
+}
+
 // AllowedMergeStyleCount returns the total count of allowed merge styles for the PullRequestsConfig
 func (cfg *PullRequestsConfig) AllowedMergeStyleCount() int {
 	count := 0
@@ -150,6 +166,9 @@ func (cfg *PullRequestsConfig) AllowedMergeStyleCount() int {
 	if cfg.AllowSquash {
 		count++
 	}
+	if cfg.AllowFastForwardOnly {
+		count++
+	}
 	return count
 }
 
@@ -0,0 +1,41 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+// GetActiveSAMLLoginSources returns all actived LoginSAML sources ordered by name
+func GetActiveSAMLLoginSources() ([]*LoginSource, error) {
+	sources := make([]*LoginSource, 0, 1)
+	if err := x.Where("is_actived = ? and type = ?", true, LoginSAML).Asc("name").Find(&sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetActiveSAMLLoginSourceByName returns a SAML LoginSource based on the given name
+func GetActiveSAMLLoginSourceByName(name string) (*LoginSource, error) {
+	loginSource := new(LoginSource)
+	has, err := x.Where("name = ? and type = ? and is_actived = ?", name, LoginSAML, true).Get(loginSource)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrLoginSourceNotExist{}
+	}
+	return loginSource, nil
+}
+
+// GetActiveExternalLoginSourceByName returns the OAuth2 or SAML LoginSource based on the given name,
+// these are the login sources whose users can be linked to existing accounts
+func GetActiveExternalLoginSourceByName(name string) (*LoginSource, error) {
+	loginSource := new(LoginSource)
+	has, err := x.Where("name = ? and is_actived = ?", name, true).
+		In("type", LoginOAuth2, LoginSAML).
+		Get(loginSource)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrLoginSourceNotExist{}
+	}
+	return loginSource, nil
+}
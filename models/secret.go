@@ -0,0 +1,163 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+var (
+	// ErrSecretNotExist indicates a secret not exist error
+	ErrSecretNotExist = errors.New("Secret does not exist")
+	// ErrSecretNameInvalid indicates an invalid secret name error
+	ErrSecretNameInvalid = errors.New("Secret name is invalid")
+)
+
+// secretNamePattern matches the names of the secrets managed by users.
+// Internal secrets use lowercase names, so they never collide with them.
+var secretNamePattern = regexp.MustCompile(`\A[A-Z_][A-Z0-9_]*\z`)
+
+// Secret represents a value which is stored encrypted with the master key.
+// A secret belongs either to a repository or to a user or organization.
+type Secret struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	RepoID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+	Data        string             `xorm:"LONGTEXT"`
+	IsInternal  bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// NormalizeSecretName returns the name in the form it is stored and checks it is a valid name.
+// Names consist of letters, digits and underscores and must not start with a digit.
+func NormalizeSecretName(name string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !secretNamePattern.MatchString(name) || strings.HasPrefix(name, "GITEA_") {
+		return "", ErrSecretNameInvalid
+	}
+	return name, nil
+}
+
+// Value decrypts the value of the secret
+func (s *Secret) Value() (string, error) {
+	return secret.DecryptSecret(setting.MasterKey, s.Data)
+}
+
+func getSecret(e Engine, ownerID, repoID int64, name string) (*Secret, error) {
+	s := &Secret{}
+	has, err := e.
+		Where("owner_id = ? AND repo_id = ? AND name = ?", ownerID, repoID, name).
+		Get(s)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSecretNotExist
+	}
+	return s, nil
+}
+
+func setSecret(e Engine, ownerID, repoID int64, name, value string, isInternal bool) (created bool, err error) {
+	data, err := secret.EncryptSecret(setting.MasterKey, value)
+	if err != nil {
+		return false, err
+	}
+
+	s, err := getSecret(e, ownerID, repoID, name)
+	if err == ErrSecretNotExist {
+		_, err = e.Insert(&Secret{
+			OwnerID:    ownerID,
+			RepoID:     repoID,
+			Name:       name,
+			Data:       data,
+			IsInternal: isInternal,
+		})
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+
+	s.Data = data
+	_, err = e.ID(s.ID).Cols("data").Update(s)
+	return false, err
+}
+
+func deleteSecret(e Engine, ownerID, repoID int64, name string) (int64, error) {
+	return e.
+		Where("owner_id = ? AND repo_id = ? AND name = ?", ownerID, repoID, name).
+		Delete(&Secret{})
+}
+
+// SetSecret stores the value of a secret of a repository (ownerID 0) or of a user or organization (repoID 0).
+// The secret is created if it does not exist yet.
+func SetSecret(ownerID, repoID int64, name, value string) (created bool, err error) {
+	name, err = NormalizeSecretName(name)
+	if err != nil {
+		return false, err
+	}
+	return setSecret(x, ownerID, repoID, name, value, false)
+}
+
+// GetSecret returns the secret with the given name
+func GetSecret(ownerID, repoID int64, name string) (*Secret, error) {
+	name, err := NormalizeSecretName(name)
+	if err != nil {
+		return nil, ErrSecretNotExist
+	}
+	return getSecret(x, ownerID, repoID, name)
+}
+
+// FindSecrets returns the secrets of a repository (ownerID 0) or of a user or organization (repoID 0).
+// Internal secrets are not included.
+func FindSecrets(ownerID, repoID int64) ([]*Secret, error) {
+	secrets := make([]*Secret, 0, 10)
+	return secrets, x.
+		Where("owner_id = ? AND repo_id = ? AND is_internal = ?", ownerID, repoID, false).
+		OrderBy("name").
+		Find(&secrets)
+}
+
+// DeleteSecret deletes the secret with the given name
+func DeleteSecret(ownerID, repoID int64, name string) error {
+	name, err := NormalizeSecretName(name)
+	if err != nil {
+		return ErrSecretNotExist
+	}
+	n, err := deleteSecret(x, ownerID, repoID, name)
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrSecretNotExist
+	}
+	return nil
+}
+
+// getInternalSecretValue returns the decrypted value of an internal secret, or an empty string if it does not exist
+func getInternalSecretValue(e Engine, ownerID, repoID int64, name string) (string, error) {
+	s, err := getSecret(e, ownerID, repoID, name)
+	if err == ErrSecretNotExist {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return s.Value()
+}
+
+// setInternalSecretValue stores the value of an internal secret. An empty value deletes the secret.
+func setInternalSecretValue(e Engine, ownerID, repoID int64, name, value string) error {
+	if value == "" {
+		_, err := deleteSecret(e, ownerID, repoID, name)
+		return err
+	}
+	_, err := setSecret(e, ownerID, repoID, name, value, true)
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSecretName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"token":      "TOKEN",
+		" My_Key_1 ": "MY_KEY_1",
+		"_KEY":       "_KEY",
+	} {
+		normalized, err := NormalizeSecretName(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, normalized)
+	}
+
+	for _, name := range []string{"", "1KEY", "MY-KEY", "MY KEY", "GITEA_TOKEN", "webhook-1"} {
+		_, err := NormalizeSecretName(name)
+		assert.Equal(t, ErrSecretNameInvalid, err, name)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	created, err := SetSecret(0, 1, "my_token", "value1")
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	s, err := GetSecret(0, 1, "MY_TOKEN")
+	assert.NoError(t, err)
+	assert.Equal(t, "MY_TOKEN", s.Name)
+	assert.NotContains(t, s.Data, "value1")
+	value, err := s.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	created, err = SetSecret(0, 1, "MY_TOKEN", "value2")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	s, err = GetSecret(0, 1, "MY_TOKEN")
+	assert.NoError(t, err)
+	value, err = s.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", value)
+
+	_, err = SetSecret(0, 1, "1TOKEN", "value")
+	assert.Equal(t, ErrSecretNameInvalid, err)
+
+	// Secrets of organizations are separate from the ones of repositories
+	_, err = GetSecret(3, 0, "MY_TOKEN")
+	assert.Equal(t, ErrSecretNotExist, err)
+	_, err = SetSecret(3, 0, "MY_TOKEN", "org")
+	assert.NoError(t, err)
+
+	secrets, err := FindSecrets(0, 1)
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 1)
+
+	assert.NoError(t, DeleteSecret(0, 1, "my_token"))
+	assert.Equal(t, ErrSecretNotExist, DeleteSecret(0, 1, "MY_TOKEN"))
+	_, err = GetSecret(3, 0, "MY_TOKEN")
+	assert.NoError(t, err)
+}
+
+func TestWebhookSecret(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hook := &Webhook{
+		RepoID:      3,
+		URL:         "www.example.com/unit_test",
+		ContentType: ContentTypeJSON,
+		Secret:      "webhook-secret",
+		Events:      `{"push_only":true}`,
+	}
+	assert.NoError(t, CreateWebhook(hook))
+
+	// The secret is stored as internal secret, which is not listed
+	secrets, err := FindSecrets(0, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, secrets)
+	s := AssertExistsAndLoadBean(t, &Secret{RepoID: 3, Name: webhookSecretName(hook.ID)}).(*Secret)
+	assert.True(t, s.IsInternal)
+
+	loaded, err := GetWebhookByID(hook.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "webhook-secret", loaded.Secret)
+
+	loaded.Secret = ""
+	assert.NoError(t, UpdateWebhook(loaded))
+	AssertNotExistsBean(t, &Secret{RepoID: 3, Name: webhookSecretName(hook.ID)})
+
+	loaded.Secret = "changed"
+	assert.NoError(t, UpdateWebhook(loaded))
+	loaded, err = GetWebhookByID(hook.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", loaded.Secret)
+
+	assert.NoError(t, DeleteWebhookByRepoID(3, hook.ID))
+	AssertNotExistsBean(t, &Secret{RepoID: 3, Name: webhookSecretName(hook.ID)})
+}
+
+func TestMirrorCredentials(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	m := &Mirror{RepoID: 5}
+	user, err := m.GetCredentials()
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+
+	assert.NoError(t, m.SetCredentials(url.UserPassword("user", "p@ss:word")))
+	user, err = m.GetCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", user.Username())
+	password, _ := user.Password()
+	assert.Equal(t, "p@ss:word", password)
+
+	assert.NoError(t, m.SetCredentials(nil))
+	user, err = m.GetCredentials()
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+}
@@ -7,6 +7,7 @@ package models
 
 import (
 	"crypto/subtle"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/base"
@@ -16,6 +17,125 @@ import (
 	gouuid "github.com/google/uuid"
 )
 
+// The scopes an access token can be granted
+const (
+	AccessTokenScopeAll         = "all"
+	AccessTokenScopeReadRepo    = "read:repo"
+	AccessTokenScopeRepo        = "repo"
+	AccessTokenScopeReadIssue   = "read:issue"
+	AccessTokenScopeIssue       = "issue"
+	AccessTokenScopeReadOrg     = "read:org"
+	AccessTokenScopeOrg         = "org"
+	AccessTokenScopeAdmin       = "admin"
+	AccessTokenScopeReadUser    = "read:user"
+	AccessTokenScopeUser        = "user"
+	AccessTokenScopeReadPackage = "read:package"
+	AccessTokenScopePackage     = "package"
+)
+
+// AccessTokenScopes are all scopes an access token can be granted, except for AccessTokenScopeAll
+var AccessTokenScopes = []string{
+	AccessTokenScopeReadRepo,
+	AccessTokenScopeRepo,
+	AccessTokenScopeReadIssue,
+	AccessTokenScopeIssue,
+	AccessTokenScopeReadOrg,
+	AccessTokenScopeOrg,
+	AccessTokenScopeAdmin,
+	AccessTokenScopeReadUser,
+	AccessTokenScopeUser,
+	AccessTokenScopeReadPackage,
+	AccessTokenScopePackage,
+}
+
+// accessTokenReadScopes maps the scopes to their read-only counterparts
+var accessTokenReadScopes = map[string]string{
+	AccessTokenScopeRepo:    AccessTokenScopeReadRepo,
+	AccessTokenScopeIssue:   AccessTokenScopeReadIssue,
+	AccessTokenScopeOrg:     AccessTokenScopeReadOrg,
+	AccessTokenScopeUser:    AccessTokenScopeReadUser,
+	AccessTokenScopePackage: AccessTokenScopeReadPackage,
+}
+
+// AccessTokenReadScope returns the read-only counterpart of a scope, or the scope itself if it has none
+func AccessTokenReadScope(scope string) string {
+	if read, ok := accessTokenReadScopes[scope]; ok {
+		return read
+	}
+	return scope
+}
+
+// AccessTokenScopeDescKey returns the locale key of the description of an access token scope
+func AccessTokenScopeDescKey(scope string) string {
+	// the locale keys may not contain colons
+	return "settings.token_scope_" + strings.ReplaceAll(scope, ":", "_")
+}
+
+// AccessTokenScope is a comma separated list of the scopes granted to an access token
+type AccessTokenScope string
+
+// NewAccessTokenScope validates the given scopes and joins them into an AccessTokenScope.
+// No scopes at all grants full access, like tokens had before scopes were introduced.
+func NewAccessTokenScope(scopes ...string) (AccessTokenScope, error) {
+	granted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if scope == AccessTokenScopeAll {
+			return AccessTokenScopeAll, nil
+		}
+		valid := false
+		for _, s := range AccessTokenScopes {
+			if s == scope {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", ErrAccessTokenScopeInvalid{Scope: scope}
+		}
+		if !AccessTokenScope(strings.Join(granted, ",")).contains(scope) {
+			granted = append(granted, scope)
+		}
+	}
+	if len(granted) == 0 {
+		return AccessTokenScopeAll, nil
+	}
+	return AccessTokenScope(strings.Join(granted, ",")), nil
+}
+
+// Scopes returns the list of scopes
+func (s AccessTokenScope) Scopes() []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(string(s), ",")
+}
+
+func (s AccessTokenScope) contains(scope string) bool {
+	for _, granted := range s.Scopes() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Has returns whether the given scope is granted, either directly or through a broader scope
+func (s AccessTokenScope) Has(scope string) bool {
+	if s.contains(AccessTokenScopeAll) || s.contains(scope) {
+		return true
+	}
+	for write, read := range accessTokenReadScopes {
+		if read == scope && s.contains(write) {
+			return true
+		}
+	}
+	return false
+}
+
 // AccessToken represents a personal access token.
 type AccessToken struct {
 	ID             int64 `xorm:"pk autoincr"`
@@ -24,7 +144,9 @@ type AccessToken struct {
 	Token          string `xorm:"-"`
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
-	TokenLastEight string `xorm:"token_last_eight"`
+	TokenLastEight string           `xorm:"token_last_eight"`
+	Scope          AccessTokenScope `xorm:"NOT NULL DEFAULT 'all'"`
+	RepoIDs        []int64          `xorm:"JSON TEXT"` // the repositories the token is restricted to, if any
 
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -38,8 +160,70 @@ func (t *AccessToken) AfterLoad() {
 	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > timeutil.TimeStampNow()
 }
 
+// IsRepoRestricted returns whether the token may only be used for some repositories
+func (t *AccessToken) IsRepoRestricted() bool {
+	return len(t.RepoIDs) > 0
+}
+
+// HasAccessToRepo returns whether the token may be used for the given repository
+func (t *AccessToken) HasAccessToRepo(repoID int64) bool {
+	if !t.IsRepoRestricted() {
+		return true
+	}
+	for _, id := range t.RepoIDs {
+		if id == repoID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRepositories restricts the token to the given repositories, which are identified by their full names.
+// No repositories lift the restriction.
+func (t *AccessToken) SetRepositories(fullNames []string) error {
+	repoIDs := make([]int64, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		fullName = strings.TrimSpace(fullName)
+		if fullName == "" {
+			continue
+		}
+		parts := strings.SplitN(fullName, "/", 2)
+		if len(parts) != 2 {
+			return ErrRepoNotExist{Name: fullName}
+		}
+		repo, err := GetRepositoryByOwnerAndName(parts[0], parts[1])
+		if err != nil {
+			return err
+		}
+		repoIDs = append(repoIDs, repo.ID)
+	}
+	t.RepoIDs = repoIDs
+	return nil
+}
+
+// RepositoryNames returns the full names of the repositories the token is restricted to
+func (t *AccessToken) RepositoryNames() ([]string, error) {
+	if !t.IsRepoRestricted() {
+		return []string{}, nil
+	}
+	repos, err := GetRepositoriesMapByIDs(t.RepoIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repos))
+	for _, id := range t.RepoIDs {
+		if repo, ok := repos[id]; ok {
+			names = append(names, repo.FullName())
+		}
+	}
+	return names, nil
+}
+
 // NewAccessToken creates new access token.
 func NewAccessToken(t *AccessToken) error {
+	if t.Scope == "" {
+		t.Scope = AccessTokenScopeAll
+	}
 	salt, err := generate.GetRandomString(10)
 	if err != nil {
 		return err
@@ -127,3 +127,61 @@ func TestDeleteAccessTokenByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, IsErrAccessTokenNotExist(err))
 }
+
+func TestNewAccessTokenScope(t *testing.T) {
+	scope, err := NewAccessTokenScope()
+	assert.NoError(t, err)
+	assert.EqualValues(t, AccessTokenScopeAll, scope)
+
+	scope, err = NewAccessTokenScope("repo", "issue", "repo")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "repo,issue", scope)
+
+	scope, err = NewAccessTokenScope("issue", "all")
+	assert.NoError(t, err)
+	assert.EqualValues(t, AccessTokenScopeAll, scope)
+
+	_, err = NewAccessTokenScope("repo", "everything")
+	assert.True(t, IsErrAccessTokenScopeInvalid(err))
+}
+
+func TestAccessTokenScope_Has(t *testing.T) {
+	assert.True(t, AccessTokenScope("all").Has(AccessTokenScopeAdmin))
+	assert.True(t, AccessTokenScope("repo,issue").Has(AccessTokenScopeReadRepo))
+	assert.True(t, AccessTokenScope("repo,issue").Has(AccessTokenScopeIssue))
+	assert.False(t, AccessTokenScope("read:repo").Has(AccessTokenScopeRepo))
+	assert.False(t, AccessTokenScope("repo,issue").Has(AccessTokenScopeOrg))
+	assert.True(t, AccessTokenScope("issue").Has(AccessTokenScopeReadIssue))
+	assert.True(t, AccessTokenScope("package").Has(AccessTokenScopeReadPackage))
+	assert.False(t, AccessTokenScope("read:org,read:user").Has(AccessTokenScopeOrg))
+	assert.False(t, AccessTokenScope("read:user").Has(AccessTokenScopeReadOrg))
+}
+
+func TestAccessTokenReadScope(t *testing.T) {
+	assert.Equal(t, AccessTokenScopeReadRepo, AccessTokenReadScope(AccessTokenScopeRepo))
+	assert.Equal(t, AccessTokenScopeReadUser, AccessTokenReadScope(AccessTokenScopeUser))
+	assert.Equal(t, AccessTokenScopeReadOrg, AccessTokenReadScope(AccessTokenScopeReadOrg))
+	assert.Equal(t, AccessTokenScopeAdmin, AccessTokenReadScope(AccessTokenScopeAdmin))
+}
+
+func TestAccessToken_SetRepositories(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	token := &AccessToken{UID: 2, Name: "Token Restricted"}
+	assert.NoError(t, token.SetRepositories([]string{"user2/repo1", " user2/repo2 ", ""}))
+	assert.EqualValues(t, []int64{1, 2}, token.RepoIDs)
+	assert.True(t, token.HasAccessToRepo(2))
+	assert.False(t, token.HasAccessToRepo(3))
+
+	assert.NoError(t, NewAccessToken(token))
+	token = AssertExistsAndLoadBean(t, &AccessToken{ID: token.ID}).(*AccessToken)
+	names, err := token.RepositoryNames()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user2/repo1", "user2/repo2"}, names)
+
+	assert.True(t, IsErrRepoNotExist(token.SetRepositories([]string{"user2/repo1", "user2/nope"})))
+
+	// tokens from before scopes were introduced have full access
+	token = AssertExistsAndLoadBean(t, &AccessToken{ID: 1}).(*AccessToken)
+	assert.EqualValues(t, AccessTokenScopeAll, token.Scope)
+	assert.False(t, token.IsRepoRestricted())
+}
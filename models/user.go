@@ -251,6 +251,11 @@ func (u *User) IsOAuth2() bool {
 	return u.LoginType == LoginOAuth2
 }
 
+// IsSAML returns true if user login type is LoginSAML.
+func (u *User) IsSAML() bool {
+	return u.LoginType == LoginSAML
+}
+
 // HasForkedRepo checks if user has already forked a repository with given ID.
 func (u *User) HasForkedRepo(repoID int64) bool {
 	_, has := HasForkedRepo(u.ID, repoID)
@@ -811,6 +816,7 @@ var (
 		"stars",
 		"template",
 		"user",
+		"v2",
 		"favicon.ico",
 	}
 
@@ -930,6 +936,19 @@ func CountUsers() int64 {
 	return countUsers(x)
 }
 
+// IsLastAdminUser returns whether the user is a site administrator and no other active
+// site administrator is allowed to sign in
+func IsLastAdminUser(u *User) (bool, error) {
+	if !u.IsAdmin {
+		return false, nil
+	}
+	count, err := x.
+		Where(builder.Eq{"is_admin": true, "is_active": true, "prohibit_login": false}).
+		And("id != ?", u.ID).
+		Count(new(User))
+	return count == 0, err
+}
+
 // get user by verify code
 func getVerifyUser(code string) (user *User) {
 	if len(code) <= base.TimeLimitCodeLength {
@@ -1170,10 +1189,19 @@ func deleteUser(e Engine, u *User) error {
 		&TeamUser{UID: u.ID},
 		&Collaboration{UserID: u.ID},
 		&Stopwatch{UserID: u.ID},
+		&WebAuthnCredential{UserID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deletePackagesByOwner(e, u.ID); err != nil {
+		return fmt.Errorf("deletePackagesByOwner: %v", err)
+	}
+
+	if err := deleteProjectsByOwnerID(e, u.ID); err != nil {
+		return fmt.Errorf("deleteProjectsByOwnerID: %v", err)
+	}
+
 	if setting.Service.UserDeleteWithCommentsMaxTime != 0 &&
 		u.CreatedUnix.AsTime().Add(setting.Service.UserDeleteWithCommentsMaxTime).After(time.Now()) {
 
@@ -1930,15 +1958,24 @@ func SyncExternalUsers(ctx context.Context, updateExisting bool) error {
 
 					if err != nil {
 						log.Error("SyncExternalUsers[%s]: Error creating user %s: %v", s.Name, su.Username, err)
+						continue
 					} else if isAttributeSSHPublicKeySet {
 						log.Trace("SyncExternalUsers[%s]: Adding LDAP Public SSH Keys for user %s", s.Name, usr.Name)
 						if addLdapSSHPublicKeys(usr, s, su.SSHPublicKey) {
 							sshKeysNeedUpdate = true
 						}
 					}
+
+					if err = syncGroupsToTeams(usr, su.LdapTeamAdd, su.LdapTeamRemove, s.LDAP().GroupTeamMapRemoval); err != nil {
+						log.Error("SyncExternalUsers[%s]: Error synchronizing teams of user %s: %v", s.Name, usr.Name, err)
+					}
 				} else if updateExisting {
 					existingUsers = append(existingUsers, usr.ID)
 
+					if err = syncGroupsToTeams(usr, su.LdapTeamAdd, su.LdapTeamRemove, s.LDAP().GroupTeamMapRemoval); err != nil {
+						log.Error("SyncExternalUsers[%s]: Error synchronizing teams of user %s: %v", s.Name, usr.Name, err)
+					}
+
 					// Synchronize SSH Public Key if that attribute is set
 					if isAttributeSSHPublicKeySet && synchronizeLdapSSHPublicKeys(usr, s, su.SSHPublicKey) {
 						sshKeysNeedUpdate = true
@@ -187,6 +187,33 @@ func TestDeleteUser(t *testing.T) {
 	assert.Error(t, DeleteUser(org))
 }
 
+func TestIsLastAdminUser(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	isLast, err := IsLastAdminUser(admin)
+	assert.NoError(t, err)
+	assert.True(t, isLast)
+	isLast, err = IsLastAdminUser(user2)
+	assert.NoError(t, err)
+	assert.False(t, isLast)
+
+	// administrators who cannot sign in don't count
+	user2.IsAdmin = true
+	user2.ProhibitLogin = true
+	assert.NoError(t, UpdateUserCols(user2, "is_admin", "prohibit_login"))
+	isLast, err = IsLastAdminUser(admin)
+	assert.NoError(t, err)
+	assert.True(t, isLast)
+
+	user2.ProhibitLogin = false
+	assert.NoError(t, UpdateUserCols(user2, "prohibit_login"))
+	isLast, err = IsLastAdminUser(admin)
+	assert.NoError(t, err)
+	assert.False(t, isLast)
+}
+
 func TestEmailNotificationPreferences(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	for _, test := range []struct {
@@ -0,0 +1,173 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/base32"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnCredential represents the WebAuthn credential data of a public-key credential conformant to WebAuthn Level 1
+type WebAuthnCredential struct {
+	ID              int64 `xorm:"pk autoincr"`
+	Name            string
+	LowerName       string `xorm:"unique(s)"`
+	UserID          int64  `xorm:"INDEX unique(s)"`
+	CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32 `xorm:"BIGINT"`
+	CloneWarning    bool
+	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName returns a better table name for WebAuthnCredential
+func (cred WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+// EncodeWebAuthnCredentialID encodes a raw credential id the way it is stored in the database.
+// base32 is used as it is case insensitive, which matters for some database collations.
+func EncodeWebAuthnCredentialID(id []byte) string {
+	return base32.HexEncoding.EncodeToString(id)
+}
+
+// BeforeInsert will be invoked by XORM before updating a record
+func (cred *WebAuthnCredential) BeforeInsert() {
+	cred.LowerName = strings.ToLower(cred.Name)
+}
+
+// BeforeUpdate will be invoked by XORM before updating a record
+func (cred *WebAuthnCredential) BeforeUpdate() {
+	cred.LowerName = strings.ToLower(cred.Name)
+}
+
+func (cred *WebAuthnCredential) updateSignCount(e Engine) error {
+	_, err := e.ID(cred.ID).Cols("sign_count").Update(cred)
+	return err
+}
+
+// UpdateSignCount will update the database value of SignCount
+func (cred *WebAuthnCredential) UpdateSignCount() error {
+	return cred.updateSignCount(x)
+}
+
+// UpdateFromLatest updates the sign count and clone warning of the credential from the latest login
+func (cred *WebAuthnCredential) UpdateFromLatest(latest *webauthn.Credential) error {
+	cred.SignCount = latest.Authenticator.SignCount
+	cred.CloneWarning = latest.Authenticator.CloneWarning
+	_, err := x.ID(cred.ID).Cols("sign_count", "clone_warning").Update(cred)
+	return err
+}
+
+// WebAuthnCredentialList is a list of *WebAuthnCredential
+type WebAuthnCredentialList []*WebAuthnCredential
+
+// ToCredentials will convert all WebAuthnCredentials to webauthn.Credentials
+func (list WebAuthnCredentialList) ToCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(list))
+	for _, cred := range list {
+		credID, _ := base32.HexEncoding.DecodeString(cred.CredentialID)
+		creds = append(creds, webauthn.Credential{
+			ID:              credID,
+			PublicKey:       cred.PublicKey,
+			AttestationType: cred.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       cred.AAGUID,
+				SignCount:    cred.SignCount,
+				CloneWarning: cred.CloneWarning,
+			},
+		})
+	}
+	return creds
+}
+
+func getWebAuthnCredentialsByUID(e Engine, uid int64) (WebAuthnCredentialList, error) {
+	creds := make(WebAuthnCredentialList, 0)
+	return creds, e.Where("user_id = ?", uid).Find(&creds)
+}
+
+// GetWebAuthnCredentialsByUID returns all WebAuthn credentials of the given user
+func GetWebAuthnCredentialsByUID(uid int64) (WebAuthnCredentialList, error) {
+	return getWebAuthnCredentialsByUID(x, uid)
+}
+
+// ExistsWebAuthnCredentialsForUID returns if the given user has credentials
+func ExistsWebAuthnCredentialsForUID(uid int64) (bool, error) {
+	return x.Where("user_id = ?", uid).Exist(&WebAuthnCredential{})
+}
+
+// GetWebAuthnCredentialByName returns WebAuthn credential by name of the given user
+func GetWebAuthnCredentialByName(uid int64, name string) (*WebAuthnCredential, error) {
+	cred := new(WebAuthnCredential)
+	if found, err := x.Where("user_id = ? AND lower_name = ?", uid, strings.ToLower(name)).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{}
+	}
+	return cred, nil
+}
+
+// GetWebAuthnCredentialByID returns WebAuthn credential by id
+func GetWebAuthnCredentialByID(id int64) (*WebAuthnCredential, error) {
+	return getWebAuthnCredentialByID(x, id)
+}
+
+func getWebAuthnCredentialByID(e Engine, id int64) (*WebAuthnCredential, error) {
+	cred := new(WebAuthnCredential)
+	if found, err := e.ID(id).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{ID: id}
+	}
+	return cred, nil
+}
+
+// GetWebAuthnCredentialByCredID returns the WebAuthn credential of the given user by its credential id
+func GetWebAuthnCredentialByCredID(userID int64, credID []byte) (*WebAuthnCredential, error) {
+	cred := new(WebAuthnCredential)
+	encoded := EncodeWebAuthnCredentialID(credID)
+	if found, err := x.Where("user_id = ? AND credential_id = ?", userID, encoded).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{CredentialID: encoded}
+	}
+	return cred, nil
+}
+
+func createWebAuthnCredential(e Engine, userID int64, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+	c := &WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    EncodeWebAuthnCredentialID(cred.ID),
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		CloneWarning:    false,
+	}
+	if _, err := e.InsertOne(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateWebAuthnCredential will create a new WebAuthnCredential from the given Credential
+func CreateWebAuthnCredential(userID int64, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+	return createWebAuthnCredential(x, userID, name, cred)
+}
+
+// DeleteWebAuthnCredential will delete the WebAuthnCredential with the given id of the given user.
+// It returns false if there was no such credential.
+func DeleteWebAuthnCredential(id, userID int64) (bool, error) {
+	n, err := x.ID(id).Delete(&WebAuthnCredential{UserID: userID})
+	return n > 0, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/base32"
+	"testing"
+
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebAuthnCredentialByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn credential", res.Name)
+
+	_, err = GetWebAuthnCredentialByID(342432)
+	assert.Error(t, err)
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestGetWebAuthnCredentialsByUID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "WebAuthn credential", res[0].Name)
+
+	has, err := ExistsWebAuthnCredentialsForUID(1)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = ExistsWebAuthnCredentialsForUID(2)
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestGetWebAuthnCredentialByName(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByName(1, "webauthn CREDENTIAL")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.ID)
+
+	_, err = GetWebAuthnCredentialByName(2, "WebAuthn credential")
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestWebAuthnCredential_TableName(t *testing.T) {
+	assert.Equal(t, "webauthn_credential", WebAuthnCredential{}.TableName())
+}
+
+func TestWebAuthnCredential_UpdateSignCount(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+	cred.SignCount = 0xffffffff
+	assert.NoError(t, cred.UpdateSignCount())
+	AssertExistsIf(t, true, &WebAuthnCredential{ID: 1, SignCount: 0xffffffff})
+}
+
+func TestCreateWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := CreateWebAuthnCredential(1, "WebAuthn Created Credential", &webauthn.Credential{ID: []byte("Test")})
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Created Credential", res.Name)
+	assert.Equal(t, base32.HexEncoding.EncodeToString([]byte("Test")), res.CredentialID)
+
+	cred, err := GetWebAuthnCredentialByCredID(1, []byte("Test"))
+	assert.NoError(t, err)
+	assert.Equal(t, res.ID, cred.ID)
+	_, err = GetWebAuthnCredentialByCredID(2, []byte("Test"))
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+
+	creds := WebAuthnCredentialList{cred}.ToCredentials()
+	if assert.Len(t, creds, 1) {
+		assert.Equal(t, []byte("Test"), creds[0].ID)
+	}
+}
+
+func TestDeleteWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	deleted, err := DeleteWebAuthnCredential(1, 2)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1})
+
+	deleted, err = DeleteWebAuthnCredential(1, 1)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	AssertNotExistsBean(t, &WebAuthnCredential{ID: 1})
+}
@@ -18,6 +18,7 @@ import (
 
 	gouuid "github.com/google/uuid"
 	jsoniter "github.com/json-iterator/go"
+	"xorm.io/xorm"
 )
 
 // HookContentType is the content type of a web hook
@@ -129,7 +130,7 @@ type Webhook struct {
 	Signature       string `xorm:"TEXT"`
 	HTTPMethod      string `xorm:"http_method"`
 	ContentType     HookContentType
-	Secret          string `xorm:"TEXT"`
+	Secret          string `xorm:"-"` // stored as internal secret
 	Events          string `xorm:"TEXT"`
 	*HookEvent      `xorm:"-"`
 	IsSSL           bool         `xorm:"is_ssl"`
@@ -143,13 +144,23 @@ type Webhook struct {
 }
 
 // AfterLoad updates the webhook object upon setting a column
-func (w *Webhook) AfterLoad() {
+func (w *Webhook) AfterLoad(session *xorm.Session) {
 	w.HookEvent = &HookEvent{}
 
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 	if err := json.Unmarshal([]byte(w.Events), w.HookEvent); err != nil {
 		log.Error("Unmarshal[%d]: %v", w.ID, err)
 	}
+
+	var err error
+	if w.Secret, err = getInternalSecretValue(session, w.OrgID, w.RepoID, webhookSecretName(w.ID)); err != nil {
+		log.Error("getInternalSecretValue[%d]: %v", w.ID, err)
+	}
+}
+
+// webhookSecretName returns the name of the internal secret which stores the secret of the webhook
+func webhookSecretName(id int64) string {
+	return fmt.Sprintf("webhook-%d", id)
 }
 
 // History returns history of webhook by given conditions.
@@ -336,8 +347,10 @@ func CreateWebhook(w *Webhook) error {
 
 func createWebhook(e Engine, w *Webhook) error {
 	w.Type = strings.TrimSpace(w.Type)
-	_, err := e.Insert(w)
-	return err
+	if _, err := e.Insert(w); err != nil {
+		return err
+	}
+	return setInternalSecretValue(e, w.OrgID, w.RepoID, webhookSecretName(w.ID), w.Secret)
 }
 
 // getWebhook uses argument bean as query condition,
@@ -464,8 +477,20 @@ func getSystemWebhooks(e Engine) ([]*Webhook, error) {
 
 // UpdateWebhook updates information of webhook.
 func UpdateWebhook(w *Webhook) error {
-	_, err := x.ID(w.ID).AllCols().Update(w)
-	return err
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(w.ID).AllCols().Update(w); err != nil {
+		return err
+	}
+	if err := setInternalSecretValue(sess, w.OrgID, w.RepoID, webhookSecretName(w.ID), w.Secret); err != nil {
+		return err
+	}
+
+	return sess.Commit()
 }
 
 // UpdateWebhookLastStatus updates last status of webhook.
@@ -489,6 +514,8 @@ func deleteWebhook(bean *Webhook) (err error) {
 		return ErrWebhookNotExist{ID: bean.ID}
 	} else if _, err = sess.Delete(&HookTask{HookID: bean.ID}); err != nil {
 		return err
+	} else if _, err = deleteSecret(sess, bean.OrgID, bean.RepoID, webhookSecretName(bean.ID)); err != nil {
+		return err
 	}
 
 	return sess.Commit()
@@ -530,6 +557,9 @@ func DeleteDefaultSystemWebhook(id int64) error {
 	if _, err := sess.Delete(&HookTask{HookID: id}); err != nil {
 		return err
 	}
+	if _, err := deleteSecret(sess, 0, 0, webhookSecretName(id)); err != nil {
+		return err
+	}
 
 	return sess.Commit()
 }
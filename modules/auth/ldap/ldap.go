@@ -9,6 +9,7 @@ package ldap
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -55,17 +56,21 @@ type Source struct {
 	GroupFilter           string // Group Name Filter
 	GroupMemberUID        string // Group Attribute containing array of UserUID
 	UserUID               string // User Attribute listed in Group
+	GroupTeamMap          string // Map LDAP groups to teams of organizations
+	GroupTeamMapRemoval   bool   // Remove user from mapped teams when they are not a member of the LDAP group anymore
 }
 
 // SearchResult : user data
 type SearchResult struct {
-	Username     string   // Username
-	Name         string   // Name
-	Surname      string   // Surname
-	Mail         string   // E-mail address
-	SSHPublicKey []string // SSH Public Key
-	IsAdmin      bool     // if user is administrator
-	IsRestricted bool     // if user is restricted
+	Username       string              // Username
+	Name           string              // Name
+	Surname        string              // Surname
+	Mail           string              // E-mail address
+	SSHPublicKey   []string            // SSH Public Key
+	IsAdmin        bool                // if user is administrator
+	IsRestricted   bool                // if user is restricted
+	LdapTeamAdd    map[string][]string // teams of organizations the user has to be added to
+	LdapTeamRemove map[string][]string // teams of organizations the user has to be removed from
 }
 
 func (ls *Source) sanitizedUserQuery(username string) (string, bool) {
@@ -112,6 +117,94 @@ func (ls *Source) sanitizedGroupDN(groupDn string) (string, bool) {
 	return groupDn, true
 }
 
+// GroupTeamMapping returns the parsed GroupTeamMap, which maps group DNs to the team names of organizations:
+// {"cn=developers,ou=group,dc=mydomain,dc=com": {"MyOrg": ["Developers", "Reviewers"]}}
+func (ls *Source) GroupTeamMapping() (map[string]map[string][]string, error) {
+	mapping := make(map[string]map[string][]string)
+	if len(strings.TrimSpace(ls.GroupTeamMap)) == 0 {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(ls.GroupTeamMap), &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// listLdapGroupMemberships returns the DNs of all groups which list the user as member
+func (ls *Source) listLdapGroupMemberships(l *ldap.Conn, memberUID string) ([]string, error) {
+	groupDN, ok := ls.sanitizedGroupDN(ls.GroupDN)
+	if !ok {
+		return nil, fmt.Errorf("invalid group search base: %s", ls.GroupDN)
+	}
+	groupFilter := fmt.Sprintf("(%s=%s)", ls.GroupMemberUID, ldap.EscapeFilter(memberUID))
+
+	log.Trace("Fetching group memberships with filter '%s' and base '%s'", groupFilter, groupDN)
+	result, err := l.Search(ldap.NewSearchRequest(
+		groupDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, groupFilter,
+		[]string{},
+		nil))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// getMappedMemberships returns the teams the user has to be added to, because they are a member of the
+// mapped LDAP group, and the teams they have to be removed from
+func (ls *Source) getMappedMemberships(l *ldap.Conn, memberUID string) (teamsToAdd, teamsToRemove map[string][]string) {
+	if !ls.GroupsEnabled || len(ls.GroupMemberUID) == 0 || len(memberUID) == 0 {
+		return nil, nil
+	}
+	mapping, err := ls.GroupTeamMapping()
+	if err != nil {
+		log.Error("Failed to parse the LDAP group team map of %s: %v", ls.Name, err)
+		return nil, nil
+	}
+	if len(mapping) == 0 {
+		return nil, nil
+	}
+
+	groups, err := ls.listLdapGroupMemberships(l, memberUID)
+	if err != nil {
+		log.Error("LDAP group membership search failed: %v", err)
+		return nil, nil
+	}
+
+	teamsToAdd = make(map[string][]string)
+	teamsToRemove = make(map[string][]string)
+	for group, orgTeams := range mapping {
+		isMember := false
+		for _, memberOf := range groups {
+			// DNs are not case sensitive
+			if strings.EqualFold(memberOf, group) {
+				isMember = true
+				break
+			}
+		}
+		for org, teams := range orgTeams {
+			if isMember {
+				teamsToAdd[org] = append(teamsToAdd[org], teams...)
+			} else {
+				teamsToRemove[org] = append(teamsToRemove[org], teams...)
+			}
+		}
+	}
+	return teamsToAdd, teamsToRemove
+}
+
+// memberUID returns the value which groups list for the member of an entry
+func (ls *Source) memberUID(entry *ldap.Entry) string {
+	if ls.UserUID == "dn" {
+		return entry.DN
+	}
+	return entry.GetAttributeValue(ls.UserUID)
+}
+
 func (ls *Source) findUserDN(l *ldap.Conn, name string) (string, bool) {
 	log.Trace("Search for LDAP user: %s", name)
 
@@ -342,7 +435,7 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 	uid := sr.Entries[0].GetAttributeValue(ls.UserUID)
 
 	// Check group membership
-	if ls.GroupsEnabled {
+	if ls.GroupsEnabled && len(ls.GroupFilter) > 0 {
 		groupFilter, ok := ls.sanitizedGroupFilter(ls.GroupFilter)
 		if !ok {
 			return nil
@@ -392,6 +485,7 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 	if !isAdmin {
 		isRestricted = checkRestricted(l, ls, userDN)
 	}
+	teamsToAdd, teamsToRemove := ls.getMappedMemberships(l, ls.memberUID(sr.Entries[0]))
 
 	if !directBind && ls.AttributesInBind {
 		// binds user (checking password) after looking-up attributes in BindDN context
@@ -402,13 +496,15 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 	}
 
 	return &SearchResult{
-		Username:     username,
-		Name:         firstname,
-		Surname:      surname,
-		Mail:         mail,
-		SSHPublicKey: sshPublicKey,
-		IsAdmin:      isAdmin,
-		IsRestricted: isRestricted,
+		Username:       username,
+		Name:           firstname,
+		Surname:        surname,
+		Mail:           mail,
+		SSHPublicKey:   sshPublicKey,
+		IsAdmin:        isAdmin,
+		IsRestricted:   isRestricted,
+		LdapTeamAdd:    teamsToAdd,
+		LdapTeamRemove: teamsToRemove,
 	}
 }
 
@@ -446,6 +542,9 @@ func (ls *Source) SearchEntries() ([]*SearchResult, error) {
 	if isAttributeSSHPublicKeySet {
 		attribs = append(attribs, ls.AttributeSSHPublicKey)
 	}
+	if ls.GroupsEnabled && len(strings.TrimSpace(ls.UserUID)) > 0 {
+		attribs = append(attribs, ls.UserUID)
+	}
 
 	log.Trace("Fetching attributes '%v', '%v', '%v', '%v', '%v' with filter %s and base %s", ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail, ls.AttributeSSHPublicKey, userFilter, ls.UserBase)
 	search := ldap.NewSearchRequest(
@@ -479,6 +578,7 @@ func (ls *Source) SearchEntries() ([]*SearchResult, error) {
 		if isAttributeSSHPublicKeySet {
 			result[i].SSHPublicKey = v.GetAttributeValues(ls.AttributeSSHPublicKey)
 		}
+		result[i].LdapTeamAdd, result[i].LdapTeamRemove = ls.getMappedMemberships(l, ls.memberUID(v))
 	}
 
 	return result, nil
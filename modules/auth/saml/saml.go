@@ -0,0 +1,431 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/setting"
+
+	mc "gitea.com/go-chi/cache"
+	"github.com/markbates/goth"
+	saml2 "github.com/russellhaering/gosaml2"
+	"github.com/russellhaering/gosaml2/types"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	// NameIDFormatUnspecified lets the identity provider choose the format of the name identifier
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	// requestLifetime is the number of minutes an identity provider has to answer an AuthnRequest
+	requestLifetime = 10
+	// requestNonceLength is the number of random bytes in the ID of an AuthnRequest
+	requestNonceLength = 16
+	// maxMetadataSize is the maximum size of identity provider metadata fetched from an URL
+	maxMetadataSize = 1 << 20
+)
+
+// Source holds configuration for a SAML 2.0 identity provider
+type Source struct {
+	IdentityProviderMetadata    string // XML metadata of the identity provider
+	IdentityProviderMetadataURL string // URL the metadata of the identity provider was imported from
+	ServiceProviderCertificate  string // PEM encoded certificate published in the service provider metadata
+	ServiceProviderPrivateKey   string `json:"-"` // PEM encoded private key used to sign requests and decrypt assertions, stored as secret
+	SignRequests                bool
+	NameIDFormat                string
+	AttributeUsername           string // Defaults to the name identifier
+	AttributeEmail              string // Defaults to the name identifier if it is an email address
+	AttributeFullName           string
+	AttributeGroups             string
+	RequiredGroup               string // Group users need to be a member of to sign in
+	AdminGroup                  string // Group users need to be a member of to be site administrator
+	AutoRegistration            bool
+}
+
+// BaseURL returns the URL the endpoints of the named SAML login source are served under
+func BaseURL(name string) string {
+	return setting.AppURL + "user/saml/" + url.PathEscape(name)
+}
+
+// ParseIdentityProviderMetadata parses and checks the XML metadata of an identity provider
+func ParseIdentityProviderMetadata(data []byte) (*types.EntityDescriptor, error) {
+	metadata := &types.EntityDescriptor{}
+	if err := xml.Unmarshal(data, metadata); err != nil {
+		return nil, err
+	}
+	if metadata.EntityID == "" {
+		return nil, errors.New("metadata has no entity ID")
+	}
+	if metadata.IDPSSODescriptor == nil {
+		return nil, errors.New("metadata has no IDPSSODescriptor")
+	}
+	if _, _, err := singleSignOnService(metadata); err != nil {
+		return nil, err
+	}
+	certs, err := signingCertificates(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("metadata has no signing certificate")
+	}
+	return metadata, nil
+}
+
+// FetchIdentityProviderMetadata downloads the XML metadata of an identity provider
+func FetchIdentityProviderMetadata(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+}
+
+// singleSignOnService returns the location and binding of the single sign-on service,
+// HTTP-Redirect is preferred over HTTP-POST
+func singleSignOnService(metadata *types.EntityDescriptor) (string, string, error) {
+	var location, binding string
+	for _, service := range metadata.IDPSSODescriptor.SingleSignOnServices {
+		switch service.Binding {
+		case saml2.BindingHttpRedirect:
+			return service.Location, service.Binding, nil
+		case saml2.BindingHttpPost:
+			if location == "" {
+				location, binding = service.Location, service.Binding
+			}
+		}
+	}
+	if location == "" {
+		return "", "", errors.New("metadata has no single sign-on service with HTTP-Redirect or HTTP-POST binding")
+	}
+	return location, binding, nil
+}
+
+// signingCertificates returns the certificates the identity provider signs its responses with
+func signingCertificates(metadata *types.EntityDescriptor) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, descriptor := range metadata.IDPSSODescriptor.KeyDescriptors {
+		if descriptor.Use != "" && descriptor.Use != "signing" {
+			continue
+		}
+		for _, cert := range descriptor.KeyInfo.X509Data.X509Certificates {
+			data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(cert.Data), ""))
+			if err != nil {
+				return nil, fmt.Errorf("invalid signing certificate: %v", err)
+			}
+			parsed, err := x509.ParseCertificate(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid signing certificate: %v", err)
+			}
+			certs = append(certs, parsed)
+		}
+	}
+	return certs, nil
+}
+
+// GenerateServiceProviderCertificate creates a self-signed certificate and private key in PEM format
+func GenerateServiceProviderCertificate(commonName string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(cert), string(privateKey), nil
+}
+
+// ValidateServiceProviderKeyPair checks that the certificate and private key belong together
+func ValidateServiceProviderKeyPair(cert, key string) error {
+	keyPair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	if err != nil {
+		return err
+	}
+	if _, ok := keyPair.PrivateKey.(*rsa.PrivateKey); !ok {
+		return dsig.ErrNonRSAKey
+	}
+	return nil
+}
+
+// ServiceProvider returns the service provider of this source, baseURL is the URL
+// the endpoints of the source are served under
+func (source *Source) ServiceProvider(baseURL string) (*saml2.SAMLServiceProvider, error) {
+	metadata, err := ParseIdentityProviderMetadata([]byte(source.IdentityProviderMetadata))
+	if err != nil {
+		return nil, err
+	}
+	ssoURL, ssoBinding, err := singleSignOnService(metadata)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := signingCertificates(metadata)
+	if err != nil {
+		return nil, err
+	}
+	keyPair, err := tls.X509KeyPair([]byte(source.ServiceProviderCertificate), []byte(source.ServiceProviderPrivateKey))
+	if err != nil {
+		return nil, err
+	}
+
+	nameIDFormat := source.NameIDFormat
+	if nameIDFormat == "" {
+		nameIDFormat = NameIDFormatUnspecified
+	}
+
+	return &saml2.SAMLServiceProvider{
+		IdentityProviderSSOURL:      ssoURL,
+		IdentityProviderSSOBinding:  ssoBinding,
+		IdentityProviderIssuer:      metadata.EntityID,
+		AssertionConsumerServiceURL: baseURL + "/acs",
+		ServiceProviderIssuer:       baseURL + "/metadata",
+		AudienceURI:                 baseURL + "/metadata",
+		SignAuthnRequests:           source.SignRequests,
+		IDPCertificateStore:         &dsig.MemoryX509CertificateStore{Roots: certs},
+		SPKeyStore:                  dsig.TLSCertKeyStore(keyPair),
+		NameIdFormat:                nameIDFormat,
+		AllowMissingAttributes:      true,
+	}, nil
+}
+
+// Metadata returns the XML metadata of the service provider of this source
+func (source *Source) Metadata(baseURL string) ([]byte, error) {
+	sp, err := source.ServiceProvider(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := sp.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	metadata.SPSSODescriptor.NameIDFormats = []string{sp.NameIdFormat}
+
+	data, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// NewRequestID returns an ID for an AuthnRequest of the named source. The ID can be verified
+// by VerifyRequestID without keeping state, as the response may arrive without a session cookie.
+func NewRequestID(name string) (string, error) {
+	nonce := make([]byte, requestNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encoded := hex.EncodeToString(nonce)
+	return "_" + encoded + base.CreateTimeLimitCode(name+encoded, requestLifetime, nil), nil
+}
+
+// VerifyRequestID checks that the ID was created by NewRequestID for the named source and has not expired
+func VerifyRequestID(name, id string) bool {
+	if len(id) != 1+2*requestNonceLength+base.TimeLimitCodeLength || id[0] != '_' {
+		return false
+	}
+	encoded := id[1 : 1+2*requestNonceLength]
+	return base.VerifyTimeLimitCode(name+encoded, requestLifetime, id[1+2*requestNonceLength:])
+}
+
+var (
+	// consumedIDs remembers the request and assertion IDs of accepted responses until the requests expire, so responses cannot be replayed
+	consumedIDs     mc.Cache
+	consumedIDsErr  error
+	consumedIDsOnce sync.Once
+	consumedIDsLock sync.Mutex
+)
+
+// getConsumedIDs returns the configured cache, which is shared between instances, or a cache in memory if caching is disabled
+func getConsumedIDs() (mc.Cache, error) {
+	consumedIDsOnce.Do(func() {
+		if consumedIDs = cache.GetCache(); consumedIDs == nil {
+			consumedIDs, consumedIDsErr = mc.NewCacher(mc.Options{Adapter: "memory", Interval: 60})
+		}
+	})
+	return consumedIDs, consumedIDsErr
+}
+
+// consumeIDs records the IDs as used for the named source. It returns false if any of them has been used before.
+func consumeIDs(name string, ids []string) (bool, error) {
+	c, err := getConsumedIDs()
+	if err != nil {
+		return false, err
+	}
+
+	consumedIDsLock.Lock()
+	defer consumedIDsLock.Unlock()
+	for _, id := range ids {
+		if c.IsExist("saml_consumed_" + name + "_" + id) {
+			return false, nil
+		}
+	}
+	for _, id := range ids {
+		if err := c.Put("saml_consumed_"+name+"_"+id, true, requestLifetime*60); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// AuthRequest builds an AuthnRequest with the given ID. It returns the URL to redirect the user to or,
+// for identity providers that only support the HTTP-POST binding, an HTML form posting the request.
+func AuthRequest(sp *saml2.SAMLServiceProvider, requestID string) (string, []byte, error) {
+	doc, err := sp.BuildAuthRequestDocumentNoSig()
+	if err != nil {
+		return "", nil, err
+	}
+	doc.Root().CreateAttr("ID", requestID)
+
+	if sp.IdentityProviderSSOBinding == saml2.BindingHttpPost {
+		if sp.SignAuthnRequests {
+			signed, err := sp.SignAuthnRequest(doc.Root())
+			if err != nil {
+				return "", nil, err
+			}
+			doc.SetRoot(signed)
+		}
+		body, err := sp.BuildAuthBodyPostFromDocument("", doc)
+		return "", body, err
+	}
+
+	// the redirect binding signs the query string instead of the document
+	redirectURL, err := sp.BuildAuthURLRedirect("", doc)
+	return redirectURL, nil, err
+}
+
+// VerifyAssertion checks the conditions of an assertion that has been validated by the
+// service provider of the named source. Each request and assertion is only accepted once.
+func VerifyAssertion(name string, info *saml2.AssertionInfo) error {
+	if info.WarningInfo != nil {
+		if info.WarningInfo.InvalidTime {
+			return errors.New("assertion is expired or not yet valid")
+		}
+		if info.WarningInfo.NotInAudience {
+			return errors.New("assertion is not addressed to this service provider")
+		}
+	}
+	for _, assertion := range info.Assertions {
+		if assertion.Subject == nil || assertion.Subject.SubjectConfirmation == nil || assertion.Subject.SubjectConfirmation.SubjectConfirmationData == nil {
+			return errors.New("assertion has no subject confirmation")
+		}
+		if !VerifyRequestID(name, assertion.Subject.SubjectConfirmation.SubjectConfirmationData.InResponseTo) {
+			return errors.New("assertion does not respond to a valid request")
+		}
+	}
+
+	ids := make([]string, 0, 2*len(info.Assertions))
+	for _, assertion := range info.Assertions {
+		ids = append(ids, "request_"+assertion.Subject.SubjectConfirmation.SubjectConfirmationData.InResponseTo)
+		if assertion.ID != "" {
+			ids = append(ids, "assertion_"+assertion.ID)
+		}
+	}
+	if ok, err := consumeIDs(name, ids); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("request or assertion has been used before")
+	}
+	return nil
+}
+
+// User maps the attributes of a verified assertion to the user of the named source
+func (source *Source) User(name string, info *saml2.AssertionInfo) goth.User {
+	user := goth.User{
+		Provider: name,
+		UserID:   info.NameID,
+		NickName: strings.SplitN(info.NameID, "@", 2)[0],
+		RawData:  make(map[string]interface{}, len(info.Values)),
+	}
+	if source.AttributeUsername != "" {
+		user.NickName = info.Values.Get(source.AttributeUsername)
+	}
+	if source.AttributeEmail != "" {
+		user.Email = info.Values.Get(source.AttributeEmail)
+	} else if strings.Contains(info.NameID, "@") {
+		user.Email = info.NameID
+	}
+	if source.AttributeFullName != "" {
+		user.Name = info.Values.Get(source.AttributeFullName)
+	}
+	for key := range info.Values {
+		user.RawData[key] = info.Values.GetAll(key)
+	}
+	return user
+}
+
+// Groups returns the groups the user of the assertion is a member of
+func (source *Source) Groups(info *saml2.AssertionInfo) []string {
+	if source.AttributeGroups == "" {
+		return nil
+	}
+	return info.Values.GetAll(source.AttributeGroups)
+}
+
+func (source *Source) isMember(info *saml2.AssertionInfo, group string) bool {
+	for _, g := range source.Groups(info) {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAllowed returns whether the user of the assertion may sign in through this source
+func (source *Source) IsAllowed(info *saml2.AssertionInfo) bool {
+	return source.RequiredGroup == "" || source.isMember(info, source.RequiredGroup)
+}
+
+// IsAdmin returns whether the user of the assertion is a site administrator,
+// the second return value is false if this source does not manage administrators
+func (source *Source) IsAdmin(info *saml2.AssertionInfo) (bool, bool) {
+	if source.AdminGroup == "" {
+		return false, false
+	}
+	return source.isMember(info, source.AdminGroup), true
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package saml
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	saml2 "github.com/russellhaering/gosaml2"
+	"github.com/russellhaering/gosaml2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testIdentityProviderMetadata(t *testing.T, binding string) string {
+	cert, _, err := GenerateServiceProviderCertificate("idp.example.com")
+	assert.NoError(t, err)
+	block, _ := pem.Decode([]byte(cert))
+
+	return fmt.Sprintf(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com/metadata">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <KeyDescriptor use="signing">
+      <KeyInfo xmlns="http://www.w3.org/2000/09/xmldsig#">
+        <X509Data><X509Certificate>%s</X509Certificate></X509Data>
+      </KeyInfo>
+    </KeyDescriptor>
+    <SingleSignOnService Binding="%s" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`, base64.StdEncoding.EncodeToString(block.Bytes), binding)
+}
+
+func TestParseIdentityProviderMetadata(t *testing.T) {
+	metadata, err := ParseIdentityProviderMetadata([]byte(testIdentityProviderMetadata(t, saml2.BindingHttpRedirect)))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/metadata", metadata.EntityID)
+
+	_, err = ParseIdentityProviderMetadata([]byte(testIdentityProviderMetadata(t, "urn:oasis:names:tc:SAML:2.0:bindings:SOAP")))
+	assert.Error(t, err)
+
+	_, err = ParseIdentityProviderMetadata([]byte(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com/metadata"/>`))
+	assert.Error(t, err)
+
+	_, err = ParseIdentityProviderMetadata([]byte("not xml"))
+	assert.Error(t, err)
+}
+
+func TestServiceProviderKeyPair(t *testing.T) {
+	cert, key, err := GenerateServiceProviderCertificate("gitea.example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateServiceProviderKeyPair(cert, key))
+
+	otherCert, _, err := GenerateServiceProviderCertificate("gitea.example.com")
+	assert.NoError(t, err)
+	assert.Error(t, ValidateServiceProviderKeyPair(otherCert, key))
+}
+
+func TestSourceMetadata(t *testing.T) {
+	cert, key, err := GenerateServiceProviderCertificate("gitea.example.com")
+	assert.NoError(t, err)
+	source := &Source{
+		IdentityProviderMetadata:   testIdentityProviderMetadata(t, saml2.BindingHttpPost),
+		ServiceProviderCertificate: cert,
+		ServiceProviderPrivateKey:  key,
+	}
+
+	sp, err := source.ServiceProvider("https://gitea.example.com/user/saml/test")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/sso", sp.IdentityProviderSSOURL)
+	assert.Equal(t, saml2.BindingHttpPost, sp.IdentityProviderSSOBinding)
+	assert.Equal(t, "https://gitea.example.com/user/saml/test/acs", sp.AssertionConsumerServiceURL)
+	assert.Equal(t, NameIDFormatUnspecified, sp.NameIdFormat)
+
+	metadata, err := source.Metadata("https://gitea.example.com/user/saml/test")
+	assert.NoError(t, err)
+	assert.Contains(t, string(metadata), `entityID="https://gitea.example.com/user/saml/test/metadata"`)
+	assert.Contains(t, string(metadata), `Location="https://gitea.example.com/user/saml/test/acs"`)
+
+	requestID, err := NewRequestID("test")
+	assert.NoError(t, err)
+	_, form, err := AuthRequest(sp, requestID)
+	assert.NoError(t, err)
+	assert.Contains(t, string(form), "https://idp.example.com/sso")
+}
+
+func TestRequestID(t *testing.T) {
+	id, err := NewRequestID("test")
+	assert.NoError(t, err)
+	assert.True(t, VerifyRequestID("test", id))
+	assert.False(t, VerifyRequestID("other", id))
+	assert.False(t, VerifyRequestID("test", id[:len(id)-1]+"0"))
+	assert.False(t, VerifyRequestID("test", ""))
+
+	other, err := NewRequestID("test")
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, other)
+}
+
+func TestVerifyAssertionReplay(t *testing.T) {
+	id, err := NewRequestID("test")
+	assert.NoError(t, err)
+	newInfo := func(assertionID string) *saml2.AssertionInfo {
+		return &saml2.AssertionInfo{Assertions: []types.Assertion{{
+			ID: assertionID,
+			Subject: &types.Subject{SubjectConfirmation: &types.SubjectConfirmation{
+				SubjectConfirmationData: &types.SubjectConfirmationData{InResponseTo: id},
+			}},
+		}}}
+	}
+
+	assert.NoError(t, VerifyAssertion("test", newInfo("assertion1")))
+	// neither the response nor another assertion for the same request is accepted again
+	assert.Error(t, VerifyAssertion("test", newInfo("assertion1")))
+	assert.Error(t, VerifyAssertion("test", newInfo("assertion2")))
+
+	id, err = NewRequestID("test")
+	assert.NoError(t, err)
+	assert.Error(t, VerifyAssertion("test", newInfo("assertion1")))
+	assert.NoError(t, VerifyAssertion("test", newInfo("assertion2")))
+}
+
+func testAssertionInfo(nameID string, values map[string][]string) *saml2.AssertionInfo {
+	info := &saml2.AssertionInfo{NameID: nameID, Values: saml2.Values{}}
+	for name, vals := range values {
+		attr := types.Attribute{Name: name}
+		for _, v := range vals {
+			attr.Values = append(attr.Values, types.AttributeValue{Value: v})
+		}
+		info.Values[name] = attr
+	}
+	return info
+}
+
+func TestSourceUser(t *testing.T) {
+	info := testAssertionInfo("user2@example.com", map[string][]string{
+		"uid":    {"user2"},
+		"mail":   {"user2@example.org"},
+		"cn":     {"User Two"},
+		"groups": {"developers", "admins"},
+	})
+
+	user := (&Source{}).User("test", info)
+	assert.Equal(t, "test", user.Provider)
+	assert.Equal(t, "user2@example.com", user.UserID)
+	assert.Equal(t, "user2", user.NickName)
+	assert.Equal(t, "user2@example.com", user.Email)
+	assert.Empty(t, user.Name)
+	assert.Equal(t, []string{"developers", "admins"}, user.RawData["groups"])
+
+	source := &Source{
+		AttributeUsername: "uid",
+		AttributeEmail:    "mail",
+		AttributeFullName: "cn",
+		AttributeGroups:   "groups",
+	}
+	user = source.User("test", info)
+	assert.Equal(t, "user2", user.NickName)
+	assert.Equal(t, "user2@example.org", user.Email)
+	assert.Equal(t, "User Two", user.Name)
+	assert.Equal(t, []string{"developers", "admins"}, source.Groups(info))
+
+	assert.True(t, source.IsAllowed(info))
+	isAdmin, managed := source.IsAdmin(info)
+	assert.False(t, isAdmin)
+	assert.False(t, managed)
+
+	source.RequiredGroup = "developers"
+	source.AdminGroup = "admins"
+	assert.True(t, source.IsAllowed(info))
+	isAdmin, managed = source.IsAdmin(info)
+	assert.True(t, isAdmin)
+	assert.True(t, managed)
+
+	source.RequiredGroup = "testers"
+	source.AdminGroup = "owners"
+	assert.False(t, source.IsAllowed(info))
+	isAdmin, managed = source.IsAdmin(info)
+	assert.False(t, isAdmin)
+	assert.True(t, managed)
+}
@@ -70,10 +70,11 @@ func (b *Basic) VerifyAuthData(req *http.Request, w http.ResponseWriter, store D
 		authToken = passwd
 	}
 
-	uid := CheckOAuthAccessToken(authToken)
+	uid, scope := CheckOAuthAccessToken(authToken)
 	if uid != 0 {
 		var err error
 		store.GetData()["IsApiToken"] = true
+		store.GetData()["ApiTokenScope"] = scope
 
 		u, err = models.GetUserByID(uid)
 		if err != nil {
@@ -93,6 +94,8 @@ func (b *Basic) VerifyAuthData(req *http.Request, w http.ResponseWriter, store D
 		if err = models.UpdateAccessToken(token); err != nil {
 			log.Error("UpdateAccessToken:  %v", err)
 		}
+		store.GetData()["ApiToken"] = token
+		store.GetData()["ApiTokenScope"] = token.Scope
 	} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 		log.Error("GetAccessTokenBySha: %v", err)
 	}
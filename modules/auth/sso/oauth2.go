@@ -21,28 +21,28 @@ var (
 	_ SingleSignOn = &OAuth2{}
 )
 
-// CheckOAuthAccessToken returns uid of user from oauth token
-func CheckOAuthAccessToken(accessToken string) int64 {
+// CheckOAuthAccessToken returns uid of user from oauth token and the API scopes granted to it
+func CheckOAuthAccessToken(accessToken string) (int64, models.AccessTokenScope) {
 	// JWT tokens require a "."
 	if !strings.Contains(accessToken, ".") {
-		return 0
+		return 0, ""
 	}
 	token, err := models.ParseOAuth2Token(accessToken)
 	if err != nil {
 		log.Trace("ParseOAuth2Token: %v", err)
-		return 0
+		return 0, ""
 	}
 	var grant *models.OAuth2Grant
 	if grant, err = models.GetOAuth2GrantByID(token.GrantID); err != nil || grant == nil {
-		return 0
+		return 0, ""
 	}
 	if token.Type != models.TypeAccessToken {
-		return 0
+		return 0, ""
 	}
 	if token.ExpiresAt < time.Now().Unix() || token.IssuedAt > time.Now().Unix() {
-		return 0
+		return 0, ""
 	}
-	return grant.UserID
+	return grant.UserID, token.AccessTokenScope()
 }
 
 // OAuth2 implements the SingleSignOn interface and authenticates requests
@@ -86,9 +86,10 @@ func (o *OAuth2) userIDFromToken(req *http.Request, store DataStore) int64 {
 
 	// Let's see if token is valid.
 	if strings.Contains(tokenSHA, ".") {
-		uid := CheckOAuthAccessToken(tokenSHA)
+		uid, scope := CheckOAuthAccessToken(tokenSHA)
 		if uid != 0 {
 			store.GetData()["IsApiToken"] = true
+			store.GetData()["ApiTokenScope"] = scope
 		}
 		return uid
 	}
@@ -104,6 +105,8 @@ func (o *OAuth2) userIDFromToken(req *http.Request, store DataStore) int64 {
 		log.Error("UpdateAccessToken: %v", err)
 	}
 	store.GetData()["IsApiToken"] = true
+	store.GetData()["ApiToken"] = t
+	store.GetData()["ApiTokenScope"] = t.Scope
 	return t.UID
 }
 
@@ -122,7 +125,7 @@ func (o *OAuth2) VerifyAuthData(req *http.Request, w http.ResponseWriter, store
 		return nil
 	}
 
-	if middleware.IsInternalPath(req) || !middleware.IsAPIPath(req) && !isAttachmentDownload(req) {
+	if middleware.IsInternalPath(req) || !middleware.IsAPIPath(req) && !isAttachmentDownload(req) && !isSCIMPath(req) {
 		return nil
 	}
 
@@ -98,6 +98,11 @@ func isAttachmentDownload(req *http.Request) bool {
 	return strings.HasPrefix(req.URL.Path, "/attachments/") && req.Method == "GET"
 }
 
+// isSCIMPath checks if the request is for the SCIM provisioning API
+func isSCIMPath(req *http.Request) bool {
+	return strings.HasPrefix(req.URL.Path, "/scim/")
+}
+
 // handleSignIn clears existing session variables and stores new ones for the specified user object
 func handleSignIn(resp http.ResponseWriter, req *http.Request, sess SessionStore, user *models.User) {
 	_ = sess.Delete("openid_verified_uri")
@@ -106,7 +111,8 @@ func handleSignIn(resp http.ResponseWriter, req *http.Request, sess SessionStore
 	_ = sess.Delete("openid_determined_username")
 	_ = sess.Delete("twofaUid")
 	_ = sess.Delete("twofaRemember")
-	_ = sess.Delete("u2fChallenge")
+	_ = sess.Delete("webauthnAssertion")
+	_ = sess.Delete("webauthnPasswordlessAssertion")
 	_ = sess.Delete("linkAccount")
 	err := sess.Set("uid", user.ID)
 	if err != nil {
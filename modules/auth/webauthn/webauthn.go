@@ -0,0 +1,111 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webauthn
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthn represents the relying party of this instance
+var WebAuthn *webauthn.WebAuthn
+
+// legacyU2F is the relying party security keys registered through the U2F API are bound to
+var legacyU2F *webauthn.WebAuthn
+
+// Init initializes the WebAuthn relying parties from the settings
+func Init() {
+	appURL, err := url.Parse(setting.AppURL)
+	if err != nil {
+		log.Fatal("Unable to parse ROOT_URL for WebAuthn: %v", err)
+	}
+	origin := appURL.Scheme + "://" + appURL.Host
+
+	config := webauthn.Config{
+		RPDisplayName: setting.AppName,
+		RPID:          appURL.Hostname(),
+		RPOrigin:      origin,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationDiscouraged,
+		},
+		AttestationPreference: protocol.PreferDirectAttestation,
+	}
+	legacyConfig := config
+	legacyConfig.RPID = setting.U2F.AppID
+
+	if WebAuthn, err = webauthn.New(&config); err != nil {
+		log.Fatal("Unable to initialize WebAuthn: %v", err)
+	}
+	if legacyU2F, err = webauthn.New(&legacyConfig); err != nil {
+		log.Fatal("Unable to initialize WebAuthn for U2F security keys: %v", err)
+	}
+}
+
+// ForAssertion returns the relying party the given assertion has been created for.
+// Security keys that were registered through the U2F API sign for the U2F App ID instead of the host.
+func ForAssertion(assertion *protocol.ParsedCredentialAssertionData) *webauthn.WebAuthn {
+	legacyHash := sha256.Sum256([]byte(setting.U2F.AppID))
+	if string(assertion.Response.AuthenticatorData.RPIDHash) == string(legacyHash[:]) {
+		return legacyU2F
+	}
+	return WebAuthn
+}
+
+// LoginExtensions returns the extensions to request on login, which allow security keys registered
+// through the U2F API to be used
+func LoginExtensions() protocol.AuthenticationExtensions {
+	return protocol.AuthenticationExtensions{"appid": setting.U2F.AppID}
+}
+
+// User represents an implementation of webauthn.User based on models.User
+type User models.User
+
+// WebAuthnID implements the webauthn.User interface
+func (u *User) WebAuthnID() []byte {
+	id := make([]byte, 8)
+	binary.PutVarint(id, u.ID)
+	return id
+}
+
+// UserIDFromHandle returns the id of the user the given user handle has been created for
+func UserIDFromHandle(handle []byte) int64 {
+	id, n := binary.Varint(handle)
+	if n <= 0 {
+		return 0
+	}
+	return id
+}
+
+// WebAuthnName implements the webauthn.User interface
+func (u *User) WebAuthnName() string {
+	return u.Name
+}
+
+// WebAuthnDisplayName implements the webauthn.User interface
+func (u *User) WebAuthnDisplayName() string {
+	return (*models.User)(u).DisplayName()
+}
+
+// WebAuthnIcon implements the webauthn.User interface
+func (u *User) WebAuthnIcon() string {
+	return (*models.User)(u).AvatarLink()
+}
+
+// WebAuthnCredentials implements the webauthn.User interface
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	creds, err := models.GetWebAuthnCredentialsByUID(u.ID)
+	if err != nil {
+		return nil
+	}
+	return creds.ToCredentials()
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package runner implements a minimal CI runner which runs the steps of jobs as shell scripts on the local machine.
+// It checks out the repositories anonymously, so it can only run the jobs of repositories it can read without credentials.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// TokenHeader is the header the runner passes its token with
+const TokenHeader = "X-Gitea-Runner-Token"
+
+// Runner fetches CI jobs from a Gitea instance and runs them
+type Runner struct {
+	// URL is the root URL of the Gitea instance
+	URL string
+	// Token is the token returned when the runner has been registered
+	Token string
+	// WorkDir holds the checkouts of the jobs while they are run
+	WorkDir string
+	Client  *http.Client
+}
+
+// RunOnce fetches a job and runs it. It returns false if there was no job to run.
+func (r *Runner) RunOnce(ctx context.Context) (bool, error) {
+	task, err := r.fetchJob(ctx)
+	if err != nil || task == nil {
+		return false, err
+	}
+
+	result := api.CIStatusSuccess
+	if err := r.runJob(ctx, task); err != nil {
+		result = api.CIStatusFailure
+		if ctx.Err() != nil {
+			result = api.CIStatusCancelled
+		}
+		if err := r.appendLog(context.Background(), task.ID, []byte(fmt.Sprintf("%v\n", err))); err != nil {
+			return true, err
+		}
+	}
+	return true, r.reportResult(context.Background(), task.ID, result)
+}
+
+func (r *Runner) runJob(ctx context.Context, task *api.CIJobTask) error {
+	dir := filepath.Join(r.WorkDir, "job-"+strconv.FormatInt(task.ID, 10))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := checkout(ctx, dir, task); err != nil {
+		return fmt.Errorf("checkout of %s failed: %v", task.CommitSHA, err)
+	}
+
+	env := os.Environ()
+	for k, v := range task.Env {
+		env = append(env, k+"="+v)
+	}
+	for i, step := range task.Steps {
+		name := step.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("Step %d", i+1)
+		}
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", step.Run)
+		cmd.Dir = dir
+		cmd.Env = env
+		for k, v := range step.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+		output, runErr := cmd.CombinedOutput()
+
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "=== %s\n", name)
+		buf.Write(output)
+		if err := r.appendLog(ctx, task.ID, buf.Bytes()); err != nil {
+			return err
+		}
+		if runErr != nil {
+			return fmt.Errorf("%s failed: %v", name, runErr)
+		}
+	}
+	return nil
+}
+
+// checkout fetches the ref of the job and checks out its commit
+func checkout(ctx context.Context, dir string, task *api.CIJobTask) error {
+	if _, err := git.NewCommandContext(ctx, "init", "--quiet").RunInDir(dir); err != nil {
+		return err
+	}
+	if _, err := git.NewCommandContext(ctx, "fetch", "--quiet", task.CloneURL, task.Ref).RunInDir(dir); err != nil {
+		return err
+	}
+	_, err := git.NewCommandContext(ctx, "checkout", "--quiet", task.CommitSHA).RunInDir(dir)
+	return err
+}
+
+func (r *Runner) do(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(r.URL, "/")+"/api/v1/ci/runner/jobs/"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(TokenHeader, r.Token)
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+func (r *Runner) post(ctx context.Context, path, contentType string, body io.Reader) error {
+	resp, err := r.do(ctx, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("POST %s: %s %s", path, resp.Status, msg)
+	}
+	return nil
+}
+
+func (r *Runner) fetchJob(ctx context.Context) (*api.CIJobTask, error) {
+	resp, err := r.do(ctx, "fetch", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK:
+		task := new(api.CIJobTask)
+		if err := json.NewDecoder(resp.Body).Decode(task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+	msg, _ := ioutil.ReadAll(resp.Body)
+	return nil, fmt.Errorf("fetch job: %s %s", resp.Status, msg)
+}
+
+func (r *Runner) appendLog(ctx context.Context, jobID int64, content []byte) error {
+	return r.post(ctx, fmt.Sprintf("%d/logs", jobID), "text/plain", bytes.NewReader(content))
+}
+
+func (r *Runner) reportResult(ctx context.Context, jobID int64, result api.CIStatus) error {
+	body, err := json.Marshal(&api.CIJobResultOption{Result: result})
+	if err != nil {
+		return err
+	}
+	return r.post(ctx, fmt.Sprintf("%d/result", jobID), "application/json", bytes.NewReader(body))
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// WorkflowsDir is the directory of a repository which holds the workflow files
+const WorkflowsDir = ".gitea/workflows"
+
+// The events which trigger workflows
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+)
+
+// IsWorkflowFile returns true if the file name is the one of a workflow file
+func IsWorkflowFile(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// Workflow represents a workflow file of a repository
+type Workflow struct {
+	Name string            `yaml:"name"`
+	On   Events            `yaml:"on"`
+	Env  map[string]string `yaml:"env"`
+	Jobs map[string]*Job   `yaml:"jobs"`
+}
+
+// Events holds the events which trigger a workflow, together with their filters
+type Events map[string]*EventFilter
+
+// EventFilter restricts the branches an event triggers a workflow for.
+// For pull requests the branches are the ones the pull requests target.
+type EventFilter struct {
+	Branches []string `yaml:"branches"`
+}
+
+// UnmarshalYAML accepts a single event, a list of events or a map of events with their filters
+func (e *Events) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*e = make(Events)
+
+	var event string
+	if err := unmarshal(&event); err == nil {
+		(*e)[event] = &EventFilter{}
+		return nil
+	}
+
+	var events []string
+	if err := unmarshal(&events); err == nil {
+		for _, event := range events {
+			(*e)[event] = &EventFilter{}
+		}
+		return nil
+	}
+
+	filters := make(map[string]*EventFilter)
+	if err := unmarshal(&filters); err != nil {
+		return err
+	}
+	for event, filter := range filters {
+		if filter == nil {
+			filter = &EventFilter{}
+		}
+		(*e)[event] = filter
+	}
+	return nil
+}
+
+// Job represents a job of a workflow, whose steps are run one after the other by a runner
+type Job struct {
+	Name   string            `yaml:"name"`
+	RunsOn StringList        `yaml:"runs-on"`
+	Env    map[string]string `yaml:"env"`
+	Steps  []*Step           `yaml:"steps"`
+}
+
+// Step represents a shell script run as part of a job
+type Step struct {
+	Name string            `yaml:"name" json:"name"`
+	Run  string            `yaml:"run" json:"run"`
+	Env  map[string]string `yaml:"env" json:"env"`
+}
+
+// StringList is a list of strings which may be given as a single string
+type StringList []string
+
+// UnmarshalYAML accepts a single string or a list of strings
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ParseWorkflow parses and validates the content of a workflow file
+func ParseWorkflow(name string, content []byte) (*Workflow, error) {
+	workflow := new(Workflow)
+	if err := yaml.Unmarshal(content, workflow); err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %v", name, err)
+	}
+	if len(workflow.Name) == 0 {
+		workflow.Name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	if len(workflow.Jobs) == 0 {
+		return nil, fmt.Errorf("invalid workflow %s: no jobs", name)
+	}
+	for id, job := range workflow.Jobs {
+		if job == nil || len(job.Steps) == 0 {
+			return nil, fmt.Errorf("invalid workflow %s: job %s has no steps", name, id)
+		}
+		if len(job.Name) == 0 {
+			job.Name = id
+		}
+		for i, step := range job.Steps {
+			if step == nil || len(strings.TrimSpace(step.Run)) == 0 {
+				return nil, fmt.Errorf("invalid workflow %s: step %d of job %s has nothing to run", name, i+1, id)
+			}
+		}
+	}
+	return workflow, nil
+}
+
+// IsTriggeredBy returns true if the event on the branch triggers the workflow
+func (w *Workflow) IsTriggeredBy(event, branch string) bool {
+	filter, ok := w.On[event]
+	if !ok {
+		return false
+	}
+	if len(filter.Branches) == 0 {
+		return true
+	}
+	for _, pattern := range filter.Branches {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			if pattern == branch {
+				return true
+			}
+			continue
+		}
+		if g.Match(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// JobIDs returns the IDs of the jobs of the workflow in a stable order
+func (w *Workflow) JobIDs() []string {
+	ids := make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	workflow, err := ParseWorkflow("test.yml", []byte(`
+on:
+  push:
+    branches: [master, "release/*"]
+  pull_request:
+env:
+  FOO: foo
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - name: Test
+        run: make test
+  lint:
+    name: Lint code
+    runs-on: [linux, go]
+    env:
+      BAR: bar
+    steps:
+      - run: make lint
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", workflow.Name)
+	assert.Equal(t, map[string]string{"FOO": "foo"}, workflow.Env)
+	assert.Equal(t, []string{"lint", "test"}, workflow.JobIDs())
+	assert.Equal(t, "test", workflow.Jobs["test"].Name)
+	assert.EqualValues(t, []string{"linux"}, workflow.Jobs["test"].RunsOn)
+	assert.Equal(t, "Lint code", workflow.Jobs["lint"].Name)
+	assert.EqualValues(t, []string{"linux", "go"}, workflow.Jobs["lint"].RunsOn)
+	assert.Equal(t, "make lint", workflow.Jobs["lint"].Steps[0].Run)
+
+	assert.True(t, workflow.IsTriggeredBy(EventPush, "master"))
+	assert.True(t, workflow.IsTriggeredBy(EventPush, "release/1.0"))
+	assert.False(t, workflow.IsTriggeredBy(EventPush, "release/1.0/fix"))
+	assert.False(t, workflow.IsTriggeredBy(EventPush, "develop"))
+	assert.True(t, workflow.IsTriggeredBy(EventPullRequest, "develop"))
+}
+
+func TestParseWorkflowEvents(t *testing.T) {
+	const jobs = `
+jobs:
+  test:
+    steps:
+      - run: make test
+`
+	workflow, err := ParseWorkflow("a.yml", []byte("on: push"+jobs))
+	assert.NoError(t, err)
+	assert.True(t, workflow.IsTriggeredBy(EventPush, "master"))
+	assert.False(t, workflow.IsTriggeredBy(EventPullRequest, "master"))
+
+	workflow, err = ParseWorkflow("a.yml", []byte("on: [push, pull_request]"+jobs))
+	assert.NoError(t, err)
+	assert.True(t, workflow.IsTriggeredBy(EventPush, "master"))
+	assert.True(t, workflow.IsTriggeredBy(EventPullRequest, "master"))
+}
+
+func TestParseWorkflowInvalid(t *testing.T) {
+	for _, content := range []string{
+		"on: push",
+		"on: push\njobs:\n  test:\n",
+		"on: push\njobs:\n  test:\n    steps:\n      - name: nothing\n",
+		"on: push\njobs: [",
+	} {
+		_, err := ParseWorkflow("a.yml", []byte(content))
+		assert.Error(t, err, content)
+	}
+}
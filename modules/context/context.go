@@ -64,8 +64,9 @@ type Context struct {
 	IsSigned    bool
 	IsBasicAuth bool
 
-	Repo *Repository
-	Org  *Organization
+	Repo    *Repository
+	Org     *Organization
+	Package *Package
 }
 
 // GetData returns the data
@@ -705,6 +706,7 @@ func Contexter() func(next http.Handler) http.Handler {
 			ctx.Data["EnableOpenIDSignIn"] = setting.Service.EnableOpenIDSignIn
 			ctx.Data["DisableMigrations"] = setting.Repository.DisableMigrations
 			ctx.Data["DisableStars"] = setting.Repository.DisableStars
+			ctx.Data["EnableCI"] = setting.CI.Enabled
 
 			ctx.Data["ManifestData"] = setting.ManifestData
 
@@ -0,0 +1,54 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Package contains the owner of the requested packages and the access the doer has to them
+type Package struct {
+	Owner      *models.User
+	AccessMode models.AccessMode
+}
+
+// AccessModeFor returns the access the doer has to the given package of the owner.
+// p may be nil for packages which do not exist yet.
+func (p *Package) AccessModeFor(doer *models.User, pkg *models.Package) (models.AccessMode, error) {
+	if pkg == nil || pkg.RepoID == 0 {
+		return p.AccessMode, nil
+	}
+	if doer == nil && setting.Service.RequireSignInView {
+		return models.AccessModeNone, nil
+	}
+	return models.GetPackageAccessMode(doer, p.Owner, pkg)
+}
+
+// PackageAssignment returns a middleware to handle Context.Package assignment
+func PackageAssignment() func(ctx *APIContext) {
+	return func(ctx *APIContext) {
+		owner, err := models.GetUserByName(ctx.Params("username"))
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+
+		ctx.Package = &Package{Owner: owner}
+		if ctx.User == nil && setting.Service.RequireSignInView {
+			return
+		}
+		ctx.Package.AccessMode, err = models.GetPackageAccessMode(ctx.User, owner, nil)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetPackageAccessMode", err)
+		}
+	}
+}
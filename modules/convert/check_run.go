@@ -0,0 +1,57 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToCheckRun converts models.CheckRun to api.CheckRun, the run's attributes have to be loaded
+func ToCheckRun(run *models.CheckRun) *api.CheckRun {
+	apiRun := &api.CheckRun{
+		ID:         run.ID,
+		HeadSHA:    run.HeadSHA,
+		Name:       run.Name,
+		Status:     run.Status,
+		Conclusion: run.Conclusion,
+		DetailsURL: run.DetailsURL,
+		ExternalID: run.ExternalID,
+		HTMLURL:    run.HTMLURL(),
+		URL:        run.APIURL(),
+		Output: &api.CheckRunOutput{
+			Title:            run.Title,
+			Summary:          run.Summary,
+			Text:             run.Text,
+			AnnotationsCount: run.AnnotationsCount,
+		},
+		Created: run.CreatedUnix.AsTime(),
+		Updated: run.UpdatedUnix.AsTime(),
+	}
+	if run.Creator != nil {
+		apiRun.Creator = ToUser(run.Creator, nil)
+	}
+	if run.StartedUnix > 0 {
+		apiRun.Started = run.StartedUnix.AsTimePtr()
+	}
+	if run.CompletedUnix > 0 {
+		apiRun.Completed = run.CompletedUnix.AsTimePtr()
+	}
+	return apiRun
+}
+
+// ToCheckRunAnnotation converts models.CheckRunAnnotation to api.CheckRunAnnotation
+func ToCheckRunAnnotation(annotation *models.CheckRunAnnotation) *api.CheckRunAnnotation {
+	return &api.CheckRunAnnotation{
+		ID:         annotation.ID,
+		Path:       annotation.Path,
+		StartLine:  annotation.StartLine,
+		EndLine:    annotation.EndLine,
+		Level:      annotation.Level,
+		Title:      annotation.Title,
+		Message:    annotation.Message,
+		RawDetails: annotation.RawDetails,
+	}
+}
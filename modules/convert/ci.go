@@ -0,0 +1,109 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToCIRunner converts models.CIRunner to api.CIRunner
+func ToCIRunner(runner *models.CIRunner) *api.CIRunner {
+	apiRunner := &api.CIRunner{
+		ID:      runner.ID,
+		Name:    runner.Name,
+		Labels:  runner.Labels,
+		Token:   runner.Token,
+		Created: runner.CreatedUnix.AsTime(),
+	}
+	if apiRunner.Labels == nil {
+		apiRunner.Labels = []string{}
+	}
+	if runner.LastOnlineUnix > 0 {
+		apiRunner.LastOnline = runner.LastOnlineUnix.AsTimePtr()
+	}
+	return apiRunner
+}
+
+// ToCIRun converts models.CIRun to api.CIRun, the run's attributes and jobs have to be loaded
+func ToCIRun(run *models.CIRun) *api.CIRun {
+	apiRun := &api.CIRun{
+		ID:           run.ID,
+		Name:         run.Name,
+		WorkflowFile: run.WorkflowFile,
+		Event:        run.Event,
+		Ref:          run.Ref,
+		CommitSHA:    run.CommitSHA,
+		Status:       run.Status,
+		TriggerUser:  ToUser(run.TriggerUser, nil),
+		HTMLURL:      run.HTMLURL(),
+		Jobs:         make([]*api.CIJob, 0, len(run.Jobs)),
+		Created:      run.CreatedUnix.AsTime(),
+		Updated:      run.UpdatedUnix.AsTime(),
+	}
+	for _, job := range run.Jobs {
+		apiRun.Jobs = append(apiRun.Jobs, ToCIJob(job))
+	}
+	return apiRun
+}
+
+// ToCIJob converts models.CIJob to api.CIJob, the job's run has to be loaded
+func ToCIJob(job *models.CIJob) *api.CIJob {
+	apiJob := &api.CIJob{
+		ID:      job.ID,
+		Name:    job.Name,
+		RunsOn:  job.RunsOn,
+		Status:  job.Status,
+		HTMLURL: job.HTMLURL(),
+	}
+	if apiJob.RunsOn == nil {
+		apiJob.RunsOn = []string{}
+	}
+	if job.StartedUnix > 0 {
+		apiJob.Started = job.StartedUnix.AsTimePtr()
+	}
+	if job.StoppedUnix > 0 {
+		apiJob.Stopped = job.StoppedUnix.AsTimePtr()
+	}
+	return apiJob
+}
+
+// ToCIJobTask converts models.CIJob to the api.CIJobTask a runner runs, the job's run has to be loaded
+func ToCIJobTask(job *models.CIJob) *api.CIJobTask {
+	run := job.Run
+	env := make(map[string]string, len(job.Env)+7)
+	for k, v := range job.Env {
+		env[k] = v
+	}
+	env["CI"] = "true"
+	env["GITEA_REPOSITORY"] = run.Repo.FullName()
+	env["GITEA_EVENT_NAME"] = run.Event
+	env["GITEA_REF"] = run.Ref
+	env["GITEA_SHA"] = run.CommitSHA
+	env["GITEA_RUN_ID"] = strconv.FormatInt(run.ID, 10)
+	env["GITEA_JOB_ID"] = strconv.FormatInt(job.ID, 10)
+
+	task := &api.CIJobTask{
+		ID:         job.ID,
+		Name:       job.Name,
+		Repository: run.Repo.FullName(),
+		CloneURL:   run.Repo.CloneLink().HTTPS,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		CommitSHA:  run.CommitSHA,
+		Env:        env,
+		Steps:      make([]*api.CIStep, 0, len(job.Steps)),
+	}
+	for _, step := range job.Steps {
+		task.Steps = append(task.Steps, &api.CIStep{
+			Name: step.Name,
+			Run:  step.Run,
+			Env:  step.Env,
+		})
+	}
+	return task
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ToCodeSearchResults converts the results of a code search to their API format
+func ToCodeSearchResults(doer *models.User, total int, groups []*code_indexer.RepoResults, languages []*code_indexer.SearchResultLanguages, truncated bool) (*api.CodeSearchResults, error) {
+	results := &api.CodeSearchResults{
+		TotalCount:   int64(total),
+		Truncated:    truncated,
+		Languages:    make([]*api.CodeSearchLanguage, 0, len(languages)),
+		Repositories: make([]*api.CodeSearchRepoResults, 0, len(groups)),
+	}
+	for _, language := range languages {
+		results.Languages = append(results.Languages, &api.CodeSearchLanguage{
+			Language: language.Language,
+			Color:    language.Color,
+			Count:    language.Count,
+		})
+	}
+
+	for _, group := range groups {
+		if err := group.Repo.GetOwner(); err != nil {
+			return nil, err
+		}
+		accessMode, err := models.AccessLevel(doer, group.Repo)
+		if err != nil {
+			return nil, err
+		}
+		repoResults := &api.CodeSearchRepoResults{
+			Repository: ToRepo(group.Repo, accessMode),
+			Files:      make([]*api.CodeSearchFile, 0, len(group.Results)),
+		}
+		for _, result := range group.Results {
+			file := &api.CodeSearchFile{
+				Filename: result.Filename,
+				CommitID: result.CommitID,
+				Language: result.Language,
+				HTMLURL:  group.Repo.HTMLURL() + "/src/commit/" + result.CommitID + "/" + util.PathEscapeSegments(result.Filename),
+				Lines:    make([]*api.CodeSearchLine, 0, len(result.Lines)),
+				Indexed:  result.UpdatedUnix.AsTime(),
+			}
+			for i, line := range result.Lines {
+				file.Lines = append(file.Lines, &api.CodeSearchLine{
+					Number:  result.LineNumbers[i],
+					Content: line,
+				})
+			}
+			repoResults.Files = append(repoResults.Files, file)
+		}
+		results.Repositories = append(results.Repositories, repoResults)
+	}
+	return results, nil
+}
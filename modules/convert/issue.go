@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	api "code.gitea.io/gitea/modules/structs"
 )
 
@@ -97,6 +98,20 @@ func ToAPIIssueList(il models.IssueList) []*api.Issue {
 	return result
 }
 
+// ToAPIIssueSearchList converts the issues of a search to their API format including the highlighted matches
+func ToAPIIssueSearchList(il models.IssueList, highlights map[int64][]issue_indexer.Highlight) []*api.Issue {
+	result := ToAPIIssueList(il)
+	for _, apiIssue := range result {
+		for _, highlight := range highlights[apiIssue.ID] {
+			apiIssue.SearchHighlights = append(apiIssue.SearchHighlights, &api.IssueSearchHighlight{
+				Field:    highlight.Field,
+				Fragment: highlight.Fragment,
+			})
+		}
+	}
+	return result
+}
+
 // ToTrackedTime converts TrackedTime to API format
 func ToTrackedTime(t *models.TrackedTime) (apiT *api.TrackedTime) {
 	apiT = &api.TrackedTime{
@@ -0,0 +1,23 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToPullMergeQueueEntry converts a merge queue entry to api.PullMergeQueueEntry,
+// the entry's attributes have to be loaded
+func ToPullMergeQueueEntry(entry *models.MergeQueueEntry, position int64) *api.PullMergeQueueEntry {
+	return &api.PullMergeQueueEntry{
+		Position:     position,
+		MergeStyle:   string(entry.MergeStyle),
+		Queuer:       ToUser(entry.Doer, nil),
+		BaseCommitID: entry.BaseCommitID,
+		CommitID:     entry.CommitID,
+		Created:      entry.CreatedUnix.AsTime(),
+	}
+}
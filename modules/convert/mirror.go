@@ -0,0 +1,35 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ToPushMirror convert a models.PushMirror to api.PushMirror
+func ToPushMirror(m *models.PushMirror) (*api.PushMirror, error) {
+	remoteAddress, err := git.GetRemoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	apiMirror := &api.PushMirror{
+		RepoName:      m.Repo.Name,
+		RemoteName:    m.RemoteName,
+		RemoteAddress: util.SanitizeURLCredentials(remoteAddress, false),
+		SyncOnCommit:  m.SyncOnCommit,
+		Interval:      m.Interval.String(),
+		Created:       m.CreatedUnix.AsTime(),
+		LastError:     m.LastError,
+	}
+	if m.LastUpdateUnix != 0 {
+		lastUpdate := m.LastUpdateUnix.AsTime()
+		apiMirror.LastUpdate = &lastUpdate
+	}
+	return apiMirror, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToPackage convert a models.PackageVersion to api.Package. The attributes of the version must be loaded.
+func ToPackage(pv *models.PackageVersion, doer *models.User) (*api.Package, error) {
+	var repo *api.Repository
+	if pv.Package.Repo != nil {
+		perm, err := models.GetUserRepoPermission(pv.Package.Repo, doer)
+		if err != nil {
+			return nil, err
+		}
+		if perm.HasAccess() {
+			repo = ToRepo(pv.Package.Repo, perm.AccessMode)
+		}
+	}
+
+	return &api.Package{
+		ID:            pv.ID,
+		Owner:         ToUser(pv.Package.Owner, doer),
+		Repository:    repo,
+		Creator:       ToUser(pv.Creator, doer),
+		Type:          pv.Package.Type.Name(),
+		Name:          pv.Package.Name,
+		Version:       pv.Version,
+		Created:       pv.CreatedUnix.AsTime(),
+		DownloadCount: pv.DownloadCount,
+	}, nil
+}
+
+// ToPackageFile convert a models.PackageFile to api.PackageFile
+func ToPackageFile(pf *models.PackageFile) *api.PackageFile {
+	return &api.PackageFile{
+		ID:         pf.ID,
+		Size:       pf.Blob.Size,
+		Name:       pf.Name,
+		HashMD5:    pf.Blob.HashMD5,
+		HashSHA1:   pf.Blob.HashSHA1,
+		HashSHA256: pf.Blob.HashSHA256,
+		HashSHA512: pf.Blob.HashSHA512,
+	}
+}
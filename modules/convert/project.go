@@ -0,0 +1,56 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToProjectType returns the API name of a project type
+func ToProjectType(t models.ProjectType) string {
+	switch t {
+	case models.ProjectTypeIndividual:
+		return "individual"
+	case models.ProjectTypeOrganization:
+		return "organization"
+	default:
+		return "repository"
+	}
+}
+
+// ToProject converts a project to its API format
+func ToProject(p *models.Project) *api.Project {
+	apiProject := &api.Project{
+		ID:          p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		Type:        ToProjectType(p.Type),
+		RepoID:      p.RepoID,
+		OwnerID:     p.OwnerID,
+		State:       api.StateOpen,
+		HTMLURL:     p.HTMLURL(),
+		Created:     p.CreatedUnix.AsTime(),
+		Updated:     p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.State = api.StateClosed
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+	return apiProject
+}
+
+// ToProjectBoard converts a project board to its API format
+func ToProjectBoard(b *models.ProjectBoard) *api.ProjectBoard {
+	return &api.ProjectBoard{
+		ID:        b.ID,
+		ProjectID: b.ProjectID,
+		Title:     b.Title,
+		Default:   b.Default,
+		Sorting:   b.Sorting,
+		Created:   b.CreatedUnix.AsTime(),
+		Updated:   b.UpdatedUnix.AsTime(),
+	}
+}
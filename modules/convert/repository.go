@@ -71,7 +71,10 @@ func innerToRepo(repo *models.Repository, mode models.AccessMode, isParent bool)
 	allowRebase := false
 	allowRebaseMerge := false
 	allowSquash := false
+	allowFastForwardOnly := false
 	defaultMergeStyle := models.MergeStyleMerge
+	mergeMessageTemplate := ""
+	squashMessageTemplate := ""
 	if unit, err := repo.GetUnit(models.UnitTypePullRequests); err == nil {
 		config := unit.PullRequestsConfig()
 		hasPullRequests = true
@@ -80,7 +83,10 @@ func innerToRepo(repo *models.Repository, mode models.AccessMode, isParent bool)
 		allowRebase = config.AllowRebase
 		allowRebaseMerge = config.AllowRebaseMerge
 		allowSquash = config.AllowSquash
+		allowFastForwardOnly = config.AllowFastForwardOnly
 		defaultMergeStyle = config.GetDefaultMergeStyle()
+		mergeMessageTemplate = config.MergeMessageTemplate
+		squashMessageTemplate = config.SquashMessageTemplate
 	}
 	hasProjects := false
 	if _, err := repo.GetUnit(models.UnitTypeProjects); err == nil {
@@ -141,7 +147,10 @@ func innerToRepo(repo *models.Repository, mode models.AccessMode, isParent bool)
 		AllowRebase:               allowRebase,
 		AllowRebaseMerge:          allowRebaseMerge,
 		AllowSquash:               allowSquash,
+		AllowFastForwardOnly:      allowFastForwardOnly,
 		DefaultMergeStyle:         string(defaultMergeStyle),
+		MergeMessageTemplate:      mergeMessageTemplate,
+		SquashMessageTemplate:     squashMessageTemplate,
 		AvatarURL:                 repo.AvatarLink(),
 		Internal:                  !repo.IsPrivate && repo.Owner.Visibility == api.VisibleTypePrivate,
 		MirrorInterval:            mirrorInterval,
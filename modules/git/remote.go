@@ -0,0 +1,35 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import "strings"
+
+// GetRemoteAddress returns the url of a specific remote of the repository.
+func GetRemoteAddress(repoPath, remoteName string) (string, error) {
+	err := LoadGitVersion()
+	if err != nil {
+		return "", err
+	}
+
+	var cmd *Command
+	if CheckGitVersionAtLeast("2.7") == nil {
+		cmd = NewCommand("remote", "get-url", remoteName)
+	} else {
+		cmd = NewCommand("config", "--get", "remote."+remoteName+".url")
+	}
+
+	result, err := cmd.RunInDir(repoPath)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote ") {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if len(result) > 0 {
+		return result[:len(result)-1], nil
+	}
+	return "", nil
+}
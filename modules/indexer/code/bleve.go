@@ -28,10 +28,14 @@ import (
 	analyzer_custom "github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	analyzer_keyword "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/ngram"
 	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/v2/analysis/token/unique"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/index/upsidedown"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/ethantkoenig/rupture"
 	"github.com/go-enry/go-enry/v2"
@@ -57,9 +61,9 @@ func addUnicodeNormalizeTokenFilter(m *mapping.IndexMappingImpl) error {
 }
 
 // openBleveIndexer open the index at the specified path, checking for metadata
-// updates and bleve version updates.  If index needs to be created (or
-// re-created), returns (nil, nil)
-func openBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
+// updates, bleve version updates and changes of the n-gram size.  If index needs
+// to be created (or re-created), returns (nil, nil)
+func openBleveIndexer(path string, latestVersion, ngramSize int) (bleve.Index, error) {
 	_, err := os.Stat(path)
 	if err != nil && os.IsNotExist(err) {
 		return nil, nil
@@ -85,15 +89,38 @@ func openBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 	} else if err != nil {
 		return nil, err
 	}
+
+	if bleveNgramSize(index) != ngramSize {
+		// the n-gram index was enabled, disabled or resized, so the index has to be re-populated
+		if err := index.Close(); err != nil {
+			return nil, err
+		}
+		return nil, util.RemoveAll(path)
+	}
 	return index, nil
 }
 
+// bleveNgramSize returns the size of the n-grams in the index, 0 if it has no n-gram field
+func bleveNgramSize(index bleve.Index) int {
+	m, ok := index.Mapping().(*mapping.IndexMappingImpl)
+	if !ok {
+		return 0
+	}
+	config, ok := m.CustomAnalysis.TokenFilters[repoIndexerNgramFilter]
+	if !ok {
+		return 0
+	}
+	size, _ := config["min"].(float64)
+	return int(size)
+}
+
 // RepoIndexerData data stored in the repo indexer
 type RepoIndexerData struct {
 	RepoID    int64
 	CommitID  string
 	Content   string
 	Language  string
+	Symbols   []string
 	UpdatedAt time.Time
 }
 
@@ -104,12 +131,15 @@ func (d *RepoIndexerData) Type() string {
 
 const (
 	repoIndexerAnalyzer      = "repoIndexerAnalyzer"
+	repoIndexerNgramAnalyzer = "repoIndexerNgramAnalyzer"
+	repoIndexerNgramFilter   = "repoIndexerNgram"
 	repoIndexerDocType       = "repoIndexerDocType"
-	repoIndexerLatestVersion = 5
+	repoIndexerLatestVersion = 6
 )
 
-// createBleveIndexer create a bleve repo indexer if one does not already exist
-func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
+// createBleveIndexer create a bleve repo indexer if one does not already exist.
+// If ngramSize is positive the content is also indexed as n-grams for substring and regexp searches.
+func createBleveIndexer(path string, latestVersion, ngramSize int) (bleve.Index, error) {
 	docMapping := bleve.NewDocumentMapping()
 	numericFieldMapping := bleve.NewNumericFieldMapping()
 	numericFieldMapping.IncludeInAll = false
@@ -117,7 +147,22 @@ func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 
 	textFieldMapping := bleve.NewTextFieldMapping()
 	textFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+	if ngramSize > 0 {
+		ngramFieldMapping := bleve.NewTextFieldMapping()
+		ngramFieldMapping.Name = "ContentNgram"
+		ngramFieldMapping.Analyzer = repoIndexerNgramAnalyzer
+		ngramFieldMapping.Store = false
+		ngramFieldMapping.IncludeInAll = false
+		ngramFieldMapping.IncludeTermVectors = false
+		docMapping.AddFieldMappingsAt("Content", textFieldMapping, ngramFieldMapping)
+	} else {
+		docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+	}
+
+	symbolsFieldMapping := bleve.NewTextFieldMapping()
+	symbolsFieldMapping.IncludeInAll = false
+	symbolsFieldMapping.Store = false
+	docMapping.AddFieldMappingsAt("Symbols", symbolsFieldMapping)
 
 	termFieldMapping := bleve.NewTextFieldMapping()
 	termFieldMapping.IncludeInAll = false
@@ -140,6 +185,24 @@ func createBleveIndexer(path string, latestVersion int) (bleve.Index, error) {
 	}); err != nil {
 		return nil, err
 	}
+	if ngramSize > 0 {
+		if err := mapping.AddCustomTokenFilter(repoIndexerNgramFilter, map[string]interface{}{
+			"type": ngram.Name,
+			"min":  float64(ngramSize),
+			"max":  float64(ngramSize),
+		}); err != nil {
+			return nil, err
+		}
+		// the whole content is a single token, so the n-grams span the boundaries of the words
+		if err := mapping.AddCustomAnalyzer(repoIndexerNgramAnalyzer, map[string]interface{}{
+			"type":          analyzer_custom.Name,
+			"char_filters":  []string{},
+			"tokenizer":     single.Name,
+			"token_filters": []string{unicodeNormalizeName, lowercase.Name, repoIndexerNgramFilter, unique.Name},
+		}); err != nil {
+			return nil, err
+		}
+	}
 	mapping.DefaultAnalyzer = repoIndexerAnalyzer
 	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
 	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
@@ -163,14 +226,17 @@ var (
 
 // BleveIndexer represents a bleve indexer implementation
 type BleveIndexer struct {
-	indexDir string
-	indexer  bleve.Index
+	indexDir  string
+	ngramSize int
+	indexer   bleve.Index
 }
 
-// NewBleveIndexer creates a new bleve local indexer
-func NewBleveIndexer(indexDir string) (*BleveIndexer, bool, error) {
+// NewBleveIndexer creates a new bleve local indexer.
+// If ngramSize is positive the content is also indexed as n-grams of that size.
+func NewBleveIndexer(indexDir string, ngramSize int) (*BleveIndexer, bool, error) {
 	indexer := &BleveIndexer{
-		indexDir: indexDir,
+		indexDir:  indexDir,
+		ngramSize: ngramSize,
 	}
 	created, err := indexer.init()
 	return indexer, created, err
@@ -217,11 +283,14 @@ func (b *BleveIndexer) addUpdate(batchWriter *io.PipeWriter, batchReader *bufio.
 	}
 
 	id := filenameIndexerID(repo.ID, update.Filename)
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := analyze.GetCodeLanguage(update.Filename, fileContents)
 	return batch.Index(id, &RepoIndexerData{
 		RepoID:    repo.ID,
 		CommitID:  commitSha,
-		Content:   string(charset.ToUTF8DropErrors(fileContents)),
-		Language:  analyze.GetCodeLanguage(update.Filename, fileContents),
+		Content:   content,
+		Language:  language,
+		Symbols:   symbolStrings(language, content),
 		UpdatedAt: time.Now().UTC(),
 	})
 }
@@ -234,7 +303,7 @@ func (b *BleveIndexer) addDelete(filename string, repo *models.Repository, batch
 // init init the indexer
 func (b *BleveIndexer) init() (bool, error) {
 	var err error
-	b.indexer, err = openBleveIndexer(b.indexDir, repoIndexerLatestVersion)
+	b.indexer, err = openBleveIndexer(b.indexDir, repoIndexerLatestVersion, b.ngramSize)
 	if err != nil {
 		return false, err
 	}
@@ -242,7 +311,7 @@ func (b *BleveIndexer) init() (bool, error) {
 		return false, nil
 	}
 
-	b.indexer, err = createBleveIndexer(b.indexDir, repoIndexerLatestVersion)
+	b.indexer, err = createBleveIndexer(b.indexDir, repoIndexerLatestVersion, b.ngramSize)
 	if err != nil {
 		return false, err
 	}
@@ -302,28 +371,94 @@ func (b *BleveIndexer) Delete(repoID int64) error {
 	return batch.Flush()
 }
 
+// candidateQuery returns the query of the files which may match a substring or regexp search
+func (b *BleveIndexer) candidateQuery(opts *SearchOptions) (query.Query, error) {
+	var queries []query.Query
+	for _, literal := range requiredLiterals(opts) {
+		if ngrams := literalNgrams(literal, b.ngramSize); len(ngrams) > 0 {
+			for _, ngram := range ngrams {
+				termQuery := bleve.NewTermQuery(ngram)
+				termQuery.FieldVal = "ContentNgram"
+				queries = append(queries, termQuery)
+			}
+			continue
+		}
+		// without n-grams each word of the literal is a part of an indexed word
+		for _, word := range literalWords(literal) {
+			wildcardQuery := bleve.NewWildcardQuery("*" + word + "*")
+			wildcardQuery.FieldVal = "Content"
+			queries = append(queries, wildcardQuery)
+		}
+	}
+	if len(queries) == 0 {
+		return nil, ErrInvalidSearchKeyword{Keyword: opts.Keyword, Err: errNoIndexableLiteral}
+	}
+	return bleve.NewConjunctionQuery(queries...), nil
+}
+
+func convertBleveHit(hit *search.DocumentMatch) *SearchResult {
+	var startIndex, endIndex int = -1, -1
+	for _, locations := range hit.Locations["Content"] {
+		location := locations[0]
+		locationStart := int(location.Start)
+		locationEnd := int(location.End)
+		if startIndex < 0 || locationStart < startIndex {
+			startIndex = locationStart
+		}
+		if endIndex < 0 || locationEnd > endIndex {
+			endIndex = locationEnd
+		}
+	}
+	language := hit.Fields["Language"].(string)
+	var updatedUnix timeutil.TimeStamp
+	if t, err := time.Parse(time.RFC3339, hit.Fields["UpdatedAt"].(string)); err == nil {
+		updatedUnix = timeutil.TimeStamp(t.Unix())
+	}
+	return &SearchResult{
+		RepoID:      int64(hit.Fields["RepoID"].(float64)),
+		StartIndex:  startIndex,
+		EndIndex:    endIndex,
+		Filename:    filenameOfIndexerID(hit.ID),
+		Content:     hit.Fields["Content"].(string),
+		CommitID:    hit.Fields["CommitID"].(string),
+		UpdatedUnix: updatedUnix,
+		Language:    language,
+		Color:       enry.GetColor(language),
+	}
+}
+
 // Search searches for files in the specified repo.
 // Returns the matching file-paths
-func (b *BleveIndexer) Search(repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+func (b *BleveIndexer) Search(opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, bool, error) {
 	var (
 		indexerQuery query.Query
 		keywordQuery query.Query
 	)
 
-	if isMatch {
-		prefixQuery := bleve.NewPrefixQuery(keyword)
-		prefixQuery.FieldVal = "Content"
+	field := "Content"
+	if opts.SymbolsOnly {
+		field = "Symbols"
+	}
+
+	if opts.isVerified() {
+		var err error
+		if keywordQuery, err = b.candidateQuery(opts); err != nil {
+			return 0, nil, nil, false, err
+		}
+	} else if opts.Mode == SearchModeMatch {
+		prefixQuery := bleve.NewPrefixQuery(opts.Keyword)
+		prefixQuery.FieldVal = field
 		keywordQuery = prefixQuery
 	} else {
-		phraseQuery := bleve.NewMatchPhraseQuery(keyword)
-		phraseQuery.FieldVal = "Content"
+		phraseQuery := bleve.NewMatchPhraseQuery(opts.Keyword)
+		phraseQuery.FieldVal = field
 		phraseQuery.Analyzer = repoIndexerAnalyzer
 		keywordQuery = phraseQuery
 	}
 
-	if len(repoIDs) > 0 {
-		var repoQueries = make([]query.Query, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
+	if len(opts.RepoIDs) > 0 {
+		var repoQueries = make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
 			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
 		}
 
@@ -335,10 +470,32 @@ func (b *BleveIndexer) Search(repoIDs []int64, language, keyword string, page, p
 		indexerQuery = keywordQuery
 	}
 
+	if opts.isVerified() {
+		// the matches are looked up in the candidates, which also yields the languages of the matches
+		candidates, truncated, err := collectCandidates(func(from, size int) ([]*SearchResult, int64, error) {
+			searchRequest := bleve.NewSearchRequestOptions(indexerQuery, size, from, false)
+			searchRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
+			result, err := b.indexer.Search(searchRequest)
+			if err != nil {
+				return nil, 0, err
+			}
+			candidates := make([]*SearchResult, 0, len(result.Hits))
+			for _, hit := range result.Hits {
+				candidates = append(candidates, convertBleveHit(hit))
+			}
+			return candidates, int64(result.Total), nil
+		})
+		if err != nil {
+			return 0, nil, nil, false, err
+		}
+		total, results, languages, err := verifySearchResults(opts, candidates)
+		return total, results, languages, truncated, err
+	}
+
 	// Save for reuse without language filter
 	facetQuery := indexerQuery
-	if len(language) > 0 {
-		languageQuery := bleve.NewMatchQuery(language)
+	if len(opts.Language) > 0 {
+		languageQuery := bleve.NewMatchQuery(opts.Language)
 		languageQuery.FieldVal = "Language"
 		languageQuery.Analyzer = analyzer_keyword.Name
 
@@ -348,56 +505,33 @@ func (b *BleveIndexer) Search(repoIDs []int64, language, keyword string, page, p
 		)
 	}
 
-	from := (page - 1) * pageSize
-	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, pageSize, from, false)
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, from, false)
 	searchRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
 	searchRequest.IncludeLocations = true
 
-	if len(language) == 0 {
+	if len(opts.Language) == 0 {
 		searchRequest.AddFacet("languages", bleve.NewFacetRequest("Language", 10))
 	}
 
 	result, err := b.indexer.Search(searchRequest)
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, false, err
 	}
 
 	total := int64(result.Total)
 
 	searchResults := make([]*SearchResult, len(result.Hits))
 	for i, hit := range result.Hits {
-		var startIndex, endIndex int = -1, -1
-		for _, locations := range hit.Locations["Content"] {
-			location := locations[0]
-			locationStart := int(location.Start)
-			locationEnd := int(location.End)
-			if startIndex < 0 || locationStart < startIndex {
-				startIndex = locationStart
-			}
-			if endIndex < 0 || locationEnd > endIndex {
-				endIndex = locationEnd
-			}
-		}
-		language := hit.Fields["Language"].(string)
-		var updatedUnix timeutil.TimeStamp
-		if t, err := time.Parse(time.RFC3339, hit.Fields["UpdatedAt"].(string)); err == nil {
-			updatedUnix = timeutil.TimeStamp(t.Unix())
-		}
-		searchResults[i] = &SearchResult{
-			RepoID:      int64(hit.Fields["RepoID"].(float64)),
-			StartIndex:  startIndex,
-			EndIndex:    endIndex,
-			Filename:    filenameOfIndexerID(hit.ID),
-			Content:     hit.Fields["Content"].(string),
-			CommitID:    hit.Fields["CommitID"].(string),
-			UpdatedUnix: updatedUnix,
-			Language:    language,
-			Color:       enry.GetColor(language),
+		searchResults[i] = convertBleveHit(hit)
+		if opts.SymbolsOnly {
+			// the locations of the hit are in the symbols, look up the definition in the content
+			locateSymbol(opts, searchResults[i])
 		}
 	}
 
 	searchResultLanguages := make([]*SearchResultLanguages, 0, 10)
-	if len(language) > 0 {
+	if len(opts.Language) > 0 {
 		// Use separate query to go get all language counts
 		facetRequest := bleve.NewSearchRequestOptions(facetQuery, 1, 0, false)
 		facetRequest.Fields = []string{"Content", "RepoID", "Language", "CommitID", "UpdatedAt"}
@@ -405,7 +539,7 @@ func (b *BleveIndexer) Search(repoIDs []int64, language, keyword string, page, p
 		facetRequest.AddFacet("languages", bleve.NewFacetRequest("Language", 10))
 
 		if result, err = b.indexer.Search(facetRequest); err != nil {
-			return 0, nil, nil, err
+			return 0, nil, nil, false, err
 		}
 
 	}
@@ -420,5 +554,5 @@ func (b *BleveIndexer) Search(repoIDs []int64, language, keyword string, page, p
 			Count:    term.Count,
 		})
 	}
-	return total, searchResults, searchResultLanguages, nil
+	return total, searchResults, searchResultLanguages, false, nil
 }
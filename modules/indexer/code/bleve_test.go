@@ -5,6 +5,7 @@
 package code
 
 import (
+	"fmt"
 	"io/ioutil"
 	"testing"
 
@@ -17,23 +18,50 @@ import (
 func TestBleveIndexAndSearch(t *testing.T) {
 	models.PrepareTestEnv(t)
 
+	for _, ngramSize := range []int{0, 3} {
+		dir, err := ioutil.TempDir("", "bleve.index")
+		assert.NoError(t, err)
+		if err != nil {
+			assert.Fail(t, "Unable to create temporary directory")
+			return
+		}
+		defer util.RemoveAll(dir)
+
+		idx, _, err := NewBleveIndexer(dir, ngramSize)
+		if err != nil {
+			assert.Fail(t, "Unable to create bleve indexer Error: %v", err)
+			if idx != nil {
+				idx.Close()
+			}
+			return
+		}
+		defer idx.Close()
+
+		testIndexer(fmt.Sprintf("beleve_ngram_%d", ngramSize), t, idx)
+	}
+}
+
+func TestBleveIndexerNgramSizeChange(t *testing.T) {
+	models.PrepareTestEnv(t)
+
 	dir, err := ioutil.TempDir("", "bleve.index")
 	assert.NoError(t, err)
-	if err != nil {
-		assert.Fail(t, "Unable to create temporary directory")
-		return
-	}
 	defer util.RemoveAll(dir)
 
-	idx, _, err := NewBleveIndexer(dir)
-	if err != nil {
-		assert.Fail(t, "Unable to create bleve indexer Error: %v", err)
-		if idx != nil {
-			idx.Close()
-		}
-		return
-	}
-	defer idx.Close()
+	idx, created, err := NewBleveIndexer(dir, 0)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	idx.Close()
+
+	idx, created, err = NewBleveIndexer(dir, 0)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	idx.Close()
 
-	testIndexer("beleve", t, idx)
+	// enabling the n-grams re-creates the index
+	idx, created, err = NewBleveIndexer(dir, 3)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.EqualValues(t, 3, bleveNgramSize(idx.indexer))
+	idx.Close()
 }
@@ -29,7 +29,7 @@ import (
 )
 
 const (
-	esRepoIndexerLatestVersion = 1
+	esRepoIndexerLatestVersion = 2
 	// multi-match-types, currently only 2 types are used
 	// Reference: https://www.elastic.co/guide/en/elasticsearch/reference/7.0/query-dsl-multi-match-query.html#multi-match-types
 	esMultiMatchTypeBestFields   = "best_fields"
@@ -100,6 +100,10 @@ const (
 					"term_vector": "with_positions_offsets",
 					"index": true
 				},
+				"symbols": {
+					"type": "text",
+					"index": true
+				},
 				"commit_id": {
 					"type": "keyword",
 					"index": true
@@ -216,6 +220,8 @@ func (b *ElasticSearchIndexer) addUpdate(batchWriter *io.PipeWriter, batchReader
 	}
 
 	id := filenameIndexerID(repo.ID, update.Filename)
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := analyze.GetCodeLanguage(update.Filename, fileContents)
 
 	return []elastic.BulkableRequest{
 		elastic.NewBulkIndexRequest().
@@ -223,9 +229,10 @@ func (b *ElasticSearchIndexer) addUpdate(batchWriter *io.PipeWriter, batchReader
 			Id(id).
 			Doc(map[string]interface{}{
 				"repo_id":    repo.ID,
-				"content":    string(charset.ToUTF8DropErrors(fileContents)),
+				"content":    content,
+				"symbols":    symbolStrings(language, content),
 				"commit_id":  sha,
-				"language":   analyze.GetCodeLanguage(update.Filename, fileContents),
+				"language":   language,
 				"updated_at": timeutil.TimeStampNow(),
 			}),
 	}, nil
@@ -296,24 +303,28 @@ func indexPos(content, start, end string) (int, int) {
 	return startIdx, startIdx + len(start) + endIdx + len(end)
 }
 
-func convertResult(searchResult *elastic.SearchResult, kw string, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
-	hits := make([]*SearchResult, 0, pageSize)
+func convertResult(searchResult *elastic.SearchResult, opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	hits := make([]*SearchResult, 0, opts.PageSize)
+	kw := "<em>" + opts.Keyword + "</em>"
 	for _, hit := range searchResult.Hits.Hits {
 		// FIXME: There is no way to get the position the keyword on the content currently on the same request.
 		// So we get it from content, this may made the query slower. See
 		// https://discuss.elastic.co/t/fetching-position-of-keyword-in-matched-document/94291
 		var startIndex, endIndex int = -1, -1
-		c, ok := hit.Highlight["content"]
-		if ok && len(c) > 0 {
-			// FIXME: Since the high lighting content will include <em> and </em> for the keywords,
-			// now we should find the poisitions. But how to avoid html content which contains the
-			// <em> and </em> tags? If elastic search has handled that?
-			startIndex, endIndex = indexPos(c[0], "<em>", "</em>")
-			if startIndex == -1 {
-				panic(fmt.Sprintf("1===%s,,,%#v,,,%s", kw, hit.Highlight, c[0]))
+		// the location of a symbol or a verified match is looked up in the content afterwards
+		if !opts.SymbolsOnly && !opts.isVerified() {
+			c, ok := hit.Highlight["content"]
+			if ok && len(c) > 0 {
+				// FIXME: Since the high lighting content will include <em> and </em> for the keywords,
+				// now we should find the poisitions. But how to avoid html content which contains the
+				// <em> and </em> tags? If elastic search has handled that?
+				startIndex, endIndex = indexPos(c[0], "<em>", "</em>")
+				if startIndex == -1 {
+					panic(fmt.Sprintf("1===%s,,,%#v,,,%s", kw, hit.Highlight, c[0]))
+				}
+			} else {
+				panic(fmt.Sprintf("2===%#v", hit.Highlight))
 			}
-		} else {
-			panic(fmt.Sprintf("2===%#v", hit.Highlight))
 		}
 
 		repoID, fileName := parseIndexerID(hit.Id)
@@ -325,7 +336,7 @@ func convertResult(searchResult *elastic.SearchResult, kw string, pageSize int)
 
 		language := res["language"].(string)
 
-		hits = append(hits, &SearchResult{
+		result := &SearchResult{
 			RepoID:      repoID,
 			Filename:    fileName,
 			CommitID:    res["commit_id"].(string),
@@ -335,7 +346,11 @@ func convertResult(searchResult *elastic.SearchResult, kw string, pageSize int)
 			StartIndex:  startIndex,
 			EndIndex:    endIndex - 9, // remove the length <em></em> since we give Content the original data
 			Color:       enry.GetColor(language),
-		})
+		}
+		if opts.SymbolsOnly && !opts.isVerified() {
+			locateSymbol(opts, result)
+		}
+		hits = append(hits, result)
 	}
 
 	return searchResult.TotalHits(), hits, extractAggs(searchResult), nil
@@ -358,36 +373,85 @@ func extractAggs(searchResult *elastic.SearchResult) []*SearchResultLanguages {
 	return searchResultLanguages
 }
 
+// candidateQuery returns the query of the files which may match a substring or regexp search
+func (b *ElasticSearchIndexer) candidateQuery(opts *SearchOptions) (elastic.Query, error) {
+	query := elastic.NewBoolQuery()
+	hasWords := false
+	for _, literal := range requiredLiterals(opts) {
+		// each word of the literal is a part of an indexed word
+		for _, word := range literalWords(literal) {
+			query = query.Must(elastic.NewWildcardQuery("content", "*"+word+"*"))
+			hasWords = true
+		}
+	}
+	if !hasWords {
+		return nil, ErrInvalidSearchKeyword{Keyword: opts.Keyword, Err: errNoIndexableLiteral}
+	}
+	return query, nil
+}
+
 // Search searches for codes and language stats by given conditions.
-func (b *ElasticSearchIndexer) Search(repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error) {
-	searchType := esMultiMatchTypeBestFields
-	if isMatch {
-		searchType = esMultiMatchTypePhrasePrefix
+func (b *ElasticSearchIndexer) Search(opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, bool, error) {
+	field := "content"
+	if opts.SymbolsOnly {
+		field = "symbols"
 	}
 
-	kwQuery := elastic.NewMultiMatchQuery(keyword, "content").Type(searchType)
+	var kwQuery elastic.Query
+	switch {
+	case opts.isVerified():
+		var err error
+		if kwQuery, err = b.candidateQuery(opts); err != nil {
+			return 0, nil, nil, false, err
+		}
+	case opts.Mode == SearchModeMatch:
+		kwQuery = elastic.NewMultiMatchQuery(opts.Keyword, field).Type(esMultiMatchTypePhrasePrefix)
+	default:
+		kwQuery = elastic.NewMultiMatchQuery(opts.Keyword, field).Type(esMultiMatchTypeBestFields)
+	}
 	query := elastic.NewBoolQuery()
 	query = query.Must(kwQuery)
-	if len(repoIDs) > 0 {
-		var repoStrs = make([]interface{}, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
+	if len(opts.RepoIDs) > 0 {
+		var repoStrs = make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
 			repoStrs = append(repoStrs, repoID)
 		}
 		repoQuery := elastic.NewTermsQuery("repo_id", repoStrs...)
 		query = query.Must(repoQuery)
 	}
 
+	if opts.isVerified() {
+		// the matches are looked up in the candidates, which also yields the languages of the matches
+		candidates, truncated, err := collectCandidates(func(from, size int) ([]*SearchResult, int64, error) {
+			searchResult, err := b.client.Search().
+				Index(b.indexerAliasName).
+				Query(query).
+				Sort("repo_id", true).
+				From(from).Size(size).
+				Do(context.Background())
+			if err != nil {
+				return nil, 0, err
+			}
+			total, candidates, _, err := convertResult(searchResult, opts)
+			return candidates, total, err
+		})
+		if err != nil {
+			return 0, nil, nil, false, err
+		}
+		total, results, languages, err := verifySearchResults(opts, candidates)
+		return total, results, languages, truncated, err
+	}
+
 	var (
 		start       int
-		kw          = "<em>" + keyword + "</em>"
 		aggregation = elastic.NewTermsAggregation().Field("language").Size(10).OrderByCountDesc()
 	)
 
-	if page > 0 {
-		start = (page - 1) * pageSize
+	if opts.Page > 0 {
+		start = (opts.Page - 1) * opts.PageSize
 	}
 
-	if len(language) == 0 {
+	if len(opts.Language) == 0 {
 		searchResult, err := b.client.Search().
 			Index(b.indexerAliasName).
 			Aggregation("language", aggregation).
@@ -399,16 +463,17 @@ func (b *ElasticSearchIndexer) Search(repoIDs []int64, language, keyword string,
 					HighlighterType("fvh"),
 			).
 			Sort("repo_id", true).
-			From(start).Size(pageSize).
+			From(start).Size(opts.PageSize).
 			Do(context.Background())
 		if err != nil {
-			return 0, nil, nil, err
+			return 0, nil, nil, false, err
 		}
 
-		return convertResult(searchResult, kw, pageSize)
+		total, hits, languages, err := convertResult(searchResult, opts)
+		return total, hits, languages, false, err
 	}
 
-	langQuery := elastic.NewMatchQuery("language", language)
+	langQuery := elastic.NewMatchQuery("language", opts.Language)
 	countResult, err := b.client.Search().
 		Index(b.indexerAliasName).
 		Aggregation("language", aggregation).
@@ -416,7 +481,7 @@ func (b *ElasticSearchIndexer) Search(repoIDs []int64, language, keyword string,
 		Size(0). // We only needs stats information
 		Do(context.Background())
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, false, err
 	}
 
 	query = query.Must(langQuery)
@@ -430,15 +495,15 @@ func (b *ElasticSearchIndexer) Search(repoIDs []int64, language, keyword string,
 				HighlighterType("fvh"),
 		).
 		Sort("repo_id", true).
-		From(start).Size(pageSize).
+		From(start).Size(opts.PageSize).
 		Do(context.Background())
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, false, err
 	}
 
-	total, hits, _, err := convertResult(searchResult, kw, pageSize)
+	total, hits, _, err := convertResult(searchResult, opts)
 
-	return total, hits, extractAggs(countResult), err
+	return total, hits, extractAggs(countResult), false, err
 }
 
 // Close implements indexer
@@ -39,11 +39,54 @@ type SearchResultLanguages struct {
 	Count    int
 }
 
+// SearchMode represents how the keyword of a code search is matched against the files
+type SearchMode string
+
+const (
+	// SearchModeFuzzy matches the words of the keyword as a phrase
+	SearchModeFuzzy SearchMode = ""
+	// SearchModeMatch matches the words beginning with the keyword
+	SearchModeMatch SearchMode = "match"
+	// SearchModeSubstring matches the keyword anywhere in the content ignoring the case
+	SearchModeSubstring SearchMode = "substring"
+	// SearchModeRegexp matches the keyword as a regular expression against the content
+	SearchModeRegexp SearchMode = "regexp"
+)
+
+// ParseSearchMode returns the search mode of the given name, falling back to a fuzzy search
+func ParseSearchMode(mode string) SearchMode {
+	switch SearchMode(mode) {
+	case SearchModeMatch, SearchModeSubstring, SearchModeRegexp:
+		return SearchMode(mode)
+	}
+	return SearchModeFuzzy
+}
+
+// SearchOptions represents the conditions of a code search
+type SearchOptions struct {
+	RepoIDs  []int64 // the repositories to search in, all repositories if empty
+	Language string
+	Keyword  string
+	Page     int
+	PageSize int
+	Mode     SearchMode
+	// only match the definitions of symbols, like functions, types and classes
+	SymbolsOnly bool
+}
+
+// isVerified returns whether the matches of the keyword are looked up in the contents of the candidates
+// found by the index instead of being resolved by the index itself
+func (opts *SearchOptions) isVerified() bool {
+	return opts.Mode == SearchModeSubstring || opts.Mode == SearchModeRegexp
+}
+
 // Indexer defines an interface to index and search code contents
 type Indexer interface {
 	Index(repo *models.Repository, sha string, changes *repoChanges) error
 	Delete(repoID int64) error
-	Search(repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int64, []*SearchResult, []*SearchResultLanguages, error)
+	// Search returns the total number of matches, the requested page of them, the languages of the matches
+	// and whether files have been left out of a substring or regexp search because of the search limits
+	Search(opts *SearchOptions) (int64, []*SearchResult, []*SearchResultLanguages, bool, error)
 	Close()
 }
 
@@ -180,7 +223,7 @@ func Init() {
 				}
 			}()
 
-			rIndexer, populate, err = NewBleveIndexer(setting.Indexer.RepoPath)
+			rIndexer, populate, err = NewBleveIndexer(setting.Indexer.RepoPath, setting.Indexer.RepoNgramSize)
 			if err != nil {
 				if rIndexer != nil {
 					rIndexer.Close()
@@ -26,6 +26,7 @@ func testIndexer(name string, t *testing.T, indexer Indexer) {
 			keywords = []struct {
 				RepoIDs []int64
 				Keyword string
+				Mode    SearchMode
 				IDs     []int64
 				Langs   int
 			}{
@@ -59,13 +60,55 @@ func testIndexer(name string, t *testing.T, indexer Indexer) {
 					IDs:     []int64{},
 					Langs:   0,
 				},
+				{
+					RepoIDs: nil,
+					Keyword: "ription for rep",
+					Mode:    SearchModeSubstring,
+					IDs:     []int64{repoID},
+					Langs:   1,
+				},
+				{
+					RepoIDs: nil,
+					Keyword: "ription fur rep",
+					Mode:    SearchModeSubstring,
+					IDs:     []int64{},
+					Langs:   0,
+				},
+				{
+					RepoIDs: nil,
+					Keyword: `^Desc\w+ for repo\d$`,
+					Mode:    SearchModeRegexp,
+					IDs:     []int64{repoID},
+					Langs:   1,
+				},
+				{
+					RepoIDs: []int64{2},
+					Keyword: `^Desc\w+ for repo\d$`,
+					Mode:    SearchModeRegexp,
+					IDs:     []int64{},
+					Langs:   0,
+				},
+				{
+					RepoIDs: nil,
+					Keyword: `^Desc\w+ for repo2$`,
+					Mode:    SearchModeRegexp,
+					IDs:     []int64{},
+					Langs:   0,
+				},
 			}
 		)
 
 		for _, kw := range keywords {
-			t.Run(kw.Keyword, func(t *testing.T) {
-				total, res, langs, err := indexer.Search(kw.RepoIDs, "", kw.Keyword, 1, 10, false)
+			t.Run(string(kw.Mode)+":"+kw.Keyword, func(t *testing.T) {
+				total, res, langs, truncated, err := indexer.Search(&SearchOptions{
+					RepoIDs:  kw.RepoIDs,
+					Keyword:  kw.Keyword,
+					Page:     1,
+					PageSize: 10,
+					Mode:     kw.Mode,
+				})
 				assert.NoError(t, err)
+				assert.False(t, truncated)
 				assert.EqualValues(t, len(kw.IDs), total)
 				assert.EqualValues(t, kw.Langs, len(langs))
 
@@ -73,11 +116,20 @@ func testIndexer(name string, t *testing.T, indexer Indexer) {
 				for _, hit := range res {
 					ids = append(ids, hit.RepoID)
 					assert.EqualValues(t, "# repo1\n\nDescription for repo1", hit.Content)
+					if kw.Mode == SearchModeSubstring {
+						assert.EqualValues(t, kw.Keyword, hit.Content[hit.StartIndex:hit.EndIndex])
+					}
 				}
 				assert.EqualValues(t, kw.IDs, ids)
 			})
 		}
 
+		// a substring or regexp search has to be narrowed down by the index
+		for _, kw := range []string{`.*`, `\w+\s`} {
+			_, _, _, _, err := indexer.Search(&SearchOptions{Keyword: kw, Page: 1, PageSize: 10, Mode: SearchModeRegexp})
+			assert.True(t, IsErrInvalidSearchKeyword(err), kw)
+		}
+
 		assert.NoError(t, indexer.Delete(repoID))
 	})
 }
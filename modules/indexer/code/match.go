@@ -0,0 +1,258 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+const (
+	// maxSearchCandidates is the maximum number of files which are looked up by a substring or regexp search
+	maxSearchCandidates = 1000
+	// maxSearchCandidateBytes is the size of the contents after which a substring or regexp search stops
+	// loading candidates, the last page of candidates may exceed it by the size of its files
+	maxSearchCandidateBytes = 16 << 20
+	// searchCandidatesPageSize is the number of candidates loaded from the index at once
+	searchCandidatesPageSize = 50
+)
+
+// errNoIndexableLiteral is returned for a substring or regexp search without a literal all matches contain,
+// which would have to look up the contents of all files
+var errNoIndexableLiteral = errors.New("the keyword has to contain a word every match contains")
+
+// ErrInvalidSearchKeyword represents a keyword which can't be used for the search mode
+type ErrInvalidSearchKeyword struct {
+	Keyword string
+	Err     error
+}
+
+// IsErrInvalidSearchKeyword checks if an error is a ErrInvalidSearchKeyword.
+func IsErrInvalidSearchKeyword(err error) bool {
+	_, ok := err.(ErrInvalidSearchKeyword)
+	return ok
+}
+
+func (err ErrInvalidSearchKeyword) Error() string {
+	return fmt.Sprintf("invalid search keyword [keyword: %s]: %v", err.Keyword, err.Err)
+}
+
+// Validate returns an ErrInvalidSearchKeyword if the keyword can't be used for the search mode
+func (opts *SearchOptions) Validate() error {
+	_, err := newKeywordMatcher(opts)
+	return err
+}
+
+// keywordMatcher returns the byte offsets of the first match of the keyword in the content, or -1, -1 if there is none
+type keywordMatcher func(content string) (int, int)
+
+func newKeywordMatcher(opts *SearchOptions) (keywordMatcher, error) {
+	expr := "(?i)" + regexp.QuoteMeta(opts.Keyword)
+	if opts.Mode == SearchModeRegexp {
+		expr = "(?m)" + opts.Keyword
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, ErrInvalidSearchKeyword{Keyword: opts.Keyword, Err: err}
+	}
+	return func(content string) (int, int) {
+		loc := re.FindStringIndex(content)
+		if loc == nil {
+			return -1, -1
+		}
+		return loc[0], loc[1]
+	}, nil
+}
+
+// matchSymbols returns the location of the first definition in the content matching the keyword
+func (m keywordMatcher) matchSymbols(language, content string) (int, int) {
+	for _, s := range extractSymbols(language, content) {
+		if start, _ := m(s.String()); start >= 0 {
+			return s.Start, s.End
+		}
+	}
+	return -1, -1
+}
+
+// match returns the location of the first match in the content of the result
+func (m keywordMatcher) match(opts *SearchOptions, result *SearchResult) (int, int) {
+	if opts.SymbolsOnly {
+		return m.matchSymbols(result.Language, result.Content)
+	}
+	return m(result.Content)
+}
+
+// locateSymbol sets the location of a result which was found by its symbols in the index
+func locateSymbol(opts *SearchOptions, result *SearchResult) {
+	result.StartIndex, result.EndIndex = 0, 0
+	m, err := newKeywordMatcher(&SearchOptions{Keyword: opts.Keyword, Mode: SearchModeSubstring})
+	if err != nil {
+		return
+	}
+	if start, end := m.matchSymbols(result.Language, result.Content); start >= 0 {
+		result.StartIndex, result.EndIndex = start, end
+	}
+}
+
+// candidateLoader loads a page of the candidates of a substring or regexp search and returns the total number of candidates
+type candidateLoader func(from, size int) ([]*SearchResult, int64, error)
+
+// collectCandidates loads the candidates page by page until all of them are loaded or the limits are reached,
+// it returns whether candidates have been left out
+func collectCandidates(load candidateLoader) ([]*SearchResult, bool, error) {
+	candidates := make([]*SearchResult, 0, searchCandidatesPageSize)
+	size := 0
+	for {
+		if len(candidates) >= maxSearchCandidates || size >= maxSearchCandidateBytes {
+			return candidates, true, nil
+		}
+		page, total, err := load(len(candidates), searchCandidatesPageSize)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, candidate := range page {
+			size += len(candidate.Content)
+		}
+		candidates = append(candidates, page...)
+		if len(page) < searchCandidatesPageSize || int64(len(candidates)) >= total {
+			return candidates, false, nil
+		}
+	}
+}
+
+// verifySearchResults looks up the keyword in the contents of the candidates and returns
+// the requested page of the matching files and the languages of all of them
+func verifySearchResults(opts *SearchOptions, candidates []*SearchResult) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	m, err := newKeywordMatcher(opts)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	matches := make([]*SearchResult, 0, len(candidates))
+	languageCounts := make(map[string]int)
+	for _, candidate := range candidates {
+		start, end := m.match(opts, candidate)
+		if start < 0 {
+			continue
+		}
+		candidate.StartIndex, candidate.EndIndex = start, end
+		languageCounts[candidate.Language]++
+		if len(opts.Language) == 0 || opts.Language == candidate.Language {
+			matches = append(matches, candidate)
+		}
+	}
+
+	languages := make([]*SearchResultLanguages, 0, len(languageCounts))
+	for language, count := range languageCounts {
+		if len(language) == 0 {
+			continue
+		}
+		languages = append(languages, &SearchResultLanguages{
+			Language: language,
+			Color:    enry.GetColor(language),
+			Count:    count,
+		})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Count != languages[j].Count {
+			return languages[i].Count > languages[j].Count
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	if len(languages) > 10 {
+		languages = languages[:10]
+	}
+
+	total := int64(len(matches))
+	start := (opts.Page - 1) * opts.PageSize
+	if start < 0 {
+		start = 0
+	}
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + opts.PageSize
+	if end > len(matches) || opts.PageSize <= 0 {
+		end = len(matches)
+	}
+	return total, matches[start:end], languages, nil
+}
+
+// requiredLiterals returns the strings which must be contained in a file matching the keyword.
+// A file may still be a candidate if it contains the strings but does not match the keyword.
+func requiredLiterals(opts *SearchOptions) []string {
+	if opts.Mode != SearchModeRegexp {
+		return []string{opts.Keyword}
+	}
+	re, err := syntax.Parse(opts.Keyword, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return regexpLiterals(re.Simplify())
+}
+
+func regexpLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexpLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return regexpLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var literals []string
+		var run strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				run.WriteString(string(sub.Rune))
+				continue
+			}
+			if run.Len() > 0 {
+				literals = append(literals, run.String())
+				run.Reset()
+			}
+			literals = append(literals, regexpLiterals(sub)...)
+		}
+		if run.Len() > 0 {
+			literals = append(literals, run.String())
+		}
+		return literals
+	}
+	return nil
+}
+
+// literalWords returns the lower-cased words of a literal, which are parts of the indexed terms
+func literalWords(literal string) []string {
+	return strings.FieldsFunc(strings.ToLower(literal), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+}
+
+// literalNgrams returns the distinct lower-cased n-grams of a literal, nil if it is shorter than n
+func literalNgrams(literal string, n int) []string {
+	runes := []rune(strings.ToLower(literal))
+	if n <= 0 || len(runes) < n {
+		return nil
+	}
+	seen := make(map[string]bool, len(runes))
+	ngrams := make([]string, 0, len(runes)-n+1)
+	for i := 0; i+n <= len(runes); i++ {
+		ngram := string(runes[i : i+n])
+		if !seen[ngram] {
+			seen[ngram] = true
+			ngrams = append(ngrams, ngram)
+		}
+	}
+	return ngrams
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	kases := []struct {
+		mode     SearchMode
+		keyword  string
+		literals []string
+	}{
+		{SearchModeSubstring, "func New", []string{"func New"}},
+		{SearchModeRegexp, `func\s+New\w+`, []string{"func", "New"}},
+		{SearchModeRegexp, `^type (Foo|Bar) struct`, []string{"type ", " struct"}},
+		{SearchModeRegexp, `(abc)+d?`, []string{"abc"}},
+		{SearchModeRegexp, `a*`, nil},
+		{SearchModeRegexp, `[`, nil},
+	}
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.literals, requiredLiterals(&SearchOptions{Mode: kase.mode, Keyword: kase.keyword}), kase.keyword)
+	}
+}
+
+func TestLiteralNgrams(t *testing.T) {
+	assert.EqualValues(t, []string{"abc", "bca", "cab"}, literalNgrams("ABCABCA", 3))
+	assert.Nil(t, literalNgrams("ab", 3))
+	assert.EqualValues(t, []string{"func", "new"}, literalWords("func New("))
+}
+
+func TestVerifySearchResults(t *testing.T) {
+	candidates := func() []*SearchResult {
+		return []*SearchResult{
+			{RepoID: 1, Filename: "a.go", Language: "Go", Content: "package a\n\nfunc NewFoo() {}\n"},
+			{RepoID: 1, Filename: "b.go", Language: "Go", Content: "package b\n\nvar x = NewFoo()\n"},
+			{RepoID: 1, Filename: "c.md", Language: "Markdown", Content: "call NewFoo to create it"},
+		}
+	}
+
+	total, res, langs, err := verifySearchResults(&SearchOptions{Keyword: "newfoo", Mode: SearchModeSubstring, Page: 1, PageSize: 2}, candidates())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, "a.go", res[0].Filename)
+		assert.Equal(t, "NewFoo", res[0].Content[res[0].StartIndex:res[0].EndIndex])
+	}
+	if assert.Len(t, langs, 2) {
+		assert.Equal(t, "Go", langs[0].Language)
+		assert.Equal(t, 2, langs[0].Count)
+	}
+
+	total, res, _, err = verifySearchResults(&SearchOptions{Keyword: "NewFoo", Mode: SearchModeSubstring, Language: "Markdown", Page: 1, PageSize: 10}, candidates())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Equal(t, "c.md", res[0].Filename)
+
+	total, res, _, err = verifySearchResults(&SearchOptions{Keyword: `^func New\w+`, Mode: SearchModeRegexp, SymbolsOnly: true, Page: 1, PageSize: 10}, candidates())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "func NewFoo", res[0].Content[res[0].StartIndex:res[0].EndIndex])
+	}
+
+	_, _, _, err = verifySearchResults(&SearchOptions{Keyword: `(`, Mode: SearchModeRegexp}, candidates())
+	assert.True(t, IsErrInvalidSearchKeyword(err))
+}
+
+func TestCollectCandidates(t *testing.T) {
+	loader := func(total, fileSize int) candidateLoader {
+		return func(from, size int) ([]*SearchResult, int64, error) {
+			page := make([]*SearchResult, 0, size)
+			for i := from; i < total && i < from+size; i++ {
+				page = append(page, &SearchResult{RepoID: 1, Content: strings.Repeat("a", fileSize)})
+			}
+			return page, int64(total), nil
+		}
+	}
+
+	candidates, truncated, err := collectCandidates(loader(searchCandidatesPageSize+1, 10))
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, candidates, searchCandidatesPageSize+1)
+
+	candidates, truncated, err = collectCandidates(loader(maxSearchCandidates, 10))
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, candidates, maxSearchCandidates)
+
+	candidates, truncated, err = collectCandidates(loader(maxSearchCandidates+1, 10))
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, candidates, maxSearchCandidates)
+
+	// the contents stop the search after the page which exceeds their limit
+	candidates, truncated, err = collectCandidates(loader(maxSearchCandidates, maxSearchCandidateBytes/searchCandidatesPageSize+1))
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, candidates, searchCandidatesPageSize)
+}
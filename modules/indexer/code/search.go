@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"strings"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
@@ -22,9 +23,16 @@ type Result struct {
 	Language       string
 	Color          string
 	LineNumbers    []int
+	Lines          []string
 	FormattedLines string
 }
 
+// RepoResults the search results in one repository
+type RepoResults struct {
+	Repo    *models.Repository
+	Results []*Result
+}
+
 func indices(content string, selectionStartIndex, selectionEndIndex int) (int, int) {
 	startIndex := selectionStartIndex
 	numLinesBefore := 0
@@ -68,6 +76,7 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 
 	contentLines := strings.SplitAfter(result.Content[startIndex:endIndex], "\n")
 	lineNumbers := make([]int, len(contentLines))
+	lines := make([]string, len(contentLines))
 	index := startIndex
 	for i, line := range contentLines {
 		var err error
@@ -91,6 +100,7 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 		}
 
 		lineNumbers[i] = startLineNum + i
+		lines[i] = strings.TrimSuffix(line, "\n")
 		index += len(line)
 	}
 	return &Result{
@@ -101,19 +111,28 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 		Language:       result.Language,
 		Color:          result.Color,
 		LineNumbers:    lineNumbers,
+		Lines:          lines,
 		FormattedLines: highlight.Code(result.Filename, formattedLinesBuffer.String()),
 	}, nil
 }
 
-// PerformSearch perform a search on a repository
-func PerformSearch(repoIDs []int64, language, keyword string, page, pageSize int, isMatch bool) (int, []*Result, []*SearchResultLanguages, error) {
-	if len(keyword) == 0 {
-		return 0, nil, nil, nil
+// PerformSearch perform a search on a repository, it also returns whether files have been left out
+// of a substring or regexp search because of the search limits
+func PerformSearch(opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, bool, error) {
+	if len(opts.Keyword) == 0 {
+		return 0, nil, nil, false, nil
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	// report an invalid keyword before asking the indexer
+	if err := opts.Validate(); err != nil {
+		return 0, nil, nil, false, err
 	}
 
-	total, results, resultLanguages, err := indexer.Search(repoIDs, language, keyword, page, pageSize, isMatch)
+	total, results, resultLanguages, truncated, err := indexer.Search(opts)
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, false, err
 	}
 
 	displayResults := make([]*Result, len(results))
@@ -122,8 +141,72 @@ func PerformSearch(repoIDs []int64, language, keyword string, page, pageSize int
 		startIndex, endIndex := indices(result.Content, result.StartIndex, result.EndIndex)
 		displayResults[i], err = searchResult(result, startIndex, endIndex)
 		if err != nil {
-			return 0, nil, nil, err
+			return 0, nil, nil, false, err
+		}
+	}
+	return int(total), displayResults, resultLanguages, truncated, nil
+}
+
+// GroupResultsByRepo groups the results by their repositories, which are ordered by their first result.
+// Results of repositories which don't exist anymore are dropped.
+func GroupResultsByRepo(results []*Result) ([]*RepoResults, error) {
+	repoIDs := make([]int64, 0, len(results))
+	groups := make(map[int64]*RepoResults, len(results))
+	for _, result := range results {
+		if _, ok := groups[result.RepoID]; !ok {
+			groups[result.RepoID] = &RepoResults{}
+			repoIDs = append(repoIDs, result.RepoID)
+		}
+		groups[result.RepoID].Results = append(groups[result.RepoID].Results, result)
+	}
+
+	repos, err := models.GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	repoResults := make([]*RepoResults, 0, len(repoIDs))
+	for _, repoID := range repoIDs {
+		repo, ok := repos[repoID]
+		if !ok {
+			continue
+		}
+		groups[repoID].Repo = repo
+		repoResults = append(repoResults, groups[repoID])
+	}
+	return repoResults, nil
+}
+
+// PerformAccessibleSearch searches the code of the repositories the doer can read, limited to the
+// repositories of the owner if ownerID is not zero, and groups the results by repository
+func PerformAccessibleSearch(doer *models.User, ownerID int64, opts *SearchOptions) (int, []*RepoResults, []*SearchResultLanguages, bool, error) {
+	if len(opts.Keyword) == 0 {
+		return 0, nil, nil, false, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return 0, nil, nil, false, err
+	}
+
+	// admins can read every repository, so there is no need to list them for a site-wide search
+	opts.RepoIDs = nil
+	if doer == nil || !doer.IsAdmin || ownerID > 0 {
+		repoIDs, err := models.FindUserCodeAccessibleRepoIDs(doer, ownerID)
+		if err != nil {
+			return 0, nil, nil, false, err
+		}
+		if len(repoIDs) == 0 {
+			return 0, nil, nil, false, nil
 		}
+		opts.RepoIDs = repoIDs
+	}
+
+	total, results, languages, truncated, err := PerformSearch(opts)
+	if err != nil {
+		return 0, nil, nil, false, err
+	}
+	repoResults, err := GroupResultsByRepo(results)
+	if err != nil {
+		return 0, nil, nil, false, err
 	}
-	return int(total), displayResults, resultLanguages, nil
+	return total, repoResults, languages, truncated, nil
 }
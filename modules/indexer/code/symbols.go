@@ -0,0 +1,113 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"regexp"
+	"sort"
+)
+
+// symbol represents the definition of a function, type or class in a file
+type symbol struct {
+	// Kind is the keyword introducing the definition, e.g. func or class. It is empty for Java methods.
+	Kind string
+	Name string
+	// Start and End are the byte offsets of the definition in the content
+	Start int
+	End   int
+}
+
+// String returns the definition as it is indexed, e.g. "func NewFoo"
+func (s symbol) String() string {
+	if len(s.Kind) == 0 {
+		return s.Name
+	}
+	return s.Kind + " " + s.Name
+}
+
+// symbolPattern matches a definition, the submatches are the kind and the name of the symbol.
+// The kind submatch may be omitted by patterns which don't have a keyword.
+type symbolPattern struct {
+	re      *regexp.Regexp
+	hasKind bool
+}
+
+func newSymbolPattern(expr string, hasKind bool) symbolPattern {
+	return symbolPattern{re: regexp.MustCompile(expr), hasKind: hasKind}
+}
+
+var (
+	jsSymbolPatterns = []symbolPattern{
+		newSymbolPattern(`(?m)^[ \t]*(?:export[ \t]+)?(?:default[ \t]+)?(?:async[ \t]+)?(function)[ \t]*\*?[ \t]*([A-Za-z_$][\w$]*)`, true),
+		newSymbolPattern(`(?m)^[ \t]*(?:export[ \t]+)?(?:default[ \t]+)?(?:abstract[ \t]+)?(class)[ \t]+([A-Za-z_$][\w$]*)`, true),
+		newSymbolPattern(`(?m)^[ \t]*(?:export[ \t]+)?(const|let|var)[ \t]+([A-Za-z_$][\w$]*)[ \t]*(?::[^=\n]+)?=[ \t]*(?:async[ \t]+)?(?:function\b|\([^)\n]*\)[ \t]*(?::[^=\n]+)?=>|[A-Za-z_$][\w$]*[ \t]*=>)`, true),
+	}
+	tsSymbolPatterns = append([]symbolPattern{
+		newSymbolPattern(`(?m)^[ \t]*(?:export[ \t]+)?(?:declare[ \t]+)?(interface|type|enum)[ \t]+([A-Za-z_$][\w$]*)`, true),
+	}, jsSymbolPatterns...)
+
+	// symbolPatterns are the patterns of the definitions by the language names of go-enry
+	symbolPatterns = map[string][]symbolPattern{
+		"Go": {
+			newSymbolPattern(`(?m)^(func)[ \t]+(?:\([^)\n]*\)[ \t]*)?([A-Za-z_]\w*)`, true),
+			newSymbolPattern(`(?m)^[ \t]*(type)[ \t]+([A-Za-z_]\w*)`, true),
+		},
+		"Python": {
+			newSymbolPattern(`(?m)^[ \t]*(?:async[ \t]+)?(def)[ \t]+([A-Za-z_]\w*)`, true),
+			newSymbolPattern(`(?m)^[ \t]*(class)[ \t]+([A-Za-z_]\w*)`, true),
+		},
+		"JavaScript": jsSymbolPatterns,
+		"JSX":        jsSymbolPatterns,
+		"TypeScript": tsSymbolPatterns,
+		"TSX":        tsSymbolPatterns,
+		"Java": {
+			newSymbolPattern(`(?m)^[ \t]*(?:(?:public|protected|private|static|abstract|final|sealed|strictfp)[ \t]+)*(class|interface|enum|record|@interface)[ \t]+([A-Za-z_$][\w$]*)`, true),
+			// methods and constructors need a modifier to tell them apart from calls and statements
+			newSymbolPattern(`(?m)^[ \t]*(?:@\w+[ \t]+)*(?:(?:public|protected|private|static|abstract|final|synchronized|native|default)[ \t]+)+(?:<[^>\n]*>[ \t]*)?(?:[\w$.<>\[\], ?]+?[ \t]+)?([A-Za-z_$][\w$]*)[ \t]*\(`, false),
+		},
+	}
+)
+
+// extractSymbols returns the definitions in the content ordered by their position
+func extractSymbols(language, content string) []symbol {
+	patterns, ok := symbolPatterns[language]
+	if !ok {
+		return nil
+	}
+
+	var symbols []symbol
+	for _, pattern := range patterns {
+		for _, loc := range pattern.re.FindAllStringSubmatchIndex(content, -1) {
+			s := symbol{}
+			nameIdx := 2
+			if pattern.hasKind {
+				s.Kind = content[loc[2]:loc[3]]
+				nameIdx = 4
+			}
+			s.Name = content[loc[nameIdx]:loc[nameIdx+1]]
+			// the definition starts at the kind or the name, leading modifiers are not part of it
+			s.Start = loc[2]
+			s.End = loc[nameIdx+1]
+			symbols = append(symbols, s)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Start < symbols[j].Start
+	})
+	return symbols
+}
+
+// symbolStrings returns the indexed form of the definitions in the content
+func symbolStrings(language, content string) []string {
+	symbols := extractSymbols(language, content)
+	if len(symbols) == 0 {
+		return nil
+	}
+	strs := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		strs = append(strs, s.String())
+	}
+	return strs
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSymbols(t *testing.T) {
+	kases := []struct {
+		language string
+		content  string
+		symbols  []string
+	}{
+		{
+			language: "Go",
+			content: `package foo
+
+type Foo struct{}
+
+func NewFoo() *Foo {
+	return &Foo{}
+}
+
+func (f *Foo) Bar() {}
+`,
+			symbols: []string{"type Foo", "func NewFoo", "func Bar"},
+		},
+		{
+			language: "Python",
+			content: `class Foo:
+    def __init__(self):
+        pass
+
+async def fetch():
+    pass
+`,
+			symbols: []string{"class Foo", "def __init__", "def fetch"},
+		},
+		{
+			language: "JavaScript",
+			content: `export default class Foo {}
+export async function load() {}
+const handler = async (req) => {};
+const value = 42;
+`,
+			symbols: []string{"class Foo", "function load", "const handler"},
+		},
+		{
+			language: "TypeScript",
+			content: `export interface Options {}
+type Id = string;
+export const parse = (s: string): Id => s;
+`,
+			symbols: []string{"interface Options", "type Id", "const parse"},
+		},
+		{
+			language: "Java",
+			content: `public final class Foo {
+    private static int count;
+
+    public Foo() {}
+
+    @Override
+    public String toString() {
+        return bar(count);
+    }
+}
+`,
+			symbols: []string{"class Foo", "Foo", "toString"},
+		},
+		{
+			language: "Markdown",
+			content:  "# func NewFoo",
+			symbols:  nil,
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.language, func(t *testing.T) {
+			assert.EqualValues(t, kase.symbols, symbolStrings(kase.language, kase.content))
+		})
+	}
+}
+
+func TestExtractSymbolsLocation(t *testing.T) {
+	content := "package foo\n\nfunc (f *Foo) Bar() {}\n"
+	symbols := extractSymbols("Go", content)
+	if assert.Len(t, symbols, 1) {
+		assert.Equal(t, "func (f *Foo) Bar", content[symbols[0].Start:symbols[0].End])
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
+
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
@@ -18,6 +21,7 @@ import (
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/index/upsidedown"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/ethantkoenig/rupture"
 )
@@ -25,9 +29,15 @@ import (
 const (
 	issueIndexerAnalyzer      = "issueIndexer"
 	issueIndexerDocType       = "issueIndexerDocType"
-	issueIndexerLatestVersion = 1
+	issueIndexerLatestVersion = 2
 )
 
+func init() {
+	RegisterIndexer("bleve", func() (Indexer, error) {
+		return NewBleveIndexer(setting.Indexer.IssuePath), nil
+	}, true)
+}
+
 // indexerID a bleve-compatible unique identifier for an integer id
 func indexerID(id int64) string {
 	return strconv.FormatInt(id, 36)
@@ -51,6 +61,29 @@ func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
 	return q
 }
 
+// numericRangeQuery a query for the values of the field between min and max, a bound of 0 is open
+func numericRangeQuery(min, max int64, field string) *query.NumericRangeQuery {
+	var minF, maxF *float64
+	if min != 0 {
+		f := float64(min)
+		minF = &f
+	}
+	if max != 0 {
+		f := float64(max)
+		maxF = &f
+	}
+	tru := true
+	q := bleve.NewNumericRangeInclusiveQuery(minF, maxF, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+func boolFieldQuery(value bool, field string) *query.BoolFieldQuery {
+	q := bleve.NewBoolFieldQuery(value)
+	q.SetField(field)
+	return q
+}
+
 func newMatchPhraseQuery(matchPhrase, field, analyzer string) *query.MatchPhraseQuery {
 	q := bleve.NewMatchPhraseQuery(matchPhrase)
 	q.FieldVal = field
@@ -117,10 +150,19 @@ func createIssueIndexer(path string, latestVersion int) (bleve.Index, error) {
 
 	numericFieldMapping := bleve.NewNumericFieldMapping()
 	numericFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+	for _, field := range []string{"RepoID", "LabelIDs", "MilestoneID", "PosterID", "AssigneeIDs", "CreatedUnix", "UpdatedUnix"} {
+		docMapping.AddFieldMappingsAt(field, numericFieldMapping)
+	}
 
+	boolFieldMapping := bleve.NewBooleanFieldMapping()
+	boolFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("IsPull", boolFieldMapping)
+	docMapping.AddFieldMappingsAt("IsClosed", boolFieldMapping)
+
+	// the text is stored for the highlighting of the matches
 	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.Store = false
+	textFieldMapping.Store = true
+	textFieldMapping.IncludeTermVectors = true
 	textFieldMapping.IncludeInAll = false
 	docMapping.AddFieldMappingsAt("Title", textFieldMapping)
 	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
@@ -200,15 +242,31 @@ func (b *BleveIndexer) Index(issues []*IndexerData) error {
 	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
 	for _, issue := range issues {
 		if err := batch.Index(indexerID(issue.ID), struct {
-			RepoID   int64
-			Title    string
-			Content  string
-			Comments []string
+			RepoID      int64
+			Title       string
+			Content     string
+			Comments    []string
+			IsPull      bool
+			IsClosed    bool
+			LabelIDs    []int64
+			MilestoneID int64
+			PosterID    int64
+			AssigneeIDs []int64
+			CreatedUnix int64
+			UpdatedUnix int64
 		}{
-			RepoID:   issue.RepoID,
-			Title:    issue.Title,
-			Content:  issue.Content,
-			Comments: issue.Comments,
+			RepoID:      issue.RepoID,
+			Title:       issue.Title,
+			Content:     issue.Content,
+			Comments:    issue.Comments,
+			IsPull:      issue.IsPull,
+			IsClosed:    issue.IsClosed,
+			LabelIDs:    issue.LabelIDs,
+			MilestoneID: issue.MilestoneID,
+			PosterID:    issue.PosterID,
+			AssigneeIDs: issue.AssigneeIDs,
+			CreatedUnix: issue.CreatedUnix,
+			UpdatedUnix: issue.UpdatedUnix,
 		}); err != nil {
 			return err
 		}
@@ -227,27 +285,99 @@ func (b *BleveIndexer) Delete(ids ...int64) error {
 	return batch.Flush()
 }
 
+var bleveSortFields = map[string][]string{
+	SortByNewest:       {"-CreatedUnix"},
+	SortByLatest:       {"-CreatedUnix"},
+	SortByOldest:       {"CreatedUnix"},
+	SortByRecentUpdate: {"-UpdatedUnix"},
+	SortByLeastUpdate:  {"UpdatedUnix"},
+}
+
+// bleveHighlightFields maps the fields of the documents to the fields of the highlights
+var bleveHighlightFields = []struct{ field, name string }{
+	{"Title", "title"},
+	{"Content", "content"},
+	{"Comments", "comments"},
+}
+
 // Search searches for issues by given conditions.
 // Returns the matching issue IDs
-func (b *BleveIndexer) Search(keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	var repoQueriesP []*query.NumericRangeQuery
-	for _, repoID := range repoIDs {
-		repoQueriesP = append(repoQueriesP, numericEqualityQuery(repoID, "RepoID"))
-	}
-	repoQueries := make([]query.Query, len(repoQueriesP))
-	for i, v := range repoQueriesP {
-		repoQueries[i] = query.Query(v)
-	}
-
-	indexerQuery := bleve.NewConjunctionQuery(
-		bleve.NewDisjunctionQuery(repoQueries...),
-		bleve.NewDisjunctionQuery(
-			newMatchPhraseQuery(keyword, "Title", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Content", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Comments", issueIndexerAnalyzer),
+func (b *BleveIndexer) Search(opts *SearchOptions) (*SearchResult, error) {
+	var queries, excludeQueries []query.Query
+
+	if len(opts.RepoIDs) > 0 {
+		repoQueries := make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+
+	if len(opts.Keyword) > 0 {
+		queries = append(queries, bleve.NewDisjunctionQuery(
+			newMatchPhraseQuery(opts.Keyword, "Title", issueIndexerAnalyzer),
+			newMatchPhraseQuery(opts.Keyword, "Content", issueIndexerAnalyzer),
+			newMatchPhraseQuery(opts.Keyword, "Comments", issueIndexerAnalyzer),
 		))
-	search := bleve.NewSearchRequestOptions(indexerQuery, limit, start, false)
-	search.SortBy([]string{"-_score"})
+	}
+
+	for _, labelID := range opts.LabelIDs {
+		if labelID > 0 {
+			queries = append(queries, numericEqualityQuery(labelID, "LabelIDs"))
+		} else if labelID < 0 {
+			excludeQueries = append(excludeQueries, numericEqualityQuery(-labelID, "LabelIDs"))
+		}
+	}
+
+	if len(opts.MilestoneIDs) > 0 {
+		milestoneQueries := make([]query.Query, 0, len(opts.MilestoneIDs))
+		for _, milestoneID := range opts.MilestoneIDs {
+			milestoneQueries = append(milestoneQueries, numericEqualityQuery(milestoneID, "MilestoneID"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(milestoneQueries...))
+	}
+
+	if opts.PosterID > 0 {
+		queries = append(queries, numericEqualityQuery(opts.PosterID, "PosterID"))
+	}
+	if opts.AssigneeID > 0 {
+		queries = append(queries, numericEqualityQuery(opts.AssigneeID, "AssigneeIDs"))
+	}
+	if !opts.IsPull.IsNone() {
+		queries = append(queries, boolFieldQuery(opts.IsPull.IsTrue(), "IsPull"))
+	}
+	if !opts.IsClosed.IsNone() {
+		queries = append(queries, boolFieldQuery(opts.IsClosed.IsTrue(), "IsClosed"))
+	}
+	if opts.UpdatedAfterUnix != 0 || opts.UpdatedBeforeUnix != 0 {
+		queries = append(queries, numericRangeQuery(opts.UpdatedAfterUnix, opts.UpdatedBeforeUnix, "UpdatedUnix"))
+	}
+
+	var indexerQuery query.Query
+	if len(queries) == 0 {
+		indexerQuery = bleve.NewMatchAllQuery()
+	} else {
+		indexerQuery = bleve.NewConjunctionQuery(queries...)
+	}
+	if len(excludeQueries) > 0 {
+		boolQuery := bleve.NewBooleanQuery()
+		boolQuery.AddMust(indexerQuery)
+		boolQuery.AddMustNot(excludeQueries...)
+		indexerQuery = boolQuery
+	}
+
+	search := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, opts.start(), false)
+	if sortBy, ok := bleveSortFields[opts.SortBy]; ok {
+		search.SortBy(sortBy)
+	} else {
+		search.SortBy([]string{"-_score", "-CreatedUnix"})
+	}
+	if len(opts.Keyword) > 0 {
+		search.Highlight = bleve.NewHighlightWithStyle(html.Name)
+		for _, f := range bleveHighlightFields {
+			search.Highlight.AddField(f.field)
+		}
+	}
 
 	result, err := b.indexer.Search(search)
 	if err != nil {
@@ -255,16 +385,32 @@ func (b *BleveIndexer) Search(keyword string, repoIDs []int64, limit, start int)
 	}
 
 	var ret = SearchResult{
-		Hits: make([]Match, 0, len(result.Hits)),
+		Total: int64(result.Total),
+		Hits:  make([]Match, 0, len(result.Hits)),
 	}
 	for _, hit := range result.Hits {
 		id, err := idOfIndexerID(hit.ID)
 		if err != nil {
 			return nil, err
 		}
-		ret.Hits = append(ret.Hits, Match{
-			ID: id,
-		})
+		match := Match{
+			ID:    id,
+			Score: hit.Score,
+		}
+		// the html highlighter escapes the fragments and marks the matches itself,
+		// the fields without a match are returned unmarked and skipped
+		for _, f := range bleveHighlightFields {
+			for _, fragment := range hit.Fragments[f.field] {
+				if !strings.Contains(fragment, "<mark>") {
+					continue
+				}
+				match.Highlights = append(match.Highlights, Highlight{
+					Field:    f.name,
+					Fragment: fragment,
+				})
+			}
+		}
+		ret.Hits = append(ret.Hits, match)
 	}
 	return &ret, nil
 }
@@ -8,7 +8,9 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/util"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,6 +40,10 @@ func TestBleveIndexAndSearch(t *testing.T) {
 				"test1",
 				"test2",
 			},
+			LabelIDs:    []int64{1},
+			PosterID:    1,
+			CreatedUnix: 100,
+			UpdatedUnix: 300,
 		},
 		{
 			ID:      2,
@@ -48,6 +54,11 @@ func TestBleveIndexAndSearch(t *testing.T) {
 				"LGTM",
 				"Good idea",
 			},
+			IsClosed:    true,
+			LabelIDs:    []int64{1, 2},
+			AssigneeIDs: []int64{3},
+			CreatedUnix: 200,
+			UpdatedUnix: 200,
 		},
 	})
 	assert.NoError(t, err)
@@ -85,7 +96,11 @@ func TestBleveIndexAndSearch(t *testing.T) {
 	)
 
 	for _, kw := range keywords {
-		res, err := indexer.Search(kw.Keyword, []int64{2}, 10, 0)
+		res, err := indexer.Search(&SearchOptions{
+			ListOptions: models.ListOptions{PageSize: 10},
+			Keyword:     kw.Keyword,
+			RepoIDs:     []int64{2},
+		})
 		assert.NoError(t, err)
 
 		var ids = make([]int64, 0, len(res.Hits))
@@ -94,4 +109,73 @@ func TestBleveIndexAndSearch(t *testing.T) {
 		}
 		assert.ElementsMatch(t, kw.IDs, ids)
 	}
+
+	filters := []struct {
+		Opts SearchOptions
+		IDs  []int64
+	}{
+		{
+			Opts: SearchOptions{LabelIDs: []int64{1, 2}},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{LabelIDs: []int64{1, -2}},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{PosterID: 1},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{AssigneeID: 3},
+			IDs:  []int64{2},
+		},
+		{
+			Opts: SearchOptions{IsClosed: util.OptionalBoolFalse},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{UpdatedAfterUnix: 250},
+			IDs:  []int64{1},
+		},
+		{
+			Opts: SearchOptions{Keyword: "support", UpdatedBeforeUnix: 250},
+			IDs:  []int64{2},
+		},
+	}
+	for _, filter := range filters {
+		opts := filter.Opts
+		opts.PageSize = 10
+		res, err := indexer.Search(&opts)
+		assert.NoError(t, err)
+
+		var ids = make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		assert.ElementsMatch(t, filter.IDs, ids)
+	}
+
+	// results can be sorted by the creation time
+	res, err := indexer.Search(&SearchOptions{
+		ListOptions: models.ListOptions{PageSize: 10},
+		SortBy:      SortByOldest,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.Total)
+	if assert.Len(t, res.Hits, 2) {
+		assert.EqualValues(t, 1, res.Hits[0].ID)
+		assert.EqualValues(t, 2, res.Hits[1].ID)
+	}
+
+	// the matches are highlighted
+	res, err = indexer.Search(&SearchOptions{
+		ListOptions: models.ListOptions{PageSize: 10},
+		Keyword:     "optional",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Hits, 1) && assert.NotEmpty(t, res.Hits[0].Highlights) {
+		assert.Equal(t, "title", res.Hits[0].Highlights[0].Field)
+		assert.Equal(t, "CJK support could be <mark>optional</mark>", res.Hits[0].Highlights[0].Fragment)
+	}
 }
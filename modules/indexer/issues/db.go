@@ -6,6 +6,12 @@ package issues
 
 import "code.gitea.io/gitea/models"
 
+func init() {
+	RegisterIndexer("db", func() (Indexer, error) {
+		return &DBIndexer{}, nil
+	}, false)
+}
+
 // DBIndexer implements Indexer interface to use database's like search
 type DBIndexer struct {
 }
@@ -29,20 +35,54 @@ func (db *DBIndexer) Delete(ids ...int64) error {
 func (db *DBIndexer) Close() {
 }
 
-// Search dummy function
-func (db *DBIndexer) Search(kw string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	total, ids, err := models.SearchIssueIDsByKeyword(kw, repoIDs, limit, start)
+// Search searches the issues in the database
+func (db *DBIndexer) Search(opts *SearchOptions) (*SearchResult, error) {
+	// the database cannot rank the matches, the most recently updated issues come first instead
+	sortType := opts.SortBy
+	if sortType == "" || sortType == SortByRelevance {
+		sortType = SortByRecentUpdate
+	}
+
+	issuesOpts := &models.IssuesOptions{
+		ListOptions:       opts.ListOptions,
+		RepoIDs:           opts.RepoIDs,
+		AssigneeID:        opts.AssigneeID,
+		PosterID:          opts.PosterID,
+		MilestoneIDs:      opts.MilestoneIDs,
+		IsClosed:          opts.IsClosed,
+		IsPull:            opts.IsPull,
+		LabelIDs:          opts.LabelIDs,
+		SortType:          sortType,
+		UpdatedAfterUnix:  opts.UpdatedAfterUnix,
+		UpdatedBeforeUnix: opts.UpdatedBeforeUnix,
+		Keyword:           opts.Keyword,
+	}
+	issues, err := models.Issues(issuesOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	issuesOpts.ListOptions = models.ListOptions{Page: -1}
+	total, err := models.CountIssues(issuesOpts)
 	if err != nil {
 		return nil, err
 	}
+
 	var result = SearchResult{
 		Total: total,
-		Hits:  make([]Match, 0, limit),
+		Hits:  make([]Match, 0, len(issues)),
 	}
-	for _, id := range ids {
-		result.Hits = append(result.Hits, Match{
-			ID: id,
-		})
+	for _, issue := range issues {
+		match := Match{ID: issue.ID}
+		for _, field := range []struct{ name, text string }{
+			{"title", issue.Title},
+			{"content", issue.Content},
+		} {
+			if fragment, ok := highlightKeyword(field.text, opts.Keyword); ok {
+				match.Highlights = append(match.Highlights, Highlight{Field: field.name, Fragment: formatHighlight(fragment)})
+			}
+		}
+		result.Hits = append(result.Hits, match)
 	}
 	return &result, nil
 }
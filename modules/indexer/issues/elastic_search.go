@@ -12,14 +12,25 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/olivere/elastic/v7"
 )
 
+// elasticIssueIndexerLatestVersion is appended to the index name,
+// a new version creates a new index which is populated from scratch
+const elasticIssueIndexerLatestVersion = 1
+
 var (
 	_ Indexer = &ElasticSearchIndexer{}
 )
 
+func init() {
+	RegisterIndexer("elasticsearch", func() (Indexer, error) {
+		return NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+	}, true)
+}
+
 // ElasticSearchIndexer implements Indexer interface
 type ElasticSearchIndexer struct {
 	client      *elastic.Client
@@ -60,7 +71,7 @@ func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, er
 
 	return &ElasticSearchIndexer{
 		client:      client,
-		indexerName: indexerName,
+		indexerName: fmt.Sprintf("%s.v%d", indexerName, elasticIssueIndexerLatestVersion),
 	}, nil
 }
 
@@ -87,6 +98,38 @@ const (
 				"comments": {
 					"type" : "text",
 					"index": true
+				},
+				"is_pull": {
+					"type": "boolean",
+					"index": true
+				},
+				"is_closed": {
+					"type": "boolean",
+					"index": true
+				},
+				"label_ids": {
+					"type": "long",
+					"index": true
+				},
+				"milestone_id": {
+					"type": "long",
+					"index": true
+				},
+				"poster_id": {
+					"type": "long",
+					"index": true
+				},
+				"assignee_ids": {
+					"type": "long",
+					"index": true
+				},
+				"created_unix": {
+					"type": "long",
+					"index": true
+				},
+				"updated_unix": {
+					"type": "long",
+					"index": true
 				}
 			}
 		}
@@ -117,6 +160,24 @@ func (b *ElasticSearchIndexer) Init() (bool, error) {
 	return true, nil
 }
 
+func elasticDocument(issue *IndexerData) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           issue.ID,
+		"repo_id":      issue.RepoID,
+		"title":        issue.Title,
+		"content":      issue.Content,
+		"comments":     issue.Comments,
+		"is_pull":      issue.IsPull,
+		"is_closed":    issue.IsClosed,
+		"label_ids":    issue.LabelIDs,
+		"milestone_id": issue.MilestoneID,
+		"poster_id":    issue.PosterID,
+		"assignee_ids": issue.AssigneeIDs,
+		"created_unix": issue.CreatedUnix,
+		"updated_unix": issue.UpdatedUnix,
+	}
+}
+
 // Index will save the index data
 func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 	if len(issues) == 0 {
@@ -126,13 +187,7 @@ func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 		_, err := b.client.Index().
 			Index(b.indexerName).
 			Id(fmt.Sprintf("%d", issue.ID)).
-			BodyJson(map[string]interface{}{
-				"id":       issue.ID,
-				"repo_id":  issue.RepoID,
-				"title":    issue.Title,
-				"content":  issue.Content,
-				"comments": issue.Comments,
-			}).
+			BodyJson(elasticDocument(issue)).
 			Do(context.Background())
 		return err
 	}
@@ -143,13 +198,7 @@ func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
 			elastic.NewBulkIndexRequest().
 				Index(b.indexerName).
 				Id(fmt.Sprintf("%d", issue.ID)).
-				Doc(map[string]interface{}{
-					"id":       issue.ID,
-					"repo_id":  issue.RepoID,
-					"title":    issue.Title,
-					"content":  issue.Content,
-					"comments": issue.Comments,
-				}),
+				Doc(elasticDocument(issue)),
 		)
 	}
 
@@ -188,36 +237,111 @@ func (b *ElasticSearchIndexer) Delete(ids ...int64) error {
 	return err
 }
 
+var elasticSortFields = map[string]struct {
+	field     string
+	ascending bool
+}{
+	SortByNewest:       {"created_unix", false},
+	SortByLatest:       {"created_unix", false},
+	SortByOldest:       {"created_unix", true},
+	SortByRecentUpdate: {"updated_unix", false},
+	SortByLeastUpdate:  {"updated_unix", true},
+}
+
+func int64sToInterfaces(ids []int64) []interface{} {
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id)
+	}
+	return values
+}
+
 // Search searches for issues by given conditions.
 // Returns the matching issue IDs
-func (b *ElasticSearchIndexer) Search(keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
-	kwQuery := elastic.NewMultiMatchQuery(keyword, "title", "content", "comments")
+func (b *ElasticSearchIndexer) Search(opts *SearchOptions) (*SearchResult, error) {
 	query := elastic.NewBoolQuery()
-	query = query.Must(kwQuery)
-	if len(repoIDs) > 0 {
-		var repoStrs = make([]interface{}, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
-			repoStrs = append(repoStrs, repoID)
+	if len(opts.Keyword) > 0 {
+		query = query.Must(elastic.NewMultiMatchQuery(opts.Keyword, "title", "content", "comments"))
+	}
+	if len(opts.RepoIDs) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("repo_id", int64sToInterfaces(opts.RepoIDs)...))
+	}
+	for _, labelID := range opts.LabelIDs {
+		if labelID > 0 {
+			query = query.Filter(elastic.NewTermQuery("label_ids", labelID))
+		} else if labelID < 0 {
+			query = query.MustNot(elastic.NewTermQuery("label_ids", -labelID))
 		}
-		repoQuery := elastic.NewTermsQuery("repo_id", repoStrs...)
-		query = query.Must(repoQuery)
 	}
-	searchResult, err := b.client.Search().
+	if len(opts.MilestoneIDs) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("milestone_id", int64sToInterfaces(opts.MilestoneIDs)...))
+	}
+	if opts.PosterID > 0 {
+		query = query.Filter(elastic.NewTermQuery("poster_id", opts.PosterID))
+	}
+	if opts.AssigneeID > 0 {
+		query = query.Filter(elastic.NewTermQuery("assignee_ids", opts.AssigneeID))
+	}
+	if !opts.IsPull.IsNone() {
+		query = query.Filter(elastic.NewTermQuery("is_pull", opts.IsPull.IsTrue()))
+	}
+	if !opts.IsClosed.IsNone() {
+		query = query.Filter(elastic.NewTermQuery("is_closed", opts.IsClosed.IsTrue()))
+	}
+	if opts.UpdatedAfterUnix != 0 || opts.UpdatedBeforeUnix != 0 {
+		rangeQuery := elastic.NewRangeQuery("updated_unix")
+		if opts.UpdatedAfterUnix != 0 {
+			rangeQuery = rangeQuery.Gte(opts.UpdatedAfterUnix)
+		}
+		if opts.UpdatedBeforeUnix != 0 {
+			rangeQuery = rangeQuery.Lte(opts.UpdatedBeforeUnix)
+		}
+		query = query.Filter(rangeQuery)
+	}
+
+	search := b.client.Search().
 		Index(b.indexerName).
 		Query(query).
-		Sort("_score", false).
-		From(start).Size(limit).
-		Do(context.Background())
+		From(opts.start()).Size(opts.PageSize)
+	if sortBy, ok := elasticSortFields[opts.SortBy]; ok {
+		search = search.Sort(sortBy.field, sortBy.ascending)
+	} else {
+		search = search.Sort("_score", false).Sort("created_unix", false)
+	}
+	if len(opts.Keyword) > 0 {
+		search = search.Highlight(elastic.NewHighlight().
+			Fields(
+				elastic.NewHighlighterField("title").NumOfFragments(0),
+				elastic.NewHighlighterField("content"),
+				elastic.NewHighlighterField("comments"),
+			).
+			PreTags(highlightPreTag).
+			PostTags(highlightPostTag))
+	}
+
+	searchResult, err := search.Do(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	hits := make([]Match, 0, limit)
+	hits := make([]Match, 0, len(searchResult.Hits.Hits))
 	for _, hit := range searchResult.Hits.Hits {
 		id, _ := strconv.ParseInt(hit.Id, 10, 64)
-		hits = append(hits, Match{
+		match := Match{
 			ID: id,
-		})
+		}
+		if hit.Score != nil {
+			match.Score = *hit.Score
+		}
+		for _, field := range []string{"title", "content", "comments"} {
+			for _, fragment := range hit.Highlight[field] {
+				match.Highlights = append(match.Highlights, Highlight{
+					Field:    field,
+					Fragment: formatHighlight(fragment),
+				})
+			}
+		}
+		hits = append(hits, match)
 	}
 
 	return &SearchResult{
@@ -0,0 +1,85 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// The external indexers are asked to wrap the matched terms in these markers,
+// they are replaced with <mark> after the fragment has been escaped.
+const (
+	highlightPreTag  = "\uE000"
+	highlightPostTag = "\uE001"
+
+	// the number of characters shown around a match
+	highlightContext = 60
+)
+
+// formatHighlight escapes a fragment which has its matches wrapped in the highlight markers
+func formatHighlight(fragment string) string {
+	fragment = html.EscapeString(fragment)
+	fragment = strings.ReplaceAll(fragment, highlightPreTag, "<mark>")
+	return strings.ReplaceAll(fragment, highlightPostTag, "</mark>")
+}
+
+// hasHighlight returns whether the fragment contains a match
+func hasHighlight(fragment string) bool {
+	return strings.Contains(fragment, highlightPreTag)
+}
+
+// highlightKeyword wraps the case insensitive occurrences of the keyword in the text in the highlight markers
+// and cuts the text down to the context of the first match. It returns false if the keyword does not occur.
+func highlightKeyword(text, keyword string) (string, bool) {
+	if len(keyword) == 0 {
+		return "", false
+	}
+	lowerText := strings.ToLower(text)
+	lowerKeyword := strings.ToLower(keyword)
+	// lowering the case may change the length of some characters, the positions are only usable if it did not
+	if len(lowerText) != len(text) || len(lowerKeyword) != len(keyword) {
+		lowerText, lowerKeyword = text, keyword
+	}
+
+	first := strings.Index(lowerText, lowerKeyword)
+	if first < 0 {
+		return "", false
+	}
+
+	start := first
+	for i := 0; i < highlightContext && start > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := first + len(keyword)
+	for i := 0; i < highlightContext && end < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	pos := start
+	for pos < end {
+		idx := strings.Index(lowerText[pos:end], lowerKeyword)
+		if idx < 0 {
+			break
+		}
+		sb.WriteString(text[pos : pos+idx])
+		sb.WriteString(highlightPreTag)
+		sb.WriteString(text[pos+idx : pos+idx+len(keyword)])
+		sb.WriteString(highlightPostTag)
+		pos += idx + len(keyword)
+	}
+	sb.WriteString(text[pos:end])
+	if end < len(text) {
+		sb.WriteString("…")
+	}
+	return sb.String(), true
+}
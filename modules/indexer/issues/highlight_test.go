@@ -0,0 +1,28 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightKeyword(t *testing.T) {
+	fragment, ok := highlightKeyword("Issue Search should support search <b>", "SEARCH")
+	assert.True(t, ok)
+	assert.Equal(t, "Issue <mark>Search</mark> should support <mark>search</mark> &lt;b&gt;", formatHighlight(fragment))
+
+	_, ok = highlightKeyword("Issue search", "missing")
+	assert.False(t, ok)
+
+	// the text is cut down to the context of the first match
+	filler := strings.Repeat("x", 100)
+	fragment, ok = highlightKeyword(filler+" keyword "+filler, "keyword")
+	assert.True(t, ok)
+	context := strings.Repeat("x", highlightContext-1)
+	assert.Equal(t, "…"+context+" <mark>keyword</mark> "+context+"…", formatHighlight(fragment))
+}
@@ -21,19 +21,35 @@ import (
 
 // IndexerData data stored in the issue indexer
 type IndexerData struct {
-	ID       int64    `json:"id"`
-	RepoID   int64    `json:"repo_id"`
-	Title    string   `json:"title"`
-	Content  string   `json:"content"`
-	Comments []string `json:"comments"`
-	IsDelete bool     `json:"is_delete"`
-	IDs      []int64  `json:"ids"`
+	ID          int64    `json:"id"`
+	RepoID      int64    `json:"repo_id"`
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	Comments    []string `json:"comments"`
+	IsPull      bool     `json:"is_pull"`
+	IsClosed    bool     `json:"is_closed"`
+	LabelIDs    []int64  `json:"label_ids"`
+	MilestoneID int64    `json:"milestone_id"`
+	PosterID    int64    `json:"poster_id"`
+	AssigneeIDs []int64  `json:"assignee_ids"`
+	CreatedUnix int64    `json:"created_unix"`
+	UpdatedUnix int64    `json:"updated_unix"`
+	IsDelete    bool     `json:"is_delete"`
+	IDs         []int64  `json:"ids"`
+}
+
+// Highlight is a fragment of an indexed field which matches the keyword of a search.
+// Fragment is HTML, the matched terms are wrapped in <mark> and everything else is escaped.
+type Highlight struct {
+	Field    string `json:"field"`
+	Fragment string `json:"fragment"`
 }
 
 // Match represents on search result
 type Match struct {
-	ID    int64   `json:"id"`
-	Score float64 `json:"score"`
+	ID         int64       `json:"id"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
 }
 
 // SearchResult represents search results
@@ -42,15 +58,77 @@ type SearchResult struct {
 	Hits  []Match
 }
 
+// The sort orders supported by the indexers, they match the sort types of the issue list
+const (
+	SortByRelevance    = "relevance"
+	SortByNewest       = "newest"
+	SortByLatest       = "latest"
+	SortByOldest       = "oldest"
+	SortByRecentUpdate = "recentupdate"
+	SortByLeastUpdate  = "leastupdate"
+)
+
+// IsSortTypeSupported returns whether the indexers can order results by the given sort type of the issue list
+func IsSortTypeSupported(sortType string) bool {
+	switch sortType {
+	case "", SortByRelevance, SortByNewest, SortByLatest, SortByOldest, SortByRecentUpdate, SortByLeastUpdate:
+		return true
+	}
+	return false
+}
+
+// SearchOptions represents the conditions of an issue search
+type SearchOptions struct {
+	models.ListOptions
+	Keyword string
+	RepoIDs []int64 // the repositories to search in, all repositories if empty
+	// the issues must have all of the labels, a negative ID excludes the issues having that label
+	LabelIDs          []int64
+	MilestoneIDs      []int64 // the issues must belong to one of the milestones
+	PosterID          int64
+	AssigneeID        int64
+	IsPull            util.OptionalBool
+	IsClosed          util.OptionalBool
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	SortBy            string // relevance if empty
+}
+
+// start returns the offset of the first result of the requested page
+func (opts *SearchOptions) start() int {
+	if opts.Page <= 1 {
+		return 0
+	}
+	return (opts.Page - 1) * opts.PageSize
+}
+
 // Indexer defines an interface to indexer issues contents
 type Indexer interface {
 	Init() (bool, error)
 	Index(issue []*IndexerData) error
 	Delete(ids ...int64) error
-	Search(kw string, repoIDs []int64, limit, start int) (*SearchResult, error)
+	Search(opts *SearchOptions) (*SearchResult, error)
 	Close()
 }
 
+// NewIndexerFunc creates the issue indexer from the indexer settings
+type NewIndexerFunc func() (Indexer, error)
+
+type indexerType struct {
+	newIndexer NewIndexerFunc
+	// whether the indexer keeps its own index which is fed from the indexer queue
+	withQueue bool
+}
+
+var indexerTypes = map[string]indexerType{}
+
+// RegisterIndexer makes an issue indexer available for the ISSUE_INDEXER_TYPE setting.
+// If withQueue is set the changes of the issues are passed to the indexer through the indexer queue,
+// otherwise the indexer is expected to search the database directly.
+func RegisterIndexer(name string, newIndexer NewIndexerFunc, withQueue bool) {
+	indexerTypes[name] = indexerType{newIndexer: newIndexer, withQueue: withQueue}
+}
+
 type indexerHolder struct {
 	indexer   Indexer
 	mutex     sync.RWMutex
@@ -98,9 +176,13 @@ var (
 func InitIssueIndexer(syncReindex bool) {
 	waitChannel := make(chan time.Duration)
 
+	typ, ok := indexerTypes[setting.Indexer.IssueType]
+	if !ok {
+		log.Fatal("Unknown issue indexer type: %s", setting.Indexer.IssueType)
+	}
+
 	// Create the Queue
-	switch setting.Indexer.IssueType {
-	case "bleve", "elasticsearch":
+	if typ.withQueue {
 		handler := func(data ...queue.Data) {
 			indexer := holder.get()
 			if indexer == nil {
@@ -132,7 +214,7 @@ func InitIssueIndexer(syncReindex bool) {
 		if issueIndexerQueue == nil {
 			log.Fatal("Unable to create issue indexer queue")
 		}
-	default:
+	} else {
 		issueIndexerQueue = &queue.DummyQueue{}
 	}
 
@@ -140,55 +222,39 @@ func InitIssueIndexer(syncReindex bool) {
 	go func() {
 		start := time.Now()
 		log.Info("PID %d: Initializing Issue Indexer: %s", os.Getpid(), setting.Indexer.IssueType)
-		var populate bool
-		switch setting.Indexer.IssueType {
-		case "bleve":
-			defer func() {
-				if err := recover(); err != nil {
-					log.Error("PANIC whilst initializing issue indexer: %v\nStacktrace: %s", err, log.Stack(2))
+		defer func() {
+			if err := recover(); err != nil {
+				log.Error("PANIC whilst initializing issue indexer: %v\nStacktrace: %s", err, log.Stack(2))
+				if setting.Indexer.IssueType == "bleve" {
 					log.Error("The indexer files are likely corrupted and may need to be deleted")
 					log.Error("You can completely remove the %q directory to make Gitea recreate the indexes", setting.Indexer.IssuePath)
-					holder.cancel()
-					log.Fatal("PID: %d Unable to initialize the Bleve Issue Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.IssuePath, err)
 				}
-			}()
-			issueIndexer := NewBleveIndexer(setting.Indexer.IssuePath)
-			exist, err := issueIndexer.Init()
-			if err != nil {
 				holder.cancel()
-				log.Fatal("Unable to initialize Bleve Issue Indexer at path: %s Error: %v", setting.Indexer.IssuePath, err)
+				log.Fatal("PID: %d Unable to initialize the %s Issue Indexer Error: %v", os.Getpid(), setting.Indexer.IssueType, err)
 			}
-			populate = !exist
-			holder.set(issueIndexer)
-			graceful.GetManager().RunAtTerminate(context.Background(), func() {
-				log.Debug("Closing issue indexer")
-				issueIndexer := holder.get()
-				if issueIndexer != nil {
-					issueIndexer.Close()
-				}
-				log.Info("PID: %d Issue Indexer closed", os.Getpid())
-			})
-			log.Debug("Created Bleve Indexer")
-		case "elasticsearch":
-			graceful.GetManager().RunWithShutdownFns(func(_, atTerminate func(context.Context, func())) {
-				issueIndexer, err := NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
-				if err != nil {
-					log.Fatal("Unable to initialize Elastic Search Issue Indexer at connection: %s Error: %v", setting.Indexer.IssueConnStr, err)
-				}
-				exist, err := issueIndexer.Init()
-				if err != nil {
-					log.Fatal("Unable to issueIndexer.Init with connection %s Error: %v", setting.Indexer.IssueConnStr, err)
-				}
-				populate = !exist
-				holder.set(issueIndexer)
-			})
-		case "db":
-			issueIndexer := &DBIndexer{}
-			holder.set(issueIndexer)
-		default:
+		}()
+
+		issueIndexer, err := typ.newIndexer()
+		if err != nil {
 			holder.cancel()
-			log.Fatal("Unknown issue indexer type: %s", setting.Indexer.IssueType)
+			log.Fatal("Unable to create the %s Issue Indexer Error: %v", setting.Indexer.IssueType, err)
 		}
+		exist, err := issueIndexer.Init()
+		if err != nil {
+			holder.cancel()
+			log.Fatal("Unable to initialize the %s Issue Indexer Error: %v", setting.Indexer.IssueType, err)
+		}
+		populate := typ.withQueue && !exist
+		holder.set(issueIndexer)
+		graceful.GetManager().RunAtTerminate(context.Background(), func() {
+			log.Debug("Closing issue indexer")
+			issueIndexer := holder.get()
+			if issueIndexer != nil {
+				issueIndexer.Close()
+			}
+			log.Info("PID: %d Issue Indexer closed", os.Getpid())
+		})
+		log.Debug("Created %s Issue Indexer", setting.Indexer.IssueType)
 
 		// Start processing the queue
 		go graceful.GetManager().RunWithShutdownFns(issueIndexerQueue.Run)
@@ -289,18 +355,51 @@ func UpdateRepoIndexer(repo *models.Repository) {
 
 // UpdateIssueIndexer add/update an issue to the issue indexer
 func UpdateIssueIndexer(issue *models.Issue) {
+	if issue.Comments == nil {
+		if err := issue.LoadDiscussComments(); err != nil {
+			log.Error("LoadDiscussComments: %v", err)
+			return
+		}
+	}
+	if err := issue.LoadLabels(); err != nil {
+		log.Error("LoadLabels: %v", err)
+		return
+	}
+	if issue.Assignees == nil {
+		if err := issue.LoadAssignees(); err != nil {
+			log.Error("LoadAssignees: %v", err)
+			return
+		}
+	}
+
 	var comments []string
 	for _, comment := range issue.Comments {
 		if comment.Type == models.CommentTypeComment {
 			comments = append(comments, comment.Content)
 		}
 	}
+	labelIDs := make([]int64, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		labelIDs = append(labelIDs, label.ID)
+	}
+	assigneeIDs := make([]int64, 0, len(issue.Assignees))
+	for _, assignee := range issue.Assignees {
+		assigneeIDs = append(assigneeIDs, assignee.ID)
+	}
 	indexerData := &IndexerData{
-		ID:       issue.ID,
-		RepoID:   issue.RepoID,
-		Title:    issue.Title,
-		Content:  issue.Content,
-		Comments: comments,
+		ID:          issue.ID,
+		RepoID:      issue.RepoID,
+		Title:       issue.Title,
+		Content:     issue.Content,
+		Comments:    comments,
+		IsPull:      issue.IsPull,
+		IsClosed:    issue.IsClosed,
+		LabelIDs:    labelIDs,
+		MilestoneID: issue.MilestoneID,
+		PosterID:    issue.PosterID,
+		AssigneeIDs: assigneeIDs,
+		CreatedUnix: int64(issue.CreatedUnix),
+		UpdatedUnix: int64(issue.UpdatedUnix),
 	}
 	log.Debug("Adding to channel: %v", indexerData)
 	if err := issueIndexerQueue.Push(indexerData); err != nil {
@@ -333,13 +432,11 @@ func DeleteRepoIssueIndexer(repo *models.Repository) {
 // WARNNING: You have to ensure user have permission to visit repoIDs' issues
 func SearchIssuesByKeyword(repoIDs []int64, keyword string) ([]int64, error) {
 	var issueIDs []int64
-	indexer := holder.get()
-
-	if indexer == nil {
-		log.Error("SearchIssuesByKeyword(): unable to get indexer!")
-		return nil, fmt.Errorf("unable to get issue indexer")
-	}
-	res, err := indexer.Search(keyword, repoIDs, 50, 0)
+	res, err := Search(&SearchOptions{
+		ListOptions: models.ListOptions{Page: 1, PageSize: 50},
+		Keyword:     keyword,
+		RepoIDs:     repoIDs,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -348,3 +445,62 @@ func SearchIssuesByKeyword(repoIDs []int64, keyword string) ([]int64, error) {
 	}
 	return issueIDs, nil
 }
+
+// Search searches the issue index
+// WARNNING: You have to ensure user have permission to visit repoIDs' issues
+func Search(opts *SearchOptions) (*SearchResult, error) {
+	indexer := holder.get()
+	if indexer == nil {
+		log.Error("Search(): unable to get indexer!")
+		return nil, fmt.Errorf("unable to get issue indexer")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.UI.IssuePagingNum
+	}
+	return indexer.Search(opts)
+}
+
+// SearchIssues searches the issue index and loads the issues of the result in the order of the result
+// WARNNING: You have to ensure user have permission to visit repoIDs' issues
+func SearchIssues(opts *SearchOptions) (*SearchResult, []*models.Issue, error) {
+	res, err := Search(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]int64, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	issues, err := models.GetIssuesByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := models.IssueList(issues).LoadAttributes(); err != nil {
+		return nil, nil, err
+	}
+
+	issuesByID := make(map[int64]*models.Issue, len(issues))
+	for _, issue := range issues {
+		issuesByID[issue.ID] = issue
+	}
+	// the index can be behind the database, skip the issues which were deleted meanwhile
+	ordered := make([]*models.Issue, 0, len(issues))
+	for _, id := range ids {
+		if issue, ok := issuesByID[id]; ok {
+			ordered = append(ordered, issue)
+		}
+	}
+	return res, ordered, nil
+}
+
+// Highlights returns the highlights of the hits by issue ID
+func (res *SearchResult) Highlights() map[int64][]Highlight {
+	highlights := make(map[int64][]Highlight, len(res.Hits))
+	for _, hit := range res.Hits {
+		if len(hit.Highlights) > 0 {
+			highlights[hit.ID] = hit.Highlights
+		}
+	}
+	return highlights
+}
@@ -94,4 +94,25 @@ func TestDBSearchIssues(t *testing.T) {
 	ids, err = SearchIssuesByKeyword([]int64{1}, "good")
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
+
+	res, err := Search(&SearchOptions{
+		Keyword:  "first",
+		RepoIDs:  []int64{1},
+		IsClosed: util.OptionalBoolFalse,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Total)
+	if assert.Len(t, res.Hits, 1) {
+		assert.Equal(t, []Highlight{
+			{Field: "content", Fragment: "content for the <mark>first</mark> issue"},
+		}, res.Hits[0].Highlights)
+	}
+
+	res, err = Search(&SearchOptions{
+		Keyword:  "first",
+		RepoIDs:  []int64{1},
+		IsClosed: util.OptionalBoolTrue,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, res.Total)
 }
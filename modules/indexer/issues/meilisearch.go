@@ -0,0 +1,323 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	_ Indexer = &MeilisearchIndexer{}
+)
+
+func init() {
+	RegisterIndexer("meilisearch", func() (Indexer, error) {
+		return NewMeilisearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+	}, true)
+}
+
+// MeilisearchIndexer implements Indexer interface on top of the REST API of Meilisearch
+type MeilisearchIndexer struct {
+	client      *http.Client
+	url         string
+	apiKey      string
+	indexerName string
+}
+
+// NewMeilisearchIndexer creates a new meilisearch indexer.
+// The API key is taken from the password of the connection string, e.g. http://:apikey@localhost:7700
+func NewMeilisearchIndexer(connStr, indexerName string) (*MeilisearchIndexer, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid meilisearch connection string: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid meilisearch connection string %q: the scheme must be http or https", u.Redacted())
+	}
+
+	var apiKey string
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			apiKey = password
+		} else {
+			apiKey = u.User.Username()
+		}
+		u.User = nil
+	}
+
+	return &MeilisearchIndexer{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		url:         strings.TrimSuffix(u.String(), "/"),
+		apiKey:      apiKey,
+		indexerName: indexerName,
+	}, nil
+}
+
+type meilisearchError struct {
+	StatusCode int
+	Message    string `json:"message"`
+	Code       string `json:"code"`
+}
+
+func (err *meilisearchError) Error() string {
+	return fmt.Sprintf("meilisearch responded with %d: %s (%s)", err.StatusCode, err.Message, err.Code)
+}
+
+// request sends a request to the meilisearch API and decodes the response into result if it is not nil
+func (b *MeilisearchIndexer) request(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, b.url+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(b.apiKey) > 0 {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		merr := &meilisearchError{StatusCode: resp.StatusCode}
+		data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err := json.Unmarshal(data, merr); err != nil {
+			merr.Message = string(data)
+		}
+		return merr
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (b *MeilisearchIndexer) indexPath() string {
+	return "/indexes/" + url.PathEscape(b.indexerName)
+}
+
+// Init will initialize the indexer
+func (b *MeilisearchIndexer) Init() (bool, error) {
+	exists := true
+	if err := b.request(http.MethodGet, b.indexPath(), nil, nil); err != nil {
+		if merr, ok := err.(*meilisearchError); !ok || merr.StatusCode != http.StatusNotFound {
+			return false, err
+		}
+		exists = false
+	}
+
+	if !exists {
+		if err := b.request(http.MethodPost, "/indexes", map[string]string{
+			"uid":        b.indexerName,
+			"primaryKey": "id",
+		}, nil); err != nil {
+			return false, err
+		}
+	}
+
+	// the settings are always updated so new attributes are picked up by existing indexes
+	if err := b.request(http.MethodPatch, b.indexPath()+"/settings", map[string]interface{}{
+		"searchableAttributes": []string{"title", "content", "comments"},
+		"filterableAttributes": []string{
+			"repo_id", "is_pull", "is_closed", "label_ids", "milestone_id",
+			"poster_id", "assignee_ids", "updated_unix",
+		},
+		"sortableAttributes": []string{"created_unix", "updated_unix"},
+	}, nil); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Index will save the index data
+func (b *MeilisearchIndexer) Index(issues []*IndexerData) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	docs := make([]map[string]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		docs = append(docs, map[string]interface{}{
+			"id":           issue.ID,
+			"repo_id":      issue.RepoID,
+			"title":        issue.Title,
+			"content":      issue.Content,
+			"comments":     issue.Comments,
+			"is_pull":      issue.IsPull,
+			"is_closed":    issue.IsClosed,
+			"label_ids":    issue.LabelIDs,
+			"milestone_id": issue.MilestoneID,
+			"poster_id":    issue.PosterID,
+			"assignee_ids": issue.AssigneeIDs,
+			"created_unix": issue.CreatedUnix,
+			"updated_unix": issue.UpdatedUnix,
+		})
+	}
+	return b.request(http.MethodPost, b.indexPath()+"/documents", docs, nil)
+}
+
+// Delete deletes indexes by ids
+func (b *MeilisearchIndexer) Delete(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return b.request(http.MethodPost, b.indexPath()+"/documents/delete-batch", ids, nil)
+}
+
+var meilisearchSortFields = map[string]string{
+	SortByNewest:       "created_unix:desc",
+	SortByLatest:       "created_unix:desc",
+	SortByOldest:       "created_unix:asc",
+	SortByRecentUpdate: "updated_unix:desc",
+	SortByLeastUpdate:  "updated_unix:asc",
+}
+
+func meilisearchInFilter(field string, ids []int64) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatInt(id, 10))
+	}
+	return fmt.Sprintf("%s IN [%s]", field, strings.Join(values, ", "))
+}
+
+// meilisearchFilter builds the filter expression of the search options
+func (opts *SearchOptions) meilisearchFilter() string {
+	var filters []string
+	if len(opts.RepoIDs) > 0 {
+		filters = append(filters, meilisearchInFilter("repo_id", opts.RepoIDs))
+	}
+	for _, labelID := range opts.LabelIDs {
+		if labelID > 0 {
+			filters = append(filters, fmt.Sprintf("label_ids = %d", labelID))
+		} else if labelID < 0 {
+			filters = append(filters, fmt.Sprintf("NOT label_ids = %d", -labelID))
+		}
+	}
+	if len(opts.MilestoneIDs) > 0 {
+		filters = append(filters, meilisearchInFilter("milestone_id", opts.MilestoneIDs))
+	}
+	if opts.PosterID > 0 {
+		filters = append(filters, fmt.Sprintf("poster_id = %d", opts.PosterID))
+	}
+	if opts.AssigneeID > 0 {
+		filters = append(filters, fmt.Sprintf("assignee_ids = %d", opts.AssigneeID))
+	}
+	if !opts.IsPull.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_pull = %t", opts.IsPull.IsTrue()))
+	}
+	if !opts.IsClosed.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_closed = %t", opts.IsClosed.IsTrue()))
+	}
+	if opts.UpdatedAfterUnix != 0 {
+		filters = append(filters, fmt.Sprintf("updated_unix >= %d", opts.UpdatedAfterUnix))
+	}
+	if opts.UpdatedBeforeUnix != 0 {
+		filters = append(filters, fmt.Sprintf("updated_unix <= %d", opts.UpdatedBeforeUnix))
+	}
+	return strings.Join(filters, " AND ")
+}
+
+type meilisearchSearchResponse struct {
+	Hits []struct {
+		ID        int64 `json:"id"`
+		Formatted struct {
+			Title    string   `json:"title"`
+			Content  string   `json:"content"`
+			Comments []string `json:"comments"`
+		} `json:"_formatted"`
+	} `json:"hits"`
+	TotalHits int64 `json:"totalHits"`
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *MeilisearchIndexer) Search(opts *SearchOptions) (*SearchResult, error) {
+	page := opts.Page
+	if page <= 0 {
+		page = 1
+	}
+	req := map[string]interface{}{
+		"q":           opts.Keyword,
+		"page":        page,
+		"hitsPerPage": opts.PageSize,
+	}
+	if filter := opts.meilisearchFilter(); len(filter) > 0 {
+		req["filter"] = filter
+	}
+	if sortBy, ok := meilisearchSortFields[opts.SortBy]; ok {
+		req["sort"] = []string{sortBy}
+	}
+	if len(opts.Keyword) > 0 {
+		req["attributesToHighlight"] = []string{"title", "content", "comments"}
+		req["attributesToCrop"] = []string{"content", "comments"}
+		req["cropLength"] = 20
+		req["cropMarker"] = "…"
+		req["highlightPreTag"] = highlightPreTag
+		req["highlightPostTag"] = highlightPostTag
+	}
+
+	var resp meilisearchSearchResponse
+	if err := b.request(http.MethodPost, b.indexPath()+"/search", req, &resp); err != nil {
+		return nil, err
+	}
+
+	hits := make([]Match, 0, len(resp.Hits))
+	for _, hit := range resp.Hits {
+		match := Match{
+			ID: hit.ID,
+		}
+		// only the fragments which contain a match are of interest, the others are the cropped fields
+		for _, field := range []struct {
+			name      string
+			fragments []string
+		}{
+			{"title", []string{hit.Formatted.Title}},
+			{"content", []string{hit.Formatted.Content}},
+			{"comments", hit.Formatted.Comments},
+		} {
+			for _, fragment := range field.fragments {
+				if hasHighlight(fragment) {
+					match.Highlights = append(match.Highlights, Highlight{
+						Field:    field.name,
+						Fragment: formatHighlight(fragment),
+					})
+				}
+			}
+		}
+		hits = append(hits, match)
+	}
+
+	return &SearchResult{
+		Total: resp.TotalHits,
+		Hits:  hits,
+	}, nil
+}
+
+// Close implements indexer
+func (b *MeilisearchIndexer) Close() {}
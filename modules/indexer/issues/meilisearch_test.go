@@ -0,0 +1,87 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeilisearchIndexer(t *testing.T) {
+	var requests []string
+	var searchRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /indexes/gitea_issues":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Index gitea_issues not found.","code":"index_not_found"}`))
+		case "POST /indexes/gitea_issues/search":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&searchRequest))
+			_, _ = w.Write([]byte(`{
+				"hits": [
+					{"id": 2, "_formatted": {"id": "2", "title": "Support search <b>", "content": "As title", "comments": ["no match", "search again"]}},
+					{"id": 1, "_formatted": {"id": "1", "title": "Other", "content": "…the search…"}}
+				],
+				"totalHits": 12
+			}`))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	indexer, err := NewMeilisearchIndexer("http://:secret@"+server.Listener.Addr().String(), "gitea_issues")
+	assert.NoError(t, err)
+
+	exists, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, []string{
+		"GET /indexes/gitea_issues",
+		"POST /indexes",
+		"PATCH /indexes/gitea_issues/settings",
+	}, requests)
+
+	res, err := indexer.Search(&SearchOptions{
+		ListOptions:      models.ListOptions{Page: 2, PageSize: 5},
+		Keyword:          "search",
+		RepoIDs:          []int64{1, 2},
+		LabelIDs:         []int64{3, -4},
+		IsClosed:         util.OptionalBoolFalse,
+		UpdatedAfterUnix: 100,
+		SortBy:           SortByOldest,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "search", searchRequest["q"])
+	assert.EqualValues(t, 2, searchRequest["page"])
+	assert.EqualValues(t, 5, searchRequest["hitsPerPage"])
+	assert.Equal(t, "repo_id IN [1, 2] AND label_ids = 3 AND NOT label_ids = 4 AND is_closed = false AND updated_unix >= 100", searchRequest["filter"])
+	assert.Equal(t, []interface{}{"created_unix:asc"}, searchRequest["sort"])
+
+	assert.EqualValues(t, 12, res.Total)
+	if assert.Len(t, res.Hits, 2) {
+		assert.EqualValues(t, 2, res.Hits[0].ID)
+		assert.Equal(t, []Highlight{
+			{Field: "title", Fragment: "Support <mark>search</mark> &lt;b&gt;"},
+			{Field: "comments", Fragment: "<mark>search</mark> again"},
+		}, res.Hits[0].Highlights)
+		assert.EqualValues(t, 1, res.Hits[1].ID)
+		assert.Equal(t, []Highlight{
+			{Field: "content", Fragment: "…the <mark>search</mark>…"},
+		}, res.Hits[1].Highlights)
+	}
+}
@@ -0,0 +1,834 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &BitbucketDownloader{}
+	_ base.DownloaderFactory = &BitbucketDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&BitbucketDownloaderFactory{})
+}
+
+const (
+	bitbucketCloudHost   = "bitbucket.org"
+	bitbucketCloudAPIURL = "https://api.bitbucket.org/2.0"
+	// bitbucketMaxPerPage is the largest page size Bitbucket Cloud accepts for issues and pull requests
+	bitbucketMaxPerPage = 50
+)
+
+// BitbucketDownloaderFactory defines a Bitbucket downloader factory.
+// Repositories on bitbucket.org are downloaded from Bitbucket Cloud, all others from a Bitbucket Server.
+type BitbucketDownloaderFactory struct {
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *BitbucketDownloaderFactory) New(ctx context.Context, opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(u.Host, bitbucketCloudHost) {
+		fields := strings.Split(strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/"), "/")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid path: %s", u.Path)
+		}
+		log.Trace("Create Bitbucket Cloud downloader. Workspace: %s RepoSlug: %s", fields[0], fields[1])
+		return NewBitbucketDownloader(ctx, bitbucketCloudAPIURL, opts.AuthUsername, opts.AuthPassword, opts.AuthToken, fields[0], fields[1]), nil
+	}
+
+	baseURL, projectKey, repoSlug, err := parseBitbucketServerURL(u)
+	if err != nil {
+		return nil, err
+	}
+	log.Trace("Create Bitbucket Server downloader. BaseURL: %s ProjectKey: %s RepoSlug: %s", baseURL, projectKey, repoSlug)
+	return NewBitbucketServerDownloader(ctx, baseURL, opts.AuthUsername, opts.AuthPassword, opts.AuthToken, projectKey, repoSlug), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *BitbucketDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.BitbucketService
+}
+
+// bitbucketClient sends authenticated requests to the REST API of Bitbucket Cloud or Bitbucket Server
+type bitbucketClient struct {
+	ctx      context.Context
+	client   *http.Client
+	baseURL  string
+	userName string
+	password string
+	token    string
+}
+
+// getJSON decodes the response of a GET request to the API
+func (c *bitbucketClient) getJSON(path string, query url.Values, v interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if len(c.userName) > 0 {
+		req.SetBasicAuth(c.userName, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &bitbucketHTTPError{URL: u, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// downloadURL returns an URL of the API which can be downloaded without further authentication
+func (c *bitbucketClient) downloadURL(path string) string {
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return c.baseURL + path
+	}
+	if len(c.token) == 0 && len(c.userName) > 0 {
+		u.User = url.UserPassword(c.userName, c.password)
+	}
+	return u.String()
+}
+
+// formatCloneURL adds the credentials to a remote URL
+func (c *bitbucketClient) formatCloneURL(remoteAddr string) (string, error) {
+	if len(c.token) == 0 && len(c.userName) == 0 {
+		return remoteAddr, nil
+	}
+	u, err := url.Parse(remoteAddr)
+	if err != nil {
+		return "", err
+	}
+	if len(c.token) > 0 {
+		// Personal access tokens are used as password of their user,
+		// repository access tokens as password of the x-token-auth user
+		userName := c.userName
+		if len(userName) == 0 {
+			userName = "x-token-auth"
+		}
+		u.User = url.UserPassword(userName, c.token)
+	} else {
+		u.User = url.UserPassword(c.userName, c.password)
+	}
+	return u.String(), nil
+}
+
+// bitbucketHTTPError represents an unexpected response of the API
+type bitbucketHTTPError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (err *bitbucketHTTPError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", err.URL, err.StatusCode, err.Body)
+}
+
+// isBitbucketNotFound checks if an error is a 404 response, which Bitbucket returns for disabled features
+func isBitbucketNotFound(err error) bool {
+	httpErr, ok := err.(*bitbucketHTTPError)
+	return ok && httpErr.StatusCode == http.StatusNotFound
+}
+
+type bitbucketUser struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+	AccountID   string `json:"account_id"`
+}
+
+// name returns the name a user is shown with in Gitea
+func (u *bitbucketUser) name() string {
+	if u == nil {
+		return "Ghost"
+	}
+	if len(u.Nickname) > 0 {
+		return u.Nickname
+	}
+	return u.DisplayName
+}
+
+type bitbucketContent struct {
+	Raw string `json:"raw"`
+}
+
+type bitbucketCommit struct {
+	Hash string `json:"hash"`
+}
+
+type bitbucketPage struct {
+	Next string `json:"next"`
+}
+
+type bitbucketRepository struct {
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+	MainBranch  *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketIssue struct {
+	ID        int64            `json:"id"`
+	Title     string           `json:"title"`
+	Content   bitbucketContent `json:"content"`
+	State     string           `json:"state"`
+	Kind      string           `json:"kind"`
+	Priority  string           `json:"priority"`
+	Reporter  *bitbucketUser   `json:"reporter"`
+	Assignee  *bitbucketUser   `json:"assignee"`
+	Milestone *struct {
+		Name string `json:"name"`
+	} `json:"milestone"`
+	Component *struct {
+		Name string `json:"name"`
+	} `json:"component"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+type bitbucketComment struct {
+	ID        int64            `json:"id"`
+	Content   bitbucketContent `json:"content"`
+	User      *bitbucketUser   `json:"user"`
+	CreatedOn time.Time        `json:"created_on"`
+	UpdatedOn time.Time        `json:"updated_on"`
+	Deleted   bool             `json:"deleted"`
+	Inline    *struct {
+		Path string `json:"path"`
+		From *int   `json:"from"`
+		To   *int   `json:"to"`
+	} `json:"inline"`
+	Parent *struct {
+		ID int64 `json:"id"`
+	} `json:"parent"`
+}
+
+type bitbucketPullRequestEndpoint struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit     *bitbucketCommit `json:"commit"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+		Links    struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+type bitbucketPullRequest struct {
+	ID           int64                        `json:"id"`
+	Title        string                       `json:"title"`
+	Description  string                       `json:"description"`
+	State        string                       `json:"state"`
+	Author       *bitbucketUser               `json:"author"`
+	Source       bitbucketPullRequestEndpoint `json:"source"`
+	Destination  bitbucketPullRequestEndpoint `json:"destination"`
+	MergeCommit  *bitbucketCommit             `json:"merge_commit"`
+	CreatedOn    time.Time                    `json:"created_on"`
+	UpdatedOn    time.Time                    `json:"updated_on"`
+	Participants []struct {
+		User           *bitbucketUser `json:"user"`
+		Role           string         `json:"role"`
+		Approved       bool           `json:"approved"`
+		State          string         `json:"state"`
+		ParticipatedOn *time.Time     `json:"participated_on"`
+	} `json:"participants"`
+}
+
+type bitbucketTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Target  struct {
+		Hash   string    `json:"hash"`
+		Date   time.Time `json:"date"`
+		Author struct {
+			Raw  string         `json:"raw"`
+			User *bitbucketUser `json:"user"`
+		} `json:"author"`
+	} `json:"target"`
+}
+
+// bitbucketIssueKinds and bitbucketIssuePriorities are the fixed kinds and priorities of Bitbucket Cloud issues,
+// which are migrated as labels together with the components of the repository
+var (
+	bitbucketIssueKinds = []*base.Label{
+		{Name: "kind/bug", Color: "ee0701"},
+		{Name: "kind/enhancement", Color: "84b6eb"},
+		{Name: "kind/proposal", Color: "c5def5"},
+		{Name: "kind/task", Color: "fbca04"},
+	}
+	bitbucketIssuePriorities = []*base.Label{
+		{Name: "priority/trivial", Color: "e6e6e6"},
+		{Name: "priority/minor", Color: "c2e0c6"},
+		{Name: "priority/major", Color: "fbca04"},
+		{Name: "priority/critical", Color: "eb6420"},
+		{Name: "priority/blocker", Color: "b60205"},
+	}
+)
+
+const bitbucketComponentColor = "5319e7"
+
+// BitbucketDownloader implements a Downloader interface to get repository information from Bitbucket Cloud.
+// Bitbucket has individual issue and pull request numbers, so the pull requests are numbered after the
+// largest issue number to not overlap with the issues.
+type BitbucketDownloader struct {
+	base.NullDownloader
+	client        *bitbucketClient
+	workspace     string
+	repoSlug      string
+	maxIssueIndex int64
+	fullHashes    map[string]string
+}
+
+// NewBitbucketDownloader creates a Bitbucket Cloud downloader
+func NewBitbucketDownloader(ctx context.Context, apiURL, userName, password, token, workspace, repoSlug string) *BitbucketDownloader {
+	return &BitbucketDownloader{
+		client: &bitbucketClient{
+			ctx:      ctx,
+			client:   &http.Client{},
+			baseURL:  strings.TrimSuffix(apiURL, "/"),
+			userName: userName,
+			password: password,
+			token:    token,
+		},
+		workspace:  workspace,
+		repoSlug:   repoSlug,
+		fullHashes: make(map[string]string),
+	}
+}
+
+// SetContext set context
+func (g *BitbucketDownloader) SetContext(ctx context.Context) {
+	g.client.ctx = ctx
+}
+
+func (g *BitbucketDownloader) repoPath() string {
+	return fmt.Sprintf("/repositories/%s/%s", url.PathEscape(g.workspace), url.PathEscape(g.repoSlug))
+}
+
+// fullHash returns the full SHA1 of a commit, as Bitbucket Cloud returns abbreviated hashes for pull requests
+func (g *BitbucketDownloader) fullHash(hash string) (string, error) {
+	if len(hash) == 0 || len(hash) == 40 {
+		return hash, nil
+	}
+	if full, ok := g.fullHashes[hash]; ok {
+		return full, nil
+	}
+	var commit bitbucketCommit
+	if err := g.client.getJSON(g.repoPath()+"/commit/"+url.PathEscape(hash), nil, &commit); err != nil {
+		return "", err
+	}
+	g.fullHashes[hash] = commit.Hash
+	return commit.Hash, nil
+}
+
+// GetRepoInfo returns a repository information
+func (g *BitbucketDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo bitbucketRepository
+	if err := g.client.getJSON(g.repoPath(), nil, &repo); err != nil {
+		return nil, err
+	}
+
+	var cloneURL string
+	for _, link := range repo.Links.Clone {
+		if link.Name == "https" {
+			cloneURL = link.Href
+		}
+	}
+	// The clone URL contains the name of the user who requested it
+	if u, err := url.Parse(cloneURL); err == nil {
+		u.User = nil
+		cloneURL = u.String()
+	}
+	var defaultBranch string
+	if repo.MainBranch != nil {
+		defaultBranch = repo.MainBranch.Name
+	}
+
+	return &base.Repository{
+		Owner:         g.workspace,
+		Name:          repo.Name,
+		IsPrivate:     repo.IsPrivate,
+		Description:   repo.Description,
+		CloneURL:      cloneURL,
+		OriginalURL:   repo.Links.HTML.Href,
+		DefaultBranch: defaultBranch,
+	}, nil
+}
+
+// GetTopics return repository topics, Bitbucket has no topics
+func (g *BitbucketDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetMilestones returns milestones
+func (g *BitbucketDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, 10)
+	err := g.getAllPages(g.repoPath()+"/milestones", func(data json.RawMessage) error {
+		var ms []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &ms); err != nil {
+			return err
+		}
+		// Bitbucket milestones neither have dates nor a state
+		for _, m := range ms {
+			milestones = append(milestones, &base.Milestone{
+				Title:   m.Name,
+				State:   "open",
+				Created: time.Now(),
+			})
+		}
+		return nil
+	})
+	if err != nil && !isBitbucketNotFound(err) {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// GetLabels returns the kinds and priorities of issues and the components of the repository as labels
+func (g *BitbucketDownloader) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, len(bitbucketIssueKinds)+len(bitbucketIssuePriorities))
+	labels = append(labels, bitbucketIssueKinds...)
+	labels = append(labels, bitbucketIssuePriorities...)
+
+	err := g.getAllPages(g.repoPath()+"/components", func(data json.RawMessage) error {
+		var components []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &components); err != nil {
+			return err
+		}
+		for _, c := range components {
+			labels = append(labels, &base.Label{Name: "component/" + c.Name, Color: bitbucketComponentColor})
+		}
+		return nil
+	})
+	if err != nil && !isBitbucketNotFound(err) {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// getAllPages requests all pages of a paginated list and passes their values to fn
+func (g *BitbucketDownloader) getAllPages(path string, fn func(json.RawMessage) error) error {
+	for page := 1; ; page++ {
+		var resp struct {
+			bitbucketPage
+			Values json.RawMessage `json:"values"`
+		}
+		if err := g.client.getJSON(path, url.Values{
+			"page":    {strconv.Itoa(page)},
+			"pagelen": {strconv.Itoa(bitbucketMaxPerPage)},
+		}, &resp); err != nil {
+			return err
+		}
+		if err := fn(resp.Values); err != nil {
+			return err
+		}
+		if len(resp.Next) == 0 {
+			return nil
+		}
+	}
+}
+
+// GetReleases returns the tags as releases, as Bitbucket has no releases
+func (g *BitbucketDownloader) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, 10)
+	err := g.getAllPages(g.repoPath()+"/refs/tags", func(data json.RawMessage) error {
+		var tags []*bitbucketTag
+		if err := json.Unmarshal(data, &tags); err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			publisher := tag.Target.Author.User.name()
+			if tag.Target.Author.User == nil {
+				publisher = strings.TrimSpace(strings.SplitN(tag.Target.Author.Raw, "<", 2)[0])
+			}
+			releases = append(releases, &base.Release{
+				TagName:         tag.Name,
+				TargetCommitish: tag.Target.Hash,
+				Name:            tag.Name,
+				Body:            strings.TrimSpace(tag.Message),
+				PublisherName:   publisher,
+				Created:         tag.Target.Date,
+				Published:       tag.Target.Date,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// GetIssues returns issues according start and limit
+func (g *BitbucketDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	if perPage > bitbucketMaxPerPage {
+		perPage = bitbucketMaxPerPage
+	}
+
+	var resp struct {
+		bitbucketPage
+		Values []*bitbucketIssue `json:"values"`
+	}
+	if err := g.client.getJSON(g.repoPath()+"/issues", url.Values{
+		"page":    {strconv.Itoa(page)},
+		"pagelen": {strconv.Itoa(perPage)},
+		"sort":    {"id"},
+	}, &resp); err != nil {
+		// Repositories without the issue tracker respond with 404
+		if isBitbucketNotFound(err) {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("error while listing issues: %v", err)
+	}
+
+	var issues = make([]*base.Issue, 0, len(resp.Values))
+	for _, issue := range resp.Values {
+		if issue.ID > g.maxIssueIndex {
+			g.maxIssueIndex = issue.ID
+		}
+		issues = append(issues, convertBitbucketIssue(issue))
+	}
+	return issues, len(resp.Next) == 0, nil
+}
+
+func convertBitbucketIssue(issue *bitbucketIssue) *base.Issue {
+	var labels = make([]*base.Label, 0, 3)
+	if len(issue.Kind) > 0 {
+		labels = append(labels, &base.Label{Name: "kind/" + issue.Kind})
+	}
+	if len(issue.Priority) > 0 {
+		labels = append(labels, &base.Label{Name: "priority/" + issue.Priority})
+	}
+	if issue.Component != nil {
+		labels = append(labels, &base.Label{Name: "component/" + issue.Component.Name, Color: bitbucketComponentColor})
+	}
+
+	var milestone string
+	if issue.Milestone != nil {
+		milestone = issue.Milestone.Name
+	}
+
+	var assignees []string
+	if issue.Assignee != nil {
+		assignees = []string{issue.Assignee.name()}
+	}
+
+	state := "open"
+	var closed *time.Time
+	switch issue.State {
+	case "resolved", "invalid", "duplicate", "wontfix", "closed":
+		state = "closed"
+		// Bitbucket does not tell when an issue has been closed
+		closed = &issue.UpdatedOn
+	}
+
+	return &base.Issue{
+		Number:     issue.ID,
+		Title:      issue.Title,
+		Content:    issue.Content.Raw,
+		PosterName: issue.Reporter.name(),
+		Milestone:  milestone,
+		State:      state,
+		Created:    issue.CreatedOn,
+		Updated:    issue.UpdatedOn,
+		Closed:     closed,
+		Labels:     labels,
+		Assignees:  assignees,
+	}
+}
+
+// getComments returns all comments of an issue or a pull request
+func (g *BitbucketDownloader) getComments(path string) ([]*bitbucketComment, error) {
+	var comments = make([]*bitbucketComment, 0, 10)
+	err := g.getAllPages(path, func(data json.RawMessage) error {
+		var cs []*bitbucketComment
+		if err := json.Unmarshal(data, &cs); err != nil {
+			return err
+		}
+		comments = append(comments, cs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedOn.Before(comments[j].CreatedOn)
+	})
+	return comments, nil
+}
+
+// GetComments returns the comments of an issue or the general comments of a pull request
+func (g *BitbucketDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	path := fmt.Sprintf("%s/issues/%d/comments", g.repoPath(), issueNumber)
+	if issueNumber > g.maxIssueIndex {
+		path = fmt.Sprintf("%s/pullrequests/%d/comments", g.repoPath(), issueNumber-g.maxIssueIndex)
+	}
+	comments, err := g.getComments(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing comments: %v", err)
+	}
+
+	var allComments = make([]*base.Comment, 0, len(comments))
+	for _, comment := range comments {
+		// Comments without content record changes of the state of issues
+		if comment.Deleted || comment.Inline != nil || len(strings.TrimSpace(comment.Content.Raw)) == 0 {
+			continue
+		}
+		allComments = append(allComments, &base.Comment{
+			IssueIndex: issueNumber,
+			PosterName: comment.User.name(),
+			Content:    comment.Content.Raw,
+			Created:    comment.CreatedOn,
+			Updated:    comment.UpdatedOn,
+		})
+	}
+	return allComments, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *BitbucketDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	if perPage > bitbucketMaxPerPage {
+		perPage = bitbucketMaxPerPage
+	}
+
+	var resp struct {
+		bitbucketPage
+		Values []*bitbucketPullRequest `json:"values"`
+	}
+	if err := g.client.getJSON(g.repoPath()+"/pullrequests", url.Values{
+		"page":    {strconv.Itoa(page)},
+		"pagelen": {strconv.Itoa(perPage)},
+		"state":   {"OPEN", "MERGED", "DECLINED", "SUPERSEDED"},
+		"sort":    {"id"},
+	}, &resp); err != nil {
+		return nil, false, fmt.Errorf("error while listing pull requests: %v", err)
+	}
+
+	var prs = make([]*base.PullRequest, 0, len(resp.Values))
+	for _, pr := range resp.Values {
+		converted, err := g.convertPullRequest(pr)
+		if err != nil {
+			return nil, false, err
+		}
+		prs = append(prs, converted)
+	}
+	return prs, len(resp.Next) == 0, nil
+}
+
+func (g *BitbucketDownloader) convertPullRequest(pr *bitbucketPullRequest) (*base.PullRequest, error) {
+	head, err := g.convertPullRequestEndpoint(&pr.Source)
+	if err != nil {
+		return nil, err
+	}
+	baseBranch, err := g.convertPullRequestEndpoint(&pr.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		state          = "open"
+		closed         *time.Time
+		merged         bool
+		mergedTime     *time.Time
+		mergeCommitSHA string
+	)
+	if pr.State != "OPEN" {
+		state = "closed"
+		// Bitbucket does not tell when a pull request has been closed
+		closed = &pr.UpdatedOn
+	}
+	if pr.State == "MERGED" {
+		merged = true
+		mergedTime = &pr.UpdatedOn
+		if pr.MergeCommit != nil {
+			if mergeCommitSHA, err = g.fullHash(pr.MergeCommit.Hash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &base.PullRequest{
+		Number:         g.maxIssueIndex + pr.ID,
+		OriginalNumber: pr.ID,
+		Title:          pr.Title,
+		PosterName:     pr.Author.name(),
+		Content:        pr.Description,
+		State:          state,
+		Created:        pr.CreatedOn,
+		Updated:        pr.UpdatedOn,
+		Closed:         closed,
+		Merged:         merged,
+		MergedTime:     mergedTime,
+		MergeCommitSHA: mergeCommitSHA,
+		Head:           head,
+		Base:           baseBranch,
+		PatchURL:       g.client.downloadURL(fmt.Sprintf("%s/pullrequests/%d/patch", g.repoPath(), pr.ID)),
+	}, nil
+}
+
+func (g *BitbucketDownloader) convertPullRequestEndpoint(endpoint *bitbucketPullRequestEndpoint) (base.PullRequestBranch, error) {
+	branch := base.PullRequestBranch{
+		Ref:       endpoint.Branch.Name,
+		OwnerName: g.workspace,
+		RepoName:  g.repoSlug,
+	}
+	// The repository of the source of a pull request from a deleted fork is unknown
+	if endpoint.Repository != nil {
+		if fields := strings.SplitN(endpoint.Repository.FullName, "/", 2); len(fields) == 2 {
+			branch.OwnerName, branch.RepoName = fields[0], fields[1]
+		}
+		branch.CloneURL = endpoint.Repository.Links.HTML.Href + ".git"
+	}
+	if endpoint.Commit != nil {
+		sha, err := g.fullHash(endpoint.Commit.Hash)
+		if err != nil {
+			return branch, err
+		}
+		branch.SHA = sha
+	}
+	return branch, nil
+}
+
+// GetReviews returns the approvals and the inline comments of a pull request, the inline comments of
+// every user are gathered in one review
+func (g *BitbucketDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var pr bitbucketPullRequest
+	if err := g.client.getJSON(fmt.Sprintf("%s/pullrequests/%d", g.repoPath(), pullRequestNumber), nil, &pr); err != nil {
+		return nil, fmt.Errorf("error while getting pull request: %v", err)
+	}
+	var commitID string
+	if pr.Source.Commit != nil {
+		var err error
+		if commitID, err = g.fullHash(pr.Source.Commit.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	var reviews = make([]*base.Review, 0, len(pr.Participants))
+	for _, participant := range pr.Participants {
+		var state string
+		switch {
+		case participant.Approved || participant.State == "approved":
+			state = base.ReviewStateApproved
+		case participant.State == "changes_requested":
+			state = base.ReviewStateChangesRequested
+		default:
+			continue
+		}
+		created := pr.UpdatedOn
+		if participant.ParticipatedOn != nil {
+			created = *participant.ParticipatedOn
+		}
+		reviews = append(reviews, &base.Review{
+			IssueIndex:   pullRequestNumber,
+			ReviewerName: participant.User.name(),
+			Official:     participant.Role == "REVIEWER",
+			CommitID:     commitID,
+			CreatedAt:    created,
+			State:        state,
+		})
+	}
+
+	comments, err := g.getComments(fmt.Sprintf("%s/pullrequests/%d/comments", g.repoPath(), pullRequestNumber))
+	if err != nil {
+		return nil, fmt.Errorf("error while listing comments: %v", err)
+	}
+	commentReviews := make(map[string]*base.Review)
+	for _, comment := range comments {
+		if comment.Deleted || comment.Inline == nil {
+			continue
+		}
+		var line int
+		if comment.Inline.To != nil {
+			line = *comment.Inline.To
+		} else if comment.Inline.From != nil {
+			line = -*comment.Inline.From
+		}
+		var inReplyTo int64
+		if comment.Parent != nil {
+			inReplyTo = comment.Parent.ID
+		}
+
+		reviewer := comment.User.name()
+		review, ok := commentReviews[reviewer]
+		if !ok {
+			review = &base.Review{
+				IssueIndex:   pullRequestNumber,
+				ReviewerName: reviewer,
+				CommitID:     commitID,
+				CreatedAt:    comment.CreatedOn,
+				State:        base.ReviewStateCommented,
+			}
+			commentReviews[reviewer] = review
+			reviews = append(reviews, review)
+		}
+		review.Comments = append(review.Comments, &base.ReviewComment{
+			ID:        comment.ID,
+			InReplyTo: inReplyTo,
+			Content:   comment.Content.Raw,
+			TreePath:  comment.Inline.Path,
+			Line:      line,
+			CommitID:  commitID,
+			CreatedAt: comment.CreatedOn,
+			UpdatedAt: comment.UpdatedOn,
+		})
+	}
+	return reviews, nil
+}
+
+// FormatCloneURL add authentification into remote URLs
+func (g *BitbucketDownloader) FormatCloneURL(opts MigrateOptions, remoteAddr string) (string, error) {
+	return g.client.formatCloneURL(remoteAddr)
+}
@@ -0,0 +1,524 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+)
+
+var (
+	_ base.Downloader = &BitbucketServerDownloader{}
+)
+
+// parseBitbucketServerURL splits an URL of a Bitbucket Server repository, either its clone URL
+// (/scm/{project}/{repo}.git) or its web URL (/projects/{project}/repos/{repo}), into the base URL
+// of the server, the project key and the repository slug
+func parseBitbucketServerURL(u *url.URL) (baseURL, projectKey, repoSlug string, err error) {
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(fields); i++ {
+		var prefix []string
+		switch {
+		case fields[i] == "scm" && i+2 < len(fields):
+			prefix = fields[:i]
+			projectKey, repoSlug = fields[i+1], strings.TrimSuffix(fields[i+2], ".git")
+		case fields[i] == "projects" && i+3 < len(fields) && fields[i+2] == "repos":
+			prefix = fields[:i]
+			projectKey, repoSlug = fields[i+1], fields[i+3]
+		case fields[i] == "users" && i+3 < len(fields) && fields[i+2] == "repos":
+			// personal repositories are addressed by the user slug prefixed with a tilde
+			prefix = fields[:i]
+			projectKey, repoSlug = "~"+fields[i+1], fields[i+3]
+		default:
+			continue
+		}
+		serverURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + strings.Join(prefix, "/")}
+		return strings.TrimSuffix(serverURL.String(), "/"), projectKey, repoSlug, nil
+	}
+	return "", "", "", fmt.Errorf("invalid path: %s", u.Path)
+}
+
+// bitbucketServerTime converts the milliseconds since epoch used by Bitbucket Server
+func bitbucketServerTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
+
+type bitbucketServerPage struct {
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+type bitbucketServerUser struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+	Slug         string `json:"slug"`
+}
+
+// name returns the name a user is shown with in Gitea
+func (u *bitbucketServerUser) name() string {
+	if u == nil {
+		return "Ghost"
+	}
+	return u.Name
+}
+
+type bitbucketServerLinks struct {
+	Clone []struct {
+		Href string `json:"href"`
+		Name string `json:"name"`
+	} `json:"clone"`
+	Self []struct {
+		Href string `json:"href"`
+	} `json:"self"`
+}
+
+// httpCloneURL returns the http(s) clone URL without the name of the user who requested it
+func (links *bitbucketServerLinks) httpCloneURL() string {
+	for _, link := range links.Clone {
+		if link.Name != "http" && link.Name != "https" {
+			continue
+		}
+		u, err := url.Parse(link.Href)
+		if err != nil {
+			return link.Href
+		}
+		u.User = nil
+		return u.String()
+	}
+	return ""
+}
+
+type bitbucketServerRepository struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+	Project     struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links bitbucketServerLinks `json:"links"`
+}
+
+type bitbucketServerRef struct {
+	ID           string                     `json:"id"`
+	DisplayID    string                     `json:"displayId"`
+	LatestCommit string                     `json:"latestCommit"`
+	Repository   *bitbucketServerRepository `json:"repository"`
+}
+
+type bitbucketServerPullRequest struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	CreatedDate int64  `json:"createdDate"`
+	UpdatedDate int64  `json:"updatedDate"`
+	ClosedDate  int64  `json:"closedDate"`
+	Author      struct {
+		User *bitbucketServerUser `json:"user"`
+	} `json:"author"`
+	FromRef bitbucketServerRef `json:"fromRef"`
+	ToRef   bitbucketServerRef `json:"toRef"`
+}
+
+type bitbucketServerComment struct {
+	ID          int64                     `json:"id"`
+	Text        string                    `json:"text"`
+	Author      *bitbucketServerUser      `json:"author"`
+	CreatedDate int64                     `json:"createdDate"`
+	UpdatedDate int64                     `json:"updatedDate"`
+	Comments    []*bitbucketServerComment `json:"comments"`
+}
+
+type bitbucketServerActivity struct {
+	ID            int64                   `json:"id"`
+	CreatedDate   int64                   `json:"createdDate"`
+	User          *bitbucketServerUser    `json:"user"`
+	Action        string                  `json:"action"`
+	CommentAction string                  `json:"commentAction"`
+	Comment       *bitbucketServerComment `json:"comment"`
+	CommentAnchor *struct {
+		Line     int    `json:"line"`
+		LineType string `json:"lineType"`
+		FileType string `json:"fileType"`
+		Path     string `json:"path"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+	} `json:"commentAnchor"`
+	Commit *struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+type bitbucketServerTag struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+type bitbucketServerCommit struct {
+	ID              string               `json:"id"`
+	Message         string               `json:"message"`
+	Author          *bitbucketServerUser `json:"author"`
+	AuthorTimestamp int64                `json:"authorTimestamp"`
+}
+
+// BitbucketServerDownloader implements a Downloader interface to get repository information from a
+// Bitbucket Server. Bitbucket Server uses Jira for issues, so only pull requests and tags are migrated.
+type BitbucketServerDownloader struct {
+	base.NullDownloader
+	client     *bitbucketClient
+	projectKey string
+	repoSlug   string
+}
+
+// NewBitbucketServerDownloader creates a Bitbucket Server downloader
+func NewBitbucketServerDownloader(ctx context.Context, baseURL, userName, password, token, projectKey, repoSlug string) *BitbucketServerDownloader {
+	return &BitbucketServerDownloader{
+		client: &bitbucketClient{
+			ctx:      ctx,
+			client:   &http.Client{},
+			baseURL:  strings.TrimSuffix(baseURL, "/"),
+			userName: userName,
+			password: password,
+			token:    token,
+		},
+		projectKey: projectKey,
+		repoSlug:   repoSlug,
+	}
+}
+
+// SetContext set context
+func (g *BitbucketServerDownloader) SetContext(ctx context.Context) {
+	g.client.ctx = ctx
+}
+
+func (g *BitbucketServerDownloader) repoPath() string {
+	return fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s", url.PathEscape(g.projectKey), url.PathEscape(g.repoSlug))
+}
+
+// getPage requests one page of a paginated list
+func (g *BitbucketServerDownloader) getPage(path string, query url.Values, page, perPage int, v interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("start", strconv.Itoa((page-1)*perPage))
+	query.Set("limit", strconv.Itoa(perPage))
+	return g.client.getJSON(path, query, v)
+}
+
+// GetRepoInfo returns a repository information
+func (g *BitbucketServerDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo bitbucketServerRepository
+	if err := g.client.getJSON(g.repoPath(), nil, &repo); err != nil {
+		return nil, err
+	}
+
+	// empty repositories have no default branch
+	var defaultBranch bitbucketServerRef
+	if err := g.client.getJSON(g.repoPath()+"/branches/default", nil, &defaultBranch); err != nil && !isBitbucketNotFound(err) {
+		return nil, err
+	}
+
+	var originalURL string
+	if len(repo.Links.Self) > 0 {
+		originalURL = strings.TrimSuffix(repo.Links.Self[0].Href, "/browse")
+	}
+
+	return &base.Repository{
+		Owner:         repo.Project.Key,
+		Name:          repo.Name,
+		IsPrivate:     !repo.Public,
+		Description:   repo.Description,
+		CloneURL:      repo.Links.httpCloneURL(),
+		OriginalURL:   originalURL,
+		DefaultBranch: defaultBranch.DisplayID,
+	}, nil
+}
+
+// GetTopics return repository topics, Bitbucket Server has no topics
+func (g *BitbucketServerDownloader) GetTopics() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetReleases returns the tags as releases, as Bitbucket Server has no releases
+func (g *BitbucketServerDownloader) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, 10)
+	for page := 1; ; page++ {
+		var resp struct {
+			bitbucketServerPage
+			Values []*bitbucketServerTag `json:"values"`
+		}
+		if err := g.getPage(g.repoPath()+"/tags", nil, page, bitbucketMaxPerPage, &resp); err != nil {
+			return nil, err
+		}
+		for _, tag := range resp.Values {
+			var commit bitbucketServerCommit
+			if err := g.client.getJSON(g.repoPath()+"/commits/"+url.PathEscape(tag.LatestCommit), nil, &commit); err != nil {
+				return nil, err
+			}
+			created := bitbucketServerTime(commit.AuthorTimestamp)
+			releases = append(releases, &base.Release{
+				TagName:         tag.DisplayID,
+				TargetCommitish: tag.LatestCommit,
+				Name:            tag.DisplayID,
+				Body:            strings.TrimSpace(commit.Message),
+				PublisherName:   commit.Author.name(),
+				PublisherEmail:  commit.Author.EmailAddress,
+				Created:         created,
+				Published:       created,
+			})
+		}
+		if resp.IsLastPage {
+			return releases, nil
+		}
+	}
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *BitbucketServerDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var resp struct {
+		bitbucketServerPage
+		Values []*bitbucketServerPullRequest `json:"values"`
+	}
+	if err := g.getPage(g.repoPath()+"/pull-requests", url.Values{
+		"state": {"ALL"},
+		"order": {"OLDEST"},
+	}, page, perPage, &resp); err != nil {
+		return nil, false, fmt.Errorf("error while listing pull requests: %v", err)
+	}
+
+	var prs = make([]*base.PullRequest, 0, len(resp.Values))
+	for _, pr := range resp.Values {
+		converted, err := g.convertPullRequest(pr)
+		if err != nil {
+			return nil, false, err
+		}
+		prs = append(prs, converted)
+	}
+	return prs, resp.IsLastPage, nil
+}
+
+func (g *BitbucketServerDownloader) convertPullRequest(pr *bitbucketServerPullRequest) (*base.PullRequest, error) {
+	var (
+		state          = "open"
+		closed         *time.Time
+		merged         bool
+		mergedTime     *time.Time
+		mergeCommitSHA string
+	)
+	if pr.State != "OPEN" {
+		state = "closed"
+		closedTime := bitbucketServerTime(pr.UpdatedDate)
+		if pr.ClosedDate > 0 {
+			closedTime = bitbucketServerTime(pr.ClosedDate)
+		}
+		closed = &closedTime
+	}
+	if pr.State == "MERGED" {
+		merged = true
+		mergedTime = closed
+
+		activities, err := g.getActivities(pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, activity := range activities {
+			if activity.Action == "MERGED" && activity.Commit != nil {
+				mergeCommitSHA = activity.Commit.ID
+			}
+		}
+	}
+
+	return &base.PullRequest{
+		Number:         pr.ID,
+		Title:          pr.Title,
+		PosterName:     pr.Author.User.name(),
+		PosterEmail:    pr.Author.User.emailAddress(),
+		Content:        pr.Description,
+		State:          state,
+		Created:        bitbucketServerTime(pr.CreatedDate),
+		Updated:        bitbucketServerTime(pr.UpdatedDate),
+		Closed:         closed,
+		Merged:         merged,
+		MergedTime:     mergedTime,
+		MergeCommitSHA: mergeCommitSHA,
+		Head:           g.convertRef(&pr.FromRef),
+		Base:           g.convertRef(&pr.ToRef),
+		PatchURL:       g.client.downloadURL(fmt.Sprintf("%s/pull-requests/%d.diff", g.repoPath(), pr.ID)),
+	}, nil
+}
+
+// emailAddress returns the email address of a user if it is visible
+func (u *bitbucketServerUser) emailAddress() string {
+	if u == nil {
+		return ""
+	}
+	return u.EmailAddress
+}
+
+func (g *BitbucketServerDownloader) convertRef(ref *bitbucketServerRef) base.PullRequestBranch {
+	branch := base.PullRequestBranch{
+		Ref:       ref.DisplayID,
+		SHA:       ref.LatestCommit,
+		OwnerName: g.projectKey,
+		RepoName:  g.repoSlug,
+	}
+	if ref.Repository != nil {
+		branch.OwnerName = ref.Repository.Project.Key
+		branch.RepoName = ref.Repository.Slug
+		branch.CloneURL = ref.Repository.Links.httpCloneURL()
+	}
+	return branch
+}
+
+// getActivities returns the activities of a pull request from the oldest to the newest
+func (g *BitbucketServerDownloader) getActivities(prID int64) ([]*bitbucketServerActivity, error) {
+	var activities = make([]*bitbucketServerActivity, 0, 10)
+	for page := 1; ; page++ {
+		var resp struct {
+			bitbucketServerPage
+			Values []*bitbucketServerActivity `json:"values"`
+		}
+		if err := g.getPage(fmt.Sprintf("%s/pull-requests/%d/activities", g.repoPath(), prID), nil, page, bitbucketMaxPerPage, &resp); err != nil {
+			return nil, fmt.Errorf("error while listing activities: %v", err)
+		}
+		activities = append(activities, resp.Values...)
+		if resp.IsLastPage {
+			break
+		}
+	}
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].CreatedDate < activities[j].CreatedDate
+	})
+	return activities, nil
+}
+
+// flattenBitbucketServerComments returns a comment followed by all its replies
+func flattenBitbucketServerComments(comment *bitbucketServerComment, inReplyTo int64, fn func(comment *bitbucketServerComment, inReplyTo int64)) {
+	fn(comment, inReplyTo)
+	for _, reply := range comment.Comments {
+		flattenBitbucketServerComments(reply, comment.ID, fn)
+	}
+}
+
+// GetComments returns the general comments of a pull request
+func (g *BitbucketServerDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	activities, err := g.getActivities(issueNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments = make([]*base.Comment, 0, len(activities))
+	for _, activity := range activities {
+		if activity.Action != "COMMENTED" || activity.CommentAction != "ADDED" || activity.Comment == nil || activity.CommentAnchor != nil {
+			continue
+		}
+		flattenBitbucketServerComments(activity.Comment, 0, func(comment *bitbucketServerComment, _ int64) {
+			comments = append(comments, &base.Comment{
+				IssueIndex:  issueNumber,
+				PosterName:  comment.Author.name(),
+				PosterEmail: comment.Author.emailAddress(),
+				Content:     comment.Text,
+				Created:     bitbucketServerTime(comment.CreatedDate),
+				Updated:     bitbucketServerTime(comment.UpdatedDate),
+			})
+		})
+	}
+	return comments, nil
+}
+
+// GetReviews returns the approvals and the inline comments of a pull request, the inline comments of
+// every user are gathered in one review
+func (g *BitbucketServerDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	activities, err := g.getActivities(pullRequestNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		reviews        = make([]*base.Review, 0, 10)
+		verdicts       = make(map[string]*base.Review)
+		commentReviews = make(map[string]*base.Review)
+	)
+	for _, activity := range activities {
+		reviewer := activity.User.name()
+		switch activity.Action {
+		case "APPROVED", "REVIEWED":
+			// only the latest verdict of every reviewer is kept
+			state := base.ReviewStateApproved
+			if activity.Action == "REVIEWED" {
+				state = base.ReviewStateChangesRequested
+			}
+			verdicts[reviewer] = &base.Review{
+				IssueIndex:   pullRequestNumber,
+				ReviewerName: reviewer,
+				Official:     true,
+				CreatedAt:    bitbucketServerTime(activity.CreatedDate),
+				State:        state,
+			}
+		case "UNAPPROVED":
+			delete(verdicts, reviewer)
+		case "COMMENTED":
+			if activity.CommentAction != "ADDED" || activity.Comment == nil || activity.CommentAnchor == nil {
+				continue
+			}
+			anchor := activity.CommentAnchor
+			line := anchor.Line
+			if anchor.FileType == "FROM" {
+				line = -line
+			}
+
+			flattenBitbucketServerComments(activity.Comment, 0, func(comment *bitbucketServerComment, inReplyTo int64) {
+				poster := comment.Author.name()
+				review, ok := commentReviews[poster]
+				if !ok {
+					review = &base.Review{
+						IssueIndex:   pullRequestNumber,
+						ReviewerName: poster,
+						CommitID:     anchor.ToHash,
+						CreatedAt:    bitbucketServerTime(comment.CreatedDate),
+						State:        base.ReviewStateCommented,
+					}
+					commentReviews[poster] = review
+					reviews = append(reviews, review)
+				}
+				review.Comments = append(review.Comments, &base.ReviewComment{
+					ID:        comment.ID,
+					InReplyTo: inReplyTo,
+					Content:   comment.Text,
+					TreePath:  anchor.Path,
+					Line:      line,
+					CommitID:  anchor.ToHash,
+					CreatedAt: bitbucketServerTime(comment.CreatedDate),
+					UpdatedAt: bitbucketServerTime(comment.UpdatedDate),
+				})
+			})
+		}
+	}
+
+	var reviewers = make([]string, 0, len(verdicts))
+	for reviewer := range verdicts {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(reviewers)
+	for _, reviewer := range reviewers {
+		reviews = append(reviews, verdicts[reviewer])
+	}
+	return reviews, nil
+}
+
+// FormatCloneURL add authentification into remote URLs
+func (g *BitbucketServerDownloader) FormatCloneURL(opts MigrateOptions, remoteAddr string) (string, error) {
+	return g.client.formatCloneURL(remoteAddr)
+}
@@ -0,0 +1,430 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newBitbucketFixtureServer serves the recorded responses in testdata/bitbucket/{kind},
+// the response to a request of /a/b is stored in a/b.json
+func newBitbucketFixtureServer(t *testing.T, kind string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "gitea-bot" || password != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "bitbucket", kind, filepath.FromSlash(r.URL.Path)+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+}
+
+func bitbucketTime(t *testing.T, value string) time.Time {
+	tm, err := time.Parse(time.RFC3339, value)
+	assert.NoError(t, err)
+	return tm
+}
+
+func TestBitbucketDownloaderFactory(t *testing.T) {
+	for _, c := range []struct {
+		cloneAddr  string
+		cloud      bool
+		baseURL    string
+		projectKey string
+		repoSlug   string
+	}{
+		{"https://bitbucket.org/gitea/test_repo.git", true, bitbucketCloudAPIURL, "gitea", "test_repo"},
+		{"https://bitbucket.org/gitea/test_repo/src/master/", true, bitbucketCloudAPIURL, "gitea", "test_repo"},
+		{"https://bitbucket.example.com/scm/test/test_repo.git", false, "https://bitbucket.example.com", "test", "test_repo"},
+		{"https://example.com/bitbucket/scm/~lunny/test_repo.git", false, "https://example.com/bitbucket", "~lunny", "test_repo"},
+		{"https://bitbucket.example.com/projects/TEST/repos/test_repo/browse", false, "https://bitbucket.example.com", "TEST", "test_repo"},
+		{"https://bitbucket.example.com/users/lunny/repos/test_repo", false, "https://bitbucket.example.com", "~lunny", "test_repo"},
+	} {
+		downloader, err := (&BitbucketDownloaderFactory{}).New(context.Background(), base.MigrateOptions{CloneAddr: c.cloneAddr})
+		assert.NoError(t, err, c.cloneAddr)
+		if c.cloud {
+			d, ok := downloader.(*BitbucketDownloader)
+			if assert.True(t, ok, c.cloneAddr) {
+				assert.EqualValues(t, c.baseURL, d.client.baseURL)
+				assert.EqualValues(t, c.projectKey, d.workspace)
+				assert.EqualValues(t, c.repoSlug, d.repoSlug)
+			}
+		} else {
+			d, ok := downloader.(*BitbucketServerDownloader)
+			if assert.True(t, ok, c.cloneAddr) {
+				assert.EqualValues(t, c.baseURL, d.client.baseURL)
+				assert.EqualValues(t, c.projectKey, d.projectKey)
+				assert.EqualValues(t, c.repoSlug, d.repoSlug)
+			}
+		}
+	}
+
+	_, err := (&BitbucketDownloaderFactory{}).New(context.Background(), base.MigrateOptions{CloneAddr: "https://bitbucket.example.com/test_repo.git"})
+	assert.Error(t, err)
+}
+
+func TestBitbucketDownloadRepo(t *testing.T) {
+	server := newBitbucketFixtureServer(t, "cloud")
+	defer server.Close()
+
+	downloader := NewBitbucketDownloader(context.Background(), server.URL, "gitea-bot", "app-password", "", "gitea", "test_repo")
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &base.Repository{
+		Name:          "test_repo",
+		Owner:         "gitea",
+		Description:   "Test repository for testing migration from Bitbucket to Gitea",
+		CloneURL:      "https://bitbucket.org/gitea/test_repo.git",
+		OriginalURL:   "https://bitbucket.org/gitea/test_repo",
+		DefaultBranch: "master",
+	}, repo)
+
+	topics, err := downloader.GetTopics()
+	assert.NoError(t, err)
+	assert.Empty(t, topics)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	if assert.Len(t, milestones, 2) {
+		assert.EqualValues(t, "1.0.0", milestones[0].Title)
+		assert.EqualValues(t, "open", milestones[0].State)
+		assert.EqualValues(t, "1.1.0", milestones[1].Title)
+	}
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	assert.Len(t, labels, len(bitbucketIssueKinds)+len(bitbucketIssuePriorities)+1)
+	assert.EqualValues(t, &base.Label{Name: "component/api", Color: bitbucketComponentColor}, labels[len(labels)-1])
+
+	releases, err := downloader.GetReleases()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Release{
+		{
+			TagName:         "v1.0.0",
+			TargetCommitish: "b0a9fd6c57d3d3d4e8c0b3c1c8b5c4d9a1e2f3a4",
+			Name:            "v1.0.0",
+			Body:            "First release",
+			PublisherName:   "gitea-bot",
+			Created:         bitbucketTime(t, "2021-04-10T14:00:00+00:00"),
+			Published:       bitbucketTime(t, "2021-04-10T14:00:00+00:00"),
+		},
+	}, releases)
+
+	issues, isEnd, err := downloader.GetIssues(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	closed := bitbucketTime(t, "2021-04-05T09:00:00+00:00")
+	assert.EqualValues(t, []*base.Issue{
+		{
+			Number:     1,
+			Title:      "Please add an animated gif icon to the merge button",
+			Content:    "I just want the merge button to hurt my eyes a little.",
+			PosterName: "gitea-bot",
+			Milestone:  "1.0.0",
+			State:      "open",
+			Created:    bitbucketTime(t, "2021-04-02T10:00:00+00:00"),
+			Updated:    bitbucketTime(t, "2021-04-03T11:30:00+00:00"),
+			Labels: []*base.Label{
+				{Name: "kind/enhancement"},
+				{Name: "priority/minor"},
+				{Name: "component/api", Color: bitbucketComponentColor},
+			},
+			Assignees: []string{"lunny"},
+		},
+		{
+			Number:     2,
+			Title:      "Test issue",
+			Content:    "This is test issue 2, do not touch!",
+			PosterName: "Ghost",
+			State:      "closed",
+			Created:    bitbucketTime(t, "2021-04-04T08:00:00+00:00"),
+			Updated:    closed,
+			Closed:     &closed,
+			Labels: []*base.Label{
+				{Name: "kind/bug"},
+				{Name: "priority/major"},
+			},
+		},
+	}, issues)
+
+	comments, err := downloader.GetComments(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Comment{
+		{
+			IssueIndex: 1,
+			PosterName: "lunny",
+			Content:    "This is a comment",
+			Created:    bitbucketTime(t, "2021-04-02T12:05:00+00:00"),
+			Updated:    bitbucketTime(t, "2021-04-02T12:06:00+00:00"),
+		},
+	}, comments)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	mergedTime := bitbucketTime(t, "2021-04-08T17:20:00+00:00")
+	patchURL, _ := url.Parse(server.URL + "/repositories/gitea/test_repo/pullrequests/1/patch")
+	patchURL.User = url.UserPassword("gitea-bot", "app-password")
+	if assert.Len(t, prs, 2) {
+		assert.EqualValues(t, &base.PullRequest{
+			Number:         3,
+			OriginalNumber: 1,
+			Title:          "Update README.md",
+			Content:        "add warning to readme",
+			PosterName:     "gitea-bot",
+			State:          "closed",
+			Created:        bitbucketTime(t, "2021-04-06T10:00:00+00:00"),
+			Updated:        mergedTime,
+			Closed:         &mergedTime,
+			Merged:         true,
+			MergedTime:     &mergedTime,
+			MergeCommitSHA: "9f733b96b98a4c6c5b4e1d2a0f3c8b7e6d5a4c3b",
+			PatchURL:       patchURL.String(),
+			Head: base.PullRequestBranch{
+				CloneURL:  "https://bitbucket.org/gitea/test_repo.git",
+				Ref:       "readme-update",
+				SHA:       "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+				RepoName:  "test_repo",
+				OwnerName: "gitea",
+			},
+			Base: base.PullRequestBranch{
+				CloneURL:  "https://bitbucket.org/gitea/test_repo.git",
+				Ref:       "master",
+				SHA:       "f32b0a9dfd09ec2a4e3b7f8d2d6a4c1c3b9e7a5f",
+				RepoName:  "test_repo",
+				OwnerName: "gitea",
+			},
+		}, prs[0])
+
+		assert.EqualValues(t, 4, prs[1].Number)
+		assert.EqualValues(t, "open", prs[1].State)
+		assert.True(t, prs[1].IsForkPullRequest())
+		assert.EqualValues(t, "lunny", prs[1].Head.OwnerName)
+		assert.EqualValues(t, "https://bitbucket.org/lunny/test_repo.git", prs[1].Head.CloneURL)
+		assert.EqualValues(t, "565e1208f5fe4d3c2b1a0e9f8d7c6b5a4e3d2c1b", prs[1].Head.SHA)
+	}
+
+	comments, err = downloader.GetComments(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Comment{
+		{
+			IssueIndex: 3,
+			PosterName: "lunny",
+			Content:    "Looks good to me",
+			Created:    bitbucketTime(t, "2021-04-08T17:00:00+00:00"),
+			Updated:    bitbucketTime(t, "2021-04-08T17:00:00+00:00"),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Review{
+		{
+			IssueIndex:   1,
+			ReviewerName: "lunny",
+			Official:     true,
+			CommitID:     "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+			CreatedAt:    bitbucketTime(t, "2021-04-08T17:00:00+00:00"),
+			State:        base.ReviewStateApproved,
+		},
+		{
+			IssueIndex:   1,
+			ReviewerName: "lunny",
+			CommitID:     "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+			CreatedAt:    bitbucketTime(t, "2021-04-07T11:00:00+00:00"),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        215501,
+					Content:   "Please use a warning box here",
+					TreePath:  "README.md",
+					Line:      4,
+					CommitID:  "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+					CreatedAt: bitbucketTime(t, "2021-04-07T11:00:00+00:00"),
+					UpdatedAt: bitbucketTime(t, "2021-04-07T11:00:00+00:00"),
+				},
+			},
+		},
+		{
+			IssueIndex:   1,
+			ReviewerName: "gitea-bot",
+			CommitID:     "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+			CreatedAt:    bitbucketTime(t, "2021-04-07T11:10:00+00:00"),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        215502,
+					InReplyTo: 215501,
+					Content:   "Done",
+					TreePath:  "README.md",
+					Line:      4,
+					CommitID:  "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+					CreatedAt: bitbucketTime(t, "2021-04-07T11:10:00+00:00"),
+					UpdatedAt: bitbucketTime(t, "2021-04-07T11:10:00+00:00"),
+				},
+			},
+		},
+	}, reviews)
+}
+
+func TestBitbucketServerDownloadRepo(t *testing.T) {
+	server := newBitbucketFixtureServer(t, "server")
+	defer server.Close()
+
+	downloader := NewBitbucketServerDownloader(context.Background(), server.URL, "gitea-bot", "app-password", "", "TEST", "test_repo")
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &base.Repository{
+		Name:          "test_repo",
+		Owner:         "TEST",
+		IsPrivate:     true,
+		Description:   "Test repository for testing migration from Bitbucket Server to Gitea",
+		CloneURL:      "https://bitbucket.example.com/scm/test/test_repo.git",
+		OriginalURL:   "https://bitbucket.example.com/projects/TEST/repos/test_repo",
+		DefaultBranch: "master",
+	}, repo)
+
+	_, err = downloader.GetMilestones()
+	assert.True(t, base.IsErrNotSupported(err))
+	_, _, err = downloader.GetIssues(1, 100)
+	assert.True(t, base.IsErrNotSupported(err))
+
+	releases, err := downloader.GetReleases()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Release{
+		{
+			TagName:         "v1.0.0",
+			TargetCommitish: "b0a9fd6c57d3d3d4e8c0b3c1c8b5c4d9a1e2f3a4",
+			Name:            "v1.0.0",
+			Body:            "First release",
+			PublisherName:   "gitea-bot",
+			PublisherEmail:  "bot@gitea.io",
+			Created:         time.Unix(1618063200, 0),
+			Published:       time.Unix(1618063200, 0),
+		},
+	}, releases)
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	mergedTime := time.Unix(1617902400, 0)
+	patchURL, _ := url.Parse(server.URL + "/rest/api/1.0/projects/TEST/repos/test_repo/pull-requests/1.diff")
+	patchURL.User = url.UserPassword("gitea-bot", "app-password")
+	if assert.Len(t, prs, 2) {
+		assert.EqualValues(t, &base.PullRequest{
+			Number:         1,
+			Title:          "Update README.md",
+			Content:        "add warning to readme",
+			PosterName:     "gitea-bot",
+			PosterEmail:    "bot@gitea.io",
+			State:          "closed",
+			Created:        time.Unix(1617703200, 0),
+			Updated:        mergedTime,
+			Closed:         &mergedTime,
+			Merged:         true,
+			MergedTime:     &mergedTime,
+			MergeCommitSHA: "9f733b96b98a4c6c5b4e1d2a0f3c8b7e6d5a4c3b",
+			PatchURL:       patchURL.String(),
+			Head: base.PullRequestBranch{
+				CloneURL:  "https://bitbucket.example.com/scm/test/test_repo.git",
+				Ref:       "readme-update",
+				SHA:       "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+				RepoName:  "test_repo",
+				OwnerName: "TEST",
+			},
+			Base: base.PullRequestBranch{
+				CloneURL:  "https://bitbucket.example.com/scm/test/test_repo.git",
+				Ref:       "master",
+				SHA:       "f32b0a9dfd09ec2a4e3b7f8d2d6a4c1c3b9e7a5f",
+				RepoName:  "test_repo",
+				OwnerName: "TEST",
+			},
+		}, prs[0])
+
+		assert.EqualValues(t, 2, prs[1].Number)
+		assert.EqualValues(t, "closed", prs[1].State)
+		assert.False(t, prs[1].Merged)
+		assert.EqualValues(t, "~LUNNY", prs[1].Head.OwnerName)
+	}
+
+	comments, err := downloader.GetComments(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Comment{
+		{
+			IssueIndex:  1,
+			PosterName:  "lunny",
+			PosterEmail: "xiaolunwen@gmail.com",
+			Content:     "Looks good to me",
+			Created:     time.Unix(1617901000, 0),
+			Updated:     time.Unix(1617901000, 0),
+		},
+	}, comments)
+
+	reviews, err := downloader.GetReviews(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Review{
+		{
+			IssueIndex:   1,
+			ReviewerName: "lunny",
+			CommitID:     "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+			CreatedAt:    time.Unix(1617793000, 0),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        51,
+					Content:   "Please use a warning box here",
+					TreePath:  "README.md",
+					Line:      4,
+					CommitID:  "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+					CreatedAt: time.Unix(1617793000, 0),
+					UpdatedAt: time.Unix(1617793000, 0),
+				},
+			},
+		},
+		{
+			IssueIndex:   1,
+			ReviewerName: "gitea-bot",
+			CommitID:     "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+			CreatedAt:    time.Unix(1617793600, 0),
+			State:        base.ReviewStateCommented,
+			Comments: []*base.ReviewComment{
+				{
+					ID:        52,
+					InReplyTo: 51,
+					Content:   "Done",
+					TreePath:  "README.md",
+					Line:      4,
+					CommitID:  "2be9101c543e2b9e5b2b5e8c3d3f1b7a0a9c6d4e",
+					CreatedAt: time.Unix(1617793600, 0),
+					UpdatedAt: time.Unix(1617793600, 0),
+				},
+			},
+		},
+		{
+			IssueIndex:   1,
+			ReviewerName: "lunny",
+			Official:     true,
+			CreatedAt:    time.Unix(1617901200, 0),
+			State:        base.ReviewStateApproved,
+		},
+	}, reviews)
+}
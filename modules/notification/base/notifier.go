@@ -58,5 +58,7 @@ type Notifier interface {
 	NotifySyncCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 	NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string)
 
+	NotifyCreateCommitStatus(doer *models.User, repo *models.Repository, sha string, status *models.CommitStatus)
+
 	NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository)
 }
@@ -167,6 +167,10 @@ func (*NullNotifier) NotifySyncCreateRef(doer *models.User, repo *models.Reposit
 func (*NullNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
 }
 
+// NotifyCreateCommitStatus places a place holder function
+func (*NullNotifier) NotifyCreateCommitStatus(doer *models.User, repo *models.Repository, sha string, status *models.CommitStatus) {
+}
+
 // NotifyRepoPendingTransfer places a place holder function
 func (*NullNotifier) NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository) {
 }
@@ -152,3 +152,37 @@ func (r *indexerNotifier) NotifyIssueChangeTitle(doer *models.User, issue *model
 func (r *indexerNotifier) NotifyIssueChangeRef(doer *models.User, issue *models.Issue, oldRef string) {
 	issue_indexer.UpdateIssueIndexer(issue)
 }
+
+func (r *indexerNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	issue_indexer.UpdateIssueIndexer(issue)
+}
+
+func (r *indexerNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+	issue_indexer.UpdateIssueIndexer(pr.Issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeMilestone(doer *models.User, issue *models.Issue, oldMilestoneID int64) {
+	issue_indexer.UpdateIssueIndexer(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeAssignee(doer *models.User, issue *models.Issue, assignee *models.User, removed bool, comment *models.Comment) {
+	// reload the assignees, they may not reflect the change yet
+	issue.Assignees = nil
+	issue_indexer.UpdateIssueIndexer(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
+	addedLabels []*models.Label, removedLabels []*models.Label) {
+	// reload the labels, they may not reflect the change yet
+	issue.Labels = nil
+	issue_indexer.UpdateIssueIndexer(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueClearLabels(doer *models.User, issue *models.Issue) {
+	issue.Labels = nil
+	issue_indexer.UpdateIssueIndexer(issue)
+}
@@ -291,6 +291,13 @@ func NotifySyncDeleteRef(pusher *models.User, repo *models.Repository, refType,
 	}
 }
 
+// NotifyCreateCommitStatus notifies new commit status to notifiers
+func NotifyCreateCommitStatus(doer *models.User, repo *models.Repository, sha string, status *models.CommitStatus) {
+	for _, notifier := range notifiers {
+		notifier.NotifyCreateCommitStatus(doer, repo, sha, status)
+	}
+}
+
 // NotifyRepoPendingTransfer notifies creation of pending transfer to notifiers
 func NotifyRepoPendingTransfer(doer, newOwner *models.User, repo *models.Repository) {
 	for _, notifier := range notifiers {
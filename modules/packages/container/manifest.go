@@ -0,0 +1,105 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+// Media types of the manifests the registry accepts
+const (
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ErrInvalidManifest indicates a manifest which can not be parsed or has an unsupported media type
+var ErrInvalidManifest = errors.New("The manifest is invalid")
+
+var (
+	// DigestPattern matches the sha256 digests used to address blobs and manifests
+	DigestPattern = regexp.MustCompile(`\Asha256:[a-f0-9]{64}\z`)
+	// ImageNamePattern matches valid image names
+	ImageNamePattern = regexp.MustCompile(`\A[a-z0-9]+(?:[._-][a-z0-9]+)*\z`)
+	// TagPattern matches valid tags
+	TagPattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+)
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        *descriptor  `json:"config"`
+	Layers        []descriptor `json:"layers"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+// Manifest describes an image manifest or an index of manifests
+type Manifest struct {
+	MediaType string
+	// Blobs are the digests of the config and the layers of an image manifest
+	Blobs []string
+	// Manifests are the digests of the manifests an index references
+	Manifests []string
+}
+
+// IsIndex returns whether the manifest references other manifests
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+// ParseManifest parses a manifest. contentType is used if the manifest does not declare its media type.
+func ParseManifest(content []byte, contentType string) (*Manifest, error) {
+	var raw manifest
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, ErrInvalidManifest
+	}
+	if raw.SchemaVersion != 2 {
+		return nil, ErrInvalidManifest
+	}
+
+	m := &Manifest{MediaType: raw.MediaType}
+	if m.MediaType == "" {
+		m.MediaType = contentType
+	}
+	if m.MediaType == "" || m.MediaType == "application/json" {
+		if raw.Manifests != nil {
+			m.MediaType = MediaTypeOCIIndex
+		} else {
+			m.MediaType = MediaTypeOCIManifest
+		}
+	}
+
+	switch m.MediaType {
+	case MediaTypeOCIManifest, MediaTypeDockerManifest:
+		if raw.Config == nil {
+			return nil, ErrInvalidManifest
+		}
+		m.Blobs = append(m.Blobs, raw.Config.Digest)
+		for _, l := range raw.Layers {
+			m.Blobs = append(m.Blobs, l.Digest)
+		}
+	case MediaTypeOCIIndex, MediaTypeDockerManifestList:
+		for _, d := range raw.Manifests {
+			m.Manifests = append(m.Manifests, d.Digest)
+		}
+	default:
+		return nil, ErrInvalidManifest
+	}
+
+	for _, d := range append(m.Blobs, m.Manifests...) {
+		if !DigestPattern.MatchString(d) {
+			return nil, ErrInvalidManifest
+		}
+	}
+	return m, nil
+}
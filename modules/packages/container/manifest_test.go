@@ -0,0 +1,43 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseManifest(t *testing.T) {
+	config := "sha256:" + strings.Repeat("a", 64)
+	layer := "sha256:" + strings.Repeat("b", 64)
+
+	m, err := ParseManifest([]byte(`{"schemaVersion":2,"mediaType":"`+MediaTypeDockerManifest+`","config":{"digest":"`+config+`"},"layers":[{"digest":"`+layer+`"}]}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, MediaTypeDockerManifest, m.MediaType)
+	assert.Equal(t, []string{config, layer}, m.Blobs)
+	assert.False(t, m.IsIndex())
+
+	m, err = ParseManifest([]byte(`{"schemaVersion":2,"manifests":[{"digest":"`+config+`"}]}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, MediaTypeOCIIndex, m.MediaType)
+	assert.Equal(t, []string{config}, m.Manifests)
+	assert.True(t, m.IsIndex())
+
+	m, err = ParseManifest([]byte(`{"schemaVersion":2,"config":{"digest":"`+config+`"}}`), MediaTypeOCIManifest)
+	assert.NoError(t, err)
+	assert.Equal(t, MediaTypeOCIManifest, m.MediaType)
+
+	for _, content := range []string{
+		`{"schemaVersion":1}`,
+		`{"schemaVersion":2,"mediaType":"` + MediaTypeOCIManifest + `"}`,
+		`{"schemaVersion":2,"config":{"digest":"sha256:invalid"}}`,
+		`{"schemaVersion":2,"mediaType":"text/plain","config":{"digest":"` + config + `"}}`,
+	} {
+		_, err = ParseManifest([]byte(content), "")
+		assert.Equal(t, ErrInvalidManifest, err, content)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// ErrFileTooLarge is returned if the content exceeds the maximum size
+var ErrFileTooLarge = errors.New("File is too large")
+
+// HashedBuffer holds the content of an upload in a temporary file and the hashes of the content
+type HashedBuffer struct {
+	file *os.File
+	size int64
+
+	md5    hash.Hash
+	sha1   hash.Hash
+	sha256 hash.Hash
+	sha512 hash.Hash
+}
+
+// NewHashedBuffer reads r into a temporary file while hashing it. maxSize limits the size, -1 means no limit.
+// The buffer must be closed to remove the temporary file.
+func NewHashedBuffer(r io.Reader, maxSize int64) (*HashedBuffer, error) {
+	file, err := ioutil.TempFile("", "gitea-package-")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &HashedBuffer{
+		file:   file,
+		md5:    md5.New(),
+		sha1:   sha1.New(),
+		sha256: sha256.New(),
+		sha512: sha512.New(),
+	}
+
+	if maxSize >= 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	b.size, err = io.Copy(io.MultiWriter(file, b.md5, b.sha1, b.sha256, b.sha512), r)
+	if err == nil && maxSize >= 0 && b.size > maxSize {
+		err = ErrFileTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Read reads the content
+func (b *HashedBuffer) Read(p []byte) (int, error) {
+	return b.file.Read(p)
+}
+
+// Seek seeks in the content
+func (b *HashedBuffer) Seek(offset int64, whence int) (int64, error) {
+	return b.file.Seek(offset, whence)
+}
+
+// Close removes the temporary file
+func (b *HashedBuffer) Close() error {
+	err := b.file.Close()
+	if removeErr := os.Remove(b.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// Size returns the size of the content
+func (b *HashedBuffer) Size() int64 {
+	return b.size
+}
+
+// Sums returns the hex encoded MD5, SHA1, SHA256 and SHA512 hashes of the content
+func (b *HashedBuffer) Sums() (hashMD5, hashSHA1, hashSHA256, hashSHA512 string) {
+	return hex.EncodeToString(b.md5.Sum(nil)),
+		hex.EncodeToString(b.sha1.Sum(nil)),
+		hex.EncodeToString(b.sha256.Sum(nil)),
+		hex.EncodeToString(b.sha512.Sum(nil))
+}
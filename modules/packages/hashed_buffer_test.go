@@ -0,0 +1,33 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashedBuffer(t *testing.T) {
+	b, err := NewHashedBuffer(strings.NewReader("gitea"), -1)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 5, b.Size())
+	hashMD5, hashSHA1, hashSHA256, hashSHA512 := b.Sums()
+	assert.Equal(t, "e3bef03c5f3b7f6b3ab3e3053ed71e9c", hashMD5)
+	assert.Equal(t, "060b3b99f88e96085b4a68e095bc9e3d1d91e1bc", hashSHA1)
+	assert.Equal(t, "6ccce4863b70f258d691f59609d31b4502e1ba5199942d3bc5d35d17a4ce771d", hashSHA256)
+	assert.Len(t, hashSHA512, 128)
+
+	content, err := ioutil.ReadAll(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "gitea", string(content))
+	assert.NoError(t, b.Close())
+
+	_, err = NewHashedBuffer(strings.NewReader("gitea"), 4)
+	assert.Equal(t, ErrFileTooLarge, err)
+}
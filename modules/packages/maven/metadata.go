@@ -0,0 +1,105 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPath indicates a path which does not point to a file of an artifact version
+var ErrInvalidPath = errors.New("The path is invalid")
+
+// MetadataFilename is the name of the file maven clients request to resolve the versions of an artifact
+const MetadataFilename = "maven-metadata.xml"
+
+// Coordinates identifies a file of a maven artifact version in the repository layout
+type Coordinates struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Filename   string
+}
+
+// PackageName returns the name a maven artifact is stored with
+func (c *Coordinates) PackageName() string {
+	return c.GroupID + ":" + c.ArtifactID
+}
+
+// ParsePath parses a path of the repository layout like org/example/artifact/1.0/artifact-1.0.jar.
+// Requests for the metadata file of an artifact have no version.
+func ParsePath(p string) (*Coordinates, error) {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, ErrInvalidPath
+		}
+	}
+
+	filename := parts[len(parts)-1]
+	if filename == MetadataFilename || strings.HasPrefix(filename, MetadataFilename+".") {
+		if len(parts) < 3 {
+			return nil, ErrInvalidPath
+		}
+		return &Coordinates{
+			GroupID:    strings.Join(parts[:len(parts)-2], "."),
+			ArtifactID: parts[len(parts)-2],
+			Filename:   filename,
+		}, nil
+	}
+
+	if len(parts) < 4 {
+		return nil, ErrInvalidPath
+	}
+	return &Coordinates{
+		GroupID:    strings.Join(parts[:len(parts)-3], "."),
+		ArtifactID: parts[len(parts)-3],
+		Version:    parts[len(parts)-2],
+		Filename:   filename,
+	}, nil
+}
+
+type metadataVersioning struct {
+	Latest      string   `xml:"latest"`
+	Release     string   `xml:"release"`
+	Versions    []string `xml:"versions>version"`
+	LastUpdated string   `xml:"lastUpdated"`
+}
+
+type metadata struct {
+	XMLName    xml.Name           `xml:"metadata"`
+	GroupID    string             `xml:"groupId"`
+	ArtifactID string             `xml:"artifactId"`
+	Versioning metadataVersioning `xml:"versioning"`
+}
+
+// CreateMetadata creates the maven-metadata.xml of an artifact. versions must be ordered from oldest to newest.
+func CreateMetadata(groupID, artifactID string, versions []string, lastUpdated time.Time) ([]byte, error) {
+	m := &metadata{
+		GroupID:    groupID,
+		ArtifactID: artifactID,
+		Versioning: metadataVersioning{
+			Versions:    versions,
+			LastUpdated: lastUpdated.UTC().Format("20060102150405"),
+		},
+	}
+	if len(versions) > 0 {
+		m.Versioning.Latest = versions[len(versions)-1]
+		for i := len(versions) - 1; i >= 0; i-- {
+			if !strings.HasSuffix(versions[i], "-SNAPSHOT") {
+				m.Versioning.Release = versions[i]
+				break
+			}
+		}
+	}
+
+	content, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	c, err := ParsePath("/org/example/artifact/1.0/artifact-1.0.jar")
+	assert.NoError(t, err)
+	assert.Equal(t, &Coordinates{GroupID: "org.example", ArtifactID: "artifact", Version: "1.0", Filename: "artifact-1.0.jar"}, c)
+	assert.Equal(t, "org.example:artifact", c.PackageName())
+
+	c, err = ParsePath("org/example/artifact/maven-metadata.xml.sha1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Coordinates{GroupID: "org.example", ArtifactID: "artifact", Filename: "maven-metadata.xml.sha1"}, c)
+
+	for _, p := range []string{"artifact/1.0/artifact-1.0.jar", "org/../artifact/1.0/a.jar", "org//artifact/1.0/a.jar", "artifact/maven-metadata.xml"} {
+		_, err = ParsePath(p)
+		assert.Equal(t, ErrInvalidPath, err, p)
+	}
+}
+
+func TestCreateMetadata(t *testing.T) {
+	content, err := CreateMetadata("org.example", "artifact", []string{"1.0", "1.1", "1.2-SNAPSHOT"}, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>org.example</groupId>
+  <artifactId>artifact</artifactId>
+  <versioning>
+    <latest>1.2-SNAPSHOT</latest>
+    <release>1.1</release>
+    <versions>
+      <version>1.0</version>
+      <version>1.1</version>
+      <version>1.2-SNAPSHOT</version>
+    </versions>
+    <lastUpdated>20210601120000</lastUpdated>
+  </versioning>
+</metadata>`, string(content))
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+
+	"github.com/hashicorp/go-version"
+)
+
+var (
+	// ErrInvalidPackage indicates an invalid package
+	ErrInvalidPackage = errors.New("The package is invalid")
+	// ErrInvalidPackageName indicates an invalid name
+	ErrInvalidPackageName = errors.New("The package name is invalid")
+	// ErrInvalidPackageVersion indicates an invalid version
+	ErrInvalidPackageVersion = errors.New("The package version is invalid")
+	// ErrInvalidAttachment indicates a invalid attachment
+	ErrInvalidAttachment = errors.New("The package attachment is invalid")
+	// ErrInvalidIntegrity indicates an integrity validation error
+	ErrInvalidIntegrity = errors.New("Failed to validate integrity")
+)
+
+var nameMatch = regexp.MustCompile(`\A((@[^\s\/~'!\(\)\*]+?)[\/])?([^_.][^\s\/~'!\(\)\*]+)\z`)
+
+// Package represents an npm package version which is published
+type Package struct {
+	Name    string
+	Version string
+	// Metadata is the package.json of the version, as sent by the client
+	Metadata map[string]interface{}
+	Filename string
+	Data     []byte
+}
+
+type publishRequest struct {
+	Name        string                            `json:"name"`
+	Versions    map[string]map[string]interface{} `json:"versions"`
+	Attachments map[string]*struct {
+		Data   string `json:"data"`
+		Length int    `json:"length"`
+	} `json:"_attachments"`
+}
+
+// ParsePackage parses the body of an npm publish request
+func ParsePackage(r io.Reader) (*Package, error) {
+	var req publishRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	if !nameMatch.MatchString(req.Name) {
+		return nil, ErrInvalidPackageName
+	}
+	if len(req.Versions) != 1 || len(req.Attachments) != 1 {
+		return nil, ErrInvalidPackage
+	}
+
+	p := &Package{Name: req.Name}
+	for v, meta := range req.Versions {
+		if _, err := version.NewSemver(v); err != nil {
+			return nil, ErrInvalidPackageVersion
+		}
+		p.Version = v
+		p.Metadata = meta
+	}
+	if name, _ := p.Metadata["name"].(string); name != p.Name {
+		return nil, ErrInvalidPackageName
+	}
+	if v, _ := p.Metadata["version"].(string); v != p.Version {
+		return nil, ErrInvalidPackageVersion
+	}
+
+	for filename, attachment := range req.Attachments {
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil || len(data) != attachment.Length {
+			return nil, ErrInvalidAttachment
+		}
+		p.Filename = filename
+		p.Data = data
+	}
+
+	// Validate the checksums the client calculated, if there are some
+	if dist, ok := p.Metadata["dist"].(map[string]interface{}); ok {
+		if shasum, ok := dist["shasum"].(string); ok {
+			sum := sha1.Sum(p.Data)
+			if shasum != hex.EncodeToString(sum[:]) {
+				return nil, ErrInvalidIntegrity
+			}
+		}
+		if integrity, ok := dist["integrity"].(string); ok {
+			sum := sha512.Sum512(p.Data)
+			if integrity != "sha512-"+base64.StdEncoding.EncodeToString(sum[:]) {
+				return nil, ErrInvalidIntegrity
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// NewReader returns a reader for the package tarball
+func (p *Package) NewReader() io.Reader {
+	return bytes.NewReader(p.Data)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createPublishBody(name, version, shasum string, data []byte) []byte {
+	return []byte(fmt.Sprintf(`{
+		"name": "%[1]s",
+		"versions": {
+			"%[2]s": {
+				"name": "%[1]s",
+				"version": "%[2]s",
+				"description": "test package",
+				"dist": {
+					"shasum": "%[3]s"
+				}
+			}
+		},
+		"_attachments": {
+			"%[1]s-%[2]s.tgz": {
+				"data": "%[4]s",
+				"length": %[5]d
+			}
+		}
+	}`, name, version, shasum, base64.StdEncoding.EncodeToString(data), len(data)))
+}
+
+func TestParsePackage(t *testing.T) {
+	data := []byte("package content")
+	sum := sha1.Sum(data)
+	shasum := hex.EncodeToString(sum[:])
+
+	p, err := ParsePackage(bytes.NewReader(createPublishBody("@scope/test-package", "1.0.1-pre", shasum, data)))
+	assert.NoError(t, err)
+	assert.Equal(t, "@scope/test-package", p.Name)
+	assert.Equal(t, "1.0.1-pre", p.Version)
+	assert.Equal(t, "@scope/test-package-1.0.1-pre.tgz", p.Filename)
+	assert.Equal(t, data, p.Data)
+	assert.Equal(t, "test package", p.Metadata["description"])
+
+	_, err = ParsePackage(bytes.NewReader(createPublishBody("_invalid", "1.0.1", shasum, data)))
+	assert.Equal(t, ErrInvalidPackageName, err)
+
+	_, err = ParsePackage(bytes.NewReader(createPublishBody("test", "1.0.1.0.a", shasum, data)))
+	assert.Equal(t, ErrInvalidPackageVersion, err)
+
+	_, err = ParsePackage(bytes.NewReader(createPublishBody("test", "1.0.1", "0000", data)))
+	assert.Equal(t, ErrInvalidIntegrity, err)
+}
+
+func TestCreatePackageDocument(t *testing.T) {
+	doc := CreatePackageDocument("test", []*VersionInfo{
+		{Version: "1.0.0"},
+		{Version: "2.0.0-beta"},
+		{Version: "1.1.0"},
+	})
+	assert.Equal(t, map[string]string{"latest": "1.1.0"}, doc["dist-tags"])
+	assert.Len(t, doc["versions"], 3)
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// VersionInfo describes a published version for the package document
+type VersionInfo struct {
+	Version  string
+	Metadata map[string]interface{}
+	Created  time.Time
+	// TarballURL is the download url of the version
+	TarballURL string
+	HashSHA1   string
+	HashSHA512 string
+}
+
+// CreatePackageDocument creates the document npm clients request to install a package.
+// The latest version is the highest one which is not a pre-release, if there is any.
+func CreatePackageDocument(name string, versions []*VersionInfo) map[string]interface{} {
+	versionsDoc := make(map[string]interface{}, len(versions))
+	times := make(map[string]string, len(versions))
+
+	var latest, latestAny *version.Version
+	for _, vi := range versions {
+		meta := make(map[string]interface{}, len(vi.Metadata)+1)
+		for k, v := range vi.Metadata {
+			meta[k] = v
+		}
+
+		sha512, _ := hex.DecodeString(vi.HashSHA512)
+		meta["dist"] = map[string]string{
+			"shasum":    vi.HashSHA1,
+			"integrity": "sha512-" + base64.StdEncoding.EncodeToString(sha512),
+			"tarball":   vi.TarballURL,
+		}
+		versionsDoc[vi.Version] = meta
+		times[vi.Version] = vi.Created.UTC().Format(time.RFC3339)
+
+		v, err := version.NewSemver(vi.Version)
+		if err != nil {
+			continue
+		}
+		if latestAny == nil || v.GreaterThan(latestAny) {
+			latestAny = v
+		}
+		if v.Prerelease() == "" && (latest == nil || v.GreaterThan(latest)) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		latest = latestAny
+	}
+
+	distTags := map[string]string{}
+	if latest != nil {
+		distTags["latest"] = latest.Original()
+	}
+
+	return map[string]interface{}{
+		"_id":       name,
+		"name":      name,
+		"dist-tags": distTags,
+		"versions":  versionsDoc,
+		"time":      times,
+	}
+}
@@ -9,11 +9,13 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/notification"
 )
 
 // CreateCommitStatus creates a new CommitStatus given a bunch of parameters
 // NOTE: All text-values will be trimmed from whitespaces.
 // Requires: Repo, Creator, SHA
+// The notifiers are told about the new status, so that e.g. pull requests waiting for it get merged.
 func CreateCommitStatus(repo *models.Repository, creator *models.User, sha string, status *models.CommitStatus) error {
 	repoPath := repo.RepoPath()
 
@@ -22,7 +24,8 @@ func CreateCommitStatus(repo *models.Repository, creator *models.User, sha strin
 	if err != nil {
 		return fmt.Errorf("OpenRepository[%s]: %v", repoPath, err)
 	}
-	if _, err := gitRepo.GetCommit(sha); err != nil {
+	commit, err := gitRepo.GetCommit(sha)
+	if err != nil {
 		gitRepo.Close()
 		return fmt.Errorf("GetCommit[%s]: %v", sha, err)
 	}
@@ -37,5 +40,6 @@ func CreateCommitStatus(repo *models.Repository, creator *models.User, sha strin
 		return fmt.Errorf("NewCommitStatus[repo_id: %d, user_id: %d, sha: %s]: %v", repo.ID, creator.ID, sha, err)
 	}
 
+	notification.NotifyCreateCommitStatus(creator, repo, commit.ID.String(), status)
 	return nil
 }
@@ -169,6 +169,9 @@ func MigrateRepositoryGitData(ctx context.Context, u *models.User, repo *models.
 		if err = models.InsertMirror(&mirrorModel); err != nil {
 			return repo, fmt.Errorf("InsertOne: %v", err)
 		}
+		if err = moveMirrorCredentialsToSecret(&mirrorModel, repo, opts.CloneAddr); err != nil {
+			return repo, fmt.Errorf("moveMirrorCredentialsToSecret: %v", err)
+		}
 
 		repo.IsMirror = true
 		err = models.UpdateRepository(repo, false)
@@ -179,6 +182,35 @@ func MigrateRepositoryGitData(ctx context.Context, u *models.User, repo *models.
 	return repo, err
 }
 
+// moveMirrorCredentialsToSecret stores the credentials of the clone address as secret
+// and removes them from the remote address in the Git repository config.
+func moveMirrorCredentialsToSecret(m *models.Mirror, repo *models.Repository, cloneAddr string) error {
+	u, err := url.Parse(cloneAddr)
+	if err != nil || u.User == nil {
+		return nil
+	}
+	if err := m.SetCredentials(u.User); err != nil {
+		return err
+	}
+
+	repoPaths := []string{repo.RepoPath()}
+	if repo.HasWiki() {
+		repoPaths = append(repoPaths, repo.WikiPath())
+	}
+	for _, repoPath := range repoPaths {
+		addr, err := git.GetRemoteAddress(repoPath, "origin")
+		if err != nil {
+			return err
+		} else if addr == "" {
+			continue
+		}
+		if _, err := git.NewCommand("remote", "set-url", "origin", util.SanitizeURLCredentials(addr, false)).RunInDir(repoPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // cleanUpMigrateGitConfig removes mirror info which prevents "push --all".
 // This also removes possible user credentials.
 func cleanUpMigrateGitConfig(configPath string) error {
@@ -0,0 +1,102 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter is a single attribute comparison of a filter expression.
+// Identity providers only use "eq" comparisons to look up resources, so other operators and logical expressions are not supported.
+type Filter struct {
+	// Attribute is the lower case path of the compared attribute, e.g. "username" or "emails.value"
+	Attribute string
+	Value     string
+}
+
+var filterPattern = regexp.MustCompile(`^\s*([A-Za-z][\w.:$-]*)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*"|(?i:true|false))\s*$`)
+
+// ParseFilter parses a filter expression of the form `attribute eq "value"`
+func ParseFilter(filter string) (*Filter, error) {
+	m := filterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported filter: %s", filter)
+	}
+
+	value := strings.ToLower(m[2])
+	if strings.HasPrefix(m[2], `"`) {
+		if err := json.Unmarshal([]byte(m[2]), &value); err != nil {
+			return nil, fmt.Errorf("invalid filter value %s: %v", m[2], err)
+		}
+	}
+	return &Filter{
+		Attribute: strings.ToLower(stripSchema(m[1])),
+		Value:     value,
+	}, nil
+}
+
+// Path is the target of a patch operation, e.g. `members[value eq "1"]` or `name.givenName`
+type Path struct {
+	// Attribute is the lower case name of the top level attribute
+	Attribute string
+	// Filter selects the values of a multi-valued attribute, its attribute is relative to them
+	Filter *Filter
+	// SubAttribute is the lower case name of the sub-attribute, if any
+	SubAttribute string
+}
+
+var attributeNamePattern = regexp.MustCompile(`^[A-Za-z][\w$-]*$`)
+
+// ParsePath parses the path of a patch operation
+func ParsePath(path string) (*Path, error) {
+	attr, rest := path, ""
+	if idx := strings.IndexByte(path, '['); idx >= 0 {
+		attr, rest = path[:idx], path[idx:]
+	}
+	attr = stripSchema(attr)
+
+	p := &Path{}
+	hasSubAttribute := false
+	if rest != "" {
+		end := strings.LastIndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		filter, err := ParseFilter(rest[1:end])
+		if err != nil {
+			return nil, err
+		}
+		p.Filter = filter
+		rest = rest[end+1:]
+		if rest != "" {
+			if rest[0] != '.' {
+				return nil, fmt.Errorf("invalid path: %s", path)
+			}
+			p.SubAttribute, hasSubAttribute = rest[1:], true
+		}
+	} else if idx := strings.IndexByte(attr, '.'); idx >= 0 {
+		attr, p.SubAttribute, hasSubAttribute = attr[:idx], attr[idx+1:], true
+	}
+
+	if !attributeNamePattern.MatchString(attr) || hasSubAttribute && !attributeNamePattern.MatchString(p.SubAttribute) {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	p.Attribute = strings.ToLower(attr)
+	p.SubAttribute = strings.ToLower(p.SubAttribute)
+	return p, nil
+}
+
+// stripSchema removes the schema URN of a fully qualified attribute name
+func stripSchema(attr string) string {
+	if strings.HasPrefix(strings.ToLower(attr), "urn:") {
+		if idx := strings.LastIndexByte(attr, ':'); idx >= 0 {
+			return attr[idx+1:]
+		}
+	}
+	return attr
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Operations of a patch request
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+// PatchRequest is a request to modify a resource
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is a single modification of a patch request
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Operation returns the lower case operation, some identity providers send "Replace" instead of "replace"
+func (op *PatchOperation) Operation() (string, error) {
+	switch o := strings.ToLower(op.Op); o {
+	case PatchAdd, PatchRemove, PatchReplace:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unsupported patch operation: %s", op.Op)
+	}
+}
+
+// Attributes returns the attributes of a patch operation without path, keyed by their paths
+func (op *PatchOperation) Attributes() (map[string]json.RawMessage, error) {
+	attributes := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(op.Value, &attributes); err != nil {
+		return nil, fmt.Errorf("patch operations without path need an object value: %v", err)
+	}
+	return attributes, nil
+}
+
+// ParseString parses a string value
+func ParseString(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", fmt.Errorf("invalid string value %s", value)
+	}
+	return s, nil
+}
+
+// ParseBool parses a boolean value, which may be sent as string like "False"
+func ParseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("invalid boolean value %s", value)
+}
+
+// ParseReferences parses a single or multiple references, like the members of a group
+func ParseReferences(value json.RawMessage) ([]Reference, error) {
+	var refs []Reference
+	if err := json.Unmarshal(value, &refs); err == nil {
+		return refs, nil
+	}
+	var ref Reference
+	if err := json.Unmarshal(value, &ref); err != nil {
+		return nil, fmt.Errorf("invalid references %s", value)
+	}
+	return []Reference{ref}, nil
+}
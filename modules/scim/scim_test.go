@@ -0,0 +1,131 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(`userName eq "fry@planetexpress.com"`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Filter{Attribute: "username", Value: "fry@planetexpress.com"}, filter)
+
+	filter, err = ParseFilter(`urn:ietf:params:scim:schemas:core:2.0:Group:displayName EQ "planet/express \"crew\""`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Filter{Attribute: "displayname", Value: `planet/express "crew"`}, filter)
+
+	filter, err = ParseFilter(`emails.value eq "fry@planetexpress.com"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "emails.value", filter.Attribute)
+
+	filter, err = ParseFilter(`active eq True`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Filter{Attribute: "active", Value: "true"}, filter)
+
+	for _, invalid := range []string{
+		``,
+		`userName`,
+		`userName co "fry"`,
+		`userName eq fry`,
+		`userName eq "fry" and active eq true`,
+	} {
+		_, err = ParseFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("displayName")
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "displayname"}, path)
+
+	path, err = ParsePath("name.givenName")
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "name", SubAttribute: "givenname"}, path)
+
+	path, err = ParsePath("urn:ietf:params:scim:schemas:core:2.0:User:name.familyName")
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "name", SubAttribute: "familyname"}, path)
+
+	path, err = ParsePath(`members[value eq "2"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "members", Filter: &Filter{Attribute: "value", Value: "2"}}, path)
+
+	path, err = ParsePath(`emails[type eq "work"].value`)
+	assert.NoError(t, err)
+	assert.Equal(t, &Path{Attribute: "emails", Filter: &Filter{Attribute: "type", Value: "work"}, SubAttribute: "value"}, path)
+
+	for _, invalid := range []string{``, `members[value eq "2"`, `members[value]`, `emails[type eq "work"]value`, `name.`} {
+		_, err = ParsePath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPatchOperation(t *testing.T) {
+	req := &PatchRequest{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "add", "value": {"displayName": "Philip J. Fry", "name.givenName": "Philip"}},
+			{"op": "add", "path": "members", "value": [{"value": "1"}, {"value": "2"}]},
+			{"op": "move", "path": "active"}
+		]
+	}`), req))
+	assert.Len(t, req.Operations, 4)
+
+	op, err := req.Operations[0].Operation()
+	assert.NoError(t, err)
+	assert.Equal(t, PatchReplace, op)
+	active, err := ParseBool(req.Operations[0].Value)
+	assert.NoError(t, err)
+	assert.False(t, active)
+
+	attributes, err := req.Operations[1].Attributes()
+	assert.NoError(t, err)
+	assert.Len(t, attributes, 2)
+	displayName, err := ParseString(attributes["displayName"])
+	assert.NoError(t, err)
+	assert.Equal(t, "Philip J. Fry", displayName)
+	_, err = req.Operations[0].Attributes()
+	assert.Error(t, err)
+
+	refs, err := ParseReferences(req.Operations[2].Value)
+	assert.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: "1"}, {Value: "2"}}, refs)
+	refs, err = ParseReferences(json.RawMessage(`{"value": "3"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: "3"}}, refs)
+
+	_, err = req.Operations[3].Operation()
+	assert.Error(t, err)
+
+	active, err = ParseBool(json.RawMessage(`true`))
+	assert.NoError(t, err)
+	assert.True(t, active)
+	_, err = ParseBool(json.RawMessage(`"yes"`))
+	assert.Error(t, err)
+}
+
+func TestUser(t *testing.T) {
+	active := false
+	u := &User{
+		Name:   &Name{GivenName: "Philip", FamilyName: "Fry"},
+		Emails: []Email{{Value: "fry@example.com"}, {Value: "fry@planetexpress.com", Primary: true}},
+		Active: &active,
+	}
+	assert.Equal(t, "Philip Fry", u.FullName())
+	assert.Equal(t, "fry@planetexpress.com", u.PrimaryEmail())
+	assert.False(t, u.IsActive())
+
+	u = &User{DisplayName: "Fry", Name: &Name{Formatted: "Philip J. Fry"}, Emails: []Email{{Value: "fry@example.com"}}}
+	assert.Equal(t, "Fry", u.FullName())
+	assert.Equal(t, "fry@example.com", u.PrimaryEmail())
+	assert.True(t, u.IsActive())
+}
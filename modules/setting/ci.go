@@ -0,0 +1,23 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+// CI settings
+var CI = struct {
+	Storage
+	Enabled         bool
+	MaxWorkflowSize int64
+}{
+	Enabled:         true,
+	MaxWorkflowSize: 1 << 20,
+}
+
+func newCIService() {
+	sec := Cfg.Section("ci")
+	CI.Enabled = sec.Key("ENABLED").MustBool(true)
+	CI.MaxWorkflowSize = sec.Key("MAX_WORKFLOW_SIZE").MustInt64(1 << 20)
+
+	CI.Storage = getStorage("ci_logs", sec.Key("STORAGE_TYPE").MustString(""), sec)
+}
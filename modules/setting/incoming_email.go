@@ -0,0 +1,63 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// IncomingEmailTokenPlaceholder is replaced by the reply token in the reply address
+const IncomingEmailTokenPlaceholder = "%{token}"
+
+// IncomingEmail settings
+var IncomingEmail = struct {
+	Enabled              bool
+	ReplyToAddress       string
+	MaildirPath          string
+	PollInterval         time.Duration
+	ReplyTokenExpiry     time.Duration
+	DeleteHandledMessage bool
+	MaximumMessageSize   int64
+}{
+	PollInterval:         time.Minute,
+	ReplyTokenExpiry:     30 * 24 * time.Hour,
+	DeleteHandledMessage: true,
+	MaximumMessageSize:   10 * 1024 * 1024,
+}
+
+func newIncomingEmailService() {
+	sec := Cfg.Section("email.incoming")
+	if !sec.Key("ENABLED").MustBool() {
+		return
+	}
+
+	IncomingEmail.ReplyToAddress = sec.Key("REPLY_TO_ADDRESS").String()
+	IncomingEmail.MaildirPath = sec.Key("MAILDIR_PATH").String()
+	IncomingEmail.PollInterval = sec.Key("POLL_INTERVAL").MustDuration(IncomingEmail.PollInterval)
+	IncomingEmail.ReplyTokenExpiry = sec.Key("REPLY_TOKEN_EXPIRY").MustDuration(IncomingEmail.ReplyTokenExpiry)
+	IncomingEmail.DeleteHandledMessage = sec.Key("DELETE_HANDLED_MESSAGE").MustBool(IncomingEmail.DeleteHandledMessage)
+	IncomingEmail.MaximumMessageSize = sec.Key("MAXIMUM_MESSAGE_SIZE").MustInt64(IncomingEmail.MaximumMessageSize)
+
+	if !strings.Contains(IncomingEmail.ReplyToAddress, IncomingEmailTokenPlaceholder) {
+		log.Fatal("Incoming email: REPLY_TO_ADDRESS must contain the placeholder %s", IncomingEmailTokenPlaceholder)
+	}
+	if _, err := mail.ParseAddress(strings.Replace(IncomingEmail.ReplyToAddress, IncomingEmailTokenPlaceholder, "token", 1)); err != nil {
+		log.Fatal("Incoming email: invalid REPLY_TO_ADDRESS %q: %v", IncomingEmail.ReplyToAddress, err)
+	}
+	if IncomingEmail.MaildirPath == "" {
+		log.Fatal("Incoming email: MAILDIR_PATH must be set")
+	}
+	if !filepath.IsAbs(IncomingEmail.MaildirPath) {
+		IncomingEmail.MaildirPath = filepath.Join(AppWorkPath, IncomingEmail.MaildirPath)
+	}
+
+	IncomingEmail.Enabled = true
+	log.Info("Incoming Email Service Enabled")
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+)
+
+// Packages settings
+var Packages = struct {
+	Storage
+	Enabled           bool
+	ChunkedUploadPath string
+	MaxFileSize       int64
+}{
+	Enabled:     true,
+	MaxFileSize: -1,
+}
+
+func newPackagesService() {
+	sec := Cfg.Section("packages")
+	Packages.Enabled = sec.Key("ENABLED").MustBool(true)
+	Packages.MaxFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(-1)
+
+	Packages.Storage = getStorage("packages", sec.Key("STORAGE_TYPE").MustString(""), sec)
+
+	Packages.ChunkedUploadPath = sec.Key("CHUNKED_UPLOAD_PATH").MustString(filepath.Join(AppDataPath, "tmp/package-upload"))
+	if !filepath.IsAbs(Packages.ChunkedUploadPath) {
+		Packages.ChunkedUploadPath = filepath.Join(AppWorkPath, Packages.ChunkedUploadPath)
+	}
+}
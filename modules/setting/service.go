@@ -54,6 +54,7 @@ var Service struct {
 	AutoWatchOnChanges                      bool
 	DefaultOrgMemberVisible                 bool
 	UserDeleteWithCommentsMaxTime           time.Duration
+	EnablePasswordlessLogin                 bool
 
 	// OpenID settings
 	EnableOpenIDSignIn bool
@@ -114,6 +115,7 @@ func newService() {
 	Service.DefaultOrgVisibilityMode = structs.VisibilityModes[Service.DefaultOrgVisibility]
 	Service.DefaultOrgMemberVisible = sec.Key("DEFAULT_ORG_MEMBER_VISIBLE").MustBool()
 	Service.UserDeleteWithCommentsMaxTime = sec.Key("USER_DELETE_WITH_COMMENTS_MAX_TIME").MustDuration(0)
+	Service.EnablePasswordlessLogin = sec.Key("ENABLE_PASSWORDLESS_LOGIN").MustBool(true)
 
 	if err := Cfg.Section("service.explore").MapTo(&Service.Explore); err != nil {
 		log.Fatal("Failed to map service.explore settings: %v", err)
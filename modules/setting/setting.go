@@ -28,7 +28,6 @@ import (
 	"code.gitea.io/gitea/modules/util"
 
 	jsoniter "github.com/json-iterator/go"
-	shellquote "github.com/kballard/go-shellquote"
 	"github.com/unknwon/com"
 	gossh "golang.org/x/crypto/ssh"
 	ini "gopkg.in/ini.v1"
@@ -164,6 +163,7 @@ var (
 	// Security settings
 	InstallLock                        bool
 	SecretKey                          string
+	MasterKey                          string
 	LogInRememberDays                  int
 	CookieUserName                     string
 	CookieRememberName                 string
@@ -372,8 +372,7 @@ var (
 	}
 
 	U2F = struct {
-		AppID         string
-		TrustedFacets []string
+		AppID string
 	}{}
 
 	// Metrics settings
@@ -822,6 +821,7 @@ func NewContext() {
 	sec = Cfg.Section("security")
 	InstallLock = sec.Key("INSTALL_LOCK").MustBool(false)
 	SecretKey = sec.Key("SECRET_KEY").MustString("!#@FDEWREWR&*(")
+	MasterKey = sec.Key("MASTER_KEY").MustString(SecretKey)
 	LogInRememberDays = sec.Key("LOGIN_REMEMBER_DAYS").MustInt(7)
 	CookieUserName = sec.Key("COOKIE_USERNAME").MustString("gitea_awesome")
 	CookieRememberName = sec.Key("COOKIE_REMEMBER_NAME").MustString("gitea_incredible")
@@ -860,6 +860,8 @@ func NewContext() {
 
 	newAttachmentService()
 	newLFSService()
+	newPackagesService()
+	newCIService()
 
 	timeFormatKey := Cfg.Section("time").Key("FORMAT").MustString("")
 	if timeFormatKey != "" {
@@ -983,7 +985,6 @@ func NewContext() {
 	newMarkup()
 
 	sec = Cfg.Section("U2F")
-	U2F.TrustedFacets, _ = shellquote.Split(sec.Key("TRUSTED_FACETS").MustString(strings.TrimSuffix(AppURL, AppSubURL+"/")))
 	U2F.AppID = sec.Key("APP_ID").MustString(strings.TrimSuffix(AppURL, "/"))
 
 	UI.ReactionsMap = make(map[string]bool)
@@ -1169,6 +1170,7 @@ func NewServices() {
 	newSessionService()
 	newCORSService()
 	newMailService()
+	newIncomingEmailService()
 	newRegisterMailService()
 	newNotifyMailService()
 	newWebhookService()
@@ -114,6 +114,12 @@ var (
 	Avatars ObjectStorage
 	// RepoAvatars represents repository avatars storage
 	RepoAvatars ObjectStorage
+
+	// Packages represents the package blob storage
+	Packages ObjectStorage
+
+	// CILogs represents the storage of the logs of CI jobs
+	CILogs ObjectStorage
 )
 
 // Init init the stoarge
@@ -130,6 +136,14 @@ func Init() error {
 		return err
 	}
 
+	if err := initPackages(); err != nil {
+		return err
+	}
+
+	if err := initCILogs(); err != nil {
+		return err
+	}
+
 	return initLFS()
 }
 
@@ -169,3 +183,21 @@ func initRepoAvatars() (err error) {
 	RepoAvatars, err = NewStorage(setting.RepoAvatar.Storage.Type, &setting.RepoAvatar.Storage)
 	return
 }
+
+func initPackages() (err error) {
+	if !setting.Packages.Enabled {
+		return nil
+	}
+	log.Info("Initialising Packages storage with type: %s", setting.Packages.Storage.Type)
+	Packages, err = NewStorage(setting.Packages.Storage.Type, &setting.Packages.Storage)
+	return
+}
+
+func initCILogs() (err error) {
+	if !setting.CI.Enabled {
+		return nil
+	}
+	log.Info("Initialising CI logs storage with type: %s", setting.CI.Storage.Type)
+	CILogs, err = NewStorage(setting.CI.Storage.Type, &setting.CI.Storage)
+	return
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// CheckRunStatus holds the progress of a CheckRun
+// It can be "queued", "in_progress" and "completed"
+type CheckRunStatus string
+
+const (
+	// CheckRunStatusQueued is for when the CheckRun has not been started yet
+	CheckRunStatusQueued CheckRunStatus = "queued"
+	// CheckRunStatusInProgress is for when the CheckRun is running
+	CheckRunStatusInProgress CheckRunStatus = "in_progress"
+	// CheckRunStatusCompleted is for when the CheckRun has finished with a conclusion
+	CheckRunStatusCompleted CheckRunStatus = "completed"
+)
+
+// IsValid returns true if the status is known
+func (s CheckRunStatus) IsValid() bool {
+	switch s {
+	case CheckRunStatusQueued, CheckRunStatusInProgress, CheckRunStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// CheckRunConclusion holds the result of a completed CheckRun
+// It can be "success", "failure", "neutral", "cancelled", "skipped", "timed_out" and "action_required"
+type CheckRunConclusion string
+
+const (
+	// CheckRunConclusionSuccess is for when the CheckRun succeeded
+	CheckRunConclusionSuccess CheckRunConclusion = "success"
+	// CheckRunConclusionFailure is for when the CheckRun failed
+	CheckRunConclusionFailure CheckRunConclusion = "failure"
+	// CheckRunConclusionNeutral is for when the CheckRun neither succeeded nor failed
+	CheckRunConclusionNeutral CheckRunConclusion = "neutral"
+	// CheckRunConclusionCancelled is for when the CheckRun has been cancelled
+	CheckRunConclusionCancelled CheckRunConclusion = "cancelled"
+	// CheckRunConclusionSkipped is for when the CheckRun has been skipped
+	CheckRunConclusionSkipped CheckRunConclusion = "skipped"
+	// CheckRunConclusionTimedOut is for when the CheckRun took too long
+	CheckRunConclusionTimedOut CheckRunConclusion = "timed_out"
+	// CheckRunConclusionActionRequired is for when the CheckRun needs further action to succeed
+	CheckRunConclusionActionRequired CheckRunConclusion = "action_required"
+)
+
+// IsValid returns true if the conclusion is known
+func (c CheckRunConclusion) IsValid() bool {
+	switch c {
+	case CheckRunConclusionSuccess, CheckRunConclusionFailure, CheckRunConclusionNeutral, CheckRunConclusionCancelled,
+		CheckRunConclusionSkipped, CheckRunConclusionTimedOut, CheckRunConclusionActionRequired:
+		return true
+	}
+	return false
+}
+
+// CheckAnnotationLevel holds the severity of a CheckRunAnnotation
+// It can be "notice", "warning" and "failure"
+type CheckAnnotationLevel string
+
+const (
+	// CheckAnnotationLevelNotice is for informational annotations
+	CheckAnnotationLevelNotice CheckAnnotationLevel = "notice"
+	// CheckAnnotationLevelWarning is for annotations about possible problems
+	CheckAnnotationLevelWarning CheckAnnotationLevel = "warning"
+	// CheckAnnotationLevelFailure is for annotations about problems which made the CheckRun fail
+	CheckAnnotationLevelFailure CheckAnnotationLevel = "failure"
+)
+
+// IsValid returns true if the level is known
+func (l CheckAnnotationLevel) IsValid() bool {
+	switch l {
+	case CheckAnnotationLevelNotice, CheckAnnotationLevelWarning, CheckAnnotationLevelFailure:
+		return true
+	}
+	return false
+}
+
+// CheckRun represents a run of a check on a commit
+type CheckRun struct {
+	ID         int64              `json:"id"`
+	HeadSHA    string             `json:"head_sha"`
+	Name       string             `json:"name"`
+	Status     CheckRunStatus     `json:"status"`
+	Conclusion CheckRunConclusion `json:"conclusion"`
+	DetailsURL string             `json:"details_url"`
+	ExternalID string             `json:"external_id"`
+	HTMLURL    string             `json:"html_url"`
+	URL        string             `json:"url"`
+	Output     *CheckRunOutput    `json:"output"`
+	Creator    *User              `json:"creator"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Completed *time.Time `json:"completed_at"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CheckRunOutput holds the report of a CheckRun
+type CheckRunOutput struct {
+	Title            string `json:"title"`
+	Summary          string `json:"summary"`
+	Text             string `json:"text"`
+	AnnotationsCount int64  `json:"annotations_count"`
+}
+
+// CheckRunAnnotation represents a remark of a CheckRun about some lines of a file
+type CheckRunAnnotation struct {
+	ID         int64                `json:"id"`
+	Path       string               `json:"path"`
+	StartLine  int64                `json:"start_line"`
+	EndLine    int64                `json:"end_line"`
+	Level      CheckAnnotationLevel `json:"annotation_level"`
+	Title      string               `json:"title"`
+	Message    string               `json:"message"`
+	RawDetails string               `json:"raw_details"`
+}
+
+// CreateCheckRunOption options for creating a check run
+type CreateCheckRunOption struct {
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+	// sha of the commit which is checked
+	// required: true
+	HeadSHA    string `json:"head_sha" binding:"Required;MaxSize(64)"`
+	DetailsURL string `json:"details_url"`
+	ExternalID string `json:"external_id" binding:"MaxSize(255)"`
+	// "queued", "in_progress" or "completed", defaults to "queued" or to "completed" if a conclusion is given
+	Status CheckRunStatus `json:"status"`
+	// required if status is "completed": "success", "failure", "neutral", "cancelled", "skipped", "timed_out" or "action_required"
+	Conclusion CheckRunConclusion `json:"conclusion"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Completed *time.Time            `json:"completed_at"`
+	Output    *CheckRunOutputOption `json:"output"`
+}
+
+// EditCheckRunOption options for updating a check run
+type EditCheckRunOption struct {
+	Name       *string `json:"name" binding:"OmitEmpty;MaxSize(255)"`
+	DetailsURL *string `json:"details_url"`
+	ExternalID *string `json:"external_id" binding:"OmitEmpty;MaxSize(255)"`
+	// "queued", "in_progress" or "completed"
+	Status *CheckRunStatus `json:"status"`
+	// required if status is "completed": "success", "failure", "neutral", "cancelled", "skipped", "timed_out" or "action_required"
+	Conclusion *CheckRunConclusion `json:"conclusion"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Completed *time.Time `json:"completed_at"`
+	// the output replaces the title, summary and text of the check run, its annotations are added to the existing ones
+	Output *CheckRunOutputOption `json:"output"`
+}
+
+// CheckRunOutputOption holds the report of a check run to create or update
+type CheckRunOutputOption struct {
+	Title string `json:"title" binding:"MaxSize(255)"`
+	// summary of the check run in markdown
+	Summary string `json:"summary"`
+	// details of the check run in markdown
+	Text        string                      `json:"text"`
+	Annotations []*CheckRunAnnotationOption `json:"annotations"`
+}
+
+// CheckRunAnnotationOption holds an annotation of a check run to create
+type CheckRunAnnotationOption struct {
+	// path of the file relative to the root of the repository
+	// required: true
+	Path string `json:"path" binding:"Required"`
+	// required: true
+	StartLine int64 `json:"start_line" binding:"Required"`
+	// defaults to start_line
+	EndLine int64 `json:"end_line"`
+	// "notice", "warning" or "failure"
+	// required: true
+	Level CheckAnnotationLevel `json:"annotation_level" binding:"Required"`
+	Title string               `json:"title" binding:"MaxSize(255)"`
+	// required: true
+	Message    string `json:"message" binding:"Required"`
+	RawDetails string `json:"raw_details"`
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// CIStatus holds the state of a CI job or of a run of a workflow
+// It can be "waiting", "running", "success", "failure" and "cancelled"
+type CIStatus string
+
+const (
+	// CIStatusWaiting is for when the job waits for a runner
+	CIStatusWaiting CIStatus = "waiting"
+	// CIStatusRunning is for when a runner runs the job
+	CIStatusRunning CIStatus = "running"
+	// CIStatusSuccess is for when all steps of the job succeeded
+	CIStatusSuccess CIStatus = "success"
+	// CIStatusFailure is for when a step of the job failed
+	CIStatusFailure CIStatus = "failure"
+	// CIStatusCancelled is for when the job has been stopped before it finished
+	CIStatusCancelled CIStatus = "cancelled"
+)
+
+// IsDone returns true if the job has finished
+func (s CIStatus) IsDone() bool {
+	return s == CIStatusSuccess || s == CIStatusFailure || s == CIStatusCancelled
+}
+
+// CommitStatusState returns the state of the commit status which reports the job
+func (s CIStatus) CommitStatusState() CommitStatusState {
+	switch s {
+	case CIStatusSuccess:
+		return CommitStatusSuccess
+	case CIStatusFailure:
+		return CommitStatusFailure
+	case CIStatusCancelled:
+		return CommitStatusError
+	}
+	return CommitStatusPending
+}
+
+// CIRunner represents a runner which runs CI jobs
+type CIRunner struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+	// the token the runner authenticates with, only returned when the runner is registered
+	Token string `json:"token,omitempty"`
+	// swagger:strfmt date-time
+	LastOnline *time.Time `json:"last_online"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// CreateCIRunnerOption options for registering a runner
+type CreateCIRunnerOption struct {
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+	// labels a job has to ask for with runs-on to be run by this runner
+	Labels []string `json:"labels"`
+}
+
+// CIRun represents a run of a workflow triggered by an event
+type CIRun struct {
+	ID           int64    `json:"id"`
+	Name         string   `json:"name"`
+	WorkflowFile string   `json:"workflow_file"`
+	Event        string   `json:"event"`
+	Ref          string   `json:"ref"`
+	CommitSHA    string   `json:"commit_sha"`
+	Status       CIStatus `json:"status"`
+	TriggerUser  *User    `json:"trigger_user"`
+	HTMLURL      string   `json:"html_url"`
+	Jobs         []*CIJob `json:"jobs"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CIJob represents a job of a run of a workflow
+type CIJob struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	RunsOn  []string `json:"runs_on"`
+	Status  CIStatus `json:"status"`
+	HTMLURL string   `json:"html_url"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+}
+
+// CIStep represents a shell script run as part of a CI job
+type CIStep struct {
+	Name string            `json:"name"`
+	Run  string            `json:"run"`
+	Env  map[string]string `json:"env"`
+}
+
+// CIJobTask is what a runner gets to run a CI job
+type CIJobTask struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Repository string            `json:"repository"`
+	CloneURL   string            `json:"clone_url"`
+	Event      string            `json:"event"`
+	Ref        string            `json:"ref"`
+	CommitSHA  string            `json:"commit_sha"`
+	Env        map[string]string `json:"env"`
+	Steps      []*CIStep         `json:"steps"`
+}
+
+// CIJobResultOption reports the result of a CI job
+type CIJobResultOption struct {
+	// "success", "failure" or "cancelled"
+	// required: true
+	Result CIStatus `json:"result" binding:"Required"`
+}
@@ -71,6 +71,16 @@ type Issue struct {
 
 	PullRequest *PullRequestMeta `json:"pull_request"`
 	Repo        *RepositoryMeta  `json:"repository"`
+	// the highlighted matches of the keyword, only set by searches
+	SearchHighlights []*IssueSearchHighlight `json:"search_highlights,omitempty"`
+}
+
+// IssueSearchHighlight represents a part of an issue matching the keyword of a search
+type IssueSearchHighlight struct {
+	// the field containing the match, one of title, content or comments
+	Field string `json:"field"`
+	// an HTML snippet of the field in which the matches are wrapped into <mark> elements
+	Fragment string `json:"fragment"`
 }
 
 // CreateIssueOption options to create one issue
@@ -0,0 +1,88 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project represents a project of a repository, a user or an organization
+type Project struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// enum: repository,individual,organization
+	Type string `json:"type"`
+	// the repository the project belongs to, 0 if it belongs to a user or an organization
+	RepoID int64 `json:"repo_id"`
+	// the user or organization the project belongs to, 0 if it belongs to a repository
+	OwnerID int64     `json:"owner_id"`
+	State   StateType `json:"state"`
+	HTMLURL string    `json:"html_url"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required: true
+	Title       string `json:"title" binding:"Required;MaxSize(100)"`
+	Description string `json:"description"`
+	// boards which are created with the project
+	// enum: none,basic_kanban,bug_triage
+	BoardType string `json:"board_type"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title" binding:"MaxSize(100)"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectBoard represents a board (column) of a project
+type ProjectBoard struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	Title     string `json:"title"`
+	// whether the board holds the issues which are not assigned to a board
+	Default bool `json:"default"`
+	Sorting int8 `json:"sorting"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectBoardOption options for creating a project board
+type CreateProjectBoardOption struct {
+	// required: true
+	Title   string `json:"title" binding:"Required;MaxSize(100)"`
+	Sorting int8   `json:"sorting"`
+}
+
+// EditProjectBoardOption options for editing a project board
+type EditProjectBoardOption struct {
+	Title   *string `json:"title" binding:"MaxSize(100)"`
+	Sorting *int8   `json:"sorting"`
+	// make the board the default board of the project
+	Default *bool `json:"default"`
+}
+
+// MoveProjectIssueOption options for adding an issue or pull request to a project or moving it to another board
+type MoveProjectIssueOption struct {
+	// ID of the issue or pull request, not its index in the repository
+	// required: true
+	IssueID int64 `json:"issue_id" binding:"Required"`
+	// the board to move the issue to, 0 for the issues not assigned to a board
+	BoardID int64 `json:"board_id"`
+	// the position of the issue on the board
+	Sorting int64 `json:"sorting"`
+}
@@ -93,3 +93,17 @@ type EditPullRequestOption struct {
 	Deadline       *time.Time `json:"due_date"`
 	RemoveDeadline *bool      `json:"unset_due_date"`
 }
+
+// PullMergeQueueEntry represents a pull request waiting in the merge queue of its base branch
+type PullMergeQueueEntry struct {
+	// Position is the 1-based position of the pull request in the merge queue
+	Position   int64  `json:"position"`
+	MergeStyle string `json:"merge_style"`
+	Queuer     *User  `json:"queuer"`
+	// BaseCommitID is the commit the pull request has been merged onto
+	BaseCommitID string `json:"base_commit_id"`
+	// CommitID is the speculative merge whose status checks have to succeed, pushed to refs/pull/{index}/queue
+	CommitID string `json:"commit_id"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// CodeSearchResults results of a code search grouped by repository
+type CodeSearchResults struct {
+	// number of matching files in all repositories
+	TotalCount   int64                    `json:"total_count"`
+	Languages    []*CodeSearchLanguage    `json:"languages"`
+	Repositories []*CodeSearchRepoResults `json:"repositories"`
+	// whether files have been left out of a substring or regexp search because of the search limits
+	Truncated bool `json:"truncated"`
+}
+
+// CodeSearchLanguage number of matching files of a language
+type CodeSearchLanguage struct {
+	Language string `json:"language"`
+	Color    string `json:"color"`
+	Count    int    `json:"count"`
+}
+
+// CodeSearchRepoResults matching files of a repository
+type CodeSearchRepoResults struct {
+	Repository *Repository       `json:"repository"`
+	Files      []*CodeSearchFile `json:"files"`
+}
+
+// CodeSearchFile a matching file
+type CodeSearchFile struct {
+	Filename string `json:"filename"`
+	CommitID string `json:"commit_id"`
+	Language string `json:"language"`
+	HTMLURL  string `json:"html_url"`
+	// lines around the match
+	Lines []*CodeSearchLine `json:"lines"`
+	// swagger:strfmt date-time
+	Indexed time.Time `json:"indexed_at"`
+}
+
+// CodeSearchLine a line of a matching file
+type CodeSearchLine struct {
+	Number  int    `json:"number"`
+	Content string `json:"content"`
+}
@@ -0,0 +1,23 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// Secret represents a secret of a repository or an organization. Its value is never returned.
+type Secret struct {
+	Name string `json:"name"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateOrUpdateSecretOption options when creating or updating a secret
+type CreateOrUpdateSecretOption struct {
+	// value of the secret
+	// required: true
+	Data string `json:"data" binding:"Required"`
+}
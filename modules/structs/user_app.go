@@ -12,10 +12,13 @@ import (
 // AccessToken represents an API access token.
 // swagger:response AccessToken
 type AccessToken struct {
-	ID             int64  `json:"id"`
-	Name           string `json:"name"`
-	Token          string `json:"sha1"`
-	TokenLastEight string `json:"token_last_eight"`
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Token          string   `json:"sha1"`
+	TokenLastEight string   `json:"token_last_eight"`
+	Scopes         []string `json:"scopes"`
+	// the full names of the repositories the token is restricted to, all repositories if empty
+	Repositories []string `json:"repositories"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -26,6 +29,10 @@ type AccessTokenList []*AccessToken
 // swagger:parameters userCreateToken
 type CreateAccessTokenOption struct {
 	Name string `json:"name" binding:"Required"`
+	// the scopes to grant the token, defaults to all
+	Scopes []string `json:"scopes"`
+	// the full names of the repositories to restrict the token to
+	Repositories []string `json:"repositories"`
 }
 
 // CreateOAuth2ApplicationOptions holds options to create an oauth2 application
@@ -0,0 +1,56 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// WikiCommit page commit/revision
+type WikiCommit struct {
+	ID        string      `json:"sha"`
+	Author    *CommitUser `json:"author"`
+	Committer *CommitUser `json:"committer"`
+	Message   string      `json:"message"`
+}
+
+// WikiPageMetaData wiki page meta information
+type WikiPageMetaData struct {
+	Title      string      `json:"title"`
+	HTMLURL    string      `json:"html_url"`
+	SubURL     string      `json:"sub_url"`
+	LastCommit *WikiCommit `json:"last_commit"`
+}
+
+// WikiPage a wiki page
+type WikiPage struct {
+	*WikiPageMetaData
+	// Page content, base64 encoded
+	ContentBase64 string `json:"content_base64"`
+	CommitCount   int64  `json:"commit_count"`
+	// Sidebar content, base64 encoded
+	Sidebar string `json:"sidebar"`
+	// Footer content, base64 encoded
+	Footer string `json:"footer"`
+}
+
+// WikiPageRevision a wiki page as it was at a given commit
+type WikiPageRevision struct {
+	*WikiCommit
+	// Page content at this revision, base64 encoded
+	ContentBase64 string `json:"content_base64"`
+}
+
+// WikiPageRevisionList revisions of a wiki page
+type WikiPageRevisionList struct {
+	Revisions []*WikiPageRevision `json:"revisions"`
+	Count     int64               `json:"count"`
+}
+
+// CreateWikiPageOptions form for creating or editing a wiki page
+type CreateWikiPageOptions struct {
+	// page title. leave empty to keep unchanged when editing; setting it to a new title renames the page
+	Title string `json:"title"`
+	// content must be base64 encoded
+	ContentBase64 string `json:"content_base64"`
+	// optional commit message summarizing the change
+	Message string `json:"message"`
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/auth/ldap"
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/auth/pam"
+	"code.gitea.io/gitea/modules/auth/saml"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -66,6 +67,7 @@ var (
 			{models.LoginNames[models.LoginSMTP], models.LoginSMTP},
 			{models.LoginNames[models.LoginOAuth2], models.LoginOAuth2},
 			{models.LoginNames[models.LoginSSPI], models.LoginSSPI},
+			{models.LoginNames[models.LoginSAML], models.LoginSAML},
 		}
 		if pam.Supported {
 			items = append(items, dropdownItem{models.LoginNames[models.LoginPAM], models.LoginPAM})
@@ -104,6 +106,8 @@ func NewAuthSource(ctx *context.Context) {
 	ctx.Data["SSPISeparatorReplacement"] = "_"
 	ctx.Data["SSPIDefaultLanguage"] = ""
 
+	ctx.Data["saml_name_id_format"] = saml.NameIDFormatUnspecified
+
 	// only the first as default
 	for key := range models.OAuth2Providers {
 		ctx.Data["oauth2_provider"] = key
@@ -142,6 +146,8 @@ func parseLDAPConfig(form forms.AuthenticationForm) *models.LDAPConfig {
 			GroupFilter:           form.GroupFilter,
 			GroupMemberUID:        form.GroupMemberUID,
 			UserUID:               form.UserUID,
+			GroupTeamMap:          form.GroupTeamMap,
+			GroupTeamMapRemoval:   form.GroupTeamMapRemoval,
 			AdminFilter:           form.AdminFilter,
 			RestrictedFilter:      form.RestrictedFilter,
 			AllowDeactivateAll:    form.AllowDeactivateAll,
@@ -180,6 +186,12 @@ func parseOAuth2Config(form forms.AuthenticationForm) *models.OAuth2Config {
 		OpenIDConnectAutoDiscoveryURL: form.OpenIDConnectAutoDiscoveryURL,
 		CustomURLMapping:              customURLMapping,
 		IconURL:                       form.Oauth2IconURL,
+		GroupClaimName:                form.Oauth2GroupClaimName,
+		RequiredGroup:                 form.Oauth2RequiredGroup,
+		AdminGroup:                    form.Oauth2AdminGroup,
+		RestrictedGroup:               form.Oauth2RestrictedGroup,
+		GroupTeamMap:                  form.Oauth2GroupTeamMap,
+		GroupTeamMapRemoval:           form.Oauth2GroupTeamMapRemoval,
 	}
 }
 
@@ -207,6 +219,55 @@ func parseSSPIConfig(ctx *context.Context, form forms.AuthenticationForm) (*mode
 	}, nil
 }
 
+func parseSAMLConfig(ctx *context.Context, form forms.AuthenticationForm) (*models.SAMLConfig, error) {
+	metadata := form.SAMLMetadata
+	if util.IsEmptyString(metadata) {
+		if util.IsEmptyString(form.SAMLMetadataURL) {
+			ctx.Data["Err_SAMLMetadata"] = true
+			return nil, errors.New(ctx.Tr("admin.auths.saml_idp_metadata_required"))
+		}
+		data, err := saml.FetchIdentityProviderMetadata(form.SAMLMetadataURL)
+		if err != nil {
+			ctx.Data["Err_SAMLMetadataURL"] = true
+			return nil, errors.New(ctx.Tr("admin.auths.saml_idp_metadata_fetch_failed", err.Error()))
+		}
+		metadata = string(data)
+	}
+	if _, err := saml.ParseIdentityProviderMetadata([]byte(metadata)); err != nil {
+		ctx.Data["Err_SAMLMetadata"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_invalid_idp_metadata", err.Error()))
+	}
+
+	cert, key := form.SAMLCertificate, form.SAMLPrivateKey
+	if util.IsEmptyString(cert) && util.IsEmptyString(key) {
+		var err error
+		if cert, key, err = saml.GenerateServiceProviderCertificate(setting.Domain); err != nil {
+			return nil, err
+		}
+	} else if err := saml.ValidateServiceProviderKeyPair(cert, key); err != nil {
+		ctx.Data["Err_SAMLCertificate"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_invalid_sp_key_pair", err.Error()))
+	}
+
+	return &models.SAMLConfig{
+		Source: &saml.Source{
+			IdentityProviderMetadata:    metadata,
+			IdentityProviderMetadataURL: form.SAMLMetadataURL,
+			ServiceProviderCertificate:  cert,
+			ServiceProviderPrivateKey:   key,
+			SignRequests:                form.SAMLSignRequests,
+			NameIDFormat:                form.SAMLNameIDFormat,
+			AttributeUsername:           form.SAMLAttributeUsername,
+			AttributeEmail:              form.SAMLAttributeEmail,
+			AttributeFullName:           form.SAMLAttributeFullName,
+			AttributeGroups:             form.SAMLAttributeGroups,
+			RequiredGroup:               form.SAMLRequiredGroup,
+			AdminGroup:                  form.SAMLAdminGroup,
+			AutoRegistration:            form.SAMLAutoRegistration,
+		},
+	}, nil
+}
+
 // NewAuthSourcePost response for adding an auth source
 func NewAuthSourcePost(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AuthenticationForm)
@@ -232,7 +293,13 @@ func NewAuthSourcePost(ctx *context.Context) {
 	var config convert.Conversion
 	switch models.LoginType(form.Type) {
 	case models.LoginLDAP, models.LoginDLDAP:
-		config = parseLDAPConfig(form)
+		ldapConfig := parseLDAPConfig(form)
+		if _, err := ldapConfig.GroupTeamMapping(); err != nil {
+			ctx.Data["Err_GroupTeamMap"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.auths.invalid_group_team_map", err.Error()), tplAuthNew, form)
+			return
+		}
+		config = ldapConfig
 		hasTLS = ldap.SecurityProtocol(form.SecurityProtocol) > ldap.SecurityProtocolUnencrypted
 	case models.LoginSMTP:
 		config = parseSMTPConfig(form)
@@ -242,7 +309,13 @@ func NewAuthSourcePost(ctx *context.Context) {
 			ServiceName: form.PAMServiceName,
 		}
 	case models.LoginOAuth2:
-		config = parseOAuth2Config(form)
+		oauth2Config := parseOAuth2Config(form)
+		if _, err := oauth2Config.GroupTeamMapping(); err != nil {
+			ctx.Data["Err_Oauth2GroupTeamMap"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.auths.oauth2_invalid_group_team_map", err.Error()), tplAuthNew, form)
+			return
+		}
+		config = oauth2Config
 	case models.LoginSSPI:
 		var err error
 		config, err = parseSSPIConfig(ctx, form)
@@ -256,6 +329,13 @@ func NewAuthSourcePost(ctx *context.Context) {
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_of_type_exist"), tplAuthNew, form)
 			return
 		}
+	case models.LoginSAML:
+		var err error
+		config, err = parseSAMLConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
@@ -311,6 +391,9 @@ func EditAuthSource(ctx *context.Context) {
 	if source.IsOAuth2() {
 		ctx.Data["CurrentOAuth2Provider"] = models.OAuth2Providers[source.OAuth2().Provider]
 	}
+	if source.IsSAML() {
+		ctx.Data["SAMLBaseURL"] = saml.BaseURL(source.Name)
+	}
 	ctx.HTML(http.StatusOK, tplAuthEdit)
 }
 
@@ -332,6 +415,9 @@ func EditAuthSourcePost(ctx *context.Context) {
 	}
 	ctx.Data["Source"] = source
 	ctx.Data["HasTLS"] = source.HasTLS()
+	if source.IsSAML() {
+		ctx.Data["SAMLBaseURL"] = saml.BaseURL(source.Name)
+	}
 
 	if ctx.HasError() {
 		ctx.HTML(http.StatusOK, tplAuthEdit)
@@ -341,7 +427,13 @@ func EditAuthSourcePost(ctx *context.Context) {
 	var config convert.Conversion
 	switch models.LoginType(form.Type) {
 	case models.LoginLDAP, models.LoginDLDAP:
-		config = parseLDAPConfig(form)
+		ldapConfig := parseLDAPConfig(form)
+		if _, err := ldapConfig.GroupTeamMapping(); err != nil {
+			ctx.Data["Err_GroupTeamMap"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.auths.invalid_group_team_map", err.Error()), tplAuthEdit, form)
+			return
+		}
+		config = ldapConfig
 	case models.LoginSMTP:
 		config = parseSMTPConfig(form)
 	case models.LoginPAM:
@@ -349,13 +441,25 @@ func EditAuthSourcePost(ctx *context.Context) {
 			ServiceName: form.PAMServiceName,
 		}
 	case models.LoginOAuth2:
-		config = parseOAuth2Config(form)
+		oauth2Config := parseOAuth2Config(form)
+		if _, err := oauth2Config.GroupTeamMapping(); err != nil {
+			ctx.Data["Err_Oauth2GroupTeamMap"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.auths.oauth2_invalid_group_team_map", err.Error()), tplAuthEdit, form)
+			return
+		}
+		config = oauth2Config
 	case models.LoginSSPI:
 		config, err = parseSSPIConfig(ctx, form)
 		if err != nil {
 			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
 			return
 		}
+	case models.LoginSAML:
+		config, err = parseSAMLConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
@@ -245,7 +245,7 @@ func EditUserPost(ctx *context.Context) {
 		}
 	}
 
-	if len(form.Password) > 0 && (u.IsLocal() || u.IsOAuth2()) {
+	if len(form.Password) > 0 && (u.IsLocal() || u.IsOAuth2() || u.IsSAML()) {
 		var err error
 		if len(form.Password) < setting.MinPasswordLength {
 			ctx.Data["Err_Password"] = true
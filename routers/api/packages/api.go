@@ -0,0 +1,103 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/packages/container"
+	"code.gitea.io/gitea/routers/api/packages/generic"
+	"code.gitea.io/gitea/routers/api/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/npm"
+
+	"gitea.com/go-chi/session"
+)
+
+func newRoute() *web.Route {
+	r := web.NewRoute()
+
+	r.Use(session.Sessioner(session.Options{
+		Provider:       setting.SessionConfig.Provider,
+		ProviderConfig: setting.SessionConfig.ProviderConfig,
+		CookieName:     setting.SessionConfig.CookieName,
+		CookiePath:     setting.SessionConfig.CookiePath,
+		Gclifetime:     setting.SessionConfig.Gclifetime,
+		Maxlifetime:    setting.SessionConfig.Maxlifetime,
+		Secure:         setting.SessionConfig.Secure,
+		Domain:         setting.SessionConfig.Domain,
+	}))
+	r.Use(context.APIContexter())
+	if setting.EnableAccessLog {
+		r.Use(context.AccessLogger())
+	}
+	return r
+}
+
+// Routes returns the routes of the package registries below /api/packages
+func Routes() *web.Route {
+	r := newRoute()
+
+	r.Group("/{username}", func() {
+		r.Group("/generic", func() {
+			r.Group("/{packagename}/{packageversion}", func() {
+				r.Delete("", generic.DeletePackage)
+				r.Group("/{filename}", func() {
+					r.Get("", generic.DownloadPackageFile)
+					r.Put("", generic.UploadPackage)
+					r.Delete("", generic.DeletePackageFile)
+				})
+			})
+		})
+		r.Group("/npm", func() {
+			r.Group("/{id}", func() {
+				r.Get("", npm.PackageMetadata)
+				r.Put("", npm.UploadPackage)
+				r.Get("/-/{version}/{filename}", npm.DownloadPackageFile)
+			})
+		})
+		r.Group("/maven", func() {
+			r.Get("/*", maven.DownloadPackageFile)
+			r.Head("/*", maven.DownloadPackageFile)
+			r.Put("/*", maven.UploadPackageFile)
+		})
+	}, context.PackageAssignment())
+
+	return r
+}
+
+// ContainerRoutes returns the routes of the container registry, which clients expect below /v2
+func ContainerRoutes() *web.Route {
+	r := newRoute()
+
+	r.Use(container.SetDistributionHeader())
+
+	r.Get("", container.ReqContainerAccess)
+	r.Group("/{username}/{image}", func() {
+		r.Group("/blobs/uploads", func() {
+			r.Post("", container.InitiateUploadBlob)
+			r.Group("/{uuid}", func() {
+				r.Get("", container.GetUploadBlob)
+				r.Patch("", container.UploadBlob)
+				r.Put("", container.EndUploadBlob)
+				r.Delete("", container.CancelUploadBlob)
+			})
+		})
+		r.Group("/blobs/{digest}", func() {
+			r.Head("", container.HeadBlob)
+			r.Get("", container.GetBlob)
+			r.Delete("", container.Unsupported)
+		})
+		r.Group("/manifests/{reference}", func() {
+			r.Put("", container.UploadManifest)
+			r.Head("", container.HeadManifest)
+			r.Get("", container.GetManifest)
+			r.Delete("", container.DeleteManifest)
+		})
+		r.Get("/tags/list", container.GetTagsList)
+	}, context.PackageAssignment())
+
+	return r
+}
@@ -0,0 +1,752 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// blobsVersion is the internal version which holds the blobs and manifests of an image
+	blobsVersion = "_blobs"
+
+	maxManifestSize = 10 * 1024 * 1024
+)
+
+var uploadUUIDPattern = regexp.MustCompile(`\A[a-f0-9-]{36}\z`)
+
+// namedError is an error with one of the error codes of the distribution specification
+type namedError struct {
+	Code       string
+	StatusCode int
+	Message    string
+}
+
+func (e *namedError) Error() string {
+	return e.Message
+}
+
+var (
+	errBlobUnknown         = &namedError{Code: "BLOB_UNKNOWN", StatusCode: http.StatusNotFound, Message: "blob unknown to registry"}
+	errBlobUploadInvalid   = &namedError{Code: "BLOB_UPLOAD_INVALID", StatusCode: http.StatusBadRequest, Message: "blob upload invalid"}
+	errBlobUploadUnknown   = &namedError{Code: "BLOB_UPLOAD_UNKNOWN", StatusCode: http.StatusNotFound, Message: "blob upload unknown to registry"}
+	errDigestInvalid       = &namedError{Code: "DIGEST_INVALID", StatusCode: http.StatusBadRequest, Message: "provided digest did not match uploaded content"}
+	errManifestBlobUnknown = &namedError{Code: "MANIFEST_BLOB_UNKNOWN", StatusCode: http.StatusNotFound, Message: "manifest references a manifest or blob unknown to registry"}
+	errManifestInvalid     = &namedError{Code: "MANIFEST_INVALID", StatusCode: http.StatusBadRequest, Message: "manifest invalid"}
+	errManifestUnknown     = &namedError{Code: "MANIFEST_UNKNOWN", StatusCode: http.StatusNotFound, Message: "manifest unknown"}
+	errNameInvalid         = &namedError{Code: "NAME_INVALID", StatusCode: http.StatusBadRequest, Message: "invalid repository name"}
+	errNameUnknown         = &namedError{Code: "NAME_UNKNOWN", StatusCode: http.StatusNotFound, Message: "repository name not known to registry"}
+	errUnauthorized        = &namedError{Code: "UNAUTHORIZED", StatusCode: http.StatusUnauthorized, Message: "authentication required"}
+	errDenied              = &namedError{Code: "DENIED", StatusCode: http.StatusForbidden, Message: "requested access to the resource is denied"}
+	errUnsupported         = &namedError{Code: "UNSUPPORTED", StatusCode: http.StatusMethodNotAllowed, Message: "the operation is unsupported"}
+)
+
+type errorResponse struct {
+	Errors []errorResponseItem `json:"errors"`
+}
+
+type errorResponseItem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// apiError responds in the error format of the distribution specification
+func apiError(ctx *context.APIContext, status int, err error) {
+	helper.LogError(status, err)
+
+	var named *namedError
+	if !errors.As(err, &named) {
+		switch status {
+		case http.StatusUnauthorized:
+			named = errUnauthorized
+		case http.StatusForbidden:
+			named = errDenied
+		default:
+			message := http.StatusText(status)
+			if err != nil && status != http.StatusInternalServerError {
+				message = err.Error()
+			}
+			named = &namedError{Code: "UNKNOWN", StatusCode: status, Message: message}
+		}
+	}
+
+	ctx.JSON(named.StatusCode, errorResponse{
+		Errors: []errorResponseItem{{Code: named.Code, Message: named.Message}},
+	})
+}
+
+func apiErrorDefined(ctx *context.APIContext, err *namedError) {
+	apiError(ctx, err.StatusCode, err)
+}
+
+func setResponseHeaders(ctx *context.APIContext, location, digest, uploadUUID string) {
+	if location != "" {
+		ctx.Resp.Header().Set("Location", location)
+	}
+	if digest != "" {
+		ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	}
+	if uploadUUID != "" {
+		ctx.Resp.Header().Set("Docker-Upload-UUID", uploadUUID)
+	}
+}
+
+func imageURL(ctx *context.APIContext) string {
+	return fmt.Sprintf("/v2/%s/%s", ctx.Package.Owner.LowerName, ctx.Params("image"))
+}
+
+// SetDistributionHeader marks the responses as responses of a registry implementing the distribution specification
+func SetDistributionHeader() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		ctx.Resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+	}
+}
+
+// ReqContainerAccess is the endpoint clients use to check whether they are authenticated
+func ReqContainerAccess(ctx *context.APIContext) {
+	if ctx.User == nil {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package Registry"`)
+		apiErrorDefined(ctx, errUnauthorized)
+		return
+	}
+	ctx.JSON(http.StatusOK, struct{}{})
+}
+
+// loadImage loads the image given by the route parameters after checking the doer has the access mode to it.
+// The image may be nil if it does not exist yet and write access is requested.
+func loadImage(ctx *context.APIContext, mode models.AccessMode) *models.Package {
+	image := ctx.Params("image")
+	if !container_module.ImageNamePattern.MatchString(image) {
+		apiErrorDefined(ctx, errNameInvalid)
+		return nil
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageContainer, image)
+	if err != nil && err != models.ErrPackageNotExist {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil
+	}
+	if !helper.CheckPackageAccess(ctx, p, mode, func(status int, err error) { apiError(ctx, status, err) }) {
+		return nil
+	}
+	if p == nil && mode < models.AccessModeWrite {
+		apiErrorDefined(ctx, errNameUnknown)
+	}
+	return p
+}
+
+func uploadPath(uploadUUID string) string {
+	return filepath.Join(setting.Packages.ChunkedUploadPath, uploadUUID)
+}
+
+// InitiateUploadBlob starts an upload of a blob. If the digest is given, the body is the whole blob.
+func InitiateUploadBlob(ctx *context.APIContext) {
+	if loadImage(ctx, models.AccessModeWrite); ctx.Written() {
+		return
+	}
+
+	if digest := ctx.Query("digest"); digest != "" {
+		buf := helper.ReadUpload(ctx, func(status int, err error) { apiError(ctx, status, err) })
+		if buf == nil {
+			return
+		}
+		defer buf.Close()
+
+		storeBlob(ctx, digest, buf)
+		return
+	}
+
+	if err := os.MkdirAll(setting.Packages.ChunkedUploadPath, os.ModePerm); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	uploadUUID := uuid.New().String()
+	f, err := os.Create(uploadPath(uploadUUID))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	f.Close()
+
+	setResponseHeaders(ctx, imageURL(ctx)+"/blobs/uploads/"+uploadUUID, "", uploadUUID)
+	ctx.Resp.Header().Set("Range", "0-0")
+	ctx.Status(http.StatusAccepted)
+}
+
+// openUpload opens the file of the upload given by the route parameters
+func openUpload(ctx *context.APIContext, flag int) (*os.File, string) {
+	uploadUUID := ctx.Params("uuid")
+	if !uploadUUIDPattern.MatchString(uploadUUID) {
+		apiErrorDefined(ctx, errBlobUploadUnknown)
+		return nil, ""
+	}
+	f, err := os.OpenFile(uploadPath(uploadUUID), flag, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			apiErrorDefined(ctx, errBlobUploadUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, ""
+	}
+	return f, uploadUUID
+}
+
+// appendToUpload appends the request body to the upload and returns the new size of the upload
+func appendToUpload(ctx *context.APIContext, f *os.File) (int64, bool) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return 0, false
+	}
+
+	if contentRange := ctx.Req.Header.Get("Content-Range"); contentRange != "" {
+		parts := strings.SplitN(contentRange, "-", 2)
+		if start, err := strconv.ParseInt(parts[0], 10, 64); err != nil || start != size {
+			ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", size-1))
+			apiError(ctx, http.StatusRequestedRangeNotSatisfiable, errBlobUploadInvalid)
+			return 0, false
+		}
+	}
+
+	var r io.Reader = ctx.Req.Body
+	if setting.Packages.MaxFileSize > -1 {
+		r = io.LimitReader(r, setting.Packages.MaxFileSize-size+1)
+	}
+	n, err := io.Copy(f, r)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return 0, false
+	}
+	size += n
+	if setting.Packages.MaxFileSize > -1 && size > setting.Packages.MaxFileSize {
+		apiError(ctx, http.StatusRequestEntityTooLarge, packages_module.ErrFileTooLarge)
+		return 0, false
+	}
+	return size, true
+}
+
+// UploadBlob appends a chunk to an upload
+func UploadBlob(ctx *context.APIContext) {
+	if loadImage(ctx, models.AccessModeWrite); ctx.Written() {
+		return
+	}
+
+	f, uploadUUID := openUpload(ctx, os.O_RDWR)
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	size, ok := appendToUpload(ctx, f)
+	if !ok {
+		return
+	}
+
+	setResponseHeaders(ctx, imageURL(ctx)+"/blobs/uploads/"+uploadUUID, "", uploadUUID)
+	ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", size-1))
+	ctx.Status(http.StatusAccepted)
+}
+
+// GetUploadBlob reports the progress of an upload
+func GetUploadBlob(ctx *context.APIContext) {
+	if loadImage(ctx, models.AccessModeWrite); ctx.Written() {
+		return
+	}
+
+	f, uploadUUID := openUpload(ctx, os.O_RDONLY)
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx, imageURL(ctx)+"/blobs/uploads/"+uploadUUID, "", uploadUUID)
+	ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", fi.Size()-1))
+	ctx.Status(http.StatusNoContent)
+}
+
+// EndUploadBlob completes an upload with the optional last chunk in the body and stores the blob
+func EndUploadBlob(ctx *context.APIContext) {
+	if loadImage(ctx, models.AccessModeWrite); ctx.Written() {
+		return
+	}
+
+	f, uploadUUID := openUpload(ctx, os.O_RDWR)
+	if f == nil {
+		return
+	}
+	defer func() {
+		f.Close()
+		if err := os.Remove(uploadPath(uploadUUID)); err != nil && !os.IsNotExist(err) {
+			log.Error("Unable to remove upload %s: %v", uploadUUID, err)
+		}
+	}()
+
+	if _, ok := appendToUpload(ctx, f); !ok {
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(f, setting.Packages.MaxFileSize)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	storeBlob(ctx, ctx.Query("digest"), buf)
+}
+
+// CancelUploadBlob aborts an upload
+func CancelUploadBlob(ctx *context.APIContext) {
+	if loadImage(ctx, models.AccessModeWrite); ctx.Written() {
+		return
+	}
+
+	f, uploadUUID := openUpload(ctx, os.O_RDONLY)
+	if f == nil {
+		return
+	}
+	f.Close()
+
+	if err := os.Remove(uploadPath(uploadUUID)); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// storeBlob verifies the digest of the content and adds it to the blobs of the image
+func storeBlob(ctx *context.APIContext, digest string, buf *packages_module.HashedBuffer) {
+	if !container_module.DigestPattern.MatchString(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+	if _, _, hashSHA256, _ := buf.Sums(); "sha256:"+hashSHA256 != digest {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	if err := addToBlobs(ctx, digest, buf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx, imageURL(ctx)+"/blobs/"+digest, digest, "")
+	ctx.Status(http.StatusCreated)
+}
+
+// addToBlobs adds the content as file named by its digest to the internal version of the image
+func addToBlobs(ctx *context.APIContext, digest string, buf *packages_module.HashedBuffer) error {
+	pv, err := packages_service.CreatePackageVersion(&packages_service.PackageInfo{
+		Owner:      ctx.Package.Owner,
+		Creator:    ctx.User,
+		Type:       models.PackageContainer,
+		Name:       ctx.Params("image"),
+		Version:    blobsVersion,
+		IsInternal: true,
+	}, true)
+	if err != nil {
+		return err
+	}
+	if _, err := packages_service.AddFileToPackageVersion(pv, &packages_service.FileInfo{
+		Filename: digest,
+		Data:     buf,
+	}); err != nil && err != models.ErrPackageFileAlreadyExist {
+		return err
+	}
+	return nil
+}
+
+// getBlob returns the blob with the digest if a file of the image references it
+func getBlob(ctx *context.APIContext, p *models.Package, digest string) *models.PackageBlob {
+	if !container_module.DigestPattern.MatchString(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return nil
+	}
+	pb, err := models.GetPackageBlobForPackage(p.ID, strings.TrimPrefix(digest, "sha256:"))
+	if err != nil {
+		if err == models.ErrPackageBlobNotExist {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	return pb
+}
+
+// HeadBlob reports whether the image has the blob
+func HeadBlob(ctx *context.APIContext) {
+	p := loadImage(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+	pb := getBlob(ctx, p, ctx.Params("digest"))
+	if pb == nil {
+		return
+	}
+
+	setResponseHeaders(ctx, "", ctx.Params("digest"), "")
+	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(pb.Size, 10))
+	ctx.Status(http.StatusOK)
+}
+
+// GetBlob serves a blob of the image
+func GetBlob(ctx *context.APIContext) {
+	p := loadImage(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+	pb := getBlob(ctx, p, ctx.Params("digest"))
+	if pb == nil {
+		return
+	}
+
+	s, err := packages_service.OpenPackageBlob(pb)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	setResponseHeaders(ctx, "", ctx.Params("digest"), "")
+	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(pb.Size, 10))
+	ctx.Status(http.StatusOK)
+	if _, err := io.Copy(ctx.Resp, s); err != nil {
+		log.Error("Unable to serve blob %s: %v", pb.HashSHA256, err)
+	}
+}
+
+// UploadManifest stores a manifest. The referenced blobs and manifests must have been uploaded before.
+// If the reference is a tag, the tag is created or moved to the manifest.
+func UploadManifest(ctx *context.APIContext) {
+	p := loadImage(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	reference := ctx.Params("reference")
+	isDigest := container_module.DigestPattern.MatchString(reference)
+	if !isDigest && !container_module.TagPattern.MatchString(reference) {
+		apiErrorDefined(ctx, errManifestInvalid)
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(ctx.Req.Body, maxManifestSize)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	content, err := ioutil.ReadAll(buf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	manifest, err := container_module.ParseManifest(content, ctx.Req.Header.Get("Content-Type"))
+	if err != nil {
+		apiErrorDefined(ctx, errManifestInvalid)
+		return
+	}
+
+	_, _, hashSHA256, _ := buf.Sums()
+	digest := "sha256:" + hashSHA256
+	if isDigest && digest != reference {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	for _, d := range append(manifest.Blobs, manifest.Manifests...) {
+		if p == nil {
+			apiErrorDefined(ctx, errManifestBlobUnknown)
+			return
+		}
+		if _, err := models.GetPackageBlobForPackage(p.ID, strings.TrimPrefix(d, "sha256:")); err != nil {
+			if err == models.ErrPackageBlobNotExist {
+				apiErrorDefined(ctx, errManifestBlobUnknown)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if err := addToBlobs(ctx, digest, buf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !isDigest {
+		if err := tagManifest(ctx, reference, digest, buf); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	setResponseHeaders(ctx, imageURL(ctx)+"/manifests/"+reference, digest, "")
+	ctx.Status(http.StatusCreated)
+}
+
+// tagManifest creates the version of the tag or replaces the manifest of an existing one
+func tagManifest(ctx *context.APIContext, tag, digest string, buf *packages_module.HashedBuffer) error {
+	pv, err := packages_service.CreatePackageVersion(&packages_service.PackageInfo{
+		Owner:   ctx.Package.Owner,
+		Creator: ctx.User,
+		Type:    models.PackageContainer,
+		Name:    ctx.Params("image"),
+		Version: tag,
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+	if err != nil {
+		return err
+	}
+	for _, pf := range pfs {
+		if pf.Name == digest {
+			return nil
+		}
+		if err := models.DeletePackageFile(pf); err != nil {
+			return err
+		}
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = packages_service.AddFileToPackageVersion(pv, &packages_service.FileInfo{
+		Filename: digest,
+		Data:     buf,
+	})
+	return err
+}
+
+// getManifestFile returns the manifest file of the reference, which is a tag or a digest
+func getManifestFile(ctx *context.APIContext, p *models.Package) (*models.PackageVersion, *models.PackageFile) {
+	reference := ctx.Params("reference")
+	version, filename := reference, ""
+	if container_module.DigestPattern.MatchString(reference) {
+		version, filename = blobsVersion, reference
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, version)
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			apiErrorDefined(ctx, errManifestUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+
+	var pf *models.PackageFile
+	if filename != "" {
+		pf, err = models.GetPackageFileByName(pv.ID, filename)
+	} else {
+		var pfs []*models.PackageFile
+		if pfs, err = models.GetPackageFilesByVersionID(pv.ID); err == nil && len(pfs) == 0 {
+			err = models.ErrPackageFileNotExist
+		} else if err == nil {
+			pf = pfs[0]
+		}
+	}
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiErrorDefined(ctx, errManifestUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+	return pv, pf
+}
+
+// serveManifest serves the manifest of the reference with its media type. The content is omitted for HEAD requests.
+func serveManifest(ctx *context.APIContext, withContent bool) {
+	p := loadImage(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+	pv, pf := getManifestFile(ctx, p)
+	if pf == nil {
+		return
+	}
+
+	s, err := packages_service.OpenPackageFile(pv, pf, withContent && !pv.IsInternal)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(s, maxManifestSize))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	manifest, err := container_module.ParseManifest(content, "")
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setResponseHeaders(ctx, "", pf.Name, "")
+	ctx.Resp.Header().Set("Content-Type", manifest.MediaType)
+	ctx.Resp.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	ctx.Status(http.StatusOK)
+	if withContent {
+		_, _ = ctx.Resp.Write(content)
+	}
+}
+
+// HeadManifest reports whether the manifest exists
+func HeadManifest(ctx *context.APIContext) {
+	serveManifest(ctx, false)
+}
+
+// GetManifest serves a manifest
+func GetManifest(ctx *context.APIContext) {
+	serveManifest(ctx, true)
+}
+
+// DeleteManifest deletes a tag, or a manifest and all tags referencing it
+func DeleteManifest(ctx *context.APIContext) {
+	p := loadImage(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+	if p == nil {
+		apiErrorDefined(ctx, errNameUnknown)
+		return
+	}
+
+	reference := ctx.Params("reference")
+	if !container_module.DigestPattern.MatchString(reference) {
+		pv, _ := getManifestFile(ctx, p)
+		if pv == nil {
+			return
+		}
+		if err := models.DeletePackageVersion(pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		ctx.Status(http.StatusAccepted)
+		return
+	}
+
+	_, pf := getManifestFile(ctx, p)
+	if pf == nil {
+		return
+	}
+	pvs, err := models.GetPackageVersionsByPackageID(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	for _, pv := range pvs {
+		if _, err := models.GetPackageFileByName(pv.ID, reference); err == models.ErrPackageFileNotExist {
+			continue
+		} else if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if err := models.DeletePackageVersion(pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err := models.DeletePackageFile(pf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+// GetTagsList lists the tags of the image, paginated with the n and last query parameters
+func GetTagsList(ctx *context.APIContext) {
+	p := loadImage(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	pvs, err := models.GetPackageVersionsByPackageID(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	tags := make([]string, 0, len(pvs))
+	for _, pv := range pvs {
+		tags = append(tags, pv.Version)
+	}
+	sort.Strings(tags)
+
+	if last := ctx.Query("last"); last != "" {
+		i := sort.SearchStrings(tags, last)
+		if i < len(tags) && tags[i] == last {
+			i++
+		}
+		tags = tags[i:]
+	}
+	if n := ctx.QueryInt("n"); n > 0 && n < len(tags) {
+		tags = tags[:n]
+		ctx.Resp.Header().Set("Link", fmt.Sprintf(`<%s/tags/list?n=%d&last=%s>; rel="next"`, imageURL(ctx), n, tags[n-1]))
+	}
+
+	ctx.JSON(http.StatusOK, struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}{
+		Name: ctx.Package.Owner.LowerName + "/" + p.LowerName,
+		Tags: tags,
+	})
+}
+
+// Unsupported responds to the operations of the specification the registry does not implement
+func Unsupported(ctx *context.APIContext) {
+	apiErrorDefined(ctx, errUnsupported)
+}
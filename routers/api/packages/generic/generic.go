@@ -0,0 +1,174 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package generic
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	packageNameRegex = regexp.MustCompile(`\A[A-Za-z0-9\.\_\-\+]+\z`)
+	filenameRegex    = packageNameRegex
+
+	errInvalidName = errors.New("Package name, version or filename is invalid")
+)
+
+func apiError(ctx *context.APIContext, status int, err error) {
+	helper.LogError(status, err)
+	message := http.StatusText(status)
+	if err != nil && status != http.StatusInternalServerError {
+		message = err.Error()
+	}
+	ctx.PlainText(status, []byte(message))
+}
+
+// loadVersion loads the version given by the route parameters after checking the doer has the access mode to it
+func loadVersion(ctx *context.APIContext, mode models.AccessMode) *models.PackageVersion {
+	errorFn := func(status int, err error) { apiError(ctx, status, err) }
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageGeneric, ctx.Params("packagename"))
+	if err != nil {
+		if err == models.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	if !helper.CheckPackageAccess(ctx, p, mode, errorFn) {
+		return nil
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, ctx.Params("packageversion"))
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	pv.Package = p
+	return pv
+}
+
+// DownloadPackageFile serves the specific generic package file
+func DownloadPackageFile(ctx *context.APIContext) {
+	pv := loadVersion(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFileByName(pv.ID, ctx.Params("filename"))
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf, func(status int, err error) { apiError(ctx, status, err) })
+}
+
+// UploadPackage uploads the specific generic package file.
+// The version and the package are created if they do not exist yet.
+func UploadPackage(ctx *context.APIContext) {
+	errorFn := func(status int, err error) { apiError(ctx, status, err) }
+
+	packageName, packageVersion, filename := ctx.Params("packagename"), ctx.Params("packageversion"), ctx.Params("filename")
+	if !packageNameRegex.MatchString(packageName) || !packageNameRegex.MatchString(packageVersion) || !filenameRegex.MatchString(filename) {
+		apiError(ctx, http.StatusBadRequest, errInvalidName)
+		return
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageGeneric, packageName)
+	if err != nil && err != models.ErrPackageNotExist {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !helper.CheckPackageAccess(ctx, p, models.AccessModeWrite, errorFn) {
+		return
+	}
+
+	buf := helper.ReadUpload(ctx, errorFn)
+	if buf == nil {
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageInfo{
+			Owner:   ctx.Package.Owner,
+			Creator: ctx.User,
+			Type:    models.PackageGeneric,
+			Name:    packageName,
+			Version: packageVersion,
+		},
+		&packages_service.FileInfo{
+			Filename: filename,
+			Data:     buf,
+		},
+		true,
+	)
+	if err != nil {
+		if err == models.ErrPackageFileAlreadyExist {
+			apiError(ctx, http.StatusConflict, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeletePackage deletes the specific generic package version
+func DeletePackage(ctx *context.APIContext) {
+	pv := loadVersion(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeletePackageVersion(pv); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeletePackageFile deletes the specific file of a generic package version
+func DeletePackageFile(ctx *context.APIContext) {
+	pv := loadVersion(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFileByName(pv.ID, ctx.Params("filename"))
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := models.DeletePackageFile(pf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var errMissingScope = errors.New("The access token does not have the package scope")
+
+// CheckPackageAccess checks that the doer has the access mode to the package, which may be nil if it does not exist yet.
+// Anonymous users are asked to authenticate, errorFn is called with the status code to respond with otherwise.
+// Access tokens need the package scope, users who sign in with their password must pass two-factor authentication.
+func CheckPackageAccess(ctx *context.APIContext, p *models.Package, mode models.AccessMode, errorFn func(status int, err error)) bool {
+	if scope, ok := ctx.Data["ApiTokenScope"].(models.AccessTokenScope); ok {
+		required := models.AccessTokenScopePackage
+		if mode <= models.AccessModeRead {
+			required = models.AccessTokenScopeReadPackage
+		}
+		if !scope.Has(required) {
+			errorFn(http.StatusForbidden, errMissingScope)
+			return false
+		}
+	} else if ctx.Context.IsBasicAuth && true != ctx.Data["IsApiToken"] {
+		if ctx.CheckForOTP(); ctx.Written() {
+			return false
+		}
+	}
+
+	granted, err := ctx.Package.AccessModeFor(ctx.User, p)
+	if err != nil {
+		errorFn(http.StatusInternalServerError, err)
+		return false
+	}
+	if granted >= mode {
+		return true
+	}
+	if ctx.User == nil {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package Registry"`)
+		errorFn(http.StatusUnauthorized, nil)
+	} else {
+		errorFn(http.StatusForbidden, nil)
+	}
+	return false
+}
+
+// ReadUpload reads the request body into a hashed buffer and reports errors with errorFn
+func ReadUpload(ctx *context.APIContext, errorFn func(status int, err error)) *packages_module.HashedBuffer {
+	buf, err := packages_module.NewHashedBuffer(ctx.Req.Body, setting.Packages.MaxFileSize)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			errorFn(http.StatusRequestEntityTooLarge, err)
+		} else {
+			errorFn(http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	return buf
+}
+
+// ServePackageFile serves the content of a package file
+func ServePackageFile(ctx *context.APIContext, pv *models.PackageVersion, pf *models.PackageFile, errorFn func(status int, err error)) {
+	s, err := packages_service.OpenPackageFile(pv, pf, true)
+	if err != nil {
+		errorFn(http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.ServeContent(pf.Name, s, pf.CreatedUnix.AsTime())
+}
+
+// LogError logs unexpected errors and returns whether there was one
+func LogError(status int, err error) {
+	if status == http.StatusInternalServerError {
+		log.Error("Package registry error: %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const (
+	extensionMD5    = ".md5"
+	extensionSHA1   = ".sha1"
+	extensionSHA256 = ".sha256"
+	extensionSHA512 = ".sha512"
+)
+
+var (
+	checksumExtensions = []string{extensionMD5, extensionSHA1, extensionSHA256, extensionSHA512}
+
+	errChecksumMismatch = errors.New("The checksum does not match the file")
+)
+
+func apiError(ctx *context.APIContext, status int, err error) {
+	helper.LogError(status, err)
+	message := http.StatusText(status)
+	if err != nil && status != http.StatusInternalServerError {
+		message = err.Error()
+	}
+	ctx.PlainText(status, []byte(message))
+}
+
+// splitChecksumExtension returns the filename without a checksum extension and the extension
+func splitChecksumExtension(filename string) (string, string) {
+	ext := path.Ext(filename)
+	for _, e := range checksumExtensions {
+		if ext == e {
+			return strings.TrimSuffix(filename, ext), ext
+		}
+	}
+	return filename, ""
+}
+
+func checksumOfBlob(pb *models.PackageBlob, ext string) string {
+	switch ext {
+	case extensionMD5:
+		return pb.HashMD5
+	case extensionSHA1:
+		return pb.HashSHA1
+	case extensionSHA256:
+		return pb.HashSHA256
+	default:
+		return pb.HashSHA512
+	}
+}
+
+func checksumOfContent(content []byte, ext string) string {
+	var h hash.Hash
+	switch ext {
+	case extensionMD5:
+		h = md5.New()
+	case extensionSHA1:
+		h = sha1.New()
+	case extensionSHA256:
+		h = sha256.New()
+	default:
+		h = sha512.New()
+	}
+	_, _ = h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DownloadPackageFile serves a file of the repository layout. The metadata of an artifact is generated on request.
+func DownloadPackageFile(ctx *context.APIContext) {
+	errorFn := func(status int, err error) { apiError(ctx, status, err) }
+
+	coords, err := maven_module.ParsePath(ctx.Params("*"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageMaven, coords.PackageName())
+	if err != nil {
+		if err == models.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if !helper.CheckPackageAccess(ctx, p, models.AccessModeRead, errorFn) {
+		return
+	}
+
+	filename, ext := splitChecksumExtension(coords.Filename)
+
+	if coords.Version == "" {
+		serveMetadata(ctx, p, coords, ext)
+		return
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, coords.Version)
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pf, err := models.GetPackageFileByName(pv.ID, filename)
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if ext != "" {
+		ctx.PlainText(http.StatusOK, []byte(checksumOfBlob(pf.Blob, ext)))
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf, errorFn)
+}
+
+func serveMetadata(ctx *context.APIContext, p *models.Package, coords *maven_module.Coordinates, ext string) {
+	pvs, err := models.GetPackageVersionsByPackageID(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, models.ErrPackageVersionNotExist)
+		return
+	}
+
+	// The versions are listed from oldest to newest
+	versions := make([]string, 0, len(pvs))
+	for i := len(pvs) - 1; i >= 0; i-- {
+		versions = append(versions, pvs[i].Version)
+	}
+
+	content, err := maven_module.CreateMetadata(coords.GroupID, coords.ArtifactID, versions, pvs[0].CreatedUnix.AsTime())
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if ext != "" {
+		ctx.PlainText(http.StatusOK, []byte(checksumOfContent(content, ext)))
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	_, _ = ctx.Resp.Write(content)
+}
+
+// UploadPackageFile adds a file to an artifact version.
+// Uploaded metadata files are ignored because the metadata is generated, checksum files are validated against the file.
+func UploadPackageFile(ctx *context.APIContext) {
+	errorFn := func(status int, err error) { apiError(ctx, status, err) }
+
+	coords, err := maven_module.ParsePath(ctx.Params("*"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageMaven, coords.PackageName())
+	if err != nil && err != models.ErrPackageNotExist {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !helper.CheckPackageAccess(ctx, p, models.AccessModeWrite, errorFn) {
+		return
+	}
+
+	if coords.Version == "" {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	filename, ext := splitChecksumExtension(coords.Filename)
+	if ext != "" {
+		verifyChecksum(ctx, p, coords.Version, filename, ext)
+		return
+	}
+
+	buf := helper.ReadUpload(ctx, errorFn)
+	if buf == nil {
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageInfo{
+			Owner:   ctx.Package.Owner,
+			Creator: ctx.User,
+			Type:    models.PackageMaven,
+			Name:    coords.PackageName(),
+			Version: coords.Version,
+		},
+		&packages_service.FileInfo{
+			Filename: filename,
+			Data:     buf,
+		},
+		true,
+	)
+	if err != nil {
+		if err == models.ErrPackageFileAlreadyExist {
+			apiError(ctx, http.StatusConflict, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func verifyChecksum(ctx *context.APIContext, p *models.Package, version, filename, ext string) {
+	if p == nil {
+		apiError(ctx, http.StatusNotFound, models.ErrPackageNotExist)
+		return
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, version)
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	pf, err := models.GetPackageFileByName(pv.ID, filename)
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	checksum, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Resp, ctx.Req.Body, 1024))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(string(checksum)), checksumOfBlob(pf.Blob, ext)) {
+		apiError(ctx, http.StatusBadRequest, errChecksumMismatch)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
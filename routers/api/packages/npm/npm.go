@@ -0,0 +1,195 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+func apiError(ctx *context.APIContext, status int, err error) {
+	helper.LogError(status, err)
+	message := http.StatusText(status)
+	if err != nil && status != http.StatusInternalServerError {
+		message = err.Error()
+	}
+	ctx.JSON(status, map[string]string{
+		"error": message,
+	})
+}
+
+func packageNameFromParams(ctx *context.APIContext) string {
+	return ctx.Params("id")
+}
+
+func tarballURL(owner *models.User, name, version, filename string) string {
+	return fmt.Sprintf("%sapi/packages/%s/npm/%s/-/%s/%s", setting.AppURL, url.PathEscape(owner.Name), url.PathEscape(name), url.PathEscape(version), url.PathEscape(filename))
+}
+
+// loadPackage loads the package given by the route parameters after checking the doer may read it
+func loadPackage(ctx *context.APIContext) *models.Package {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageNpm, packageNameFromParams(ctx))
+	if err != nil {
+		if err == models.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	if !helper.CheckPackageAccess(ctx, p, models.AccessModeRead, func(status int, err error) { apiError(ctx, status, err) }) {
+		return nil
+	}
+	return p
+}
+
+// PackageMetadata returns the metadata document of a package which npm clients use to install it
+func PackageMetadata(ctx *context.APIContext) {
+	p := loadPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	pvs, err := models.GetPackageVersionsByPackageID(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, models.ErrPackageNotExist)
+		return
+	}
+
+	versions := make([]*npm_module.VersionInfo, 0, len(pvs))
+	for _, pv := range pvs {
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if len(pfs) == 0 {
+			continue
+		}
+
+		var metadata map[string]interface{}
+		if pv.MetadataJSON != "" {
+			if err := json.Unmarshal([]byte(pv.MetadataJSON), &metadata); err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		versions = append(versions, &npm_module.VersionInfo{
+			Version:    pv.Version,
+			Metadata:   metadata,
+			Created:    pv.CreatedUnix.AsTime(),
+			TarballURL: tarballURL(ctx.Package.Owner, p.Name, pv.Version, pfs[0].Name),
+			HashSHA1:   pfs[0].Blob.HashSHA1,
+			HashSHA512: pfs[0].Blob.HashSHA512,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, npm_module.CreatePackageDocument(p.Name, versions))
+}
+
+// DownloadPackageFile serves the tarball of a package version
+func DownloadPackageFile(ctx *context.APIContext) {
+	p := loadPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, ctx.Params("version"))
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	pf, err := models.GetPackageFileByName(pv.ID, ctx.Params("filename"))
+	if err != nil {
+		if err == models.ErrPackageFileNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf, func(status int, err error) { apiError(ctx, status, err) })
+}
+
+// UploadPackage publishes a new version of a package
+func UploadPackage(ctx *context.APIContext) {
+	errorFn := func(status int, err error) { apiError(ctx, status, err) }
+
+	npmPackage, err := npm_module.ParsePackage(ctx.Req.Body)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if npmPackage.Name != packageNameFromParams(ctx) {
+		apiError(ctx, http.StatusBadRequest, npm_module.ErrInvalidPackageName)
+		return
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageNpm, npmPackage.Name)
+	if err != nil && err != models.ErrPackageNotExist {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !helper.CheckPackageAccess(ctx, p, models.AccessModeWrite, errorFn) {
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(npmPackage.NewReader(), setting.Packages.MaxFileSize)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		&packages_service.PackageInfo{
+			Owner:    ctx.Package.Owner,
+			Creator:  ctx.User,
+			Type:     models.PackageNpm,
+			Name:     npmPackage.Name,
+			Version:  npmPackage.Version,
+			Metadata: npmPackage.Metadata,
+		},
+		&packages_service.FileInfo{
+			Filename: npmPackage.Filename,
+			Data:     buf,
+		},
+		false,
+	)
+	if err != nil {
+		if err == models.ErrPackageVersionAlreadyExist {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
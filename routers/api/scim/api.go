@@ -0,0 +1,247 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package scim implements the SCIM 2.0 provisioning API below /scim/v2, which lets identity providers
+// create, update, deactivate and delete users and manage the members of organization teams.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+
+	"gitea.com/go-chi/session"
+)
+
+func newRoute() *web.Route {
+	r := web.NewRoute()
+
+	r.Use(session.Sessioner(session.Options{
+		Provider:       setting.SessionConfig.Provider,
+		ProviderConfig: setting.SessionConfig.ProviderConfig,
+		CookieName:     setting.SessionConfig.CookieName,
+		CookiePath:     setting.SessionConfig.CookiePath,
+		Gclifetime:     setting.SessionConfig.Gclifetime,
+		Maxlifetime:    setting.SessionConfig.Maxlifetime,
+		Secure:         setting.SessionConfig.Secure,
+		Domain:         setting.SessionConfig.Domain,
+	}))
+	r.Use(context.APIContexter())
+	if setting.EnableAccessLog {
+		r.Use(context.AccessLogger())
+	}
+	return r
+}
+
+// Routes returns the routes of the SCIM API below /scim/v2
+func Routes() *web.Route {
+	r := newRoute()
+
+	r.Group("", func() {
+		r.Get("/ServiceProviderConfig", ServiceProviderConfig)
+		r.Get("/ResourceTypes", ResourceTypes)
+		r.Group("/Users", func() {
+			r.Get("", ListUsers)
+			r.Post("", CreateUser)
+			r.Group("/{id}", func() {
+				r.Get("", GetUser)
+				r.Put("", ReplaceUser)
+				r.Patch("", PatchUser)
+				r.Delete("", DeleteUser)
+			})
+		})
+		r.Group("/Groups", func() {
+			r.Get("", ListGroups)
+			r.Post("", CreateGroup)
+			r.Group("/{id}", func() {
+				r.Get("", GetGroup)
+				r.Put("", ReplaceGroup)
+				r.Patch("", PatchGroup)
+				r.Delete("", DeleteGroup)
+			})
+		})
+	}, reqSiteAdminToken())
+
+	return r
+}
+
+// reqSiteAdminToken only lets site administrators in who authenticate with an access token.
+// The token needs the admin scope.
+func reqSiteAdminToken() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if ctx.User == nil || true != ctx.Data["IsApiToken"] {
+			ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="Gitea SCIM"`)
+			apiError(ctx, http.StatusUnauthorized, "", errors.New("an access token is required"))
+			return
+		}
+		if !ctx.User.IsAdmin {
+			apiError(ctx, http.StatusForbidden, "", errors.New("only site administrators may use the SCIM API"))
+			return
+		}
+		if scope, ok := ctx.Data["ApiTokenScope"].(models.AccessTokenScope); ok && !scope.Has(models.AccessTokenScopeAdmin) {
+			apiError(ctx, http.StatusForbidden, "", fmt.Errorf("token does not have the required scope: %s", models.AccessTokenScopeAdmin))
+		}
+	}
+}
+
+// writeJSON writes obj as SCIM response
+func writeJSON(ctx *context.APIContext, status int, obj interface{}) {
+	ctx.Resp.Header().Set("Content-Type", scim.ContentType+"; charset=utf-8")
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("SCIM: unable to write response: %v", err)
+	}
+}
+
+// apiError writes a SCIM error response, scimType is only set for bad requests
+func apiError(ctx *context.APIContext, status int, scimType string, err error) {
+	detail := http.StatusText(status)
+	if status == http.StatusInternalServerError {
+		log.Error("SCIM error: %v", err)
+	} else if err != nil {
+		detail = err.Error()
+	}
+	writeJSON(ctx, status, &scim.Error{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// requestError is an invalid request with its SCIM error type
+type requestError struct {
+	scimType string
+	err      error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func errInvalid(scimType, format string, args ...interface{}) error {
+	return &requestError{scimType: scimType, err: fmt.Errorf(format, args...)}
+}
+
+// writeRequestError responds to invalid requests with a bad request error and to other errors with an internal server error
+func writeRequestError(ctx *context.APIContext, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		apiError(ctx, http.StatusBadRequest, reqErr.scimType, reqErr.err)
+		return
+	}
+	apiError(ctx, http.StatusInternalServerError, "", err)
+}
+
+// decodeRequest decodes the JSON request body into obj and responds with an error if it is invalid
+func decodeRequest(ctx *context.APIContext, obj interface{}) bool {
+	if err := json.NewDecoder(ctx.Req.Body).Decode(obj); err != nil {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// listOptions returns the 1-based start index and the number of resources requested by the query parameters
+func listOptions(ctx *context.APIContext) (startIndex, count int) {
+	startIndex = ctx.QueryInt("startIndex")
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count = setting.API.DefaultPagingNum
+	if ctx.Query("count") != "" {
+		count = ctx.QueryInt("count")
+	}
+	if count < 0 {
+		count = 0
+	} else if count > setting.API.MaxResponseItems {
+		count = setting.API.MaxResponseItems
+	}
+	return startIndex, count
+}
+
+// loadResources loads count resources starting at the 0-based offset with load, which returns a page of resources and their total number.
+// The SCIM start index does not need to be aligned with pages, so the following page is loaded as well if it is not.
+func loadResources(offset, count int, load func(opts models.ListOptions) ([]interface{}, int64, error)) ([]interface{}, int64, error) {
+	if count == 0 {
+		_, total, err := load(models.ListOptions{Page: 1, PageSize: 1})
+		return nil, total, err
+	}
+
+	opts := models.ListOptions{Page: offset/count + 1, PageSize: count}
+	resources, total, err := load(opts)
+	if err != nil || offset%count == 0 {
+		return resources, total, err
+	}
+
+	skip := offset % count
+	if len(resources) <= skip {
+		return nil, total, nil
+	}
+	opts.Page++
+	next, _, err := load(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	resources = append(resources[skip:], next...)
+	if len(resources) > count {
+		resources = resources[:count]
+	}
+	return resources, total, nil
+}
+
+func resourceLocation(endpoint string, id int64) string {
+	return fmt.Sprintf("%sscim/v2/%s/%d", setting.AppURL, endpoint, id)
+}
+
+// ServiceProviderConfig describes the supported features of the SCIM API
+func ServiceProviderConfig(ctx *context.APIContext) {
+	writeJSON(ctx, http.StatusOK, &scim.ServiceProviderConfig{
+		Schemas:          []string{scim.SchemaServiceProviderConfig},
+		DocumentationURI: "https://docs.gitea.io/en-us/authentication/#scim-20-provisioning",
+		Patch:            scim.Supported{Supported: true},
+		Filter:           scim.FilterSupport{Supported: true, MaxResults: setting.API.MaxResponseItems},
+		AuthenticationSchemes: []scim.AuthenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Access token of a site administrator with the admin scope",
+				Primary:     true,
+			},
+		},
+	})
+}
+
+// ResourceTypes lists the resource types of the SCIM API
+func ResourceTypes(ctx *context.APIContext) {
+	resourceTypes := []interface{}{
+		&scim.ResourceType{
+			Schemas:  []string{scim.SchemaResourceType},
+			ID:       "User",
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   scim.SchemaUser,
+			Meta:     &scim.Meta{ResourceType: "ResourceType", Location: setting.AppURL + "scim/v2/ResourceTypes/User"},
+		},
+		&scim.ResourceType{
+			Schemas:     []string{scim.SchemaResourceType},
+			ID:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Organization team, the display name is the organization and team name separated by a slash",
+			Schema:      scim.SchemaGroup,
+			Meta:        &scim.Meta{ResourceType: "ResourceType", Location: setting.AppURL + "scim/v2/ResourceTypes/Group"},
+		},
+	}
+	writeJSON(ctx, http.StatusOK, scim.NewListResponse(resourceTypes, int64(len(resourceTypes)), 1))
+}
@@ -0,0 +1,537 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+
+	"gitea.com/go-chi/binding"
+)
+
+// groupChanges collects the changes of a team before they are applied
+type groupChanges struct {
+	name    string
+	members map[int64]bool
+}
+
+// groupDisplayName returns the display name of a team, which is the organization and team name separated by a slash
+func groupDisplayName(team *models.Team, orgs map[int64]*models.User) (string, error) {
+	org, ok := orgs[team.OrgID]
+	if !ok {
+		var err error
+		if org, err = models.GetUserByID(team.OrgID); err != nil {
+			return "", err
+		}
+		orgs[team.OrgID] = org
+	}
+	return org.Name + "/" + team.Name, nil
+}
+
+func toSCIMGroup(team *models.Team, orgs map[int64]*models.User, withMembers bool) (*scim.Group, error) {
+	displayName, err := groupDisplayName(team, orgs)
+	if err != nil {
+		return nil, err
+	}
+	sg := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(team.ID, 10),
+		DisplayName: displayName,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     resourceLocation("Groups", team.ID),
+		},
+	}
+	if !withMembers {
+		return sg, nil
+	}
+
+	if err := team.GetMembers(&models.SearchMembersOptions{}); err != nil {
+		return nil, err
+	}
+	sg.Members = make([]scim.Reference, 0, len(team.Members))
+	for _, member := range team.Members {
+		sg.Members = append(sg.Members, scim.Reference{
+			Value:   strconv.FormatInt(member.ID, 10),
+			Display: member.Name,
+			Ref:     resourceLocation("Users", member.ID),
+		})
+	}
+	return sg, nil
+}
+
+// parseGroupDisplayName returns the organization and the team name of a group display name
+func parseGroupDisplayName(displayName string) (*models.User, string, error) {
+	parts := strings.SplitN(displayName, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, "", errInvalid(scim.ErrorTypeInvalidValue, "the display name %q is not of the form organization/team", displayName)
+	}
+	org, err := models.GetOrgByName(parts[0])
+	if err != nil {
+		if models.IsErrOrgNotExist(err) || models.IsErrUserNotExist(err) {
+			return nil, "", errInvalid(scim.ErrorTypeInvalidValue, "organization %s does not exist", parts[0])
+		}
+		return nil, "", err
+	}
+	return org, parts[1], nil
+}
+
+// validateTeamName applies the rules of team names created through the web interface and the API
+func validateTeamName(name string) error {
+	if binding.AlphaDashDotPattern.MatchString(name) || len(name) > 30 {
+		return errInvalid(scim.ErrorTypeInvalidValue, "the team name %q may only contain alphanumeric, dash, underscore and dot characters and may not exceed 30 characters", name)
+	}
+	if err := models.IsUsableTeamName(name); err != nil {
+		return errInvalid(scim.ErrorTypeInvalidValue, "%v", err)
+	}
+	return nil
+}
+
+// loadGroup loads the team given by the id parameter
+func loadGroup(ctx *context.APIContext) *models.Team {
+	id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, "", fmt.Errorf("group %s does not exist", ctx.Params("id")))
+		return nil
+	}
+	team, err := models.GetTeamByID(id)
+	if err != nil {
+		if models.IsErrTeamNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", fmt.Errorf("group %d does not exist", id))
+		} else {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+		}
+		return nil
+	}
+	return team
+}
+
+// findGroups looks up the teams matching the filter
+func findGroups(filter *scim.Filter) ([]interface{}, error) {
+	var team *models.Team
+	var err error
+	switch filter.Attribute {
+	case "id":
+		var id int64
+		if id, err = strconv.ParseInt(filter.Value, 10, 64); err != nil {
+			return nil, nil
+		}
+		team, err = models.GetTeamByID(id)
+	case "displayname":
+		var org *models.User
+		var name string
+		if org, name, err = parseGroupDisplayName(filter.Value); err != nil {
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		team, err = models.GetTeam(org.ID, name)
+	case "externalid":
+		// external ids are not stored, identity providers fall back to the display name
+		return nil, nil
+	default:
+		return nil, errInvalid(scim.ErrorTypeInvalidFilter, "filtering by %s is not supported", filter.Attribute)
+	}
+	if err != nil {
+		if models.IsErrTeamNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []interface{}{team}, nil
+}
+
+// ListGroups lists the teams of all organizations, optionally filtered by display name
+func ListGroups(ctx *context.APIContext) {
+	startIndex, count := listOptions(ctx)
+	withMembers := !strings.Contains(strings.ToLower(ctx.Query("excludedAttributes")), "members")
+
+	var teams []interface{}
+	var total int64
+	var err error
+	if ctx.Query("filter") != "" {
+		filter, err := scim.ParseFilter(ctx.Query("filter"))
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+			return
+		}
+		if teams, err = findGroups(filter); err != nil {
+			writeRequestError(ctx, err)
+			return
+		}
+		total = int64(len(teams))
+		if startIndex > len(teams) {
+			teams = nil
+		} else if teams = teams[startIndex-1:]; len(teams) > count {
+			teams = teams[:count]
+		}
+	} else {
+		teams, total, err = loadResources(startIndex-1, count, func(opts models.ListOptions) ([]interface{}, int64, error) {
+			teams, total, err := models.SearchTeam(&models.SearchTeamOptions{ListOptions: opts})
+			resources := make([]interface{}, 0, len(teams))
+			for _, team := range teams {
+				resources = append(resources, team)
+			}
+			return resources, total, err
+		})
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	orgs := make(map[int64]*models.User)
+	resources := make([]interface{}, 0, len(teams))
+	for _, team := range teams {
+		sg, err := toSCIMGroup(team.(*models.Team), orgs, withMembers)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+		resources = append(resources, sg)
+	}
+	writeJSON(ctx, http.StatusOK, scim.NewListResponse(resources, total, startIndex))
+}
+
+// GetGroup returns a team with its members
+func GetGroup(ctx *context.APIContext) {
+	team := loadGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeGroup(ctx, http.StatusOK, team)
+}
+
+func writeGroup(ctx *context.APIContext, status int, team *models.Team) {
+	sg, err := toSCIMGroup(team, make(map[int64]*models.User), true)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ctx.Resp.Header().Set("Location", sg.Meta.Location)
+	writeJSON(ctx, status, sg)
+}
+
+// memberIDs returns the ids of the referenced users
+func memberIDs(refs []scim.Reference) ([]int64, error) {
+	ids := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		id, err := strconv.ParseInt(ref.Value, 10, 64)
+		if err != nil {
+			return nil, errInvalid(scim.ErrorTypeInvalidValue, "user %s does not exist", ref.Value)
+		}
+		u, err := models.GetUserByID(id)
+		if err != nil || u.Type != models.UserTypeIndividual {
+			if err == nil || models.IsErrUserNotExist(err) {
+				return nil, errInvalid(scim.ErrorTypeInvalidValue, "user %s does not exist", ref.Value)
+			}
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// CreateGroup creates a team in an existing organization. The team gets read access to all units of the repositories added to it.
+func CreateGroup(ctx *context.APIContext) {
+	sg := &scim.Group{}
+	if !decodeRequest(ctx, sg) {
+		return
+	}
+	org, name, err := parseGroupDisplayName(sg.DisplayName)
+	if err == nil {
+		err = validateTeamName(name)
+	}
+	if err != nil {
+		writeRequestError(ctx, err)
+		return
+	}
+	ids, err := memberIDs(sg.Members)
+	if err != nil {
+		writeRequestError(ctx, err)
+		return
+	}
+
+	team := &models.Team{
+		OrgID:     org.ID,
+		Name:      name,
+		Authorize: models.AccessModeRead,
+	}
+	team.Units = make([]*models.TeamUnit, 0, len(models.AllRepoUnitTypes))
+	for _, tp := range models.AllRepoUnitTypes {
+		team.Units = append(team.Units, &models.TeamUnit{
+			OrgID: org.ID,
+			Type:  tp,
+		})
+	}
+	if err := models.NewTeam(team); err != nil {
+		if models.IsErrTeamAlreadyExist(err) {
+			apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+		}
+		return
+	}
+	log.Trace("Team created by SCIM (%s): %s/%s", ctx.User.Name, org.Name, team.Name)
+
+	for _, id := range ids {
+		if err := models.AddTeamMember(team, id); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	writeGroup(ctx, http.StatusCreated, team)
+}
+
+// newGroupChanges returns the changes of a team starting with its current members
+func newGroupChanges(team *models.Team) (*groupChanges, error) {
+	if err := team.GetMembers(&models.SearchMembersOptions{}); err != nil {
+		return nil, err
+	}
+	changes := &groupChanges{members: make(map[int64]bool, len(team.Members))}
+	for _, member := range team.Members {
+		changes.members[member.ID] = true
+	}
+	return changes, nil
+}
+
+// ReplaceGroup renames a team and replaces its members
+func ReplaceGroup(ctx *context.APIContext) {
+	team := loadGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	sg := &scim.Group{}
+	if !decodeRequest(ctx, sg) {
+		return
+	}
+
+	changes, err := newGroupChanges(team)
+	if err == nil {
+		err = changes.setDisplayName(team, sg.DisplayName)
+	}
+	if err == nil {
+		err = changes.setMembers(scim.PatchReplace, sg.Members)
+	}
+	if err != nil {
+		writeRequestError(ctx, err)
+		return
+	}
+
+	if saveGroup(ctx, team, changes) {
+		writeGroup(ctx, http.StatusOK, team)
+	}
+}
+
+// PatchGroup renames a team or modifies its members
+func PatchGroup(ctx *context.APIContext) {
+	team := loadGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	req := &scim.PatchRequest{}
+	if !decodeRequest(ctx, req) {
+		return
+	}
+
+	changes, err := newGroupChanges(team)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	for i := range req.Operations {
+		if err := applyGroupOperation(team, changes, &req.Operations[i]); err != nil {
+			writeRequestError(ctx, err)
+			return
+		}
+	}
+
+	if saveGroup(ctx, team, changes) {
+		writeGroup(ctx, http.StatusOK, team)
+	}
+}
+
+func applyGroupOperation(team *models.Team, changes *groupChanges, op *scim.PatchOperation) error {
+	operation, err := op.Operation()
+	if err != nil {
+		return errInvalid(scim.ErrorTypeInvalidSyntax, "%v", err)
+	}
+	if op.Path == "" {
+		if operation == scim.PatchRemove {
+			return errInvalid(scim.ErrorTypeNoTarget, "remove operations need a path")
+		}
+		attributes, err := op.Attributes()
+		if err != nil {
+			return errInvalid(scim.ErrorTypeInvalidValue, "%v", err)
+		}
+		for key, value := range attributes {
+			if err := applyGroupAttribute(team, changes, operation, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return applyGroupAttribute(team, changes, operation, op.Path, op.Value)
+}
+
+// applyGroupAttribute applies an operation on a single attribute, attributes which are not stored are ignored
+func applyGroupAttribute(team *models.Team, changes *groupChanges, operation, attr string, value json.RawMessage) error {
+	path, err := scim.ParsePath(attr)
+	if err != nil {
+		return errInvalid(scim.ErrorTypeInvalidPath, "%v", err)
+	}
+
+	switch path.Attribute {
+	case "displayname":
+		if operation == scim.PatchRemove {
+			return errInvalid(scim.ErrorTypeMutability, "%s cannot be removed", attr)
+		}
+		displayName, err := scim.ParseString(value)
+		if err != nil {
+			return errInvalid(scim.ErrorTypeInvalidValue, "invalid value of %s: %v", attr, err)
+		}
+		return changes.setDisplayName(team, displayName)
+	case "members":
+		var refs []scim.Reference
+		if path.Filter != nil {
+			if path.Filter.Attribute != "value" || operation != scim.PatchRemove {
+				return errInvalid(scim.ErrorTypeInvalidFilter, "only members can be removed by their value")
+			}
+			refs = []scim.Reference{{Value: path.Filter.Value}}
+		} else if len(value) > 0 {
+			if refs, err = scim.ParseReferences(value); err != nil {
+				return errInvalid(scim.ErrorTypeInvalidValue, "invalid value of %s: %v", attr, err)
+			}
+		} else if operation == scim.PatchRemove {
+			operation = scim.PatchReplace
+		}
+		return changes.setMembers(operation, refs)
+	}
+	return nil
+}
+
+// setDisplayName renames the team, which has to stay in its organization
+func (changes *groupChanges) setDisplayName(team *models.Team, displayName string) error {
+	org, name, err := parseGroupDisplayName(displayName)
+	if err != nil {
+		return err
+	}
+	if org.ID != team.OrgID {
+		return errInvalid(scim.ErrorTypeMutability, "teams cannot be moved to another organization")
+	}
+	if name == team.Name {
+		return nil
+	}
+	if team.IsOwnerTeam() {
+		return errInvalid(scim.ErrorTypeMutability, "the owners team cannot be renamed")
+	}
+	if err := validateTeamName(name); err != nil {
+		return err
+	}
+	changes.name = name
+	return nil
+}
+
+// setMembers adds, removes or replaces members
+func (changes *groupChanges) setMembers(operation string, refs []scim.Reference) error {
+	var ids []int64
+	var err error
+	if operation == scim.PatchRemove {
+		// removed members need not exist anymore
+		for _, ref := range refs {
+			if id, err := strconv.ParseInt(ref.Value, 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	} else if ids, err = memberIDs(refs); err != nil {
+		return err
+	}
+
+	if operation == scim.PatchReplace {
+		changes.members = make(map[int64]bool, len(ids))
+	}
+	for _, id := range ids {
+		if operation == scim.PatchRemove {
+			delete(changes.members, id)
+		} else {
+			changes.members[id] = true
+		}
+	}
+	return nil
+}
+
+// saveGroup renames the team and updates its members.
+// New members are added before members are removed so that the owners team can be replaced.
+func saveGroup(ctx *context.APIContext, team *models.Team, changes *groupChanges) bool {
+	if changes.name != "" {
+		team.Name = changes.name
+		if err := models.UpdateTeam(team, false, false); err != nil {
+			if models.IsErrTeamAlreadyExist(err) {
+				apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+			}
+			return false
+		}
+	}
+
+	current := make(map[int64]bool, len(team.Members))
+	for _, member := range team.Members {
+		current[member.ID] = true
+	}
+	for id := range changes.members {
+		if !current[id] {
+			if err := models.AddTeamMember(team, id); err != nil {
+				apiError(ctx, http.StatusInternalServerError, "", err)
+				return false
+			}
+		}
+	}
+	for id := range current {
+		if !changes.members[id] {
+			if err := models.RemoveTeamMember(team, id); err != nil {
+				if models.IsErrLastOrgOwner(err) {
+					apiError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, err)
+				} else {
+					apiError(ctx, http.StatusInternalServerError, "", err)
+				}
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DeleteGroup deletes a team, the owners team of an organization cannot be deleted
+func DeleteGroup(ctx *context.APIContext) {
+	team := loadGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	if team.IsOwnerTeam() {
+		apiError(ctx, http.StatusConflict, "", errors.New("the owners team cannot be deleted"))
+		return
+	}
+
+	if err := models.DeleteTeam(team); err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	log.Trace("Team deleted by SCIM (%s): %d", ctx.User.Name, team.ID)
+
+	ctx.Status(http.StatusNoContent)
+}
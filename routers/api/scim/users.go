@@ -0,0 +1,487 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/password"
+	"code.gitea.io/gitea/modules/scim"
+)
+
+// userChanges collects the changes of a user which need more than updating its columns
+type userChanges struct {
+	name     string
+	password string
+}
+
+func toSCIMUser(u *models.User) (*scim.User, error) {
+	teams, err := models.GetUserTeams(u.ID, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]scim.Reference, 0, len(teams))
+	orgs := make(map[int64]*models.User)
+	for _, team := range teams {
+		displayName, err := groupDisplayName(team, orgs)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, scim.Reference{
+			Value:   strconv.FormatInt(team.ID, 10),
+			Display: displayName,
+			Ref:     resourceLocation("Groups", team.ID),
+		})
+	}
+
+	active := !u.ProhibitLogin
+	su := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		UserName:    u.Name,
+		DisplayName: u.FullName,
+		Active:      &active,
+		Groups:      groups,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      u.CreatedUnix.AsTimePtr(),
+			LastModified: u.UpdatedUnix.AsTimePtr(),
+			Location:     resourceLocation("Users", u.ID),
+		},
+	}
+	if u.FullName != "" {
+		su.Name = &scim.Name{Formatted: u.FullName}
+	}
+	if u.Email != "" {
+		su.Emails = []scim.Email{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	return su, nil
+}
+
+// loadUser loads the user given by the id parameter, organizations are not exposed as users
+func loadUser(ctx *context.APIContext) *models.User {
+	id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, "", fmt.Errorf("user %s does not exist", ctx.Params("id")))
+		return nil
+	}
+	u, err := models.GetUserByID(id)
+	if err != nil || u.Type != models.UserTypeIndividual {
+		if err == nil || models.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "", fmt.Errorf("user %d does not exist", id))
+		} else {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+		}
+		return nil
+	}
+	return u
+}
+
+// findUsers looks up the users matching the filter
+func findUsers(filter *scim.Filter) ([]interface{}, error) {
+	var u *models.User
+	var err error
+	switch filter.Attribute {
+	case "id":
+		var id int64
+		if id, err = strconv.ParseInt(filter.Value, 10, 64); err != nil {
+			return nil, nil
+		}
+		u, err = models.GetUserByID(id)
+	case "username":
+		u, err = models.GetUserByName(filter.Value)
+	case "emails", "emails.value":
+		u, err = models.GetUserByEmail(filter.Value)
+	case "externalid":
+		// external ids are not stored, identity providers fall back to the user name
+		return nil, nil
+	default:
+		return nil, errInvalid(scim.ErrorTypeInvalidFilter, "filtering by %s is not supported", filter.Attribute)
+	}
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if u.Type != models.UserTypeIndividual {
+		return nil, nil
+	}
+	return []interface{}{u}, nil
+}
+
+// ListUsers lists the users, optionally filtered by user name or email address
+func ListUsers(ctx *context.APIContext) {
+	startIndex, count := listOptions(ctx)
+
+	var users []interface{}
+	var total int64
+	var err error
+	if ctx.Query("filter") != "" {
+		filter, err := scim.ParseFilter(ctx.Query("filter"))
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err)
+			return
+		}
+		if users, err = findUsers(filter); err != nil {
+			writeRequestError(ctx, err)
+			return
+		}
+		total = int64(len(users))
+		if startIndex > len(users) {
+			users = nil
+		} else if users = users[startIndex-1:]; len(users) > count {
+			users = users[:count]
+		}
+	} else {
+		users, total, err = loadResources(startIndex-1, count, func(opts models.ListOptions) ([]interface{}, int64, error) {
+			users, total, err := models.SearchUsers(&models.SearchUserOptions{
+				ListOptions: opts,
+				Type:        models.UserTypeIndividual,
+				OrderBy:     models.SearchOrderByID,
+			})
+			resources := make([]interface{}, 0, len(users))
+			for _, u := range users {
+				resources = append(resources, u)
+			}
+			return resources, total, err
+		})
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	resources := make([]interface{}, 0, len(users))
+	for _, u := range users {
+		su, err := toSCIMUser(u.(*models.User))
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+		resources = append(resources, su)
+	}
+	writeJSON(ctx, http.StatusOK, scim.NewListResponse(resources, total, startIndex))
+}
+
+// GetUser returns a user
+func GetUser(ctx *context.APIContext) {
+	u := loadUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeUser(ctx, http.StatusOK, u)
+}
+
+func writeUser(ctx *context.APIContext, status int, u *models.User) {
+	su, err := toSCIMUser(u)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return
+	}
+	ctx.Resp.Header().Set("Location", su.Meta.Location)
+	writeJSON(ctx, status, su)
+}
+
+// checkPassword responds with an error if the password does not meet the complexity requirements or has been pwned
+func checkPassword(ctx *context.APIContext, passwd string) bool {
+	if !password.IsComplexEnough(passwd) {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, errors.New("the password does not meet the complexity requirements"))
+		return false
+	}
+	pwned, err := password.IsPwned(ctx.Req.Context(), passwd)
+	if pwned {
+		if err != nil {
+			log.Error(err.Error())
+		}
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, errors.New("the password has been exposed in a data breach"))
+		return false
+	}
+	return true
+}
+
+// writeUserError responds with the error of creating or updating a user
+func writeUserError(ctx *context.APIContext, err error) {
+	switch {
+	case models.IsErrUserAlreadyExist(err),
+		models.IsErrEmailAlreadyUsed(err):
+		apiError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err)
+	case models.IsErrNameReserved(err),
+		models.IsErrNamePatternNotAllowed(err),
+		models.IsErrNameCharsNotAllowed(err),
+		models.IsErrEmailInvalid(err):
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err)
+	default:
+		writeRequestError(ctx, err)
+	}
+}
+
+// CreateUser creates a local user. Users provisioned without password get a random one and are expected to sign in through an external login source.
+func CreateUser(ctx *context.APIContext) {
+	su := &scim.User{}
+	if !decodeRequest(ctx, su) {
+		return
+	}
+	if su.UserName == "" {
+		apiError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, errors.New("userName is required"))
+		return
+	}
+
+	passwd := su.Password
+	if passwd != "" {
+		if !checkPassword(ctx, passwd) {
+			return
+		}
+	} else {
+		var err error
+		if passwd, err = generate.GetRandomString(32); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	u := &models.User{
+		Name:          su.UserName,
+		FullName:      su.FullName(),
+		Email:         su.PrimaryEmail(),
+		Passwd:        passwd,
+		IsActive:      true,
+		ProhibitLogin: !su.IsActive(),
+		LoginType:     models.LoginPlain,
+	}
+	if err := models.CreateUser(u); err != nil {
+		writeUserError(ctx, err)
+		return
+	}
+	log.Trace("Account created by SCIM (%s): %s", ctx.User.Name, u.Name)
+
+	writeUser(ctx, http.StatusCreated, u)
+}
+
+// ReplaceUser replaces the attributes of a user
+func ReplaceUser(ctx *context.APIContext) {
+	u := loadUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	su := &scim.User{}
+	if !decodeRequest(ctx, su) {
+		return
+	}
+
+	changes := &userChanges{name: su.UserName, password: su.Password}
+	u.FullName = su.FullName()
+	if email := su.PrimaryEmail(); email != "" {
+		u.Email = email
+	}
+	u.ProhibitLogin = !su.IsActive()
+
+	if saveUser(ctx, u, changes) {
+		writeUser(ctx, http.StatusOK, u)
+	}
+}
+
+// PatchUser modifies the attributes of a user
+func PatchUser(ctx *context.APIContext) {
+	u := loadUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	req := &scim.PatchRequest{}
+	if !decodeRequest(ctx, req) {
+		return
+	}
+
+	changes := &userChanges{}
+	for i := range req.Operations {
+		if err := applyUserOperation(u, changes, &req.Operations[i]); err != nil {
+			writeRequestError(ctx, err)
+			return
+		}
+	}
+
+	if saveUser(ctx, u, changes) {
+		writeUser(ctx, http.StatusOK, u)
+	}
+}
+
+func applyUserOperation(u *models.User, changes *userChanges, op *scim.PatchOperation) error {
+	operation, err := op.Operation()
+	if err != nil {
+		return errInvalid(scim.ErrorTypeInvalidSyntax, "%v", err)
+	}
+	if op.Path == "" {
+		if operation == scim.PatchRemove {
+			return errInvalid(scim.ErrorTypeNoTarget, "remove operations need a path")
+		}
+		attributes, err := op.Attributes()
+		if err != nil {
+			return errInvalid(scim.ErrorTypeInvalidValue, "%v", err)
+		}
+		for key, value := range attributes {
+			if err := applyUserAttribute(u, changes, operation, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return applyUserAttribute(u, changes, operation, op.Path, op.Value)
+}
+
+// applyUserAttribute applies an operation on a single attribute, attributes which are not stored are ignored
+func applyUserAttribute(u *models.User, changes *userChanges, operation, attr string, value json.RawMessage) error {
+	path, err := scim.ParsePath(attr)
+	if err != nil {
+		return errInvalid(scim.ErrorTypeInvalidPath, "%v", err)
+	}
+
+	if operation == scim.PatchRemove {
+		switch path.Attribute {
+		case "username", "emails", "active":
+			return errInvalid(scim.ErrorTypeMutability, "%s cannot be removed", attr)
+		case "displayname", "name":
+			u.FullName = ""
+		}
+		return nil
+	}
+
+	switch path.Attribute {
+	case "username":
+		changes.name, err = scim.ParseString(value)
+	case "displayname":
+		u.FullName, err = scim.ParseString(value)
+	case "name":
+		switch path.SubAttribute {
+		case "formatted":
+			u.FullName, err = scim.ParseString(value)
+		case "":
+			name := &scim.Name{}
+			if err = json.Unmarshal(value, name); err == nil {
+				u.FullName = (&scim.User{Name: name}).FullName()
+			}
+		}
+	case "emails":
+		if path.Filter != nil || path.SubAttribute == "value" {
+			u.Email, err = scim.ParseString(value)
+			break
+		}
+		var emails []scim.Email
+		if err = json.Unmarshal(value, &emails); err == nil {
+			if email := (&scim.User{Emails: emails}).PrimaryEmail(); email != "" {
+				u.Email = email
+			}
+		}
+	case "active":
+		var active bool
+		active, err = scim.ParseBool(value)
+		u.ProhibitLogin = !active
+	case "password":
+		changes.password, err = scim.ParseString(value)
+	}
+	if err != nil {
+		return errInvalid(scim.ErrorTypeInvalidValue, "invalid value of %s: %v", attr, err)
+	}
+	return nil
+}
+
+// checkRemovableUser writes an error if the user is the caller or the last site administrator,
+// who must not be deleted or deactivated
+func checkRemovableUser(ctx *context.APIContext, u *models.User) bool {
+	if u.ID == ctx.User.ID {
+		apiError(ctx, http.StatusBadRequest, "", errors.New("you cannot delete or deactivate yourself"))
+		return false
+	}
+	isLast, err := models.IsLastAdminUser(u)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return false
+	}
+	if isLast {
+		apiError(ctx, http.StatusConflict, "", errors.New("the last site administrator cannot be deleted or deactivated"))
+		return false
+	}
+	return true
+}
+
+// saveUser renames the user and changes its password if needed, then updates its columns
+func saveUser(ctx *context.APIContext, u *models.User, changes *userChanges) bool {
+	if u.ProhibitLogin && !checkRemovableUser(ctx, u) {
+		return false
+	}
+
+	if changes.password != "" {
+		if !checkPassword(ctx, changes.password) {
+			return false
+		}
+		var err error
+		if u.Salt, err = models.GetUserSalt(); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return false
+		}
+		if err = u.SetPassword(changes.password); err != nil {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+			return false
+		}
+	}
+
+	if owner, err := models.GetUserByEmail(u.Email); err == nil && owner.ID != u.ID {
+		writeUserError(ctx, models.ErrEmailAlreadyUsed{Email: u.Email})
+		return false
+	} else if err != nil && !models.IsErrUserNotExist(err) {
+		apiError(ctx, http.StatusInternalServerError, "", err)
+		return false
+	}
+
+	if changes.name != "" && changes.name != u.Name {
+		if u.LowerName != strings.ToLower(changes.name) {
+			if err := models.ChangeUserName(u, changes.name); err != nil {
+				writeUserError(ctx, err)
+				return false
+			}
+			log.Trace("User name changed by SCIM: %s -> %s", u.Name, changes.name)
+		}
+		u.Name = changes.name
+		u.LowerName = strings.ToLower(changes.name)
+	}
+
+	if err := models.UpdateUser(u); err != nil {
+		writeUserError(ctx, err)
+		return false
+	}
+	return true
+}
+
+// DeleteUser deletes a user, which fails if it still owns repositories or organizations
+func DeleteUser(ctx *context.APIContext) {
+	u := loadUser(ctx)
+	if ctx.Written() {
+		return
+	}
+	if !checkRemovableUser(ctx, u) {
+		return
+	}
+
+	if err := models.DeleteUser(u); err != nil {
+		if models.IsErrUserOwnRepos(err) ||
+			models.IsErrUserHasOrgs(err) {
+			apiError(ctx, http.StatusConflict, "", err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, "", err)
+		}
+		return
+	}
+	log.Trace("Account deleted by SCIM (%s): %s", ctx.User.Name, u.Name)
+
+	ctx.Status(http.StatusNoContent)
+}
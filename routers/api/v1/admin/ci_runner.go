@@ -0,0 +1,114 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	ci_service "code.gitea.io/gitea/services/ci"
+)
+
+// ListCIRunners lists the registered CI runners
+func ListCIRunners(ctx *context.APIContext) {
+	// swagger:operation GET /admin/ci/runners admin adminListCIRunners
+	// ---
+	// summary: List the registered CI runners
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CIRunnerList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	runners, err := models.GetCIRunners()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCIRunners", err)
+		return
+	}
+
+	apiRunners := make([]*api.CIRunner, 0, len(runners))
+	for _, runner := range runners {
+		apiRunners = append(apiRunners, convert.ToCIRunner(runner))
+	}
+	ctx.JSON(http.StatusOK, apiRunners)
+}
+
+// CreateCIRunner registers a CI runner
+func CreateCIRunner(ctx *context.APIContext) {
+	// swagger:operation POST /admin/ci/runners admin adminCreateCIRunner
+	// ---
+	// summary: Register a CI runner, the token it authenticates with is only returned once
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateCIRunnerOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/CIRunner"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateCIRunnerOption)
+	runner := &models.CIRunner{
+		Name:   form.Name,
+		Labels: form.Labels,
+	}
+	if err := models.CreateCIRunner(runner); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CreateCIRunner", err)
+		return
+	}
+	log.Trace("CI runner %s registered by admin(%s)", runner.Name, ctx.User.Name)
+
+	ctx.JSON(http.StatusCreated, convert.ToCIRunner(runner))
+}
+
+// DeleteCIRunner unregisters a CI runner
+func DeleteCIRunner(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/ci/runners/{id} admin adminDeleteCIRunner
+	// ---
+	// summary: Unregister a CI runner, the jobs it is running are handed to other runners
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the runner
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := ci_service.DeleteRunner(ctx.ParamsInt64(":id")); err != nil {
+		if models.IsErrCIRunnerNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteCIRunner", err)
+		}
+		return
+	}
+	log.Trace("CI runner %d unregistered by admin(%s)", ctx.ParamsInt64(":id"), ctx.User.Name)
+
+	ctx.Status(http.StatusNoContent)
+}
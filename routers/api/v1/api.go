@@ -65,6 +65,7 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
@@ -76,9 +77,12 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/admin"
+	"code.gitea.io/gitea/routers/api/v1/ci"
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
+	"code.gitea.io/gitea/routers/api/v1/packages"
+	"code.gitea.io/gitea/routers/api/v1/project"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	"code.gitea.io/gitea/routers/api/v1/settings"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
@@ -173,6 +177,11 @@ func repoAssignment() func(ctx *context.APIContext) {
 		repo.Owner = owner
 		ctx.Repo.Repository = repo
 
+		if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.HasAccessToRepo(repo.ID) {
+			ctx.NotFound()
+			return
+		}
+
 		ctx.Repo.Permission, err = models.GetUserRepoPermission(repo, ctx.User)
 		if err != nil {
 			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
@@ -204,6 +213,42 @@ func reqToken() func(ctx *context.APIContext) {
 	}
 }
 
+// isRepoScope returns true if the scope grants access to repository contents, which tokens may be restricted to some repositories for
+func isRepoScope(scope string) bool {
+	switch scope {
+	case models.AccessTokenScopeRepo, models.AccessTokenScopeReadRepo, models.AccessTokenScopeIssue, models.AccessTokenScopeReadIssue:
+		return true
+	}
+	return false
+}
+
+// tokenRequiresScopes checks that the access token the request is authenticated with has been granted all given scopes,
+// which applies to personal access tokens and OAuth2 access tokens alike.
+// Write scopes are downgraded to their read-only counterparts for safe methods. Requests authenticated in any other way pass.
+func tokenRequiresScopes(scopes ...string) func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		granted, ok := ctx.Data["ApiTokenScope"].(models.AccessTokenScope)
+		if !ok {
+			return
+		}
+		token, _ := ctx.Data["ApiToken"].(*models.AccessToken)
+		for _, scope := range scopes {
+			if ctx.Req.Method == http.MethodGet || ctx.Req.Method == http.MethodHead {
+				scope = models.AccessTokenReadScope(scope)
+			}
+			if !granted.Has(scope) {
+				ctx.Error(http.StatusForbidden, "tokenRequiresScopes", fmt.Sprintf("token does not have the required scope: %s", scope))
+				return
+			}
+			// Tokens restricted to some repositories may only be used on those
+			if token != nil && token.IsRepoRestricted() && isRepoScope(scope) && ctx.Repo.Repository == nil {
+				ctx.Error(http.StatusForbidden, "tokenRequiresScopes", "token is restricted to specific repositories")
+				return
+			}
+		}
+	}
+}
+
 func reqExploreSignIn() func(ctx *context.APIContext) {
 	return func(ctx *context.APIContext) {
 		if setting.Service.Explore.RequireSigninView && !ctx.IsSigned {
@@ -218,10 +263,24 @@ func reqBasicAuth() func(ctx *context.APIContext) {
 			ctx.Error(http.StatusUnauthorized, "reqBasicAuth", "basic auth required")
 			return
 		}
+		// an access token passed as password must not be able to manage tokens, it could escape its scopes otherwise
+		if true == ctx.Data["IsApiToken"] {
+			ctx.Error(http.StatusUnauthorized, "reqBasicAuth", "basic auth with the password of the user required")
+			return
+		}
 		ctx.CheckForOTP()
 	}
 }
 
+// reqNoAccessToken rejects access tokens, they could escape their scopes by creating credentials with more access
+func reqNoAccessToken() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if true == ctx.Data["IsApiToken"] {
+			ctx.Error(http.StatusUnauthorized, "reqNoAccessToken", "access tokens cannot manage credentials")
+		}
+	}
+}
+
 // reqSiteAdmin user should be the site admin
 func reqSiteAdmin() func(ctx *context.APIContext) {
 	return func(ctx *context.APIContext) {
@@ -580,6 +639,16 @@ func Routes() *web.Route {
 		SignInRequired: setting.Service.RequireSignInView,
 	}))
 
+	// the scopes an access token needs for the routes below
+	var (
+		repoScope    = tokenRequiresScopes(models.AccessTokenScopeRepo)
+		issueScope   = tokenRequiresScopes(models.AccessTokenScopeIssue)
+		orgScope     = tokenRequiresScopes(models.AccessTokenScopeOrg)
+		adminScope   = tokenRequiresScopes(models.AccessTokenScopeAdmin)
+		userScope    = tokenRequiresScopes(models.AccessTokenScopeUser)
+		packageScope = tokenRequiresScopes(models.AccessTokenScopePackage)
+	)
+
 	m.Group("", func() {
 		// Miscellaneous
 		if setting.API.EnableSwagger {
@@ -607,20 +676,21 @@ func Routes() *web.Route {
 			m.Combo("/threads/{id}").
 				Get(notify.GetThread).
 				Patch(notify.ReadThread)
-		}, reqToken())
+		}, reqToken(), userScope)
 
 		// Users
 		m.Group("/users", func() {
-			m.Get("/search", reqExploreSignIn(), user.Search)
+			m.Get("/search", reqExploreSignIn(), userScope, user.Search)
 
 			m.Group("/{username}", func() {
-				m.Get("", reqExploreSignIn(), user.GetInfo)
+				m.Get("", reqExploreSignIn(), userScope, user.GetInfo)
 
 				if setting.Service.EnableUserHeatmap {
-					m.Get("/heatmap", user.GetUserHeatmapData)
+					m.Get("/heatmap", userScope, user.GetUserHeatmapData)
 				}
 
-				m.Get("/repos", reqExploreSignIn(), user.ListUserRepos)
+				m.Get("/repos", reqExploreSignIn(), repoScope, user.ListUserRepos)
+				m.Get("/projects", reqExploreSignIn(), issueScope, user.ListUserProjects)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
@@ -644,81 +714,89 @@ func Routes() *web.Route {
 
 				m.Get("/subscriptions", user.GetWatchedRepos)
 			})
-		}, reqToken())
+		}, reqToken(), userScope)
 
 		m.Group("/user", func() {
-			m.Get("", user.GetAuthenticatedUser)
-			m.Combo("/emails").Get(user.ListEmails).
-				Post(bind(api.CreateEmailOption{}), user.AddEmail).
-				Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
-
-			m.Get("/followers", user.ListMyFollowers)
-			m.Group("/following", func() {
-				m.Get("", user.ListMyFollowing)
-				m.Combo("/{username}").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
-			})
+			m.Group("", func() {
+				m.Get("", user.GetAuthenticatedUser)
+				m.Combo("/emails").Get(user.ListEmails).
+					Post(bind(api.CreateEmailOption{}), user.AddEmail).
+					Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
 
-			m.Group("/keys", func() {
-				m.Combo("").Get(user.ListMyPublicKeys).
-					Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
-				m.Combo("/{id}").Get(user.GetPublicKey).
-					Delete(user.DeletePublicKey)
-			})
-			m.Group("/applications", func() {
-				m.Combo("/oauth2").
-					Get(user.ListOauth2Applications).
-					Post(bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
-				m.Combo("/oauth2/{id}").
-					Delete(user.DeleteOauth2Application).
-					Patch(bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
-					Get(user.GetOauth2Application)
-			}, reqToken())
-
-			m.Group("/gpg_keys", func() {
-				m.Combo("").Get(user.ListMyGPGKeys).
-					Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
-				m.Combo("/{id}").Get(user.GetGPGKey).
-					Delete(user.DeleteGPGKey)
-			})
+				m.Get("/followers", user.ListMyFollowers)
+				m.Group("/following", func() {
+					m.Get("", user.ListMyFollowing)
+					m.Combo("/{username}").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
+				})
+
+				m.Group("/keys", func() {
+					m.Combo("").Get(user.ListMyPublicKeys).
+						Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
+					m.Combo("/{id}").Get(user.GetPublicKey).
+						Delete(user.DeletePublicKey)
+				})
+				m.Group("/applications", func() {
+					m.Combo("/oauth2").
+						Get(user.ListOauth2Applications).
+						Post(reqNoAccessToken(), bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
+					m.Combo("/oauth2/{id}").
+						Delete(user.DeleteOauth2Application).
+						Patch(reqNoAccessToken(), bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
+						Get(user.GetOauth2Application)
+				}, reqToken())
+
+				m.Group("/gpg_keys", func() {
+					m.Combo("").Get(user.ListMyGPGKeys).
+						Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
+					m.Combo("/{id}").Get(user.GetGPGKey).
+						Delete(user.DeleteGPGKey)
+				})
 
-			m.Combo("/repos").Get(user.ListMyRepos).
+				m.Group("/starred", func() {
+					m.Get("", user.GetMyStarredRepos)
+					m.Group("/{username}/{reponame}", func() {
+						m.Get("", user.IsStarring)
+						m.Put("", user.Star)
+						m.Delete("", user.Unstar)
+					}, repoAssignment())
+				})
+
+				m.Get("/subscriptions", user.GetMyWatchedRepos)
+			}, userScope)
+
+			m.Combo("/repos", repoScope).Get(user.ListMyRepos).
 				Post(bind(api.CreateRepoOption{}), repo.Create)
 
-			m.Group("/starred", func() {
-				m.Get("", user.GetMyStarredRepos)
-				m.Group("/{username}/{reponame}", func() {
-					m.Get("", user.IsStarring)
-					m.Put("", user.Star)
-					m.Delete("", user.Unstar)
-				}, repoAssignment())
-			})
-			m.Get("/times", repo.ListMyTrackedTimes)
+			m.Get("/times", issueScope, repo.ListMyTrackedTimes)
 
-			m.Get("/stopwatches", repo.GetStopwatches)
+			m.Get("/stopwatches", issueScope, repo.GetStopwatches)
 
-			m.Get("/subscriptions", user.GetMyWatchedRepos)
+			m.Combo("/projects", issueScope).Get(user.ListMyProjects).
+				Post(bind(api.CreateProjectOption{}), user.CreateMyProject)
 
-			m.Get("/teams", org.ListUserTeams)
+			m.Get("/teams", orgScope, org.ListUserTeams)
 		}, reqToken())
 
 		// Repositories
-		m.Post("/org/{org}/repos", reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
+		m.Post("/org/{org}/repos", reqToken(), repoScope, bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
 
-		m.Combo("/repositories/{id}", reqToken()).Get(repo.GetByID)
+		m.Combo("/repositories/{id}", reqToken(), repoScope).Get(repo.GetByID)
 
 		m.Group("/repos", func() {
-			m.Get("/search", repo.Search)
+			m.Get("/search", repoScope, repo.Search)
 
-			m.Get("/issues/search", repo.SearchIssues)
+			m.Get("/issues/search", issueScope, repo.SearchIssues)
 
-			m.Post("/migrate", reqToken(), bind(api.MigrateRepoOptions{}), repo.Migrate)
+			m.Get("/code/search", repoScope, repo.SearchCode)
+
+			m.Post("/migrate", reqToken(), repoScope, bind(api.MigrateRepoOptions{}), repo.Migrate)
 
 			m.Group("/{username}/{reponame}", func() {
-				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
+				m.Combo("", repoScope).Get(reqAnyRepoReader(), repo.Get).
 					Delete(reqToken(), reqOwner(), repo.Delete).
 					Patch(reqToken(), reqAdmin(), context.RepoRefForAPI, bind(api.EditRepoOption{}), repo.Edit)
-				m.Post("/transfer", reqOwner(), bind(api.TransferRepoOption{}), repo.Transfer)
-				m.Combo("/notifications").
+				m.Post("/transfer", repoScope, reqOwner(), bind(api.TransferRepoOption{}), repo.Transfer)
+				m.Combo("/notifications", userScope).
 					Get(reqToken(), notify.ListRepoNotifications).
 					Put(reqToken(), notify.ReadRepoNotifications)
 				m.Group("/hooks/git", func() {
@@ -728,7 +806,7 @@ func Routes() *web.Route {
 							Patch(bind(api.EditGitHookOption{}), repo.EditGitHook).
 							Delete(repo.DeleteGitHook)
 					})
-				}, reqToken(), reqAdmin(), reqGitHook(), context.ReferencesGitRepo(true))
+				}, reqToken(), repoScope, reqAdmin(), reqGitHook(), context.ReferencesGitRepo(true))
 				m.Group("/hooks", func() {
 					m.Combo("").Get(repo.ListHooks).
 						Post(bind(api.CreateHookOption{}), repo.CreateHook)
@@ -738,29 +816,29 @@ func Routes() *web.Route {
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRefForAPI, repo.TestHook)
 					})
-				}, reqToken(), reqAdmin(), reqWebhooksEnabled())
+				}, reqToken(), repoScope, reqAdmin(), reqWebhooksEnabled())
 				m.Group("/collaborators", func() {
 					m.Get("", reqAnyRepoReader(), repo.ListCollaborators)
 					m.Combo("/{collaborator}").Get(reqAnyRepoReader(), repo.IsCollaborator).
 						Put(reqAdmin(), bind(api.AddCollaboratorOption{}), repo.AddCollaborator).
 						Delete(reqAdmin(), repo.DeleteCollaborator)
-				}, reqToken())
+				}, reqToken(), repoScope)
 				m.Group("/teams", func() {
 					m.Get("", reqAnyRepoReader(), repo.ListTeams)
 					m.Combo("/{team}").Get(reqAnyRepoReader(), repo.IsTeam).
 						Put(reqAdmin(), repo.AddTeam).
 						Delete(reqAdmin(), repo.DeleteTeam)
-				}, reqToken())
-				m.Get("/raw/*", context.RepoRefForAPI, reqRepoReader(models.UnitTypeCode), repo.GetRawFile)
-				m.Get("/archive/*", reqRepoReader(models.UnitTypeCode), repo.GetArchive)
-				m.Combo("/forks").Get(repo.ListForks).
+				}, reqToken(), repoScope)
+				m.Get("/raw/*", repoScope, context.RepoRefForAPI, reqRepoReader(models.UnitTypeCode), repo.GetRawFile)
+				m.Get("/archive/*", repoScope, reqRepoReader(models.UnitTypeCode), repo.GetArchive)
+				m.Combo("/forks", repoScope).Get(repo.ListForks).
 					Post(reqToken(), reqRepoReader(models.UnitTypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
 				m.Group("/branches", func() {
 					m.Get("", repo.ListBranches)
 					m.Get("/*", repo.GetBranch)
 					m.Delete("/*", context.ReferencesGitRepo(false), reqRepoWriter(models.UnitTypeCode), repo.DeleteBranch)
 					m.Post("", reqRepoWriter(models.UnitTypeCode), bind(api.CreateBranchRepoOption{}), repo.CreateBranch)
-				}, reqRepoReader(models.UnitTypeCode))
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
 				m.Group("/branch_protections", func() {
 					m.Get("", repo.ListBranchProtections)
 					m.Post("", bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
@@ -769,21 +847,27 @@ func Routes() *web.Route {
 						m.Patch("", bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection)
 						m.Delete("", repo.DeleteBranchProtection)
 					})
-				}, reqToken(), reqAdmin())
+				}, reqToken(), repoScope, reqAdmin())
 				m.Group("/tags", func() {
 					m.Get("", repo.ListTags)
 					m.Delete("/{tag}", repo.DeleteTag)
-				}, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(true))
+				}, repoScope, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(true))
 				m.Group("/keys", func() {
 					m.Combo("").Get(repo.ListDeployKeys).
 						Post(bind(api.CreateKeyOption{}), repo.CreateDeployKey)
 					m.Combo("/{id}").Get(repo.GetDeployKey).
 						Delete(repo.DeleteDeploykey)
-				}, reqToken(), reqAdmin())
+				}, reqToken(), repoScope, reqAdmin())
+				m.Group("/secrets", func() {
+					m.Get("", repo.ListSecrets)
+					m.Combo("/{secretname}").
+						Put(bind(api.CreateOrUpdateSecretOption{}), repo.CreateOrUpdateSecret).
+						Delete(repo.DeleteSecret)
+				}, reqToken(), repoScope, reqAdmin())
 				m.Group("/times", func() {
 					m.Combo("").Get(repo.ListTrackedTimesByRepository)
 					m.Combo("/{timetrackingusername}").Get(repo.ListTrackedTimesByUser)
-				}, mustEnableIssues, reqToken())
+				}, mustEnableIssues, reqToken(), issueScope)
 				m.Group("/issues", func() {
 					m.Combo("").Get(repo.ListIssues).
 						Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
@@ -840,14 +924,14 @@ func Routes() *web.Route {
 							Post(reqToken(), bind(api.EditReactionOption{}), repo.PostIssueReaction).
 							Delete(reqToken(), bind(api.EditReactionOption{}), repo.DeleteIssueReaction)
 					})
-				}, mustEnableIssuesOrPulls)
+				}, mustEnableIssuesOrPulls, issueScope)
 				m.Group("/labels", func() {
 					m.Combo("").Get(repo.ListLabels).
 						Post(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.CreateLabelOption{}), repo.CreateLabel)
 					m.Combo("/{id}").Get(repo.GetLabel).
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteLabel)
-				})
+				}, issueScope)
 				m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
 				m.Post("/markdown/raw", misc.MarkdownRaw)
 				m.Group("/milestones", func() {
@@ -856,14 +940,16 @@ func Routes() *web.Route {
 					m.Combo("/{id}").Get(repo.GetMilestone).
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
-				})
-				m.Get("/stargazers", repo.ListStargazers)
-				m.Get("/subscribers", repo.ListSubscribers)
+				}, issueScope)
+				m.Combo("/projects", issueScope, reqRepoReader(models.UnitTypeProjects)).Get(repo.ListProjects).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), mustNotBeArchived, bind(api.CreateProjectOption{}), repo.CreateProject)
+				m.Get("/stargazers", repoScope, repo.ListStargazers)
+				m.Get("/subscribers", repoScope, repo.ListSubscribers)
 				m.Group("/subscription", func() {
 					m.Get("", user.IsWatching)
 					m.Put("", reqToken(), user.Watch)
 					m.Delete("", reqToken(), user.Unwatch)
-				})
+				}, userScope)
 				m.Group("/releases", func() {
 					m.Combo("").Get(repo.ListReleases).
 						Post(reqToken(), reqRepoWriter(models.UnitTypeReleases), context.ReferencesGitRepo(false), bind(api.CreateReleaseOption{}), repo.CreateRelease)
@@ -884,9 +970,31 @@ func Routes() *web.Route {
 							Get(repo.GetReleaseByTag).
 							Delete(reqToken(), reqRepoWriter(models.UnitTypeReleases), repo.DeleteReleaseByTag)
 					})
-				}, reqRepoReader(models.UnitTypeReleases))
-				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
-				m.Get("/editorconfig/{filename}", context.RepoRefForAPI, reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
+				}, repoScope, reqRepoReader(models.UnitTypeReleases))
+				if setting.Packages.Enabled {
+					m.Get("/packages", packageScope, reqRepoReader(models.UnitTypeCode), repo.ListPackages)
+				}
+				m.Group("/wiki", func() {
+					m.Combo("/pages").
+						Get(repo.ListWikiPages).
+						Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), bind(api.CreateWikiPageOptions{}), repo.NewWikiPage)
+					m.Combo("/page/{pageName}").
+						Get(repo.GetWikiPage).
+						Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), bind(api.CreateWikiPageOptions{}), repo.EditWikiPage).
+						Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeWiki), repo.DeleteWikiPage)
+					m.Get("/revisions/{pageName}", repo.ListPageRevisions)
+					m.Get("/search", repo.SearchWikiPages)
+				}, repoScope, reqRepoReader(models.UnitTypeWiki))
+				m.Post("/mirror-sync", reqToken(), repoScope, reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
+				m.Post("/push_mirrors-sync", reqToken(), repoScope, reqAdmin(), repo.PushMirrorSync)
+				m.Group("/push_mirrors", func() {
+					m.Combo("").Get(repo.ListPushMirrors).
+						Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
+					m.Combo("/{name}").
+						Delete(repo.DeletePushMirrorByName).
+						Get(repo.GetPushMirrorByName)
+				}, reqToken(), repoScope, reqAdmin())
+				m.Get("/editorconfig/{filename}", repoScope, context.RepoRefForAPI, reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(repo.ListPullRequests).
 						Post(reqToken(), mustNotBeArchived, bind(api.CreatePullRequestOption{}), repo.CreatePullRequest)
@@ -895,9 +1003,12 @@ func Routes() *web.Route {
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Get(".diff", repo.DownloadPullDiff)
 						m.Get(".patch", repo.DownloadPullPatch)
-						m.Post("/update", reqToken(), repo.UpdatePullRequest)
+						m.Post("/update", reqToken(), repoScope, repo.UpdatePullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest)
+							Post(reqToken(), repoScope, mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest).
+							Delete(reqToken(), repoScope, mustNotBeArchived, repo.CancelScheduledAutoMerge)
+						m.Combo("/merge_queue").Get(repo.GetPullMergeQueueEntry).
+							Delete(reqToken(), repoScope, mustNotBeArchived, repo.RemoveFromMergeQueue)
 						m.Group("/reviews", func() {
 							m.Combo("").
 								Get(repo.ListPullReviews).
@@ -917,18 +1028,34 @@ func Routes() *web.Route {
 							Delete(reqToken(), bind(api.PullReviewRequestOptions{}), repo.DeleteReviewRequests).
 							Post(reqToken(), bind(api.PullReviewRequestOptions{}), repo.CreateReviewRequests)
 					})
-				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
+				}, mustAllowPulls, issueScope, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
 					m.Combo("/{sha}").Get(repo.GetCommitStatuses).
 						Post(reqToken(), bind(api.CreateStatusOption{}), repo.NewCommitStatus)
-				}, reqRepoReader(models.UnitTypeCode))
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
 				m.Group("/commits", func() {
 					m.Get("", repo.GetAllCommits)
 					m.Group("/{ref}", func() {
 						m.Get("/status", repo.GetCombinedCommitStatusByRef)
 						m.Get("/statuses", repo.GetCommitStatusesByRef)
+						m.Get("/check-runs", repo.ListCheckRunsByRef)
+					})
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
+				m.Group("/check-runs", func() {
+					m.Post("", reqToken(), reqRepoWriter(models.UnitTypeCode), bind(api.CreateCheckRunOption{}), repo.CreateCheckRun)
+					m.Group("/{id}", func() {
+						m.Combo("").Get(repo.GetCheckRun).
+							Patch(reqToken(), reqRepoWriter(models.UnitTypeCode), bind(api.EditCheckRunOption{}), repo.EditCheckRun)
+						m.Get("/annotations", repo.ListCheckRunAnnotations)
 					})
-				}, reqRepoReader(models.UnitTypeCode))
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
+				if setting.CI.Enabled {
+					m.Group("/ci", func() {
+						m.Get("/runs", repo.ListCIRuns)
+						m.Get("/runs/{id}", repo.GetCIRun)
+						m.Get("/jobs/{id}/logs", repo.GetCIJobLog)
+					}, repoScope, reqRepoReader(models.UnitTypeCode))
+				}
 				m.Group("/git", func() {
 					m.Group("/commits", func() {
 						m.Get("/{sha}", repo.GetSingleCommit)
@@ -938,7 +1065,7 @@ func Routes() *web.Route {
 					m.Get("/trees/{sha}", context.RepoRefForAPI, repo.GetTree)
 					m.Get("/blobs/{sha}", context.RepoRefForAPI, repo.GetBlob)
 					m.Get("/tags/{sha}", context.RepoRefForAPI, repo.GetTag)
-				}, reqRepoReader(models.UnitTypeCode))
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
 				m.Group("/contents", func() {
 					m.Get("", repo.GetContentsList)
 					m.Get("/*", repo.GetContents)
@@ -947,7 +1074,7 @@ func Routes() *web.Route {
 						m.Put("", bind(api.UpdateFileOptions{}), repo.UpdateFile)
 						m.Delete("", bind(api.DeleteFileOptions{}), repo.DeleteFile)
 					}, reqRepoWriter(models.UnitTypeCode), reqToken())
-				}, reqRepoReader(models.UnitTypeCode))
+				}, repoScope, reqRepoReader(models.UnitTypeCode))
 				m.Get("/signing-key.gpg", misc.SigningKey)
 				m.Group("/topics", func() {
 					m.Combo("").Get(repo.ListTopics).
@@ -956,23 +1083,24 @@ func Routes() *web.Route {
 						m.Combo("").Put(reqToken(), repo.AddTopic).
 							Delete(reqToken(), repo.DeleteTopic)
 					}, reqAdmin())
-				}, reqAnyRepoReader())
-				m.Get("/issue_templates", context.ReferencesGitRepo(false), repo.GetIssueTemplates)
-				m.Get("/languages", reqRepoReader(models.UnitTypeCode), repo.GetLanguages)
+				}, repoScope, reqAnyRepoReader())
+				m.Get("/issue_templates", issueScope, context.ReferencesGitRepo(false), repo.GetIssueTemplates)
+				m.Get("/languages", repoScope, reqRepoReader(models.UnitTypeCode), repo.GetLanguages)
 			}, repoAssignment())
 		})
 
 		// Organizations
-		m.Get("/user/orgs", reqToken(), org.ListMyOrgs)
-		m.Get("/users/{username}/orgs", org.ListUserOrgs)
-		m.Post("/orgs", reqToken(), bind(api.CreateOrgOption{}), org.Create)
-		m.Get("/orgs", org.GetAll)
+		m.Get("/user/orgs", reqToken(), orgScope, org.ListMyOrgs)
+		m.Get("/users/{username}/orgs", orgScope, org.ListUserOrgs)
+		m.Post("/orgs", reqToken(), orgScope, bind(api.CreateOrgOption{}), org.Create)
+		m.Get("/orgs", orgScope, org.GetAll)
 		m.Group("/orgs/{org}", func() {
 			m.Combo("").Get(org.Get).
 				Patch(reqToken(), reqOrgOwnership(), bind(api.EditOrgOption{}), org.Edit).
 				Delete(reqToken(), reqOrgOwnership(), org.Delete)
-			m.Combo("/repos").Get(user.ListOrgRepos).
+			m.Combo("/repos", repoScope).Get(user.ListOrgRepos).
 				Post(reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+			m.Get("/code/search", repoScope, org.SearchCode)
 			m.Group("/members", func() {
 				m.Get("", org.ListMembers)
 				m.Combo("/{username}").Get(org.IsMember).
@@ -996,6 +1124,8 @@ func Routes() *web.Route {
 					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
 					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
 			})
+			m.Combo("/projects", issueScope).Get(org.ListProjects).
+				Post(reqToken(), bind(api.CreateProjectOption{}), org.CreateProject)
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
@@ -1003,7 +1133,13 @@ func Routes() *web.Route {
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
 			}, reqToken(), reqOrgOwnership(), reqWebhooksEnabled())
-		}, orgAssignment(true))
+			m.Group("/secrets", func() {
+				m.Get("", org.ListSecrets)
+				m.Combo("/{secretname}").
+					Put(bind(api.CreateOrUpdateSecretOption{}), org.CreateOrUpdateSecret).
+					Delete(org.DeleteSecret)
+			}, reqToken(), reqOrgOwnership())
+		}, orgAssignment(true), orgScope)
 		m.Group("/teams/{teamid}", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -1021,7 +1157,7 @@ func Routes() *web.Route {
 					Put(org.AddTeamRepository).
 					Delete(org.RemoveTeamRepository)
 			})
-		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
+		}, orgAssignment(false, true), reqToken(), orgScope, reqTeamMembership())
 
 		m.Group("/admin", func() {
 			m.Group("/cron", func() {
@@ -1049,11 +1185,63 @@ func Routes() *web.Route {
 				m.Post("/{username}/{reponame}", admin.AdoptRepository)
 				m.Delete("/{username}/{reponame}", admin.DeleteUnadoptedRepository)
 			})
-		}, reqToken(), reqSiteAdmin())
+			if setting.CI.Enabled {
+				m.Group("/ci/runners", func() {
+					m.Combo("").Get(admin.ListCIRunners).
+						Post(bind(api.CreateCIRunnerOption{}), admin.CreateCIRunner)
+					m.Delete("/{id}", admin.DeleteCIRunner)
+				})
+			}
+		}, reqToken(), adminScope, reqSiteAdmin())
+
+		if setting.CI.Enabled {
+			m.Group("/ci/runner/jobs", func() {
+				m.Post("/fetch", ci.FetchJob)
+				m.Post("/{id}/logs", ci.AppendJobLog)
+				m.Post("/{id}/result", bind(api.CIJobResultOption{}), ci.ReportJobResult)
+			}, ci.ReqRunner())
+		}
 
 		m.Group("/topics", func() {
-			m.Get("/search", repo.TopicSearch)
+			m.Get("/search", repoScope, repo.TopicSearch)
 		})
+
+		m.Group("/projects", func() {
+			m.Group("/boards/{id}", func() {
+				m.Combo("").Get(project.GetBoard).
+					Patch(reqToken(), bind(api.EditProjectBoardOption{}), project.EditBoard).
+					Delete(reqToken(), project.DeleteBoard)
+				m.Get("/issues", project.ListBoardIssues)
+			})
+			m.Group("/{id}", func() {
+				m.Combo("").Get(project.GetProject).
+					Patch(reqToken(), bind(api.EditProjectOption{}), project.EditProject).
+					Delete(reqToken(), project.DeleteProject)
+				m.Combo("/boards").Get(project.ListBoards).
+					Post(reqToken(), bind(api.CreateProjectBoardOption{}), project.CreateBoard)
+				m.Group("/issues", func() {
+					m.Post("", bind(api.MoveProjectIssueOption{}), project.MoveIssue)
+					m.Delete("/{issue_id}", project.RemoveIssue)
+				}, reqToken())
+			})
+		}, issueScope)
+
+		if setting.Packages.Enabled {
+			m.Group("/packages/{username}", func() {
+				m.Get("", packages.ListPackages)
+				m.Group("/{type}/{name}", func() {
+					m.Group("/-/link", func() {
+						m.Put("/{reponame}", packages.LinkPackage)
+						m.Delete("", packages.UnlinkPackage)
+					}, reqToken())
+					m.Group("/{version}", func() {
+						m.Combo("").Get(packages.GetPackage).
+							Delete(reqToken(), packages.DeletePackage)
+						m.Get("/files", packages.ListPackageFiles)
+					})
+				})
+			}, packageScope, context.PackageAssignment())
+		}
 	}, sudo())
 
 	return m
@@ -0,0 +1,192 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	ci_runner "code.gitea.io/gitea/modules/ci/runner"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	ci_service "code.gitea.io/gitea/services/ci"
+)
+
+// maxLogChunkSize limits the size of a chunk of log a runner can send at once
+const maxLogChunkSize = 10 << 20
+
+// ReqRunner authenticates the runner a request comes from by its token
+func ReqRunner() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		runner, err := models.GetCIRunnerByToken(ctx.Req.Header.Get(ci_runner.TokenHeader))
+		if err != nil {
+			if models.IsErrCIRunnerNotExist(err) {
+				ctx.Error(http.StatusUnauthorized, "ReqRunner", "invalid runner token")
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetCIRunnerByToken", err)
+			}
+			return
+		}
+		ctx.Data["CIRunner"] = runner
+	}
+}
+
+// runningJob returns the job of the request, which has to be run by the runner
+func runningJob(ctx *context.APIContext) *models.CIJob {
+	runner := ctx.Data["CIRunner"].(*models.CIRunner)
+	job, err := models.GetCIJobByID(0, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIJobNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCIJobByID", err)
+		}
+		return nil
+	}
+	if job.RunnerID != runner.ID {
+		ctx.NotFound()
+		return nil
+	}
+	if job.Status != api.CIStatusRunning {
+		ctx.Error(http.StatusConflict, "", fmt.Sprintf("job is %s", job.Status))
+		return nil
+	}
+	return job
+}
+
+// FetchJob hands a waiting job to the runner
+func FetchJob(ctx *context.APIContext) {
+	// swagger:operation POST /ci/runner/jobs/fetch ci ciRunnerFetchJob
+	// ---
+	// summary: Fetch the oldest waiting job the runner can run, the runner authenticates with the X-Gitea-Runner-Token header
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CIJobTask"
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "401":
+	//     "$ref": "#/responses/error"
+
+	runner := ctx.Data["CIRunner"].(*models.CIRunner)
+	job, err := ci_service.FetchJob(runner)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FetchJob", err)
+		return
+	}
+	if job == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToCIJobTask(job))
+}
+
+// AppendJobLog appends the request body to the log of a job
+func AppendJobLog(ctx *context.APIContext) {
+	// swagger:operation POST /ci/runner/jobs/{id}/logs ci ciRunnerAppendJobLog
+	// ---
+	// summary: Append output to the log of a job the runner is running
+	// consumes:
+	// - text/plain
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     type: string
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "401":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "413":
+	//     "$ref": "#/responses/error"
+
+	job := runningJob(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(ctx.Req.Body, maxLogChunkSize+1))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReadAll", err)
+		return
+	}
+	if len(content) > maxLogChunkSize {
+		ctx.Error(http.StatusRequestEntityTooLarge, "", "log chunk is too large")
+		return
+	}
+	if err := ci_service.AppendJobLog(job, content); err != nil {
+		ctx.Error(http.StatusInternalServerError, "AppendJobLog", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ReportJobResult finishes a job
+func ReportJobResult(ctx *context.APIContext) {
+	// swagger:operation POST /ci/runner/jobs/{id}/result ci ciRunnerReportJobResult
+	// ---
+	// summary: Report the result of a job the runner has finished
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CIJobResultOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "401":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CIJobResultOption)
+	if !form.Result.IsDone() {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("invalid result: %s", form.Result))
+		return
+	}
+
+	job := runningJob(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := ci_service.FinishJob(job, form.Result); err != nil {
+		ctx.Error(http.StatusInternalServerError, "FinishJob", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,420 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListPackages gets all packages of an owner
+func ListPackages(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner} package listPackages
+	// ---
+	// summary: Gets all packages of an owner
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the packages
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// - name: type
+	//   in: query
+	//   description: package type filter
+	//   type: string
+	//   enum: [generic, npm, maven, container]
+	// - name: q
+	//   in: query
+	//   description: name filter
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if ctx.Package.AccessMode < models.AccessModeRead {
+		ctx.NotFound()
+		return
+	}
+
+	listOptions := utils.GetListOptions(ctx)
+	pvs, count, err := models.SearchPackageVersions(&models.PackageSearchOptions{
+		ListOptions: listOptions,
+		OwnerID:     ctx.Package.Owner.ID,
+		Type:        models.PackageTypeFromName(ctx.Query("type")),
+		Query:       ctx.Query("q"),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchPackageVersions", err)
+		return
+	}
+
+	apiPackages := make([]*api.Package, 0, len(pvs))
+	for _, pv := range pvs {
+		if err := pv.LoadAttributes(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+			return
+		}
+		mode, err := ctx.Package.AccessModeFor(ctx.User, pv.Package)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "AccessModeFor", err)
+			return
+		}
+		if mode < models.AccessModeRead {
+			continue
+		}
+		apiPackage, err := convert.ToPackage(pv, ctx.User)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToPackage", err)
+			return
+		}
+		apiPackages = append(apiPackages, apiPackage)
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, apiPackages)
+}
+
+// loadPackage loads the package given by the route parameters after checking the doer has the access mode to it
+func loadPackage(ctx *context.APIContext, mode models.AccessMode) *models.Package {
+	pt := models.PackageTypeFromName(ctx.Params("type"))
+	if pt == 0 {
+		ctx.NotFound()
+		return nil
+	}
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, pt, ctx.Params("name"))
+	if err != nil {
+		if err == models.ErrPackageNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil
+	}
+
+	granted, err := ctx.Package.AccessModeFor(ctx.User, p)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "AccessModeFor", err)
+		return nil
+	}
+	if granted < models.AccessModeRead {
+		ctx.NotFound()
+		return nil
+	}
+	if granted < mode {
+		ctx.Error(http.StatusForbidden, "", "You do not have the required access to the package")
+		return nil
+	}
+	return p
+}
+
+// loadPackageVersion loads the version given by the route parameters like loadPackage
+func loadPackageVersion(ctx *context.APIContext, mode models.AccessMode) *models.PackageVersion {
+	p := loadPackage(ctx, mode)
+	if ctx.Written() {
+		return nil
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, ctx.Params("version"))
+	if err != nil {
+		if err == models.ErrPackageVersionNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageVersionByName", err)
+		}
+		return nil
+	}
+	if pv.IsInternal {
+		ctx.NotFound()
+		return nil
+	}
+	pv.Package = p
+	if err := pv.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return nil
+	}
+	return pv
+}
+
+// GetPackage gets a package version
+func GetPackage(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version} package getPackage
+	// ---
+	// summary: Gets a package version
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Package"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := loadPackageVersion(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	apiPackage, err := convert.ToPackage(pv, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPackage", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiPackage)
+}
+
+// DeletePackage deletes a package version
+func DeletePackage(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version} package deletePackage
+	// ---
+	// summary: Deletes a package version
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := loadPackageVersion(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeletePackageVersion(pv); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListPackageFiles gets all files of a package version
+func ListPackageFiles(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/files package listPackageFiles
+	// ---
+	// summary: Gets all files of a package version
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageFileList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := loadPackageVersion(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageFilesByVersionID", err)
+		return
+	}
+
+	apiFiles := make([]*api.PackageFile, 0, len(pfs))
+	for _, pf := range pfs {
+		apiFiles = append(apiFiles, convert.ToPackageFile(pf))
+	}
+	ctx.JSON(http.StatusOK, apiFiles)
+}
+
+// LinkPackage links a package to a repository of its owner
+func LinkPackage(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/-/link/{repo_name} package linkPackage
+	// ---
+	// summary: Links a package to a repository of its owner
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: repo_name
+	//   in: path
+	//   description: name of the repository to link
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := loadPackage(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+	if ctx.Package.AccessMode < models.AccessModeWrite {
+		ctx.Error(http.StatusForbidden, "", "You do not have the required access to the packages of the owner")
+		return
+	}
+
+	repo, err := models.GetRepositoryByName(ctx.Package.Owner.ID, ctx.Params("reponame"))
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+		}
+		return
+	}
+	perm, err := models.GetUserRepoPermission(repo, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+		return
+	}
+	if !perm.HasAccess() {
+		ctx.NotFound()
+		return
+	}
+	if !perm.CanWrite(models.UnitTypeCode) {
+		ctx.Error(http.StatusForbidden, "", "You do not have write access to the repository")
+		return
+	}
+
+	if err := models.SetPackageRepository(p.ID, repo.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetPackageRepository", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// UnlinkPackage removes the link between a package and a repository
+func UnlinkPackage(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/-/link package unlinkPackage
+	// ---
+	// summary: Unlinks a package from its repository
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := loadPackage(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+	if ctx.Package.AccessMode < models.AccessModeWrite {
+		ctx.Error(http.StatusForbidden, "", "You do not have the required access to the packages of the owner")
+		return
+	}
+
+	if err := models.SetPackageRepository(p.ID, 0); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetPackageRepository", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
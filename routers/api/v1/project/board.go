@@ -0,0 +1,298 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+)
+
+// getBoard returns the board of the ":id" parameter and its project if the doer has at least the given access to the project
+func getBoard(ctx *context.APIContext, mode models.AccessMode) (*models.Project, *models.ProjectBoard) {
+	board, err := models.GetProjectBoard(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil, nil
+	}
+
+	p := getProject(ctx, board.ProjectID, mode)
+	if ctx.Written() {
+		return nil, nil
+	}
+	return p, board
+}
+
+// ListBoards list the boards of a project
+func ListBoards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/boards project projectListBoards
+	// ---
+	// summary: List the boards of a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoards", err)
+		return
+	}
+
+	apiBoards := make([]*api.ProjectBoard, 0, len(boards))
+	for _, board := range boards {
+		// the placeholder for the issues not assigned to a board is not a real board
+		if board.ID == 0 {
+			continue
+		}
+		apiBoards = append(apiBoards, convert.ToProjectBoard(board))
+	}
+	ctx.JSON(http.StatusOK, apiBoards)
+}
+
+// CreateBoard create a board for a project
+func CreateBoard(ctx *context.APIContext) {
+	// swagger:operation POST /projects/{id}/boards project projectCreateBoard
+	// ---
+	// summary: Create a board for a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateProjectBoardOption)
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	board := &models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		Sorting:   form.Sorting,
+		CreatorID: ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToProjectBoard(board))
+}
+
+// GetBoard get a project board
+func GetBoard(ctx *context.APIContext) {
+	// swagger:operation GET /projects/boards/{id} project projectGetBoard
+	// ---
+	// summary: Get a project board
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getBoard(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToProjectBoard(board))
+}
+
+// EditBoard edit a project board
+func EditBoard(ctx *context.APIContext) {
+	// swagger:operation PATCH /projects/boards/{id} project projectEditBoard
+	// ---
+	// summary: Edit a project board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditProjectBoardOption)
+	p, board := getBoard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil {
+		if len(*form.Title) == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", errors.New("title must not be empty"))
+			return
+		}
+		board.Title = *form.Title
+		if err := models.UpdateProjectBoard(board); err != nil {
+			ctx.Error(http.StatusInternalServerError, "UpdateProjectBoard", err)
+			return
+		}
+	}
+
+	if form.Sorting != nil {
+		board.Sorting = *form.Sorting
+		if err := models.UpdateProjectBoardSorting(models.ProjectBoardList{board}); err != nil {
+			ctx.Error(http.StatusInternalServerError, "UpdateProjectBoardSorting", err)
+			return
+		}
+	}
+
+	if form.Default != nil && *form.Default != board.Default {
+		defaultBoardID := board.ID
+		if !*form.Default {
+			defaultBoardID = 0
+		}
+		if err := models.SetDefaultBoard(p.ID, defaultBoardID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SetDefaultBoard", err)
+			return
+		}
+	}
+
+	board, err := models.GetProjectBoard(board.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToProjectBoard(board))
+}
+
+// DeleteBoard delete a project board
+func DeleteBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/boards/{id} project projectDeleteBoard
+	// ---
+	// summary: Delete a project board, its issues are moved to the default board
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getBoard(ctx, models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectBoardByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListBoardIssues list the issues of a project board
+func ListBoardIssues(ctx *context.APIContext) {
+	// swagger:operation GET /projects/boards/{id}/issues project projectListBoardIssues
+	// ---
+	// summary: List the issues and pull requests of a project board in the order of the board
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getBoard(ctx, models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+
+	issues, err := board.LoadIssues()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssues", err)
+		return
+	}
+	issues, err = models.FilterReadableIssues(issues, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FilterReadableIssues", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToAPIIssueList(issues))
+}
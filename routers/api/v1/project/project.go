@@ -0,0 +1,333 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+)
+
+// getProject returns the project with the given ID if the doer has at least the given access to it
+func getProject(ctx *context.APIContext, id int64, mode models.AccessMode) *models.Project {
+	p, err := models.GetProjectByID(id)
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectByID", err)
+		}
+		return nil
+	}
+
+	accessMode, err := models.GetProjectAccessMode(p, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectAccessMode", err)
+		return nil
+	}
+	if accessMode < models.AccessModeRead {
+		ctx.NotFound()
+		return nil
+	}
+	if accessMode < mode {
+		ctx.Error(http.StatusForbidden, "", "no permission to change the project")
+		return nil
+	}
+	return p
+}
+
+// getReadableIssue returns the issue of the given ID if the doer can read it
+func getReadableIssue(ctx *context.APIContext, id int64) (*models.Issue, models.Permission) {
+	var perm models.Permission
+	issue, err := models.GetIssueByID(id)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		}
+		return nil, perm
+	}
+	if err := issue.LoadRepo(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadRepo", err)
+		return nil, perm
+	}
+	perm, err = models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+		return nil, perm
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.NotFound()
+		return nil, perm
+	}
+	return issue, perm
+}
+
+// GetProject get a project
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id} project projectGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeRead)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToProject(p))
+}
+
+// EditProject edit a project
+func EditProject(ctx *context.APIContext) {
+	// swagger:operation PATCH /projects/{id} project projectEditProject
+	// ---
+	// summary: Edit a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditProjectOption)
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil || form.Description != nil {
+		if form.Title != nil {
+			if len(*form.Title) == 0 {
+				ctx.Error(http.StatusUnprocessableEntity, "", errors.New("title must not be empty"))
+				return
+			}
+			p.Title = *form.Title
+		}
+		if form.Description != nil {
+			p.Description = *form.Description
+		}
+		if err := models.UpdateProject(p); err != nil {
+			ctx.Error(http.StatusInternalServerError, "UpdateProject", err)
+			return
+		}
+	}
+
+	if form.State != nil {
+		var isClosed bool
+		switch api.StateType(*form.State) {
+		case api.StateOpen:
+			isClosed = false
+		case api.StateClosed:
+			isClosed = true
+		default:
+			ctx.Error(http.StatusUnprocessableEntity, "", errors.New("state must be open or closed"))
+			return
+		}
+		if isClosed != p.IsClosed {
+			if err := models.ChangeProjectStatus(p, isClosed); err != nil {
+				ctx.Error(http.StatusInternalServerError, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+
+	p, err := models.GetProjectByID(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectByID", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToProject(p))
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id} project projectDeleteProject
+	// ---
+	// summary: Delete a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// MoveIssue add an issue to a project or move it to another board
+func MoveIssue(ctx *context.APIContext) {
+	// swagger:operation POST /projects/{id}/issues project projectMoveIssue
+	// ---
+	// summary: Add an issue or pull request to a project, or move it to another board of the project
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectIssueOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.MoveProjectIssueOption)
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	issue, perm := getReadableIssue(ctx, form.IssueID)
+	if ctx.Written() {
+		return
+	}
+	if p.Type == models.ProjectTypeRepository && issue.RepoID != p.RepoID {
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("the issue does not belong to the repository of the project"))
+		return
+	}
+
+	board := &models.ProjectBoard{ProjectID: p.ID}
+	if form.BoardID != 0 {
+		var err error
+		board, err = models.GetProjectBoard(form.BoardID)
+		if err != nil && !models.IsErrProjectBoardNotExist(err) {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+			return
+		}
+		if err != nil || board.ProjectID != p.ID {
+			ctx.Error(http.StatusUnprocessableEntity, "", errors.New("the board does not belong to the project"))
+			return
+		}
+	}
+
+	if issue.ProjectID() != p.ID {
+		if !perm.CanWriteIssuesOrPulls(issue.IsPull) {
+			ctx.Error(http.StatusForbidden, "", "no permission to change the project of the issue")
+			return
+		}
+		if err := models.ChangeProjectAssign(issue, ctx.User, p.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+			return
+		}
+	}
+
+	if err := models.MoveIssueToProjectBoard(issue, board, form.Sorting); err != nil {
+		ctx.Error(http.StatusInternalServerError, "MoveIssueToProjectBoard", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveIssue remove an issue from a project
+func RemoveIssue(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/issues/{issue_id} project projectRemoveIssue
+	// ---
+	// summary: Remove an issue or pull request from a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issue_id
+	//   in: path
+	//   description: id of the issue or pull request, not its index in the repository
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"), models.AccessModeWrite)
+	if ctx.Written() {
+		return
+	}
+
+	issue, _ := getReadableIssue(ctx, ctx.ParamsInt64(":issue_id"))
+	if ctx.Written() {
+		return
+	}
+	if issue.ProjectID() != p.ID {
+		ctx.NotFound()
+		return
+	}
+
+	if err := models.ChangeProjectAssign(issue, ctx.User, 0); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
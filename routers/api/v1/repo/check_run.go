@@ -0,0 +1,443 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// CreateCheckRun creates a new check run on a commit
+func CreateCheckRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/check-runs repository repoCreateCheckRun
+	// ---
+	// summary: Create a check run on a commit
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateCheckRunOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/CheckRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateCheckRunOption)
+
+	run := &models.CheckRun{
+		HeadSHA:    form.HeadSHA,
+		Name:       form.Name,
+		Status:     form.Status,
+		Conclusion: form.Conclusion,
+		DetailsURL: form.DetailsURL,
+		ExternalID: form.ExternalID,
+	}
+	if len(run.Status) == 0 {
+		run.Status = api.CheckRunStatusQueued
+		if len(run.Conclusion) > 0 {
+			run.Status = api.CheckRunStatusCompleted
+		}
+	}
+	if form.Started != nil {
+		run.StartedUnix = timeutil.TimeStamp(form.Started.Unix())
+	}
+	if form.Completed != nil {
+		run.CompletedUnix = timeutil.TimeStamp(form.Completed.Unix())
+	}
+
+	var annotations []*models.CheckRunAnnotation
+	if form.Output != nil {
+		run.Title, run.Summary, run.Text = form.Output.Title, form.Output.Summary, form.Output.Text
+		annotations = toCheckRunAnnotations(form.Output.Annotations)
+	}
+	if err := validateCheckRun(run, annotations); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+
+	if err := pull_service.CreateCheckRun(ctx.Repo.Repository, ctx.User, run, annotations); err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("commit does not exist: %s", form.HeadSHA))
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "CreateCheckRun", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToCheckRun(run))
+}
+
+// GetCheckRun returns a check run of the repository
+func GetCheckRun(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/check-runs/{id} repository repoGetCheckRun
+	// ---
+	// summary: Get a check run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run := getCheckRunByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToCheckRun(run))
+}
+
+// EditCheckRun updates a check run and adds annotations to it
+func EditCheckRun(ctx *context.APIContext) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/check-runs/{id} repository repoEditCheckRun
+	// ---
+	// summary: Update a check run
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditCheckRunOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditCheckRunOption)
+	run := getCheckRunByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Name != nil {
+		run.Name = *form.Name
+	}
+	if form.DetailsURL != nil {
+		run.DetailsURL = *form.DetailsURL
+	}
+	if form.ExternalID != nil {
+		run.ExternalID = *form.ExternalID
+	}
+	if form.Conclusion != nil {
+		run.Conclusion = *form.Conclusion
+		if form.Status == nil {
+			run.Status = api.CheckRunStatusCompleted
+		}
+	}
+	if form.Status != nil {
+		run.Status = *form.Status
+		if !run.IsCompleted() {
+			run.Conclusion = ""
+		}
+	}
+	if form.Started != nil {
+		run.StartedUnix = timeutil.TimeStamp(form.Started.Unix())
+	}
+	if form.Completed != nil {
+		run.CompletedUnix = timeutil.TimeStamp(form.Completed.Unix())
+	}
+
+	var annotations []*models.CheckRunAnnotation
+	if form.Output != nil {
+		run.Title, run.Summary, run.Text = form.Output.Title, form.Output.Summary, form.Output.Text
+		annotations = toCheckRunAnnotations(form.Output.Annotations)
+	}
+	if err := validateCheckRun(run, annotations); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	if err := pull_service.UpdateCheckRun(ctx.User, run, annotations,
+		"name", "details_url", "external_id", "status", "conclusion", "started_unix", "completed_unix", "title", "summary", "text"); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateCheckRun", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToCheckRun(run))
+}
+
+// ListCheckRunAnnotations lists the annotations of a check run
+func ListCheckRunAnnotations(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/check-runs/{id}/annotations repository repoListCheckRunAnnotations
+	// ---
+	// summary: List the annotations of a check run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRunAnnotationList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run := getCheckRunByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	listOptions := utils.GetListOptions(ctx)
+	annotations, err := models.GetCheckRunAnnotations(run.ID, listOptions)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCheckRunAnnotations", err)
+		return
+	}
+
+	apiAnnotations := make([]*api.CheckRunAnnotation, 0, len(annotations))
+	for _, annotation := range annotations {
+		apiAnnotations = append(apiAnnotations, convert.ToCheckRunAnnotation(annotation))
+	}
+
+	ctx.SetLinkHeader(int(run.AnnotationsCount), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", run.AnnotationsCount))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, apiAnnotations)
+}
+
+// ListCheckRunsByRef lists the check runs of a commit
+func ListCheckRunsByRef(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/commits/{ref}/check-runs repository repoListCheckRunsByRef
+	// ---
+	// summary: List the check runs of a commit, by branch/tag/commit reference
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: ref
+	//   in: path
+	//   description: name of branch/tag/commit
+	//   type: string
+	//   required: true
+	// - name: check_name
+	//   in: query
+	//   description: only list the check runs with this name
+	//   type: string
+	// - name: status
+	//   in: query
+	//   description: only list the check runs with this status
+	//   type: string
+	//   enum: [queued, in_progress, completed]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRunList"
+	//   "400":
+	//     "$ref": "#/responses/error"
+
+	sha := ctx.Params("ref")
+	if len(sha) == 0 {
+		ctx.Error(http.StatusBadRequest, "ref not given", nil)
+		return
+	}
+	for _, reftype := range []string{"heads", "tags"} {
+		refSHA, lastMethodName, err := searchRefCommitByType(ctx, reftype, sha)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, lastMethodName, err)
+			return
+		}
+		if refSHA != "" {
+			sha = refSHA
+			break
+		}
+	}
+
+	listOptions := utils.GetListOptions(ctx)
+	runs, count, err := models.FindCheckRuns(models.FindCheckRunsOptions{
+		ListOptions: listOptions,
+		RepoID:      ctx.Repo.Repository.ID,
+		HeadSHA:     sha,
+		Name:        ctx.QueryTrim("check_name"),
+		Status:      api.CheckRunStatus(ctx.QueryTrim("status")),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindCheckRuns", err)
+		return
+	}
+
+	apiRuns := make([]*api.CheckRun, 0, len(runs))
+	for _, run := range runs {
+		run.Repo = ctx.Repo.Repository
+		if err := run.LoadAttributes(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+			return
+		}
+		apiRuns = append(apiRuns, convert.ToCheckRun(run))
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, apiRuns)
+}
+
+func getCheckRunByParams(ctx *context.APIContext) *models.CheckRun {
+	run, err := models.GetCheckRunByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCheckRunNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCheckRunByID", err)
+		}
+		return nil
+	}
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return nil
+	}
+	return run
+}
+
+func toCheckRunAnnotations(opts []*api.CheckRunAnnotationOption) []*models.CheckRunAnnotation {
+	annotations := make([]*models.CheckRunAnnotation, 0, len(opts))
+	for _, opt := range opts {
+		annotations = append(annotations, &models.CheckRunAnnotation{
+			Path:       opt.Path,
+			StartLine:  opt.StartLine,
+			EndLine:    opt.EndLine,
+			Level:      opt.Level,
+			Title:      opt.Title,
+			Message:    opt.Message,
+			RawDetails: opt.RawDetails,
+		})
+	}
+	return annotations
+}
+
+// validateCheckRun checks the status, the conclusion and the annotations of a check run
+// and fills in the start and end time of a started or completed run if they are missing
+func validateCheckRun(run *models.CheckRun, annotations []*models.CheckRunAnnotation) error {
+	if !run.Status.IsValid() {
+		return fmt.Errorf("invalid status: %s", run.Status)
+	}
+	if run.IsCompleted() {
+		if !run.Conclusion.IsValid() {
+			return fmt.Errorf("invalid conclusion: %s", run.Conclusion)
+		}
+	} else if len(run.Conclusion) > 0 {
+		return fmt.Errorf("a conclusion requires the status to be %s", api.CheckRunStatusCompleted)
+	}
+	for _, annotation := range annotations {
+		if !annotation.Level.IsValid() {
+			return fmt.Errorf("invalid annotation level: %s", annotation.Level)
+		}
+		if annotation.StartLine < 1 || (annotation.EndLine != 0 && annotation.EndLine < annotation.StartLine) {
+			return fmt.Errorf("invalid lines of annotation for %s: %d-%d", annotation.Path, annotation.StartLine, annotation.EndLine)
+		}
+	}
+
+	now := timeutil.TimeStampNow()
+	if run.Status != api.CheckRunStatusQueued && run.StartedUnix == 0 {
+		run.StartedUnix = now
+	}
+	if run.IsCompleted() && run.CompletedUnix == 0 {
+		run.CompletedUnix = now
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	ci_service "code.gitea.io/gitea/services/ci"
+)
+
+// loadCIRun loads the attributes and the jobs of a run of the repository of the request
+func loadCIRun(ctx *context.APIContext, run *models.CIRun) error {
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		return err
+	}
+	return run.LoadJobs()
+}
+
+// ListCIRuns lists the runs of the workflows of a repository
+func ListCIRuns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/ci/runs repository repoListCIRuns
+	// ---
+	// summary: List the runs of the workflows of a repository, the latest first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CIRunList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	listOptions := utils.GetListOptions(ctx)
+	runs, count, err := models.GetCIRuns(ctx.Repo.Repository.ID, listOptions)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCIRuns", err)
+		return
+	}
+
+	apiRuns := make([]*api.CIRun, 0, len(runs))
+	for _, run := range runs {
+		if err := loadCIRun(ctx, run); err != nil {
+			ctx.Error(http.StatusInternalServerError, "loadCIRun", err)
+			return
+		}
+		apiRuns = append(apiRuns, convert.ToCIRun(run))
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, apiRuns)
+}
+
+// GetCIRun gets a run of a workflow with its jobs
+func GetCIRun(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/ci/runs/{id} repository repoGetCIRun
+	// ---
+	// summary: Get a run of a workflow with its jobs
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CIRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run, err := models.GetCIRunByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIRunNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCIRunByID", err)
+		}
+		return
+	}
+	if err := loadCIRun(ctx, run); err != nil {
+		ctx.Error(http.StatusInternalServerError, "loadCIRun", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToCIRun(run))
+}
+
+// GetCIJobLog gets the log of a job
+func GetCIJobLog(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/ci/jobs/{id}/logs repository repoGetCIJobLog
+	// ---
+	// summary: Get the log of a job as far as the runner has reported it
+	// produces:
+	// - text/plain
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CIJobLog"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, err := models.GetCIJobByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIJobNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCIJobByID", err)
+		}
+		return
+	}
+	content, err := ci_service.ReadJobLog(job)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReadJobLog", err)
+		return
+	}
+	ctx.PlainText(http.StatusOK, content)
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// SearchCode searches for code across the repositories that the user has access to
+func SearchCode(ctx *context.APIContext) {
+	// swagger:operation GET /repos/code/search repository repoSearchCode
+	// ---
+	// summary: Search for code across the repositories that the user has access to
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: keyword to search for
+	//   type: string
+	//   required: true
+	// - name: mode
+	//   in: query
+	//   description: how the keyword is matched. Supported values are
+	//                "" (fuzzy), "match", "substring" and "regexp"
+	//   type: string
+	// - name: symbols
+	//   in: query
+	//   description: only search the definitions of functions, types and classes
+	//   type: boolean
+	// - name: language
+	//   in: query
+	//   description: only return files of this language
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CodeSearchResults"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	utils.SearchCode(ctx, 0)
+}
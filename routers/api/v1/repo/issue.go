@@ -140,13 +140,6 @@ func SearchIssues(ctx *context.APIContext) {
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
-	var issueIDs []int64
-	if len(keyword) > 0 && len(repoIDs) > 0 {
-		if issueIDs, err = issue_indexer.SearchIssuesByKeyword(repoIDs, keyword); err != nil {
-			ctx.Error(http.StatusInternalServerError, "SearchIssuesByKeyword", err)
-			return
-		}
-	}
 
 	var isPull util.OptionalBool
 	switch ctx.Query("type") {
@@ -173,9 +166,53 @@ func SearchIssues(ctx *context.APIContext) {
 		limit = setting.API.MaxResponseItems
 	}
 
+	// the issue indexer ranks the results by relevance unless a condition
+	// is requested which is only known to the database
+	useIndexer := len(keyword) > 0 && len(includedLabelNames) == 0 && ctx.QueryInt64("priority_repo_id") == 0 &&
+		!ctx.QueryBool("mentioned") && !ctx.QueryBool("review_requested")
+
+	var issueIDs []int64
+	var highlights map[int64][]issue_indexer.Highlight
+	if useIndexer {
+		if len(repoIDs) > 0 {
+			searchOpts := &issue_indexer.SearchOptions{
+				ListOptions: models.ListOptions{
+					Page:     ctx.QueryInt("page"),
+					PageSize: limit,
+				},
+				Keyword:           keyword,
+				RepoIDs:           repoIDs,
+				IsPull:            isPull,
+				IsClosed:          isClosed,
+				UpdatedBeforeUnix: before,
+				UpdatedAfterUnix:  since,
+				SortBy:            issue_indexer.SortByRelevance,
+			}
+			if ctx.QueryBool("created") {
+				searchOpts.PosterID = ctx.User.ID
+			}
+			if ctx.QueryBool("assigned") {
+				searchOpts.AssigneeID = ctx.User.ID
+			}
+
+			var res *issue_indexer.SearchResult
+			if res, issues, err = issue_indexer.SearchIssues(searchOpts); err != nil {
+				ctx.Error(http.StatusInternalServerError, "SearchIssues", err)
+				return
+			}
+			filteredCount = res.Total
+			highlights = res.Highlights()
+		}
+	} else if len(keyword) > 0 && len(repoIDs) > 0 {
+		if issueIDs, err = issue_indexer.SearchIssuesByKeyword(repoIDs, keyword); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SearchIssuesByKeyword", err)
+			return
+		}
+	}
+
 	// Only fetch the issues if we either don't have a keyword or the search returned issues
 	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0 {
+	if !useIndexer && (len(keyword) == 0 || len(issueIDs) > 0 || len(includedLabelNames) > 0) {
 		issuesOpt := &models.IssuesOptions{
 			ListOptions: models.ListOptions{
 				Page:     ctx.QueryInt("page"),
@@ -223,7 +260,7 @@ func SearchIssues(ctx *context.APIContext) {
 	ctx.SetLinkHeader(int(filteredCount), setting.UI.IssuePagingNum)
 	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", filteredCount))
 	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
-	ctx.JSON(http.StatusOK, convert.ToAPIIssueList(issues))
+	ctx.JSON(http.StatusOK, convert.ToAPIIssueSearchList(issues, highlights))
 }
 
 // ListIssues list the issues of a repository
@@ -295,17 +332,8 @@ func ListIssues(ctx *context.APIContext) {
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
-	var issueIDs []int64
 	var labelIDs []int64
 	var err error
-	if len(keyword) > 0 {
-		issueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{ctx.Repo.Repository.ID}, keyword)
-		if err != nil {
-			ctx.Error(http.StatusInternalServerError, "SearchIssuesByKeyword", err)
-			return
-		}
-	}
-
 	if splitted := strings.Split(ctx.Query("labels"), ","); len(splitted) > 0 {
 		labelIDs, err = models.GetLabelIDsInRepoByNames(ctx.Repo.Repository.ID, splitted)
 		if err != nil {
@@ -356,14 +384,30 @@ func ListIssues(ctx *context.APIContext) {
 		isPull = util.OptionalBoolNone
 	}
 
-	// Only fetch the issues if we either don't have a keyword or the search returned issues
-	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(labelIDs) > 0 {
+	var highlights map[int64][]issue_indexer.Highlight
+	if len(keyword) > 0 {
+		res, found, err := issue_indexer.SearchIssues(&issue_indexer.SearchOptions{
+			ListOptions:  listOptions,
+			Keyword:      keyword,
+			RepoIDs:      []int64{ctx.Repo.Repository.ID},
+			LabelIDs:     labelIDs,
+			MilestoneIDs: mileIDs,
+			IsPull:       isPull,
+			IsClosed:     isClosed,
+			SortBy:       issue_indexer.SortByRelevance,
+		})
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "SearchIssues", err)
+			return
+		}
+		issues = found
+		filteredCount = res.Total
+		highlights = res.Highlights()
+	} else {
 		issuesOpt := &models.IssuesOptions{
 			ListOptions:  listOptions,
 			RepoIDs:      []int64{ctx.Repo.Repository.ID},
 			IsClosed:     isClosed,
-			IssueIDs:     issueIDs,
 			LabelIDs:     labelIDs,
 			MilestoneIDs: mileIDs,
 			IsPull:       isPull,
@@ -386,7 +430,7 @@ func ListIssues(ctx *context.APIContext) {
 	ctx.SetLinkHeader(int(filteredCount), listOptions.PageSize)
 	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", filteredCount))
 	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
-	ctx.JSON(http.StatusOK, convert.ToAPIIssueList(issues))
+	ctx.JSON(http.StatusOK, convert.ToAPIIssueSearchList(issues, highlights))
 }
 
 // GetIssue get an issue of a repository
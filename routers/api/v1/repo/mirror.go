@@ -5,10 +5,19 @@
 package repo
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/forms"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 )
 
@@ -46,3 +55,266 @@ func MirrorSync(ctx *context.APIContext) {
 
 	ctx.Status(http.StatusOK)
 }
+
+// ListPushMirrors get list of push mirrors of a repository
+func ListPushMirrors(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors repository repoListPushMirrors
+	// ---
+	// summary: Get all push mirrors of the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirrorList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID, utils.GetListOptions(ctx))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+
+	apiMirrors := make([]*api.PushMirror, 0, len(pushMirrors))
+	for _, m := range pushMirrors {
+		apiMirror, err := convert.ToPushMirror(m)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToPushMirror", err)
+			return
+		}
+		apiMirrors = append(apiMirrors, apiMirror)
+	}
+	ctx.JSON(http.StatusOK, apiMirrors)
+}
+
+// GetPushMirrorByName get a push mirror of a repository by its remote name
+func GetPushMirrorByName(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors/{name} repository repoGetPushMirrorByRemoteName
+	// ---
+	// summary: Get a push mirror of the repository by remote name
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: remote name of the push mirror
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirror"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByName(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	apiMirror, err := convert.ToPushMirror(m)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPushMirror", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiMirror)
+}
+
+// AddPushMirror adds a push mirror to a repository
+func AddPushMirror(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors repository repoAddPushMirror
+	// ---
+	// summary: Add a push mirror to the repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePushMirrorOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PushMirror"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreatePushMirrorOption)
+
+	if setting.Repository.DisableMirrors {
+		ctx.Error(http.StatusForbidden, "MirrorsGlobalDisabled", fmt.Errorf("the site administrator has disabled mirrors"))
+		return
+	}
+
+	interval := setting.Mirror.DefaultInterval
+	if len(form.Interval) > 0 {
+		var err error
+		interval, err = time.ParseDuration(form.Interval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Error(http.StatusUnprocessableEntity, "Interval", fmt.Errorf("invalid mirror interval: %q", form.Interval))
+			return
+		}
+	}
+
+	syncOnCommit := true
+	if form.SyncOnCommit != nil {
+		syncOnCommit = *form.SyncOnCommit
+	}
+
+	address, err := forms.ParseRemoteAddr(form.RemoteAddress, form.RemoteUsername, form.RemotePassword)
+	if err == nil {
+		err = migrations.IsMigrateURLAllowed(address, ctx.User)
+	}
+	if err != nil {
+		handleRemoteAddrError(ctx, err)
+		return
+	}
+
+	m, err := mirror_service.AddPushMirror(ctx.Repo.Repository, address, interval, syncOnCommit)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "AddPushMirror", err)
+		return
+	}
+
+	apiMirror, err := convert.ToPushMirror(m)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPushMirror", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiMirror)
+}
+
+// DeletePushMirrorByName deletes a push mirror of a repository by its remote name
+func DeletePushMirrorByName(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/push_mirrors/{name} repository repoDeletePushMirror
+	// ---
+	// summary: Delete a push mirror of the repository by remote name
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: remote name of the push mirror
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByName(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := mirror_service.RemovePushMirror(m); err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemovePushMirror", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// PushMirrorSync adds all push mirrored repositories to the mirror queue
+func PushMirrorSync(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors-sync repository repoPushMirrorSync
+	// ---
+	// summary: Sync all push mirrors of the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo to sync
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo to sync
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID, models.ListOptions{})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+	for _, m := range pushMirrors {
+		mirror_service.AddPushMirrorToQueue(m.ID)
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func getPushMirrorByName(ctx *context.APIContext) *models.PushMirror {
+	m, err := models.GetPushMirrorByRepoIDAndName(ctx.Repo.Repository.ID, ctx.Params(":name"))
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPushMirrorByRepoIDAndName", err)
+		}
+		return nil
+	}
+	return m
+}
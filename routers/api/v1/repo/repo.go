@@ -752,6 +752,9 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 			if opts.AllowSquash != nil {
 				config.AllowSquash = *opts.AllowSquash
 			}
+			if opts.AllowFastForwardOnly != nil {
+				config.AllowFastForwardOnly = *opts.AllowFastForwardOnly
+			}
 			if opts.AllowManualMerge != nil {
 				config.AllowManualMerge = *opts.AllowManualMerge
 			}
@@ -761,6 +764,12 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 			if opts.DefaultMergeStyle != nil {
 				config.DefaultMergeStyle = models.MergeStyle(*opts.DefaultMergeStyle)
 			}
+			if opts.MergeMessageTemplate != nil {
+				config.MergeMessageTemplate = strings.TrimSpace(*opts.MergeMessageTemplate)
+			}
+			if opts.SquashMessageTemplate != nil {
+				config.SquashMessageTemplate = strings.TrimSpace(*opts.SquashMessageTemplate)
+			}
 
 			units = append(units, models.RepoUnit{
 				RepoID: repo.ID,
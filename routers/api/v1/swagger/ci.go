@@ -0,0 +1,51 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package swagger
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// CIRunner
+// swagger:response CIRunner
+type swaggerResponseCIRunner struct {
+	// in:body
+	Body api.CIRunner `json:"body"`
+}
+
+// CIRunnerList
+// swagger:response CIRunnerList
+type swaggerResponseCIRunnerList struct {
+	// in:body
+	Body []api.CIRunner `json:"body"`
+}
+
+// CIRun
+// swagger:response CIRun
+type swaggerResponseCIRun struct {
+	// in:body
+	Body api.CIRun `json:"body"`
+}
+
+// CIRunList
+// swagger:response CIRunList
+type swaggerResponseCIRunList struct {
+	// in:body
+	Body []api.CIRun `json:"body"`
+}
+
+// CIJobTask
+// swagger:response CIJobTask
+type swaggerResponseCIJobTask struct {
+	// in:body
+	Body api.CIJobTask `json:"body"`
+}
+
+// CIJobLog
+// swagger:response CIJobLog
+type swaggerResponseCIJobLog struct {
+	// in:body
+	Body string `json:"body"`
+}
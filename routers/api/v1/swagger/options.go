@@ -158,4 +158,40 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	PullReviewRequestOptions api.PullReviewRequestOptions
+
+	// in:body
+	CreatePushMirrorOption api.CreatePushMirrorOption
+
+	// in:body
+	CreateWikiPageOptions api.CreateWikiPageOptions
+
+	// in:body
+	CreateOrUpdateSecretOption api.CreateOrUpdateSecretOption
+
+	// in:body
+	CreateProjectOption api.CreateProjectOption
+
+	// in:body
+	EditProjectOption api.EditProjectOption
+
+	// in:body
+	CreateProjectBoardOption api.CreateProjectBoardOption
+
+	// in:body
+	EditProjectBoardOption api.EditProjectBoardOption
+
+	// in:body
+	MoveProjectIssueOption api.MoveProjectIssueOption
+
+	// in:body
+	CreateCheckRunOption api.CreateCheckRunOption
+
+	// in:body
+	EditCheckRunOption api.EditCheckRunOption
+
+	// in:body
+	CreateCIRunnerOption api.CreateCIRunnerOption
+
+	// in:body
+	CIJobResultOption api.CIJobResultOption
 }
@@ -141,6 +141,13 @@ type swaggerResponsePullRequestList struct {
 	Body []api.PullRequest `json:"body"`
 }
 
+// PullMergeQueueEntry
+// swagger:response PullMergeQueueEntry
+type swaggerResponsePullMergeQueueEntry struct {
+	// in:body
+	Body api.PullMergeQueueEntry `json:"body"`
+}
+
 // PullReview
 // swagger:response PullReview
 type swaggerResponsePullReview struct {
@@ -197,6 +204,13 @@ type swaggerResponseSearchResults struct {
 	Body api.SearchResults `json:"body"`
 }
 
+// CodeSearchResults
+// swagger:response CodeSearchResults
+type swaggerResponseCodeSearchResults struct {
+	// in:body
+	Body api.CodeSearchResults `json:"body"`
+}
+
 // AttachmentList
 // swagger:response AttachmentList
 type swaggerResponseAttachmentList struct {
@@ -316,3 +330,59 @@ type swaggerCombinedStatus struct {
 	// in: body
 	Body api.CombinedStatus `json:"body"`
 }
+
+// PushMirror
+// swagger:response PushMirror
+type swaggerPushMirror struct {
+	// in:body
+	Body api.PushMirror `json:"body"`
+}
+
+// PushMirrorList
+// swagger:response PushMirrorList
+type swaggerPushMirrorList struct {
+	// in:body
+	Body []api.PushMirror `json:"body"`
+}
+
+// WikiPageList
+// swagger:response WikiPageList
+type swaggerWikiPageList struct {
+	// in:body
+	Body []api.WikiPageMetaData `json:"body"`
+}
+
+// WikiPage
+// swagger:response WikiPage
+type swaggerWikiPage struct {
+	// in:body
+	Body api.WikiPage `json:"body"`
+}
+
+// WikiPageRevisionList
+// swagger:response WikiPageRevisionList
+type swaggerWikiPageRevisionList struct {
+	// in:body
+	Body api.WikiPageRevisionList `json:"body"`
+}
+
+// CheckRun
+// swagger:response CheckRun
+type swaggerCheckRun struct {
+	// in:body
+	Body api.CheckRun `json:"body"`
+}
+
+// CheckRunList
+// swagger:response CheckRunList
+type swaggerCheckRunList struct {
+	// in:body
+	Body []api.CheckRun `json:"body"`
+}
+
+// CheckRunAnnotationList
+// swagger:response CheckRunAnnotationList
+type swaggerCheckRunAnnotationList struct {
+	// in:body
+	Body []api.CheckRunAnnotation `json:"body"`
+}
@@ -52,10 +52,17 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
+		repoNames, err := tokens[i].RepositoryNames()
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "RepositoryNames", err)
+			return
+		}
 		apiTokens[i] = &api.AccessToken{
 			ID:             tokens[i].ID,
 			Name:           tokens[i].Name,
 			TokenLastEight: tokens[i].TokenLastEight,
+			Scopes:         tokens[i].Scope.Scopes(),
+			Repositories:   repoNames,
 		}
 	}
 	ctx.JSON(http.StatusOK, &apiTokens)
@@ -85,6 +92,14 @@ func CreateAccessToken(ctx *context.APIContext) {
 	//     properties:
 	//       name:
 	//         type: string
+	//       scopes:
+	//         type: array
+	//         items:
+	//           type: string
+	//       repositories:
+	//         type: array
+	//         items:
+	//           type: string
 	// responses:
 	//   "201":
 	//     "$ref": "#/responses/AccessToken"
@@ -93,9 +108,24 @@ func CreateAccessToken(ctx *context.APIContext) {
 
 	form := web.GetForm(ctx).(*api.CreateAccessTokenOption)
 
+	scope, err := models.NewAccessTokenScope(form.Scopes...)
+	if err != nil {
+		ctx.Error(http.StatusBadRequest, "NewAccessTokenScope", err)
+		return
+	}
+
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: scope,
+	}
+	if err := t.SetRepositories(form.Repositories); err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Error(http.StatusBadRequest, "SetRepositories", err)
+			return
+		}
+		ctx.InternalServerError(err)
+		return
 	}
 
 	exist, err := models.AccessTokenByNameExists(t)
@@ -112,11 +142,18 @@ func CreateAccessToken(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
+	repoNames, err := t.RepositoryNames()
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
 	ctx.JSON(http.StatusCreated, &api.AccessToken{
 		Name:           t.Name,
 		Token:          t.Token,
 		ID:             t.ID,
 		TokenLastEight: t.TokenLastEight,
+		Scopes:         t.Scope.Scopes(),
+		Repositories:   repoNames,
 	})
 }
 
@@ -0,0 +1,71 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// SearchCode writes the results of the code search given by the query parameters in the repositories
+// the doer can read, limited to the repositories of the owner if ownerID is not zero
+func SearchCode(ctx *context.APIContext, ownerID int64) {
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.NotFound()
+		return
+	}
+
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	if len(keyword) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("keyword must not be empty"))
+		return
+	}
+
+	mode := ctx.Query("mode")
+	switch code_indexer.SearchMode(mode) {
+	case code_indexer.SearchModeFuzzy, code_indexer.SearchModeMatch, code_indexer.SearchModeSubstring, code_indexer.SearchModeRegexp:
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("Invalid search mode: \"%s\"", mode))
+		return
+	}
+
+	listOptions := GetListOptions(ctx)
+	opts := &code_indexer.SearchOptions{
+		Language:    strings.TrimSpace(ctx.Query("language")),
+		Keyword:     keyword,
+		Page:        listOptions.Page,
+		PageSize:    listOptions.PageSize,
+		Mode:        code_indexer.SearchMode(mode),
+		SymbolsOnly: ctx.QueryBool("symbols"),
+	}
+
+	total, groups, languages, truncated, err := code_indexer.PerformAccessibleSearch(ctx.User, ownerID, opts)
+	if err != nil {
+		if code_indexer.IsErrInvalidSearchKeyword(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "PerformAccessibleSearch", err)
+		}
+		return
+	}
+
+	results, err := convert.ToCodeSearchResults(ctx.User, total, groups, languages, truncated)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToCodeSearchResults", err)
+		return
+	}
+
+	ctx.SetLinkHeader(total, opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, results)
+}
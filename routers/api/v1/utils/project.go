@@ -0,0 +1,137 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+)
+
+var projectBoardTypes = map[string]models.ProjectBoardType{
+	"":             models.ProjectBoardTypeNone,
+	"none":         models.ProjectBoardTypeNone,
+	"basic_kanban": models.ProjectBoardTypeBasicKanban,
+	"bug_triage":   models.ProjectBoardTypeBugTriage,
+}
+
+// ListProjects writes the projects of a repository or of a user or organization matching the state query parameter
+func ListProjects(ctx *context.APIContext, opts models.ProjectSearchOptions) {
+	switch strings.ToLower(ctx.Query("state")) {
+	case "", "open":
+		opts.IsClosed = util.OptionalBoolFalse
+	case "closed":
+		opts.IsClosed = util.OptionalBoolTrue
+	case "all":
+		opts.IsClosed = util.OptionalBoolNone
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("state must be open, closed or all"))
+		return
+	}
+
+	listOptions := GetListOptions(ctx)
+	opts.Page = listOptions.Page
+	opts.PageSize = listOptions.PageSize
+
+	projects, count, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjects", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, 0, len(projects))
+	for _, p := range projects {
+		apiProjects = append(apiProjects, convert.ToProject(p))
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, apiProjects)
+}
+
+// CreateProject creates the project from the options in the request body.
+// The owner and the type of the project must already be set.
+func CreateProject(ctx *context.APIContext, p *models.Project) {
+	form := web.GetForm(ctx).(*api.CreateProjectOption)
+
+	boardType, ok := projectBoardTypes[strings.ToLower(form.BoardType)]
+	if !ok {
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("board_type must be none, basic_kanban or bug_triage"))
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Description
+	p.BoardType = boardType
+	p.CreatorID = ctx.User.ID
+	if err := models.NewProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProject", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToProject(p))
+}
+
+// ListOwnerProjects writes the projects of a user or organization
+func ListOwnerProjects(ctx *context.APIContext, owner *models.User) {
+	if !checkOwnerProjectsVisible(ctx, owner) {
+		return
+	}
+
+	projectType := models.ProjectTypeIndividual
+	if owner.IsOrganization() {
+		projectType = models.ProjectTypeOrganization
+	}
+	ListProjects(ctx, models.ProjectSearchOptions{
+		OwnerID: owner.ID,
+		Type:    projectType,
+	})
+}
+
+// CreateOwnerProject creates a project of a user or organization if the doer is allowed to manage its projects
+func CreateOwnerProject(ctx *context.APIContext, owner *models.User) {
+	if !checkOwnerProjectsVisible(ctx, owner) {
+		return
+	}
+
+	canWrite, err := models.CanWriteOwnerProjects(owner, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CanWriteOwnerProjects", err)
+		return
+	} else if !canWrite {
+		ctx.Error(http.StatusForbidden, "", "must be a member of the organization to create projects")
+		return
+	}
+
+	projectType := models.ProjectTypeIndividual
+	if owner.IsOrganization() {
+		projectType = models.ProjectTypeOrganization
+	}
+	CreateProject(ctx, &models.Project{
+		OwnerID: owner.ID,
+		Type:    projectType,
+	})
+}
+
+func checkOwnerProjectsVisible(ctx *context.APIContext, owner *models.User) bool {
+	if models.UnitTypeProjects.UnitGlobalDisabled() {
+		ctx.NotFound()
+		return false
+	}
+	if owner.IsOrganization() && !models.HasOrgVisible(owner, ctx.User) {
+		ctx.NotFound()
+		return false
+	}
+	return true
+}
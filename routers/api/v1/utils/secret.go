@@ -0,0 +1,64 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+)
+
+// ListSecrets writes the secrets of a repository (ownerID 0) or of an organization (repoID 0) without their values
+func ListSecrets(ctx *context.APIContext, ownerID, repoID int64) {
+	secrets, err := models.FindSecrets(ownerID, repoID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindSecrets", err)
+		return
+	}
+
+	apiSecrets := make([]*api.Secret, 0, len(secrets))
+	for _, s := range secrets {
+		apiSecrets = append(apiSecrets, convert.ToSecret(s))
+	}
+	ctx.JSON(http.StatusOK, apiSecrets)
+}
+
+// CreateOrUpdateSecret stores the secret given by the route parameter secretname
+func CreateOrUpdateSecret(ctx *context.APIContext, ownerID, repoID int64) {
+	form := web.GetForm(ctx).(*api.CreateOrUpdateSecretOption)
+
+	created, err := models.SetSecret(ownerID, repoID, ctx.Params(":secretname"), form.Data)
+	if err != nil {
+		if err == models.ErrSecretNameInvalid {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "SetSecret", err)
+		}
+		return
+	}
+
+	if created {
+		ctx.Status(http.StatusCreated)
+	} else {
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// DeleteSecret deletes the secret given by the route parameter secretname
+func DeleteSecret(ctx *context.APIContext, ownerID, repoID int64) {
+	if err := models.DeleteSecret(ownerID, repoID, ctx.Params(":secretname")); err != nil {
+		if err == models.ErrSecretNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteSecret", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
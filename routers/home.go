@@ -309,98 +309,43 @@ func ExploreCode(ctx *context.Context) {
 	}
 
 	queryType := strings.TrimSpace(ctx.Query("t"))
-	isMatch := queryType == "match"
-
-	var (
-		repoIDs []int64
-		err     error
-		isAdmin bool
-	)
-	if ctx.User != nil {
-		isAdmin = ctx.User.IsAdmin
+	symbolsOnly := ctx.QueryBool("symbols")
+
+	searchOpts := &code_indexer.SearchOptions{
+		Language:    language,
+		Keyword:     keyword,
+		Page:        page,
+		PageSize:    setting.UI.RepoSearchPagingNum,
+		Mode:        code_indexer.ParseSearchMode(queryType),
+		SymbolsOnly: symbolsOnly,
 	}
 
-	// guest user or non-admin user
-	if ctx.User == nil || !isAdmin {
-		repoIDs, err = models.FindUserAccessibleRepoIDs(ctx.User)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-	}
-
-	var (
-		total                 int
-		searchResults         []*code_indexer.Result
-		searchResultLanguages []*code_indexer.SearchResultLanguages
-	)
-
-	// if non-admin login user, we need check UnitTypeCode at first
-	if ctx.User != nil && len(repoIDs) > 0 {
-		repoMaps, err := models.GetRepositoriesMapByIDs(repoIDs)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var rightRepoMap = make(map[int64]*models.Repository, len(repoMaps))
-		repoIDs = make([]int64, 0, len(repoMaps))
-		for id, repo := range repoMaps {
-			if repo.CheckUnitUser(ctx.User, models.UnitTypeCode) {
-				rightRepoMap[id] = repo
-				repoIDs = append(repoIDs, id)
-			}
-		}
-
-		ctx.Data["RepoMaps"] = rightRepoMap
-
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-		// if non-login user or isAdmin, no need to check UnitTypeCode
-	} else if (ctx.User == nil && len(repoIDs) > 0) || isAdmin {
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var loadRepoIDs = make([]int64, 0, len(searchResults))
-		for _, result := range searchResults {
-			var find bool
-			for _, id := range loadRepoIDs {
-				if id == result.RepoID {
-					find = true
-					break
-				}
-			}
-			if !find {
-				loadRepoIDs = append(loadRepoIDs, result.RepoID)
-			}
-		}
-
-		repoMaps, err := models.GetRepositoriesMapByIDs(loadRepoIDs)
-		if err != nil {
+	total, searchResultGroups, searchResultLanguages, truncated, err := code_indexer.PerformAccessibleSearch(ctx.User, 0, searchOpts)
+	if err != nil {
+		if !code_indexer.IsErrInvalidSearchKeyword(err) {
 			ctx.ServerError("SearchResults", err)
 			return
 		}
-
-		ctx.Data["RepoMaps"] = repoMaps
+		ctx.Data["SearchError"] = ctx.Tr("explore.search.invalid_keyword", err.(code_indexer.ErrInvalidSearchKeyword).Err)
 	}
 
 	ctx.Data["Keyword"] = keyword
 	ctx.Data["Language"] = language
 	ctx.Data["queryType"] = queryType
-	ctx.Data["SearchResults"] = searchResults
+	ctx.Data["SymbolsOnly"] = symbolsOnly
+	ctx.Data["SearchResultGroups"] = searchResultGroups
 	ctx.Data["SearchResultLanguages"] = searchResultLanguages
+	ctx.Data["SearchResultsTruncated"] = truncated
+	ctx.Data["CodeSearchLink"] = setting.AppSubURL + "/explore/code"
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
 
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
 	pager.AddParam(ctx, "l", "Language")
+	if symbolsOnly {
+		pager.AddParamString("symbols", "true")
+	}
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(http.StatusOK, tplExploreCode)
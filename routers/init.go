@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/migrations"
 	"code.gitea.io/gitea/modules/auth/sso"
+	"code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/cron"
 	"code.gitea.io/gitea/modules/eventsource"
@@ -32,7 +33,11 @@ import (
 	"code.gitea.io/gitea/modules/svg"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/translation"
+	"code.gitea.io/gitea/services/automerge"
+	ci_service "code.gitea.io/gitea/services/ci"
 	"code.gitea.io/gitea/services/mailer"
+	"code.gitea.io/gitea/services/mailer/incoming"
+	"code.gitea.io/gitea/services/mergequeue"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	pull_service "code.gitea.io/gitea/services/pull"
 	"code.gitea.io/gitea/services/repository"
@@ -175,9 +180,21 @@ func GlobalInit(ctx context.Context) {
 	if err := pull_service.Init(); err != nil {
 		log.Fatal("Failed to initialize test pull requests queue: %v", err)
 	}
+	if err := automerge.Init(); err != nil {
+		log.Fatal("Failed to initialize auto merge pull requests queue: %v", err)
+	}
+	if err := mergequeue.Init(); err != nil {
+		log.Fatal("Failed to initialize merge queue: %v", err)
+	}
+	if err := ci_service.Init(); err != nil {
+		log.Fatal("Failed to initialize CI workflow queue: %v", err)
+	}
 	if err := task.Init(); err != nil {
 		log.Fatal("Failed to initialize task scheduler: %v", err)
 	}
+	if err := incoming.Init(); err != nil {
+		log.Fatal("Failed to initialize incoming email: %v", err)
+	}
 	if err := repo_migrations.Init(); err != nil {
 		log.Fatal("Failed to initialize repository migrations: %v", err)
 	}
@@ -190,6 +207,7 @@ func GlobalInit(ctx context.Context) {
 		ssh.Unused()
 	}
 	sso.Init()
+	webauthn.Init()
 
 	svg.Init()
 }
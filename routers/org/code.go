@@ -0,0 +1,90 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplOrgCode base.TplName = "org/code"
+)
+
+// Code render the code search page of an organization
+func Code(ctx *context.Context) {
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.NotFound("RepoIndexerEnabled", nil)
+		return
+	}
+
+	ctx.SetParams(":org", ctx.Params(":username"))
+	context.HandleOrgAssignment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	org := ctx.Org.Organization
+	if !models.HasOrgVisible(org, ctx.User) {
+		ctx.NotFound("HasOrgVisible", nil)
+		return
+	}
+
+	ctx.Data["Title"] = org.DisplayName()
+	ctx.Data["PageIsOrgCode"] = true
+
+	language := strings.TrimSpace(ctx.Query("l"))
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	queryType := strings.TrimSpace(ctx.Query("t"))
+	symbolsOnly := ctx.QueryBool("symbols")
+
+	searchOpts := &code_indexer.SearchOptions{
+		Language:    language,
+		Keyword:     keyword,
+		Page:        page,
+		PageSize:    setting.UI.RepoSearchPagingNum,
+		Mode:        code_indexer.ParseSearchMode(queryType),
+		SymbolsOnly: symbolsOnly,
+	}
+
+	total, searchResultGroups, searchResultLanguages, truncated, err := code_indexer.PerformAccessibleSearch(ctx.User, org.ID, searchOpts)
+	if err != nil {
+		if !code_indexer.IsErrInvalidSearchKeyword(err) {
+			ctx.ServerError("SearchResults", err)
+			return
+		}
+		ctx.Data["SearchError"] = ctx.Tr("explore.search.invalid_keyword", err.(code_indexer.ErrInvalidSearchKeyword).Err)
+	}
+
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = language
+	ctx.Data["queryType"] = queryType
+	ctx.Data["SymbolsOnly"] = symbolsOnly
+	ctx.Data["SearchResultGroups"] = searchResultGroups
+	ctx.Data["SearchResultLanguages"] = searchResultLanguages
+	ctx.Data["SearchResultsTruncated"] = truncated
+	ctx.Data["CodeSearchLink"] = org.HomeLink() + "/-/code"
+	ctx.Data["RequireHighlightJS"] = true
+
+	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "l", "Language")
+	if symbolsOnly {
+		pager.AddParamString("symbols", "true")
+	}
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplOrgCode)
+}
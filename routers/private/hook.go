@@ -155,12 +155,53 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 			private.GitQuarantinePath+"="+opts.GitQuarantinePath)
 	}
 
+	// The protected tags are only loaded once a tag is pushed
+	var protectedTags []*models.ProtectedTag
+	gotProtectedTags := false
+
 	// Iterate across the provided old commit IDs
 	for i := range opts.OldCommitIDs {
 		oldCommitID := opts.OldCommitIDs[i]
 		newCommitID := opts.NewCommitIDs[i]
 		refFullName := opts.RefFullNames[i]
 
+		if strings.HasPrefix(refFullName, git.TagPrefix) {
+			if !gotProtectedTags {
+				protectedTags, err = repo.GetProtectedTags()
+				if err != nil {
+					log.Error("Unable to get protected tags for %-v Error: %v", repo, err)
+					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+						"err": err.Error(),
+					})
+					return
+				}
+				gotProtectedTags = true
+			}
+
+			tagName := strings.TrimPrefix(refFullName, git.TagPrefix)
+			userID := opts.UserID
+			if opts.IsDeployKey {
+				// Deploy keys can't be part of an allowlist
+				userID = 0
+			}
+			isAllowed, err := models.IsUserAllowedToControlTag(protectedTags, tagName, userID)
+			if err != nil {
+				log.Error("Unable to check if user %d is allowed to control tag %s in %-v Error: %v", userID, tagName, repo, err)
+				ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+					"err": err.Error(),
+				})
+				return
+			}
+			if !isAllowed {
+				log.Warn("Forbidden: Tag %s in %-v is protected", tagName, repo)
+				ctx.JSON(http.StatusForbidden, map[string]interface{}{
+					"err": fmt.Sprintf("Tag %s is protected", tagName),
+				})
+				return
+			}
+			continue
+		}
+
 		branchName := strings.TrimPrefix(refFullName, git.BranchPrefix)
 		if branchName == repo.DefaultBranch && newCommitID == git.EmptySHA {
 			log.Warn("Forbidden: Branch: %s is the default branch in %-v and cannot be deleted", branchName, repo)
@@ -349,6 +390,24 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 				return
 			}
 
+			// Branches with a merge queue only accept the speculative merges the queue has tested
+			if protectBranch.EnableMergeQueue {
+				if err := pull_service.CheckMergeQueueCommitReady(pr, newCommitID); err != nil {
+					if models.IsErrNotAllowedToMerge(err) {
+						log.Warn("Forbidden: User %d is not allowed push to protected branch %s in %-v and pr #%d is not ready to be merged: %s", opts.UserID, branchName, repo, pr.Index, err.Error())
+						ctx.JSON(http.StatusForbidden, map[string]interface{}{
+							"err": fmt.Sprintf("Not allowed to push to protected branch %s and pr #%d is not ready to be merged: %s", branchName, opts.ProtectedBranchID, err.Error()),
+						})
+						return
+					}
+					log.Error("Unable to check merge queue: protected branch %s in %-v and pr #%d. Error: %v", branchName, repo, pr.Index, err)
+					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+						"err": fmt.Sprintf("Unable to get merge queue status of pull request %d. Error: %v", opts.ProtectedBranchID, err),
+					})
+					return
+				}
+			}
+
 			// Check all status checks and reviews are ok
 			if err := pull_service.CheckPRReadyToMerge(pr, true); err != nil {
 				if models.IsErrNotAllowedToMerge(err) {
@@ -0,0 +1,61 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+)
+
+const (
+	tplCheckRun base.TplName = "repo/check_run"
+)
+
+// CheckRun renders the report of a check run together with its annotations
+func CheckRun(ctx *context.Context) {
+	run, err := models.GetCheckRunByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCheckRunNotExist(err) {
+			ctx.NotFound("GetCheckRunByID", err)
+		} else {
+			ctx.ServerError("GetCheckRunByID", err)
+		}
+		return
+	}
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+
+	renderCtx := &markup.RenderContext{
+		URLPrefix: ctx.Repo.RepoLink,
+		Metas:     ctx.Repo.Repository.ComposeMetas(),
+	}
+	if ctx.Data["Summary"], err = markdown.RenderString(renderCtx, run.Summary); err != nil {
+		ctx.ServerError("RenderString", err)
+		return
+	}
+	if ctx.Data["Text"], err = markdown.RenderString(renderCtx, run.Text); err != nil {
+		ctx.ServerError("RenderString", err)
+		return
+	}
+
+	annotations, err := models.GetCheckRunAnnotations(run.ID, models.ListOptions{})
+	if err != nil {
+		ctx.ServerError("GetCheckRunAnnotations", err)
+		return
+	}
+
+	ctx.Data["Title"] = run.Name
+	ctx.Data["CheckRun"] = run
+	ctx.Data["Annotations"] = annotations
+	ctx.HTML(http.StatusOK, tplCheckRun)
+}
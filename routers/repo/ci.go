@@ -0,0 +1,109 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	ci_service "code.gitea.io/gitea/services/ci"
+)
+
+const (
+	tplCIRuns base.TplName = "repo/ci/runs"
+	tplCIRun  base.TplName = "repo/ci/run"
+	tplCIJob  base.TplName = "repo/ci/job"
+)
+
+// CIRuns renders the runs of the workflows of a repository
+func CIRuns(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.ci")
+	ctx.Data["PageIsCI"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	runs, count, err := models.GetCIRuns(ctx.Repo.Repository.ID, models.ListOptions{
+		Page:     page,
+		PageSize: setting.UI.IssuePagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("GetCIRuns", err)
+		return
+	}
+	for _, run := range runs {
+		run.Repo = ctx.Repo.Repository
+		if err := run.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+	ctx.Data["Runs"] = runs
+
+	pager := context.NewPagination(int(count), setting.UI.IssuePagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplCIRuns)
+}
+
+// CIRun renders a run of a workflow with its jobs
+func CIRun(ctx *context.Context) {
+	run, err := models.GetCIRunByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIRunNotExist(err) {
+			ctx.NotFound("GetCIRunByID", err)
+		} else {
+			ctx.ServerError("GetCIRunByID", err)
+		}
+		return
+	}
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	if err := run.LoadJobs(); err != nil {
+		ctx.ServerError("LoadJobs", err)
+		return
+	}
+
+	ctx.Data["Title"] = run.Name
+	ctx.Data["PageIsCI"] = true
+	ctx.Data["Run"] = run
+	ctx.HTML(http.StatusOK, tplCIRun)
+}
+
+// CIJob renders a job with its log
+func CIJob(ctx *context.Context) {
+	job, err := models.GetCIJobByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIJobNotExist(err) {
+			ctx.NotFound("GetCIJobByID", err)
+		} else {
+			ctx.ServerError("GetCIJobByID", err)
+		}
+		return
+	}
+	if err := job.LoadRun(); err != nil {
+		ctx.ServerError("LoadRun", err)
+		return
+	}
+	content, err := ci_service.ReadJobLog(job)
+	if err != nil {
+		ctx.ServerError("ReadJobLog", err)
+		return
+	}
+
+	ctx.Data["Title"] = job.Name
+	ctx.Data["PageIsCI"] = true
+	ctx.Data["Job"] = job
+	ctx.Data["Log"] = string(content)
+	ctx.HTML(http.StatusOK, tplCIJob)
+}
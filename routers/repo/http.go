@@ -210,7 +210,11 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 				// Assume password is token
 				authToken = authPasswd
 			}
-			uid := sso.CheckOAuthAccessToken(authToken)
+			requiredScope := models.AccessTokenScopeRepo
+			if isPull {
+				requiredScope = models.AccessTokenScopeReadRepo
+			}
+			uid, scope := sso.CheckOAuthAccessToken(authToken)
 			if uid != 0 {
 				ctx.Data["IsApiToken"] = true
 
@@ -219,6 +223,10 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 					ctx.ServerError("GetUserByID", err)
 					return
 				}
+				if !scope.Has(requiredScope) {
+					ctx.HandleText(http.StatusForbidden, fmt.Sprintf("Token does not have the required scope: %s", requiredScope))
+					return
+				}
 			}
 			// Assume password is a token.
 			token, err := models.GetAccessTokenBySHA(authToken)
@@ -233,6 +241,15 @@ func httpBase(ctx *context.Context) (h *serviceHandler) {
 				if err = models.UpdateAccessToken(token); err != nil {
 					ctx.ServerError("UpdateAccessToken", err)
 				}
+
+				if !token.Scope.Has(requiredScope) {
+					ctx.HandleText(http.StatusForbidden, fmt.Sprintf("Token does not have the required scope: %s", requiredScope))
+					return
+				}
+				if token.IsRepoRestricted() && (!repoExist || !token.HasAccessToRepo(repo.ID)) {
+					ctx.HandleText(http.StatusForbidden, "Token is restricted to other repositories")
+					return
+				}
 			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
@@ -108,6 +108,25 @@ func MustAllowPulls(ctx *context.Context) {
 	}
 }
 
+// getIndexerIssueStats counts the open and closed issues matching the search options in the issue index
+func getIndexerIssueStats(opts issue_indexer.SearchOptions) (*models.IssueStats, error) {
+	opts.PageSize = 1
+
+	opts.IsClosed = util.OptionalBoolFalse
+	res, err := issue_indexer.Search(&opts)
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.IssueStats{OpenCount: res.Total}
+
+	opts.IsClosed = util.OptionalBoolTrue
+	if res, err = issue_indexer.Search(&opts); err != nil {
+		return nil, err
+	}
+	stats.ClosedCount = res.Total
+	return stats, nil
+}
+
 func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption util.OptionalBool) {
 	var err error
 	viewType := ctx.Query("type")
@@ -154,8 +173,31 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 		keyword = ""
 	}
 
+	var mileIDs []int64
+	if milestoneID > 0 {
+		mileIDs = []int64{milestoneID}
+	}
+
+	// the issue indexer filters, sorts and pages the results itself unless
+	// a condition is requested which is only known to the database
+	useIndexer := len(keyword) > 0 && mentionedID == 0 && reviewRequestedID == 0 && projectID == 0 &&
+		issue_indexer.IsSortTypeSupported(sortType)
+	if useIndexer && len(sortType) == 0 {
+		sortType = issue_indexer.SortByRelevance
+	}
+	searchOpts := &issue_indexer.SearchOptions{
+		Keyword:      keyword,
+		RepoIDs:      []int64{repo.ID},
+		LabelIDs:     labelIDs,
+		MilestoneIDs: mileIDs,
+		PosterID:     posterID,
+		AssigneeID:   assigneeID,
+		IsPull:       isPullOption,
+		SortBy:       sortType,
+	}
+
 	var issueIDs []int64
-	if len(keyword) > 0 {
+	if len(keyword) > 0 && !useIndexer {
 		issueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{repo.ID}, keyword)
 		if err != nil {
 			ctx.ServerError("issueIndexer.Search", err)
@@ -169,6 +211,12 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 	var issueStats *models.IssueStats
 	if forceEmpty {
 		issueStats = &models.IssueStats{}
+	} else if useIndexer {
+		issueStats, err = getIndexerIssueStats(*searchOpts)
+		if err != nil {
+			ctx.ServerError("getIndexerIssueStats", err)
+			return
+		}
 	} else {
 		issueStats, err = models.GetIssueStats(&models.IssueStatsOptions{
 			RepoID:            repo.ID,
@@ -206,14 +254,20 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 	}
 	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
 
-	var mileIDs []int64
-	if milestoneID > 0 {
-		mileIDs = []int64{milestoneID}
-	}
-
 	var issues []*models.Issue
 	if forceEmpty {
 		issues = []*models.Issue{}
+	} else if useIndexer {
+		searchOpts.IsClosed = util.OptionalBoolOf(isShowClosed)
+		searchOpts.Page = pager.Paginater.Current()
+		searchOpts.PageSize = setting.UI.IssuePagingNum
+		var res *issue_indexer.SearchResult
+		res, issues, err = issue_indexer.SearchIssues(searchOpts)
+		if err != nil {
+			ctx.ServerError("issueIndexer.SearchIssues", err)
+			return
+		}
+		ctx.Data["IssueHighlights"] = res.Highlights()
 	} else {
 		issues, err = models.Issues(&models.IssuesOptions{
 			ListOptions: models.ListOptions{
@@ -335,6 +389,7 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption uti
 	ctx.Data["AssigneeID"] = assigneeID
 	ctx.Data["IsShowClosed"] = isShowClosed
 	ctx.Data["Keyword"] = keyword
+	ctx.Data["CanSortByRelevance"] = useIndexer
 	if isShowClosed {
 		ctx.Data["State"] = "closed"
 	} else {
@@ -422,10 +477,7 @@ func RetrieveRepoMilestonesAndAssignees(ctx *context.Context, repo *models.Repos
 }
 
 func retrieveProjects(ctx *context.Context, repo *models.Repository) {
-
-	var err error
-
-	ctx.Data["OpenProjects"], _, err = models.GetProjects(models.ProjectSearchOptions{
+	openProjects, _, err := models.GetProjects(models.ProjectSearchOptions{
 		RepoID:   repo.ID,
 		Page:     -1,
 		IsClosed: util.OptionalBoolFalse,
@@ -436,7 +488,7 @@ func retrieveProjects(ctx *context.Context, repo *models.Repository) {
 		return
 	}
 
-	ctx.Data["ClosedProjects"], _, err = models.GetProjects(models.ProjectSearchOptions{
+	closedProjects, _, err := models.GetProjects(models.ProjectSearchOptions{
 		RepoID:   repo.ID,
 		Page:     -1,
 		IsClosed: util.OptionalBoolTrue,
@@ -446,6 +498,51 @@ func retrieveProjects(ctx *context.Context, repo *models.Repository) {
 		ctx.ServerError("GetProjects", err)
 		return
 	}
+
+	// Issues can also be added to the projects of the repository owner
+	if err := repo.GetOwner(); err != nil {
+		ctx.ServerError("GetOwner", err)
+		return
+	}
+	canWriteOwnerProjects, err := models.CanWriteOwnerProjects(repo.Owner, ctx.User)
+	if err != nil {
+		ctx.ServerError("CanWriteOwnerProjects", err)
+		return
+	}
+	if canWriteOwnerProjects {
+		ownerProjects, _, err := models.GetProjects(models.ProjectSearchOptions{
+			OwnerID: repo.OwnerID,
+			Page:    -1,
+		})
+		if err != nil {
+			ctx.ServerError("GetProjects", err)
+			return
+		}
+		for _, p := range ownerProjects {
+			if p.IsClosed {
+				closedProjects = append(closedProjects, p)
+			} else {
+				openProjects = append(openProjects, p)
+			}
+		}
+	}
+
+	ctx.Data["OpenProjects"] = openProjects
+	ctx.Data["ClosedProjects"] = closedProjects
+}
+
+// canAssignProject checks if issues of the current repository can be added to the project by the doer
+func canAssignProject(ctx *context.Context, p *models.Project) (bool, error) {
+	if p.Type == models.ProjectTypeRepository {
+		return p.RepoID == ctx.Repo.Repository.ID, nil
+	}
+	if p.OwnerID != ctx.Repo.Repository.OwnerID {
+		return false, nil
+	}
+	if err := ctx.Repo.Repository.GetOwner(); err != nil {
+		return false, err
+	}
+	return models.CanWriteOwnerProjects(ctx.Repo.Repository.Owner, ctx.User)
 }
 
 // repoReviewerSelection items to bee shown
@@ -795,7 +892,9 @@ func NewIssue(ctx *context.Context) {
 		project, err := models.GetProjectByID(projectID)
 		if err != nil {
 			log.Error("GetProjectByID: %d: %v", projectID, err)
-		} else if project.RepoID != ctx.Repo.Repository.ID {
+		} else if ok, err := canAssignProject(ctx, project); err != nil {
+			log.Error("canAssignProject: %d: %v", projectID, err)
+		} else if !ok {
 			log.Error("GetProjectByID: %d: %v", projectID, fmt.Errorf("project[%d] not in repo [%d]", project.ID, ctx.Repo.Repository.ID))
 		} else {
 			ctx.Data["project_id"] = projectID
@@ -879,7 +978,10 @@ func ValidateRepoMetas(ctx *context.Context, form forms.CreateIssueForm, isPull
 			ctx.ServerError("GetProjectByID", err)
 			return nil, nil, 0, 0
 		}
-		if p.RepoID != ctx.Repo.Repository.ID {
+		if ok, err := canAssignProject(ctx, p); err != nil {
+			ctx.ServerError("canAssignProject", err)
+			return nil, nil, 0, 0
+		} else if !ok {
 			ctx.NotFound("", nil)
 			return nil, nil, 0, 0
 		}
@@ -1505,12 +1607,36 @@ func ViewIssue(ctx *context.Context) {
 				ctx.Data["MergeStyle"] = models.MergeStyleRebaseMerge
 			} else if prConfig.AllowSquash {
 				ctx.Data["MergeStyle"] = models.MergeStyleSquash
+			} else if prConfig.AllowFastForwardOnly {
+				ctx.Data["MergeStyle"] = models.MergeStyleFastForwardOnly
 			} else if prConfig.AllowManualMerge {
 				ctx.Data["MergeStyle"] = models.MergeStyleManuallyMerged
 			} else {
 				ctx.Data["MergeStyle"] = ""
 			}
 		}
+
+		// Prefill the merge forms from the commit message templates of the repository
+		if allowMerge, _ := ctx.Data["AllowMerge"].(bool); allowMerge && !pull.HasMerged && !issue.IsClosed {
+			if len(prConfig.MergeMessageTemplate) > 0 {
+				message, err := pull_service.GetDefaultMergeMessage(pull, models.MergeStyleMerge)
+				if err != nil {
+					ctx.ServerError("GetDefaultMergeMessage", err)
+					return
+				}
+				ctx.Data["HasMergeMessageTemplate"] = true
+				ctx.Data["MergeMessageTitle"], ctx.Data["MergeMessageBody"] = splitCommitMessage(message)
+			}
+			if len(prConfig.SquashMessageTemplate) > 0 {
+				message, err := pull_service.GetDefaultMergeMessage(pull, models.MergeStyleSquash)
+				if err != nil {
+					ctx.ServerError("GetDefaultMergeMessage", err)
+					return
+				}
+				ctx.Data["HasSquashMessageTemplate"] = true
+				ctx.Data["SquashMessageTitle"], ctx.Data["SquashMessageBody"] = splitCommitMessage(message)
+			}
+		}
 		if err = pull.LoadProtectedBranch(); err != nil {
 			ctx.ServerError("LoadProtectedBranch", err)
 			return
@@ -1521,6 +1647,11 @@ func ViewIssue(ctx *context.Context) {
 			ctx.Data["IsBlockedByRejection"] = pull.ProtectedBranch.MergeBlockedByRejectedReview(pull)
 			ctx.Data["IsBlockedByOfficialReviewRequests"] = pull.ProtectedBranch.MergeBlockedByOfficialReviewRequests(pull)
 			ctx.Data["IsBlockedByOutdatedBranch"] = pull.ProtectedBranch.MergeBlockedByOutdatedBranch(pull)
+			ctx.Data["IsBlockedByCodeOwners"], err = pull_service.MergeBlockedByCodeOwners(pull)
+			if err != nil {
+				ctx.ServerError("MergeBlockedByCodeOwners", err)
+				return
+			}
 			ctx.Data["GrantedApprovals"] = cnt
 			ctx.Data["RequireSigned"] = pull.ProtectedBranch.RequireSignedCommits
 			ctx.Data["ChangedProtectedFiles"] = pull.ChangedProtectedFiles
@@ -1563,6 +1694,41 @@ func ViewIssue(ctx *context.Context) {
 		}
 
 		ctx.Data["StillCanManualMerge"] = stillCanManualMerge()
+
+		// Check if there is a pending auto merge
+		exists, scheduledPRM, err := models.GetScheduledAutoMergeByPullID(pull.ID)
+		if err != nil {
+			ctx.ServerError("GetScheduledAutoMergeByPullID", err)
+			return
+		}
+		ctx.Data["IsPullAutoMergeScheduled"] = exists
+		if exists {
+			ctx.Data["PullAutoMergeDoer"] = scheduledPRM.Doer
+			ctx.Data["CanCancelAutoMerge"] = ctx.IsSigned &&
+				(ctx.Data["AllowMerge"].(bool) || scheduledPRM.DoerID == ctx.User.ID)
+		}
+
+		// Check if the pull request waits in the merge queue
+		ctx.Data["IsMergeQueueEnabled"] = pull.ProtectedBranch != nil && pull.ProtectedBranch.EnableMergeQueue
+		inQueue, queueEntry, err := models.GetMergeQueueEntryByPullID(pull.ID)
+		if err != nil {
+			ctx.ServerError("GetMergeQueueEntryByPullID", err)
+			return
+		}
+		ctx.Data["IsPullInMergeQueue"] = inQueue
+		if inQueue {
+			if err = queueEntry.LoadAttributes(); err != nil {
+				ctx.ServerError("LoadAttributes", err)
+				return
+			}
+			if ctx.Data["MergeQueuePosition"], err = queueEntry.Position(); err != nil {
+				ctx.ServerError("Position", err)
+				return
+			}
+			ctx.Data["MergeQueueEntry"] = queueEntry
+			ctx.Data["CanRemoveFromMergeQueue"] = ctx.IsSigned &&
+				(ctx.Data["AllowMerge"].(bool) || queueEntry.DoerID == ctx.User.ID)
+		}
 	}
 
 	// Get Dependencies
@@ -1591,6 +1757,15 @@ func ViewIssue(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, tplIssueView)
 }
 
+// splitCommitMessage splits a commit message into its title line and its body
+func splitCommitMessage(message string) (string, string) {
+	parts := strings.SplitN(message, "\n", 2)
+	if len(parts) == 1 {
+		return strings.TrimSpace(parts[0]), ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
 // GetActionIssue will return the issue which is used in the context.
 func GetActionIssue(ctx *context.Context) *models.Issue {
 	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
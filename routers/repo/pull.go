@@ -29,8 +29,10 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/modules/web/middleware"
 	"code.gitea.io/gitea/routers/utils"
+	"code.gitea.io/gitea/services/automerge"
 	"code.gitea.io/gitea/services/forms"
 	"code.gitea.io/gitea/services/gitdiff"
+	"code.gitea.io/gitea/services/mergequeue"
 	pull_service "code.gitea.io/gitea/services/pull"
 	repo_service "code.gitea.io/gitea/services/repository"
 	"github.com/unknwon/com"
@@ -478,14 +480,7 @@ func PrepareViewPullInfo(ctx *context.Context, issue *models.Issue) *git.Compare
 	}
 
 	if pull.ProtectedBranch != nil && pull.ProtectedBranch.EnableStatusCheck {
-		ctx.Data["is_context_required"] = func(context string) bool {
-			for _, c := range pull.ProtectedBranch.StatusCheckContexts {
-				if c == context {
-					return true
-				}
-			}
-			return false
-		}
+		ctx.Data["is_context_required"] = pull.ProtectedBranch.IsStatusCheckContextRequired
 		ctx.Data["RequiredStatusCheckState"] = pull_service.MergeRequiredContextsCommitStatus(commitStatuses, pull.ProtectedBranch.StatusCheckContexts)
 	}
 
@@ -635,6 +630,11 @@ func ViewPullFiles(ctx *context.Context) {
 		return
 	}
 
+	if err = diff.LoadCheckRunAnnotations(pull.BaseRepoID, endCommitID); err != nil {
+		ctx.ServerError("LoadCheckRunAnnotations", err)
+		return
+	}
+
 	if err = pull.LoadProtectedBranch(); err != nil {
 		ctx.ServerError("LoadProtectedBranch", err)
 		return
@@ -832,21 +832,6 @@ func MergePullRequest(ctx *context.Context) {
 		return
 	}
 
-	if err := pull_service.CheckPRReadyToMerge(pr, false); err != nil {
-		if !models.IsErrNotAllowedToMerge(err) {
-			ctx.ServerError("Merge PR status", err)
-			return
-		}
-		if isRepoAdmin, err := models.IsUserRepoAdmin(pr.BaseRepo, ctx.User); err != nil {
-			ctx.ServerError("IsUserRepoAdmin", err)
-			return
-		} else if !isRepoAdmin {
-			ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_not_ready"))
-			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
-			return
-		}
-	}
-
 	if ctx.HasError() {
 		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
 		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
@@ -855,14 +840,9 @@ func MergePullRequest(ctx *context.Context) {
 
 	message := strings.TrimSpace(form.MergeTitleField)
 	if len(message) == 0 {
-		if models.MergeStyle(form.Do) == models.MergeStyleMerge {
-			message = pr.GetDefaultMergeMessage()
-		}
-		if models.MergeStyle(form.Do) == models.MergeStyleRebaseMerge {
-			message = pr.GetDefaultMergeMessage()
-		}
-		if models.MergeStyle(form.Do) == models.MergeStyleSquash {
-			message = pr.GetDefaultSquashMessage()
+		if message, err = pull_service.GetDefaultMergeMessage(pr, models.MergeStyle(form.Do)); err != nil {
+			ctx.ServerError("GetDefaultMergeMessage", err)
+			return
 		}
 	}
 
@@ -871,6 +851,43 @@ func MergePullRequest(ctx *context.Context) {
 		message += "\n\n" + form.MergeMessageField
 	}
 
+	if form.MergeWhenChecksSucceed {
+		scheduled, err := automerge.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message)
+		if err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+				return
+			} else if models.IsErrAlreadyScheduledToAutoMerge(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_already_scheduled"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+				return
+			}
+			ctx.ServerError("ScheduleAutoMerge", err)
+			return
+		} else if scheduled {
+			ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_newly_scheduled"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+			return
+		}
+		// All checks succeed already, so merge straight away
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr, false); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			ctx.ServerError("Merge PR status", err)
+			return
+		}
+		if isRepoAdmin, err := models.IsUserRepoAdmin(pr.BaseRepo, ctx.User); err != nil {
+			ctx.ServerError("IsUserRepoAdmin", err)
+			return
+		} else if !isRepoAdmin {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_not_ready"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+			return
+		}
+	}
+
 	pr.Issue = issue
 	pr.Issue.Repo = ctx.Repo.Repository
 
@@ -885,6 +902,25 @@ func MergePullRequest(ctx *context.Context) {
 		return
 	}
 
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err := mergequeue.Add(ctx.User, pr, models.MergeStyle(form.Do), message); err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+				return
+			} else if models.IsErrAlreadyInMergeQueue(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue.already_added"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+				return
+			}
+			ctx.ServerError("AddToMergeQueue", err)
+			return
+		}
+		ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.newly_added"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+		return
+	}
+
 	if err = pull_service.Merge(pr, ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
@@ -923,6 +959,11 @@ func MergePullRequest(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.unrelated_histories"))
 			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
 			return
+		} else if models.IsErrMergeDivergingFastForwardOnly(err) {
+			log.Debug("MergeDivergingFastForwardOnly error: %v", err)
+			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_conflict_diverging_fast_forward_only"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
+			return
 		} else if git.IsErrPushOutOfDate(err) {
 			log.Debug("MergePushOutOfDate error: %v", err)
 			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_out_of_date"))
@@ -962,6 +1003,96 @@ func MergePullRequest(ctx *context.Context) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(pr.Index))
 }
 
+// CancelAutoMergePullRequest cancels a scheduled auto merge of a pull request
+func CancelAutoMergePullRequest(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	pr := issue.PullRequest
+
+	exists, scheduledPRM, err := models.GetScheduledAutoMergeByPullID(pr.ID)
+	if err != nil {
+		ctx.ServerError("GetScheduledAutoMergeByPullID", err)
+		return
+	} else if !exists {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_not_scheduled"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+		return
+	}
+
+	if scheduledPRM.DoerID != ctx.User.ID {
+		allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+		if err != nil {
+			ctx.ServerError("IsUserAllowedToMerge", err)
+			return
+		}
+		if !allowedMerge {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.update_not_allowed"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+			return
+		}
+	}
+
+	if err := automerge.CancelScheduledAutoMerge(ctx.User, pr); err != nil {
+		if models.IsErrNotScheduledToAutoMerge(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_not_scheduled"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+			return
+		}
+		ctx.ServerError("CancelScheduledAutoMerge", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_canceled_schedule"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+}
+
+// RemoveFromMergeQueue takes a pull request out of the merge queue of its base branch
+func RemoveFromMergeQueue(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	pr := issue.PullRequest
+
+	exists, entry, err := models.GetMergeQueueEntryByPullID(pr.ID)
+	if err != nil {
+		ctx.ServerError("GetMergeQueueEntryByPullID", err)
+		return
+	} else if !exists {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue.not_added"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+		return
+	}
+
+	if entry.DoerID != ctx.User.ID {
+		allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+		if err != nil {
+			ctx.ServerError("IsUserAllowedToMerge", err)
+			return
+		}
+		if !allowedMerge {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.update_not_allowed"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+			return
+		}
+	}
+
+	if err := mergequeue.Remove(ctx.User, pr); err != nil {
+		if models.IsErrNotInMergeQueue(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue.not_added"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+			return
+		}
+		ctx.ServerError("RemoveFromMergeQueue", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.removed"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + fmt.Sprint(issue.Index))
+}
+
 func stopTimerIfAvailable(user *models.User, issue *models.Issue) error {
 
 	if models.StopwatchExists(user.ID, issue.ID) {
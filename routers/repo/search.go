@@ -29,26 +29,43 @@ func Search(ctx *context.Context) {
 		page = 1
 	}
 	queryType := strings.TrimSpace(ctx.Query("t"))
-	isMatch := queryType == "match"
+	symbolsOnly := ctx.QueryBool("symbols")
 
-	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch([]int64{ctx.Repo.Repository.ID},
-		language, keyword, page, setting.UI.RepoSearchPagingNum, isMatch)
+	searchOpts := &code_indexer.SearchOptions{
+		RepoIDs:     []int64{ctx.Repo.Repository.ID},
+		Language:    language,
+		Keyword:     keyword,
+		Page:        page,
+		PageSize:    setting.UI.RepoSearchPagingNum,
+		Mode:        code_indexer.ParseSearchMode(queryType),
+		SymbolsOnly: symbolsOnly,
+	}
+
+	total, searchResults, searchResultLanguages, truncated, err := code_indexer.PerformSearch(searchOpts)
 	if err != nil {
-		ctx.ServerError("SearchResults", err)
-		return
+		if !code_indexer.IsErrInvalidSearchKeyword(err) {
+			ctx.ServerError("SearchResults", err)
+			return
+		}
+		ctx.Data["SearchError"] = ctx.Tr("repo.search.invalid_keyword", err.(code_indexer.ErrInvalidSearchKeyword).Err)
 	}
 	ctx.Data["Keyword"] = keyword
 	ctx.Data["Language"] = language
 	ctx.Data["queryType"] = queryType
+	ctx.Data["SymbolsOnly"] = symbolsOnly
 	ctx.Data["SourcePath"] = ctx.Repo.Repository.HTMLURL()
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["SearchResultLanguages"] = searchResultLanguages
+	ctx.Data["SearchResultsTruncated"] = truncated
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
 
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
 	pager.AddParam(ctx, "l", "Language")
+	if symbolsOnly {
+		pager.AddParamString("symbols", "true")
+	}
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(http.StatusOK, tplSearch)
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -41,6 +42,7 @@ const (
 	tplGithookEdit     base.TplName = "repo/settings/githook_edit"
 	tplDeployKeys      base.TplName = "repo/settings/deploy_keys"
 	tplProtectedBranch base.TplName = "repo/settings/protected_branch"
+	tplTags            base.TplName = "repo/settings/tags"
 )
 
 // Settings show a repository's settings page
@@ -53,9 +55,25 @@ func Settings(ctx *context.Context) {
 	ctx.Data["SigningKeyAvailable"] = len(signing) > 0
 	ctx.Data["SigningSettings"] = setting.Repository.Signing
 
+	if !loadPushMirrors(ctx) {
+		return
+	}
+
 	ctx.HTML(http.StatusOK, tplSettingsOptions)
 }
 
+func loadPushMirrors(ctx *context.Context) bool {
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID, models.ListOptions{})
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return false
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+	ctx.Data["DisableNewPushMirrors"] = setting.Repository.DisableMirrors
+	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
+	return true
+}
+
 // SettingsPost response for changes of a repository
 func SettingsPost(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.RepoSettingForm)
@@ -64,6 +82,10 @@ func SettingsPost(ctx *context.Context) {
 
 	repo := ctx.Repo.Repository
 
+	if !loadPushMirrors(ctx) {
+		return
+	}
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -219,6 +241,68 @@ func SettingsPost(ctx *context.Context) {
 		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
 		ctx.Redirect(repo.Link() + "/settings")
 
+	case "push-mirror-sync":
+		m, err := selectPushMirrorByForm(form, repo)
+		if err != nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		mirror_service.AddPushMirrorToQueue(m.ID)
+
+		ctx.Flash.Info(ctx.Tr("repo.settings.push_mirror_sync_in_progress", mirror_service.PushMirrorAddress(m)))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-remove":
+		m, err := selectPushMirrorByForm(form, repo)
+		if err != nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := mirror_service.RemovePushMirror(m); err != nil {
+			ctx.ServerError("RemovePushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror.remove_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-add":
+		if setting.Repository.DisableMirrors {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		address, err := forms.ParseRemoteAddr(form.PushMirrorAddress, form.PushMirrorUsername, form.PushMirrorPassword)
+		if err == nil {
+			err = migrations.IsMigrateURLAllowed(address, ctx.User)
+		}
+		if err != nil {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			handleSettingRemoteAddrError(ctx, err, form)
+			return
+		}
+
+		if _, err := mirror_service.AddPushMirror(repo, address, interval, form.PushMirrorSyncOnCommit); err != nil {
+			ctx.ServerError("AddPushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror.add_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
 	case "advanced":
 		var repoChanged bool
 		var units []models.RepoUnit
@@ -324,9 +408,12 @@ func SettingsPost(ctx *context.Context) {
 					AllowRebase:               form.PullsAllowRebase,
 					AllowRebaseMerge:          form.PullsAllowRebaseMerge,
 					AllowSquash:               form.PullsAllowSquash,
+					AllowFastForwardOnly:      form.PullsAllowFastForwardOnly,
 					AllowManualMerge:          form.PullsAllowManualMerge,
 					AutodetectManualMerge:     form.EnableAutodetectManualMerge,
 					DefaultMergeStyle:         models.MergeStyle(form.PullsDefaultMergeStyle),
+					MergeMessageTemplate:      strings.TrimSpace(form.PullsMergeMessageTemplate),
+					SquashMessageTemplate:     strings.TrimSpace(form.PullsSquashMessageTemplate),
 				},
 			})
 		} else if !models.UnitTypePullRequests.UnitGlobalDisabled() {
@@ -618,6 +705,22 @@ func SettingsPost(ctx *context.Context) {
 	}
 }
 
+func selectPushMirrorByForm(form *forms.RepoSettingForm, repo *models.Repository) (*models.PushMirror, error) {
+	id, err := strconv.ParseInt(form.PushMirrorID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := models.GetPushMirrorByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if m.RepoID != repo.ID {
+		return nil, models.ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
 func handleSettingRemoteAddrError(ctx *context.Context, err error, form *forms.RepoSettingForm) {
 	if models.IsErrInvalidCloneAddr(err) {
 		addrErr := err.(*models.ErrInvalidCloneAddr)
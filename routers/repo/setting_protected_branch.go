@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/services/forms"
 	pull_service "code.gitea.io/gitea/services/pull"
@@ -233,7 +234,14 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 
 		protectBranch.EnableStatusCheck = f.EnableStatusCheck
 		if f.EnableStatusCheck {
-			protectBranch.StatusCheckContexts = f.StatusCheckContexts
+			// The checkboxes of the known contexts and the input for a new pattern share the same name
+			protectBranch.StatusCheckContexts = make([]string, 0, len(f.StatusCheckContexts))
+			for _, context := range f.StatusCheckContexts {
+				context = strings.TrimSpace(context)
+				if context != "" && !util.IsStringInSlice(context, protectBranch.StatusCheckContexts) {
+					protectBranch.StatusCheckContexts = append(protectBranch.StatusCheckContexts, context)
+				}
+			}
 		} else {
 			protectBranch.StatusCheckContexts = nil
 		}
@@ -254,6 +262,8 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 		protectBranch.RequireSignedCommits = f.RequireSignedCommits
 		protectBranch.ProtectedFilePatterns = f.ProtectedFilePatterns
 		protectBranch.BlockOnOutdatedBranch = f.BlockOnOutdatedBranch
+		protectBranch.RequireCodeOwnerApproval = f.RequireCodeOwnerApproval
+		protectBranch.EnableMergeQueue = f.EnableMergeQueue
 
 		err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
 			UserIDs:          whitelistUsers,
@@ -0,0 +1,115 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplSecrets    base.TplName = "repo/settings/secrets"
+	tplOrgSecrets base.TplName = "org/settings/secrets"
+)
+
+type secretsCtx struct {
+	OwnerID  int64
+	RepoID   int64
+	Link     string
+	Template base.TplName
+}
+
+// getSecretsCtx determines whether the secrets of a repository or of an organization are managed
+func getSecretsCtx(ctx *context.Context) *secretsCtx {
+	if len(ctx.Repo.RepoLink) > 0 {
+		return &secretsCtx{
+			RepoID:   ctx.Repo.Repository.ID,
+			Link:     ctx.Repo.RepoLink + "/settings/secrets",
+			Template: tplSecrets,
+		}
+	}
+	return &secretsCtx{
+		OwnerID:  ctx.Org.Organization.ID,
+		Link:     ctx.Org.OrgLink + "/settings/secrets",
+		Template: tplOrgSecrets,
+	}
+}
+
+func prepareSecrets(ctx *context.Context, sCtx *secretsCtx) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.secrets")
+	ctx.Data["PageIsSettingsSecrets"] = true
+	ctx.Data["Link"] = sCtx.Link
+	if sCtx.OwnerID != 0 {
+		ctx.Data["Description"] = ctx.Tr("org.settings.secrets_desc")
+	} else {
+		ctx.Data["Description"] = ctx.Tr("repo.settings.secrets_desc")
+	}
+
+	secrets, err := models.FindSecrets(sCtx.OwnerID, sCtx.RepoID)
+	if err != nil {
+		ctx.ServerError("FindSecrets", err)
+		return
+	}
+	ctx.Data["Secrets"] = secrets
+}
+
+// Secrets render the secrets of a repository or an organization
+func Secrets(ctx *context.Context) {
+	sCtx := getSecretsCtx(ctx)
+	prepareSecrets(ctx, sCtx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, sCtx.Template)
+}
+
+// SecretsPost response for adding or updating a secret
+func SecretsPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AddSecretForm)
+	sCtx := getSecretsCtx(ctx)
+	prepareSecrets(ctx, sCtx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, sCtx.Template)
+		return
+	}
+
+	if _, err := models.SetSecret(sCtx.OwnerID, sCtx.RepoID, form.Name, form.Data); err != nil {
+		if err == models.ErrSecretNameInvalid {
+			ctx.Data["Err_Name"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.secret_name_invalid"), sCtx.Template, form)
+			return
+		}
+		ctx.ServerError("SetSecret", err)
+		return
+	}
+
+	name, _ := models.NormalizeSecretName(form.Name)
+	ctx.Flash.Success(ctx.Tr("repo.settings.add_secret_success", name))
+	ctx.Redirect(sCtx.Link)
+}
+
+// DeleteSecret response for deleting a secret
+func DeleteSecret(ctx *context.Context) {
+	sCtx := getSecretsCtx(ctx)
+	if err := models.DeleteSecret(sCtx.OwnerID, sCtx.RepoID, ctx.Query("id")); err != nil {
+		ctx.Flash.Error("DeleteSecret: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.settings.secret_deletion_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": sCtx.Link,
+	})
+}
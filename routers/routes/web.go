@@ -27,6 +27,8 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers"
 	"code.gitea.io/gitea/routers/admin"
+	packages_router "code.gitea.io/gitea/routers/api/packages"
+	scim_router "code.gitea.io/gitea/routers/api/scim"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/dev"
@@ -47,10 +49,10 @@ import (
 	"gitea.com/go-chi/session"
 	"github.com/NYTimes/gziphandler"
 	"github.com/chi-middleware/proxy"
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/tstranex/u2f"
 	"github.com/unknwon/com"
 )
 
@@ -123,6 +125,11 @@ func NormalRoutes() *web.Route {
 	r.Mount("/", WebRoutes())
 	r.Mount("/api/v1", apiv1.Routes())
 	r.Mount("/api/internal", private.Routes())
+	r.Mount("/scim/v2", scim_router.Routes())
+	if setting.Packages.Enabled {
+		r.Mount("/api/packages", packages_router.Routes())
+		r.Mount("/v2", packages_router.ContainerRoutes())
+	}
 	return r
 }
 
@@ -159,7 +166,7 @@ func WebRoutes() *web.Route {
 	r.Use(storageHandler(setting.Avatar.Storage, "avatars", storage.Avatars))
 	r.Use(storageHandler(setting.RepoAvatar.Storage, "repo-avatars", storage.RepoAvatars))
 
-	gob.Register(&u2f.Challenge{})
+	gob.Register(&webauthn.SessionData{})
 
 	if setting.EnableGzip {
 		h, err := gziphandler.GzipHandlerWithOpts(gziphandler.MinSize(GzipMinSize))
@@ -190,6 +197,7 @@ func WebRoutes() *web.Route {
 		ctx.Data["UnitIssuesGlobalDisabled"] = models.UnitTypeIssues.UnitGlobalDisabled()
 		ctx.Data["UnitPullsGlobalDisabled"] = models.UnitTypePullRequests.UnitGlobalDisabled()
 		ctx.Data["UnitProjectsGlobalDisabled"] = models.UnitTypeProjects.UnitGlobalDisabled()
+		ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
 	})
 
 	// for health check
@@ -317,6 +325,13 @@ func RegisterRoutes(m *web.Route) {
 		}
 	}
 
+	passwordlessLoginEnabled := func(ctx *context.Context) {
+		if !setting.Service.EnablePasswordlessLogin {
+			ctx.Error(http.StatusForbidden)
+			return
+		}
+	}
+
 	reqMilestonesDashboardPageEnabled := func(ctx *context.Context) {
 		if !setting.Service.ShowMilestonesDashboardPage {
 			ctx.Error(http.StatusForbidden)
@@ -376,6 +391,10 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("/{provider}", user.SignInOAuth)
 			m.Get("/{provider}/callback", user.SignInOAuthCallback)
 		})
+		m.Group("/saml", func() {
+			m.Get("/{provider}", user.SignInSAML)
+			m.Post("/{provider}/acs", user.SignInSAMLCallback)
+		})
 		m.Get("/link_account", user.LinkAccount)
 		m.Post("/link_account_signin", bindIgnErr(forms.SignInForm{}), user.LinkAccountPostSignIn)
 		m.Post("/link_account_signup", bindIgnErr(forms.RegisterForm{}), user.LinkAccountPostRegister)
@@ -385,22 +404,31 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("/scratch", user.TwoFactorScratch)
 			m.Post("/scratch", bindIgnErr(forms.TwoFactorScratchAuthForm{}), user.TwoFactorScratchPost)
 		})
-		m.Group("/u2f", func() {
-			m.Get("", user.U2F)
-			m.Get("/challenge", user.U2FChallenge)
-			m.Post("/sign", bindIgnErr(u2f.SignResponse{}), user.U2FSign)
-
+		m.Group("/webauthn", func() {
+			m.Get("", user.WebAuthn)
+			m.Get("/assertion", user.WebAuthnLoginAssertion)
+			m.Post("/assertion", user.WebAuthnLoginAssertionPost)
+			m.Group("/passwordless", func() {
+				m.Get("/assertion", user.WebAuthnPasswordlessAssertion)
+				m.Post("/assertion", user.WebAuthnPasswordlessAssertionPost)
+			}, passwordlessLoginEnabled)
 		})
 	}, reqSignOut)
 
 	m.Any("/user/events", events.Events)
+	m.Get("/user/saml/{provider}/metadata", user.SAMLMetadata)
 
 	m.Group("/login/oauth", func() {
 		m.Get("/authorize", bindIgnErr(forms.AuthorizationForm{}), user.AuthorizeOAuth)
 		m.Post("/grant", bindIgnErr(forms.GrantApplicationForm{}), user.GrantApplicationOAuth)
 		// TODO manage redirection
 		m.Post("/authorize", bindIgnErr(forms.AuthorizationForm{}), user.AuthorizeOAuth)
+		m.Get("/device", user.DeviceOAuth)
+		m.Post("/device", bindIgnErr(forms.GrantDeviceForm{}), user.DeviceOAuthPost)
 	}, ignSignInAndCsrf, reqSignIn)
+	m.Post("/login/oauth/device_authorization", bindIgnErr(forms.DeviceAuthorizationForm{}), ignSignInAndCsrf, user.DeviceAuthorizationOAuth)
+	m.Post("/login/oauth/introspect", bindIgnErr(forms.OAuth2TokenForm{}), ignSignInAndCsrf, user.IntrospectOAuth)
+	m.Post("/login/oauth/revoke", bindIgnErr(forms.OAuth2TokenForm{}), ignSignInAndCsrf, user.RevokeOAuth)
 	if setting.CORSConfig.Enabled {
 		m.Post("/login/oauth/access_token", cors.Handler(cors.Options{
 			//Scheme:           setting.CORSConfig.Scheme, // FIXME: the cors middleware needs scheme option
@@ -436,10 +464,10 @@ func RegisterRoutes(m *web.Route) {
 				m.Get("/enroll", userSetting.EnrollTwoFactor)
 				m.Post("/enroll", bindIgnErr(forms.TwoFactorAuthForm{}), userSetting.EnrollTwoFactorPost)
 			})
-			m.Group("/u2f", func() {
-				m.Post("/request_register", bindIgnErr(forms.U2FRegistrationForm{}), userSetting.U2FRegister)
-				m.Post("/register", bindIgnErr(u2f.RegisterResponse{}), userSetting.U2FRegisterPost)
-				m.Post("/delete", bindIgnErr(forms.U2FDeleteForm{}), userSetting.U2FDelete)
+			m.Group("/webauthn", func() {
+				m.Post("/request_register", bindIgnErr(forms.WebAuthnRegistrationForm{}), userSetting.WebAuthnRegister)
+				m.Post("/register", userSetting.WebAuthnRegisterPost)
+				m.Post("/delete", bindIgnErr(forms.WebAuthnDeleteForm{}), userSetting.WebAuthnDelete)
 			})
 			m.Group("/openid", func() {
 				m.Post("", bindIgnErr(forms.AddOpenIDForm{}), userSetting.OpenIDPost)
@@ -583,6 +611,34 @@ func RegisterRoutes(m *web.Route) {
 		m.Post("/action/{action}", user.Action)
 	}, reqSignIn)
 
+	m.Get("/{username}/-/code", ignSignIn, org.Code)
+
+	m.Group("/{username}/-/projects", func() {
+		m.Get("", user.Projects)
+		m.Get("/{id}", user.ViewProject)
+		m.Group("", func() {
+			m.Get("/new", user.NewProject)
+			m.Post("/new", bindIgnErr(forms.CreateProjectForm{}), user.NewProjectPost)
+			m.Group("/{id}", func() {
+				m.Post("", bindIgnErr(forms.EditProjectBoardForm{}), user.AddBoardToProjectPost)
+				m.Post("/delete", user.DeleteProject)
+				m.Post("/issues", bindIgnErr(forms.AddProjectIssueForm{}), user.AddIssueToProject)
+
+				m.Get("/edit", user.EditProject)
+				m.Post("/edit", bindIgnErr(forms.CreateProjectForm{}), user.EditProjectPost)
+				m.Post("/{action:open|close}", user.ChangeProjectStatus)
+
+				m.Group("/{boardID}", func() {
+					m.Put("", bindIgnErr(forms.EditProjectBoardForm{}), user.EditProjectBoard)
+					m.Delete("", user.DeleteProjectBoard)
+					m.Post("/default", user.SetDefaultProjectBoard)
+
+					m.Post("/{index}", user.MoveIssueAcrossBoards)
+				})
+			})
+		}, reqSignIn, user.MustWriteProjects)
+	}, ignSignIn, user.ProjectsAssignment)
+
 	if !setting.IsProd() {
 		m.Get("/template/*", dev.TemplatePreview)
 	}
@@ -675,6 +731,12 @@ func RegisterRoutes(m *web.Route) {
 					m.Post("/initialize", bindIgnErr(forms.InitializeLabelsForm{}), org.InitializeLabels)
 				})
 
+				m.Group("/secrets", func() {
+					m.Combo("").Get(repo.Secrets).
+						Post(bindIgnErr(forms.AddSecretForm{}), repo.SecretsPost)
+					m.Post("/delete", repo.DeleteSecret)
+				})
+
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
 			})
 		}, context.OrgAssignment(true, true))
@@ -718,6 +780,14 @@ func RegisterRoutes(m *web.Route) {
 					Post(bindIgnErr(forms.ProtectBranchForm{}), context.RepoMustNotBeArchived(), repo.SettingsProtectedBranchPost)
 			}, repo.MustBeNotEmpty)
 
+			m.Group("/tags", func() {
+				m.Get("", repo.Tags)
+				m.Post("", bindIgnErr(forms.ProtectTagForm{}), context.RepoMustNotBeArchived(), repo.NewProtectedTagPost)
+				m.Post("/delete", context.RepoMustNotBeArchived(), repo.DeleteProtectedTagPost)
+				m.Get("/{id}", repo.EditProtectedTag)
+				m.Post("/{id}", bindIgnErr(forms.ProtectTagForm{}), context.RepoMustNotBeArchived(), repo.EditProtectedTagPost)
+			}, repo.MustBeNotEmpty)
+
 			m.Group("/hooks/git", func() {
 				m.Get("", repo.GitHooks)
 				m.Combo("/{name}").Get(repo.GitHooksEdit).
@@ -756,6 +826,12 @@ func RegisterRoutes(m *web.Route) {
 				m.Post("/delete", repo.DeleteDeployKey)
 			})
 
+			m.Group("/secrets", func() {
+				m.Combo("").Get(repo.Secrets).
+					Post(bindIgnErr(forms.AddSecretForm{}), repo.SecretsPost)
+				m.Post("/delete", repo.DeleteSecret)
+			})
+
 			m.Group("/lfs", func() {
 				m.Get("/", repo.LFSFiles)
 				m.Get("/show/{oid}", repo.LFSFileGet)
@@ -1022,6 +1098,8 @@ func RegisterRoutes(m *web.Route) {
 			m.Get(".patch", repo.DownloadPullPatch)
 			m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 			m.Post("/merge", context.RepoMustNotBeArchived(), bindIgnErr(forms.MergePullRequestForm{}), repo.MergePullRequest)
+			m.Post("/cancel_auto_merge", context.RepoMustNotBeArchived(), repo.CancelAutoMergePullRequest)
+			m.Post("/remove_from_merge_queue", context.RepoMustNotBeArchived(), repo.RemoveFromMergeQueue)
 			m.Post("/update", repo.UpdatePullRequest)
 			m.Post("/cleanup", context.RepoMustNotBeArchived(), context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
@@ -1069,8 +1147,17 @@ func RegisterRoutes(m *web.Route) {
 		m.Group("", func() {
 			m.Get("/graph", repo.Graph)
 			m.Get("/commit/{sha:([a-f0-9]{7,40})$}", repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.Diff)
+			m.Get("/checks/{id}", repo.CheckRun)
 		}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
 
+		if setting.CI.Enabled {
+			m.Group("/ci", func() {
+				m.Get("", repo.CIRuns)
+				m.Get("/runs/{id}", repo.CIRun)
+				m.Get("/jobs/{id}", repo.CIJob)
+			}, repo.MustBeNotEmpty, context.RepoRef(), reqRepoCodeReader)
+		}
+
 		m.Group("/src", func() {
 			m.Get("/branch/*", context.RepoRefByType(context.RepoRefBranch), repo.Home)
 			m.Get("/tag/*", context.RepoRefByType(context.RepoRefTag), repo.Home)
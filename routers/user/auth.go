@@ -32,7 +32,6 @@ import (
 	"code.gitea.io/gitea/services/mailer"
 
 	"github.com/markbates/goth"
-	"github.com/tstranex/u2f"
 )
 
 const (
@@ -49,7 +48,7 @@ const (
 	tplTwofa          base.TplName = "user/auth/twofa"
 	tplTwofaScratch   base.TplName = "user/auth/twofa_scratch"
 	tplLinkAccount    base.TplName = "user/auth/link_account"
-	tplU2F            base.TplName = "user/auth/u2f"
+	tplWebAuthn       base.TplName = "user/auth/webauthn"
 )
 
 // AutoSignIn reads cookie and try to auto-login.
@@ -142,11 +141,18 @@ func SignIn(ctx *context.Context) {
 	}
 	ctx.Data["OrderedOAuth2Names"] = orderedOAuth2Names
 	ctx.Data["OAuth2Providers"] = oauth2Providers
+	samlSources, err := models.GetActiveSAMLLoginSources()
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	ctx.Data["SAMLLoginSources"] = samlSources
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
 	ctx.Data["PageIsSignIn"] = true
 	ctx.Data["PageIsLogin"] = true
 	ctx.Data["EnableSSPI"] = models.IsSSPIEnabled()
+	ctx.Data["EnablePasswordlessLogin"] = setting.Service.EnablePasswordlessLogin
 
 	ctx.HTML(http.StatusOK, tplSignIn)
 }
@@ -162,11 +168,18 @@ func SignInPost(ctx *context.Context) {
 	}
 	ctx.Data["OrderedOAuth2Names"] = orderedOAuth2Names
 	ctx.Data["OAuth2Providers"] = oauth2Providers
+	samlSources, err := models.GetActiveSAMLLoginSources()
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	ctx.Data["SAMLLoginSources"] = samlSources
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
 	ctx.Data["PageIsSignIn"] = true
 	ctx.Data["PageIsLogin"] = true
 	ctx.Data["EnableSSPI"] = models.IsSSPIEnabled()
+	ctx.Data["EnablePasswordlessLogin"] = setting.Service.EnablePasswordlessLogin
 
 	if ctx.HasError() {
 		ctx.HTML(http.StatusOK, tplSignIn)
@@ -226,9 +239,8 @@ func SignInPost(ctx *context.Context) {
 		return
 	}
 
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	if hasWebAuthn, err := models.ExistsWebAuthnCredentialsForUID(u.ID); err == nil && hasWebAuthn {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -380,118 +392,6 @@ func TwoFactorScratchPost(ctx *context.Context) {
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_scratch_token_incorrect"), tplTwofaScratch, forms.TwoFactorScratchAuthForm{})
 }
 
-// U2F shows the U2F login page
-func U2F(ctx *context.Context) {
-	ctx.Data["Title"] = ctx.Tr("twofa")
-	ctx.Data["RequireU2F"] = true
-	// Check auto-login.
-	if checkAutoLogin(ctx) {
-		return
-	}
-
-	// Ensure user is in a 2FA session.
-	if ctx.Session.Get("twofaUid") == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
-		return
-	}
-
-	ctx.HTML(http.StatusOK, tplU2F)
-}
-
-// U2FChallenge submits a sign challenge to the browser
-func U2FChallenge(ctx *context.Context) {
-	// Ensure user is in a U2F session.
-	idSess := ctx.Session.Get("twofaUid")
-	if idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
-		return
-	}
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
-	if err != nil {
-		ctx.ServerError("UserSignIn", err)
-		return
-	}
-	if len(regs) == 0 {
-		ctx.ServerError("UserSignIn", errors.New("no device registered"))
-		return
-	}
-	challenge, err := u2f.NewChallenge(setting.U2F.AppID, setting.U2F.TrustedFacets)
-	if err != nil {
-		ctx.ServerError("u2f.NewChallenge", err)
-		return
-	}
-	if err := ctx.Session.Set("u2fChallenge", challenge); err != nil {
-		ctx.ServerError("UserSignIn: unable to set u2fChallenge in session", err)
-		return
-	}
-	if err := ctx.Session.Release(); err != nil {
-		ctx.ServerError("UserSignIn: unable to store session", err)
-	}
-
-	ctx.JSON(http.StatusOK, challenge.SignRequest(regs.ToRegistrations()))
-}
-
-// U2FSign authenticates the user by signResp
-func U2FSign(ctx *context.Context) {
-	signResp := web.GetForm(ctx).(*u2f.SignResponse)
-	challSess := ctx.Session.Get("u2fChallenge")
-	idSess := ctx.Session.Get("twofaUid")
-	if challSess == nil || idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
-		return
-	}
-	challenge := challSess.(*u2f.Challenge)
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
-	if err != nil {
-		ctx.ServerError("UserSignIn", err)
-		return
-	}
-	for _, reg := range regs {
-		r, err := reg.Parse()
-		if err != nil {
-			log.Fatal("parsing u2f registration: %v", err)
-			continue
-		}
-		newCounter, authErr := r.Authenticate(*signResp, *challenge, reg.Counter)
-		if authErr == nil {
-			reg.Counter = newCounter
-			user, err := models.GetUserByID(id)
-			if err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
-			remember := ctx.Session.Get("twofaRemember").(bool)
-			if err := reg.UpdateCounter(); err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
-
-			if ctx.Session.Get("linkAccount") != nil {
-				gothUser := ctx.Session.Get("linkAccountGothUser")
-				if gothUser == nil {
-					ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
-					return
-				}
-
-				err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
-				if err != nil {
-					ctx.ServerError("UserSignIn", err)
-					return
-				}
-			}
-			redirect := handleSignInFull(ctx, user, remember, false)
-			if redirect == "" {
-				redirect = setting.AppSubURL + "/"
-			}
-			ctx.PlainText(200, []byte(redirect))
-			return
-		}
-	}
-	ctx.Error(http.StatusUnauthorized)
-}
-
 // This handles the final part of the sign-in process of the user.
 func handleSignIn(ctx *context.Context, u *models.User, remember bool) {
 	handleSignInFull(ctx, u, remember, true)
@@ -511,7 +411,8 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 	_ = ctx.Session.Delete("openid_determined_username")
 	_ = ctx.Session.Delete("twofaUid")
 	_ = ctx.Session.Delete("twofaRemember")
-	_ = ctx.Session.Delete("u2fChallenge")
+	_ = ctx.Session.Delete("webauthnAssertion")
+	_ = ctx.Session.Delete("webauthnPasswordlessAssertion")
 	_ = ctx.Session.Delete("linkAccount")
 	if err := ctx.Session.Set("uid", u.ID); err != nil {
 		log.Error("Error setting uid %d in session: %v", u.ID, err)
@@ -573,6 +474,14 @@ func SignInOAuth(ctx *context.Context) {
 	user, gothUser, err := oAuth2UserLoginCallback(loginSource, ctx.Req, ctx.Resp)
 	if err == nil && user != nil {
 		// we got the user without going through the whole OAuth2 authentication flow again
+		groups, ok := getOAuth2UserGroups(ctx, loginSource, gothUser)
+		if !ok {
+			return
+		}
+		if err := models.SyncOAuth2Groups(user, loginSource, groups); err != nil {
+			ctx.ServerError("SyncOAuth2Groups", err)
+			return
+		}
 		handleOAuth2SignIn(ctx, user, gothUser)
 		return
 	}
@@ -616,6 +525,11 @@ func SignInOAuthCallback(ctx *context.Context) {
 		return
 	}
 
+	groups, ok := getOAuth2UserGroups(ctx, loginSource, gothUser)
+	if !ok {
+		return
+	}
+
 	if u == nil {
 		if setting.OAuth2Client.EnableAutoRegistration {
 			// create new user with details from oauth2 provider
@@ -659,9 +573,28 @@ func SignInOAuthCallback(ctx *context.Context) {
 		}
 	}
 
+	if err := models.SyncOAuth2Groups(u, loginSource, groups); err != nil {
+		ctx.ServerError("SyncOAuth2Groups", err)
+		return
+	}
+
 	handleOAuth2SignIn(ctx, u, gothUser)
 }
 
+// getOAuth2UserGroups returns the groups listed in the group claim of the user, it redirects
+// to the sign in page and returns false if the user is not a member of the required group
+func getOAuth2UserGroups(ctx *context.Context, loginSource *models.LoginSource, gothUser goth.User) ([]string, bool) {
+	cfg := loginSource.OAuth2()
+	groups := cfg.Groups(gothUser)
+	if !cfg.IsAllowed(groups) {
+		log.Warn("OAuth2 user %s of login source %s is not a member of the required group", gothUser.UserID, loginSource.Name)
+		ctx.Flash.Error(ctx.Tr("auth.oauth_signin_not_allowed"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return nil, false
+	}
+	return groups, true
+}
+
 func getUserName(gothUser *goth.User) string {
 	switch setting.OAuth2Client.Username {
 	case setting.OAuth2UsernameEmail:
@@ -759,10 +692,9 @@ func handleOAuth2SignIn(ctx *context.Context, u *models.User, gothUser goth.User
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if hasWebAuthn, err := models.ExistsWebAuthnCredentialsForUID(u.ID); err == nil && hasWebAuthn {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -782,19 +714,29 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 		return nil, goth.User{}, err
 	}
 
+	user, err := getExternalUser(loginSource, gothUser)
+	if err != nil {
+		return nil, goth.User{}, err
+	}
+	return user, gothUser, nil
+}
+
+// getExternalUser returns the user that signs in through the external login source,
+// either directly or as a linked account. It returns nil if there is no such user.
+func getExternalUser(loginSource *models.LoginSource, gothUser goth.User) (*models.User, error) {
 	user := &models.User{
 		LoginName:   gothUser.UserID,
-		LoginType:   models.LoginOAuth2,
+		LoginType:   loginSource.Type,
 		LoginSource: loginSource.ID,
 	}
 
 	hasUser, err := models.GetUser(user)
 	if err != nil {
-		return nil, goth.User{}, err
+		return nil, err
 	}
 
 	if hasUser {
-		return user, gothUser, nil
+		return user, nil
 	}
 
 	// search in external linked users
@@ -804,16 +746,14 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 	}
 	hasUser, err = models.GetExternalLogin(externalLoginUser)
 	if err != nil {
-		return nil, goth.User{}, err
+		return nil, err
 	}
 	if hasUser {
-		user, err = models.GetUserByID(externalLoginUser.UserID)
-		return user, gothUser, err
+		return models.GetUserByID(externalLoginUser.UserID)
 	}
 
 	// no user found to login
-	return nil, gothUser, nil
-
+	return nil, nil
 }
 
 // LinkAccount shows the page where the user can decide to login or create a new account
@@ -950,10 +890,9 @@ func linkAccount(ctx *context.Context, u *models.User, gothUser goth.User, remem
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if hasWebAuthn, err := models.ExistsWebAuthnCredentialsForUID(u.ID); err == nil && hasWebAuthn {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -1048,9 +987,11 @@ func LinkAccountPostRegister(ctx *context.Context) {
 		}
 	}
 
-	loginSource, err := models.GetActiveOAuth2LoginSourceByName(gothUser.(goth.User).Provider)
+	gu := gothUser.(goth.User)
+	loginSource, err := models.GetActiveExternalLoginSourceByName(gu.Provider)
 	if err != nil {
 		ctx.ServerError("CreateUser", err)
+		return
 	}
 
 	u := &models.User{
@@ -1058,12 +999,12 @@ func LinkAccountPostRegister(ctx *context.Context) {
 		Email:       form.Email,
 		Passwd:      form.Password,
 		IsActive:    !(setting.Service.RegisterEmailConfirm || setting.Service.RegisterManualConfirm),
-		LoginType:   models.LoginOAuth2,
+		LoginType:   loginSource.Type,
 		LoginSource: loginSource.ID,
-		LoginName:   gothUser.(goth.User).UserID,
+		LoginName:   gu.UserID,
 	}
 
-	if !createAndHandleCreatedUser(ctx, tplLinkAccount, form, u, gothUser.(*goth.User), false) {
+	if !createAndHandleCreatedUser(ctx, tplLinkAccount, form, u, &gu, false) {
 		// error already handled
 		return
 	}
@@ -1510,7 +1451,7 @@ func ForgotPasswdPost(ctx *context.Context) {
 		return
 	}
 
-	if !u.IsLocal() && !u.IsOAuth2() {
+	if !u.IsLocal() && !u.IsOAuth2() && !u.IsSAML() {
 		ctx.Data["Err_Email"] = true
 		ctx.RenderWithErr(ctx.Tr("auth.non_local_account"), tplForgotPassword, nil)
 		return
@@ -0,0 +1,162 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/saml"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+func getActiveSAMLLoginSource(ctx *context.Context) *models.LoginSource {
+	source, err := models.GetActiveSAMLLoginSourceByName(ctx.Params(":provider"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetActiveSAMLLoginSourceByName", models.IsErrLoginSourceNotExist, err)
+		return nil
+	}
+	return source
+}
+
+// SAMLMetadata serves the service provider metadata of a SAML login source
+func SAMLMetadata(ctx *context.Context) {
+	source := getActiveSAMLLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	metadata, err := source.SAML().Metadata(saml.BaseURL(source.Name))
+	if err != nil {
+		ctx.ServerError("Metadata", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	if _, err := ctx.Write(metadata); err != nil {
+		log.Error("Failed to write SAML metadata: %v", err)
+	}
+}
+
+// SignInSAML sends the user to the identity provider of a SAML login source
+func SignInSAML(ctx *context.Context) {
+	source := getActiveSAMLLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	sp, err := source.SAML().ServiceProvider(saml.BaseURL(source.Name))
+	if err != nil {
+		ctx.ServerError("ServiceProvider", err)
+		return
+	}
+	requestID, err := saml.NewRequestID(source.Name)
+	if err != nil {
+		ctx.ServerError("NewRequestID", err)
+		return
+	}
+	redirectURL, postForm, err := saml.AuthRequest(sp, requestID)
+	if err != nil {
+		ctx.ServerError("AuthRequest", err)
+		return
+	}
+
+	if postForm != nil {
+		ctx.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := ctx.Write(postForm); err != nil {
+			log.Error("Failed to write SAML request form: %v", err)
+		}
+		return
+	}
+	ctx.Redirect(redirectURL)
+}
+
+// SignInSAMLCallback handles the response the identity provider posts to the assertion consumer service
+func SignInSAMLCallback(ctx *context.Context) {
+	source := getActiveSAMLLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+	cfg := source.SAML()
+
+	sp, err := cfg.ServiceProvider(saml.BaseURL(source.Name))
+	if err != nil {
+		ctx.ServerError("ServiceProvider", err)
+		return
+	}
+
+	info, err := sp.RetrieveAssertionInfo(ctx.Req.FormValue("SAMLResponse"))
+	if err == nil {
+		err = saml.VerifyAssertion(source.Name, info)
+	}
+	if err != nil {
+		log.Warn("Invalid SAML response for login source %s from %s: %v", source.Name, ctx.RemoteAddr(), err)
+		ctx.Flash.Error(ctx.Tr("auth.saml_invalid_response"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	gothUser := cfg.User(source.Name, info)
+	if !cfg.IsAllowed(info) {
+		log.Warn("SAML user %s of login source %s is not a member of the required group", gothUser.UserID, source.Name)
+		ctx.Flash.Error(ctx.Tr("auth.saml_not_allowed"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	u, err := getExternalUser(source, gothUser)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	if u == nil {
+		if !cfg.AutoRegistration {
+			// no existing user is found, request attach or new account
+			showLinkingLogin(ctx, gothUser)
+			return
+		}
+
+		var missingFields []string
+		if gothUser.NickName == "" {
+			missingFields = append(missingFields, "username")
+		}
+		if gothUser.Email == "" {
+			missingFields = append(missingFields, "email")
+		}
+		if len(missingFields) > 0 {
+			log.Error("SAML login source %s returned empty or missing fields: %s", source.Name, missingFields)
+			ctx.ServerError("CreateUser", fmt.Errorf("SAML login source %s returned empty or missing fields: %s", source.Name, missingFields))
+			return
+		}
+
+		isAdmin, _ := cfg.IsAdmin(info)
+		u = &models.User{
+			Name:        gothUser.NickName,
+			FullName:    gothUser.Name,
+			Email:       gothUser.Email,
+			IsAdmin:     isAdmin,
+			IsActive:    !setting.OAuth2Client.RegisterEmailConfirm,
+			LoginType:   models.LoginSAML,
+			LoginSource: source.ID,
+			LoginName:   gothUser.UserID,
+		}
+
+		if !createAndHandleCreatedUser(ctx, base.TplName(""), nil, u, &gothUser, setting.OAuth2Client.AccountLinking != setting.OAuth2AccountLinkingDisabled) {
+			// error already handled
+			return
+		}
+	} else if isAdmin, managed := cfg.IsAdmin(info); managed && u.IsAdmin != isAdmin {
+		u.IsAdmin = isAdmin
+		if err := models.UpdateUserCols(u, "is_admin"); err != nil {
+			ctx.ServerError("UpdateUserCols", err)
+			return
+		}
+	}
+
+	handleOAuth2SignIn(ctx, u, gothUser)
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	wa "code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/externalaccount"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/markbates/goth"
+)
+
+// attestationTypeU2F is the attestation type of credentials that were migrated from U2F registrations
+const attestationTypeU2F = "fido-u2f"
+
+// WebAuthn shows the WebAuthn login page
+func WebAuthn(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("twofa")
+
+	// Check auto-login.
+	if checkAutoLogin(ctx) {
+		return
+	}
+
+	// Ensure user is in a 2FA session.
+	if ctx.Session.Get("twofaUid") == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplWebAuthn)
+}
+
+// WebAuthnLoginAssertion submits a WebAuthn challenge to the browser
+func WebAuthnLoginAssertion(ctx *context.Context) {
+	// Ensure user is in a WebAuthn session.
+	idSess, ok := ctx.Session.Get("twofaUid").(int64)
+	if !ok || idSess == 0 {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+
+	user, err := models.GetUserByID(idSess)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	creds, err := models.GetWebAuthnCredentialsByUID(user.ID)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if len(creds) == 0 {
+		ctx.ServerError("UserSignIn", errors.New("no device registered"))
+		return
+	}
+
+	// Only ask for the U2F App ID if there are security keys that need it,
+	// as browsers reject the extension if the App ID does not match the origin.
+	var opts []webauthn.LoginOption
+	for _, cred := range creds {
+		if cred.AttestationType == attestationTypeU2F {
+			opts = append(opts, webauthn.WithAssertionExtensions(wa.LoginExtensions()))
+			break
+		}
+	}
+
+	assertion, sessionData, err := wa.WebAuthn.BeginLogin((*wa.User)(user), opts...)
+	if err != nil {
+		ctx.ServerError("webauthn.BeginLogin", err)
+		return
+	}
+
+	if err := ctx.Session.Set("webauthnAssertion", sessionData); err != nil {
+		ctx.ServerError("UserSignIn: unable to set webauthnAssertion in session", err)
+		return
+	}
+	if err := ctx.Session.Release(); err != nil {
+		ctx.ServerError("UserSignIn: unable to store session", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, assertion)
+}
+
+// WebAuthnLoginAssertionPost validates the signature and logs the user in
+func WebAuthnLoginAssertionPost(ctx *context.Context) {
+	idSess, ok := ctx.Session.Get("twofaUid").(int64)
+	sessionData, okData := ctx.Session.Get("webauthnAssertion").(*webauthn.SessionData)
+	if !ok || !okData || idSess == 0 || sessionData == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+	defer func() {
+		_ = ctx.Session.Delete("webauthnAssertion")
+	}()
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBody(ctx.Req.Body)
+	if err != nil {
+		log.Info("Unable to parse WebAuthn assertion of user %d from %s: %v", idSess, ctx.RemoteAddr(), err)
+		ctx.Error(http.StatusBadRequest)
+		return
+	}
+
+	user, err := models.GetUserByID(idSess)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	// Validate the parsed response.
+	cred, err := wa.ForAssertion(parsedResponse).ValidateLogin((*wa.User)(user), *sessionData, parsedResponse)
+	if err != nil {
+		log.Info("Failed WebAuthn authentication attempt for %s from %s: %v", user.Name, ctx.RemoteAddr(), err)
+		ctx.Error(http.StatusUnauthorized)
+		return
+	}
+
+	if err := updateWebAuthnCredential(user.ID, cred); err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	remember, _ := ctx.Session.Get("twofaRemember").(bool)
+
+	if ctx.Session.Get("linkAccount") != nil {
+		gothUser := ctx.Session.Get("linkAccountGothUser")
+		if gothUser == nil {
+			ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
+			return
+		}
+
+		err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
+		if err != nil {
+			ctx.ServerError("UserSignIn", err)
+			return
+		}
+	}
+
+	redirect := handleSignInFull(ctx, user, remember, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.PlainText(http.StatusOK, []byte(redirect))
+}
+
+// WebAuthnPasswordlessAssertion submits a WebAuthn challenge for a passwordless login to the browser.
+// Any resident key of this instance may answer it, which identifies the user through its user handle.
+func WebAuthnPasswordlessAssertion(ctx *context.Context) {
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		ctx.ServerError("protocol.CreateChallenge", err)
+		return
+	}
+
+	sessionData := &webauthn.SessionData{
+		Challenge:        challenge.String(),
+		UserVerification: protocol.VerificationRequired,
+	}
+	if err := ctx.Session.Set("webauthnPasswordlessAssertion", sessionData); err != nil {
+		ctx.ServerError("UserSignIn: unable to set webauthnPasswordlessAssertion in session", err)
+		return
+	}
+	if err := ctx.Session.Release(); err != nil {
+		ctx.ServerError("UserSignIn: unable to store session", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &protocol.CredentialAssertion{
+		Response: protocol.PublicKeyCredentialRequestOptions{
+			Challenge:        challenge,
+			Timeout:          wa.WebAuthn.Config.Timeout,
+			RelyingPartyID:   wa.WebAuthn.Config.RPID,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
+
+// WebAuthnPasswordlessAssertionPost validates the signature of a resident key and logs its user in
+func WebAuthnPasswordlessAssertionPost(ctx *context.Context) {
+	sessionData, ok := ctx.Session.Get("webauthnPasswordlessAssertion").(*webauthn.SessionData)
+	if !ok || sessionData == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+	defer func() {
+		_ = ctx.Session.Delete("webauthnPasswordlessAssertion")
+	}()
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBody(ctx.Req.Body)
+	if err != nil {
+		log.Info("Unable to parse WebAuthn assertion from %s: %v", ctx.RemoteAddr(), err)
+		ctx.Error(http.StatusBadRequest)
+		return
+	}
+
+	user, err := models.GetUserByID(wa.UserIDFromHandle(parsedResponse.Response.UserHandle))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			log.Info("Failed WebAuthn authentication attempt from %s: unknown user handle", ctx.RemoteAddr())
+			ctx.Error(http.StatusUnauthorized)
+			return
+		}
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if !user.IsActive || user.ProhibitLogin {
+		log.Info("Failed WebAuthn authentication attempt for %s from %s: user is not allowed to login", user.Name, ctx.RemoteAddr())
+		ctx.Error(http.StatusForbidden)
+		return
+	}
+
+	// The challenge is bound to the user only now that the authenticator told us who it is.
+	sessionData.UserID = (*wa.User)(user).WebAuthnID()
+	cred, err := wa.WebAuthn.ValidateLogin((*wa.User)(user), *sessionData, parsedResponse)
+	if err != nil {
+		log.Info("Failed WebAuthn authentication attempt for %s from %s: %v", user.Name, ctx.RemoteAddr(), err)
+		ctx.Error(http.StatusUnauthorized)
+		return
+	}
+
+	if err := updateWebAuthnCredential(user.ID, cred); err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	redirect := handleSignInFull(ctx, user, false, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.PlainText(http.StatusOK, []byte(redirect))
+}
+
+// updateWebAuthnCredential stores the sign count of the credential that was just used
+func updateWebAuthnCredential(userID int64, cred *webauthn.Credential) error {
+	dbCred, err := models.GetWebAuthnCredentialByCredID(userID, cred.ID)
+	if err != nil {
+		return err
+	}
+	if cred.Authenticator.CloneWarning {
+		log.Warn("WebAuthn credential %d of user %d may have been cloned", dbCred.ID, userID)
+	}
+	return dbCred.UpdateFromLatest(cred)
+}
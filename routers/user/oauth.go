@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
@@ -80,8 +81,19 @@ const (
 	AccessTokenErrorCodeUnsupportedGrantType = "unsupported_grant_type"
 	// AccessTokenErrorCodeInvalidScope represents an error code specified in RFC 6749
 	AccessTokenErrorCodeInvalidScope = "invalid_scope"
+	// AccessTokenErrorCodeAuthorizationPending represents an error code specified in RFC 8628
+	AccessTokenErrorCodeAuthorizationPending = "authorization_pending"
+	// AccessTokenErrorCodeSlowDown represents an error code specified in RFC 8628
+	AccessTokenErrorCodeSlowDown = "slow_down"
+	// AccessTokenErrorCodeAccessDenied represents an error code specified in RFC 8628
+	AccessTokenErrorCodeAccessDenied = "access_denied"
+	// AccessTokenErrorCodeExpiredToken represents an error code specified in RFC 8628
+	AccessTokenErrorCodeExpiredToken = "expired_token"
 )
 
+// grantTypeDeviceCode is the grant type of access token requests of devices (RFC 8628)
+const grantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
 // AccessTokenError represents an error response specified in RFC 6749
 type AccessTokenError struct {
 	ErrorCode        AccessTokenErrorCode `json:"error" form:"error"`
@@ -108,35 +120,60 @@ type AccessTokenResponse struct {
 	AccessToken  string    `json:"access_token"`
 	TokenType    TokenType `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
-	RefreshToken string    `json:"refresh_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
 	IDToken      string    `json:"id_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
 }
 
-func newAccessTokenResponse(grant *models.OAuth2Grant, clientSecret string) (*AccessTokenResponse, *AccessTokenError) {
-	if setting.OAuth2.InvalidateRefreshTokens {
-		if err := grant.IncreaseCounter(); err != nil {
-			return nil, &AccessTokenError{
-				ErrorCode:        AccessTokenErrorCodeInvalidGrant,
-				ErrorDescription: "cannot increase the grant counter",
-			}
-		}
-	}
-	// generate access token to access the API
+// IntrospectionResponse represents the response of a token introspection request specified in RFC 7662
+type IntrospectionResponse struct {
+	Active    bool      `json:"active"`
+	Scope     string    `json:"scope,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	TokenType TokenType `json:"token_type,omitempty"`
+	ExpiresAt int64     `json:"exp,omitempty"`
+	IssuedAt  int64     `json:"iat,omitempty"`
+	Subject   string    `json:"sub,omitempty"`
+	Issuer    string    `json:"iss,omitempty"`
+}
+
+// signAccessToken signs an access token for the grant which is limited to the given scope
+func signAccessToken(grant *models.OAuth2Grant, scope string) (string, *AccessTokenError) {
 	expirationDate := timeutil.TimeStampNow().Add(setting.OAuth2.AccessTokenExpirationTime)
 	accessToken := &models.OAuth2Token{
 		GrantID: grant.ID,
 		Type:    models.TypeAccessToken,
+		Scope:   &scope,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationDate.AsTime().Unix(),
 		},
 	}
 	signedAccessToken, err := accessToken.SignToken()
 	if err != nil {
-		return nil, &AccessTokenError{
+		return "", &AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
 			ErrorDescription: "cannot sign token",
 		}
 	}
+	return signedAccessToken, nil
+}
+
+func newAccessTokenResponse(grant *models.OAuth2Grant, clientSecret string) (*AccessTokenResponse, *AccessTokenError) {
+	if setting.OAuth2.InvalidateRefreshTokens {
+		if err := grant.IncreaseCounter(); err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+				ErrorDescription: "cannot increase the grant counter",
+			}
+		}
+	}
+	// generate access token to access the API
+	expirationDate := timeutil.TimeStampNow().Add(setting.OAuth2.AccessTokenExpirationTime)
+	signedAccessToken, tokenErr := signAccessToken(grant, grant.Scope)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
 
 	// generate refresh token to request an access token after it expired later
 	refreshExpirationDate := timeutil.TimeStampNow().Add(setting.OAuth2.RefreshTokenExpirationTime * 60 * 60).AsTime().Unix()
@@ -156,9 +193,9 @@ func newAccessTokenResponse(grant *models.OAuth2Grant, clientSecret string) (*Ac
 		}
 	}
 
-	// generate OpenID Connect id_token
+	// generate OpenID Connect id_token, it is signed with the client secret which devices may not have sent
 	signedIDToken := ""
-	if grant.ScopeContains("openid") {
+	if grant.ScopeContains("openid") && clientSecret != "" {
 		app, err := models.GetOAuth2ApplicationByID(grant.ApplicationID)
 		if err != nil {
 			return nil, &AccessTokenError{
@@ -190,9 +227,24 @@ func newAccessTokenResponse(grant *models.OAuth2Grant, clientSecret string) (*Ac
 		ExpiresIn:    setting.OAuth2.AccessTokenExpirationTime,
 		RefreshToken: signedRefreshToken,
 		IDToken:      signedIDToken,
+		Scope:        grant.Scope,
 	}, nil
 }
 
+// prepareScopeDescs describes the API access an OAuth2 scope asks for, which is either full access, some scopes or none
+func prepareScopeDescs(ctx *context.Context, scope string) {
+	granted := models.OAuth2AccessTokenScope(scope)
+	ctx.Data["FullAccess"] = granted.Has(models.AccessTokenScopeAll)
+	if granted.Has(models.AccessTokenScopeAll) {
+		return
+	}
+	descs := make([]string, 0, len(granted.Scopes()))
+	for _, s := range granted.Scopes() {
+		descs = append(descs, models.AccessTokenScopeDescKey(s))
+	}
+	ctx.Data["ScopeDescs"] = descs
+}
+
 // AuthorizeOAuth manages authorize requests
 func AuthorizeOAuth(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.AuthorizationForm)
@@ -285,8 +337,8 @@ func AuthorizeOAuth(ctx *context.Context) {
 		return
 	}
 
-	// Redirect if user already granted access
-	if grant != nil {
+	// Redirect if user already granted access to everything the application asks for
+	if grant != nil && grant.CoversScope(form.Scope) {
 		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod)
 		if err != nil {
 			handleServerError(ctx, form.State, form.RedirectURI)
@@ -313,6 +365,7 @@ func AuthorizeOAuth(ctx *context.Context) {
 	ctx.Data["RedirectURI"] = form.RedirectURI
 	ctx.Data["State"] = form.State
 	ctx.Data["Scope"] = form.Scope
+	prepareScopeDescs(ctx, form.Scope)
 	ctx.Data["Nonce"] = form.Nonce
 	ctx.Data["ApplicationUserLink"] = "<a href=\"" + html.EscapeString(setting.AppURL) + html.EscapeString(url.PathEscape(app.User.LowerName)) + "\">@" + html.EscapeString(app.User.Name) + "</a>"
 	ctx.Data["ApplicationRedirectDomainHTML"] = "<strong>" + html.EscapeString(form.RedirectURI) + "</strong>"
@@ -356,7 +409,17 @@ func GrantApplicationOAuth(ctx *context.Context) {
 		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
 		return
 	}
-	grant, err := app.CreateGrant(ctx.User.ID, form.Scope)
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		return
+	}
+	// the user authorizes an application again if it asks for more scopes than before
+	if grant != nil {
+		err = grant.AddScope(form.Scope)
+	} else {
+		grant, err = app.CreateGrant(ctx.User.ID, form.Scope)
+	}
 	if err != nil {
 		handleAuthorizeError(ctx, AuthorizeError{
 			State:            form.State,
@@ -399,32 +462,56 @@ func OIDCWellKnown(ctx *context.Context) {
 	}
 }
 
+// parseClientAuthorization takes the client credentials from the basic authorization header if the form did not contain them
+func parseClientAuthorization(ctx *context.Context, clientID, clientSecret *string) *AccessTokenError {
+	if *clientID != "" {
+		return nil
+	}
+	authHeader := ctx.Req.Header.Get("Authorization")
+	authContent := strings.SplitN(authHeader, " ", 2)
+	if len(authContent) != 2 || authContent[0] != "Basic" {
+		return nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(authContent[1])
+	if err != nil {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	pair := strings.SplitN(string(payload), ":", 2)
+	if len(pair) != 2 {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	*clientID = pair[0]
+	*clientSecret = pair[1]
+	return nil
+}
+
+// authenticateClient returns the application the client credentials belong to, or nil if they are invalid
+func authenticateClient(clientID, clientSecret string) (*models.OAuth2Application, error) {
+	app, err := models.GetOAuth2ApplicationByClientID(clientID)
+	if err != nil {
+		if models.IsErrOauthClientIDInvalid(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !app.ValidateClientSecret([]byte(clientSecret)) {
+		return nil, nil
+	}
+	return app, nil
+}
+
 // AccessTokenOAuth manages all access token requests by the client
 func AccessTokenOAuth(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AccessTokenForm)
-	if form.ClientID == "" {
-		authHeader := ctx.Req.Header.Get("Authorization")
-		authContent := strings.SplitN(authHeader, " ", 2)
-		if len(authContent) == 2 && authContent[0] == "Basic" {
-			payload, err := base64.StdEncoding.DecodeString(authContent[1])
-			if err != nil {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			pair := strings.SplitN(string(payload), ":", 2)
-			if len(pair) != 2 {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			form.ClientID = pair[0]
-			form.ClientSecret = pair[1]
-		}
+	if tokenErr := parseClientAuthorization(ctx, &form.ClientID, &form.ClientSecret); tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
 	}
 	switch form.GrantType {
 	case "refresh_token":
@@ -433,10 +520,16 @@ func AccessTokenOAuth(ctx *context.Context) {
 	case "authorization_code":
 		handleAuthorizationCode(ctx, form)
 		return
+	case "client_credentials":
+		handleClientCredentials(ctx, form)
+		return
+	case grantTypeDeviceCode:
+		handleDeviceCode(ctx, form)
+		return
 	default:
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeUnsupportedGrantType,
-			ErrorDescription: "Only refresh_token or authorization_code grant type is supported",
+			ErrorDescription: "Only refresh_token, authorization_code, client_credentials or device_code grant type is supported",
 		})
 	}
 }
@@ -540,6 +633,194 @@ func handleAuthorizationCode(ctx *context.Context, form forms.AccessTokenForm) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// handleClientCredentials issues an access token to act on behalf of the owner of the application, e.g. for a service account.
+// There is no user interaction, so the token gets the requested scope and no refresh token. It belongs to a grant of its own,
+// revoking it leaves the consent the owner may have given to the application as a user untouched.
+func handleClientCredentials(ctx *context.Context, form forms.AccessTokenForm) {
+	app, err := authenticateClient(form.ClientID, form.ClientSecret)
+	if err != nil {
+		log.Error("authenticateClient: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+	if app == nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: "client is not authorized",
+		})
+		return
+	}
+	owner, err := models.GetUserByID(app.UID)
+	if err != nil || !owner.IsActive || owner.ProhibitLogin {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "the owner of the client is not allowed to sign in",
+		})
+		return
+	}
+
+	// there is no default scope, the client has to ask for what it needs
+	if len(models.OAuth2AccessTokenScope(form.Scope)) == 0 {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidScope,
+			ErrorDescription: "the client has to request at least one API scope",
+		})
+		return
+	}
+
+	// the application can never get more access than its owner authorized it for
+	ownerGrant, err := app.GetGrantByUserID(owner.ID)
+	if err != nil {
+		log.Error("Unable to get the grant of the owner: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+	if ownerGrant == nil || !ownerGrant.CoversScope(form.Scope) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidScope,
+			ErrorDescription: "the requested scope exceeds the scope the owner of the client authorized it for",
+		})
+		return
+	}
+
+	grant, err := app.GetClientCredentialsGrant()
+	if err != nil {
+		log.Error("Unable to get the client credentials grant: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+
+	signedAccessToken, tokenErr := signAccessToken(grant, form.Scope)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, &AccessTokenResponse{
+		AccessToken: signedAccessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   setting.OAuth2.AccessTokenExpirationTime,
+		Scope:       form.Scope,
+	})
+}
+
+// loadClientToken parses a token issued to the application and loads its grant, both are nil if it is invalid or belongs to another application
+func loadClientToken(app *models.OAuth2Application, signedToken string) (*models.OAuth2Token, *models.OAuth2Grant, error) {
+	token, err := models.ParseOAuth2Token(signedToken)
+	if err != nil {
+		return nil, nil, nil
+	}
+	grant, err := models.GetOAuth2GrantByID(token.GrantID)
+	if err != nil || grant == nil || grant.ApplicationID != app.ID {
+		return nil, nil, err
+	}
+	return token, grant, nil
+}
+
+// handleClientAuthorizationError responds to requests of clients which failed to authenticate
+func handleClientAuthorizationError(ctx *context.Context) {
+	ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea OAuth2"`)
+	ctx.JSON(http.StatusUnauthorized, AccessTokenError{
+		ErrorCode:        AccessTokenErrorCodeInvalidClient,
+		ErrorDescription: "client is not authorized",
+	})
+}
+
+// IntrospectOAuth tells a client whether a token issued to it is active and what it grants (RFC 7662)
+func IntrospectOAuth(ctx *context.Context) {
+	form := *web.GetForm(ctx).(*forms.OAuth2TokenForm)
+	if tokenErr := parseClientAuthorization(ctx, &form.ClientID, &form.ClientSecret); tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	app, err := authenticateClient(form.ClientID, form.ClientSecret)
+	if err != nil {
+		ctx.ServerError("authenticateClient", err)
+		return
+	}
+	if app == nil {
+		handleClientAuthorizationError(ctx)
+		return
+	}
+
+	token, grant, err := loadClientToken(app, form.Token)
+	if err != nil {
+		ctx.ServerError("loadClientToken", err)
+		return
+	}
+	now := time.Now().Unix()
+	if token == nil || token.ExpiresAt < now || token.IssuedAt > now ||
+		token.Type == models.TypeRefreshToken && setting.OAuth2.InvalidateRefreshTokens && grant.Counter != token.Counter {
+		ctx.JSON(http.StatusOK, &IntrospectionResponse{})
+		return
+	}
+	user, err := models.GetUserByID(grant.UserID)
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			ctx.JSON(http.StatusOK, &IntrospectionResponse{})
+			return
+		}
+		ctx.ServerError("GetUserByID", err)
+		return
+	}
+
+	resp := &IntrospectionResponse{
+		Active:    true,
+		Scope:     grant.Scope,
+		ClientID:  app.ClientID,
+		Username:  user.Name,
+		ExpiresAt: token.ExpiresAt,
+		IssuedAt:  token.IssuedAt,
+		Subject:   fmt.Sprint(user.ID),
+		Issuer:    setting.AppURL,
+	}
+	if token.Type == models.TypeAccessToken {
+		resp.Scope = token.GrantedScope(grant)
+		resp.TokenType = TokenTypeBearer
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RevokeOAuth revokes the grant a token was issued for, which invalidates all access and refresh tokens of the grant (RFC 7009)
+func RevokeOAuth(ctx *context.Context) {
+	form := *web.GetForm(ctx).(*forms.OAuth2TokenForm)
+	if tokenErr := parseClientAuthorization(ctx, &form.ClientID, &form.ClientSecret); tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	app, err := authenticateClient(form.ClientID, form.ClientSecret)
+	if err != nil {
+		ctx.ServerError("authenticateClient", err)
+		return
+	}
+	if app == nil {
+		handleClientAuthorizationError(ctx)
+		return
+	}
+
+	_, grant, err := loadClientToken(app, form.Token)
+	if err != nil {
+		ctx.ServerError("loadClientToken", err)
+		return
+	}
+	// invalid tokens are no error, the client has nothing to revoke anymore
+	if grant != nil {
+		if err := models.RevokeOAuth2Grant(grant.ID, grant.UserID); err != nil {
+			ctx.ServerError("RevokeOAuth2Grant", err)
+			return
+		}
+	}
+	ctx.Status(http.StatusOK)
+}
+
 func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
 	ctx.JSON(http.StatusBadRequest, acErr)
 }
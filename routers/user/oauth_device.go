@@ -0,0 +1,237 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"html"
+	"net/http"
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const tplDevice base.TplName = "user/auth/device"
+
+// DeviceAuthorizationResponse represents a successful device authorization response specified in RFC 8628
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceAuthorizationOAuth starts the authorization of a device which cannot open a browser itself, e.g. a command line tool.
+// The device shows the user code and polls the token endpoint with the device code until the user decided (RFC 8628).
+func DeviceAuthorizationOAuth(ctx *context.Context) {
+	form := *web.GetForm(ctx).(*forms.DeviceAuthorizationForm)
+	if tokenErr := parseClientAuthorization(ctx, &form.ClientID, &form.ClientSecret); tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
+	if err != nil {
+		if models.IsErrOauthClientIDInvalid(err) {
+			handleClientAuthorizationError(ctx)
+			return
+		}
+		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
+		return
+	}
+	// devices are public clients which may not know the secret, but if they send it, it has to be right
+	if form.ClientSecret != "" && !app.ValidateClientSecret([]byte(form.ClientSecret)) {
+		handleClientAuthorizationError(ctx)
+		return
+	}
+
+	auth, err := app.CreateOAuth2DeviceAuthorization(form.Scope)
+	if err != nil {
+		ctx.ServerError("CreateOAuth2DeviceAuthorization", err)
+		return
+	}
+	verificationURI := setting.AppURL + "login/oauth/device"
+	ctx.JSON(http.StatusOK, &DeviceAuthorizationResponse{
+		DeviceCode:              auth.DeviceCode,
+		UserCode:                auth.FormattedUserCode(),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(auth.FormattedUserCode()),
+		ExpiresIn:               int64(models.OAuth2DeviceCodeExpirationTime.Seconds()),
+		Interval:                auth.PollInterval,
+	})
+}
+
+// handleDeviceCode issues the tokens once the user approved the authorization of the device
+func handleDeviceCode(ctx *context.Context, form forms.AccessTokenForm) {
+	auth, err := models.GetOAuth2DeviceAuthorizationByDeviceCode(form.DeviceCode)
+	if err != nil {
+		log.Error("GetOAuth2DeviceAuthorizationByDeviceCode: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: "cannot load client with client id: '" + form.ClientID + "'",
+		})
+		return
+	}
+	if form.ClientSecret != "" && !app.ValidateClientSecret([]byte(form.ClientSecret)) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "client is not authorized",
+		})
+		return
+	}
+	if auth == nil || auth.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid device code",
+		})
+		return
+	}
+
+	if auth.IsExpired() {
+		if err := auth.Invalidate(); err != nil {
+			log.Error("Unable to delete expired device authorization: %v", err)
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeExpiredToken,
+			ErrorDescription: "the device code has expired",
+		})
+		return
+	}
+
+	switch auth.Status {
+	case models.OAuth2DeviceAuthorizationPending:
+		slowDown, err := auth.Poll()
+		if err != nil {
+			log.Error("Unable to record polling of device: %v", err)
+		}
+		if slowDown {
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeSlowDown,
+				ErrorDescription: "the device polls too often",
+			})
+			return
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAuthorizationPending,
+			ErrorDescription: "the user has not authorized the device yet",
+		})
+		return
+	case models.OAuth2DeviceAuthorizationDenied:
+		if err := auth.Invalidate(); err != nil {
+			log.Error("Unable to delete denied device authorization: %v", err)
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAccessDenied,
+			ErrorDescription: "the user denied the authorization",
+		})
+		return
+	}
+
+	grant, err := models.GetOAuth2GrantByID(auth.GrantID)
+	if err != nil || grant == nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "grant does not exist",
+		})
+		return
+	}
+	// remove the authorization from database to deny duplicate usage
+	if err := auth.Invalidate(); err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+	resp, tokenErr := newAccessTokenResponse(grant, form.ClientSecret)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// prepareDeviceAuthorization looks up the pending device authorization with the user code and shows what the device asks for
+func prepareDeviceAuthorization(ctx *context.Context, userCode string) *models.OAuth2DeviceAuthorization {
+	ctx.Data["Title"] = ctx.Tr("auth.device_title")
+	ctx.Data["user_code"] = userCode
+
+	auth, err := models.GetOAuth2DeviceAuthorizationByUserCode(userCode)
+	if err != nil {
+		ctx.ServerError("GetOAuth2DeviceAuthorizationByUserCode", err)
+		return nil
+	}
+	if auth == nil {
+		ctx.Data["Err_UserCode"] = true
+		ctx.RenderWithErr(ctx.Tr("auth.device_code_invalid"), tplDevice, nil)
+		return nil
+	}
+	app, err := models.GetOAuth2ApplicationByID(auth.ApplicationID)
+	if err != nil {
+		ctx.ServerError("GetOAuth2ApplicationByID", err)
+		return nil
+	}
+	if err := app.LoadUser(); err != nil {
+		ctx.ServerError("LoadUser", err)
+		return nil
+	}
+
+	ctx.Data["DeviceAuthorization"] = auth
+	ctx.Data["Application"] = app
+	prepareScopeDescs(ctx, auth.Scope)
+	ctx.Data["ApplicationUserLink"] = "<a href=\"" + html.EscapeString(setting.AppURL) + html.EscapeString(url.PathEscape(app.User.LowerName)) + "\">@" + html.EscapeString(app.User.Name) + "</a>"
+	return auth
+}
+
+// DeviceOAuth shows the page where users enter the user code of a device and confirm its authorization
+func DeviceOAuth(ctx *context.Context) {
+	userCode := ctx.QueryTrim("user_code")
+	if userCode == "" {
+		ctx.Data["Title"] = ctx.Tr("auth.device_title")
+		ctx.HTML(http.StatusOK, tplDevice)
+		return
+	}
+	if prepareDeviceAuthorization(ctx, userCode) == nil {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplDevice)
+}
+
+// DeviceOAuthPost approves or denies the authorization of a device
+func DeviceOAuthPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.GrantDeviceForm)
+	auth := prepareDeviceAuthorization(ctx, form.UserCode)
+	if auth == nil {
+		return
+	}
+
+	if form.Granted {
+		if err := auth.Approve(ctx.User.ID); err != nil {
+			ctx.ServerError("Approve", err)
+			return
+		}
+	} else if err := auth.Deny(); err != nil {
+		ctx.ServerError("Deny", err)
+		return
+	}
+	ctx.Data["DeviceAuthorization"] = nil
+	ctx.Data["DeviceGranted"] = form.Granted
+	ctx.Data["DeviceDecided"] = true
+	ctx.HTML(http.StatusOK, tplDevice)
+}
@@ -0,0 +1,608 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplProjects     base.TplName = "user/projects/list"
+	tplProjectsNew  base.TplName = "user/projects/new"
+	tplProjectsView base.TplName = "user/projects/view"
+)
+
+// ProjectsAssignment loads the user or organization owning the projects and checks the doer can see them
+func ProjectsAssignment(ctx *context.Context) {
+	if models.UnitTypeProjects.UnitGlobalDisabled() {
+		ctx.NotFound("EnableKanbanBoard", nil)
+		return
+	}
+
+	owner := GetUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if owner.IsOrganization() {
+		if !models.HasOrgVisible(owner, ctx.User) {
+			ctx.NotFound("HasOrgVisible", nil)
+			return
+		}
+		ctx.Data["Org"] = owner
+		ctx.Data["OrgLink"] = owner.OrganisationLink()
+	}
+
+	canWrite, err := models.CanWriteOwnerProjects(owner, ctx.User)
+	if err != nil {
+		ctx.ServerError("CanWriteOwnerProjects", err)
+		return
+	}
+
+	ctx.Data["ContextUser"] = owner
+	ctx.Data["ProjectsLink"] = owner.HomeLink() + "/-/projects"
+	ctx.Data["CanWriteProjects"] = canWrite
+	ctx.Data["PageIsOwnerProjects"] = true
+}
+
+// MustWriteProjects checks the doer is allowed to manage the projects of the owner
+func MustWriteProjects(ctx *context.Context) {
+	if canWrite, _ := ctx.Data["CanWriteProjects"].(bool); !canWrite {
+		ctx.NotFound("MustWriteProjects", nil)
+	}
+}
+
+func projectsOwner(ctx *context.Context) *models.User {
+	return ctx.Data["ContextUser"].(*models.User)
+}
+
+func projectsLink(ctx *context.Context) string {
+	return ctx.Data["ProjectsLink"].(string)
+}
+
+func renderProjectDescription(ctx *context.Context, p *models.Project) (err error) {
+	p.RenderedContent, err = markdown.RenderString(&markup.RenderContext{
+		URLPrefix: projectsOwner(ctx).HomeLink(),
+		Metas:     map[string]string{"mode": "document"},
+	}, p.Description)
+	return err
+}
+
+// getProject returns the project given by the :id parameter if it belongs to the owner
+func getProject(ctx *context.Context) *models.Project {
+	p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByID", err)
+		}
+		return nil
+	}
+	if p.Type == models.ProjectTypeRepository || p.OwnerID != projectsOwner(ctx).ID {
+		ctx.NotFound("", nil)
+		return nil
+	}
+	return p
+}
+
+// getProjectBoard returns the board given by the :boardID parameter if it belongs to the project
+func getProjectBoard(ctx *context.Context, p *models.Project) *models.ProjectBoard {
+	board, err := models.GetProjectBoard(ctx.ParamsInt64(":boardID"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectBoard", err)
+		}
+		return nil
+	}
+	if board.ProjectID != p.ID {
+		ctx.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"message": fmt.Sprintf("ProjectBoard[%d] is not in Project[%d] as expected", board.ID, p.ID),
+		})
+		return nil
+	}
+	return board
+}
+
+// Projects renders the projects of a user or organization
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.project_board")
+
+	owner := projectsOwner(ctx)
+	sortType := ctx.QueryTrim("sort")
+	isShowClosed := strings.ToLower(ctx.QueryTrim("state")) == "closed"
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	openCount, err := models.CountProjects(models.ProjectSearchOptions{
+		OwnerID:  owner.ID,
+		IsClosed: util.OptionalBoolFalse,
+	})
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	closedCount, err := models.CountProjects(models.ProjectSearchOptions{
+		OwnerID:  owner.ID,
+		IsClosed: util.OptionalBoolTrue,
+	})
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	ctx.Data["OpenCount"] = openCount
+	ctx.Data["ClosedCount"] = closedCount
+
+	projects, count, err := models.GetProjects(models.ProjectSearchOptions{
+		OwnerID:  owner.ID,
+		Page:     page,
+		IsClosed: util.OptionalBoolOf(isShowClosed),
+		SortType: sortType,
+	})
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+
+	for _, p := range projects {
+		if err := renderProjectDescription(ctx, p); err != nil {
+			ctx.ServerError("RenderString", err)
+			return
+		}
+	}
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+
+	pager := context.NewPagination(int(count), setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["IsProjectsPage"] = true
+	ctx.Data["SortType"] = sortType
+
+	ctx.HTML(http.StatusOK, tplProjects)
+}
+
+// NewProject renders the page to create a project of a user or organization
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["ProjectTypes"] = models.GetProjectsConfig()
+	ctx.HTML(http.StatusOK, tplProjectsNew)
+}
+
+// NewProjectPost creates a project of a user or organization
+func NewProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.CreateProjectForm)
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+
+	if ctx.HasError() {
+		ctx.Data["ProjectTypes"] = models.GetProjectsConfig()
+		ctx.HTML(http.StatusOK, tplProjectsNew)
+		return
+	}
+
+	owner := projectsOwner(ctx)
+	projectType := models.ProjectTypeIndividual
+	if owner.IsOrganization() {
+		projectType = models.ProjectTypeOrganization
+	}
+
+	if err := models.NewProject(&models.Project{
+		OwnerID:     owner.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		BoardType:   form.BoardType,
+		Type:        projectType,
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(projectsLink(ctx))
+}
+
+// ChangeProjectStatus updates the status of a project between "open" and "close"
+func ChangeProjectStatus(ctx *context.Context) {
+	var toClose bool
+	switch ctx.Params(":action") {
+	case "open":
+		toClose = false
+	case "close":
+		toClose = true
+	default:
+		ctx.Redirect(projectsLink(ctx))
+		return
+	}
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.ChangeProjectStatus(p, toClose); err != nil {
+		ctx.ServerError("ChangeProjectStatus", err)
+		return
+	}
+	ctx.Redirect(projectsLink(ctx) + "?state=" + ctx.Params(":action"))
+}
+
+// DeleteProject deletes a project of a user or organization
+func DeleteProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Flash.Error("DeleteProjectByID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": projectsLink(ctx),
+	})
+}
+
+// EditProject renders the page to edit a project of a user or organization
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+
+	ctx.HTML(http.StatusOK, tplProjectsNew)
+}
+
+// EditProjectPost updates a project of a user or organization
+func EditProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.CreateProjectForm)
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplProjectsNew)
+		return
+	}
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(projectsLink(ctx))
+}
+
+// ViewProject renders the boards of a project of a user or organization.
+// Only the cards of the issues and pull requests the doer can read are shown.
+func ViewProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+
+	if boards[0].ID == 0 {
+		boards[0].Title = ctx.Tr("repo.projects.type.uncategorized")
+	}
+
+	if _, err := boards.LoadIssues(); err != nil {
+		ctx.ServerError("LoadIssuesOfBoards", err)
+		return
+	}
+
+	linkedPrsMap := make(map[int64][]*models.Issue)
+	for _, board := range boards {
+		if board.Issues, err = models.FilterReadableIssues(board.Issues, ctx.User); err != nil {
+			ctx.ServerError("FilterReadableIssues", err)
+			return
+		}
+
+		for _, issue := range board.Issues {
+			var referencedIds []int64
+			for _, comment := range issue.Comments {
+				if comment.RefIssueID != 0 && comment.RefIsPull {
+					referencedIds = append(referencedIds, comment.RefIssueID)
+				}
+			}
+			if len(referencedIds) == 0 {
+				continue
+			}
+
+			linkedPrs, err := models.Issues(&models.IssuesOptions{
+				IssueIDs: referencedIds,
+				IsPull:   util.OptionalBoolTrue,
+			})
+			if err != nil {
+				continue
+			}
+			if linkedPrsMap[issue.ID], err = models.FilterReadableIssues(linkedPrs, ctx.User); err != nil {
+				ctx.ServerError("FilterReadableIssues", err)
+				return
+			}
+		}
+	}
+	ctx.Data["LinkedPRs"] = linkedPrsMap
+
+	if err := renderProjectDescription(ctx, p); err != nil {
+		ctx.ServerError("RenderString", err)
+		return
+	}
+
+	ctx.Data["Title"] = p.Title
+	ctx.Data["Project"] = p
+	ctx.Data["Boards"] = boards
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["RequiresDraggable"] = true
+
+	ctx.HTML(http.StatusOK, tplProjectsView)
+}
+
+// parseIssueReference parses a reference of the form owner/repo#index
+func parseIssueReference(ref string) (owner, repo string, index int64, ok bool) {
+	ref = strings.TrimSpace(ref)
+	pos := strings.LastIndexByte(ref, '#')
+	if pos < 0 {
+		return "", "", 0, false
+	}
+	index, err := strconv.ParseInt(ref[pos+1:], 10, 64)
+	if err != nil || index <= 0 {
+		return "", "", 0, false
+	}
+	fields := strings.Split(ref[:pos], "/")
+	if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+		return "", "", 0, false
+	}
+	return fields[0], fields[1], index, true
+}
+
+// AddIssueToProject adds an issue or pull request of any repository to a project of a user or organization.
+// The doer needs write access to the issues or pull requests of the repository.
+func AddIssueToProject(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AddProjectIssueForm)
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	link := fmt.Sprintf("%s/%d", projectsLink(ctx), p.ID)
+
+	ownerName, repoName, index, ok := parseIssueReference(form.Issue)
+	if ctx.HasError() || !ok {
+		ctx.Flash.Error(ctx.Tr("repo.projects.issue.invalid_reference", form.Issue))
+		ctx.Redirect(link)
+		return
+	}
+
+	repo, err := models.GetRepositoryByOwnerAndName(ownerName, repoName)
+	if err != nil && !models.IsErrRepoNotExist(err) {
+		ctx.ServerError("GetRepositoryByOwnerAndName", err)
+		return
+	}
+	var issue *models.Issue
+	if repo != nil {
+		issue, err = models.GetIssueByIndex(repo.ID, index)
+		if err != nil && !models.IsErrIssueNotExist(err) {
+			ctx.ServerError("GetIssueByIndex", err)
+			return
+		}
+	}
+	if issue != nil {
+		perm, err := models.GetUserRepoPermission(repo, ctx.User)
+		if err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return
+		}
+		if !perm.CanWriteIssuesOrPulls(issue.IsPull) {
+			issue = nil
+		}
+	}
+	if issue == nil {
+		ctx.Flash.Error(ctx.Tr("repo.projects.issue.not_found", form.Issue))
+		ctx.Redirect(link)
+		return
+	}
+
+	if issue.ProjectID() != p.ID {
+		issue.Repo = repo
+		if err := models.ChangeProjectAssign(issue, ctx.User, p.ID); err != nil {
+			ctx.ServerError("ChangeProjectAssign", err)
+			return
+		}
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.issue.add_success", form.Issue))
+	ctx.Redirect(link)
+}
+
+// AddBoardToProjectPost adds a board to a project of a user or organization
+func AddBoardToProjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.EditProjectBoardForm)
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// EditProjectBoard updates the title or the position of a board
+func EditProjectBoard(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.EditProjectBoardForm)
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	board := getProjectBoard(ctx, p)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != "" {
+		board.Title = form.Title
+	}
+
+	if form.Sorting != 0 {
+		board.Sorting = form.Sorting
+	}
+
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// DeleteProjectBoard deletes a board of a project
+func DeleteProjectBoard(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	board := getProjectBoard(ctx, p)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.ServerError("DeleteProjectBoardByID", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// SetDefaultProjectBoard sets the board which holds the uncategorized issues and pull requests
+func SetDefaultProjectBoard(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	board := getProjectBoard(ctx, p)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.SetDefaultBoard(p.ID, board.ID); err != nil {
+		ctx.ServerError("SetDefaultBoard", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// MoveIssueAcrossBoards moves a card from one board to another.
+// The doer must be able to read the issue or pull request of the card.
+func MoveIssueAcrossBoards(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var board *models.ProjectBoard
+	if ctx.ParamsInt64(":boardID") == 0 {
+		board = &models.ProjectBoard{
+			ID:        0,
+			ProjectID: 0,
+			Title:     ctx.Tr("repo.projects.type.uncategorized"),
+		}
+	} else {
+		board = getProjectBoard(ctx, p)
+		if ctx.Written() {
+			return
+		}
+	}
+
+	issue, err := models.GetIssueByID(ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return
+	}
+	if issue.ProjectID() != p.ID {
+		ctx.NotFound("", nil)
+		return
+	}
+	if readable, err := models.FilterReadableIssues(models.IssueList{issue}, ctx.User); err != nil {
+		ctx.ServerError("FilterReadableIssues", err)
+		return
+	} else if len(readable) == 0 {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	if err := models.MoveIssueAcrossProjectBoards(issue, board); err != nil {
+		ctx.ServerError("MoveIssueAcrossProjectBoards", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"ok": true,
+	})
+}
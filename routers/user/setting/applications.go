@@ -7,6 +7,7 @@ package setting
 
 import (
 	"net/http"
+	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
@@ -43,9 +44,26 @@ func ApplicationsPost(ctx *context.Context) {
 		return
 	}
 
+	scope, err := models.NewAccessTokenScope(form.Scopes...)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("settings.token_scope_invalid", err.(models.ErrAccessTokenScopeInvalid).Scope))
+		ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+		return
+	}
+
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: scope,
+	}
+	if err := t.SetRepositories(strings.Split(form.Repositories, ",")); err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("settings.token_repository_not_exist"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		ctx.ServerError("SetRepositories", err)
+		return
 	}
 
 	exist, err := models.AccessTokenByNameExists(t)
@@ -90,6 +108,12 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	scopeDescs := make(map[string]string, len(models.AccessTokenScopes))
+	for _, scope := range models.AccessTokenScopes {
+		scopeDescs[scope] = models.AccessTokenScopeDescKey(scope)
+	}
+	ctx.Data["AccessTokenScopes"] = models.AccessTokenScopes
+	ctx.Data["AccessTokenScopeDescs"] = scopeDescs
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)
@@ -23,7 +23,6 @@ const (
 func Security(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("settings")
 	ctx.Data["PageIsSettingsSecurity"] = true
-	ctx.Data["RequireU2F"] = true
 
 	if ctx.Query("openid.return_to") != "" {
 		settingsOpenIDVerify(ctx)
@@ -65,10 +64,11 @@ func loadSecurityData(ctx *context.Context) {
 		}
 	}
 	ctx.Data["TwofaEnrolled"] = enrolled
+	ctx.Data["EnablePasswordlessLogin"] = setting.Service.EnablePasswordlessLogin
 	if enrolled {
-		ctx.Data["U2FRegistrations"], err = models.GetU2FRegistrationsByUID(ctx.User.ID)
+		ctx.Data["WebAuthnCredentials"], err = models.GetWebAuthnCredentialsByUID(ctx.User.ID)
 		if err != nil {
-			ctx.ServerError("GetU2FRegistrationsByUID", err)
+			ctx.ServerError("GetWebAuthnCredentialsByUID", err)
 			return
 		}
 	}
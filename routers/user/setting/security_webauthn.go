@@ -0,0 +1,153 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	wa "code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnRegister initializes the WebAuthn registration procedure
+func WebAuthnRegister(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.WebAuthnRegistrationForm)
+	if form.Name == "" {
+		ctx.Error(http.StatusConflict)
+		return
+	}
+
+	// Security keys are a second factor and may only be added to accounts that have one.
+	if _, err := models.GetTwoFactorByUID(ctx.User.ID); err != nil {
+		if models.IsErrTwoFactorNotEnrolled(err) {
+			ctx.Error(http.StatusForbidden)
+			return
+		}
+		ctx.ServerError("GetTwoFactorByUID", err)
+		return
+	}
+
+	cred, err := models.GetWebAuthnCredentialByName(ctx.User.ID, form.Name)
+	if err != nil && !models.IsErrWebAuthnCredentialNotExist(err) {
+		ctx.ServerError("GetWebAuthnCredentialByName", err)
+		return
+	}
+	if cred != nil {
+		ctx.Error(http.StatusConflict, "Name already taken")
+		return
+	}
+
+	creds, err := models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(creds))
+	for _, c := range creds.ToCredentials() {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: c.ID,
+		})
+	}
+
+	opts := []webauthn.RegistrationOption{webauthn.WithExclusions(exclusions)}
+	if form.Passwordless && setting.Service.EnablePasswordlessLogin {
+		// Passwordless logins need a key that stores the user handle and verifies the user itself.
+		requireResidentKey := true
+		opts = append(opts, webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			RequireResidentKey: &requireResidentKey,
+			UserVerification:   protocol.VerificationRequired,
+		}))
+	}
+
+	credentialOptions, sessionData, err := wa.WebAuthn.BeginRegistration((*wa.User)(ctx.User), opts...)
+	if err != nil {
+		ctx.ServerError("Unable to BeginRegistration", err)
+		return
+	}
+
+	if err := ctx.Session.Set("webauthnRegistration", sessionData); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnRegistration", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnName", form.Name); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnName", err)
+		return
+	}
+	// Here we're just going to try to release the session early
+	if err := ctx.Session.Release(); err != nil {
+		// we'll tolerate errors here as they *should* get saved elsewhere
+		log.Error("Unable to save changes to the session: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, credentialOptions)
+}
+
+// WebAuthnRegisterPost receives the response of the security key
+func WebAuthnRegisterPost(ctx *context.Context) {
+	name, ok := ctx.Session.Get("webauthnName").(string)
+	sessionData, okData := ctx.Session.Get("webauthnRegistration").(*webauthn.SessionData)
+	if !ok || !okData || name == "" || sessionData == nil {
+		ctx.ServerError("WebAuthnRegisterPost", errors.New("not in WebAuthn session"))
+		return
+	}
+	defer func() {
+		_ = ctx.Session.Delete("webauthnRegistration")
+		_ = ctx.Session.Delete("webauthnName")
+	}()
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBody(ctx.Req.Body)
+	if err != nil {
+		log.Info("Unable to parse WebAuthn attestation of user %s: %v", ctx.User.Name, err)
+		ctx.Error(http.StatusBadRequest)
+		return
+	}
+
+	// Verify that the challenge succeeded
+	cred, err := wa.WebAuthn.CreateCredential((*wa.User)(ctx.User), *sessionData, parsedResponse)
+	if err != nil {
+		log.Info("Unable to create WebAuthn credential for user %s: %v", ctx.User.Name, err)
+		ctx.Error(http.StatusBadRequest)
+		return
+	}
+
+	dbCred, err := models.GetWebAuthnCredentialByName(ctx.User.ID, name)
+	if err != nil && !models.IsErrWebAuthnCredentialNotExist(err) {
+		ctx.ServerError("GetWebAuthnCredentialByName", err)
+		return
+	}
+	if dbCred != nil {
+		ctx.Error(http.StatusConflict, "Name already taken")
+		return
+	}
+
+	// Create the credential
+	if _, err := models.CreateWebAuthnCredential(ctx.User.ID, name, cred); err != nil {
+		ctx.ServerError("CreateWebAuthnCredential", err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// WebAuthnDelete deletes a security key by id
+func WebAuthnDelete(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.WebAuthnDeleteForm)
+	if _, err := models.DeleteWebAuthnCredential(form.ID, ctx.User.ID); err != nil {
+		ctx.ServerError("DeleteWebAuthnCredential", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/security",
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package automerge
+
+import (
+	"fmt"
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/services/mergequeue"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// prAutoMergeQueue represents a queue to handle pull requests scheduled to merge automatically
+var prAutoMergeQueue queue.UniqueQueue
+
+// Init runs the task queue to merge the pull requests which are scheduled to auto merge
+func Init() error {
+	prAutoMergeQueue = queue.CreateUniqueQueue("pr_auto_merge", handle, "").(queue.UniqueQueue)
+	if prAutoMergeQueue == nil {
+		return fmt.Errorf("Unable to create pr_auto_merge Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(prAutoMergeQueue.Run)
+
+	notification.RegisterNotifier(NewNotifier())
+	return nil
+}
+
+// handle passed PR IDs and merge the PRs which are ready
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		id, _ := strconv.ParseInt(datum.(string), 10, 64)
+
+		log.Trace("Checking PR ID %d from the pull requests auto merge queue", id)
+		handlePull(id)
+	}
+}
+
+func addToQueue(pr *models.PullRequest) {
+	if err := prAutoMergeQueue.PushFunc(strconv.FormatInt(pr.ID, 10), func() error {
+		log.Trace("Adding PR ID: %d to the pull requests auto merge queue", pr.ID)
+		return nil
+	}); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Error adding prID %d to the pull requests auto merge queue: %v", pr.ID, err)
+	}
+}
+
+// ScheduleAutoMerge schedules a pull request to be merged by doer once all its checks succeed.
+// If the pull request is already ready to be merged nothing is scheduled and false is returned,
+// so the caller can merge it straight away.
+func ScheduleAutoMerge(doer *models.User, pr *models.PullRequest, style models.MergeStyle, message string) (scheduled bool, err error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return false, err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return false, err
+	}
+	if style == models.MergeStyleManuallyMerged || !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return false, models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr, false); err == nil {
+		return false, nil
+	} else if !models.IsErrNotAllowedToMerge(err) {
+		return false, err
+	}
+
+	if err := models.ScheduleAutoMerge(doer, pr, style, message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CancelScheduledAutoMerge cancels the scheduled auto merge of a pull request
+func CancelScheduledAutoMerge(doer *models.User, pr *models.PullRequest) error {
+	return models.CancelScheduledAutoMerge(doer, pr)
+}
+
+// mergeScheduledPullRequest queues the pull requests into repo whose head is sha and which
+// are scheduled to auto merge, so that they get merged if their checks succeed now.
+func mergeScheduledPullRequest(sha string, repo *models.Repository) {
+	prs, err := models.GetScheduledAutoMergePullRequests(repo.ID)
+	if err != nil {
+		log.Error("GetScheduledAutoMergePullRequests[%d]: %v", repo.ID, err)
+		return
+	}
+	if len(prs) == 0 {
+		return
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", repo.RepoPath(), err)
+		return
+	}
+	defer gitRepo.Close()
+
+	for _, pr := range prs {
+		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil {
+			log.Error("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
+			continue
+		}
+		if headCommitID == sha {
+			addToQueue(pr)
+		}
+	}
+}
+
+func handlePull(pullID int64) {
+	pr, err := models.GetPullRequestByID(pullID)
+	if err != nil {
+		log.Error("GetPullRequestByID[%d]: %v", pullID, err)
+		return
+	}
+
+	exists, scheduledPRM, err := models.GetScheduledAutoMergeByPullID(pr.ID)
+	if err != nil {
+		log.Error("GetScheduledAutoMergeByPullID[%d]: %v", pr.ID, err)
+		return
+	} else if !exists {
+		return
+	}
+
+	if err = pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue[%d]: %v", pr.ID, err)
+		return
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		if err := models.DeleteScheduledAutoMerge(pr.ID); err != nil {
+			log.Error("DeleteScheduledAutoMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+	if !pr.CanAutoMerge() || pr.IsWorkInProgress() {
+		log.Trace("PR[%d] is not mergeable yet", pr.ID)
+		return
+	}
+
+	if err = pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo[%d]: %v", pr.ID, err)
+		return
+	}
+	doer := scheduledPRM.Doer
+
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, doer)
+	if err != nil {
+		log.Error("GetUserRepoPermission[%d]: %v", pr.BaseRepo.ID, err)
+		return
+	}
+	if allowed, err := pull_service.IsUserAllowedToMerge(pr, perm, doer); err != nil {
+		log.Error("IsUserAllowedToMerge[%d]: %v", pr.ID, err)
+		return
+	} else if !allowed {
+		log.Warn("%-v who scheduled PR[%d] to auto merge is no longer allowed to merge it", doer, pr.ID)
+		return
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr, false); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			log.Error("CheckPRReadyToMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+
+	if _, err := pull_service.IsSignedIfRequired(pr, doer); err != nil {
+		if !models.IsErrWontSign(err) {
+			log.Error("IsSignedIfRequired[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+
+	if noDeps, err := models.IssueNoDependenciesLeft(pr.Issue); err != nil {
+		log.Error("IssueNoDependenciesLeft[%d]: %v", pr.ID, err)
+		return
+	} else if !noDeps {
+		return
+	}
+
+	// Branches with a merge queue only accept merges through the queue
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err := mergequeue.Add(doer, pr, scheduledPRM.MergeStyle, scheduledPRM.Message); err != nil && !models.IsErrAlreadyInMergeQueue(err) {
+			log.Error("Add to merge queue[%d]: %v", pr.ID, err)
+			return
+		}
+		if err := models.DeleteScheduledAutoMerge(pr.ID); err != nil {
+			log.Error("DeleteScheduledAutoMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+
+	baseGitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", pr.BaseRepo.RepoPath(), err)
+		return
+	}
+	defer baseGitRepo.Close()
+
+	if err := pull_service.Merge(pr, doer, baseGitRepo, scheduledPRM.MergeStyle, scheduledPRM.Message); err != nil {
+		log.Error("Merge[%d]: %v", pr.ID, err)
+	}
+}
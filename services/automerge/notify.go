@@ -0,0 +1,57 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package automerge
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type autoMergeNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &autoMergeNotifier{}
+)
+
+// NewNotifier create a new autoMergeNotifier notifier
+func NewNotifier() base.Notifier {
+	return &autoMergeNotifier{}
+}
+
+// NotifyPullRequestReview queues the pull request as an approval may be all it was waiting for
+func (n *autoMergeNotifier) NotifyPullRequestReview(pr *models.PullRequest, review *models.Review, comment *models.Comment, mentions []*models.User) {
+	if review.Type == models.ReviewTypeApprove {
+		addToQueue(pr)
+	}
+}
+
+// NotifyCreateCommitStatus queues the pull requests scheduled to auto merge whose head got a new status
+func (n *autoMergeNotifier) NotifyCreateCommitStatus(doer *models.User, repo *models.Repository, sha string, status *models.CommitStatus) {
+	mergeScheduledPullRequest(sha, repo)
+}
+
+// NotifyMergePullRequest drops the scheduled auto merge once the pull request has been merged
+func (n *autoMergeNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
+	if err := models.DeleteScheduledAutoMerge(pr.ID); err != nil {
+		log.Error("DeleteScheduledAutoMerge[%d]: %v", pr.ID, err)
+	}
+}
+
+// NotifyIssueChangeStatus drops the scheduled auto merge when the pull request is closed
+func (n *autoMergeNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !issue.IsPull || !isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		log.Error("LoadPullRequest[%d]: %v", issue.ID, err)
+		return
+	}
+	if err := models.DeleteScheduledAutoMerge(issue.PullRequest.ID); err != nil {
+		log.Error("DeleteScheduledAutoMerge[%d]: %v", issue.PullRequest.ID, err)
+	}
+}
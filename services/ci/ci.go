@@ -0,0 +1,220 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"code.gitea.io/gitea/models"
+	ci_module "code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// event represents a push or a pull request which may trigger workflows
+type event struct {
+	RepoID    int64
+	DoerID    int64
+	Event     string
+	Ref       string
+	Branch    string // the pushed branch or the branch a pull request targets
+	CommitSHA string
+	PullID    int64
+}
+
+// eventQueue represents a queue to handle the events the workflows of their repositories are triggered by
+var eventQueue queue.Queue
+
+// Init runs the task queues to start the workflows triggered by pushes and pull requests
+// and to hand their jobs to the runners
+func Init() error {
+	if !setting.CI.Enabled {
+		return nil
+	}
+
+	eventQueue = queue.CreateQueue("ci_workflow", handle, &event{})
+	if eventQueue == nil {
+		return fmt.Errorf("Unable to create ci_workflow Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(eventQueue.Run)
+
+	jobQueue = queue.CreateUniqueQueue("ci_job", handleJobs, "").(queue.UniqueQueue)
+	if jobQueue == nil {
+		return fmt.Errorf("Unable to create ci_job Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(jobQueue.Run)
+	go graceful.GetManager().RunWithShutdownContext(initializeJobs)
+
+	notification.RegisterNotifier(NewNotifier())
+	return nil
+}
+
+// handle passed events and create the runs of the workflows they trigger
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		e := datum.(*event)
+		log.Trace("Detecting the workflows of repo %d triggered by %s of %s", e.RepoID, e.Event, e.CommitSHA)
+		if err := startWorkflows(e); err != nil {
+			log.Error("startWorkflows[repo: %d, sha: %s]: %v", e.RepoID, e.CommitSHA, err)
+		}
+	}
+}
+
+func addToQueue(e *event) {
+	if err := eventQueue.Push(e); err != nil {
+		log.Error("Unable to push %s event of repo %d to the ci_workflow queue: %v", e.Event, e.RepoID, err)
+	}
+}
+
+// DetectWorkflows parses the workflow files of a commit, invalid workflows are skipped
+func DetectWorkflows(commit *git.Commit) (map[string]*ci_module.Workflow, error) {
+	tree, err := commit.SubTree(ci_module.WorkflowsDir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make(map[string]*ci_module.Workflow)
+	for _, entry := range entries {
+		if !entry.IsRegular() || !ci_module.IsWorkflowFile(entry.Name()) {
+			continue
+		}
+		if entry.Blob().Size() > setting.CI.MaxWorkflowSize {
+			log.Warn("Workflow %s of commit %s is too large", entry.Name(), commit.ID)
+			continue
+		}
+		content, err := readBlob(entry.Blob())
+		if err != nil {
+			return nil, err
+		}
+		workflow, err := ci_module.ParseWorkflow(entry.Name(), content)
+		if err != nil {
+			log.Warn("Skipping workflow of commit %s: %v", commit.ID, err)
+			continue
+		}
+		workflows[entry.Name()] = workflow
+	}
+	return workflows, nil
+}
+
+func readBlob(blob *git.Blob) ([]byte, error) {
+	rc, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// startWorkflows creates the runs of the workflows triggered by an event and reports their jobs as
+// pending commit statuses until a runner has finished them
+func startWorkflows(e *event) error {
+	repo, err := models.GetRepositoryByID(e.RepoID)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	doer, err := models.GetUserByID(e.DoerID)
+	if err != nil {
+		return err
+	}
+
+	// The head of a pull request may not have been pushed to the base repository yet,
+	// so its workflows are read from the head repository
+	repoPath := repo.RepoPath()
+	var pr *models.PullRequest
+	if e.PullID > 0 {
+		if pr, err = models.GetPullRequestByID(e.PullID); err != nil {
+			return err
+		}
+		if err := pr.LoadHeadRepo(); err != nil {
+			return err
+		}
+		if pr.HeadRepo == nil {
+			return nil
+		}
+		repoPath = pr.HeadRepo.RepoPath()
+	}
+
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+	if len(e.CommitSHA) == 0 {
+		if e.CommitSHA, err = gitRepo.GetRefCommitID(git.BranchPrefix + pr.HeadBranch); err != nil {
+			return err
+		}
+	}
+	commit, err := gitRepo.GetCommit(e.CommitSHA)
+	if err != nil {
+		return err
+	}
+
+	workflows, err := DetectWorkflows(commit)
+	if err != nil {
+		return err
+	}
+	for file, workflow := range workflows {
+		if !workflow.IsTriggeredBy(e.Event, e.Branch) {
+			continue
+		}
+
+		run := &models.CIRun{
+			RepoID:        repo.ID,
+			Repo:          repo,
+			WorkflowFile:  file,
+			Name:          workflow.Name,
+			Event:         e.Event,
+			Ref:           e.Ref,
+			CommitSHA:     commit.ID.String(),
+			TriggerUserID: doer.ID,
+			TriggerUser:   doer,
+			PullID:        e.PullID,
+		}
+		jobs := make([]*models.CIJob, 0, len(workflow.Jobs))
+		for _, id := range workflow.JobIDs() {
+			job := workflow.Jobs[id]
+			env := make(map[string]string, len(workflow.Env)+len(job.Env))
+			for k, v := range workflow.Env {
+				env[k] = v
+			}
+			for k, v := range job.Env {
+				env[k] = v
+			}
+			jobs = append(jobs, &models.CIJob{
+				Name:   job.Name,
+				RunsOn: []string(job.RunsOn),
+				Env:    env,
+				Steps:  job.Steps,
+			})
+		}
+		if err := models.CreateCIRun(run, jobs); err != nil {
+			return fmt.Errorf("CreateCIRun: %v", err)
+		}
+		log.Trace("Created run %d of workflow %s of repo %s", run.ID, file, repo.FullName())
+
+		for _, job := range run.Jobs {
+			if err := createJobCommitStatus(job); err != nil {
+				log.Error("createJobCommitStatus[%d]: %v", job.ID, err)
+			}
+			addJobToQueue(job.ID)
+		}
+	}
+	return nil
+}
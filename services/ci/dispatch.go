@@ -0,0 +1,145 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// jobQueue represents a queue of the IDs of the jobs which wait for a runner
+var jobQueue queue.UniqueQueue
+
+// waitingJobs holds the jobs taken from the job queue until a runner with their labels fetches them
+var waitingJobs = newJobDispatcher()
+
+// jobDispatcher hands the waiting jobs to runners by the set of labels the jobs ask for
+type jobDispatcher struct {
+	mutex sync.Mutex
+	// labels holds the labels of each label set
+	labels map[string][]string
+	// jobs holds the IDs of the waiting jobs of each label set, the oldest first
+	jobs map[string][]int64
+	// waiting holds the IDs of all waiting jobs, so a job which is queued again is not handed out twice
+	waiting map[int64]bool
+}
+
+func newJobDispatcher() *jobDispatcher {
+	return &jobDispatcher{
+		labels:  make(map[string][]string),
+		jobs:    make(map[string][]int64),
+		waiting: make(map[int64]bool),
+	}
+}
+
+// labelSetKey returns the key of a set of labels, which ignores their case and order
+func labelSetKey(labels []string) string {
+	keys := make([]string, 0, len(labels))
+	for _, label := range labels {
+		keys = append(keys, strings.ToLower(label))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// add lets a job wait for a runner with its labels
+func (d *jobDispatcher) add(id int64, labels []string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.waiting[id] {
+		return
+	}
+	d.waiting[id] = true
+	key := labelSetKey(labels)
+	if _, ok := d.labels[key]; !ok {
+		d.labels[key] = labels
+	}
+	jobs := d.jobs[key]
+	i := sort.Search(len(jobs), func(i int) bool { return jobs[i] > id })
+	jobs = append(jobs, 0)
+	copy(jobs[i+1:], jobs[i:])
+	jobs[i] = id
+	d.jobs[key] = jobs
+}
+
+// next takes the oldest waiting job the runner can run, it returns false if there is none
+func (d *jobDispatcher) next(runner *models.CIRunner) (int64, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var nextKey string
+	var nextID int64
+	for key, jobs := range d.jobs {
+		if (nextID == 0 || jobs[0] < nextID) && runner.HasLabels(d.labels[key]) {
+			nextKey, nextID = key, jobs[0]
+		}
+	}
+	if nextID == 0 {
+		return 0, false
+	}
+
+	if jobs := d.jobs[nextKey][1:]; len(jobs) > 0 {
+		d.jobs[nextKey] = jobs
+	} else {
+		delete(d.jobs, nextKey)
+		delete(d.labels, nextKey)
+	}
+	delete(d.waiting, nextID)
+	return nextID, true
+}
+
+// handleJobs passes the queued jobs which still wait for a runner to the dispatcher
+func handleJobs(data ...queue.Data) {
+	for _, datum := range data {
+		id, _ := strconv.ParseInt(datum.(string), 10, 64)
+		job, err := models.GetCIJobByID(0, id)
+		if err != nil {
+			if !models.IsErrCIJobNotExist(err) {
+				log.Error("GetCIJobByID[%d]: %v", id, err)
+			}
+			continue
+		}
+		if job.Status != api.CIStatusWaiting {
+			continue
+		}
+		log.Trace("Job %d waits for a runner with the labels %v", job.ID, job.RunsOn)
+		waitingJobs.add(job.ID, job.RunsOn)
+	}
+}
+
+func addJobToQueue(id int64) {
+	if err := jobQueue.PushFunc(strconv.FormatInt(id, 10), func() error {
+		log.Trace("Adding job %d to the ci_job queue", id)
+		return nil
+	}); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Error adding job %d to the ci_job queue: %v", id, err)
+	}
+}
+
+// initializeJobs queues the jobs which have been waiting for a runner before the server started
+func initializeJobs(ctx context.Context) {
+	ids, err := models.GetWaitingCIJobIDs()
+	if err != nil {
+		log.Error("GetWaitingCIJobIDs: %v", err)
+		return
+	}
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			addJobToQueue(id)
+		}
+	}
+}
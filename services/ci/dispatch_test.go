@@ -0,0 +1,44 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobDispatcher(t *testing.T) {
+	d := newJobDispatcher()
+	linux := &models.CIRunner{Labels: []string{"linux", "docker"}}
+	windows := &models.CIRunner{Labels: []string{"Windows"}}
+
+	d.add(4, []string{"Docker", "Linux"})
+	d.add(2, []string{"linux", "docker"})
+	d.add(3, nil)
+	d.add(1, []string{"windows"})
+	// a job queued again is only handed out once
+	d.add(2, []string{"linux", "docker"})
+	assert.Len(t, d.jobs, 3)
+
+	for _, expected := range []int64{2, 3, 4} {
+		id, ok := d.next(linux)
+		assert.True(t, ok)
+		assert.EqualValues(t, expected, id)
+	}
+	_, ok := d.next(linux)
+	assert.False(t, ok)
+
+	id, ok := d.next(windows)
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, id)
+	_, ok = d.next(windows)
+	assert.False(t, ok)
+	assert.Empty(t, d.jobs)
+	assert.Empty(t, d.labels)
+	assert.Empty(t, d.waiting)
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// FetchJob hands the oldest waiting job the runner can run to it, it returns nil if there is none
+func FetchJob(runner *models.CIRunner) (*models.CIJob, error) {
+	if err := runner.UpdateLastOnline(); err != nil {
+		return nil, err
+	}
+
+	var job *models.CIJob
+	for job == nil {
+		id, ok := waitingJobs.next(runner)
+		if !ok {
+			return nil, nil
+		}
+		var err error
+		// the job is skipped if it has been cancelled or handed to a runner by another instance meanwhile
+		if job, err = models.AssignCIJob(runner, id); err != nil {
+			addJobToQueue(id)
+			return nil, err
+		}
+	}
+	if err := job.LoadRun(); err != nil {
+		return nil, err
+	}
+	log.Trace("Runner %s picked job %d of repo %s", runner.Name, job.ID, job.Run.Repo.FullName())
+
+	if err := createJobCommitStatus(job); err != nil {
+		log.Error("createJobCommitStatus[%d]: %v", job.ID, err)
+	}
+	return job, nil
+}
+
+// DeleteRunner unregisters a runner, the jobs it is running are queued for another runner
+func DeleteRunner(id int64) error {
+	jobIDs, err := models.DeleteCIRunner(id)
+	if err != nil {
+		return err
+	}
+	for _, jobID := range jobIDs {
+		addJobToQueue(jobID)
+	}
+	return nil
+}
+
+// AppendJobLog adds a chunk of output to the log of a running job
+func AppendJobLog(job *models.CIJob, content []byte) error {
+	if len(content) == 0 {
+		return nil
+	}
+	index, err := models.AllocateCIJobLogChunk(job)
+	if err != nil {
+		return fmt.Errorf("AllocateCIJobLogChunk: %v", err)
+	}
+	if _, err := storage.CILogs.Save(job.LogChunkPath(index), bytes.NewReader(content), int64(len(content))); err != nil {
+		return fmt.Errorf("Save: %v", err)
+	}
+	return nil
+}
+
+// ReadJobLog returns the log of a job as far as it has been reported
+func ReadJobLog(job *models.CIJob) ([]byte, error) {
+	var buf bytes.Buffer
+	for i := 0; i < job.LogChunks; i++ {
+		if err := readLogChunk(&buf, job.LogChunkPath(i)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func readLogChunk(w io.Writer, path string) error {
+	obj, err := storage.CILogs.Open(path)
+	if err != nil {
+		// The chunk has been allocated but is still being uploaded
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Open[%s]: %v", path, err)
+	}
+	defer obj.Close()
+	content, err := ioutil.ReadAll(obj)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// FinishJob records the result of a job a runner has finished and reports it as commit status
+func FinishJob(job *models.CIJob, status api.CIStatus) error {
+	if err := models.FinishCIJob(job, status); err != nil {
+		return err
+	}
+	if err := job.LoadRun(); err != nil {
+		return err
+	}
+	return createJobCommitStatus(job)
+}
+
+// createJobCommitStatus reports the status of a job as commit status of the commit of its run
+func createJobCommitStatus(job *models.CIJob) error {
+	if err := job.LoadRun(); err != nil {
+		return err
+	}
+
+	var description string
+	switch job.Status {
+	case api.CIStatusWaiting:
+		description = "Waiting for a runner"
+	case api.CIStatusRunning:
+		description = "Running"
+	case api.CIStatusSuccess:
+		description = "Successful"
+	case api.CIStatusFailure:
+		description = "Failed"
+	case api.CIStatusCancelled:
+		description = "Cancelled"
+	}
+
+	return repofiles.CreateCommitStatus(job.Run.Repo, job.Run.TriggerUser, job.Run.CommitSHA, &models.CommitStatus{
+		State:       job.Status.CommitStatusState(),
+		TargetURL:   job.HTMLURL(),
+		Description: description,
+		Context:     job.CommitStatusContext(),
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"code.gitea.io/gitea/models"
+	ci_module "code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+)
+
+type ciNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &ciNotifier{}
+)
+
+// NewNotifier create a new ciNotifier notifier
+func NewNotifier() base.Notifier {
+	return &ciNotifier{}
+}
+
+// NotifyPushCommits starts the workflows triggered by the push to a branch
+func (n *ciNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	if !opts.IsBranch() || opts.IsDelRef() {
+		return
+	}
+	addToQueue(&event{
+		RepoID:    repo.ID,
+		DoerID:    pusher.ID,
+		Event:     ci_module.EventPush,
+		Ref:       opts.RefFullName,
+		Branch:    opts.BranchName(),
+		CommitSHA: opts.NewCommitID,
+	})
+}
+
+// NotifyNewPullRequest starts the workflows triggered by the new pull request
+func (n *ciNotifier) NotifyNewPullRequest(pr *models.PullRequest, mentions []*models.User) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue[%d]: %v", pr.ID, err)
+		return
+	}
+	addPullRequestToQueue(pr.Issue.PosterID, pr)
+}
+
+// NotifyPullRequestSynchronized starts the workflows triggered by the pull request again for its new commits
+func (n *ciNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	addPullRequestToQueue(doer.ID, pr)
+}
+
+// addPullRequestToQueue queues the pull request event, its commit is looked up in the head repository
+// when the event is handled
+func addPullRequestToQueue(doerID int64, pr *models.PullRequest) {
+	addToQueue(&event{
+		RepoID: pr.BaseRepoID,
+		DoerID: doerID,
+		Event:  ci_module.EventPullRequest,
+		Ref:    pr.GetGitRefName(),
+		Branch: pr.BaseBranch,
+		PullID: pr.ID,
+	})
+}
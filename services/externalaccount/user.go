@@ -15,7 +15,7 @@ import (
 
 // LinkAccountToUser link the gothUser to the user
 func LinkAccountToUser(user *models.User, gothUser goth.User) error {
-	loginSource, err := models.GetActiveOAuth2LoginSourceByName(gothUser.Provider)
+	loginSource, err := models.GetActiveExternalLoginSourceByName(gothUser.Provider)
 	if err != nil {
 		return err
 	}
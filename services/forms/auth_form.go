@@ -16,7 +16,7 @@ import (
 // AuthenticationForm form for authentication
 type AuthenticationForm struct {
 	ID                            int64
-	Type                          int    `binding:"Range(2,7)"`
+	Type                          int    `binding:"Range(2,8)"`
 	Name                          string `binding:"Required;MaxSize(30)"`
 	Host                          string
 	Port                          int
@@ -39,6 +39,8 @@ type AuthenticationForm struct {
 	GroupFilter                   string
 	GroupMemberUID                string
 	UserUID                       string
+	GroupTeamMap                  string
+	GroupTeamMapRemoval           bool
 	RestrictedFilter              string
 	AllowDeactivateAll            bool
 	IsActive                      bool
@@ -61,11 +63,30 @@ type AuthenticationForm struct {
 	Oauth2ProfileURL              string
 	Oauth2EmailURL                string
 	Oauth2IconURL                 string
+	Oauth2GroupClaimName          string
+	Oauth2RequiredGroup           string
+	Oauth2AdminGroup              string
+	Oauth2RestrictedGroup         string
+	Oauth2GroupTeamMap            string
+	Oauth2GroupTeamMapRemoval     bool
 	SSPIAutoCreateUsers           bool
 	SSPIAutoActivateUsers         bool
 	SSPIStripDomainNames          bool
 	SSPISeparatorReplacement      string `binding:"AlphaDashDot;MaxSize(5)"`
 	SSPIDefaultLanguage           string
+	SAMLMetadataURL               string
+	SAMLMetadata                  string
+	SAMLCertificate               string
+	SAMLPrivateKey                string
+	SAMLSignRequests              bool
+	SAMLNameIDFormat              string
+	SAMLAttributeUsername         string
+	SAMLAttributeEmail            string
+	SAMLAttributeFullName         string
+	SAMLAttributeGroups           string
+	SAMLRequiredGroup             string
+	SAMLAdminGroup                string
+	SAMLAutoRegistration          bool
 }
 
 // Validate validates fields
@@ -77,6 +77,7 @@ type DiffLine struct {
 	Type        DiffLineType
 	Content     string
 	Comments    []*models.Comment
+	Annotations []*models.CheckRunAnnotation
 	SectionInfo *DiffLineSectionInfo
 }
 
@@ -676,6 +677,43 @@ func (diff *Diff) LoadComments(issue *models.Issue, currentUser *models.User) er
 	return nil
 }
 
+// LoadCheckRunAnnotations attaches the annotations of the latest check runs of a commit to the last line
+// of their lines which is shown in the diff
+func (diff *Diff) LoadCheckRunAnnotations(repoID int64, sha string) error {
+	annotations, err := models.GetLatestCheckRunAnnotations(repoID, sha)
+	if err != nil || len(annotations) == 0 {
+		return err
+	}
+
+	annotationsByPath := make(map[string][]*models.CheckRunAnnotation)
+	for _, annotation := range annotations {
+		annotationsByPath[annotation.Path] = append(annotationsByPath[annotation.Path], annotation)
+	}
+	for _, file := range diff.Files {
+		fileAnnotations, ok := annotationsByPath[file.Name]
+		if !ok {
+			continue
+		}
+		for _, annotation := range fileAnnotations {
+			var target *DiffLine
+			for _, section := range file.Sections {
+				for _, line := range section.Lines {
+					if line.Type == DiffLineDel || line.Type == DiffLineSection {
+						continue
+					}
+					if int64(line.RightIdx) >= annotation.StartLine && int64(line.RightIdx) <= annotation.EndLine {
+						target = line
+					}
+				}
+			}
+			if target != nil {
+				target.Annotations = append(target.Annotations, annotation)
+			}
+		}
+	}
+	return nil
+}
+
 const cmdDiffHead = "diff --git "
 
 // ParsePatch builds a Diff object from a io.Reader and some parameters.
@@ -1273,6 +1311,27 @@ func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID
 	return diff, nil
 }
 
+// GetChangedFiles returns the paths of the files changed between the merge base of
+// beforeCommitID and afterCommitID, and afterCommitID. Renamed files are reported with both names.
+func GetChangedFiles(repoPath, beforeCommitID, afterCommitID string) ([]string, error) {
+	stdout, err := git.NewCommand("diff", "-z", "--name-only", "--no-renames", beforeCommitID+"..."+afterCommitID).RunInDirBytes(repoPath)
+	if err != nil && strings.Contains(err.Error(), "no merge base") {
+		// git >= 2.28 now returns an error if base and head have become unrelated.
+		stdout, err = git.NewCommand("diff", "-z", "--name-only", "--no-renames", beforeCommitID, afterCommitID).RunInDirBytes(repoPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, bytes.Count(stdout, []byte{'\x00'}))
+	for _, file := range bytes.Split(stdout, []byte{'\x00'}) {
+		if len(file) > 0 {
+			files = append(files, string(file))
+		}
+	}
+	return files, nil
+}
+
 // GetDiffCommit builds a Diff representing the given commitID.
 func GetDiffCommit(repoPath, commitID string, maxLines, maxLineCharacters, maxFiles int) (*Diff, error) {
 	return GetDiffRangeWithWhitespaceBehavior(repoPath, "", commitID, maxLines, maxLineCharacters, maxFiles, "")
@@ -0,0 +1,128 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+var errNoTextContent = errors.New("Message has no text/plain content")
+
+// getTextContent returns the first text/plain part of the message, decoded to UTF-8
+func getTextContent(header mail.Header, body io.Reader) (string, error) {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", errNoTextContent
+			} else if err != nil {
+				return "", err
+			}
+			// multipart.Reader already removes the quoted-printable encoding
+			content, err := getTextContent(mail.Header(part.Header), part)
+			if err == errNoTextContent {
+				continue
+			}
+			return content, err
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", errNoTextContent
+	}
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" {
+		return "", errNoTextContent
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if charset := params["charset"]; charset != "" && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "us-ascii") {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return "", err
+		}
+		body = enc.NewDecoder().Reader(body)
+	}
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// newlineStripper removes line breaks, which the base64 decoder does not skip in every case
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	c, err := n.r.Read(p)
+	j := 0
+	for _, b := range p[:c] {
+		if b != '\r' && b != '\n' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
+
+var (
+	quoteHeaderPattern    = regexp.MustCompile(`(?i)^on\b.*\bwrote:$`)
+	originalMessageMarker = regexp.MustCompile(`(?i)^-+\s*original message\s*-+$`)
+)
+
+// stripQuotedReply removes the quoted text mail clients add to replies and the signature of the sender
+func stripQuotedReply(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || originalMessageMarker.MatchString(trimmed) {
+			break
+		}
+		// The quote header may be wrapped into two lines
+		if quoteHeaderPattern.MatchString(trimmed) {
+			break
+		}
+		if i+1 < len(lines) && quoteHeaderPattern.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
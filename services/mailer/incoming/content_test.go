@@ -0,0 +1,81 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTextContent(t *testing.T) {
+	cases := []struct {
+		message  string
+		expected string
+	}{
+		{
+			"Subject: plain\r\n\r\nplain content",
+			"plain content",
+		},
+		{
+			"Content-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nGr=FC=DFe",
+			"Grüße",
+		},
+		{
+			"Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\nYmFzZTY0\r\nIGNvbnRlbnQ=",
+			"base64 content",
+		},
+		{
+			"Content-Type: multipart/alternative; boundary=b\r\n\r\n" +
+				"--b\r\nContent-Type: text/html\r\n\r\n<p>html</p>\r\n" +
+				"--b\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nmultipart=20content\r\n" +
+				"--b--\r\n",
+			"multipart content",
+		},
+		{
+			"Content-Type: multipart/mixed; boundary=outer\r\n\r\n" +
+				"--outer\r\nContent-Type: multipart/alternative; boundary=inner\r\n\r\n" +
+				"--inner\r\nContent-Type: text/plain\r\n\r\nnested content\r\n" +
+				"--inner--\r\n" +
+				"--outer\r\nContent-Type: text/plain\r\nContent-Disposition: attachment; filename=a.txt\r\n\r\nattachment\r\n" +
+				"--outer--\r\n",
+			"nested content",
+		},
+	}
+
+	for _, c := range cases {
+		msg, err := mail.ReadMessage(strings.NewReader(c.message))
+		assert.NoError(t, err)
+		content, err := getTextContent(msg.Header, msg.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, content)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader("Content-Type: text/html\r\n\r\n<p>html</p>"))
+	assert.NoError(t, err)
+	_, err = getTextContent(msg.Header, msg.Body)
+	assert.Equal(t, errNoTextContent, err)
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected string
+	}{
+		{"Just a reply", "Just a reply"},
+		{"Reply\r\n\r\nOn Mon, Jun 7, 2021 at 10:00 AM User <user@example.com> wrote:\r\n> quoted\r\n> text", "Reply"},
+		{"Reply\n\nOn Mon, Jun 7, 2021 at 10:00 AM User\n<user@example.com> wrote:\n> quoted", "Reply"},
+		{"First\n> inline quote\nSecond", "First\nSecond"},
+		{"Reply\n\n-- \nSignature", "Reply"},
+		{"Reply\n\n-----Original Message-----\nFrom: someone", "Reply"},
+		{"> only quoted", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, stripQuotedReply(c.content), c.content)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	comment_service "code.gitea.io/gitea/services/comments"
+	"code.gitea.io/gitea/services/mailer/token"
+)
+
+// Handler processes the content of a mail sent to the reply address of a token
+type Handler interface {
+	Handle(content string, doer *models.User, payload []byte) error
+}
+
+var handlers = map[token.HandlerType]Handler{
+	token.ReplyHandlerType: &ReplyHandler{},
+}
+
+// ReplyHandler adds the content of the mail as comment to the issue or pull request of the token
+type ReplyHandler struct{}
+
+// Handle implements Handler
+func (h *ReplyHandler) Handle(content string, doer *models.User, payload []byte) error {
+	issueID, err := token.IssueIDFromReplyPayload(payload)
+	if err != nil {
+		return rejectedError{err.Error()}
+	}
+
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			return rejectedError{"issue does not exist"}
+		}
+		return err
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return err
+	}
+	if issue.Repo.IsArchived {
+		return rejectedError{"repository is archived"}
+	}
+
+	perm, err := models.GetUserRepoPermission(issue.Repo, doer)
+	if err != nil {
+		return err
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return rejectedError{fmt.Sprintf("user %s can not read the issue", doer.Name)}
+	}
+	if issue.IsLocked && !perm.CanWriteIssuesOrPulls(issue.IsPull) && !doer.IsAdmin {
+		return rejectedError{"issue is locked"}
+	}
+
+	comment, err := comment_service.CreateIssueComment(doer, issue.Repo, issue, content, nil)
+	if err != nil {
+		return err
+	}
+	log.Trace("Incoming email: user %s commented on issue %d with comment %d", doer.Name, issue.ID, comment.ID)
+	return nil
+}
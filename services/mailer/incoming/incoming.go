@@ -0,0 +1,199 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
+)
+
+// rejectedError indicates a message which can never be processed. Such messages are not retried.
+type rejectedError struct {
+	reason string
+}
+
+func (e rejectedError) Error() string {
+	return e.reason
+}
+
+// Init starts polling the maildir for replies if incoming email is enabled
+func Init() error {
+	if !setting.IncomingEmail.Enabled {
+		return nil
+	}
+
+	for _, dir := range []string{"new", "cur"} {
+		if fi, err := os.Stat(filepath.Join(setting.IncomingEmail.MaildirPath, dir)); err != nil {
+			return fmt.Errorf("maildir %s is not accessible: %v", setting.IncomingEmail.MaildirPath, err)
+		} else if !fi.IsDir() {
+			return fmt.Errorf("maildir %s is invalid: %s is not a directory", setting.IncomingEmail.MaildirPath, dir)
+		}
+	}
+
+	go graceful.GetManager().RunWithShutdownContext(poll)
+	return nil
+}
+
+func poll(ctx context.Context) {
+	ticker := time.NewTicker(setting.IncomingEmail.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := ProcessMaildir(ctx); err != nil {
+			log.Error("Incoming email: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessMaildir processes the new messages of the maildir. Messages which can not be processed because of
+// internal errors are left in place to be retried.
+func ProcessMaildir(ctx context.Context) error {
+	newDir := filepath.Join(setting.IncomingEmail.MaildirPath, "new")
+	entries, err := ioutil.ReadDir(newDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		err := processMessageFile(filepath.Join(newDir, entry.Name()), entry.Size())
+		if err != nil {
+			if _, ok := err.(rejectedError); !ok {
+				log.Error("Incoming email: unable to process message %s: %v", entry.Name(), err)
+				continue
+			}
+			log.Warn("Incoming email: rejected message %s: %v", entry.Name(), err)
+		}
+
+		if err := finishMessage(entry.Name()); err != nil {
+			log.Error("Incoming email: unable to remove message %s from the new messages: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// finishMessage deletes the message or moves it to the seen messages of the maildir
+func finishMessage(name string) error {
+	p := filepath.Join(setting.IncomingEmail.MaildirPath, "new", name)
+	if setting.IncomingEmail.DeleteHandledMessage {
+		return os.Remove(p)
+	}
+	return os.Rename(p, filepath.Join(setting.IncomingEmail.MaildirPath, "cur", name+":2,S"))
+}
+
+func processMessageFile(p string, size int64) error {
+	if setting.IncomingEmail.MaximumMessageSize > 0 && size > setting.IncomingEmail.MaximumMessageSize {
+		return rejectedError{fmt.Sprintf("message is larger than %d bytes", setting.IncomingEmail.MaximumMessageSize)}
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		return rejectedError{fmt.Sprintf("message can not be parsed: %v", err)}
+	}
+	return processMessage(msg)
+}
+
+// recipientHeaders are the headers which may contain the reply address
+var recipientHeaders = []string{"To", "Cc", "Delivered-To", "X-Original-To", "Envelope-To"}
+
+// findToken returns the token of the first reply address among the recipients of the message
+func findToken(msg *mail.Message) string {
+	for _, header := range recipientHeaders {
+		for _, value := range msg.Header[header] {
+			addresses, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+			for _, address := range addresses {
+				if t := token.TokenFromAddress(address.Address); t != "" {
+					return t
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isAutomatic reports whether the message is an automatic reply like an out of office notice
+func isAutomatic(msg *mail.Message) bool {
+	if autoSubmitted := strings.ToLower(msg.Header.Get("Auto-Submitted")); autoSubmitted != "" && autoSubmitted != "no" {
+		return true
+	}
+	if msg.Header.Get("X-Autoreply") != "" || msg.Header.Get("X-Autorespond") != "" {
+		return true
+	}
+	switch strings.ToLower(msg.Header.Get("Precedence")) {
+	case "bulk", "auto_reply", "junk", "list":
+		return true
+	}
+	return false
+}
+
+func processMessage(msg *mail.Message) error {
+	if isAutomatic(msg) {
+		return rejectedError{"message is an automatic reply"}
+	}
+
+	t := findToken(msg)
+	if t == "" {
+		return rejectedError{"message is not addressed to a reply address"}
+	}
+	ht, user, payload, err := token.ExtractToken(t)
+	if err != nil {
+		if err == token.ErrTokenInvalid || err == token.ErrTokenExpired {
+			return rejectedError{err.Error()}
+		}
+		return err
+	}
+	if !user.IsActive || user.ProhibitLogin {
+		return rejectedError{fmt.Sprintf("user %s is not allowed to sign in", user.Name)}
+	}
+
+	handler, ok := handlers[ht]
+	if !ok {
+		return rejectedError{fmt.Sprintf("unsupported token handler type %d", ht)}
+	}
+
+	content, err := getTextContent(msg.Header, msg.Body)
+	if err != nil {
+		return rejectedError{fmt.Sprintf("message content can not be read: %v", err)}
+	}
+	content = stripQuotedReply(content)
+	if content == "" {
+		return rejectedError{"message has no content"}
+	}
+
+	return handler.Handle(content, user, payload)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessMaildir(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	maildir, err := ioutil.TempDir("", "maildir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(maildir)
+	for _, dir := range []string{"new", "cur", "tmp"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(maildir, dir), 0o700))
+	}
+
+	defer func(old string, oldExpiry time.Duration, oldDelete bool) {
+		setting.IncomingEmail.ReplyToAddress = old
+		setting.IncomingEmail.MaildirPath = ""
+		setting.IncomingEmail.ReplyTokenExpiry = oldExpiry
+		setting.IncomingEmail.DeleteHandledMessage = oldDelete
+	}(setting.IncomingEmail.ReplyToAddress, setting.IncomingEmail.ReplyTokenExpiry, setting.IncomingEmail.DeleteHandledMessage)
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@example.com"
+	setting.IncomingEmail.MaildirPath = maildir
+	setting.IncomingEmail.ReplyTokenExpiry = time.Hour
+	setting.IncomingEmail.DeleteHandledMessage = false
+
+	createReplyAddress := func(userID, issueID int64) string {
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: userID}).(*models.User)
+		replyToken, err := token.CreateToken(token.ReplyHandlerType, user, token.ReplyPayload(issueID))
+		assert.NoError(t, err)
+		return token.ReplyAddress(replyToken)
+	}
+
+	deliver := func(name, to, extraHeaders, body string) {
+		content := fmt.Sprintf("From: someone@example.com\r\nTo: %s\r\nSubject: Re: issue\r\n%s\r\n%s", to, extraHeaders, body)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(maildir, "new", name), []byte(content), 0o600))
+	}
+
+	commentCount := func(issueID int64) int {
+		return models.GetCount(t, &models.Comment{IssueID: issueID, Type: models.CommentTypeComment})
+	}
+
+	beforeIssue1, beforeIssue4 := commentCount(1), commentCount(4)
+
+	deliver("valid", createReplyAddress(2, 1), "", "A reply by mail\r\n\r\nOn Mon, Jun 7, 2021 User wrote:\r\n> quoted")
+	deliver("unsigned", "incoming+aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa@example.com", "", "forged")
+	deliver("other-address", "someone@example.org", "", "unrelated")
+	deliver("no-permission", createReplyAddress(4, 4), "", "private repository")
+	deliver("auto-reply", createReplyAddress(2, 1), "Auto-Submitted: auto-replied\r\n", "Out of office")
+
+	setting.IncomingEmail.ReplyTokenExpiry = -time.Hour
+	deliver("expired", createReplyAddress(2, 1), "", "too late")
+
+	assert.NoError(t, ProcessMaildir(context.Background()))
+
+	assert.Equal(t, beforeIssue1+1, commentCount(1))
+	assert.Equal(t, beforeIssue4, commentCount(4))
+	comment := models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: 1, PosterID: 2, Content: "A reply by mail"}).(*models.Comment)
+	assert.Equal(t, models.CommentTypeComment, comment.Type)
+
+	// All messages are handled and moved to the seen messages
+	entries, err := ioutil.ReadDir(filepath.Join(maildir, "new"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+	entries, err = ioutil.ReadDir(filepath.Join(maildir, "cur"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6)
+	assert.FileExists(t, filepath.Join(maildir, "cur", "valid:2,S"))
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// A token is the payload of a reply address. It is encoded with lower case base32 because the local part of
+// email addresses may be treated case-insensitively, and consists of
+//
+//  version | handler type | user id (uvarint) | expiry unix time (uvarint) | handler data | mac
+//
+// The mac is a truncated HMAC-SHA256 over everything before it, keyed by the secret key of the instance and
+// the random salt of the user, so tokens are invalidated when the salt of the user changes.
+
+// HandlerType specifies which handler processes the mails sent to the reply address of a token
+type HandlerType byte
+
+// The handler types
+const (
+	ReplyHandlerType HandlerType = 1
+)
+
+const (
+	tokenVersion = 1
+	macLength    = 16
+)
+
+var (
+	// ErrTokenInvalid indicates a token which is malformed or whose signature does not match
+	ErrTokenInvalid = errors.New("Token is invalid")
+	// ErrTokenExpired indicates a token whose expiry has passed
+	ErrTokenExpired = errors.New("Token is expired")
+
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+func computeMAC(user *models.User, data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(setting.SecretKey+user.Rands))
+	_, _ = mac.Write(data)
+	return mac.Sum(nil)[:macLength]
+}
+
+// CreateToken creates a token for the user which expires after the configured reply token expiry
+func CreateToken(ht HandlerType, user *models.User, data []byte) (string, error) {
+	if user.Rands == "" {
+		var err error
+		if user.Rands, err = models.GetUserSalt(); err != nil {
+			return "", err
+		}
+		if err := models.UpdateUserCols(user, "rands"); err != nil {
+			return "", err
+		}
+	}
+
+	payload := make([]byte, 2, 2+2*binary.MaxVarintLen64+len(data)+macLength)
+	payload[0] = tokenVersion
+	payload[1] = byte(ht)
+	var buf [binary.MaxVarintLen64]byte
+	payload = append(payload, buf[:binary.PutUvarint(buf[:], uint64(user.ID))]...)
+	payload = append(payload, buf[:binary.PutUvarint(buf[:], uint64(time.Now().Add(setting.IncomingEmail.ReplyTokenExpiry).Unix()))]...)
+	payload = append(payload, data...)
+	payload = append(payload, computeMAC(user, payload)...)
+
+	return strings.ToLower(encoding.EncodeToString(payload)), nil
+}
+
+// ExtractToken validates the token and returns its handler type, user and handler data
+func ExtractToken(token string) (HandlerType, *models.User, []byte, error) {
+	payload, err := encoding.DecodeString(strings.ToUpper(token))
+	if err != nil || len(payload) < 2+2+macLength || payload[0] != tokenVersion {
+		return 0, nil, nil, ErrTokenInvalid
+	}
+
+	signed, mac := payload[:len(payload)-macLength], payload[len(payload)-macLength:]
+
+	ht := HandlerType(signed[1])
+	rest := signed[2:]
+	uid, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return 0, nil, nil, ErrTokenInvalid
+	}
+	rest = rest[n:]
+	expiry, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return 0, nil, nil, ErrTokenInvalid
+	}
+	data := rest[n:]
+
+	user, err := models.GetUserByID(int64(uid))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return 0, nil, nil, ErrTokenInvalid
+		}
+		return 0, nil, nil, err
+	}
+	if !hmac.Equal(mac, computeMAC(user, signed)) {
+		return 0, nil, nil, ErrTokenInvalid
+	}
+	if time.Now().Unix() > int64(expiry) {
+		return 0, nil, nil, ErrTokenExpired
+	}
+
+	return ht, user, data, nil
+}
+
+// ReplyAddress returns the address mails for the token are sent to
+func ReplyAddress(token string) string {
+	return strings.Replace(setting.IncomingEmail.ReplyToAddress, setting.IncomingEmailTokenPlaceholder, token, 1)
+}
+
+// TokenFromAddress extracts the token from a reply address, returning an empty string if the address is none
+func TokenFromAddress(address string) string {
+	parts := strings.SplitN(strings.ToLower(setting.IncomingEmail.ReplyToAddress), setting.IncomingEmailTokenPlaceholder, 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	address = strings.ToLower(address)
+	if len(address) <= len(parts[0])+len(parts[1]) || !strings.HasPrefix(address, parts[0]) || !strings.HasSuffix(address, parts[1]) {
+		return ""
+	}
+	return address[len(parts[0]) : len(address)-len(parts[1])]
+}
+
+// ReplyPayload returns the handler data of a reply token for the issue
+func ReplyPayload(issueID int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return buf[:binary.PutUvarint(buf[:], uint64(issueID))]
+}
+
+// IssueIDFromReplyPayload returns the issue id of the handler data of a reply token
+func IssueIDFromReplyPayload(data []byte) (int64, error) {
+	issueID, n := binary.Uvarint(data)
+	if n <= 0 || n != len(data) {
+		return 0, ErrTokenInvalid
+	}
+	return int64(issueID), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	defer func(expiry time.Duration) { setting.IncomingEmail.ReplyTokenExpiry = expiry }(setting.IncomingEmail.ReplyTokenExpiry)
+	setting.IncomingEmail.ReplyTokenExpiry = time.Hour
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	token, err := CreateToken(ReplyHandlerType, user, ReplyPayload(1234))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ToLower(token), token)
+
+	ht, u, data, err := ExtractToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, ReplyHandlerType, ht)
+	assert.Equal(t, user.ID, u.ID)
+	issueID, err := IssueIDFromReplyPayload(data)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1234, issueID)
+
+	// Addresses may be upper cased by mail servers
+	_, _, _, err = ExtractToken(strings.ToUpper(token))
+	assert.NoError(t, err)
+
+	// A modified token is rejected
+	tampered := []byte(token)
+	if tampered[5] == 'a' {
+		tampered[5] = 'b'
+	} else {
+		tampered[5] = 'a'
+	}
+	_, _, _, err = ExtractToken(string(tampered))
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	_, _, _, err = ExtractToken("invalid")
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	// Changing the salt of the user invalidates the token
+	user.Rands = "changed"
+	assert.NoError(t, models.UpdateUserCols(user, "rands"))
+	_, _, _, err = ExtractToken(token)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	setting.IncomingEmail.ReplyTokenExpiry = -time.Hour
+	token, err = CreateToken(ReplyHandlerType, user, ReplyPayload(1234))
+	assert.NoError(t, err)
+	_, _, _, err = ExtractToken(token)
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestTokenFromAddress(t *testing.T) {
+	defer func(address string) { setting.IncomingEmail.ReplyToAddress = address }(setting.IncomingEmail.ReplyToAddress)
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@example.com"
+
+	assert.Equal(t, "incoming+abc@example.com", ReplyAddress("abc"))
+	assert.Equal(t, "abc", TokenFromAddress("incoming+abc@example.com"))
+	assert.Equal(t, "abc", TokenFromAddress("Incoming+ABC@Example.com"))
+	assert.Empty(t, TokenFromAddress("incoming+@example.com"))
+	assert.Empty(t, TokenFromAddress("other+abc@example.com"))
+	assert.Empty(t, TokenFromAddress("incoming+abc@example.org"))
+}
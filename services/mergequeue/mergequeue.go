@@ -0,0 +1,297 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mergequeue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/sync"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// branchQueue represents a queue to handle the base branches whose merge queue has to be advanced
+var branchQueue queue.UniqueQueue
+
+// branchWorkingPool makes sure the merge queue of a branch is only handled once at a time
+var branchWorkingPool = sync.NewExclusivePool()
+
+// Init runs the task queue to test and merge the pull requests in the merge queues of protected branches
+func Init() error {
+	branchQueue = queue.CreateUniqueQueue("pr_merge_queue", handle, "").(queue.UniqueQueue)
+	if branchQueue == nil {
+		return fmt.Errorf("Unable to create pr_merge_queue Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(branchQueue.Run)
+
+	notification.RegisterNotifier(NewNotifier())
+	return nil
+}
+
+// handle passed "repoID:branch" keys and advance the merge queues of the branches
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		parts := strings.SplitN(datum.(string), ":", 2)
+		if len(parts) != 2 {
+			log.Error("Invalid merge queue key: %s", datum)
+			continue
+		}
+		repoID, _ := strconv.ParseInt(parts[0], 10, 64)
+
+		log.Trace("Checking merge queue of branch %s in repo %d", parts[1], repoID)
+		handleBranch(repoID, parts[1])
+	}
+}
+
+func addToQueue(repoID int64, branch string) {
+	key := strconv.FormatInt(repoID, 10) + ":" + branch
+	if err := branchQueue.PushFunc(key, func() error {
+		log.Trace("Adding branch %s of repo %d to the merge queue task queue", branch, repoID)
+		return nil
+	}); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Error adding branch %s of repo %d to the merge queue task queue: %v", branch, repoID, err)
+	}
+}
+
+// Add appends a pull request to the merge queue of its base branch. It is merged by doer once the
+// speculative merge onto the pull requests ahead of it passes the required status checks.
+func Add(doer *models.User, pr *models.PullRequest, style models.MergeStyle, message string) error {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return err
+	}
+	if style == models.MergeStyleManuallyMerged || !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := models.AddToMergeQueue(doer, pr, style, message); err != nil {
+		return err
+	}
+	addToQueue(pr.BaseRepoID, pr.BaseBranch)
+	return nil
+}
+
+// Remove takes a pull request out of the merge queue of its base branch
+func Remove(doer *models.User, pr *models.PullRequest) error {
+	if err := models.RemoveFromMergeQueue(doer, pr, ""); err != nil {
+		return err
+	}
+	addToQueue(pr.BaseRepoID, pr.BaseBranch)
+	return nil
+}
+
+// handleCommitStatus queues the branches of the merge queues which wait for the statuses of sha
+func handleCommitStatus(sha string, repo *models.Repository) {
+	entries, err := models.GetMergeQueueEntriesByCommitID(repo.ID, sha)
+	if err != nil {
+		log.Error("GetMergeQueueEntriesByCommitID[%d, %s]: %v", repo.ID, sha, err)
+		return
+	}
+	for _, entry := range entries {
+		addToQueue(entry.RepoID, entry.BaseBranch)
+	}
+}
+
+// eject removes a queued pull request from the merge queue and tells why in its timeline
+func eject(entry *models.MergeQueueEntry, reason string) {
+	log.Trace("Ejecting PR[%d] from the merge queue: %s", entry.PullID, reason)
+	if err := models.RemoveFromMergeQueue(entry.Doer, entry.Pull, reason); err != nil && !models.IsErrNotInMergeQueue(err) {
+		log.Error("RemoveFromMergeQueue[%d]: %v", entry.PullID, err)
+	}
+}
+
+// handleBranch brings the speculative merges of the merge queue of a branch up to date and
+// merges the pull request at the front of the queue once its status checks succeed
+func handleBranch(repoID int64, branch string) {
+	key := strconv.FormatInt(repoID, 10) + ":" + branch
+	branchWorkingPool.CheckIn(key)
+	defer branchWorkingPool.CheckOut(key)
+
+	entries, err := models.GetMergeQueue(repoID, branch)
+	if err != nil {
+		log.Error("GetMergeQueue[%d, %s]: %v", repoID, branch, err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		log.Error("GetRepositoryByID[%d]: %v", repoID, err)
+		return
+	}
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", repo.RepoPath(), err)
+		return
+	}
+	defer gitRepo.Close()
+
+	baseCommitID, err := gitRepo.GetBranchCommitID(branch)
+	if err != nil {
+		log.Error("GetBranchCommitID[%s]: %v", branch, err)
+		return
+	}
+
+	// isFront is true as long as no entry ahead is still waiting to be merged
+	isFront := true
+	for _, entry := range entries {
+		if err := entry.LoadAttributes(); err != nil {
+			log.Error("LoadAttributes[%d]: %v", entry.ID, err)
+			return
+		}
+		pr := entry.Pull
+		if err := pr.LoadIssue(); err != nil {
+			log.Error("LoadIssue[%d]: %v", pr.ID, err)
+			return
+		}
+		pr.Issue.Repo = repo
+		pr.BaseRepo = repo
+
+		if pr.HasMerged || pr.Issue.IsClosed {
+			if err := models.DeleteMergeQueueEntry(pr.ID); err != nil {
+				log.Error("DeleteMergeQueueEntry[%d]: %v", pr.ID, err)
+			}
+			continue
+		}
+		if pr.BaseBranch != branch {
+			eject(entry, "The target branch has been changed")
+			continue
+		}
+
+		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil {
+			log.Error("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
+			return
+		}
+
+		// Rebuild the speculative merge if anything ahead of it or the pull request itself changed
+		if entry.BaseCommitID != baseCommitID || entry.HeadCommitID != headCommitID || len(entry.CommitID) == 0 {
+			commitID, err := pull_service.PrepareMergeQueueCommit(pr, entry.Doer, entry.MergeStyle, entry.Message, baseCommitID)
+			if err != nil {
+				if models.IsErrMergeConflicts(err) || models.IsErrRebaseConflicts(err) || models.IsErrMergeUnrelatedHistories(err) {
+					eject(entry, "The pull request can't be merged onto the pull requests ahead of it without conflicts")
+					continue
+				} else if models.IsErrMergeDivergingFastForwardOnly(err) {
+					eject(entry, "The pull request can't be fast-forwarded onto the pull requests ahead of it")
+					continue
+				}
+				log.Error("PrepareMergeQueueCommit[%d]: %v", pr.ID, err)
+				return
+			}
+			entry.BaseCommitID, entry.HeadCommitID, entry.CommitID = baseCommitID, headCommitID, commitID
+			if err := entry.UpdateCommits(); err != nil {
+				log.Error("UpdateCommits[%d]: %v", entry.ID, err)
+				return
+			}
+		}
+
+		if isFront {
+			switch handleFront(entry) {
+			case frontMerged:
+				baseCommitID = entry.CommitID
+				continue
+			case frontEjected:
+				continue
+			case frontWaiting:
+				isFront = false
+			default:
+				return
+			}
+		}
+		baseCommitID = entry.CommitID
+	}
+}
+
+// frontResult is the outcome of handling the entry at the front of a merge queue
+type frontResult int
+
+const (
+	// frontWaiting means the status checks of the speculative merge have not finished yet
+	frontWaiting frontResult = iota
+	// frontMerged means the pull request has been merged
+	frontMerged
+	// frontEjected means the pull request has been removed from the merge queue
+	frontEjected
+	// frontFailed means the merge queue can't be advanced because of an error
+	frontFailed
+)
+
+// handleFront merges the entry at the front of the merge queue if the status checks of its speculative merge
+// succeeded, or ejects it if they failed.
+func handleFront(entry *models.MergeQueueEntry) frontResult {
+	pr := entry.Pull
+	state, err := pull_service.GetMergeQueueCommitStatusState(pr, entry.CommitID)
+	if err != nil {
+		log.Error("GetMergeQueueCommitStatusState[%d]: %v", pr.ID, err)
+		return frontFailed
+	}
+	if state.IsPending() {
+		return frontWaiting
+	}
+	if !state.IsSuccess() {
+		eject(entry, "Not all required status checks of the merge queue successful")
+		return frontEjected
+	}
+
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, entry.Doer)
+	if err != nil {
+		log.Error("GetUserRepoPermission[%d]: %v", pr.BaseRepo.ID, err)
+		return frontFailed
+	}
+	if allowed, err := pull_service.IsUserAllowedToMerge(pr, perm, entry.Doer); err != nil {
+		log.Error("IsUserAllowedToMerge[%d]: %v", pr.ID, err)
+		return frontFailed
+	} else if !allowed {
+		eject(entry, fmt.Sprintf("%s is no longer allowed to merge the pull request", entry.Doer.Name))
+		return frontEjected
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr, false); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			log.Error("CheckPRReadyToMerge[%d]: %v", pr.ID, err)
+			return frontFailed
+		}
+		eject(entry, err.(models.ErrNotAllowedToMerge).Reason)
+		return frontEjected
+	}
+
+	if noDeps, err := models.IssueNoDependenciesLeft(pr.Issue); err != nil {
+		log.Error("IssueNoDependenciesLeft[%d]: %v", pr.ID, err)
+		return frontFailed
+	} else if !noDeps {
+		eject(entry, "The pull request depends on issues which are still open")
+		return frontEjected
+	}
+
+	if err := pull_service.MergeQueued(pr, entry.Doer, entry.CommitID); err != nil {
+		if git.IsErrPushOutOfDate(err) {
+			// The branch has moved on meanwhile, its push queues the merge queue again
+			log.Debug("MergeQueued[%d]: %v", pr.ID, err)
+			return frontFailed
+		} else if git.IsErrPushRejected(err) {
+			eject(entry, "The merge has been rejected: "+err.(*git.ErrPushRejected).Message)
+			return frontEjected
+		}
+		log.Error("MergeQueued[%d]: %v", pr.ID, err)
+		return frontFailed
+	}
+
+	if err := models.DeleteMergeQueueEntry(pr.ID); err != nil {
+		log.Error("DeleteMergeQueueEntry[%d]: %v", pr.ID, err)
+	}
+	return frontMerged
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mergequeue
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+)
+
+type mergeQueueNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &mergeQueueNotifier{}
+)
+
+// NewNotifier create a new mergeQueueNotifier notifier
+func NewNotifier() base.Notifier {
+	return &mergeQueueNotifier{}
+}
+
+// NotifyMergePullRequest drops the pull request from the merge queue once it has been merged
+func (n *mergeQueueNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
+	if err := models.DeleteMergeQueueEntry(pr.ID); err != nil {
+		log.Error("DeleteMergeQueueEntry[%d]: %v", pr.ID, err)
+	}
+}
+
+// NotifyIssueChangeStatus drops the pull request from the merge queue when it is closed,
+// so that the pull requests behind it get merged without it
+func (n *mergeQueueNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !issue.IsPull || !isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		log.Error("LoadPullRequest[%d]: %v", issue.ID, err)
+		return
+	}
+	if err := models.DeleteMergeQueueEntry(issue.PullRequest.ID); err != nil {
+		log.Error("DeleteMergeQueueEntry[%d]: %v", issue.PullRequest.ID, err)
+		return
+	}
+	queueBranchIfNeeded(issue.PullRequest.BaseRepoID, issue.PullRequest.BaseBranch)
+}
+
+// NotifyPullRequestSynchronized ejects the pull request from the merge queue as its tested merge is outdated
+func (n *mergeQueueNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	ejectChangedPullRequest(doer, pr, pr.BaseBranch, "New commits have been pushed to the pull request")
+}
+
+// NotifyPullRequestChangeTargetBranch ejects the pull request from the merge queue of its former target branch
+func (n *mergeQueueNotifier) NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullRequest, oldBranch string) {
+	ejectChangedPullRequest(doer, pr, oldBranch, "The target branch has been changed")
+}
+
+// NotifyCreateCommitStatus queues the merge queues whose speculative merges got a new status
+func (n *mergeQueueNotifier) NotifyCreateCommitStatus(doer *models.User, repo *models.Repository, sha string, status *models.CommitStatus) {
+	handleCommitStatus(sha, repo)
+}
+
+// NotifyPushCommits queues the merge queue of a branch which has been pushed to,
+// as the speculative merges have to be rebuilt onto the new head
+func (n *mergeQueueNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
+	if !opts.IsUpdateBranch() {
+		return
+	}
+	queueBranchIfNeeded(repo.ID, opts.BranchName())
+}
+
+func ejectChangedPullRequest(doer *models.User, pr *models.PullRequest, branch, reason string) {
+	if err := models.RemoveFromMergeQueue(doer, pr, reason); err != nil {
+		if !models.IsErrNotInMergeQueue(err) {
+			log.Error("RemoveFromMergeQueue[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+	addToQueue(pr.BaseRepoID, branch)
+}
+
+func queueBranchIfNeeded(repoID int64, branch string) {
+	entries, err := models.GetMergeQueue(repoID, branch)
+	if err != nil {
+		log.Error("GetMergeQueue[%d, %s]: %v", repoID, branch, err)
+		return
+	}
+	if len(entries) > 0 {
+		addToQueue(repoID, branch)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +30,7 @@ import (
 // mirrorQueue holds an UniqueQueue object of the mirror
 var mirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
 
+// readAddress reads the remote address from the Git repository config and adds the credentials stored as secret
 func readAddress(m *models.Mirror) {
 	if len(m.Address) > 0 {
 		return
@@ -37,32 +39,27 @@ func readAddress(m *models.Mirror) {
 	m.Address, err = remoteAddress(m.Repo.RepoPath())
 	if err != nil {
 		log.Error("remoteAddress: %v", err)
+		return
 	}
-}
 
-func remoteAddress(repoPath string) (string, error) {
-	var cmd *git.Command
-	err := git.LoadGitVersion()
+	user, err := m.GetCredentials()
 	if err != nil {
-		return "", err
-	}
-	if git.CheckGitVersionAtLeast("2.7") == nil {
-		cmd = git.NewCommand("remote", "get-url", "origin")
-	} else {
-		cmd = git.NewCommand("config", "--get", "remote.origin.url")
+		log.Error("GetCredentials: %v", err)
+		return
 	}
-
-	result, err := cmd.RunInDir(repoPath)
-	if err != nil {
-		if strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote ") {
-			return "", nil
+	if user != nil {
+		u, err := url.Parse(m.Address)
+		if err != nil {
+			log.Error("Unable to parse remote address of mirror %-v: %v", m.Repo, err)
+			return
 		}
-		return "", err
+		u.User = user
+		m.Address = u.String()
 	}
-	if len(result) > 0 {
-		return result[:len(result)-1], nil
-	}
-	return "", nil
+}
+
+func remoteAddress(repoPath string) (string, error) {
+	return git.GetRemoteAddress(repoPath, "origin")
 }
 
 // sanitizeOutput sanitizes output of a command, replacing occurrences of the
@@ -89,11 +86,44 @@ func AddressNoCredentials(m *models.Mirror) string {
 	return u.String()
 }
 
-// UpdateAddress writes new address to Git repository and database
+// credentialHelper is a Git credential helper which answers with the credentials passed in the environment
+const credentialHelper = `!f() { test "$1" = get && echo "username=${GITEA_MIRROR_USERNAME}" && echo "password=${GITEA_MIRROR_PASSWORD}"; }; f`
+
+// remoteCredentials is a pull or push mirror whose remote credentials are stored as secret
+type remoteCredentials interface {
+	GetCredentials() (*url.Userinfo, error)
+}
+
+// credentialArgs returns the Git arguments and the environment which provide the stored credentials of the mirror to Git.
+// This way the credentials never appear in the Git repository config or in the command line.
+func credentialArgs(m remoteCredentials) ([]string, []string, error) {
+	user, err := m.GetCredentials()
+	if err != nil || user == nil {
+		return nil, nil, err
+	}
+	password, _ := user.Password()
+	args := []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}
+	env := append(os.Environ(), "GITEA_MIRROR_USERNAME="+user.Username(), "GITEA_MIRROR_PASSWORD="+password)
+	return args, env, nil
+}
+
+// UpdateAddress writes new address to Git repository and database.
+// The credentials of the address are stored as secret of the repository.
 func UpdateAddress(m *models.Mirror, addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return err
+	}
+	if err := m.SetCredentials(u.User); err != nil {
+		return err
+	}
+	u.User = nil
+	addr = u.String()
+	m.Address = ""
+
 	repoPath := m.Repo.RepoPath()
 	// Remove old origin
-	_, err := git.NewCommand("remote", "rm", "origin").RunInDir(repoPath)
+	_, err = git.NewCommand("remote", "rm", "origin").RunInDir(repoPath)
 	if err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote ") {
 		return err
 	}
@@ -212,8 +242,14 @@ func runSync(ctx context.Context, m *models.Mirror) ([]*mirrorSyncResult, bool)
 	wikiPath := m.Repo.WikiPath()
 	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
 
+	credArgs, env, err := credentialArgs(m)
+	if err != nil {
+		log.Error("Unable to load credentials of mirror %-v: %v", m.Repo, err)
+		return nil, false
+	}
+
 	log.Trace("SyncMirrors [repo: %-v]: running git remote update...", m.Repo)
-	gitArgs := []string{"remote", "update"}
+	gitArgs := append(credArgs, "remote", "update")
 	if m.EnablePrune {
 		gitArgs = append(gitArgs, "--prune")
 	}
@@ -222,7 +258,7 @@ func runSync(ctx context.Context, m *models.Mirror) ([]*mirrorSyncResult, bool)
 	stderrBuilder := strings.Builder{}
 	if err := git.NewCommand(gitArgs...).
 		SetDescription(fmt.Sprintf("Mirror.runSync: %s", m.Repo.FullName())).
-		RunInDirTimeoutPipeline(timeout, repoPath, &stdoutBuilder, &stderrBuilder); err != nil {
+		RunInDirTimeoutEnvPipeline(env, timeout, repoPath, &stdoutBuilder, &stderrBuilder); err != nil {
 		stdout := stdoutBuilder.String()
 		stderr := stderrBuilder.String()
 		// sanitize the output, since it may contain the remote address, which may
@@ -279,9 +315,9 @@ func runSync(ctx context.Context, m *models.Mirror) ([]*mirrorSyncResult, bool)
 		log.Trace("SyncMirrors [repo: %-v Wiki]: running git remote update...", m.Repo)
 		stderrBuilder.Reset()
 		stdoutBuilder.Reset()
-		if err := git.NewCommand("remote", "update", "--prune").
+		if err := git.NewCommand(append(credArgs, "remote", "update", "--prune")...).
 			SetDescription(fmt.Sprintf("Mirror.runSync Wiki: %s ", m.Repo.FullName())).
-			RunInDirTimeoutPipeline(timeout, wikiPath, &stdoutBuilder, &stderrBuilder); err != nil {
+			RunInDirTimeoutEnvPipeline(env, timeout, wikiPath, &stdoutBuilder, &stderrBuilder); err != nil {
 			stdout := stdoutBuilder.String()
 			stderr := stderrBuilder.String()
 			// sanitize the output, since it may contain the remote address, which may
@@ -353,24 +389,50 @@ func Password(m *models.Mirror) string {
 	return password
 }
 
+const (
+	pullMirrorPrefix = "pull "
+	pushMirrorPrefix = "push "
+)
+
 // Update checks and updates mirror repositories.
 func Update(ctx context.Context) error {
 	log.Trace("Doing: Update")
-	if err := models.MirrorsIterate(func(idx int, bean interface{}) error {
-		m := bean.(*models.Mirror)
-		if m.Repo == nil {
-			log.Error("Disconnected mirror repository found: %d", m.ID)
+
+	handler := func(idx int, bean interface{}) error {
+		var item string
+		switch m := bean.(type) {
+		case *models.Mirror:
+			if m.Repo == nil {
+				log.Error("Disconnected mirror repository found: %d", m.ID)
+				return nil
+			}
+			item = pullMirrorPrefix + strconv.FormatInt(m.RepoID, 10)
+		case *models.PushMirror:
+			if m.Repo == nil {
+				log.Error("Disconnected push-mirror repository found: %d", m.ID)
+				return nil
+			}
+			item = pushMirrorPrefix + strconv.FormatInt(m.ID, 10)
+		default:
+			log.Error("Unknown bean: %v", bean)
 			return nil
 		}
+
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("Aborted")
 		default:
-			mirrorQueue.Add(m.RepoID)
+			mirrorQueue.Add(item)
 			return nil
 		}
-	}); err != nil {
-		log.Trace("Update: %v", err)
+	}
+
+	if err := models.MirrorsIterate(handler); err != nil {
+		log.Trace("MirrorsIterate: %v", err)
+		return err
+	}
+	if err := models.PushMirrorsIterate(handler); err != nil {
+		log.Trace("PushMirrorsIterate: %v", err)
 		return err
 	}
 	log.Trace("Finished: Update")
@@ -386,13 +448,22 @@ func SyncMirrors(ctx context.Context) {
 		case <-ctx.Done():
 			mirrorQueue.Close()
 			return
-		case repoID := <-mirrorQueue.Queue():
-			syncMirror(ctx, repoID)
+		case item := <-mirrorQueue.Queue():
+			switch {
+			case strings.HasPrefix(item, pullMirrorPrefix):
+				syncMirror(ctx, item)
+			case strings.HasPrefix(item, pushMirrorPrefix):
+				syncPushMirror(ctx, item)
+			default:
+				log.Error("Unknown item in mirror queue: %q", item)
+				mirrorQueue.Remove(item)
+			}
 		}
 	}
 }
 
-func syncMirror(ctx context.Context, repoID string) {
+func syncMirror(ctx context.Context, item string) {
+	repoID := strings.TrimPrefix(item, pullMirrorPrefix)
 	log.Trace("SyncMirrors [repo_id: %v]", repoID)
 	defer func() {
 		err := recover()
@@ -402,7 +473,7 @@ func syncMirror(ctx context.Context, repoID string) {
 		// There was a panic whilst syncMirrors...
 		log.Error("PANIC whilst syncMirrors[%s] Panic: %v\nStacktrace: %s", repoID, err, log.Stack(2))
 	}()
-	mirrorQueue.Remove(repoID)
+	mirrorQueue.Remove(item)
 
 	id, _ := strconv.ParseInt(repoID, 10, 64)
 	m, err := models.GetMirrorByRepoID(id)
@@ -595,5 +666,10 @@ func InitSyncMirrors() {
 
 // StartToMirror adds repoID to mirror queue
 func StartToMirror(repoID int64) {
-	go mirrorQueue.Add(repoID)
+	go mirrorQueue.Add(pullMirrorPrefix + strconv.FormatInt(repoID, 10))
+}
+
+// AddPushMirrorToQueue adds the push mirror to the mirror queue
+func AddPushMirrorToQueue(mirrorID int64) {
+	go mirrorQueue.Add(pushMirrorPrefix + strconv.FormatInt(mirrorID, 10))
 }
@@ -0,0 +1,253 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+)
+
+var stripExitStatus = regexp.MustCompile(`exit status \d+ - `)
+
+// AddPushMirror creates a new push mirror of the repository to addr.
+// The credentials of addr are stored as secret of the repository, the git config of the repository only has the address without them.
+func AddPushMirror(repo *models.Repository, addr string, interval time.Duration, syncOnCommit bool) (*models.PushMirror, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	user := u.User
+	u.User = nil
+	addr = u.String()
+
+	remoteSuffix, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.PushMirror{
+		RepoID:       repo.ID,
+		Repo:         repo,
+		RemoteName:   "remote_mirror_" + remoteSuffix,
+		SyncOnCommit: syncOnCommit,
+		Interval:     interval,
+	}
+	if err := models.InsertPushMirror(m); err != nil {
+		return nil, err
+	}
+
+	if err := m.SetCredentials(user); err != nil {
+		if errDelete := models.DeletePushMirrorByID(m.ID); errDelete != nil {
+			log.Error("DeletePushMirrorByID %v", errDelete)
+		}
+		return nil, err
+	}
+	if err := addPushMirrorRemote(m, addr); err != nil {
+		if errDelete := models.DeletePushMirrorByID(m.ID); errDelete != nil {
+			log.Error("DeletePushMirrorByID %v", errDelete)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemovePushMirror removes the git remote and the database entry of the push mirror.
+func RemovePushMirror(m *models.PushMirror) error {
+	if err := removePushMirrorRemote(m); err != nil {
+		return err
+	}
+	return models.DeletePushMirrorByID(m.ID)
+}
+
+func addPushMirrorRemote(m *models.PushMirror, addr string) error {
+	addRemoteAndConfig := func(addr, path string) error {
+		if _, err := git.NewCommand("remote", "add", m.RemoteName, addr).RunInDir(path); err != nil {
+			return err
+		}
+		// Pull mirrors run "git remote update", which must not fetch from push mirrors.
+		if _, err := git.NewCommand("config", "remote."+m.RemoteName+".skipDefaultUpdate", "true").RunInDir(path); err != nil {
+			return err
+		}
+		if _, err := git.NewCommand("config", "--add", "remote."+m.RemoteName+".push", "+refs/heads/*:refs/heads/*").RunInDir(path); err != nil {
+			return err
+		}
+		if _, err := git.NewCommand("config", "--add", "remote."+m.RemoteName+".push", "+refs/tags/*:refs/tags/*").RunInDir(path); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err := addRemoteAndConfig(addr, m.Repo.RepoPath()); err != nil {
+		return util.URLSanitizedError(err, addr)
+	}
+
+	if m.Repo.HasWiki() {
+		wikiRemoteURL := repo_module.WikiRemoteURL(addr)
+		if len(wikiRemoteURL) > 0 {
+			if err := addRemoteAndConfig(wikiRemoteURL, m.Repo.WikiPath()); err != nil {
+				return util.URLSanitizedError(err, wikiRemoteURL)
+			}
+		}
+	}
+
+	return nil
+}
+
+func removePushMirrorRemote(m *models.PushMirror) error {
+	cmd := git.NewCommand("remote", "rm", m.RemoteName)
+
+	if _, err := cmd.RunInDir(m.Repo.RepoPath()); err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote") {
+		return err
+	}
+
+	if m.Repo.HasWiki() {
+		if _, err := cmd.RunInDir(m.Repo.WikiPath()); err != nil {
+			// The wiki remote may not exist
+			log.Warn("Wiki remote of push mirror[%d] could not be removed: %v", m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// PushMirrorAddress returns the address of the push mirror without credentials.
+func PushMirrorAddress(m *models.PushMirror) string {
+	addr, err := git.GetRemoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error("GetRemoteAddress: %v", err)
+		return ""
+	}
+	return util.SanitizeURLCredentials(addr, false)
+}
+
+// SyncPushMirrorsOnCommit queues all push mirrors of the repository which want to be synced on each push.
+func SyncPushMirrorsOnCommit(repoID int64) {
+	mirrors, err := models.GetPushMirrorsSyncedOnCommit(repoID)
+	if err != nil {
+		log.Error("GetPushMirrorsSyncedOnCommit [repo_id: %d]: %v", repoID, err)
+		return
+	}
+
+	for _, m := range mirrors {
+		AddPushMirrorToQueue(m.ID)
+	}
+}
+
+func syncPushMirror(ctx context.Context, item string) {
+	defer mirrorQueue.Remove(item)
+
+	id, _ := strconv.ParseInt(strings.TrimPrefix(item, pushMirrorPrefix), 10, 64)
+	_ = SyncPushMirror(ctx, id)
+}
+
+// SyncPushMirror pushes the repository to the push mirror and records the result.
+// It returns true if the sync finished without error.
+func SyncPushMirror(ctx context.Context, mirrorID int64) bool {
+	log.Trace("SyncPushMirror [mirror: %d]", mirrorID)
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		// There was a panic whilst syncPushMirror...
+		log.Error("PANIC whilst syncPushMirror[%d] Panic: %v\nStacktrace: %s", mirrorID, err, log.Stack(2))
+	}()
+
+	m, err := models.GetPushMirrorByID(mirrorID)
+	if err != nil {
+		log.Error("GetPushMirrorByID [%d]: %v", mirrorID, err)
+		return false
+	}
+	if m.Repo == nil {
+		log.Error("Disconnected push-mirror repository found: %d", m.ID)
+		return false
+	}
+
+	m.LastError = ""
+
+	log.Trace("SyncPushMirror [mirror: %d][repo: %-v]: Running Sync", m.ID, m.Repo)
+	err = runPushSync(ctx, m)
+	if err != nil {
+		log.Error("SyncPushMirror [mirror: %d][repo: %-v]: %v", m.ID, m.Repo, err)
+		m.LastError = stripExitStatus.ReplaceAllLiteralString(err.Error(), "")
+	}
+
+	m.LastUpdateUnix = timeutil.TimeStampNow()
+
+	if err := models.UpdatePushMirror(m); err != nil {
+		log.Error("UpdatePushMirror [%d]: %v", m.ID, err)
+		return false
+	}
+
+	log.Trace("SyncPushMirror [mirror: %d][repo: %-v]: Finished", m.ID, m.Repo)
+
+	return err == nil
+}
+
+func runPushSync(ctx context.Context, m *models.PushMirror) error {
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	credArgs, env, err := credentialArgs(m)
+	if err != nil {
+		log.Error("Unable to load credentials of push mirror[%d]: %v", m.ID, err)
+		return errors.New("Unexpected error")
+	}
+
+	performPush := func(path string) error {
+		remoteAddr, err := git.GetRemoteAddress(path, m.RemoteName)
+		if err != nil {
+			log.Error("GetRemoteAddress(%s) Error %v", path, err)
+			return errors.New("Unexpected error")
+		}
+
+		log.Trace("Pushing %s mirror[%d] remote %s", path, m.ID, m.RemoteName)
+
+		if err := git.Push(path, git.PushOptions{
+			Remote:     m.RemoteName,
+			Force:      true,
+			Prune:      true,
+			Env:        env,
+			Timeout:    timeout,
+			ConfigArgs: credArgs,
+		}); err != nil {
+			log.Error("Error pushing %s mirror[%d] remote %s: %v", path, m.ID, m.RemoteName, util.URLSanitizedError(err, remoteAddr))
+
+			return util.URLSanitizedError(err, remoteAddr)
+		}
+
+		return nil
+	}
+
+	if err := performPush(m.Repo.RepoPath()); err != nil {
+		return err
+	}
+
+	if m.Repo.HasWiki() {
+		wikiPath := m.Repo.WikiPath()
+		if addr, err := git.GetRemoteAddress(wikiPath, m.RemoteName); err == nil && len(addr) > 0 {
+			if err := performPush(wikiPath); err != nil {
+				return err
+			}
+		} else {
+			log.Trace("Skipping wiki: No remote configured")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+// PackageInfo describes a package version to create
+type PackageInfo struct {
+	Owner   *models.User
+	Creator *models.User
+	Type    models.PackageType
+	Name    string
+	Version string
+	// Metadata is stored as JSON with the version
+	Metadata   interface{}
+	IsInternal bool
+}
+
+// FileInfo describes a file to add to a package version
+type FileInfo struct {
+	Filename string
+	Data     *packages_module.HashedBuffer
+}
+
+// blobPath returns the path of a blob in the package storage
+func blobPath(hashSHA256 string) string {
+	return path.Join(hashSHA256[0:2], hashSHA256[2:4], hashSHA256)
+}
+
+// CreatePackageVersion creates the version, and the package if it does not exist yet.
+// If the version exists already, it is returned if allowExisting is set and ErrPackageVersionAlreadyExist otherwise.
+func CreatePackageVersion(pi *PackageInfo, allowExisting bool) (*models.PackageVersion, error) {
+	p, err := models.TryInsertPackage(&models.Package{
+		OwnerID: pi.Owner.ID,
+		Type:    pi.Type,
+		Name:    pi.Name,
+	})
+	if err != nil && err != models.ErrPackageAlreadyExist {
+		return nil, err
+	}
+
+	var metadata []byte
+	if pi.Metadata != nil {
+		if metadata, err = json.Marshal(pi.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	pv, err := models.InsertPackageVersion(&models.PackageVersion{
+		PackageID:    p.ID,
+		CreatorID:    pi.Creator.ID,
+		Version:      pi.Version,
+		IsInternal:   pi.IsInternal,
+		MetadataJSON: string(metadata),
+	})
+	if err == models.ErrPackageVersionAlreadyExist && allowExisting {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pv.Package = p
+	return pv, nil
+}
+
+// AddFileToPackageVersion stores the content of the file and adds the file to the version
+func AddFileToPackageVersion(pv *models.PackageVersion, fi *FileInfo) (*models.PackageFile, error) {
+	hashMD5, hashSHA1, hashSHA256, hashSHA512 := fi.Data.Sums()
+	pb, exists, err := models.GetOrInsertPackageBlob(&models.PackageBlob{
+		Size:       fi.Data.Size(),
+		HashMD5:    hashMD5,
+		HashSHA1:   hashSHA1,
+		HashSHA256: hashSHA256,
+		HashSHA512: hashSHA512,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if _, err := fi.Data.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := storage.Packages.Save(blobPath(hashSHA256), fi.Data, fi.Data.Size()); err != nil {
+			if delErr := models.DeletePackageBlob(pb); delErr != nil {
+				log.Error("DeletePackageBlob: %v", delErr)
+			}
+			return nil, err
+		}
+	}
+
+	pf := &models.PackageFile{
+		VersionID: pv.ID,
+		BlobID:    pb.ID,
+		Name:      fi.Filename,
+		Blob:      pb,
+	}
+	if err := models.InsertPackageFile(pf); err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+// CreatePackageAndAddFile creates the version like CreatePackageVersion and adds the file to it
+func CreatePackageAndAddFile(pi *PackageInfo, fi *FileInfo, allowExisting bool) (*models.PackageVersion, *models.PackageFile, error) {
+	pv, err := CreatePackageVersion(pi, allowExisting)
+	if err != nil {
+		return nil, nil, err
+	}
+	pf, err := AddFileToPackageVersion(pv, fi)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pv, pf, nil
+}
+
+// OpenPackageFile opens the content of a file. Downloads of the main file of a version are counted.
+func OpenPackageFile(pv *models.PackageVersion, pf *models.PackageFile, countDownload bool) (storage.Object, error) {
+	if err := pf.LoadBlob(); err != nil {
+		return nil, err
+	}
+	obj, err := storage.Packages.Open(blobPath(pf.Blob.HashSHA256))
+	if err != nil {
+		return nil, err
+	}
+	if countDownload {
+		if err := models.IncrementPackageVersionDownloadCount(pv); err != nil {
+			log.Error("IncrementPackageVersionDownloadCount: %v", err)
+		}
+	}
+	return obj, nil
+}
+
+// OpenPackageBlob opens the content of a blob
+func OpenPackageBlob(pb *models.PackageBlob) (storage.Object, error) {
+	return storage.Packages.Open(blobPath(pb.HashSHA256))
+}
+
+// Cleanup removes the blobs no package file references anymore
+func Cleanup(ctx context.Context) error {
+	pbs, err := models.FindUnreferencedPackageBlobs()
+	if err != nil {
+		return err
+	}
+	for _, pb := range pbs {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted package cleanup before completion")
+		default:
+		}
+
+		if err := storage.Packages.Delete(blobPath(pb.HashSHA256)); err != nil {
+			log.Error("Unable to delete package blob %s: %v", pb.HashSHA256, err)
+			continue
+		}
+		if err := models.DeletePackageBlob(pb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
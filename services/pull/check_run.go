@@ -0,0 +1,74 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// CreateCheckRun records a new run of a check on a commit of a repository and mirrors its state into
+// a commit status with the name of the run as context, so that it can be required by branch protection.
+func CreateCheckRun(repo *models.Repository, doer *models.User, run *models.CheckRun, annotations []*models.CheckRunAnnotation) error {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository[%s]: %v", repo.RepoPath(), err)
+	}
+	commit, err := gitRepo.GetCommit(run.HeadSHA)
+	gitRepo.Close()
+	if err != nil {
+		return err
+	}
+
+	run.RepoID = repo.ID
+	run.Repo = repo
+	run.HeadSHA = commit.ID.String()
+	run.Name = strings.TrimSpace(run.Name)
+	run.CreatorID = doer.ID
+	run.Creator = doer
+	if err := models.CreateCheckRun(run, annotations); err != nil {
+		return fmt.Errorf("CreateCheckRun: %v", err)
+	}
+	return createCheckRunCommitStatus(doer, run)
+}
+
+// UpdateCheckRun updates the given columns of a check run, adds the annotations to it and mirrors its new
+// state into a commit status
+func UpdateCheckRun(doer *models.User, run *models.CheckRun, annotations []*models.CheckRunAnnotation, cols ...string) error {
+	if err := run.LoadAttributes(); err != nil {
+		return err
+	}
+	run.Name = strings.TrimSpace(run.Name)
+	if err := models.UpdateCheckRun(run, annotations, cols...); err != nil {
+		return fmt.Errorf("UpdateCheckRun: %v", err)
+	}
+	return createCheckRunCommitStatus(doer, run)
+}
+
+func createCheckRunCommitStatus(doer *models.User, run *models.CheckRun) error {
+	description := run.Title
+	if len(description) == 0 {
+		switch run.Status {
+		case api.CheckRunStatusQueued:
+			description = "Queued"
+		case api.CheckRunStatusInProgress:
+			description = "In progress"
+		default:
+			description = "Completed: " + strings.ReplaceAll(string(run.Conclusion), "_", " ")
+		}
+	}
+
+	return repofiles.CreateCommitStatus(run.Repo, doer, run.HeadSHA, &models.CommitStatus{
+		State:       run.CommitStatusState(),
+		TargetURL:   run.HTMLURL(),
+		Description: description,
+		Context:     run.Name,
+	})
+}
@@ -0,0 +1,393 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/services/gitdiff"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// CodeOwnersFiles lists the places a CODEOWNERS file is looked up, in order of precedence
+var CodeOwnersFiles = []string{"CODEOWNERS", ".gitea/CODEOWNERS", "docs/CODEOWNERS", ".github/CODEOWNERS"}
+
+// codeOwnersMaxSize is the maximum size of a CODEOWNERS file that will be read
+const codeOwnersMaxSize = 3 * 1024 * 1024
+
+// CodeOwnerRule represents a single line of a CODEOWNERS file
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string
+	regexp  *regexp.Regexp
+}
+
+// Match returns true if the rule applies to the given path
+func (rule *CodeOwnerRule) Match(path string) bool {
+	return rule.regexp.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file.
+// Lines which are empty, comments or have an invalid pattern are skipped.
+func ParseCodeOwners(content string) []*CodeOwnerRule {
+	rules := make([]*CodeOwnerRule, 0, 10)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		rule := &CodeOwnerRule{
+			Pattern: strings.ReplaceAll(fields[0], `\#`, "#"),
+			Owners:  make([]string, 0, len(fields)-1),
+		}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+
+		var err error
+		if rule.regexp, err = codeOwnersPatternToRegexp(rule.Pattern); err != nil {
+			log.Trace("Invalid CODEOWNERS pattern %q (skipped): %v", rule.Pattern, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// codeOwnersPatternToRegexp converts a CODEOWNERS pattern, which follows the gitignore rules, to a regexp.
+func codeOwnersPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	// a pattern containing a slash anywhere but at its end is relative to the repository root
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if dirOnly {
+		sb.WriteString("/.*$")
+	} else {
+		// a pattern matching a directory matches everything below it
+		sb.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(sb.String())
+}
+
+// FindCodeOwners returns the owners of the given path. As in git, the last matching rule wins.
+func FindCodeOwners(rules []*CodeOwnerRule, path string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Match(path) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// GetCodeOwnersFromCommit returns the rules of the CODEOWNERS file in the given commit.
+// It returns nil if there is no such file.
+func GetCodeOwnersFromCommit(commit *git.Commit) ([]*CodeOwnerRule, error) {
+	for _, treePath := range CodeOwnersFiles {
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if entry.IsDir() || entry.Blob().Size() > codeOwnersMaxSize {
+			continue
+		}
+
+		dataRc, err := entry.Blob().DataAsync()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(dataRc)
+		dataRc.Close()
+		if err != nil {
+			return nil, err
+		}
+		return ParseCodeOwners(string(content)), nil
+	}
+	return nil, nil
+}
+
+// codeOwners holds the users and teams which own a set of files
+type codeOwners struct {
+	Users []*models.User
+	Teams []*models.Team
+}
+
+// codeOwnersResolver resolves the owners named in a CODEOWNERS file to users and teams of a repository
+type codeOwnersResolver struct {
+	repo  *models.Repository
+	users map[string]*models.User
+	teams map[string]*models.Team
+}
+
+func newCodeOwnersResolver(repo *models.Repository) *codeOwnersResolver {
+	return &codeOwnersResolver{
+		repo:  repo,
+		users: make(map[string]*models.User),
+		teams: make(map[string]*models.Team),
+	}
+}
+
+// resolve returns the user or team named by owner. Unknown owners, teams from other
+// organizations and users which can't read the pull requests of the repository are ignored.
+func (r *codeOwnersResolver) resolve(owner string) (*models.User, *models.Team, error) {
+	owner = strings.ToLower(owner)
+	if u, ok := r.users[owner]; ok {
+		return u, nil, nil
+	}
+	if t, ok := r.teams[owner]; ok {
+		return nil, t, nil
+	}
+
+	if idx := strings.IndexByte(owner, '/'); strings.HasPrefix(owner, "@") && idx > 0 {
+		var team *models.Team
+		if r.repo.Owner.IsOrganization() && owner[1:idx] == r.repo.Owner.LowerName {
+			t, err := models.GetTeam(r.repo.OwnerID, owner[idx+1:])
+			if err != nil && !models.IsErrTeamNotExist(err) {
+				return nil, nil, err
+			}
+			if t != nil && (!r.repo.IsPrivate || models.HasTeamRepo(t.OrgID, t.ID, r.repo.ID)) {
+				team = t
+			}
+		}
+		r.teams[owner] = team
+		return nil, team, nil
+	}
+
+	var u *models.User
+	var err error
+	if strings.HasPrefix(owner, "@") {
+		u, err = models.GetUserByName(owner[1:])
+	} else if strings.Contains(owner, "@") {
+		u, err = models.GetUserByEmail(owner)
+	}
+	if err != nil && !models.IsErrUserNotExist(err) {
+		return nil, nil, err
+	}
+	if u != nil {
+		if u.IsOrganization() || !u.IsActive || u.ProhibitLogin {
+			u = nil
+		} else {
+			perm, err := models.GetUserRepoPermission(r.repo, u)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !perm.CanRead(models.UnitTypePullRequests) {
+				u = nil
+			}
+		}
+	}
+	r.users[owner] = u
+	return u, nil, nil
+}
+
+// getCodeOwnersOfChangedFiles matches the CODEOWNERS file of the base branch against the files changed
+// by the pull request and returns the users and teams owning each of them.
+func getCodeOwnersOfChangedFiles(pr *models.PullRequest) (map[string]*codeOwners, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return nil, err
+	}
+	if err := pr.BaseRepo.GetOwner(); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := GetCodeOwnersFromCommit(commit)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	files, err := gitdiff.GetChangedFiles(pr.BaseRepo.RepoPath(), git.BranchPrefix+pr.BaseBranch, pr.GetGitRefName())
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := newCodeOwnersResolver(pr.BaseRepo)
+	owned := make(map[string]*codeOwners, len(files))
+	for _, file := range files {
+		owners := FindCodeOwners(rules, file)
+		if len(owners) == 0 {
+			continue
+		}
+		fileOwners := &codeOwners{}
+		for _, owner := range owners {
+			u, t, err := resolver.resolve(owner)
+			if err != nil {
+				return nil, err
+			}
+			if u != nil {
+				fileOwners.Users = append(fileOwners.Users, u)
+			} else if t != nil {
+				fileOwners.Teams = append(fileOwners.Teams, t)
+			}
+		}
+		if len(fileOwners.Users) > 0 || len(fileOwners.Teams) > 0 {
+			owned[file] = fileOwners
+		}
+	}
+	return owned, nil
+}
+
+// RequestCodeOwnersReview requests reviews from the code owners of the files changed by the pull request.
+// Owners which have already reviewed or were already requested are skipped, as is the poster of the pull request.
+func RequestCodeOwnersReview(pr *models.PullRequest, doer *models.User) error {
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	}
+	if pr.Issue.IsClosed || pr.HasMerged {
+		return nil
+	}
+
+	owned, err := getCodeOwnersOfChangedFiles(pr)
+	if err != nil || len(owned) == 0 {
+		return err
+	}
+	pr.Issue.Repo = pr.BaseRepo
+
+	requestedUsers := make(map[int64]bool)
+	requestedTeams := make(map[int64]bool)
+	for _, owners := range owned {
+		for _, u := range owners.Users {
+			if requestedUsers[u.ID] || u.ID == pr.Issue.PosterID || u.ID == doer.ID {
+				continue
+			}
+			requestedUsers[u.ID] = true
+
+			if _, err := models.GetReviewByIssueIDAndUserID(pr.IssueID, u.ID); err == nil {
+				continue
+			} else if !models.IsErrReviewNotExist(err) {
+				return err
+			}
+			if _, err := issue_service.ReviewRequest(pr.Issue, doer, u, true); err != nil {
+				return fmt.Errorf("ReviewRequest: %v", err)
+			}
+		}
+		for _, t := range owners.Teams {
+			if requestedTeams[t.ID] {
+				continue
+			}
+			requestedTeams[t.ID] = true
+
+			if _, err := issue_service.TeamReviewRequest(pr.Issue, doer, t, true); err != nil {
+				return fmt.Errorf("TeamReviewRequest: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// MergeBlockedByCodeOwners returns true if the protected branch requires the approval of code owners
+// and a file changed by the pull request has not been approved by any of its owners.
+func MergeBlockedByCodeOwners(pr *models.PullRequest) (bool, error) {
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return false, err
+	}
+	if pr.ProtectedBranch == nil || !pr.ProtectedBranch.RequireCodeOwnerApproval {
+		return false, nil
+	}
+
+	owned, err := getCodeOwnersOfChangedFiles(pr)
+	if err != nil || len(owned) == 0 {
+		return false, err
+	}
+
+	reviews, err := models.FindReviews(models.FindReviewOptions{Type: models.ReviewTypeUnknown, IssueID: pr.IssueID})
+	if err != nil {
+		return false, err
+	}
+	// only the latest approval or rejection of every reviewer counts
+	latest := make(map[int64]*models.Review, len(reviews))
+	for _, review := range reviews {
+		if review.Type == models.ReviewTypeApprove || review.Type == models.ReviewTypeReject {
+			latest[review.ReviewerID] = review
+		}
+	}
+	approvers := make(map[int64]bool, len(latest))
+	for reviewerID, review := range latest {
+		if review.Type == models.ReviewTypeApprove && !review.Dismissed && !(review.Stale && pr.ProtectedBranch.DismissStaleApprovals) {
+			approvers[reviewerID] = true
+		}
+	}
+
+	for _, owners := range owned {
+		if !isApprovedByCodeOwners(owners, approvers) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isApprovedByCodeOwners(owners *codeOwners, approvers map[int64]bool) bool {
+	for _, u := range owners.Users {
+		if approvers[u.ID] {
+			return true
+		}
+	}
+	for _, t := range owners.Teams {
+		for approverID := range approvers {
+			if t.IsMember(approverID) {
+				return true
+			}
+		}
+	}
+	return false
+}
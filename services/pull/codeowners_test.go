@@ -0,0 +1,58 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeOwners(t *testing.T) {
+	rules := ParseCodeOwners(`# comment
+
+*                @user1
+*.go             @org3/team1 user2@example.com # trailing comment
+/docs/           @user4
+build/logs/      @user5
+apps/            @user6
+**/vendor        @user7
+/src/**/test.?s  @user8
+\#hash           @user9
+`)
+	if assert.Len(t, rules, 8) {
+		assert.Equal(t, "*", rules[0].Pattern)
+		assert.Equal(t, []string{"@user1"}, rules[0].Owners)
+		assert.Equal(t, []string{"@org3/team1", "user2@example.com"}, rules[1].Owners)
+		assert.Equal(t, "#hash", rules[7].Pattern)
+	}
+
+	cases := []struct {
+		path   string
+		owners []string
+	}{
+		{"README.md", []string{"@user1"}},
+		{"main.go", []string{"@org3/team1", "user2@example.com"}},
+		{"modules/git/repo.go", []string{"@org3/team1", "user2@example.com"}},
+		{"docs/index.md", []string{"@user4"}},
+		{"docs/api/index.go", []string{"@user4"}},
+		{"other/docs/index.md", []string{"@user1"}},
+		{"build/logs/today.log", []string{"@user5"}},
+		{"x/build/logs/today.log", []string{"@user1"}},
+		{"apps/web/index.html", []string{"@user6"}},
+		{"web/apps/index.html", []string{"@user6"}},
+		{"vendor/lib.c", []string{"@user7"}},
+		{"a/b/vendor/lib.c", []string{"@user7"}},
+		{"src/test.ts", []string{"@user8"}},
+		{"src/a/b/test.js", []string{"@user8"}},
+		{"src/a/b/test.tsx", []string{"@user1"}},
+		{"#hash", []string{"@user9"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.owners, FindCodeOwners(rules, c.path), c.path)
+	}
+
+	assert.Nil(t, FindCodeOwners(ParseCodeOwners("/docs/ @user1"), "README.md"))
+}
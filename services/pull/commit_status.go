@@ -25,11 +25,13 @@ func MergeRequiredContextsCommitStatus(commitStatuses []*models.CommitStatus, re
 
 	var returnedStatus = structs.CommitStatusSuccess
 	for _, ctx := range requiredContexts {
+		// A pattern like "ci/*" is as good as the worst of the statuses it matches
 		var targetStatus structs.CommitStatusState
 		for _, commitStatus := range commitStatuses {
-			if commitStatus.Context == ctx {
-				targetStatus = commitStatus.State
-				break
+			if models.MatchStatusCheckContext(ctx, commitStatus.Context) {
+				if targetStatus == "" || commitStatus.State.NoBetterThan(targetStatus) {
+					targetStatus = commitStatus.State
+				}
 			}
 		}
 
@@ -62,13 +64,12 @@ func IsCommitStatusContextSuccess(commitStatuses []*models.CommitStatus, require
 	for _, ctx := range requiredContexts {
 		var found bool
 		for _, commitStatus := range commitStatuses {
-			if commitStatus.Context == ctx {
+			if models.MatchStatusCheckContext(ctx, commitStatus.Context) {
 				if commitStatus.State != structs.CommitStatusSuccess {
 					return false
 				}
 
 				found = true
-				break
 			}
 		}
 		if !found {
@@ -87,6 +88,21 @@ func IsPullCommitStatusPass(pr *models.PullRequest) (bool, error) {
 		return true, nil
 	}
 
+	// Once a pull request has been queued, its speculative merge has to pass the status checks instead of its head
+	if pr.ProtectedBranch.EnableMergeQueue {
+		exist, entry, err := models.GetMergeQueueEntryByPullID(pr.ID)
+		if err != nil {
+			return false, errors.Wrap(err, "GetMergeQueueEntryByPullID")
+		}
+		if exist && len(entry.CommitID) > 0 {
+			state, err := GetMergeQueueCommitStatusState(pr, entry.CommitID)
+			if err != nil {
+				return false, err
+			}
+			return state.IsSuccess(), nil
+		}
+	}
+
 	state, err := GetPullRequestCommitStatusState(pr)
 	if err != nil {
 		return false, err
@@ -0,0 +1,41 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRequiredContextsCommitStatus(t *testing.T) {
+	commitStatuses := []*models.CommitStatus{
+		{Context: "ci/build", State: structs.CommitStatusSuccess},
+		{Context: "ci/test", State: structs.CommitStatusFailure},
+		{Context: "lint", State: structs.CommitStatusSuccess},
+	}
+
+	assert.EqualValues(t, structs.CommitStatusSuccess, MergeRequiredContextsCommitStatus(commitStatuses, []string{"ci/build", "lint"}))
+	assert.EqualValues(t, structs.CommitStatusFailure, MergeRequiredContextsCommitStatus(commitStatuses, []string{"ci/*"}))
+	assert.EqualValues(t, structs.CommitStatusSuccess, MergeRequiredContextsCommitStatus(commitStatuses, []string{"ci/b*", "l?nt"}))
+	assert.EqualValues(t, structs.CommitStatusPending, MergeRequiredContextsCommitStatus(commitStatuses, []string{"deploy/*"}))
+	assert.EqualValues(t, structs.CommitStatusPending, MergeRequiredContextsCommitStatus(commitStatuses, []string{"lint", "ci/build/*"}))
+}
+
+func TestIsCommitStatusContextSuccess(t *testing.T) {
+	commitStatuses := []*models.CommitStatus{
+		{Context: "ci/build", State: structs.CommitStatusSuccess},
+		{Context: "ci/test", State: structs.CommitStatusFailure},
+		{Context: "lint", State: structs.CommitStatusSuccess},
+	}
+
+	assert.True(t, IsCommitStatusContextSuccess(commitStatuses, []string{"ci/build", "lint"}))
+	assert.True(t, IsCommitStatusContextSuccess(commitStatuses, []string{"ci/bu*"}))
+	assert.False(t, IsCommitStatusContextSuccess(commitStatuses, []string{"ci/*"}))
+	assert.False(t, IsCommitStatusContextSuccess(commitStatuses, []string{"deploy/*"}))
+}
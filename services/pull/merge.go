@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,15 +52,120 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
 	}
 
+	if len(message) == 0 {
+		if message, err = GetDefaultMergeMessage(pr, mergeStyle); err != nil {
+			log.Error("GetDefaultMergeMessage: %v", err)
+			return err
+		}
+	}
+
 	defer func() {
 		go AddTestPullRequestTask(doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "")
 	}()
 
-	pr.MergedCommitID, err = rawMerge(pr, doer, mergeStyle, message)
+	pr.MergedCommitID, err = rawMerge(pr, doer, mergeStyle, message, "")
 	if err != nil {
 		return err
 	}
 
+	return afterMerge(pr, doer)
+}
+
+// mergeMessagePlaceholderPattern matches the ${Name} placeholders of commit message templates
+var mergeMessagePlaceholderPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// GetDefaultMergeMessage returns the default commit message for merging pr with mergeStyle. It is rendered
+// from the merge or squash message template of the base repository if there is one.
+func GetDefaultMergeMessage(pr *models.PullRequest, mergeStyle models.MergeStyle) (string, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return "", err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return "", err
+	}
+	if template := prUnit.PullRequestsConfig().GetMergeMessageTemplate(mergeStyle); len(template) > 0 {
+		return RenderMergeMessageTemplate(pr, template)
+	}
+
+	switch mergeStyle {
+	case models.MergeStyleMerge, models.MergeStyleRebaseMerge:
+		return pr.GetDefaultMergeMessage(), nil
+	case models.MergeStyleSquash:
+		return pr.GetDefaultSquashMessage(), nil
+	}
+	return "", nil
+}
+
+// RenderMergeMessageTemplate fills in the placeholders of a commit message template with the details of pr.
+// Unknown placeholders are left as they are.
+func RenderMergeMessageTemplate(pr *models.PullRequest, template string) (string, error) {
+	if err := pr.LoadIssue(); err != nil {
+		return "", err
+	}
+	if err := pr.LoadBaseRepo(); err != nil {
+		return "", err
+	}
+	pr.Issue.Repo = pr.BaseRepo
+
+	placeholders := map[string]func() (string, error){
+		"PullRequestTitle": func() (string, error) {
+			return pr.Issue.Title, nil
+		},
+		"PullRequestIndex": func() (string, error) {
+			return strconv.FormatInt(pr.Index, 10), nil
+		},
+		"PullRequestReference": func() (string, error) {
+			if pr.BaseRepo.UnitEnabled(models.UnitTypeExternalTracker) {
+				return "!" + strconv.FormatInt(pr.Index, 10), nil
+			}
+			return "#" + strconv.FormatInt(pr.Index, 10), nil
+		},
+		"PullRequestDescription": func() (string, error) {
+			return pr.Issue.Content, nil
+		},
+		"PullRequestURL": func() (string, error) {
+			return pr.Issue.HTMLURL(), nil
+		},
+		"HeadBranch": func() (string, error) {
+			return pr.HeadBranch, nil
+		},
+		"BaseBranch": func() (string, error) {
+			return pr.BaseBranch, nil
+		},
+		"Reviewers": func() (string, error) {
+			return strings.TrimSuffix(pr.GetApprovers(), "\n"), nil
+		},
+		"CoAuthors": func() (string, error) {
+			authors, err := getCoAuthors(pr)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(coAuthoredByLines(authors), "\n"), nil
+		},
+	}
+
+	var renderErr error
+	message := mergeMessagePlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, ok := placeholders[placeholder[2:len(placeholder)-1]]
+		if !ok || renderErr != nil {
+			return placeholder
+		}
+		rendered, err := value()
+		if err != nil {
+			renderErr = err
+			return placeholder
+		}
+		return rendered
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return strings.TrimSpace(message), nil
+}
+
+// afterMerge marks the pull request as merged once its merge has been pushed to the base branch
+func afterMerge(pr *models.PullRequest, doer *models.User) (err error) {
 	pr.MergedUnix = timeutil.TimeStampNow()
 	pr.Merger = doer
 	pr.MergerID = doer.ID
@@ -109,8 +215,10 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 	return nil
 }
 
-// rawMerge perform the merge operation without changing any pull information in database
-func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message string) (string, error) {
+// rawMerge perform the merge operation without changing any pull information in database.
+// If mergeQueueBase is given, the pull request is merged onto that commit instead of the head of
+// the base branch and the result is pushed to the merge queue ref of the pull request.
+func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message, mergeQueueBase string) (string, error) {
 	err := git.LoadGitVersion()
 	if err != nil {
 		log.Error("git.LoadGitVersion: %v", err)
@@ -135,6 +243,17 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 
 	var outbuf, errbuf strings.Builder
 
+	if len(mergeQueueBase) > 0 {
+		for _, branch := range []string{baseBranch, "original_" + baseBranch} {
+			if err := git.NewCommand("update-ref", git.BranchPrefix+branch, mergeQueueBase).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
+				log.Error("git update-ref %s %s: %v\n%s\n%s", branch, mergeQueueBase, err, outbuf.String(), errbuf.String())
+				return "", fmt.Errorf("Unable to reset %s to %s: %v\n%s\n%s", branch, mergeQueueBase, err, outbuf.String(), errbuf.String())
+			}
+			outbuf.Reset()
+			errbuf.Reset()
+		}
+	}
+
 	// Enable sparse-checkout
 	sparseCheckoutList, err := getDiffTree(tmpBasePath, baseBranch, trackingBranch)
 	if err != nil {
@@ -251,6 +370,12 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 			log.Error("Unable to make final commit: %v", err)
 			return "", err
 		}
+	case models.MergeStyleFastForwardOnly:
+		cmd := git.NewCommand("merge", "--ff-only", trackingBranch)
+		if err := runMergeCommand(pr, mergeStyle, cmd, tmpBasePath); err != nil {
+			log.Error("Unable to fast-forward base to tracking: %v", err)
+			return "", err
+		}
 	case models.MergeStyleRebase:
 		fallthrough
 	case models.MergeStyleRebaseMerge:
@@ -410,8 +535,15 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 		pr.ID,
 	)
 
+	pushCmd := git.NewCommand("push", "origin", baseBranch+":"+git.BranchPrefix+pr.BaseBranch)
+	if len(mergeQueueBase) > 0 {
+		// Use InternalPushingEnvironment here because we know that pre-receive and post-receive do not run on a refs/pulls/...
+		env = models.InternalPushingEnvironment(doer, pr.BaseRepo)
+		pushCmd = git.NewCommand("push", "-f", "origin", baseBranch+":"+pr.GetMergeQueueRefName())
+	}
+
 	// Push back to upstream.
-	if err := git.NewCommand("push", "origin", baseBranch+":refs/heads/"+pr.BaseBranch).RunInDirTimeoutEnvPipeline(env, -1, tmpBasePath, &outbuf, &errbuf); err != nil {
+	if err := pushCmd.RunInDirTimeoutEnvPipeline(env, -1, tmpBasePath, &outbuf, &errbuf); err != nil {
 		if strings.Contains(errbuf.String(), "non-fast-forward") {
 			return "", &git.ErrPushOutOfDate{
 				StdOut: outbuf.String(),
@@ -472,6 +604,13 @@ func runMergeCommand(pr *models.PullRequest, mergeStyle models.MergeStyle, cmd *
 				StdErr: errbuf.String(),
 				Err:    err,
 			}
+		} else if mergeStyle == models.MergeStyleFastForwardOnly && strings.Contains(errbuf.String(), "Not possible to fast-forward") {
+			log.Debug("MergeDivergingFastForwardOnly [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
+			return models.ErrMergeDivergingFastForwardOnly{
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
 		}
 		log.Error("git merge [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
 		return fmt.Errorf("git merge [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
@@ -603,6 +742,16 @@ func CheckPRReadyToMerge(pr *models.PullRequest, skipProtectedFilesCheck bool) (
 		}
 	}
 
+	blocked, err := MergeBlockedByCodeOwners(pr)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return models.ErrNotAllowedToMerge{
+			Reason: "Not all changed files have been approved by their code owners",
+		}
+	}
+
 	if skipProtectedFilesCheck {
 		return nil
 	}
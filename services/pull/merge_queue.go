@@ -0,0 +1,113 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// PrepareMergeQueueCommit merges a pull request onto the given base commit in a temporary repository and
+// pushes the result to the merge queue ref of the pull request, so that CI can report its commit statuses.
+// It returns the ID of the speculative merge commit.
+func PrepareMergeQueueCommit(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message, baseCommitID string) (string, error) {
+	if err := pr.LoadHeadRepo(); err != nil {
+		log.Error("LoadHeadRepo: %v", err)
+		return "", fmt.Errorf("LoadHeadRepo: %v", err)
+	} else if err := pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo: %v", err)
+		return "", fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+	return rawMerge(pr, doer, mergeStyle, message, baseCommitID)
+}
+
+// MergeQueued merges a pull request by fast-forwarding its base branch to the speculative merge
+// the merge queue has prepared for it.
+// Caller should check PR is ready to be merged (review and status checks of the speculative merge)
+func MergeQueued(pr *models.PullRequest, doer *models.User, commitID string) (err error) {
+	if err = pr.LoadHeadRepo(); err != nil {
+		log.Error("LoadHeadRepo: %v", err)
+		return fmt.Errorf("LoadHeadRepo: %v", err)
+	} else if err = pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo: %v", err)
+		return fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+
+	defer func() {
+		go AddTestPullRequestTask(doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "")
+	}()
+
+	headUser := doer
+	if err := pr.HeadRepo.GetOwner(); err != nil {
+		if !models.IsErrUserNotExist(err) {
+			log.Error("Can't find user: %d for head repository - %v", pr.HeadRepo.OwnerID, err)
+			return err
+		}
+	} else {
+		headUser = pr.HeadRepo.Owner
+	}
+
+	// A push which isn't a fast-forward is rejected, e.g. if the base branch has moved on meanwhile
+	if err := git.Push(pr.BaseRepo.RepoPath(), git.PushOptions{
+		Remote: pr.BaseRepo.RepoPath(),
+		Branch: commitID + ":" + git.BranchPrefix + pr.BaseBranch,
+		Env:    models.FullPushingEnvironment(headUser, doer, pr.BaseRepo, pr.BaseRepo.Name, pr.ID),
+	}); err != nil {
+		return err
+	}
+
+	pr.MergedCommitID = commitID
+	return afterMerge(pr, doer)
+}
+
+// GetMergeQueueCommitStatusState returns the state of the required status checks of the speculative merge of a queued pull request
+func GetMergeQueueCommitStatusState(pr *models.PullRequest, commitID string) (structs.CommitStatusState, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return "", fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return "", fmt.Errorf("LoadProtectedBranch: %v", err)
+	}
+
+	commitStatuses, err := models.GetLatestCommitStatus(pr.BaseRepo.ID, commitID, models.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("GetLatestCommitStatus: %v", err)
+	}
+
+	var requiredContexts []string
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableStatusCheck {
+		requiredContexts = pr.ProtectedBranch.StatusCheckContexts
+	}
+	return MergeRequiredContextsCommitStatus(commitStatuses, requiredContexts), nil
+}
+
+// CheckMergeQueueCommitReady checks whether commitID is the speculative merge of the queued pull request
+// and its status checks succeeded, so that it may be pushed to the base branch.
+func CheckMergeQueueCommitReady(pr *models.PullRequest, commitID string) error {
+	exist, entry, err := models.GetMergeQueueEntryByPullID(pr.ID)
+	if err != nil {
+		return fmt.Errorf("GetMergeQueueEntryByPullID: %v", err)
+	}
+	if !exist || entry.CommitID != commitID {
+		return models.ErrNotAllowedToMerge{
+			Reason: "Pull requests have to be merged through the merge queue",
+		}
+	}
+
+	state, err := GetMergeQueueCommitStatusState(pr, commitID)
+	if err != nil {
+		return err
+	}
+	if !state.IsSuccess() {
+		return models.ErrNotAllowedToMerge{
+			Reason: "Not all required status checks of the merge queue successful",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMergeMessageTemplate(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 2}).(*models.PullRequest)
+
+	message, err := RenderMergeMessageTemplate(pr, "${PullRequestTitle} (${PullRequestReference})\n\n${PullRequestDescription}\n\nMerge ${HeadBranch} into ${BaseBranch} ${Unknown} $PullRequestIndex\n")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "issue3 (#3)\n\ncontent for the third issue\n\nMerge branch2 into master ${Unknown} $PullRequestIndex", message)
+
+	message, err = RenderMergeMessageTemplate(pr, "${PullRequestIndex}: ${PullRequestURL}")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "3: https://try.gitea.io/user2/repo1/pulls/3", message)
+}
+
+func TestGetDefaultMergeMessage(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 2}).(*models.PullRequest)
+
+	message, err := GetDefaultMergeMessage(pr, models.MergeStyleSquash)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "issue3 (#3)", message)
+
+	message, err = GetDefaultMergeMessage(pr, models.MergeStyleFastForwardOnly)
+	assert.NoError(t, err)
+	assert.Empty(t, message)
+}
@@ -103,6 +103,10 @@ func NewPullRequest(repo *models.Repository, pull *models.Issue, labelIDs []int6
 		_, _ = models.CreateComment(ops)
 	}
 
+	if err := RequestCodeOwnersReview(pr, pull.Poster); err != nil {
+		log.Error("RequestCodeOwnersReview: %v", err)
+	}
+
 	return nil
 }
 
@@ -291,6 +295,10 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 			if err == nil && comment != nil {
 				notification.NotifyPullRequestPushCommits(doer, pr, comment)
 			}
+
+			if err := RequestCodeOwnersReview(pr, doer); err != nil {
+				log.Error("RequestCodeOwnersReview: %v", err)
+			}
 		}
 
 		log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
@@ -523,58 +531,82 @@ func GetSquashMergeCommitMessages(pr *models.PullRequest) string {
 		return ""
 	}
 
-	if err := pr.Issue.LoadPoster(); err != nil {
-		log.Error("Cannot load poster %d for pr id %d, index %d Error: %v", pr.Issue.PosterID, pr.ID, pr.Index, err)
+	authors, err := getCoAuthors(pr)
+	if err != nil {
+		log.Error("Unable to get the co-authors of PR id %d: Error: %v", pr.ID, err)
 		return ""
 	}
 
-	if pr.HeadRepo == nil {
-		var err error
-		pr.HeadRepo, err = models.GetRepositoryByID(pr.HeadRepoID)
-		if err != nil {
-			log.Error("GetRepositoryById[%d]: %v", pr.HeadRepoID, err)
-			return ""
-		}
+	stringBuilder := strings.Builder{}
+
+	stringBuilder.WriteString(pr.Issue.Content)
+	if stringBuilder.Len() > 0 {
+		stringBuilder.WriteRune('\n')
+		stringBuilder.WriteRune('\n')
+	}
+
+	if len(authors) > 0 {
+		stringBuilder.WriteRune('\n')
+	}
+	stringBuilder.WriteString(coAuthoredByLines(authors))
+
+	return stringBuilder.String()
+}
+
+// coAuthoredByLines returns a Co-authored-by trailer line for each of authors
+func coAuthoredByLines(authors []string) string {
+	stringBuilder := strings.Builder{}
+	for _, author := range authors {
+		stringBuilder.WriteString("Co-authored-by: ")
+		stringBuilder.WriteString(author)
+		stringBuilder.WriteRune('\n')
+	}
+	return stringBuilder.String()
+}
+
+// getCoAuthors returns the authors of the commits between head and merge base other than the poster of pr
+func getCoAuthors(pr *models.PullRequest) ([]string, error) {
+	if err := pr.LoadIssue(); err != nil {
+		return nil, err
+	}
+
+	if err := pr.Issue.LoadPoster(); err != nil {
+		return nil, err
+	}
+
+	if err := pr.LoadHeadRepo(); err != nil {
+		return nil, err
+	} else if pr.HeadRepo == nil {
+		return nil, models.ErrRepoNotExist{ID: pr.HeadRepoID}
 	}
 
 	gitRepo, err := git.OpenRepository(pr.HeadRepo.RepoPath())
 	if err != nil {
-		log.Error("Unable to open head repository: Error: %v", err)
-		return ""
+		return nil, fmt.Errorf("Unable to open head repository: %v", err)
 	}
 	defer gitRepo.Close()
 
 	headCommit, err := gitRepo.GetBranchCommit(pr.HeadBranch)
 	if err != nil {
-		log.Error("Unable to get head commit: %s Error: %v", pr.HeadBranch, err)
-		return ""
+		return nil, fmt.Errorf("Unable to get head commit: %s Error: %v", pr.HeadBranch, err)
 	}
 
 	mergeBase, err := gitRepo.GetCommit(pr.MergeBase)
 	if err != nil {
-		log.Error("Unable to get merge base commit: %s Error: %v", pr.MergeBase, err)
-		return ""
+		return nil, fmt.Errorf("Unable to get merge base commit: %s Error: %v", pr.MergeBase, err)
 	}
 
 	limit := setting.Repository.PullRequest.DefaultMergeMessageCommitsLimit
 
 	list, err := gitRepo.CommitsBetweenLimit(headCommit, mergeBase, limit, 0)
 	if err != nil {
-		log.Error("Unable to get commits between: %s %s Error: %v", pr.HeadBranch, pr.MergeBase, err)
-		return ""
+		return nil, fmt.Errorf("Unable to get commits between: %s %s Error: %v", pr.HeadBranch, pr.MergeBase, err)
 	}
 
 	posterSig := pr.Issue.Poster.NewGitSig().String()
 
 	authorsMap := map[string]bool{}
 	authors := make([]string, 0, list.Len())
-	stringBuilder := strings.Builder{}
-
-	stringBuilder.WriteString(pr.Issue.Content)
-	if stringBuilder.Len() > 0 {
-		stringBuilder.WriteRune('\n')
-		stringBuilder.WriteRune('\n')
-	}
 
 	// commits list is in reverse chronological order
 	element := list.Back()
@@ -595,9 +627,7 @@ func GetSquashMergeCommitMessages(pr *models.PullRequest) string {
 		for {
 			list, err := gitRepo.CommitsBetweenLimit(headCommit, mergeBase, limit, skip)
 			if err != nil {
-				log.Error("Unable to get commits between: %s %s Error: %v", pr.HeadBranch, pr.MergeBase, err)
-				return ""
-
+				return nil, fmt.Errorf("Unable to get commits between: %s %s Error: %v", pr.HeadBranch, pr.MergeBase, err)
 			}
 			if list.Len() == 0 {
 				break
@@ -617,29 +647,7 @@ func GetSquashMergeCommitMessages(pr *models.PullRequest) string {
 		}
 	}
 
-	if len(authors) > 0 {
-		if _, err := stringBuilder.WriteRune('\n'); err != nil {
-			log.Error("Unable to write to string builder Error: %v", err)
-			return ""
-		}
-	}
-
-	for _, author := range authors {
-		if _, err := stringBuilder.Write([]byte("Co-authored-by: ")); err != nil {
-			log.Error("Unable to write to string builder Error: %v", err)
-			return ""
-		}
-		if _, err := stringBuilder.Write([]byte(author)); err != nil {
-			log.Error("Unable to write to string builder Error: %v", err)
-			return ""
-		}
-		if _, err := stringBuilder.WriteRune('\n'); err != nil {
-			log.Error("Unable to write to string builder Error: %v", err)
-			return ""
-		}
-	}
-
-	return stringBuilder.String()
+	return authors, nil
 }
 
 // GetIssuesLastCommitStatus returns a map
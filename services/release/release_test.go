@@ -267,13 +267,13 @@ func TestRelease_createTag(t *testing.T) {
 		IsPrerelease: false,
 		IsTag:        false,
 	}
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, release.CreatedUnix)
 	releaseCreatedUnix := release.CreatedUnix
 	time.Sleep(2 * time.Second) // sleep 2 seconds to ensure a different timestamp
 	release.Note = "Changed note"
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(releaseCreatedUnix), int64(release.CreatedUnix))
 
@@ -289,12 +289,12 @@ func TestRelease_createTag(t *testing.T) {
 		IsPrerelease: false,
 		IsTag:        false,
 	}
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	releaseCreatedUnix = release.CreatedUnix
 	time.Sleep(2 * time.Second) // sleep 2 seconds to ensure a different timestamp
 	release.Title = "Changed title"
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	assert.Less(t, int64(releaseCreatedUnix), int64(release.CreatedUnix))
 
@@ -310,13 +310,13 @@ func TestRelease_createTag(t *testing.T) {
 		IsPrerelease: true,
 		IsTag:        false,
 	}
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	releaseCreatedUnix = release.CreatedUnix
 	time.Sleep(2 * time.Second) // sleep 2 seconds to ensure a different timestamp
 	release.Title = "Changed title"
 	release.Note = "Changed note"
-	_, err = createTag(gitRepo, release, "")
+	_, err = createTag(gitRepo, release, user.ID, "")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(releaseCreatedUnix), int64(release.CreatedUnix))
 }
@@ -329,3 +329,24 @@ func TestCreateNewTag(t *testing.T) {
 	assert.NoError(t, CreateNewTag(user, repo, "master", "v2.0",
 		"v2.0 is released \n\n BUGFIX: .... \n\n 123"))
 }
+
+func TestCreateNewTag_Protected(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	assert.NoError(t, models.InsertProtectedTag(&models.ProtectedTag{
+		RepoID:           repo.ID,
+		NamePattern:      "v3.*",
+		AllowlistUserIDs: []int64{1},
+	}))
+
+	err := CreateNewTag(user, repo, "master", "v3.0", "")
+	assert.True(t, models.IsErrProtectedTagName(err))
+
+	err = CreateNewTag(user, repo, "master", "--v3.1", "")
+	assert.True(t, models.IsErrProtectedTagName(err))
+
+	admin := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	assert.NoError(t, CreateNewTag(admin, repo, "master", "v3.0", ""))
+}
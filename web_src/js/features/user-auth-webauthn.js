@@ -0,0 +1,197 @@
+const {AppSubUrl, csrf} = window.config;
+
+export function initUserAuthWebAuthn() {
+  if ($('.user.signin.webauthn-prompt').length === 0) {
+    return;
+  }
+
+  if (!detectWebAuthnSupport()) {
+    return;
+  }
+
+  $.getJSON(`${AppSubUrl}/user/webauthn/assertion`, {})
+    .done((makeAssertionOptions) => {
+      verifyAssertion(makeAssertionOptions, `${AppSubUrl}/user/webauthn/assertion`)
+        .catch((err) => {
+          webAuthnError(errorType(err));
+        });
+    })
+    .fail(() => {
+      webAuthnError('unknown');
+    });
+}
+
+export function initUserAuthPasswordless() {
+  $('#signin-passwordless').on('click', (e) => {
+    e.preventDefault();
+    if (!detectWebAuthnSupport()) {
+      return;
+    }
+
+    $.getJSON(`${AppSubUrl}/user/webauthn/passwordless/assertion`, {})
+      .done((makeAssertionOptions) => {
+        verifyAssertion(makeAssertionOptions, `${AppSubUrl}/user/webauthn/passwordless/assertion`)
+          .catch((err) => {
+            webAuthnError(errorType(err));
+          });
+      })
+      .fail(() => {
+        webAuthnError('unknown');
+      });
+  });
+}
+
+async function verifyAssertion(makeAssertionOptions, url) {
+  makeAssertionOptions.publicKey.challenge = decodeURLEncodedBase64(makeAssertionOptions.publicKey.challenge);
+  for (const allowCredential of makeAssertionOptions.publicKey.allowCredentials || []) {
+    allowCredential.id = decodeURLEncodedBase64(allowCredential.id);
+  }
+
+  const credential = await navigator.credentials.get({
+    publicKey: makeAssertionOptions.publicKey,
+  });
+
+  const {authenticatorData, clientDataJSON, signature, userHandle} = credential.response;
+  const redirect = await $.ajax({
+    url,
+    type: 'POST',
+    headers: {'X-Csrf-Token': csrf},
+    data: JSON.stringify({
+      id: credential.id,
+      rawId: encodeURLEncodedBase64(new Uint8Array(credential.rawId)),
+      type: credential.type,
+      response: {
+        authenticatorData: encodeURLEncodedBase64(new Uint8Array(authenticatorData)),
+        clientDataJSON: encodeURLEncodedBase64(new Uint8Array(clientDataJSON)),
+        signature: encodeURLEncodedBase64(new Uint8Array(signature)),
+        userHandle: userHandle ? encodeURLEncodedBase64(new Uint8Array(userHandle)) : null,
+      },
+    }),
+    contentType: 'application/json; charset=utf-8',
+  });
+  window.location.href = redirect;
+}
+
+// Encode an ArrayBuffer into a URLEncoded base64 string.
+function encodeURLEncodedBase64(value) {
+  return window.btoa(String.fromCharCode(...value))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=/g, '');
+}
+
+// Decode a URLEncoded base64 string into an Uint8Array.
+function decodeURLEncodedBase64(value) {
+  return Uint8Array.from(window.atob(value.replace(/_/g, '/').replace(/-/g, '+')), (c) => c.charCodeAt(0));
+}
+
+function webauthnRegistered(newCredential) {
+  const attestationObject = new Uint8Array(newCredential.response.attestationObject);
+  const clientDataJSON = new Uint8Array(newCredential.response.clientDataJSON);
+  const rawId = new Uint8Array(newCredential.rawId);
+
+  return $.ajax({
+    url: `${AppSubUrl}/user/settings/security/webauthn/register`,
+    type: 'POST',
+    headers: {'X-Csrf-Token': csrf},
+    data: JSON.stringify({
+      id: newCredential.id,
+      rawId: encodeURLEncodedBase64(rawId),
+      type: newCredential.type,
+      response: {
+        attestationObject: encodeURLEncodedBase64(attestationObject),
+        clientDataJSON: encodeURLEncodedBase64(clientDataJSON),
+      },
+    }),
+    dataType: 'text',
+    contentType: 'application/json; charset=utf-8',
+  }).then(() => {
+    window.location.reload();
+  }).fail(() => {
+    webAuthnError('unknown');
+  });
+}
+
+function errorType(err) {
+  if (err && err.name === 'InvalidStateError') {
+    return 'duplicated';
+  }
+  if (err && err.name === 'SecurityError') {
+    return 'insecure';
+  }
+  if (err && err.name === 'NotAllowedError') {
+    return 'timeout';
+  }
+  return 'unknown';
+}
+
+function webAuthnError(type) {
+  $('#webauthn-error [data-webauthn-error-msg]').addClass('hide');
+  $(`#webauthn-error [data-webauthn-error-msg=${type}]`).removeClass('hide');
+  $('#webauthn-error .webauthn_error_timeout').toggleClass('hide', type !== 'timeout');
+  $('#webauthn-error').modal('show');
+}
+
+function detectWebAuthnSupport() {
+  if (!window.isSecureContext) {
+    webAuthnError('insecure');
+    return false;
+  }
+
+  if (typeof window.PublicKeyCredential !== 'function') {
+    webAuthnError('browser');
+    return false;
+  }
+
+  return true;
+}
+
+export function initUserAuthWebAuthnRegister() {
+  if ($('#register-webauthn').length === 0) {
+    return;
+  }
+
+  $('#register-device').modal({allowMultiple: false});
+  $('#webauthn-error').modal({allowMultiple: false});
+  $('#register-webauthn').on('click', (e) => {
+    e.preventDefault();
+    if (!detectWebAuthnSupport()) {
+      return;
+    }
+    webAuthnRegisterRequest();
+  });
+}
+
+function webAuthnRegisterRequest() {
+  if ($('#nickname').val() === '') {
+    webAuthnError('empty');
+    return;
+  }
+  $.post(`${AppSubUrl}/user/settings/security/webauthn/request_register`, {
+    _csrf: csrf,
+    name: $('#nickname').val(),
+    passwordless: $('#webauthn-passwordless').is(':checked'),
+  }).done((makeCredentialOptions) => {
+    $('#nickname').closest('div.field').removeClass('error');
+    $('#register-device').modal('show');
+
+    makeCredentialOptions.publicKey.challenge = decodeURLEncodedBase64(makeCredentialOptions.publicKey.challenge);
+    makeCredentialOptions.publicKey.user.id = decodeURLEncodedBase64(makeCredentialOptions.publicKey.user.id);
+    for (const excludeCredential of makeCredentialOptions.publicKey.excludeCredentials || []) {
+      excludeCredential.id = decodeURLEncodedBase64(excludeCredential.id);
+    }
+
+    navigator.credentials.create({
+      publicKey: makeCredentialOptions.publicKey
+    }).then(webauthnRegistered)
+      .catch((err) => {
+        webAuthnError(errorType(err));
+      });
+  }).fail((xhr) => {
+    if (xhr.status === 409) {
+      $('#nickname').closest('div.field').addClass('error');
+    } else {
+      webAuthnError('unable-to-process');
+    }
+  });
+}